@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"path"
+
+	"github.com/ledgerwatch/erigon/cmd/utils"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/spf13/cobra"
+)
+
+var snapshotsDir string
+var dumpFrom, dumpTo uint64
+
+var cmdDumpBlocks = &cobra.Command{
+	Use:   "dump_blocks",
+	Short: "freeze canonical blocks [--from, --to) of '--chaindata' into segment files of '--snapshots.dir', read by rpcdaemon --snapshots.dir",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, _ := utils.RootContext()
+		logger := log.New()
+		db := openDB(chaindata, logger, false)
+		defer db.Close()
+		if snapshotsDir == "" {
+			snapshotsDir = path.Join(datadir, "snapshots")
+		}
+		tx, err := db.BeginRo(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		for from := dumpFrom; from < dumpTo; from += snapshotsync.DefaultSegmentSize {
+			to := from + snapshotsync.DefaultSegmentSize
+			if to > dumpTo {
+				to = dumpTo
+			}
+			if err := snapshotsync.DumpBlocks(ctx, tx, from, to, snapshotsDir, path.Join(datadir, "tmp")); err != nil {
+				log.Error(err.Error())
+				return err
+			}
+			log.Info("Dumped blocks", "from", from, "to", to)
+		}
+		return nil
+	},
+}
+
+func init() {
+	withDatadir(cmdDumpBlocks)
+	cmdDumpBlocks.Flags().StringVar(&snapshotsDir, "snapshots.dir", "", "directory of segment files, default: <datadir>/snapshots")
+	cmdDumpBlocks.Flags().Uint64Var(&dumpFrom, "from", 0, "first block, multiple of 1000")
+	cmdDumpBlocks.Flags().Uint64Var(&dumpTo, "to", 0, "block after the last one, multiple of 1000")
+
+	rootCmd.AddCommand(cmdDumpBlocks)
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/health"
+	"github.com/ledgerwatch/erigon/node"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+var chainNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// validateChains - names of --chains are path prefixes, they must not hide paths of healthcheck
+func validateChains(chains map[string]string) error {
+	for name, addr := range chains {
+		if !chainNameRe.MatchString(name) || name == "health" || name == "ready" {
+			return fmt.Errorf("invalid name of chain %q in --chains: letters, digits, '-' and '_' are allowed", name)
+		}
+		if addr == "" {
+			return fmt.Errorf("chain %s in --chains has no private api address", name)
+		}
+	}
+	return nil
+}
+
+// ChainFlags - flags of additional chain of --chains: its Erigon is remote, local db and snapshots are of main chain
+func (cfg Flags) ChainFlags(name string) Flags {
+	cfg.PrivateApiAddr, cfg.SingleNodeMode = cfg.Chains[name], false
+	cfg.Datadir, cfg.Chaindata, cfg.SnapshotsDir, cfg.ReplicaRefresh = "", "", "", 0
+	cfg.Chains = nil
+	return cfg
+}
+
+// ChainAPI - API of additional chain, served on /<Name> path of HTTP endpoint, see --chains
+type ChainAPI struct {
+	Name string
+	API  []rpc.API
+}
+
+// rpcServerLimits - limits and access control of RPC servers, shared by servers of all chains: clients have same
+// rate limits and API keys on all of them
+type rpcServerLimits struct {
+	allowList     rpc.AllowList
+	rateLimiter   rpc.RateLimiter
+	auditLog      rpc.AuditLog
+	authenticator rpc.Authenticator
+	usage         rpc.UsageMeter
+	recorder      rpc.RequestRecorder
+}
+
+// chainEndpoint - RPC server of one chain with its HTTP (including healthcheck) and websocket handlers
+type chainEndpoint struct {
+	srv      *rpc.Server
+	rpcAPI   []rpc.API
+	http, ws http.Handler
+}
+
+func newChainEndpoint(cfg Flags, limits rpcServerLimits, rpcAPI []rpc.API) (*chainEndpoint, error) {
+	srv := rpc.NewServer(cfg.RpcBatchConcurrency)
+	srv.SetAllowList(limits.allowList)
+	if limits.rateLimiter != nil {
+		srv.SetRateLimiter(limits.rateLimiter)
+	}
+	srv.SetBatchLimits(rpc.BatchLimits{MaxItems: cfg.RpcBatchLimit, MaxResponseSize: cfg.RpcBatchResponseLimit})
+	if len(cfg.NamespaceConcurrency) > 0 {
+		srv.SetNamespaceConcurrency(cfg.NamespaceConcurrency)
+	}
+	srv.SetSlowCallThreshold(cfg.SlowCallThreshold)
+	if cfg.GethCompatibility {
+		srv.SetResponseFormatter(rpchelper.GethCompatFormatter{})
+	}
+	if limits.auditLog != nil {
+		srv.SetAuditLog(limits.auditLog)
+	}
+	if limits.authenticator != nil {
+		srv.SetAuthenticator(limits.authenticator)
+	}
+	if limits.usage != nil {
+		srv.SetUsageMeter(limits.usage)
+	}
+	if limits.recorder != nil {
+		srv.SetRequestRecorder(limits.recorder)
+	}
+	if err := node.RegisterApisFromWhitelist(rpcAPI, cfg.API, srv, false); err != nil {
+		return nil, fmt.Errorf("could not start register RPC apis: %w", err)
+	}
+	e := &chainEndpoint{srv: srv, rpcAPI: rpcAPI, http: node.NewHTTPHandlerStack(srv, cfg.HttpCORSDomain, cfg.HttpVirtualHost, cfg.httpGzip())}
+	if cfg.WebsocketEnabled {
+		srv.SetWebsocketLimits(cfg.WebsocketLimits)
+		srv.SetWebsocketCompression(cfg.WebsocketDeflate)
+		e.ws = srv.WebsocketHandler([]string{"*"}, cfg.WebsocketCompression)
+	}
+	return e, nil
+}
+
+func (e *chainEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// adding a healthcheck here
+	if health.ProcessHealthcheckIfNeeded(w, r, e.rpcAPI) {
+		return
+	}
+	if e.ws != nil && websocket.IsWebSocketUpgrade(r) {
+		e.ws.ServeHTTP(w, r)
+		return
+	}
+	e.http.ServeHTTP(w, r)
+}
+
+// chainsHandler - requests with path /<name of chain>[/...] go to endpoint of the chain (the path without prefix is
+// served as / of it: /mainnet/health is healthcheck of mainnet), other requests go to `main`
+func chainsHandler(main *chainEndpoint, chains map[string]http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+		if chain, ok := chains[name]; ok {
+			chain.ServeHTTP(w, r)
+			return
+		}
+		main.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+type chainNameService struct{ name string }
+
+func (s *chainNameService) Name() string { return s.name }
+
+func TestChainsHandler(t *testing.T) {
+	cfg := Flags{API: []string{"test"}, HttpVirtualHost: []string{"*"}}
+	limits := rpcServerLimits{rateLimiter: rpc.NewRateLimiter(rpc.RateLimits{Default: &rpc.RateLimit{Rate: 0.001, Burst: 4}})}
+	endpoint := func(name string) *chainEndpoint {
+		e, err := newChainEndpoint(cfg, limits, []rpc.API{{Namespace: "test", Public: true, Service: &chainNameService{name}, Version: "1.0"}})
+		require.NoError(t, err)
+		t.Cleanup(e.srv.Stop)
+		return e
+	}
+	server := httptest.NewServer(chainsHandler(endpoint("mainnet"), map[string]http.Handler{"sepolia": http.StripPrefix("/sepolia", endpoint("sepolia"))}))
+	defer server.Close()
+
+	call := func(path string) (string, error) {
+		client, err := rpc.DialHTTP(server.URL + path)
+		require.NoError(t, err)
+		defer client.Close()
+		var name string
+		err = client.Call(&name, "test_name")
+		return name, err
+	}
+	for path, expected := range map[string]string{"/": "mainnet", "/sepolia": "sepolia", "/sepolia/": "sepolia", "/sepoliax": "mainnet"} {
+		name, err := call(path)
+		require.NoError(t, err, path)
+		require.Equal(t, expected, name, path)
+	}
+	// rate limits are shared by chains
+	_, err := call("/sepolia")
+	require.Error(t, err)
+	_, err = call("/")
+	require.Error(t, err)
+
+	require.NoError(t, validateChains(map[string]string{"sepolia": "10.0.0.2:9090", "gnosis-chain_1": "10.0.0.3:9090"}))
+	require.Error(t, validateChains(map[string]string{"health": "10.0.0.2:9090"}))
+	require.Error(t, validateChains(map[string]string{"a/b": "10.0.0.2:9090"}))
+	require.Error(t, validateChains(map[string]string{"sepolia": ""}))
+}
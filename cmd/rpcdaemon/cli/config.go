@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/binary"
 	"errors"
@@ -8,10 +9,12 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"os"
 	"path"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/grpcutil"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
@@ -20,11 +23,14 @@ import (
 	kv2 "github.com/ledgerwatch/erigon-lib/kv/mdbx"
 	"github.com/ledgerwatch/erigon-lib/kv/remotedb"
 	"github.com/ledgerwatch/erigon-lib/kv/remotedbserver"
-	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/health"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/erigon/common/paths"
+	"github.com/ledgerwatch/erigon/eth/gasprice"
 	"github.com/ledgerwatch/erigon/internal/debug"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/internal/tracing"
 	"github.com/ledgerwatch/erigon/node"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/log/v3"
@@ -38,9 +44,13 @@ import (
 
 type Flags struct {
 	PrivateApiAddr         string
+	Chains                 map[string]string
+	KvPrefetch             int
 	SingleNodeMode         bool // Erigon's database can be read by separated processes on same machine - in read-only mode - with full support of transactions. It will share same "OS PageCache" with Erigon process.
 	Datadir                string
+	SnapshotsDir           string
 	Chaindata              string
+	ReplicaRefresh         time.Duration
 	HttpListenAddress      string
 	TLSCertfile            string
 	TLSCACert              string
@@ -49,24 +59,99 @@ type Flags struct {
 	HttpCORSDomain         []string
 	HttpVirtualHost        []string
 	HttpCompression        bool
+	HttpCompressionLevel   int
+	HttpCompressionMinSize int
+	HttpTLSCertFile        string
+	HttpTLSKeyFile         string
+	HttpTLSClientCAFile    string
+	HttpListenersFilePath  string
 	API                    []string
 	Gascap                 uint64
+	EVMTimeout             time.Duration
+	ReturnDataLimit        int
+	FeeHistoryMaxBlocks    int
+	GpoStrategy            string
+	GpoBlocks              int
+	GpoPercentile          int
+	GpoMinPrice            int64
+	GpoMaxPrice            int64
+	ProofMaxDepth          uint64
+	ProofTimeout           time.Duration
+	LogsMaxRange           uint64
+	LogsMaxResults         int
+	LogsTimeout            time.Duration
 	MaxTraces              uint64
 	WebsocketEnabled       bool
 	WebsocketCompression   bool
+	WebsocketDeflate       rpc.WebsocketCompression
+	WebsocketLimits        rpc.WebsocketLimits
+	SocketPath             string
+	SocketPerm             string
 	RpcAllowListFilePath   string
+	RpcRateLimitFilePath   string
+	RpcRateLimitRedisAddr  string
+	RpcAPIKeysFilePath     string
+	ResponseCacheSize      int
+	ResponseCacheDepth     uint64
+	FilterTTL              time.Duration
+	FiltersPerClient       int
+	FiltersRedisAddr       string
+	TracingEndpoint        string
+	TracingSampleRatio     float64
+	HealthMaxSyncLag       uint64
+	HealthMinPeers         uint64
 	RpcBatchConcurrency    uint
+	RpcBatchLimit          int
+	RpcBatchResponseLimit  int
+	NamespaceConcurrency   map[string]int
+	ShutdownTimeout        time.Duration
+	SlowCallThreshold      time.Duration
+	AuditLogPath           string
+	RecordFilePath         string
+	RecordKVReads          bool
+	UsageEnabled           bool
+	UsageCostsFilePath     string
+	UsageFilePath          string
+	UsageRetention         time.Duration
 	TraceCompatibility     bool // Bug for bug compatibility for trace_ routines with OpenEthereum
+	GethCompatibility      bool
+	RevertABIPath          string
+	RevertDecoder          *ethapi.RevertDecoder // of RevertABIPath, nil if it's not set
 	TxPoolV2               bool
 	TxPoolApiAddr          string
+	SentryApiAddr          string
 	TevmEnabled            bool
 	StateCache             kvcache.CoherentConfig
 	GRPCServerEnabled      bool
 	GRPCListenAddress      string
 	GRPCPort               int
 	GRPCHealthCheckEnabled bool
+	KeepaliveInterval      time.Duration
+	KeepaliveTimeout       time.Duration
+	GRPCMaxMsgSize         int
+	GRPCCallTimeout        time.Duration
+	GRPCPoolSize           int
+	RetryAttempts          int
+	RetryBackoff           time.Duration
+	LogsBufferSize         int
+	LogsDropOldest         bool
+	SubscriberLogsBuffer   int
+	DisconnectSlowLogs     bool
+	TotalSupply            bool
+	PrivateApiRoundRobin   bool
+	AuthRpcEnabled         bool
+	AuthRpcListenAddress   string
+	AuthRpcPort            int
+	JWTSecretPath          string
+	JWTWindow              time.Duration
+	GraphQLEnabled         bool
+	GraphQLListenAddress   string
+	GraphQLPort            int
 }
 
+// stopTracing - flushes spans on exit, nil if tracing is not enabled
+var stopTracing func(context.Context) error
+
 var rootCmd = &cobra.Command{
 	Use:   "rpcdaemon",
 	Short: "rpcdaemon is JSON RPC server that connects to Erigon node for remote DB access",
@@ -76,8 +161,10 @@ func RootCommand() (*cobra.Command, *Flags) {
 	utils.CobraFlags(rootCmd, append(debug.Flags, utils.MetricFlags...))
 
 	cfg := &Flags{StateCache: kvcache.DefaultCoherentConfig}
-	rootCmd.PersistentFlags().StringVar(&cfg.PrivateApiAddr, "private.api.addr", "127.0.0.1:9090", "private api network address, for example: 127.0.0.1:9090")
+	rootCmd.PersistentFlags().StringVar(&cfg.PrivateApiAddr, "private.api.addr", "127.0.0.1:9090", "private api network address, for example: 127.0.0.1:9090. Comma-separated list of addresses of nodes serving same chain enables failover between them")
+	rootCmd.PersistentFlags().BoolVar(&cfg.PrivateApiRoundRobin, "private.api.round_robin", false, "Spread read-only calls over all nodes of --private.api.addr instead of using first healthy one")
 	rootCmd.PersistentFlags().StringVar(&cfg.Datadir, "datadir", "", "path to Erigon working directory")
+	rootCmd.PersistentFlags().StringVar(&cfg.SnapshotsDir, "snapshots.dir", "", "Read blocks frozen in segment files of this directory from the files instead of db. Empty - disabled")
 	rootCmd.PersistentFlags().StringVar(&cfg.Chaindata, "chaindata", "", "path to the database")
 	rootCmd.PersistentFlags().StringVar(&cfg.HttpListenAddress, "http.addr", node.DefaultHTTPHost, "HTTP-RPC server listening interface")
 	rootCmd.PersistentFlags().StringVar(&cfg.TLSCertfile, "tls.cert", "", "certificate for client side TLS handshake")
@@ -87,28 +174,121 @@ func RootCommand() (*cobra.Command, *Flags) {
 	rootCmd.PersistentFlags().StringSliceVar(&cfg.HttpCORSDomain, "http.corsdomain", []string{}, "Comma separated list of domains from which to accept cross origin requests (browser enforced)")
 	rootCmd.PersistentFlags().StringSliceVar(&cfg.HttpVirtualHost, "http.vhosts", node.DefaultConfig.HTTPVirtualHosts, "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.")
 	rootCmd.PersistentFlags().BoolVar(&cfg.HttpCompression, "http.compression", true, "Disable http compression")
-	rootCmd.PersistentFlags().StringSliceVar(&cfg.API, "http.api", []string{"eth", "erigon"}, "API's offered over the HTTP-RPC interface: eth,erigon,web3,net,debug,trace,txpool,db. Supported methods: https://github.com/ledgerwatch/erigon/tree/devel/cmd/rpcdaemon")
+	rootCmd.PersistentFlags().IntVar(&cfg.HttpCompressionLevel, "http.compression.level", gzip.DefaultCompression, "Gzip level of http responses: 1 (best speed) to 9 (best compression), -1 - default (6)")
+	rootCmd.PersistentFlags().IntVar(&cfg.HttpCompressionMinSize, "http.compression.minsize", 1024, "Http responses smaller than this amount of bytes are not compressed")
+	rootCmd.PersistentFlags().StringVar(&cfg.HttpTLSCertFile, "http.tls.cert", "", "Serve HTTP and websocket endpoint over TLS with this certificate, reloaded when the file changes")
+	rootCmd.PersistentFlags().StringVar(&cfg.HttpTLSKeyFile, "http.tls.key", "", "Key of --http.tls.cert")
+	rootCmd.PersistentFlags().StringVar(&cfg.HttpTLSClientCAFile, "http.tls.clientca", "", "Require clients of HTTP and websocket endpoint to present certificate signed by CA from this file (mutual TLS)")
+	rootCmd.PersistentFlags().StringVar(&cfg.HttpListenersFilePath, "http.listeners", "", "TOML file with [[listener]] entries (addr, api, corsdomain, vhosts, ws) served instead of --http.addr:--http.port endpoint, each with its own namespaces. Fields which are not set are taken from --http.api, --http.corsdomain, --http.vhosts and --ws")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.API, "http.api", []string{"eth", "erigon"}, "API's offered over the HTTP-RPC interface: eth,erigon,web3,net,debug,trace,txpool,db,ots,bor. Supported methods: https://github.com/ledgerwatch/erigon/tree/devel/cmd/rpcdaemon")
 	rootCmd.PersistentFlags().Uint64Var(&cfg.Gascap, "rpc.gascap", 50000000, "Sets a cap on gas that can be used in eth_call/estimateGas")
+	rootCmd.PersistentFlags().DurationVar(&cfg.EVMTimeout, "rpc.evmtimeout", 5*time.Minute, "Sets a limit on time of EVM execution of eth_call/estimateGas. 0 - no limit")
+	rootCmd.PersistentFlags().IntVar(&cfg.ReturnDataLimit, "rpc.returndata.limit", 0, "eth_call returning more bytes fails with error. 0 - no limit")
+	rootCmd.PersistentFlags().IntVar(&cfg.FeeHistoryMaxBlocks, "rpc.feehistory.maxblocks", gasprice.DefaultMaxFeeHistory, "Sets a limit on amount of blocks eth_feeHistory returns in one request")
+	rootCmd.PersistentFlags().StringVar(&cfg.GpoStrategy, "gpo.strategy", gasprice.StrategyGeth, "How eth_gasPrice and eth_maxPriorityFeePerGas sample tips of recent blocks: geth - 3 lowest tips of each block, eip1559 - all tips of --gpo.blocks blocks, gas price includes base fee of the next block")
+	rootCmd.PersistentFlags().IntVar(&cfg.GpoBlocks, "gpo.blocks", 20, "Number of recent blocks to check for gas prices")
+	rootCmd.PersistentFlags().IntVar(&cfg.GpoPercentile, "gpo.percentile", 60, "Suggested gas price is the given percentile of a set of recent transaction gas prices")
+	rootCmd.PersistentFlags().Int64Var(&cfg.GpoMinPrice, "gpo.minprice", 0, "Minimum priority fee (wei) will be recommended by gpo. Equal to --gpo.maxprice - always recommend it")
+	rootCmd.PersistentFlags().Int64Var(&cfg.GpoMaxPrice, "gpo.maxprice", gasprice.DefaultMaxPrice.Int64(), "Maximum priority fee (wei) will be recommended by gpo")
+	rootCmd.PersistentFlags().Uint64Var(&cfg.ProofMaxDepth, "rpc.getproof.maxdepth", 1024, "eth_getProof regenerates state trie of blocks below the head, this limits how many blocks below the head it can be done for. 0 - no limit")
+	rootCmd.PersistentFlags().DurationVar(&cfg.ProofTimeout, "rpc.getproof.timeout", 30*time.Second, "Sets a limit on time of eth_getProof regenerating state trie of one block. 0 - no limit")
+	rootCmd.PersistentFlags().Uint64Var(&cfg.LogsMaxRange, "rpc.logs.maxrange", 0, "Sets a limit on amount of blocks eth_getLogs can query in one request. 0 - no limit")
+	rootCmd.PersistentFlags().IntVar(&cfg.LogsMaxResults, "rpc.logs.maxresults", 0, "eth_getLogs returning more logs fails with error suggesting smaller block range. 0 - no limit")
+	rootCmd.PersistentFlags().DurationVar(&cfg.LogsTimeout, "rpc.logs.timeout", 0, "eth_getLogs processing blocks for longer fails with error suggesting smaller block range. 0 - no limit")
 	rootCmd.PersistentFlags().Uint64Var(&cfg.MaxTraces, "trace.maxtraces", 200, "Sets a limit on traces that can be returned in trace_filter")
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketEnabled, "ws", false, "Enable Websockets")
 	rootCmd.PersistentFlags().BoolVar(&cfg.WebsocketCompression, "ws.compression", false, "Enable Websocket compression (RFC 7692)")
+	rootCmd.PersistentFlags().IntVar(&cfg.WebsocketDeflate.Level, "ws.compression.level", 1, "Deflate level of websocket messages: 1 (best speed) to 9 (best compression), -1 - default (6)")
+	rootCmd.PersistentFlags().IntVar(&cfg.WebsocketDeflate.MinSize, "ws.compression.minsize", 1024, "Websocket messages smaller than this amount of bytes are not compressed")
+	rootCmd.PersistentFlags().IntVar(&cfg.WebsocketLimits.MaxConnections, "ws.max.connections", 0, "Maximum of open websocket connections, connections over it are closed with code 1013 (try again later). 0 - no limit")
+	rootCmd.PersistentFlags().IntVar(&cfg.WebsocketLimits.MaxSubscriptions, "ws.max.subscriptions", 0, "Maximum of subscriptions of one websocket connection, eth_subscribe over it fails with error -32005. 0 - no limit")
+	rootCmd.PersistentFlags().DurationVar(&cfg.WebsocketLimits.ReadTimeout, "ws.read.timeout", 0, "Close websocket connection sending neither requests nor pongs for this long with code 1008. 0 - no timeout")
+	rootCmd.PersistentFlags().DurationVar(&cfg.WebsocketLimits.WriteTimeout, "ws.write.timeout", 0, "Close websocket connection if writing a message to it takes longer. 0 - 10m, or deadline of the call")
+	rootCmd.PersistentFlags().DurationVar(&cfg.WebsocketLimits.PingInterval, "ws.ping.interval", 60*time.Second, "Ping idle websocket connections with this interval, all of them with --ws.read.timeout, set below it")
+	rootCmd.PersistentFlags().StringVar(&cfg.SocketPath, "socket", "", "Serve JSON-RPC API also over unix socket at this path, for example: /var/run/erigon/rpc.sock")
+	rootCmd.PersistentFlags().StringVar(&cfg.SocketPerm, "socket.perm", "0600", "Permissions of --socket file (octal)")
 	rootCmd.PersistentFlags().StringVar(&cfg.RpcAllowListFilePath, "rpc.accessList", "", "Specify granular (method-by-method) API allowlist")
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcRateLimitFilePath, "rpc.ratelimit", "", "Specify per-method limits of calls per second of each client (IP address, or X-API-Key header accepted by --rpc.apikeys)")
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcRateLimitRedisAddr, "rpc.ratelimit.redis", "", "Redis address to keep --rpc.ratelimit buckets shared by multiple rpcdaemons, for example: 127.0.0.1:6379. Buckets are kept in memory if not set")
+	rootCmd.PersistentFlags().IntVar(&cfg.ResponseCacheSize, "rpc.responsecache", 0, "Amount of responses of eth_getBlockByNumber, eth_getTransactionReceipt, eth_getLogs and erigon_getInternalTransactions about old blocks to cache. 0 disables the cache")
+	rootCmd.PersistentFlags().Uint64Var(&cfg.ResponseCacheDepth, "rpc.responsecache.depth", 64, "Only responses about blocks at least this amount of blocks below the head are cached")
+	rootCmd.PersistentFlags().DurationVar(&cfg.FilterTTL, "rpc.filters.ttl", filters.DefaultFilterLimits().TTL, "Filters of eth_newFilter and eth_newBlockFilter not polled for this long are uninstalled")
+	rootCmd.PersistentFlags().IntVar(&cfg.FiltersPerClient, "rpc.filters.limit", filters.DefaultFilterLimits().PerClient, "Maximum amount of installed filters of each client (IP address, or X-API-Key header accepted by --rpc.apikeys). 0 - no limit")
+	rootCmd.PersistentFlags().StringVar(&cfg.FiltersRedisAddr, "rpc.filters.redis", "", "Redis address to keep installed filters shared by multiple rpcdaemons, for example: 127.0.0.1:6379. Filters are kept in memory if not set")
+	rootCmd.PersistentFlags().StringVar(&cfg.TracingEndpoint, "tracing.endpoint", "", "Export OpenTelemetry spans of RPC calls, gRPC calls to Erigon and database transactions to OTLP collector (gRPC) at this address, for example: 127.0.0.1:4317")
+	rootCmd.PersistentFlags().Float64Var(&cfg.TracingSampleRatio, "tracing.sample_ratio", 1, "Share of calls traced when caller doesn't send traceparent header")
+	rootCmd.PersistentFlags().StringVar(&cfg.RpcAPIKeysFilePath, "rpc.apikeys", "", "Specify namespaces and methods available to clients without API key and to each key sent in X-API-Key header")
+	rootCmd.PersistentFlags().DurationVar(&cfg.ShutdownTimeout, "rpc.shutdown.timeout", 10*time.Second, "On shutdown wait this long for requests in progress before cancelling them")
+	rootCmd.PersistentFlags().DurationVar(&cfg.SlowCallThreshold, "rpc.slow", 0, "Log calls taking longer than this, with time spent waiting for Erigon. 0 - don't log")
+	rootCmd.PersistentFlags().StringVar(&cfg.AuditLogPath, "rpc.audit", "", "Record every call as JSON line to this file, or to local syslog if set to 'syslog'")
+	rootCmd.PersistentFlags().StringVar(&cfg.RecordFilePath, "rpc.record", "", "Record request and response of every call as JSON line to this file, for replay against another instance by `rpctest replayRecording`")
+	rootCmd.PersistentFlags().BoolVar(&cfg.RecordKVReads, "rpc.record.kv", false, "Record reads of db made by each call with it in --rpc.record file. Reads served by state cache are not recorded")
+	rootCmd.PersistentFlags().BoolVar(&cfg.UsageEnabled, "rpc.usage", false, "Account calls, compute units and bytes of each client (API key or IP address), enforce quotas of --rpc.apikeys. Reported by admin_usageReport on --authrpc endpoint and by metrics")
+	rootCmd.PersistentFlags().StringVar(&cfg.UsageCostsFilePath, "rpc.usage.costs", "", "Specify compute units of each call of methods for --rpc.usage. Each call costs 1 unit if not set")
+	rootCmd.PersistentFlags().StringVar(&cfg.UsageFilePath, "rpc.usage.file", "", "Save hourly aggregates of --rpc.usage to this file every minute and load them on start. Kept in memory only if not set")
+	rootCmd.PersistentFlags().DurationVar(&cfg.UsageRetention, "rpc.usage.retention", 31*24*time.Hour, "Keep hourly aggregates of --rpc.usage for this long")
+	rootCmd.PersistentFlags().StringToIntVar(&cfg.NamespaceConcurrency, "rpc.namespace.concurrency", nil, "Maximum of concurrently executed calls of namespaces, calls over it wait for running ones. For example: debug=4,trace=4")
 	rootCmd.PersistentFlags().UintVar(&cfg.RpcBatchConcurrency, "rpc.batch.concurrency", 2, "Does limit amount of goroutines to process 1 batch request. Means 1 bach request can't overload server. 1 batch still can have unlimited amount of request")
+	rootCmd.PersistentFlags().IntVar(&cfg.RpcBatchLimit, "rpc.batch.limit", 0, "Maximum amount of requests in 1 batch, larger batches are rejected. 0 - no limit")
+	rootCmd.PersistentFlags().IntVar(&cfg.RpcBatchResponseLimit, "rpc.batch.response.limit", 0, "Maximum size (bytes) of response to 1 batch, answers after reaching it are replaced by error. 0 - no limit")
 	rootCmd.PersistentFlags().BoolVar(&cfg.TraceCompatibility, "trace.compat", false, "Bug for bug compatibility with OE for trace_ routines")
+	rootCmd.PersistentFlags().BoolVar(&cfg.GethCompatibility, "rpc.gethcompat", false, "Send errors (reverts, pruned state as missing trie node) and transaction fields as geth does, for SDKs parsing them")
+	rootCmd.PersistentFlags().StringVar(&cfg.RevertABIPath, "rpc.revert.abi", "", "JSON ABI file with custom errors (entries of type error), reverts of eth_call and eth_estimateGas by them are decoded in error data")
 	rootCmd.PersistentFlags().StringVar(&cfg.TxPoolApiAddr, "txpool.api.addr", "127.0.0.1:9090", "txpool api network address, for example: 127.0.0.1:9090")
+	rootCmd.PersistentFlags().StringVar(&cfg.SentryApiAddr, "sentry.api.addr", "", "comma separated sentry api network addresses, for example: 127.0.0.1:9091,127.0.0.1:9191. If set, admin_ peers methods talk to sentries directly")
 	rootCmd.PersistentFlags().BoolVar(&cfg.TevmEnabled, "tevm", false, "Enables Transpiled EVM experiment")
 	rootCmd.PersistentFlags().IntVar(&cfg.StateCache.KeysLimit, "state.cache", kvcache.DefaultCoherentConfig.KeysLimit, "Amount of keys to store in StateCache (enabled if no --datadir set). Set 0 to disable StateCache. 1_000_000 keys ~ equal to 2Gb RAM (maybe we will add RAM accounting in future versions).")
 	rootCmd.PersistentFlags().BoolVar(&cfg.GRPCServerEnabled, "grpc", false, "Enable GRPC server")
 	rootCmd.PersistentFlags().StringVar(&cfg.GRPCListenAddress, "grpc.addr", node.DefaultGRPCHost, "GRPC server listening interface")
 	rootCmd.PersistentFlags().IntVar(&cfg.GRPCPort, "grpc.port", node.DefaultGRPCPort, "GRPC server listening port")
 	rootCmd.PersistentFlags().BoolVar(&cfg.GRPCHealthCheckEnabled, "grpc.healthcheck", false, "Enable GRPC health check")
-
+	rootCmd.PersistentFlags().Uint64Var(&cfg.HealthMaxSyncLag, "health.max_sync_lag", 0, "/ready fails while chain is more than this amount of blocks behind the highest known header. 0 disables the check")
+	rootCmd.PersistentFlags().Uint64Var(&cfg.HealthMinPeers, "health.min_peers", 0, "/ready fails while Erigon has less peers. 0 disables the check")
+	rootCmd.PersistentFlags().StringToStringVar(&cfg.Chains, "chains", nil, "Additional chains served on path-prefixed endpoints, name=private api address of their Erigon, for example: sepolia=10.0.0.2:9090,gnosis=10.0.0.3:9090 - served on /sepolia and /gnosis")
+	rootCmd.PersistentFlags().IntVar(&cfg.KvPrefetch, "private.api.kv.prefetch", services.DefaultKvPrefetch, "Max amount of key/value pairs read ahead by one round trip of remote db cursor scanning a table (up to 2048). 0 - no prefetching")
+	rootCmd.PersistentFlags().DurationVar(&cfg.KeepaliveInterval, "private.api.keepalive.interval", services.DefaultKeepaliveInterval, "Ping idle private api connection with this interval to detect dead connections (min 10s)")
+	rootCmd.PersistentFlags().DurationVar(&cfg.KeepaliveTimeout, "private.api.keepalive.timeout", services.DefaultKeepaliveTimeout, "Close private api connection if keepalive ping is not acknowledged within this timeout")
+	rootCmd.PersistentFlags().IntVar(&cfg.GRPCMaxMsgSize, "private.api.maxmsgsize", services.DefaultMaxMsgSize, "Max size in bytes of messages sent to and received from private api, txpool and sentries")
+	rootCmd.PersistentFlags().DurationVar(&cfg.GRPCCallTimeout, "private.api.call.timeout", services.DefaultCallTimeout, "Deadline of each unary call of private api, txpool and sentries, streams have none. 0 - no deadline")
+	rootCmd.PersistentFlags().IntVar(&cfg.GRPCPoolSize, "private.api.conns", 1, "Amount of connections to each private api, txpool and sentry address, calls are spread over them")
+	rootCmd.PersistentFlags().IntVar(&cfg.RetryAttempts, "private.api.retry.attempts", 1, "Amount of attempts of read-only private api calls failed with transient errors (Unavailable/Aborted). 1 means no retries")
+	rootCmd.PersistentFlags().DurationVar(&cfg.RetryBackoff, "private.api.retry.backoff", services.DefaultRetryPolicy().BaseDelay, "Delay before first retry of private api call, doubled on each next retry")
+	rootCmd.PersistentFlags().IntVar(&cfg.LogsBufferSize, "private.api.logs.buffer", services.DefaultLogsBuffer().Size, "Amount of logs subscription replies buffered while filters are busy, 0 - no buffering")
+	rootCmd.PersistentFlags().BoolVar(&cfg.LogsDropOldest, "private.api.logs.drop_oldest", false, "Drop oldest buffered logs subscription reply instead of waiting when buffer is full, requires --private.api.logs.buffer > 0")
+	rootCmd.PersistentFlags().IntVar(&cfg.SubscriberLogsBuffer, "rpc.subscription.logs.buffer", filters.DefaultSubscriberLogsBuffer().Size, "Amount of logs buffered for each eth_subscribe(\"logs\") subscriber, the oldest ones are dropped when it's full")
+	rootCmd.PersistentFlags().BoolVar(&cfg.DisconnectSlowLogs, "rpc.subscription.logs.disconnect", false, "Close connection of logs subscriber with full buffer instead of dropping its oldest logs")
+	rootCmd.PersistentFlags().BoolVar(&cfg.AuthRpcEnabled, "authrpc", false, "Enable JWT-authenticated HTTP-RPC server with Engine API (engine_ namespace) for consensus client")
+	rootCmd.PersistentFlags().StringVar(&cfg.AuthRpcListenAddress, "authrpc.addr", node.DefaultHTTPHost, "Engine API server listening interface")
+	rootCmd.PersistentFlags().IntVar(&cfg.AuthRpcPort, "authrpc.port", 8551, "Engine API server listening port")
+	rootCmd.PersistentFlags().StringVar(&cfg.JWTSecretPath, "authrpc.jwtsecret", "", "Path to hex-encoded secret shared with consensus client, generated if doesn't exist. Default: <datadir>/jwt.hex")
+	rootCmd.PersistentFlags().DurationVar(&cfg.JWTWindow, "authrpc.jwtwindow", 5*time.Second, "Max difference between issued-at time of Engine API token and local clock")
+	rootCmd.PersistentFlags().BoolVar(&cfg.GraphQLEnabled, "graphql", false, "Enable GraphQL server (EIP-1767 schema)")
+	rootCmd.PersistentFlags().StringVar(&cfg.GraphQLListenAddress, "graphql.addr", node.DefaultHTTPHost, "GraphQL server listening interface")
+	rootCmd.PersistentFlags().IntVar(&cfg.GraphQLPort, "graphql.port", 8547, "GraphQL server listening port")
+	rootCmd.PersistentFlags().BoolVar(&cfg.TotalSupply, "private.api.total_supply", false, "Allow total supply queries, expensive: node iterates over all accounts to answer them")
+
+	if err := rootCmd.MarkPersistentFlagFilename("http.listeners", "toml"); err != nil {
+		panic(err)
+	}
+	if err := rootCmd.MarkPersistentFlagFilename("rpc.revert.abi", "json"); err != nil {
+		panic(err)
+	}
 	if err := rootCmd.MarkPersistentFlagFilename("rpc.accessList", "json"); err != nil {
 		panic(err)
 	}
+	if err := rootCmd.MarkPersistentFlagFilename("rpc.ratelimit", "json"); err != nil {
+		panic(err)
+	}
+	if err := rootCmd.MarkPersistentFlagFilename("rpc.apikeys", "json"); err != nil {
+		panic(err)
+	}
+	if err := rootCmd.MarkPersistentFlagFilename("rpc.usage.costs", "json"); err != nil {
+		panic(err)
+	}
 	if err := rootCmd.MarkPersistentFlagDirname("datadir"); err != nil {
 		panic(err)
 	}
+	rootCmd.PersistentFlags().DurationVar(&cfg.ReplicaRefresh, "replica.refresh", 0, "--chaindata is read-only copy of db of another machine (rsync without --inplace, ZFS snapshot behind symlink): check it with this interval and reopen new version without restart. 0 - disabled")
 	if err := rootCmd.MarkPersistentFlagDirname("chaindata"); err != nil {
 		panic(err)
 	}
@@ -117,6 +297,13 @@ func RootCommand() (*cobra.Command, *Flags) {
 		if err := utils.SetupCobra(cmd); err != nil {
 			return err
 		}
+		if cfg.TracingEndpoint != "" {
+			shutdown, err := tracing.Setup(context.Background(), "rpcdaemon", cfg.TracingEndpoint, cfg.TracingSampleRatio)
+			if err != nil {
+				return err
+			}
+			stopTracing = shutdown
+		}
 		cfg.SingleNodeMode = cfg.Datadir != "" || cfg.Chaindata != ""
 		if cfg.SingleNodeMode {
 			if cfg.Datadir == "" {
@@ -126,11 +313,32 @@ func RootCommand() (*cobra.Command, *Flags) {
 				cfg.Chaindata = path.Join(cfg.Datadir, "chaindata")
 			}
 		}
+		if err := validateChains(cfg.Chains); err != nil {
+			return err
+		}
+		if cfg.RevertABIPath != "" {
+			decoder, err := ethapi.LoadRevertDecoder(cfg.RevertABIPath)
+			if err != nil {
+				return fmt.Errorf("--rpc.revert.abi: %w", err)
+			}
+			cfg.RevertDecoder = decoder
+		}
+		if err := validateCompressionLevel("http.compression.level", cfg.HttpCompressionLevel); err != nil {
+			return err
+		}
+		if err := validateCompressionLevel("ws.compression.level", cfg.WebsocketDeflate.Level); err != nil {
+			return err
+		}
 		cfg.TxPoolV2 = true
 		return nil
 	}
 	rootCmd.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
 		utils.StopDebug()
+		if stopTracing != nil {
+			if err := stopTracing(context.Background()); err != nil {
+				log.Warn("flushing traces", "err", err)
+			}
+		}
 		return nil
 	}
 
@@ -232,15 +440,25 @@ func RemoteServices(ctx context.Context, cfg Flags, logger log.Logger, rootCance
 	// Do not change the order of these checks. Chaindata needs to be checked first, because PrivateApiAddr has default value which is not ""
 	// If PrivateApiAddr is checked first, the Chaindata option will never work
 	if cfg.SingleNodeMode {
-		var rwKv kv.RwDB
-		rwKv, err = kv2.NewMDBX(logger).Path(cfg.Chaindata).Readonly().Open()
+		open := func(dir string) (kv.RoDB, error) {
+			rwKv, err := kv2.NewMDBX(logger).Path(dir).Readonly().Open()
+			if err != nil {
+				return nil, err
+			}
+			if compatErr := checkDbCompatibility(ctx, rwKv); compatErr != nil {
+				rwKv.Close()
+				return nil, compatErr
+			}
+			return rwKv, nil
+		}
+		if cfg.ReplicaRefresh > 0 {
+			db, err = openReplicaDB(ctx, cfg.Chaindata, cfg.ReplicaRefresh, open, logger.New("db", "replica"))
+		} else {
+			db, err = open(cfg.Chaindata)
+		}
 		if err != nil {
 			return nil, nil, nil, nil, nil, err
 		}
-		if compatErr := checkDbCompatibility(ctx, rwKv); compatErr != nil {
-			return nil, nil, nil, nil, nil, compatErr
-		}
-		db = rwKv
 		stateCache = kvcache.NewDummy()
 	} else {
 		if cfg.StateCache.KeysLimit > 0 {
@@ -252,36 +470,82 @@ func RemoteServices(ctx context.Context, cfg Flags, logger log.Logger, rootCance
 	}
 
 	if cfg.PrivateApiAddr == "" {
-		return db, eth, txPool, mining, stateCache, nil
+		return wrapDB(cfg, db), eth, txPool, mining, stateCache, nil
 	}
 
 	creds, err := grpcutil.TLS(cfg.TLSCACert, cfg.TLSCertfile, cfg.TLSKeyFile)
 	if err != nil {
 		return nil, nil, nil, nil, nil, fmt.Errorf("open tls cert: %w", err)
 	}
-	conn, err := grpcutil.Connect(creds, cfg.PrivateApiAddr)
-	if err != nil {
-		return nil, nil, nil, nil, nil, fmt.Errorf("could not connect to execution service privateApi: %w", err)
+	if cfg.GRPCMaxMsgSize <= 0 {
+		return nil, nil, nil, nil, nil, fmt.Errorf("--private.api.maxmsgsize must be positive: %d", cfg.GRPCMaxMsgSize)
+	}
+	connOpts := services.ConnOptions{
+		Keepalive:   services.KeepaliveParams(cfg.KeepaliveInterval, cfg.KeepaliveTimeout),
+		MaxMsgSize:  cfg.GRPCMaxMsgSize,
+		CallTimeout: cfg.GRPCCallTimeout,
+		PoolSize:    cfg.GRPCPoolSize,
+	}
+	var conn grpc.ClientConnInterface
+	if addrs := strings.Split(cfg.PrivateApiAddr, ","); len(addrs) == 1 {
+		if conn, err = services.Connect(creds, cfg.PrivateApiAddr, connOpts); err != nil {
+			return nil, nil, nil, nil, nil, fmt.Errorf("could not connect to execution service privateApi: %w", err)
+		}
+	} else {
+		conns := make([]services.ClientConn, len(addrs))
+		for i, addr := range addrs {
+			if conns[i], err = services.Connect(creds, strings.TrimSpace(addr), connOpts); err != nil {
+				return nil, nil, nil, nil, nil, fmt.Errorf("could not connect to execution service privateApi %s: %w", addr, err)
+			}
+		}
+		conn = services.NewFailoverConn(conns, cfg.PrivateApiRoundRobin, logger.New("remote_service", "failover"))
 	}
 
 	kvClient := remote.NewKVClient(conn)
-	remoteKv, err := remotedb.NewRemote(gointerfaces.VersionFromProto(remotedbserver.KvServiceAPIVersion), logger, kvClient).Open()
+	var remoteKvClient remote.KVClient = kvClient
+	if cfg.KvPrefetch > 1 {
+		remoteKvClient = services.NewPrefetchKVClient(kvClient, cfg.KvPrefetch)
+	}
+	remoteKv, err := remotedb.NewRemote(gointerfaces.VersionFromProto(remotedbserver.KvServiceAPIVersion), logger, remoteKvClient).Open()
 	if err != nil {
 		return nil, nil, nil, nil, nil, fmt.Errorf("could not connect to remoteKv: %w", err)
 	}
 
 	subscribeToStateChangesLoop(ctx, kvClient, stateCache)
 
-	remoteEth := services.NewRemoteBackend(conn)
+	retryPolicy := services.DefaultRetryPolicy()
+	retryPolicy.MaxAttempts, retryPolicy.BaseDelay = cfg.RetryAttempts, cfg.RetryBackoff
 	txpoolConn := conn
 	if cfg.TxPoolV2 {
-		txpoolConn, err = grpcutil.Connect(creds, cfg.TxPoolApiAddr)
+		txpoolConn, err = services.Connect(creds, cfg.TxPoolApiAddr, connOpts)
 		if err != nil {
 			return nil, nil, nil, nil, nil, fmt.Errorf("could not connect to txpool api: %w", err)
 		}
 	}
 	mining = services.NewMiningService(txpoolConn)
 	txPool = services.NewTxPoolService(txpoolConn)
+	logsBuffer := services.LogsBuffer{Size: cfg.LogsBufferSize, DropOldest: cfg.LogsDropOldest}
+	if err = logsBuffer.Validate(); err != nil {
+		return nil, nil, nil, nil, nil, fmt.Errorf("--private.api.logs.buffer: %w", err)
+	}
+	backendOpts := []services.RemoteBackendOption{services.WithRetry(retryPolicy), services.WithLogger(logger.New("remote_service", "eth_backend")),
+		services.WithLogsBuffer(logsBuffer), services.WithTxPool(txPool), services.WithReconnect(services.DefaultReconnectPolicy())}
+	if cfg.TotalSupply {
+		backendOpts = append(backendOpts, services.WithTotalSupply())
+	}
+	if cfg.SentryApiAddr != "" {
+		var sentries []grpc.ClientConnInterface
+		for _, addr := range strings.Split(cfg.SentryApiAddr, ",") {
+			// sentry gRPC server doesn't use TLS
+			sentryConn, err := services.Connect(nil, strings.TrimSpace(addr), connOpts)
+			if err != nil {
+				return nil, nil, nil, nil, nil, fmt.Errorf("could not connect to sentry api %s: %w", addr, err)
+			}
+			sentries = append(sentries, sentryConn)
+		}
+		backendOpts = append(backendOpts, services.WithSentries(sentries...))
+	}
+	remoteEth := services.NewRemoteBackend(conn, backendOpts...)
 	if db == nil {
 		db = remoteKv
 	}
@@ -300,49 +564,188 @@ func RemoteServices(ctx context.Context, cfg Flags, logger log.Logger, rootCance
 			rootCancel()
 		}
 	}()
-	return db, eth, txPool, mining, stateCache, err
+	return wrapDB(cfg, db), eth, txPool, mining, stateCache, err
 }
 
-func StartRpcServer(ctx context.Context, cfg Flags, rpcAPI []rpc.API) error {
-	// register apis and create handler stack
-	httpEndpoint := fmt.Sprintf("%s:%d", cfg.HttpListenAddress, cfg.HttpPort)
+// wrapDB - db traced by tracing.WrapDB, with reads of calls recorded for --rpc.record.kv
+func wrapDB(cfg Flags, db kv.RoDB) kv.RoDB {
+	if db == nil {
+		return db
+	}
+	if cfg.RecordFilePath != "" && cfg.RecordKVReads {
+		db = tracing.RecordKVReads(db)
+	}
+	return tracing.WrapDB(db)
+}
 
+// startAuthRpcServer - HTTP endpoint serving only Engine API and other rpc.API marked Authenticated, each request
+// must carry JWT signed by shared secret
+func startAuthRpcServer(cfg Flags, authAPI []rpc.API) (*http.Server, *rpc.Server, error) {
+	secretPath := cfg.JWTSecretPath
+	if secretPath == "" {
+		secretPath = path.Join(cfg.Datadir, "jwt.hex")
+	}
+	secret, err := obtainJWTSecret(secretPath)
+	if err != nil {
+		return nil, nil, err
+	}
 	srv := rpc.NewServer(cfg.RpcBatchConcurrency)
+	if err = node.RegisterApisFromWhitelist(authAPI, nil, srv, true); err != nil {
+		return nil, nil, fmt.Errorf("could not start register Engine API: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s:%d", cfg.AuthRpcListenAddress, cfg.AuthRpcPort)
+	listener, _, err := node.StartHTTPEndpoint(endpoint, rpc.DefaultHTTPTimeouts, newJWTHandler(secret, cfg.JWTWindow, srv))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not start Engine API: %w", err)
+	}
+	log.Info("Engine HTTP endpoint opened", "url", endpoint)
+	return listener, srv, nil
+}
+
+// startIPCServer - same API as HTTP endpoint over unix socket, connections are served like websocket ones
+func startIPCServer(cfg Flags, srv *rpc.Server) (net.Listener, error) {
+	perm, err := strconv.ParseUint(cfg.SocketPerm, 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --socket.perm %q: %w", cfg.SocketPerm, err)
+	}
+	listener, err := rpc.ListenIPC(cfg.SocketPath, os.FileMode(perm))
+	if err != nil {
+		return nil, fmt.Errorf("could not start IPC endpoint: %w", err)
+	}
+	go srv.ServeListener(listener)
+	log.Info("IPC endpoint opened", "path", cfg.SocketPath, "perm", cfg.SocketPerm)
+	return listener, nil
+}
+
+// startGraphQLServer - GraphQL served on its own port, with same CORS and virtual hosts restrictions as HTTP-RPC
+func startGraphQLServer(cfg Flags, graphQLHandler http.Handler) (*http.Server, error) {
+	endpoint := fmt.Sprintf("%s:%d", cfg.GraphQLListenAddress, cfg.GraphQLPort)
+	handler := node.NewHTTPHandlerStack(graphQLHandler, cfg.HttpCORSDomain, cfg.HttpVirtualHost, cfg.httpGzip())
+	listener, _, err := node.StartHTTPEndpoint(endpoint, rpc.DefaultHTTPTimeouts, handler)
+	if err != nil {
+		return nil, fmt.Errorf("could not start GraphQL api: %w", err)
+	}
+	log.Info("GraphQL endpoint opened", "url", endpoint)
+	return listener, nil
+}
 
+// StartRpcServer - serves `rpcAPI` and API of additional `chains` until `ctx` is done. `graphQLHandler` is required if
+// --graphql is set
+func StartRpcServer(ctx context.Context, cfg Flags, rpcAPI []rpc.API, graphQLHandler http.Handler, chains ...ChainAPI) error {
+	var limits rpcServerLimits
 	allowListForRPC, err := parseAllowListForRPC(cfg.RpcAllowListFilePath)
 	if err != nil {
 		return err
 	}
-	srv.SetAllowList(allowListForRPC)
+	limits.allowList = allowListForRPC
 
-	if err := node.RegisterApisFromWhitelist(rpcAPI, cfg.API, srv, false); err != nil {
-		return fmt.Errorf("could not start register RPC apis: %w", err)
+	rateLimits, err := parseRateLimitsForRPC(cfg.RpcRateLimitFilePath)
+	if err != nil {
+		return err
+	}
+	if rateLimits != nil {
+		if cfg.RpcRateLimitRedisAddr != "" {
+			limits.rateLimiter = rpc.NewRedisRateLimiter(cfg.RpcRateLimitRedisAddr, *rateLimits)
+		} else {
+			limits.rateLimiter = rpc.NewRateLimiter(*rateLimits)
+		}
 	}
 
-	httpHandler := node.NewHTTPHandlerStack(srv, cfg.HttpCORSDomain, cfg.HttpVirtualHost, cfg.HttpCompression)
-	var wsHandler http.Handler
-	if cfg.WebsocketEnabled {
-		wsHandler = srv.WebsocketHandler([]string{"*"}, cfg.WebsocketCompression)
+	if cfg.AuditLogPath != "" {
+		auditLog, err := rpc.NewAuditLog(cfg.AuditLogPath)
+		if err != nil {
+			return err
+		}
+		defer auditLog.Close()
+		limits.auditLog = auditLog
 	}
 
-	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// adding a healthcheck here
-		if health.ProcessHealthcheckIfNeeded(w, r, rpcAPI) {
-			return
+	if cfg.RecordFilePath != "" {
+		recorder, err := rpc.NewRequestRecorder(cfg.RecordFilePath, cfg.RecordKVReads)
+		if err != nil {
+			return err
 		}
-		if cfg.WebsocketEnabled && websocket.IsWebSocketUpgrade(r) {
-			wsHandler.ServeHTTP(w, r)
-			return
+		defer recorder.Close()
+		limits.recorder = recorder
+	} else if cfg.RecordKVReads {
+		log.Warn("--rpc.record.kv has no effect without --rpc.record")
+	}
+
+	apiKeys, err := parseAPIKeysForRPC(cfg.RpcAPIKeysFilePath)
+	if err != nil {
+		return err
+	}
+	if apiKeys != nil {
+		limits.authenticator = rpc.NewAPIKeyAuthenticator(*apiKeys)
+	}
+
+	var publicAPI, authAPI []rpc.API
+	for _, api := range rpcAPI {
+		if api.Namespace == "engine" || api.Authenticated {
+			authAPI = append(authAPI, api)
+		} else {
+			publicAPI = append(publicAPI, api)
 		}
-		httpHandler.ServeHTTP(w, r)
-	})
+	}
 
-	listener, _, err := node.StartHTTPEndpoint(httpEndpoint, rpc.DefaultHTTPTimeouts, handler)
+	if cfg.UsageEnabled {
+		costs, err := parseUsageCostsForRPC(cfg.UsageCostsFilePath)
+		if err != nil {
+			return err
+		}
+		usage, err := rpc.NewUsageAccounting(costs, cfg.UsageRetention, cfg.UsageFilePath)
+		if err != nil {
+			return err
+		}
+		saveCtx, stopSaving := context.WithCancel(context.Background())
+		saved := make(chan struct{})
+		go func() {
+			defer close(saved)
+			usage.SaveEvery(saveCtx, time.Minute)
+		}()
+		defer func() {
+			stopSaving()
+			<-saved
+		}()
+		limits.usage = usage
+		authAPI = append(authAPI, rpc.API{
+			Namespace:     "admin",
+			Public:        false,
+			Service:       &usageAPI{usage: usage},
+			Version:       "1.0",
+			Authenticated: true,
+		})
+		if !cfg.AuthRpcEnabled {
+			log.Warn("admin_usageReport is served only with --authrpc, usage is reported by metrics")
+		}
+	}
+	listenersCfg, err := parseHTTPListeners(cfg.HttpListenersFilePath, cfg)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := httpTLSConfig(cfg)
 	if err != nil {
-		return fmt.Errorf("could not start RPC api: %w", err)
+		return err
+	}
+	listeners := make([]*httpListener, 0, len(listenersCfg))
+	defer func() {
+		// new connections aren't accepted while requests in progress are drained
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		for _, l := range listeners {
+			l.shutdown(shutdownCtx)
+			log.Info("HTTP endpoint closed", "url", l.endpoint)
+		}
+	}()
+	for _, listenerCfg := range listenersCfg {
+		l, err := startHTTPListener(listenerCfg, limits, publicAPI, rpcAPI, chains, tlsConfig)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, l)
 	}
-	info := []interface{}{"url", httpEndpoint, "ws", cfg.WebsocketEnabled,
-		"ws.compression", cfg.WebsocketCompression, "grpc", cfg.GRPCServerEnabled}
+	srv := listeners[0].srv // IPC endpoint serves API of the first one
+
 	var (
 		healthServer *grpcHealth.Server
 		grpcServer   *grpc.Server
@@ -360,18 +763,59 @@ func StartRpcServer(ctx context.Context, cfg Flags, rpcAPI []rpc.API) error {
 			grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
 		}
 		go grpcServer.Serve(grpcListener)
-		info = append(info, "grpc.port", cfg.GRPCPort)
 	}
 
-	log.Info("HTTP endpoint opened", info...)
+	for _, l := range listeners {
+		info := []interface{}{"url", l.endpoint, "api", l.cfg.API, "ws", l.cfg.WebsocketEnabled,
+			"ws.compression", l.cfg.WebsocketCompression, "grpc", cfg.GRPCServerEnabled, "tls", tlsConfig != nil}
+		if cfg.GRPCServerEnabled {
+			info = append(info, "grpc.port", cfg.GRPCPort)
+		}
+		for _, chain := range chains {
+			info = append(info, "chain."+chain.Name, "/"+chain.Name)
+		}
+		log.Info("HTTP endpoint opened", info...)
+	}
 
-	defer func() {
-		srv.Stop()
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		_ = listener.Shutdown(shutdownCtx)
-		log.Info("HTTP endpoint closed", "url", httpEndpoint)
+	if cfg.AuthRpcEnabled {
+		authListener, authSrv, err := startAuthRpcServer(cfg, authAPI)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			authSrv.Stop()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = authListener.Shutdown(shutdownCtx)
+			log.Info("Engine HTTP endpoint closed", "url", authListener.Addr)
+		}()
+	}
 
+	if cfg.GraphQLEnabled {
+		graphQLListener, err := startGraphQLServer(cfg, graphQLHandler)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = graphQLListener.Shutdown(shutdownCtx)
+			log.Info("GraphQL endpoint closed", "url", graphQLListener.Addr)
+		}()
+	}
+
+	if cfg.SocketPath != "" {
+		socketListener, err := startIPCServer(cfg, srv)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = socketListener.Close()
+			log.Info("IPC endpoint closed", "path", cfg.SocketPath)
+		}()
+	}
+
+	defer func() {
 		if cfg.GRPCServerEnabled {
 			if cfg.GRPCHealthCheckEnabled {
 				healthServer.Shutdown()
@@ -385,3 +829,18 @@ func StartRpcServer(ctx context.Context, cfg Flags, rpcAPI []rpc.API) error {
 	log.Info("Exiting...")
 	return nil
 }
+
+// httpGzip - compression of HTTP responses, nil if it's disabled
+func (cfg Flags) httpGzip() *node.GzipConfig {
+	if !cfg.HttpCompression {
+		return nil
+	}
+	return &node.GzipConfig{Level: cfg.HttpCompressionLevel, MinSize: cfg.HttpCompressionMinSize}
+}
+
+func validateCompressionLevel(flag string, level int) error {
+	if level < gzip.DefaultCompression || level > gzip.BestCompression || level == gzip.NoCompression {
+		return fmt.Errorf("--%s must be -1 or between 1 and 9, got %d", flag, level)
+	}
+	return nil
+}
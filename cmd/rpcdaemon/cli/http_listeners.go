@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/node"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/pelletier/go-toml"
+)
+
+// listenerConfig - [[listener]] of --http.listeners file, fields which are not set are taken from flags
+type listenerConfig struct {
+	Addr       string   `toml:"addr"` // host:port
+	API        []string `toml:"api"`
+	CORSDomain []string `toml:"corsdomain"`
+	VHosts     []string `toml:"vhosts"`
+	WS         *bool    `toml:"ws"`
+}
+
+// parseHTTPListeners - flags of each HTTP endpoint of --http.listeners file, only of --http.addr:--http.port endpoint if
+// no file is provided
+func parseHTTPListeners(path string, cfg Flags) ([]Flags, error) {
+	path = strings.TrimSpace(path)
+	if path == "" { // no file is provided
+		return []Flags{cfg}, nil
+	}
+
+	fileContents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Listener []listenerConfig `toml:"listener"`
+	}
+	if err = toml.Unmarshal(fileContents, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(file.Listener) == 0 {
+		return nil, fmt.Errorf("%s: no [[listener]] entries", path)
+	}
+
+	result := make([]Flags, 0, len(file.Listener))
+	addrs := make(map[string]struct{}, len(file.Listener))
+	for i, l := range file.Listener {
+		host, port, err := net.SplitHostPort(l.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: listener %d: invalid addr %q: %w", path, i, l.Addr, err)
+		}
+		listenerCfg := cfg
+		if listenerCfg.HttpPort, err = strconv.Atoi(port); err != nil {
+			return nil, fmt.Errorf("%s: listener %d: invalid port %q", path, i, port)
+		}
+		listenerCfg.HttpListenAddress = host
+		if _, ok := addrs[l.Addr]; ok {
+			return nil, fmt.Errorf("%s: listener %d: addr %s is used by another listener", path, i, l.Addr)
+		}
+		addrs[l.Addr] = struct{}{}
+		if l.API != nil {
+			listenerCfg.API = l.API
+		}
+		if l.CORSDomain != nil {
+			listenerCfg.HttpCORSDomain = l.CORSDomain
+		}
+		if l.VHosts != nil {
+			listenerCfg.HttpVirtualHost = l.VHosts
+		}
+		if l.WS != nil {
+			listenerCfg.WebsocketEnabled = *l.WS
+		}
+		result = append(result, listenerCfg)
+	}
+	return result, nil
+}
+
+// httpListener - HTTP endpoint with its own RPC servers of main chain and of additional chains
+type httpListener struct {
+	cfg      Flags
+	endpoint string // address it listens on
+	listener *http.Server
+	srv      *rpc.Server // of main chain
+	chainSrv []*rpc.Server
+}
+
+// startHTTPListener - serves API of namespaces of cfg.API of main chain and of `chains` on cfg.HttpListenAddress:cfg.HttpPort
+func startHTTPListener(cfg Flags, limits rpcServerLimits, publicAPI, rpcAPI []rpc.API, chains []ChainAPI, tlsConfig *tls.Config) (*httpListener, error) {
+	mainEndpoint, err := newChainEndpoint(cfg, limits, publicAPI)
+	if err != nil {
+		return nil, err
+	}
+	mainEndpoint.rpcAPI = rpcAPI
+	l := &httpListener{cfg: cfg, endpoint: fmt.Sprintf("%s:%d", cfg.HttpListenAddress, cfg.HttpPort), srv: mainEndpoint.srv}
+
+	var handler http.Handler = mainEndpoint
+	if len(chains) > 0 {
+		chainHandlers := make(map[string]http.Handler, len(chains))
+		for _, chain := range chains {
+			endpoint, err := newChainEndpoint(cfg, limits, chain.API)
+			if err != nil {
+				return nil, fmt.Errorf("chain %s: %w", chain.Name, err)
+			}
+			chainHandlers[chain.Name] = http.StripPrefix("/"+chain.Name, endpoint)
+			l.chainSrv = append(l.chainSrv, endpoint.srv)
+		}
+		handler = chainsHandler(mainEndpoint, chainHandlers)
+	}
+
+	listener, addr, err := node.StartHTTPSEndpoint(l.endpoint, rpc.DefaultHTTPTimeouts, handler, tlsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not start RPC api on %s: %w", l.endpoint, err)
+	}
+	l.listener, l.endpoint = listener, addr.String()
+	return l, nil
+}
+
+// shutdown - stops accepting connections and drains requests in progress until `ctx` is done
+func (l *httpListener) shutdown(ctx context.Context) {
+	httpClosed := make(chan struct{})
+	go func() {
+		defer close(httpClosed)
+		_ = l.listener.Shutdown(ctx)
+	}()
+	_ = l.srv.Shutdown(ctx)
+	for _, chainSrv := range l.chainSrv {
+		_ = chainSrv.Shutdown(ctx)
+	}
+	<-httpClosed
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHTTPListeners(t *testing.T) {
+	cfg := Flags{HttpListenAddress: "localhost", HttpPort: 8545, API: []string{"eth", "erigon"}, HttpCORSDomain: []string{}, HttpVirtualHost: []string{"localhost"}}
+	listeners, err := parseHTTPListeners("", cfg)
+	require.NoError(t, err)
+	require.Equal(t, []Flags{cfg}, listeners)
+
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "listeners.toml")
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+	listeners, err = parseHTTPListeners(write(`
+[[listener]]
+addr = "0.0.0.0:8545"
+api = ["eth", "net", "web3"]
+corsdomain = ["*"]
+vhosts = ["*"]
+ws = true
+
+[[listener]]
+addr = "127.0.0.1:8546"
+api = ["debug", "trace"]
+`), cfg)
+	require.NoError(t, err)
+	require.Len(t, listeners, 2)
+	require.Equal(t, "0.0.0.0", listeners[0].HttpListenAddress)
+	require.Equal(t, 8545, listeners[0].HttpPort)
+	require.Equal(t, []string{"eth", "net", "web3"}, listeners[0].API)
+	require.Equal(t, []string{"*"}, listeners[0].HttpCORSDomain)
+	require.Equal(t, []string{"*"}, listeners[0].HttpVirtualHost)
+	require.True(t, listeners[0].WebsocketEnabled)
+	require.Equal(t, "127.0.0.1", listeners[1].HttpListenAddress)
+	require.Equal(t, 8546, listeners[1].HttpPort)
+	require.Equal(t, []string{"debug", "trace"}, listeners[1].API)
+	require.Equal(t, cfg.HttpCORSDomain, listeners[1].HttpCORSDomain) // not set - from flags
+	require.Equal(t, cfg.HttpVirtualHost, listeners[1].HttpVirtualHost)
+	require.False(t, listeners[1].WebsocketEnabled)
+
+	for _, invalid := range []string{
+		``,
+		`listener = 1`,
+		"[[listener]]\napi = [\"eth\"]",
+		"[[listener]]\naddr = \"127.0.0.1\"",
+		"[[listener]]\naddr = \"127.0.0.1:port\"",
+		"[[listener]]\naddr = \"127.0.0.1:8545\"\n[[listener]]\naddr = \"127.0.0.1:8545\"",
+	} {
+		_, err = parseHTTPListeners(write(invalid), cfg)
+		require.Error(t, err, invalid)
+	}
+	_, err = parseHTTPListeners(filepath.Join(t.TempDir(), "missing.toml"), cfg)
+	require.Error(t, err)
+}
+
+func TestHTTPListenersNamespaces(t *testing.T) {
+	api := []rpc.API{
+		{Namespace: "eth", Public: true, Service: &chainNameService{"eth"}, Version: "1.0"},
+		{Namespace: "debug", Public: true, Service: &chainNameService{"debug"}, Version: "1.0"},
+	}
+	cfg := Flags{HttpListenAddress: "127.0.0.1", HttpVirtualHost: []string{"*"}}
+	public, internal := cfg, cfg
+	public.API, public.HttpCORSDomain = []string{"eth"}, []string{"https://app.example"}
+	internal.API = []string{"debug"}
+	call := func(l *httpListener, method string) (string, error) {
+		client, err := rpc.DialHTTP("http://" + l.endpoint)
+		require.NoError(t, err)
+		defer client.Close()
+		var name string
+		err = client.Call(&name, method)
+		return name, err
+	}
+	var listeners []*httpListener
+	for _, listenerCfg := range []Flags{public, internal} {
+		l, err := startHTTPListener(listenerCfg, rpcServerLimits{}, api, api, nil, nil)
+		require.NoError(t, err)
+		defer l.shutdown(context.Background())
+		listeners = append(listeners, l)
+	}
+
+	name, err := call(listeners[0], "eth_name")
+	require.NoError(t, err)
+	require.Equal(t, "eth", name)
+	_, err = call(listeners[0], "debug_name")
+	require.Error(t, err)
+	name, err = call(listeners[1], "debug_name")
+	require.NoError(t, err)
+	require.Equal(t, "debug", name)
+	_, err = call(listeners[1], "eth_name")
+	require.Error(t, err)
+
+	// CORS origins are of each listener
+	preflight := func(l *httpListener) string {
+		req, err := http.NewRequest(http.MethodOptions, "http://"+l.endpoint, nil)
+		require.NoError(t, err)
+		req.Header.Set("Origin", "https://app.example")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.Header.Get("Access-Control-Allow-Origin")
+	}
+	require.Equal(t, "https://app.example", preflight(listeners[0]))
+	require.Equal(t, "", preflight(listeners[1]))
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+)
+
+// tlsReloadInterval - how often files of certificate and client CAs are checked for changes, at most
+const tlsReloadInterval = 10 * time.Second
+
+// tlsReloader - certificate and client CAs of HTTP endpoint, loaded again after their files change, so certificates
+// can be rotated without restart. Connections keep using previous files if new ones are invalid.
+type tlsReloader struct {
+	certFile, keyFile, clientCAFile string
+
+	lock        sync.Mutex
+	config      *tls.Config
+	modTime     time.Time // the latest modification time of the files
+	lastChecked time.Time
+}
+
+// httpTLSConfig - nil if TLS of HTTP endpoint isn't configured. With --http.tls.clientca clients must present
+// certificate signed by one of those CAs
+func httpTLSConfig(cfg Flags) (*tls.Config, error) {
+	if cfg.HttpTLSCertFile == "" && cfg.HttpTLSKeyFile == "" {
+		if cfg.HttpTLSClientCAFile != "" {
+			return nil, errors.New("--http.tls.clientca requires --http.tls.cert and --http.tls.key")
+		}
+		return nil, nil
+	}
+	if cfg.HttpTLSCertFile == "" || cfg.HttpTLSKeyFile == "" {
+		return nil, errors.New("both --http.tls.cert and --http.tls.key must be set")
+	}
+	r, err := newTLSReloader(cfg.HttpTLSCertFile, cfg.HttpTLSKeyFile, cfg.HttpTLSClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) { return r.current(time.Now()), nil },
+	}, nil
+}
+
+func newTLSReloader(certFile, keyFile, clientCAFile string) (*tlsReloader, error) {
+	r := &tlsReloader{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if r.config, err = r.load(); err != nil {
+		return nil, err
+	}
+	r.modTime, r.lastChecked = modTime, time.Now()
+	return r, nil
+}
+
+func (r *tlsReloader) files() []string {
+	if r.clientCAFile == "" {
+		return []string{r.certFile, r.keyFile}
+	}
+	return []string{r.certFile, r.keyFile, r.clientCAFile}
+}
+
+func (r *tlsReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *tlsReloader) load() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load http tls certificate: %w", err)
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}
+	if r.clientCAFile == "" {
+		return config, nil
+	}
+	pem, err := os.ReadFile(r.clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read http tls client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates in http tls client CA file %s", r.clientCAFile)
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return config, nil
+}
+
+// current - config for new connection, reloaded if files changed since the previous load
+func (r *tlsReloader) current(now time.Time) *tls.Config {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if now.Sub(r.lastChecked) < tlsReloadInterval {
+		return r.config
+	}
+	r.lastChecked = now
+	modTime, err := r.latestModTime()
+	if err != nil || !modTime.After(r.modTime) {
+		return r.config
+	}
+	config, err := r.load()
+	if err != nil {
+		// files may be replaced one by one, they are loaded again on the next check
+		log.Warn("Could not reload HTTP TLS certificate", "error", err)
+		return r.config
+	}
+	r.config, r.modTime = config, modTime
+	log.Info("Reloaded HTTP TLS certificate", "cert", r.certFile)
+	return r.config
+}
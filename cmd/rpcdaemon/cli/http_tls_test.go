@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon/node"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// issueCert - certificate for localhost signed by parent (self-signed if parent is nil) and its key
+func issueCert(t *testing.T, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		DNSNames:              []string{"localhost"},
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestHTTPMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0600))
+		return path
+	}
+	ca, caKey, caPEM, _ := issueCert(t, "ca", true, nil, nil)
+	_, _, serverPEM, serverKeyPEM := issueCert(t, "server", false, ca, caKey)
+	_, _, clientPEM, clientKeyPEM := issueCert(t, "client", false, ca, caKey)
+	cfg := Flags{HttpTLSCertFile: write("server.crt", serverPEM), HttpTLSKeyFile: write("server.key", serverKeyPEM), HttpTLSClientCAFile: write("ca.crt", caPEM)}
+
+	tlsConfig, err := httpTLSConfig(cfg)
+	require.NoError(t, err)
+	server, addr, err := node.StartHTTPSEndpoint("127.0.0.1:0", rpc.DefaultHTTPTimeouts, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), tlsConfig)
+	require.NoError(t, err)
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	get := func(clientCerts []tls.Certificate) error {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: clientCerts}}}
+		resp, err := client.Get("https://" + addr.String())
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+	require.Error(t, get(nil), "client without certificate must be rejected")
+	clientCert, err := tls.X509KeyPair(clientPEM, clientKeyPEM)
+	require.NoError(t, err)
+	require.NoError(t, get([]tls.Certificate{clientCert}))
+
+	_, err = httpTLSConfig(Flags{HttpTLSCertFile: cfg.HttpTLSCertFile})
+	require.Error(t, err)
+	tlsConfig, err = httpTLSConfig(Flags{})
+	require.NoError(t, err)
+	require.Nil(t, tlsConfig)
+}
+
+func TestTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	_, _, certPEM, keyPEM := issueCert(t, "first", false, nil, nil)
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+	r, err := newTLSReloader(certFile, keyFile, "")
+	require.NoError(t, err)
+	commonName := func(config *tls.Config) string {
+		cert, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+		require.NoError(t, err)
+		return cert.Subject.CommonName
+	}
+
+	future := time.Now().Add(time.Minute)
+	_, _, certPEM, keyPEM = issueCert(t, "second", false, nil, nil)
+	// certificate replaced before its key: previous pair is used until both are
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0600))
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	require.Equal(t, "first", commonName(r.current(time.Now())), "files are checked once per interval")
+	require.Equal(t, "first", commonName(r.current(time.Now().Add(tlsReloadInterval))))
+
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0600))
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+	require.Equal(t, "second", commonName(r.current(time.Now().Add(2*tlsReloadInterval))))
+}
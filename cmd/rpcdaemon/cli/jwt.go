@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+)
+
+const jwtSecretLength = 32
+
+// obtainJWTSecret - reads hex-encoded secret shared with consensus client, generates it if file doesn't exist
+func obtainJWTSecret(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		secret := make([]byte, jwtSecretLength)
+		if _, err = rand.Read(secret); err != nil {
+			return nil, err
+		}
+		if err = os.WriteFile(path, []byte(hex.EncodeToString(secret)), 0600); err != nil {
+			return nil, fmt.Errorf("could not write jwt secret: %w", err)
+		}
+		log.Info("Generated JWT secret", "path", path)
+		return secret, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read jwt secret: %w", err)
+	}
+	secret, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwt secret: %w", err)
+	}
+	if len(secret) != jwtSecretLength {
+		return nil, fmt.Errorf("invalid jwt secret: expected %d bytes, got %d", jwtSecretLength, len(secret))
+	}
+	return secret, nil
+}
+
+// validateJWT - checks HS256 signature of `token` and that its `iat` claim is within `window` from now
+func validateJWT(token string, secret []byte, window time.Duration, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return fmt.Errorf("malformed header: %w", err)
+	}
+	if header.Alg != "HS256" {
+		return fmt.Errorf("unsupported signing algorithm %q", header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("malformed signature: %w", err)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return errors.New("invalid signature")
+	}
+	var claims struct {
+		IssuedAt *int64 `json:"iat"`
+	}
+	if err = decodeJWTPart(parts[1], &claims); err != nil {
+		return fmt.Errorf("malformed claims: %w", err)
+	}
+	if claims.IssuedAt == nil {
+		return errors.New("missing issued-at claim")
+	}
+	if skew := now.Sub(time.Unix(*claims.IssuedAt, 0)); skew > window || skew < -window {
+		return fmt.Errorf("stale token: issued %s from now", skew)
+	}
+	return nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// newJWTHandler - passes to `next` only requests with valid "Authorization: Bearer <token>" header
+func newJWTHandler(secret []byte, window time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			http.Error(w, "missing token", http.StatusForbidden)
+			return
+		}
+		if err := validateJWT(token, secret, window, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func signJWT(secret []byte, alg string, claims string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"alg":%q,"typ":"JWT"}`, alg)))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(header + "." + payload))
+	return header + "." + payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidateJWT(t *testing.T) {
+	secret := make([]byte, jwtSecretLength)
+	now := time.Unix(1650000000, 0)
+	iat := func(ts time.Time) string { return fmt.Sprintf(`{"iat":%d}`, ts.Unix()) }
+
+	require.NoError(t, validateJWT(signJWT(secret, "HS256", iat(now)), secret, 5*time.Second, now))
+	require.NoError(t, validateJWT(signJWT(secret, "HS256", iat(now.Add(-4*time.Second))), secret, 5*time.Second, now))
+	require.ErrorContains(t, validateJWT(signJWT(secret, "HS256", iat(now.Add(-10*time.Second))), secret, 5*time.Second, now), "stale token")
+	require.ErrorContains(t, validateJWT(signJWT(secret, "HS256", iat(now.Add(10*time.Second))), secret, 5*time.Second, now), "stale token")
+	require.ErrorContains(t, validateJWT(signJWT(secret, "HS256", `{}`), secret, 5*time.Second, now), "missing issued-at")
+	require.ErrorContains(t, validateJWT(signJWT(secret, "none", iat(now)), secret, 5*time.Second, now), "unsupported signing algorithm")
+	otherSecret := append([]byte{1}, secret[1:]...)
+	require.ErrorContains(t, validateJWT(signJWT(otherSecret, "HS256", iat(now)), secret, 5*time.Second, now), "invalid signature")
+	require.ErrorContains(t, validateJWT("abc", secret, 5*time.Second, now), "malformed")
+}
+
+func TestJWTHandler(t *testing.T) {
+	secret, err := obtainJWTSecret(filepath.Join(t.TempDir(), "jwt.hex"))
+	require.NoError(t, err)
+	handler := newJWTHandler(secret, 5*time.Second, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for token, code := range map[string]int{
+		"": http.StatusForbidden,
+		signJWT(secret, "HS256", fmt.Sprintf(`{"iat":%d}`, time.Now().Unix())):                   http.StatusOK,
+		signJWT(secret, "HS256", fmt.Sprintf(`{"iat":%d}`, time.Now().Add(-time.Minute).Unix())): http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, code, rec.Code, token)
+	}
+}
+
+func TestObtainJWTSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt.hex")
+	generated, err := obtainJWTSecret(path)
+	require.NoError(t, err)
+	require.Len(t, generated, jwtSecretLength)
+
+	read, err := obtainJWTSecret(path)
+	require.NoError(t, err)
+	require.Equal(t, generated, read)
+}
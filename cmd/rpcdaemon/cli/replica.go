@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// replicaDB - db of --chaindata which is read-only copy of database of another machine, updated by replacing its file
+// (rsync without --inplace, ZFS snapshot behind symlink). mdbx.dat is checked every interval, its new version is opened
+// once it doesn't change for an interval, then new transactions read it. Transactions begun before keep reading the
+// previous version, which is closed after the last of them ends: replaced file stays on disk while it's open.
+// Writing opened file in place (rsync --inplace) is not safe, readers may see torn pages
+type replicaDB struct {
+	path     string
+	interval time.Duration
+	open     func(dir string) (kv.RoDB, error)
+	log      log.Logger
+
+	lock    sync.RWMutex
+	current *replicaVersion
+	pending *replicaFile // new version seen by the last check, opened if it's the same at the next one
+	stop    context.CancelFunc
+	stopped chan struct{}
+}
+
+type replicaFile struct {
+	dir  string // chaindata with symlinks evaluated
+	info os.FileInfo
+}
+
+func (f replicaFile) same(other replicaFile) bool {
+	return os.SameFile(f.info, other.info) && f.info.ModTime().Equal(other.info.ModTime()) && f.info.Size() == other.info.Size()
+}
+
+type replicaVersion struct {
+	db   kv.RoDB
+	file replicaFile
+	txs  sync.WaitGroup
+}
+
+type replicaTx struct {
+	kv.Tx
+	version *replicaVersion
+	once    sync.Once
+}
+
+func (tx *replicaTx) Commit() error {
+	err := tx.Tx.Commit()
+	tx.once.Do(tx.version.txs.Done)
+	return err
+}
+
+func (tx *replicaTx) Rollback() {
+	tx.Tx.Rollback()
+	tx.once.Do(tx.version.txs.Done)
+}
+
+func statReplica(path string) (replicaFile, error) {
+	dir, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return replicaFile{}, err
+	}
+	info, err := os.Stat(filepath.Join(dir, "mdbx.dat"))
+	if err != nil {
+		return replicaFile{}, err
+	}
+	return replicaFile{dir: dir, info: info}, nil
+}
+
+// openReplicaDB - `open` opens and checks db of the directory
+func openReplicaDB(ctx context.Context, path string, interval time.Duration, open func(dir string) (kv.RoDB, error), logger log.Logger) (*replicaDB, error) {
+	file, err := statReplica(path)
+	if err != nil {
+		return nil, err
+	}
+	db, err := open(file.dir)
+	if err != nil {
+		return nil, err
+	}
+	r := &replicaDB{path: path, interval: interval, open: open, log: logger, current: &replicaVersion{db: db, file: file}, stopped: make(chan struct{})}
+	ctx, r.stop = context.WithCancel(ctx)
+	go r.checkLoop(ctx)
+	return r, nil
+}
+
+func (r *replicaDB) checkLoop(ctx context.Context) {
+	defer close(r.stopped)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check()
+		}
+	}
+}
+
+// check - opens new version of mdbx.dat if it's not changed since previous check. Only checkLoop changes r.current
+func (r *replicaDB) check() {
+	file, err := statReplica(r.path)
+	if err != nil {
+		r.log.Warn("Replica chaindata is not available", "path", r.path, "err", err)
+		return
+	}
+	if file.same(r.current.file) {
+		r.pending = nil
+		return
+	}
+	if r.pending == nil || !file.same(*r.pending) { // may be still being copied
+		r.pending = &file
+		return
+	}
+	r.pending = nil
+	db, err := r.open(file.dir)
+	if err != nil {
+		r.log.Warn("Replica chaindata is not opened, previous version is used", "dir", file.dir, "err", err)
+		return
+	}
+	r.lock.Lock()
+	previous := r.current
+	r.current = &replicaVersion{db: db, file: file}
+	r.lock.Unlock()
+	go func() {
+		previous.txs.Wait()
+		previous.db.Close()
+	}()
+	r.log.Info("Replica chaindata reopened", "dir", file.dir, "head", replicaHead(db))
+}
+
+func replicaHead(db kv.RoDB) (head uint64) {
+	_ = db.View(context.Background(), func(tx kv.Tx) (err error) {
+		head, err = stages.GetStageProgress(tx, stages.Finish)
+		return err
+	})
+	return head
+}
+
+func (r *replicaDB) BeginRo(ctx context.Context) (kv.Tx, error) {
+	r.lock.RLock()
+	version := r.current
+	version.txs.Add(1)
+	r.lock.RUnlock()
+	tx, err := version.db.BeginRo(ctx)
+	if err != nil {
+		version.txs.Done()
+		return nil, err
+	}
+	return &replicaTx{Tx: tx, version: version}, nil
+}
+
+func (r *replicaDB) View(ctx context.Context, f func(tx kv.Tx) error) error {
+	tx, err := r.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return f(tx)
+}
+
+func (r *replicaDB) AllBuckets() kv.TableCfg {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.current.db.AllBuckets()
+}
+
+func (r *replicaDB) Close() {
+	r.stop()
+	<-r.stopped
+	r.current.txs.Wait()
+	r.current.db.Close()
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+)
+
+type closeCountingDB struct {
+	kv.RwDB
+	closed int32
+}
+
+func (db *closeCountingDB) Close() {
+	atomic.StoreInt32(&db.closed, 1)
+	db.RwDB.Close()
+}
+
+func TestReplicaDB(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	replace := func(content string) { // as rsync does
+		tmp := filepath.Join(dir, "mdbx.dat.tmp")
+		require.NoError(t, os.WriteFile(tmp, []byte(content), 0600))
+		require.NoError(t, os.Rename(tmp, filepath.Join(dir, "mdbx.dat")))
+	}
+	replace("v1")
+
+	var versions []*closeCountingDB
+	var openErr error
+	open := func(string) (kv.RoDB, error) {
+		if openErr != nil {
+			return nil, openErr
+		}
+		db := &closeCountingDB{RwDB: memdb.New()}
+		require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+			return tx.Put(kv.DatabaseInfo, []byte("version"), []byte{byte(len(versions) + 1)})
+		}))
+		versions = append(versions, db)
+		return db, nil
+	}
+	r, err := openReplicaDB(ctx, dir, time.Hour, open, log.New())
+	require.NoError(t, err)
+	defer r.Close()
+	version := func(tx kv.Tx) byte {
+		v, err := tx.GetOne(kv.DatabaseInfo, []byte("version"))
+		require.NoError(t, err)
+		return v[0]
+	}
+
+	tx1, err := r.BeginRo(ctx)
+	require.NoError(t, err)
+	replace("v2")
+	r.check() // may be still being copied
+	require.Len(t, versions, 1)
+	replace("v2 and more")
+	r.check()
+	require.Len(t, versions, 1)
+	r.check()
+	require.Len(t, versions, 2)
+
+	tx2, err := r.BeginRo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, byte(2), version(tx2))
+	require.Equal(t, byte(1), version(tx1))
+	require.Equal(t, int32(0), atomic.LoadInt32(&versions[0].closed))
+	tx1.Rollback()
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&versions[0].closed) == 1 }, time.Second, time.Millisecond)
+	tx2.Rollback()
+
+	// broken copy is not used
+	openErr = errors.New("incompatible DB Schema versions")
+	replace("v3")
+	r.check()
+	r.check()
+	require.NoError(t, r.View(ctx, func(tx kv.Tx) error {
+		require.Equal(t, byte(2), version(tx))
+		return nil
+	}))
+	openErr = nil
+	replace("v4")
+	r.check()
+	r.check()
+	require.NoError(t, r.View(ctx, func(tx kv.Tx) error {
+		require.Equal(t, byte(3), version(tx))
+		return nil
+	}))
+}
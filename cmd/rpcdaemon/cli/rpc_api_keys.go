@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// parseAPIKeysForRPC - reads permissions of --rpc.apikeys file, nil if no file is provided
+func parseAPIKeysForRPC(path string) (*rpc.APIKeys, error) {
+	path = strings.TrimSpace(path)
+	if path == "" { // no file is provided
+		return nil, nil
+	}
+
+	fileContents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys rpc.APIKeys
+	if err = json.Unmarshal(fileContents, &keys); err != nil {
+		return nil, err
+	}
+	if err = keys.Validate(); err != nil {
+		return nil, err
+	}
+	return &keys, nil
+}
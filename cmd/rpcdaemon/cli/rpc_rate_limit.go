@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// parseRateLimitsForRPC - reads limits of --rpc.ratelimit file, nil if no file is provided
+func parseRateLimitsForRPC(path string) (*rpc.RateLimits, error) {
+	path = strings.TrimSpace(path)
+	if path == "" { // no file is provided
+		return nil, nil
+	}
+
+	fileContents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var limits rpc.RateLimits
+	if err = json.Unmarshal(fileContents, &limits); err != nil {
+		return nil, err
+	}
+	if err = limits.Validate(); err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// parseUsageCostsForRPC - reads compute units of methods of --rpc.usage.costs file, empty costs if no file is
+// provided: each call costs 1 unit
+func parseUsageCostsForRPC(path string) (rpc.UsageCosts, error) {
+	var costs rpc.UsageCosts
+	path = strings.TrimSpace(path)
+	if path == "" { // no file is provided
+		return costs, nil
+	}
+
+	fileContents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return costs, err
+	}
+	if err = json.Unmarshal(fileContents, &costs); err != nil {
+		return costs, err
+	}
+	return costs, nil
+}
+
+// UsageReportOptions - clients and period of admin_usageReport, all clients of the last day by default
+type UsageReportOptions struct {
+	Client string     `json:"client"`
+	From   *time.Time `json:"from"`
+	To     *time.Time `json:"to"`
+}
+
+// usageAPI - admin_usageReport, StartRpcServer serves it only on authenticated endpoint
+type usageAPI struct {
+	usage *rpc.UsageAccounting
+}
+
+// UsageReport implements admin_usageReport. Returns usage of clients within the period with their quotas
+func (api *usageAPI) UsageReport(_ context.Context, opts *UsageReportOptions) ([]*rpc.ClientUsage, error) {
+	to := time.Now()
+	from := to.Add(-24 * time.Hour)
+	client := ""
+	if opts != nil {
+		client = opts.Client
+		if opts.To != nil {
+			to = *opts.To
+		}
+		if opts.From != nil {
+			from = *opts.From
+		}
+	}
+	return api.usage.Report(client, from, to), nil
+}
@@ -2,17 +2,33 @@ package commands
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
+	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/log/v3"
 )
 
 // AdminAPI the interface for the admin_* RPC commands.
 type AdminAPI interface {
 	// NodeInfo returns a collection of metadata known about the host.
 	NodeInfo(ctx context.Context) (*p2p.NodeInfo, error)
+
+	// AddPeer requests connecting to a remote node, and also maintaining the new
+	// connection at all times, even reconnecting if it is lost.
+	AddPeer(ctx context.Context, url string) (bool, error)
+
+	// RemovePeer disconnects from a remote node if the connection exists
+	RemovePeer(ctx context.Context, url string) (bool, error)
+
+	// Peers retrieves all the information we know about each individual peer at the
+	// protocol granularity.
+	Peers(ctx context.Context) ([]*p2p.PeerInfo, error)
+
+	// PeerEvents creates an RPC subscription which receives peer events from sentries
+	PeerEvents(ctx context.Context) (*rpc.Subscription, error)
 }
 
 // AdminAPIImpl data structure to store things needed for admin_* commands.
@@ -27,15 +43,57 @@ func NewAdminAPI(eth services.ApiBackend) *AdminAPIImpl {
 	}
 }
 
+// NodeInfo - identity of the first sentry, with protocols of all of them
 func (api *AdminAPIImpl) NodeInfo(ctx context.Context) (*p2p.NodeInfo, error) {
-	nodes, err := api.ethBackend.NodeInfo(ctx, 1)
+	nodes, err := api.ethBackend.NodeInfo(ctx, allNodesInfo)
 	if err != nil {
 		return nil, fmt.Errorf("node info request error: %w", err)
 	}
+	node, err := services.MergeNodeInfos(nodes)
+	if err != nil {
+		return nil, fmt.Errorf("node info request error: %w", err)
+	}
+
+	return &node, nil
+}
+
+func (api *AdminAPIImpl) AddPeer(ctx context.Context, url string) (bool, error) {
+	return api.ethBackend.AddPeer(ctx, url)
+}
+
+func (api *AdminAPIImpl) RemovePeer(ctx context.Context, url string) (bool, error) {
+	return api.ethBackend.RemovePeer(ctx, url)
+}
+
+func (api *AdminAPIImpl) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
+	return api.ethBackend.Peers(ctx)
+}
 
-	if len(nodes) == 0 {
-		return nil, errors.New("empty nodesInfo response")
+// PeerEvents - sends services.PeerEvent of each peer connecting to or disconnecting from any of sentries
+func (api *AdminAPIImpl) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
 
-	return &nodes[0], nil
+	rpcSub := notifier.CreateSubscription()
+	subCtx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer debug.LogPanic()
+		defer cancel()
+		err := api.ethBackend.SubscribePeerEvents(subCtx, func(event services.PeerEvent) {
+			if err := notifier.Notify(rpcSub.ID, event); err != nil {
+				log.Warn("error while notifying subscription", "err", err)
+			}
+		})
+		if err != nil && subCtx.Err() == nil {
+			log.Warn("peer events subscription failed", "err", err)
+		}
+	}()
+	go func() {
+		<-rpcSub.Err()
+		cancel()
+	}()
+
+	return rpcSub, nil
 }
@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus/clique"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// BorAPI - bor_ RPC commands of Polygon. This node has no Bor consensus engine: answers are computed from headers,
+// validator snapshots of engine are not available
+type BorAPI interface {
+	GetAuthor(ctx context.Context, blockNr *rpc.BlockNumber) (*common.Address, error)
+	GetRootHash(ctx context.Context, start uint64, end uint64) (string, error)
+	GetSnapshot(ctx context.Context, blockNr *rpc.BlockNumber) (interface{}, error)
+}
+
+// BorImpl is implementation of the BorAPI interface
+type BorImpl struct {
+	*BaseAPI
+	db kv.RoDB
+}
+
+// NewBorAPI returns BorImpl instance
+func NewBorAPI(base *BaseAPI, db kv.RoDB) *BorImpl {
+	return &BorImpl{BaseAPI: base, db: db}
+}
+
+// maxCheckpointLength - max amount of blocks of checkpoint, root hash of which bor_getRootHash calculates
+const maxCheckpointLength = 1 << 15
+
+// borJaipurBlocks - since Jaipur fork base fee is part of sealed header, by chain id
+var borJaipurBlocks = map[uint64]uint64{
+	137:   23850000, // mainnet
+	80001: 22770000, // mumbai
+}
+
+var errMissingBorSignature = errors.New("extra-data 65 byte signature suffix missing")
+
+// GetAuthor implements bor_getAuthor. Returns address of validator which sealed the block, latest if not specified
+func (api *BorImpl) GetAuthor(ctx context.Context, blockNr *rpc.BlockNumber) (*common.Address, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	header, err := api.borHeader(tx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	author, err := borAuthor(header, chainConfig.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+// GetSnapshot implements bor_getSnapshot. Snapshots of validator set are kept by Bor consensus engine, which this node
+// doesn't run
+func (api *BorImpl) GetSnapshot(ctx context.Context, blockNr *rpc.BlockNumber) (interface{}, error) {
+	return nil, fmt.Errorf(NotImplemented, "bor_getSnapshot: validator snapshots of Bor consensus are not kept by this node")
+}
+
+// GetRootHash implements bor_getRootHash. Returns root of merkle tree of headers [start, end] of checkpoint, hex
+// without 0x prefix, as Heimdall expects it
+func (api *BorImpl) GetRootHash(ctx context.Context, start uint64, end uint64) (string, error) {
+	if end >= start && end-start+1 > maxCheckpointLength {
+		return "", fmt.Errorf("start: %d and end block: %d exceed max allowed checkpoint length: %d", start, end, maxCheckpointLength)
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	head, err := getLatestBlockNumber(tx)
+	if err != nil {
+		return "", err
+	}
+	if start > end || end > head {
+		return "", fmt.Errorf("invalid parameters start: %d and end block: %d params. Current header: %d", start, end, head)
+	}
+	leaves := make([]common.Hash, nextPowerOfTwo(end-start+1))
+	for number := start; number <= end; number++ {
+		header := rawdb.ReadHeaderByNumber(tx, number)
+		if header == nil {
+			return "", fmt.Errorf("header %d not found", number)
+		}
+		leaves[number-start] = crypto.Keccak256Hash(
+			common.LeftPadBytes(header.Number.Bytes(), 32),
+			common.LeftPadBytes(new(big.Int).SetUint64(header.Time).Bytes(), 32),
+			header.TxHash.Bytes(),
+			header.ReceiptHash.Bytes(),
+		)
+	}
+	for len(leaves) > 1 {
+		for i := 0; i < len(leaves)/2; i++ {
+			leaves[i] = crypto.Keccak256Hash(leaves[2*i].Bytes(), leaves[2*i+1].Bytes())
+		}
+		leaves = leaves[:len(leaves)/2]
+	}
+	return hex.EncodeToString(leaves[0].Bytes()), nil
+}
+
+func (api *BorImpl) borHeader(tx kv.Tx, blockNr *rpc.BlockNumber) (*types.Header, error) {
+	number := rpc.LatestBlockNumber
+	if blockNr != nil {
+		number = *blockNr
+	}
+	blockNum, err := getBlockNumber(number, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	header := rawdb.ReadHeaderByNumber(tx, blockNum)
+	if header == nil {
+		return nil, fmt.Errorf("block header not found: %d", blockNum)
+	}
+	return header, nil
+}
+
+// borAuthor - signer of seal in extra-data, sealed header is the one of clique, with base fee only since Jaipur
+func borAuthor(header *types.Header, chainID *big.Int) (common.Address, error) {
+	if len(header.Extra) < crypto.SignatureLength {
+		return common.Address{}, errMissingBorSignature
+	}
+	sealed := *header
+	if chainID != nil {
+		if jaipur, ok := borJaipurBlocks[chainID.Uint64()]; ok && header.Number.Uint64() < jaipur {
+			sealed.Eip1559 = false
+		}
+	}
+	pubkey, err := crypto.Ecrecover(clique.SealHash(&sealed).Bytes(), header.Extra[len(header.Extra)-crypto.SignatureLength:])
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return signer, nil
+}
+
+func nextPowerOfTwo(n uint64) uint64 {
+	power := uint64(1)
+	for power < n {
+		power <<= 1
+	}
+	return power
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus/clique"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBorGetRootHash(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewBorAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db)
+
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	leaf := func(number uint64) common.Hash {
+		header := rawdb.ReadHeaderByNumber(tx, number)
+		return crypto.Keccak256Hash(common.LeftPadBytes(header.Number.Bytes(), 32), common.LeftPadBytes(new(big.Int).SetUint64(header.Time).Bytes(), 32), header.TxHash.Bytes(), header.ReceiptHash.Bytes())
+	}
+	node := func(left, right common.Hash) common.Hash { return crypto.Keccak256Hash(left.Bytes(), right.Bytes()) }
+
+	root, err := api.GetRootHash(ctx, 4, 4)
+	require.NoError(t, err)
+	require.Equal(t, hex.EncodeToString(leaf(4).Bytes()), root)
+	root, err = api.GetRootHash(ctx, 1, 3) // padded with empty leaf
+	require.NoError(t, err)
+	require.Equal(t, hex.EncodeToString(node(node(leaf(1), leaf(2)), node(leaf(3), common.Hash{})).Bytes()), root)
+
+	_, err = api.GetRootHash(ctx, 3, 1)
+	require.Error(t, err)
+	_, err = api.GetRootHash(ctx, 1, 11) // above head
+	require.Error(t, err)
+	_, err = api.GetRootHash(ctx, 0, maxCheckpointLength)
+	require.Error(t, err)
+}
+
+func TestBorAuthor(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	header := &types.Header{Number: big.NewInt(23849999), Difficulty: big.NewInt(1), Extra: make([]byte, 32+crypto.SignatureLength), BaseFee: big.NewInt(7), Eip1559: true}
+	sealed := *header
+	sealed.Eip1559 = false // before Jaipur of mainnet base fee is not sealed
+	sig, err := crypto.Sign(clique.SealHash(&sealed).Bytes(), key)
+	require.NoError(t, err)
+	copy(header.Extra[32:], sig)
+
+	author, err := borAuthor(header, big.NewInt(137))
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), author)
+	author, err = borAuthor(header, big.NewInt(12345)) // base fee is sealed on unknown chains
+	require.NoError(t, err)
+	require.NotEqual(t, crypto.PubkeyToAddress(key.PublicKey), author)
+
+	_, err = borAuthor(&types.Header{Number: big.NewInt(1), Extra: []byte{1}}, big.NewInt(137))
+	require.Equal(t, errMissingBorSignature, err)
+}
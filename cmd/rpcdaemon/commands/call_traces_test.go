@@ -3,11 +3,14 @@ package commands
 import (
 	"bytes"
 	"context"
+	"errors"
+	"math"
 	"sync"
 	"testing"
 
 	"github.com/holiman/uint256"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 	"github.com/stretchr/testify/require"
 
@@ -16,6 +19,10 @@ import (
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync"
+	stages2 "github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/stages"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/stretchr/testify/assert"
@@ -182,6 +189,66 @@ func TestFilterNoAddresses(t *testing.T) {
 	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, blockNumbersFromTraces(t, buf.Bytes()))
 }
 
+// writesCounter - counts writes made to it, to check that output is flushed in chunks
+type writesCounter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *writesCounter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestFilterPagination(t *testing.T) {
+	m := stages.Mock(t)
+	defer m.DB.Close()
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 10, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{1})
+	}, false /* intemediateHashes */)
+	if err != nil {
+		t.Fatalf("generate chain: %v", err)
+	}
+	api := NewTraceAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), m.DB, &cli.Flags{})
+	if err = m.InsertChain(chain); err != nil {
+		t.Fatalf("inserting chain: %v", err)
+	}
+	var fromBlock, toBlock uint64
+	fromBlock = 1
+	toBlock = 10
+	after, count := uint64(3), uint64(4)
+	traceReq1 := TraceFilterRequest{
+		FromBlock: (*hexutil.Uint64)(&fromBlock),
+		ToBlock:   (*hexutil.Uint64)(&toBlock),
+		After:     &after,
+		Count:     &count,
+	}
+	var buf writesCounter
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+	if err = api.Filter(context.Background(), traceReq1, stream); err != nil {
+		t.Fatalf("trace_filter failed: %v", err)
+	}
+	// every block has only reward trace
+	assert.Equal(t, []int{4, 5, 6, 7}, blockNumbersFromTraces(t, buf.Bytes()))
+	assert.GreaterOrEqual(t, buf.writes, int(count))
+
+	// walking whole history page by page
+	var numbers []int
+	for after = 0; ; after += count {
+		buf.Reset()
+		stream.Reset(&buf)
+		if err = api.Filter(context.Background(), traceReq1, stream); err != nil {
+			t.Fatalf("trace_filter failed: %v", err)
+		}
+		page := blockNumbersFromTraces(t, buf.Bytes())
+		if len(page) == 0 {
+			break
+		}
+		numbers = append(numbers, page...)
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, numbers)
+}
+
 func TestFilterAddressIntersection(t *testing.T) {
 	m := stages.Mock(t)
 	defer m.DB.Close()
@@ -265,3 +332,79 @@ func TestFilterAddressIntersection(t *testing.T) {
 		require.Empty(t, blockNumbersFromTraces(t, stream.Buffer()))
 	})
 }
+
+func TestFilterUnindexedAndPruned(t *testing.T) {
+	m := stages.Mock(t)
+	defer m.DB.Close()
+	ctx := context.Background()
+	api := NewTraceAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), m.DB, &cli.Flags{})
+	otsAPI := NewOtterscanAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), m.DB)
+
+	toAddress1, toAddress2 := common.Address{1}, common.Address{2}
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 10, func(i int, block *core.BlockGen) {
+		block.SetCoinbase(common.Address{4})
+		rcv := toAddress1
+		if i%2 == 1 {
+			rcv = toAddress2
+		}
+		signer := types.LatestSigner(m.ChainConfig)
+		txn, err := types.SignTx(types.NewTransaction(block.TxNonce(m.Address), rcv, new(uint256.Int), 21000, new(uint256.Int), nil), *signer, m.Key)
+		require.NoError(t, err)
+		block.AddTx(txn)
+	}, false /* intemediateHashes */)
+	require.NoError(t, err, "generate chain")
+	require.NoError(t, m.InsertChain(chain), "inserting chain")
+
+	filter := func(from *uint64, to uint64, mode TraceFilterMode, addrs ...*common.Address) ([]int, error) {
+		stream := jsoniter.ConfigDefault.BorrowStream(nil)
+		defer jsoniter.ConfigDefault.ReturnStream(stream)
+		err := api.Filter(ctx, TraceFilterRequest{FromBlock: (*hexutil.Uint64)(from), ToBlock: (*hexutil.Uint64)(&to), FromAddress: []*common.Address{&m.Address}, ToAddress: addrs, Mode: mode}, stream)
+		if err != nil {
+			return nil, err
+		}
+		return blockNumbersFromTraces(t, stream.Buffer()), nil
+	}
+	one := uint64(1)
+	expected := []int{2, 4, 6, 8, 10}
+	blocks, err := filter(&one, 10, TraceFilterModeIntersection, &toAddress2)
+	require.NoError(t, err)
+	require.Equal(t, expected, blocks)
+
+	// blocks 7-10 are executed, but not indexed yet
+	require.NoError(t, m.DB.Update(ctx, func(tx kv.RwTx) error {
+		if err := stagedsync.DoUnwindCallTraces("test", tx, 10, 6, ctx, t.TempDir()); err != nil {
+			return err
+		}
+		return stages2.SaveStageProgress(tx, stages2.CallTraces, 6)
+	}))
+	require.NoError(t, m.DB.View(ctx, func(tx kv.Tx) error {
+		indexed, err := callTraceBlocks(tx, kv.CallToIndex, toAddress2, 0, 6)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{2, 4, 6}, indexed.ToArray())
+		return nil
+	}))
+	blocks, err = filter(&one, 10, TraceFilterModeIntersection, &toAddress2)
+	require.NoError(t, err)
+	require.Equal(t, expected, blocks)
+	page, err := otsAPI.SearchTransactionsBefore(ctx, toAddress2, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, page.Txs, 5)
+	require.Equal(t, uint64(10), page.Txs[0].BlockNumber.ToInt().Uint64())
+
+	// index of blocks before 4 is pruned
+	require.NoError(t, m.DB.Update(ctx, func(tx kv.RwTx) error {
+		return prune.Override(tx, prune.Mode{History: prune.Distance(math.MaxUint64), Receipts: prune.Distance(math.MaxUint64), TxIndex: prune.Distance(math.MaxUint64), CallTraces: prune.Before(4)})
+	}))
+	_, err = filter(&one, 10, TraceFilterModeIntersection, &toAddress2)
+	var pruned *rpchelper.PrunedError
+	require.True(t, errors.As(err, &pruned), "expected pruned error, got %v", err)
+	require.Equal(t, uint64(4), pruned.Earliest)
+	blocks, err = filter(nil, 10, TraceFilterModeIntersection, &toAddress2)
+	require.NoError(t, err)
+	require.Equal(t, []int{4, 6, 8, 10}, blocks)
+	page, err = otsAPI.SearchTransactionsBefore(ctx, toAddress2, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, page.Txs, 4)
+	_, err = otsAPI.SearchTransactionsBefore(ctx, toAddress2, 3, 10)
+	require.True(t, errors.As(err, &pruned), "expected pruned error, got %v", err)
+}
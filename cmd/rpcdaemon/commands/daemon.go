@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"math/big"
 
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
 	"github.com/ledgerwatch/erigon-lib/kv"
@@ -10,22 +11,51 @@ import (
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/log/v3"
 )
 
 // APIList describes the list of available RPC apis
 func APIList(ctx context.Context, db kv.RoDB,
-	eth services.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient, filters *filters.Filters,
+	eth services.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient, ff *filters.Filters,
 	stateCache kvcache.Cache,
 	cfg cli.Flags, customAPIList []rpc.API) []rpc.API {
 	var defaultAPIList []rpc.API
 
-	base := NewBaseApi(filters, stateCache, cfg.SingleNodeMode)
+	base := NewBaseApi(ff, stateCache, cfg.SingleNodeMode)
 	if cfg.TevmEnabled {
 		base.EnableTevmExperiment()
 	}
+	if cfg.RevertDecoder != nil {
+		base.setRevertDecoder(cfg.RevertDecoder)
+	}
+	if cfg.ResponseCacheSize > 0 {
+		base.enableResponseCache(ctx, cfg.ResponseCacheSize, cfg.ResponseCacheDepth)
+	}
+	filterLimits := filters.FilterLimits{TTL: cfg.FilterTTL, PerClient: cfg.FiltersPerClient}
+	if cfg.FiltersRedisAddr != "" {
+		base.setFilterStore(filters.NewRedisFilterStore(cfg.FiltersRedisAddr, filterLimits))
+	} else {
+		base.setFilterStore(filters.NewMemoryFilterStore(filterLimits))
+	}
+	if cfg.SnapshotsDir != "" {
+		if snapshots, err := snapshotsync.OpenBlockSnapshots(cfg.SnapshotsDir); err != nil {
+			log.Warn("Block snapshots are not used", "dir", cfg.SnapshotsDir, "err", err)
+		} else {
+			base.setBlockSnapshots(snapshots)
+			log.Info("Block snapshots opened", "dir", cfg.SnapshotsDir, "blocks", snapshots.BlocksAvailable())
+		}
+	}
 	ethImpl := NewEthAPI(base, db, eth, txPool, mining, cfg.Gascap)
+	ethImpl.EVMTimeout, ethImpl.ReturnDataLimit = cfg.EVMTimeout, cfg.ReturnDataLimit
+	ethImpl.FeeHistoryMaxBlocks = cfg.FeeHistoryMaxBlocks
+	ethImpl.GasPriceConfig.Strategy, ethImpl.GasPriceConfig.Blocks, ethImpl.GasPriceConfig.Percentile = cfg.GpoStrategy, cfg.GpoBlocks, cfg.GpoPercentile
+	ethImpl.GasPriceConfig.MinPrice, ethImpl.GasPriceConfig.MaxPrice = big.NewInt(cfg.GpoMinPrice), big.NewInt(cfg.GpoMaxPrice)
+	ethImpl.startGasPriceOracle(ctx)
+	ethImpl.ProofMaxDepth, ethImpl.ProofTimeout = cfg.ProofMaxDepth, cfg.ProofTimeout
+	ethImpl.LogsMaxRange, ethImpl.LogsMaxResults, ethImpl.LogsTimeout = cfg.LogsMaxRange, cfg.LogsMaxResults, cfg.LogsTimeout
 	erigonImpl := NewErigonAPI(base, db, eth)
-	txpoolImpl := NewTxPoolAPI(base, db, txPool)
+	txpoolImpl := NewTxPoolAPI(base, db, services.NewRemoteTxPool(txPool))
 	netImpl := NewNetAPIImpl(eth)
 	debugImpl := NewPrivateDebugAPI(base, db, cfg.Gascap)
 	traceImpl := NewTraceAPI(base, db, &cfg)
@@ -33,6 +63,10 @@ func APIList(ctx context.Context, db kv.RoDB,
 	dbImpl := NewDBAPIImpl() /* deprecated */
 	adminImpl := NewAdminAPI(eth)
 	parityImpl := NewParityAPIImpl(db)
+	engineImpl := NewEngineAPI(eth)
+	otsImpl := NewOtterscanAPI(base, db)
+	borImpl := NewBorAPI(base, db)
+	healthImpl := NewHealthAPI(db, eth, cfg.HealthMaxSyncLag, cfg.HealthMinPeers)
 
 	for _, enabledAPI := range cfg.API {
 		switch enabledAPI {
@@ -43,6 +77,12 @@ func APIList(ctx context.Context, db kv.RoDB,
 				Service:   EthAPI(ethImpl),
 				Version:   "1.0",
 			})
+			defaultAPIList = append(defaultAPIList, rpc.API{
+				Namespace: "eth",
+				Public:    true,
+				Service:   NewSyncingAPI(base),
+				Version:   "1.0",
+			})
 		case "debug":
 			defaultAPIList = append(defaultAPIList, rpc.API{
 				Namespace: "debug",
@@ -106,8 +146,46 @@ func APIList(ctx context.Context, db kv.RoDB,
 				Service:   ParityAPI(parityImpl),
 				Version:   "1.0",
 			})
+		case "ots":
+			defaultAPIList = append(defaultAPIList, rpc.API{
+				Namespace: "ots",
+				Public:    true,
+				Service:   OtterscanAPI(otsImpl),
+				Version:   "1.0",
+			})
+		case "bor":
+			defaultAPIList = append(defaultAPIList, rpc.API{
+				Namespace: "bor",
+				Public:    true,
+				Service:   BorAPI(borImpl),
+				Version:   "1.0",
+			})
 		}
 	}
 
+	// not in cfg.API: /health and /ready are served regardless of it, health_ methods only if it's listed there
+	defaultAPIList = append(defaultAPIList, rpc.API{
+		Namespace: "health",
+		Public:    false,
+		Service:   HealthAPI(healthImpl),
+		Version:   "1.0",
+	})
+
+	if cfg.AuthRpcEnabled {
+		// not in cfg.API: StartRpcServer serves them only on authenticated endpoint
+		defaultAPIList = append(defaultAPIList, rpc.API{
+			Namespace: "engine",
+			Public:    false,
+			Service:   EngineAPI(engineImpl),
+			Version:   "1.0",
+		}, rpc.API{
+			Namespace:     "erigon",
+			Public:        false,
+			Service:       ErigonAdminAPI(NewErigonAdminAPI(base, db, eth)),
+			Version:       "1.0",
+			Authenticated: true,
+		})
+	}
+
 	return append(defaultAPIList, customAPIList...)
 }
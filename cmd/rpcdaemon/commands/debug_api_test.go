@@ -4,15 +4,26 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"math/big"
 	"testing"
 
+	"github.com/holiman/uint256"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/tracers"
 	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/stretchr/testify/require"
 )
 
 var debugTraceTransactionTests = []struct {
@@ -178,3 +189,232 @@ func TestTraceTransactionNoRefund(t *testing.T) {
 		}
 	}
 }
+
+func TestTraceCall(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewPrivateDebugAPI(NewBaseApi(nil, stateCache, false), db, 5000000)
+	from := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	to := common.HexToAddress("0x5678")
+	// PUSH1 0; SLOAD; PUSH1 0; MSTORE; PUSH1 32; PUSH1 0; RETURN
+	code := hexutil.Bytes{0x60, 0x00, 0x54, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+	stateDiff := map[common.Hash]uint256.Int{{}: *uint256.NewInt(7)}
+	overrides := &ethapi.StateOverrides{to: ethapi.Account{Code: &code, StateDiff: &stateDiff}}
+	args := ethapi.CallArgs{From: &from, To: &to}
+
+	traceCall := func(blockNrOrHash rpc.BlockNumberOrHash, config *tracers.TraceConfig) []byte {
+		var buf bytes.Buffer
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+		if err := api.TraceCall(context.Background(), args, blockNrOrHash, config, stream); err != nil {
+			t.Fatalf("traceCall: %v", err)
+		}
+		if err := stream.Flush(); err != nil {
+			t.Fatalf("error flusing: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	var er ethapi.ExecutionResult
+	if err := json.Unmarshal(traceCall(rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), &tracers.TraceConfig{StateOverrides: overrides}), &er); err != nil {
+		t.Fatalf("parsing result: %v", err)
+	}
+	if er.ReturnValue != common.BigToHash(big.NewInt(7)).Hex()[2:] {
+		t.Errorf("wrong return value %s", er.ReturnValue)
+	}
+	if len(er.StructLogs) != 7 {
+		t.Errorf("wrong number of struct logs %d", len(er.StructLogs))
+	}
+
+	callTracer := "callTracer"
+	var call struct {
+		Type   string         `json:"type"`
+		From   common.Address `json:"from"`
+		To     common.Address `json:"to"`
+		Output hexutil.Bytes  `json:"output"`
+	}
+	if err := json.Unmarshal(traceCall(rpc.BlockNumberOrHashWithNumber(2), &tracers.TraceConfig{Tracer: &callTracer, StateOverrides: overrides}), &call); err != nil {
+		t.Fatalf("parsing callTracer result: %v", err)
+	}
+	if call.Type != "CALL" || call.From != from || call.To != to {
+		t.Errorf("wrong callTracer result %+v", call)
+	}
+	if common.BytesToHash(call.Output) != common.BigToHash(big.NewInt(7)) {
+		t.Errorf("wrong callTracer output %x", call.Output)
+	}
+
+	// balance of sender after block 1 and after block 2 differs, prestateTracer has to see historical state
+	prestateTracer := "prestateTracer"
+	prestate := func(blockNum rpc.BlockNumber) map[common.Address]struct {
+		Balance *hexutil.Big `json:"balance"`
+	} {
+		var res map[common.Address]struct {
+			Balance *hexutil.Big `json:"balance"`
+		}
+		if err := json.Unmarshal(traceCall(rpc.BlockNumberOrHashWithNumber(blockNum), &tracers.TraceConfig{Tracer: &prestateTracer}), &res); err != nil {
+			t.Fatalf("parsing prestateTracer result: %v", err)
+		}
+		return res
+	}
+	balance1, balance2 := prestate(1)[from].Balance, prestate(2)[from].Balance
+	if balance1 == nil || balance2 == nil || balance1.ToInt().Cmp(balance2.ToInt()) <= 0 {
+		t.Errorf("wrong historical balances %v, %v", balance1, balance2)
+	}
+}
+
+func TestStateOfNonCanonicalBlock(t *testing.T) {
+	m, _, orphanedChain := rpcdaemontest.CreateTestSentry(t)
+	api := NewPrivateDebugAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), m.DB, 5000000)
+	orphanedHash := orphanedChain[0].Blocks[0].Hash()
+	from := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+
+	// state is kept only for canonical blocks, so it isn't served for orphaned ones even without requireCanonical
+	for _, requireCanonical := range []bool{false, true} {
+		blockNrOrHash := rpc.BlockNumberOrHashWithHash(orphanedHash, requireCanonical)
+		_, err := api.AccountRange(context.Background(), blockNrOrHash, nil, 1, true, true, nil)
+		require.ErrorIs(t, err, rpchelper.NonCanonicalHashError{Hash: orphanedHash})
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &bytes.Buffer{}, 4096)
+		err = api.TraceCall(context.Background(), ethapi.CallArgs{From: &from}, blockNrOrHash, &tracers.TraceConfig{}, stream)
+		require.ErrorIs(t, err, rpchelper.NonCanonicalHashError{Hash: orphanedHash})
+	}
+
+	_, err := api.AccountRange(context.Background(), rpc.BlockNumberOrHashWithHash(common.Hash{1}, false), nil, 1, true, true, nil)
+	var rpcErr rpc.Error
+	require.True(t, errors.As(err, &rpcErr))
+	require.Equal(t, -32001, rpcErr.ErrorCode(), "EIP-1898 code of unknown block")
+	res, err := api.AccountRange(context.Background(), rpc.BlockNumberOrHashWithHash(m.Genesis.Hash(), true), nil, 1, true, true, nil)
+	require.NoError(t, err)
+	require.Equal(t, m.Genesis.Root().String(), res.Root)
+}
+
+func TestStorageRangeAt(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewPrivateDebugAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, 0)
+	contract := common.HexToAddress("0x537e697c7ab75a26f9ecf0ce810e3154dfcaaf44")
+	ctx := context.Background()
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	blockHash, err := rawdb.ReadCanonicalHash(tx, 10)
+	require.NoError(t, err)
+
+	all, err := api.StorageRangeAt(ctx, blockHash, 0, contract, nil, 1024)
+	require.NoError(t, err)
+	require.Greater(t, len(all.Storage), 1)
+	require.Nil(t, all.NextKey)
+	stateReader := state.NewPlainState(tx, 9) // state before the first transaction of block 10
+	for seckey, entry := range all.Storage {
+		require.Equal(t, crypto.Keccak256Hash(entry.Key[:]), seckey)
+		value, err := stateReader.ReadAccountStorage(contract, 1, entry.Key)
+		require.NoError(t, err)
+		require.Equal(t, common.BytesToHash(value), entry.Value)
+	}
+
+	// pages follow each other in order of hashed locations
+	paged := StorageMap{}
+	var start, last []byte
+	for {
+		page, err := api.StorageRangeAt(ctx, blockHash, 0, contract, start, 1)
+		require.NoError(t, err)
+		require.Len(t, page.Storage, 1)
+		for seckey, entry := range page.Storage {
+			require.Equal(t, 1, bytes.Compare(seckey[:], last))
+			last = common.CopyBytes(seckey[:])
+			paged[seckey] = entry
+		}
+		if page.NextKey == nil {
+			break
+		}
+		start = page.NextKey[:]
+	}
+	require.Equal(t, all.Storage, paged)
+}
+
+func TestAccountRange(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewPrivateDebugAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, 0)
+	ctx := context.Background()
+	blockNrOrHash := rpc.BlockNumberOrHashWithNumber(10)
+
+	all, err := api.AccountRange(ctx, blockNrOrHash, nil, 0, true, true, nil)
+	require.NoError(t, err)
+	require.Greater(t, len(all.Accounts), 2)
+	require.Nil(t, all.Next)
+
+	paged := map[common.Address]state.DumpAccount{}
+	var start AccountRangeKey
+	for {
+		page, err := api.AccountRange(ctx, blockNrOrHash, start, 2, true, true, nil)
+		require.NoError(t, err)
+		for addr, account := range page.Accounts {
+			_, duplicate := paged[addr]
+			require.False(t, duplicate, "pages don't overlap")
+			paged[addr] = account
+		}
+		if page.Next == nil {
+			break
+		}
+		require.Len(t, page.Next, common.AddressLength)
+		start = page.Next
+	}
+	require.Equal(t, all.Accounts, paged)
+
+	// start is accepted both as hex and as base64 `next`
+	var hexKey, base64Key AccountRangeKey
+	require.NoError(t, json.Unmarshal([]byte(`"0x71562b71999873db5b286df957af199ec94617f7"`), &hexKey))
+	next, err := json.Marshal(common.FromHex("0x71562b71999873db5b286df957af199ec94617f7"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(next, &base64Key))
+	require.Equal(t, hexKey, base64Key)
+}
+
+type rawReceipts []hexutil.Bytes
+
+func (rs rawReceipts) Len() int                           { return len(rs) }
+func (rs rawReceipts) EncodeIndex(i int, w *bytes.Buffer) { w.Write(rs[i]) }
+
+func TestGetRawBlockData(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewPrivateDebugAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, 0)
+	ctx := context.Background()
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	var txCount int
+	for number := uint64(0); number <= 10; number++ {
+		block, err := rawdb.ReadBlockByNumber(tx, number)
+		require.NoError(t, err)
+
+		rawHeader, err := api.GetRawHeader(ctx, rpc.BlockNumberOrHashWithHash(block.Hash(), true))
+		require.NoError(t, err)
+		require.Equal(t, block.Hash(), crypto.Keccak256Hash(rawHeader))
+
+		rawBlock, err := api.GetRawBlock(ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number)))
+		require.NoError(t, err)
+		decoded := &types.Block{}
+		require.NoError(t, rlp.DecodeBytes(rawBlock, decoded))
+		require.Equal(t, block.Hash(), decoded.Hash())
+		require.Equal(t, block.Transactions().Len(), decoded.Transactions().Len())
+
+		for _, txn := range block.Transactions() {
+			rawTxn, err := api.GetRawTransaction(ctx, txn.Hash())
+			require.NoError(t, err)
+			decodedTxn, err := types.UnmarshalTransactionFromBinary(rawTxn)
+			require.NoError(t, err)
+			require.Equal(t, txn.Hash(), decodedTxn.Hash())
+			txCount++
+		}
+
+		receipts, err := api.GetRawReceipts(ctx, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number)))
+		require.NoError(t, err)
+		require.Len(t, receipts, block.Transactions().Len())
+		require.Equal(t, block.ReceiptHash(), types.DeriveSha(rawReceipts(receipts)), "block %d", number)
+	}
+	require.NotZero(t, txCount)
+
+	rawTxn, err := api.GetRawTransaction(ctx, common.Hash{1})
+	require.NoError(t, err)
+	require.Nil(t, rawTxn)
+	_, err = api.GetRawBlock(ctx, rpc.BlockNumberOrHashWithNumber(100))
+	require.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// GetRawHeader implements debug_getRawHeader. Returns RLP of the block header, as it is kept in the db
+func (api *PrivateDebugAPIImpl) GetRawHeader(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	number, hash, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	header := rawdb.ReadHeaderRLP(tx, hash, number)
+	if header == nil {
+		return nil, fmt.Errorf("header #%d not found", number)
+	}
+	return hexutil.Bytes(header), nil
+}
+
+// GetRawBlock implements debug_getRawBlock. Returns consensus RLP of the block. Bodies keep only ids of
+// transactions, so the block is assembled and encoded again
+func (api *PrivateDebugAPIImpl) GetRawBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	number, hash, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	block := rawdb.ReadBlock(tx, hash, number)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return rlp.EncodeToBytes(block)
+}
+
+// GetRawTransaction implements debug_getRawTransaction. Returns consensus encoding of the mined transaction
+// (typed envelope for EIP-2718 transactions), nil if the transaction is unknown
+func (api *PrivateDebugAPIImpl) GetRawTransaction(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	txn, _, _, _, err := rawdb.ReadTransaction(tx, hash)
+	if err != nil || txn == nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := txn.MarshalBinary(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GetRawReceipts implements debug_getRawReceipts. Returns consensus encoding of every receipt of the block, receipts
+// are kept only for canonical blocks
+func (api *PrivateDebugAPIImpl) GetRawReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]hexutil.Bytes, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	number, hash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	block, err := api.blockWithSenders(tx, hash, number)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := getReceipts(ctx, tx, chainConfig, block, block.Body().SendersFromTxs())
+	if err != nil {
+		return nil, fmt.Errorf("getReceipts error: %w", err)
+	}
+	result := make([]hexutil.Bytes, len(receipts))
+	for i, receipt := range receipts {
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt}) // bloom isn't kept in the db
+		var buf bytes.Buffer
+		receipts.EncodeIndex(i, &buf)
+		result[i] = buf.Bytes()
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+)
+
+// EngineAPI the interface for the engine_* RPC commands, used by consensus client to drive the node.
+// Served only by the JWT-authenticated endpoint (--authrpc.port)
+type EngineAPI interface {
+	NewPayloadV1(ctx context.Context, payload *services.ExecutionPayload) (*services.PayloadStatus, error)
+	ForkchoiceUpdatedV1(ctx context.Context, state *services.ForkChoiceState, attributes *services.PayloadAttributes) (*services.ForkChoiceUpdatedResult, error)
+	GetPayloadV1(ctx context.Context, payloadID hexutil.Bytes) (*services.ExecutionPayload, error)
+}
+
+// EngineImpl data structure to store things needed for engine_* commands.
+type EngineImpl struct {
+	ethBackend services.ApiBackend
+}
+
+// NewEngineAPI returns EngineImpl instance.
+func NewEngineAPI(eth services.ApiBackend) *EngineImpl {
+	return &EngineImpl{
+		ethBackend: eth,
+	}
+}
+
+// NewPayloadV1 implements engine_newPayloadV1. Validates and executes the block, doesn't change head of the chain.
+func (e *EngineImpl) NewPayloadV1(ctx context.Context, payload *services.ExecutionPayload) (*services.PayloadStatus, error) {
+	if payload == nil {
+		return nil, fmt.Errorf("payload is required")
+	}
+	return e.ethBackend.EngineNewPayloadV1(ctx, payload)
+}
+
+// ForkchoiceUpdatedV1 implements engine_forkchoiceUpdatedV1. Changes head, safe and finalized blocks, and starts building of payload if attributes are given.
+func (e *EngineImpl) ForkchoiceUpdatedV1(ctx context.Context, state *services.ForkChoiceState, attributes *services.PayloadAttributes) (*services.ForkChoiceUpdatedResult, error) {
+	if state == nil {
+		return nil, fmt.Errorf("forkchoice state is required")
+	}
+	return e.ethBackend.EngineForkchoiceUpdatedV1(ctx, state, attributes)
+}
+
+// GetPayloadV1 implements engine_getPayloadV1. Returns payload built since engine_forkchoiceUpdatedV1 returned given id.
+func (e *EngineImpl) GetPayloadV1(ctx context.Context, payloadID hexutil.Bytes) (*services.ExecutionPayload, error) {
+	return e.ethBackend.EngineGetPayloadV1(ctx, payloadID)
+}
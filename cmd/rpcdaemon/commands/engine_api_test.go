@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// emptyPayload - payload of block without transactions on top of `parent`
+func emptyPayload(parent *types.Header) *services.ExecutionPayload {
+	header := &types.Header{
+		ParentHash:  parent.Hash(),
+		UncleHash:   types.EmptyUncleHash,
+		Root:        parent.Root,
+		TxHash:      types.EmptyRootHash,
+		ReceiptHash: types.EmptyRootHash,
+		Difficulty:  new(big.Int),
+		Number:      new(big.Int).Add(parent.Number, big.NewInt(1)),
+		GasLimit:    parent.GasLimit,
+		Time:        parent.Time + 12,
+	}
+	return &services.ExecutionPayload{
+		ParentHash:   header.ParentHash,
+		StateRoot:    header.Root,
+		ReceiptsRoot: header.ReceiptHash,
+		LogsBloom:    make(hexutil.Bytes, types.BloomByteLength),
+		BlockNumber:  hexutil.Uint64(header.Number.Uint64()),
+		GasLimit:     hexutil.Uint64(header.GasLimit),
+		Timestamp:    hexutil.Uint64(header.Time),
+		BlockHash:    header.Hash(),
+		Transactions: []hexutil.Bytes{},
+	}
+}
+
+func TestEngineAPI(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	backend := services.NewRemoteBackend(conn)
+	defer backend.Close()
+	api := NewEngineAPI(backend)
+
+	tx, err := m.DB.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	head := rawdb.ReadCurrentHeader(tx)
+
+	payload := emptyPayload(head)
+	status, err := api.NewPayloadV1(ctx, payload)
+	require.NoError(t, err)
+	require.Equal(t, "ACCEPTED", status.Status)
+
+	orphan := emptyPayload(&types.Header{Number: head.Number, Root: head.Root, GasLimit: head.GasLimit})
+	status, err = api.NewPayloadV1(ctx, orphan)
+	require.NoError(t, err)
+	require.Equal(t, "SYNCING", status.Status)
+
+	payload.BlockHash = common.HexToHash("0x01")
+	status, err = api.NewPayloadV1(ctx, payload)
+	require.NoError(t, err)
+	require.Equal(t, "INVALID_BLOCK_HASH", status.Status)
+
+	updated, err := api.ForkchoiceUpdatedV1(ctx, &services.ForkChoiceState{HeadBlockHash: head.Hash()}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "VALID", updated.PayloadStatus.Status)
+	require.Equal(t, head.Hash(), *updated.PayloadStatus.LatestValidHash)
+	require.Nil(t, updated.PayloadID)
+	updated, err = api.ForkchoiceUpdatedV1(ctx, &services.ForkChoiceState{HeadBlockHash: common.HexToHash("0x01")}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "SYNCING", updated.PayloadStatus.Status)
+
+	_, err = api.GetPayloadV1(ctx, hexutil.Bytes{0, 0, 0, 0, 0, 0, 0, 1})
+	require.ErrorContains(t, err, "unknown payload")
+}
+
+func TestEngineForkChoiceBlockTags(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	backend := services.NewRemoteBackend(conn)
+	defer backend.Close()
+	ff := filters.New(ctx, backend, nil, nil)
+	api := NewEthAPI(NewBaseApi(ff, kvcache.New(kvcache.DefaultCoherentConfig), false), m.DB, backend, nil, nil, 5000000)
+	engine := NewEngineAPI(backend)
+
+	tx, err := m.DB.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	head := rawdb.ReadCurrentHeader(tx)
+	safe, err := rawdb.ReadCanonicalHash(tx, 8)
+	require.NoError(t, err)
+	finalized, err := rawdb.ReadCanonicalHash(tx, 6)
+	require.NoError(t, err)
+
+	state := &services.ForkChoiceState{HeadBlockHash: head.Hash(), SafeBlockHash: safe, FinalizedBlockHash: common.HexToHash("0x01")}
+	_, err = engine.ForkchoiceUpdatedV1(ctx, state, nil)
+	require.ErrorContains(t, err, "invalid forkchoice state")
+
+	state.FinalizedBlockHash = finalized
+	updated, err := engine.ForkchoiceUpdatedV1(ctx, state, nil)
+	require.NoError(t, err)
+	require.Equal(t, "VALID", updated.PayloadStatus.Status)
+	for ff.ForkChoice() == nil { // sent when filters subscribe
+		time.Sleep(10 * time.Millisecond)
+	}
+	block, err := api.GetBlockByNumber(ctx, rpc.FinalizedBlockNumber, false)
+	require.NoError(t, err)
+	require.Equal(t, finalized, block["hash"])
+	block, err = api.GetBlockByNumber(ctx, rpc.SafeBlockNumber, false)
+	require.NoError(t, err)
+	require.Equal(t, safe, block["hash"])
+}
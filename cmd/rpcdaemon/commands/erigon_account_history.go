@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/changeset"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/ethdb/bitmapdb"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// maxBalanceHistoryPoints - max amount of balances erigon_getBalanceHistory returns
+const maxBalanceHistoryPoints = 10_000
+
+// AccountState - fields of account erigon_getBalanceChangesInBlock compares
+type AccountState struct {
+	Balance  *hexutil.Big   `json:"balance"`
+	Nonce    hexutil.Uint64 `json:"nonce"`
+	CodeHash common.Hash    `json:"codeHash"`
+}
+
+// AccountChange - state of account before and after block, nil if it didn't exist
+type AccountChange struct {
+	Before *AccountState `json:"before"`
+	After  *AccountState `json:"after"`
+}
+
+// BalanceAt - balance of account after the block
+type BalanceAt struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Balance     *hexutil.Big   `json:"balance"`
+}
+
+func newAccountState(acc *accounts.Account) *AccountState {
+	if acc == nil {
+		return nil
+	}
+	return &AccountState{Balance: (*hexutil.Big)(acc.Balance.ToBig()), Nonce: hexutil.Uint64(acc.Nonce), CodeHash: acc.CodeHash}
+}
+
+func (s *AccountState) equal(other *AccountState) bool {
+	if s == nil || other == nil {
+		return s == other
+	}
+	return s.Balance.ToInt().Cmp(other.Balance.ToInt()) == 0 && s.Nonce == other.Nonce && s.CodeHash == other.CodeHash
+}
+
+// GetBalanceChangesInBlock implements erigon_getBalanceChangesInBlock. Returns balance, nonce and code hash before and
+// after the block of accounts it changed, only of addr if it's given. Accounts are found in AccountChangeSet of the
+// block, PrunedError if history of the block is pruned (see --prune=h)
+func (api *ErigonImpl) GetBalanceChangesInBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, addr *common.Address) (map[common.Address]*AccountChange, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blockNumber, _, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if blockNumber > 0 {
+		if err = rpchelper.CheckPruned(tx, rpchelper.PrunedHistory, blockNumber-1); err != nil {
+			return nil, err
+		}
+	}
+
+	var changed []common.Address
+	if err = changeset.ForRange(tx, kv.AccountChangeSet, blockNumber, blockNumber+1, func(_ uint64, k, _ []byte) error {
+		if address := common.BytesToAddress(k); addr == nil || address == *addr {
+			changed = append(changed, address)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	before, after := state.NewPlainState(tx, blockNumber-1), state.NewPlainState(tx, blockNumber)
+	result := make(map[common.Address]*AccountChange, len(changed))
+	for _, address := range changed {
+		change := &AccountChange{}
+		if blockNumber > 0 { // accounts of genesis didn't exist before it
+			acc, err := before.ReadAccountData(address)
+			if err != nil {
+				return nil, err
+			}
+			change.Before = newAccountState(acc)
+		}
+		acc, err := after.ReadAccountData(address)
+		if err != nil {
+			return nil, err
+		}
+		change.After = newAccountState(acc)
+		if !change.Before.equal(change.After) {
+			result[address] = change
+		}
+	}
+	return result, nil
+}
+
+// GetBalanceHistory implements erigon_getBalanceHistory. Returns balances of addr after blocks fromBlock, fromBlock+step,
+// ... up to toBlock, or, if step is 0, after fromBlock and each block which changed the account. Balances are read only
+// at blocks with changes since the previous one, found in AccountsHistory index
+func (api *ErigonImpl) GetBalanceHistory(ctx context.Context, addr common.Address, fromBlock rpc.BlockNumber, toBlock rpc.BlockNumber, step uint64) ([]*BalanceAt, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	begin, err := getBlockNumber(fromBlock, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	end, err := getBlockNumber(toBlock, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := getLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	if end < begin || end > latest {
+		return nil, fmt.Errorf("invalid range from %d to %d, latest block %d", begin, end, latest)
+	}
+	if err = rpchelper.CheckPruned(tx, rpchelper.PrunedHistory, begin); err != nil {
+		return nil, err
+	}
+
+	changes, err := bitmapdb.Get64(tx, kv.AccountsHistory, addr[:], begin, end)
+	if err != nil {
+		return nil, err
+	}
+	changes.RemoveRange(0, begin+1) // changes after begin
+	changes.RemoveRange(end+1, math.MaxUint64)
+	var blocks []uint64
+	if step == 0 {
+		if changes.GetCardinality() >= maxBalanceHistoryPoints {
+			return nil, fmt.Errorf("account changed more than %d times in range, narrow it", maxBalanceHistoryPoints-1)
+		}
+		blocks = append([]uint64{begin}, changes.ToArray()...)
+	} else {
+		if (end-begin)/step >= maxBalanceHistoryPoints {
+			return nil, fmt.Errorf("range with step %d has more than %d points, narrow it or increase step", step, maxBalanceHistoryPoints)
+		}
+		for block := begin; ; block += step {
+			blocks = append(blocks, block)
+			if end-block < step {
+				break
+			}
+		}
+	}
+
+	reader := state.NewPlainState(tx, begin)
+	result := make([]*BalanceAt, 0, len(blocks))
+	for i, block := range blocks {
+		if i > 0 && changes.Rank(block) == changes.Rank(blocks[i-1]) { // not changed since the previous point
+			result = append(result, &BalanceAt{BlockNumber: hexutil.Uint64(block), Balance: result[i-1].Balance})
+			continue
+		}
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		reader.SetBlockNr(block)
+		acc, err := reader.ReadAccountData(addr)
+		if err != nil {
+			return nil, err
+		}
+		balance := new(hexutil.Big)
+		if acc != nil {
+			balance = (*hexutil.Big)(acc.Balance.ToBig())
+		}
+		result = append(result, &BalanceAt{BlockNumber: hexutil.Uint64(block), Balance: balance})
+	}
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// ErigonAdminAPI - erigon_ methods changing the node, served only on JWT-authenticated endpoint (--authrpc)
+type ErigonAdminAPI interface {
+	// RebuildIndex starts rebuild of index of blocks [fromBlock, toBlock] in background: tx-lookup, log-index or
+	// call-trace-index. Returns the rebuild with range adjusted by the node, its progress is reported by IndexRebuilds
+	RebuildIndex(ctx context.Context, name string, fromBlock, toBlock rpc.BlockNumber) (*services.IndexRebuild, error)
+
+	// IndexRebuilds returns the latest rebuild of each index since start of the node
+	IndexRebuilds(ctx context.Context) ([]*services.IndexRebuild, error)
+}
+
+// ErigonAdminImpl is implementation of the ErigonAdminAPI interface
+type ErigonAdminImpl struct {
+	*BaseAPI
+	db         kv.RoDB
+	ethBackend services.ApiBackend
+}
+
+// NewErigonAdminAPI returns ErigonAdminImpl instance
+func NewErigonAdminAPI(base *BaseAPI, db kv.RoDB, eth services.ApiBackend) *ErigonAdminImpl {
+	return &ErigonAdminImpl{
+		BaseAPI:    base,
+		db:         db,
+		ethBackend: eth,
+	}
+}
+
+// RebuildIndex implements erigon_rebuildIndex. Returns once the rebuild is started
+func (api *ErigonAdminImpl) RebuildIndex(ctx context.Context, name string, fromBlock, toBlock rpc.BlockNumber) (*services.IndexRebuild, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	from, err := getBlockNumber(fromBlock, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	to, err := getBlockNumber(toBlock, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	tx.Rollback()
+	return api.ethBackend.RebuildIndex(ctx, name, from, to)
+}
+
+// IndexRebuilds implements erigon_indexRebuilds
+func (api *ErigonAdminImpl) IndexRebuilds(ctx context.Context) ([]*services.IndexRebuild, error) {
+	return api.ethBackend.IndexRebuilds(ctx)
+}
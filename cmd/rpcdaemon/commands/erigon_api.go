@@ -6,6 +6,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/p2p"
 	"github.com/ledgerwatch/erigon/rpc"
@@ -15,21 +16,41 @@ import (
 type ErigonAPI interface {
 	// System related (see ./erigon_system.go)
 	Forks(ctx context.Context) (Forks, error)
+	SyncStatus(ctx context.Context) (*SyncStatus, error)
 
 	// Blocks related (see ./erigon_blocks.go)
 	GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error)
 	GetHeaderByHash(_ context.Context, hash common.Hash) (*types.Header, error)
-	GetBlockByTimestamp(ctx context.Context, timeStamp rpc.Timestamp, fullTx bool) (map[string]interface{}, error)
+	GetBlockByTimestamp(ctx context.Context, timeStamp rpc.Timestamp, fullTx bool, closest *string) (map[string]interface{}, error)
+	Reorgs(ctx context.Context) (*rpc.Subscription, error)
+	ForkChoice(ctx context.Context) (*rpc.Subscription, error)
+	GetBlobSidecars(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*services.BlobSidecar, error)
 
 	// Receipt related (see ./erigon_receipts.go)
 	GetLogsByHash(ctx context.Context, hash common.Hash) ([][]*types.Log, error)
+	NewReceipts(ctx context.Context, opts *NewReceiptsOptions) (*rpc.Subscription, error)
 	//GetLogsByNumber(ctx context.Context, number rpc.BlockNumber) ([][]*types.Log, error)
 
+	// Account history related (see ./erigon_account_history.go)
+	GetBalanceChangesInBlock(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, addr *common.Address) (map[common.Address]*AccountChange, error)
+	GetBalanceHistory(ctx context.Context, addr common.Address, fromBlock rpc.BlockNumber, toBlock rpc.BlockNumber, step uint64) ([]*BalanceAt, error)
+
+	// Transaction related (see ./erigon_transactions.go)
+	GetTransactionBySenderAndNonce(ctx context.Context, addr common.Address, nonce uint64) (*RPCTransaction, error)
+	GetInternalTransactions(ctx context.Context, target TxHashOrBlockNumber) ([]*InternalTransaction, error)
+
+	// Token related (see ./erigon_tokens.go)
+	GetTokenTransfers(ctx context.Context, addr common.Address, fromBlock rpc.BlockNumber, toBlock rpc.BlockNumber, page uint64) (*TokenTransfers, error)
+	GetTokenBalanceAt(ctx context.Context, token common.Address, holder common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error)
+
 	// WatchTheBurn / reward related (see ./erigon_issuance.go)
 	WatchTheBurn(ctx context.Context, blockNr rpc.BlockNumber) (Issuance, error)
 
 	// NodeInfo returns a collection of metadata known about the host.
 	NodeInfo(ctx context.Context) ([]p2p.NodeInfo, error)
+
+	// PruneInfo returns prune mode and the earliest blocks with data of each kind (see ./erigon_pruneInfo.go)
+	PruneInfo(ctx context.Context) (*PruneInfo, error)
 }
 
 // ErigonImpl is implementation of the ErigonAPI interface
@@ -3,14 +3,21 @@ package commands
 import (
 	"context"
 	"fmt"
+	"math"
 	"sort"
 
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
 	"github.com/ledgerwatch/erigon/internal/ethapi"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/log/v3"
 )
 
 // GetHeaderByNumber implements erigon_getHeaderByNumber. Returns a block's header given a block number ignoring the block's transaction and uncle list (may be faster).
@@ -30,7 +37,7 @@ func (api *ErigonImpl) GetHeaderByNumber(ctx context.Context, blockNumber rpc.Bl
 	}
 	defer tx.Rollback()
 
-	blockNum, err := getBlockNumber(blockNumber, tx)
+	blockNum, err := getBlockNumber(blockNumber, tx, api.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -62,62 +69,74 @@ func (api *ErigonImpl) GetHeaderByHash(ctx context.Context, hash common.Hash) (*
 	return header, nil
 }
 
-func (api *ErigonImpl) GetBlockByTimestamp(ctx context.Context, timeStamp rpc.Timestamp, fullTx bool) (map[string]interface{}, error) {
+// Modes of erigon_getBlockByTimestamp, which block is returned if there is none with given timestamp
+const (
+	ClosestBefore  = "before"  // the latest block before, genesis if timestamp is before it. The default
+	ClosestAfter   = "after"   // the earliest block after, null if timestamp is after the head
+	ClosestNearest = "nearest" // nearer of the two, the earlier one if they are equally far
+)
+
+// GetBlockByTimestamp implements erigon_getBlockByTimestamp. Returns block with given timestamp, `closest` one (see
+// ClosestBefore, ClosestAfter, ClosestNearest) if there is no such block
+func (api *ErigonImpl) GetBlockByTimestamp(ctx context.Context, timeStamp rpc.Timestamp, fullTx bool, closest *string) (map[string]interface{}, error) {
+	mode := ClosestBefore
+	if closest != nil {
+		mode = *closest
+	}
+	if mode != ClosestBefore && mode != ClosestAfter && mode != ClosestNearest {
+		return nil, fmt.Errorf("invalid closest %q, expected %q, %q or %q", mode, ClosestBefore, ClosestAfter, ClosestNearest)
+	}
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	uintTimestamp := timeStamp.TurnIntoUint64()
+	blockNum, found, err := blockNumberByTimestamp(tx, timeStamp.TurnIntoUint64(), mode)
+	if err != nil || !found {
+		return nil, err
+	}
+	return buildBlockResponse(tx, blockNum, fullTx)
+}
 
+// blockNumberByTimestamp - binary search over timestamps of canonical headers up to the current one, they only grow
+func blockNumberByTimestamp(tx kv.Tx, timestamp uint64, mode string) (uint64, bool, error) {
 	currentHeader := rawdb.ReadCurrentHeader(tx)
-	currenttHeaderTime := currentHeader.Time
-	highestNumber := currentHeader.Number.Uint64()
-
-	firstHeader := rawdb.ReadHeaderByNumber(tx, 0)
-	firstHeaderTime := firstHeader.Time
-
-	if currenttHeaderTime <= uintTimestamp {
-		blockResponse, err := buildBlockResponse(tx, highestNumber, fullTx)
-		if err != nil {
-			return nil, err
-		}
-
-		return blockResponse, nil
+	if currentHeader == nil {
+		return 0, false, nil
 	}
-
-	if firstHeaderTime >= uintTimestamp {
-		blockResponse, err := buildBlockResponse(tx, 0, fullTx)
-		if err != nil {
-			return nil, err
+	highest := currentHeader.Number.Uint64()
+	var searchErr error
+	timeOf := func(blockNum uint64) uint64 {
+		header := rawdb.ReadHeaderByNumber(tx, blockNum)
+		if header == nil {
+			if searchErr == nil {
+				searchErr = fmt.Errorf("block header not found: %d", blockNum)
+			}
+			return math.MaxUint64
 		}
-
-		return blockResponse, nil
+		return header.Time
 	}
-
-	blockNum := sort.Search(int(currentHeader.Number.Uint64()), func(blockNum int) bool {
-		currentHeader := rawdb.ReadHeaderByNumber(tx, uint64(blockNum))
-
-		return currentHeader.Time >= uintTimestamp
-	})
-
-	resultingHeader := rawdb.ReadHeaderByNumber(tx, uint64(blockNum))
-
-	if resultingHeader.Time > uintTimestamp {
-		response, err := buildBlockResponse(tx, uint64(blockNum)-1, fullTx)
-		if err != nil {
-			return nil, err
-		}
-		return response, nil
+	// the first block not before timestamp, highest+1 if there is none
+	after := uint64(sort.Search(int(highest+1), func(i int) bool { return timeOf(uint64(i)) >= timestamp }))
+	if searchErr != nil {
+		return 0, false, searchErr
 	}
-
-	response, err := buildBlockResponse(tx, uint64(blockNum), fullTx)
-	if err != nil {
-		return nil, err
+	if after <= highest && timeOf(after) == timestamp {
+		return after, true, searchErr
+	}
+	switch {
+	case mode == ClosestAfter:
+		return after, after <= highest, nil
+	case after == 0:
+		return 0, true, nil
+	case after > highest:
+		return highest, true, nil
+	case mode == ClosestNearest && timeOf(after)-timestamp < timestamp-timeOf(after-1):
+		return after, true, searchErr
+	default:
+		return after - 1, true, searchErr
 	}
-
-	return response, nil
 }
 
 func buildBlockResponse(db kv.Tx, blockNum uint64, fullTx bool) (map[string]interface{}, error) {
@@ -140,3 +159,139 @@ func buildBlockResponse(db kv.Tx, blockNum uint64, fullTx bool) (map[string]inte
 	}
 	return response, err
 }
+
+// BlockRef - number and hash of block in reorgs notification
+type BlockRef struct {
+	Number hexutil.Uint64 `json:"number"`
+	Hash   common.Hash    `json:"hash"`
+}
+
+// ReorgResult - notification of erigon_subscribe("reorgs"): blocks after CommonAncestor up to OldHead were replaced by
+// blocks up to NewHead. Logs of removed blocks are sent to logs subscriptions with removed: true
+type ReorgResult struct {
+	OldHead        BlockRef `json:"oldHead"`
+	NewHead        BlockRef `json:"newHead"`
+	CommonAncestor BlockRef `json:"commonAncestor"`
+}
+
+func newBlockRef(ref privateapi.BlockRef) BlockRef {
+	return BlockRef{Number: hexutil.Uint64(ref.Number), Hash: ref.Hash}
+}
+
+// Reorgs send a notification each time canonical chain changes
+func (api *ErigonImpl) Reorgs(ctx context.Context) (*rpc.Subscription, error) {
+	if api.filters == nil {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		reorgs := make(chan *privateapi.Reorg, 1)
+		defer close(reorgs)
+		id := api.filters.SubscribeReorgs(reorgs)
+		defer api.filters.UnsubscribeReorgs(id)
+
+		for {
+			select {
+			case reorg := <-reorgs:
+				err := notifier.Notify(rpcSub.ID, &ReorgResult{
+					OldHead:        newBlockRef(reorg.OldHead),
+					NewHead:        newBlockRef(reorg.NewHead),
+					CommonAncestor: newBlockRef(reorg.CommonAncestor),
+				})
+				if err != nil {
+					log.Warn("error while notifying subscription", "err", err)
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// ForkChoiceResult - notification of erigon_subscribe("forkChoice"): head, safe and finalized blocks chosen by consensus
+// layer, safe and finalized are null until it sets them
+type ForkChoiceResult struct {
+	Head      BlockRef  `json:"head"`
+	Safe      *BlockRef `json:"safe"`
+	Finalized *BlockRef `json:"finalized"`
+}
+
+func newForkChoiceResult(forkChoice *privateapi.ForkChoice) *ForkChoiceResult {
+	result := &ForkChoiceResult{Head: newBlockRef(forkChoice.Head)}
+	if forkChoice.Safe != nil {
+		safe := newBlockRef(*forkChoice.Safe)
+		result.Safe = &safe
+	}
+	if forkChoice.Finalized != nil {
+		finalized := newBlockRef(*forkChoice.Finalized)
+		result.Finalized = &finalized
+	}
+	return result
+}
+
+// ForkChoice send a notification with the latest fork choice, if there is one, and then each time consensus layer
+// changes it, so clients can track blocks becoming safe and finalized
+func (api *ErigonImpl) ForkChoice(ctx context.Context) (*rpc.Subscription, error) {
+	if api.filters == nil {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		forkChoices := make(chan *privateapi.ForkChoice, 1)
+		id := api.filters.SubscribeForkChoice(forkChoices)
+		defer api.filters.UnsubscribeForkChoice(id)
+
+		if latest := api.filters.ForkChoice(); latest != nil {
+			if err := notifier.Notify(rpcSub.ID, newForkChoiceResult(latest)); err != nil {
+				log.Warn("error while notifying subscription", "err", err)
+			}
+		}
+		for {
+			select {
+			case forkChoice := <-forkChoices:
+				if err := notifier.Notify(rpcSub.ID, newForkChoiceResult(forkChoice)); err != nil {
+					log.Warn("error while notifying subscription", "err", err)
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// GetBlobSidecars implements erigon_getBlobSidecars. Returns blobs of EIP-4844 transactions of the block with their KZG
+// commitments and proofs, null if node doesn't have them. Erigon doesn't store blobs, node keeps in memory only
+// sidecars of the latest blocks which consensus layer passed to it
+func (api *ErigonImpl) GetBlobSidecars(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*services.BlobSidecar, error) {
+	if api.ethBackend == nil {
+		return nil, fmt.Errorf("blob sidecars are not available")
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	_, hash, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	return api.ethBackend.BlobSidecars(ctx, hash)
+}
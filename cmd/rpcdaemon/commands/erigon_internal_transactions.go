@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// TxHashOrBlockNumber - parameter of erigon_getInternalTransactions, hash of transaction or number (tag) of block
+type TxHashOrBlockNumber struct {
+	TxHash      *common.Hash
+	BlockNumber *rpc.BlockNumber
+}
+
+func (p *TxHashOrBlockNumber) UnmarshalJSON(data []byte) error {
+	var input string
+	if err := json.Unmarshal(data, &input); err == nil && len(input) == 66 {
+		var hash common.Hash
+		if err := hash.UnmarshalText([]byte(input)); err != nil {
+			return err
+		}
+		p.TxHash = &hash
+		return nil
+	}
+	var number rpc.BlockNumber
+	if err := number.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	p.BlockNumber = &number
+	return nil
+}
+
+// InternalTransaction - ETH moved by nested call, contract creation or self-destruct of transaction
+type InternalTransaction struct {
+	Type        string         `json:"type"` // call, create, create2 or selfdestruct
+	Depth       int            `json:"depth"`
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	Value       *hexutil.Big   `json:"value"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     hexutil.Uint64 `json:"transactionIndex"`
+}
+
+// internalTransfersTracer - collects value-bearing nested frames of transaction which didn't revert
+type internalTransfersTracer struct {
+	DefaultTracer
+	results []*InternalTransaction
+	frames  []int // len(results) at start of each open frame
+}
+
+func (t *internalTransfersTracer) CaptureStart(depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) error {
+	t.frames = append(t.frames, len(t.results))
+	if depth == 0 || value.Sign() <= 0 {
+		return nil
+	}
+	var typ string
+	switch callType {
+	case vm.CALLT:
+		typ = "call"
+	case vm.CREATET:
+		typ = "create"
+	case vm.CREATE2T:
+		typ = "create2"
+	default: // CALLCODE keeps value in the caller
+		return nil
+	}
+	t.results = append(t.results, &InternalTransaction{Type: typ, Depth: depth, From: from, To: to, Value: (*hexutil.Big)(new(big.Int).Set(value))})
+	return nil
+}
+
+func (t *internalTransfersTracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64, d time.Duration, err error) error {
+	if len(t.frames) == 0 {
+		return nil
+	}
+	start := t.frames[len(t.frames)-1]
+	t.frames = t.frames[:len(t.frames)-1]
+	if err != nil { // transfers of reverted frame and of its children are undone
+		t.results = t.results[:start]
+	}
+	return nil
+}
+
+func (t *internalTransfersTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+	if value.Sign() <= 0 {
+		return
+	}
+	t.results = append(t.results, &InternalTransaction{Type: "selfdestruct", Depth: len(t.frames), From: from, To: to, Value: (*hexutil.Big)(new(big.Int).Set(value))})
+}
+
+// GetInternalTransactions implements erigon_getInternalTransactions. Returns ETH transfers of nested calls, contract
+// creations and self-destructs of the transaction or of all transactions of the block, without ones of reverted frames.
+// Transactions are re-executed, results about blocks below --rpc.responsecache.depth are kept in the response cache
+func (api *ErigonImpl) GetInternalTransactions(ctx context.Context, target TxHashOrBlockNumber) ([]*InternalTransaction, error) {
+	var cacheKey string
+	if target.TxHash != nil {
+		cacheKey = "erigon_getInternalTransactions/" + target.TxHash.Hex()
+	} else if target.BlockNumber != nil && *target.BlockNumber >= 0 {
+		cacheKey = fmt.Sprintf("erigon_getInternalTransactions/%d", *target.BlockNumber)
+	}
+	var cacheGen uint64
+	if cacheKey != "" {
+		cached, gen, ok := api.responseCache.get(cacheKey)
+		if ok {
+			return cached.([]*InternalTransaction), nil
+		}
+		cacheGen = gen
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var block *types.Block
+	txIndex := -1
+	if target.TxHash != nil {
+		txn, blockHash, blockNum, idx, err := rawdb.ReadTransaction(tx, *target.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		if txn == nil {
+			if err = txIndexPruned(tx, *target.TxHash); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("transaction %#x not found", *target.TxHash)
+		}
+		if block, err = api.blockWithSenders(tx, blockHash, blockNum); err != nil {
+			return nil, err
+		}
+		txIndex = int(idx)
+	} else if target.BlockNumber != nil {
+		blockNum, err := getBlockNumber(*target.BlockNumber, tx, api.filters)
+		if err != nil {
+			return nil, err
+		}
+		if block, err = api.blockByNumberWithSenders(tx, blockNum); err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, fmt.Errorf("transaction hash or block number expected")
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found")
+	}
+
+	result, err := api.internalTransactions(ctx, tx, block, txIndex)
+	if err != nil {
+		return nil, err
+	}
+	if cacheKey != "" {
+		api.responseCache.add(tx, cacheGen, cacheKey, block.NumberU64(), result)
+	}
+	return result, nil
+}
+
+// internalTransactions - internal transactions of the transaction of block at txIndex, of all of them if it's -1
+func (api *ErigonImpl) internalTransactions(ctx context.Context, tx kv.Tx, block *types.Block, txIndex int) ([]*InternalTransaction, error) {
+	result := []*InternalTransaction{}
+	if len(block.Transactions()) == 0 {
+		return result, nil
+	}
+	err := api.traceBlockTxs(ctx, tx, block, func(int) vm.Tracer {
+		return &internalTransfersTracer{}
+	}, func(idx int, tracer vm.Tracer) bool {
+		if txIndex < 0 || idx == txIndex {
+			txHash := block.Transactions()[idx].Hash()
+			for _, internal := range tracer.(*internalTransfersTracer).results {
+				internal.BlockNumber, internal.TxHash, internal.TxIndex = hexutil.Uint64(block.NumberU64()), txHash, hexutil.Uint64(idx)
+				result = append(result, internal)
+			}
+		}
+		return txIndex < 0 || idx < txIndex
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
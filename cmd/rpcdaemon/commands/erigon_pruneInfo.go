@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// PruneInfo - prune mode of the node and the first block of which it keeps each kind of data, so clients can send
+// queries about older blocks to archive nodes
+type PruneInfo struct {
+	Mode           string                    `json:"mode"`           // prune flags of the node, "archive" if nothing is pruned
+	EarliestBlocks map[string]hexutil.Uint64 `json:"earliestBlocks"` // by kind: history, receipts, txIndex, callTraces
+}
+
+// PruneInfo implements erigon_pruneInfo. Data about blocks before the earliest ones is answered with error code 4444
+func (api *ErigonImpl) PruneInfo(ctx context.Context) (*PruneInfo, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	pm, earliest, err := rpchelper.EarliestAvailableBlocks(tx)
+	if err != nil {
+		return nil, err
+	}
+	info := &PruneInfo{Mode: "archive", EarliestBlocks: make(map[string]hexutil.Uint64, len(earliest))}
+	if pm.History.Enabled() || pm.Receipts.Enabled() || pm.TxIndex.Enabled() || pm.CallTraces.Enabled() {
+		info.Mode = pm.String()
+	}
+	for kind, block := range earliest {
+		info.EarliestBlocks[kind] = hexutil.Uint64(block)
+	}
+	return info, nil
+}
@@ -4,10 +4,29 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/debug"
+	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/log/v3"
 )
 
+// NewReceiptsOptions - options of erigon_subscribe("newReceipts"), logs of receipts are sent only if IncludeLogs is set
+type NewReceiptsOptions struct {
+	IncludeLogs bool `json:"includeLogs"`
+}
+
+// NewReceiptsResult - notification of erigon_subscribe("newReceipts"), receipts of the block in eth_getTransactionReceipt
+// format
+type NewReceiptsResult struct {
+	BlockNumber hexutil.Uint64           `json:"blockNumber"`
+	BlockHash   common.Hash              `json:"blockHash"`
+	Receipts    []map[string]interface{} `json:"receipts"`
+}
+
 // GetLogsByHash implements erigon_getLogsByHash. Returns an array of arrays of logs generated by the transactions in the block given by the block's hash.
 func (api *ErigonImpl) GetLogsByHash(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
 	tx, err := api.db.BeginRo(ctx)
@@ -40,6 +59,84 @@ func (api *ErigonImpl) GetLogsByHash(ctx context.Context, hash common.Hash) ([][
 	return logs, nil
 }
 
+// NewReceipts send a notification with receipts of all transactions of each new canonical block once it's executed.
+// Receipts are read by Erigon, which streams them only while there are subscribers
+func (api *ErigonImpl) NewReceipts(ctx context.Context, opts *NewReceiptsOptions) (*rpc.Subscription, error) {
+	if api.filters == nil {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	includeLogs := opts != nil && opts.IncludeLogs
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		receipts := make(chan *privateapi.BlockReceipts, 8)
+		id := api.filters.SubscribeReceipts(receipts)
+		defer api.filters.UnsubscribeReceipts(id)
+
+		for {
+			select {
+			case blockReceipts := <-receipts:
+				var result *NewReceiptsResult
+				if err := api.db.View(context.Background(), func(tx kv.Tx) (err error) { // ctx ends with the subscribe call
+					result, err = api.newReceiptsResult(tx, blockReceipts, includeLogs)
+					return err
+				}); err != nil {
+					log.Warn("error while reading block of receipts", "block", blockReceipts.Number, "err", err)
+					continue
+				}
+				if result == nil { // reorged meanwhile
+					continue
+				}
+				if err := notifier.Notify(rpcSub.ID, result); err != nil {
+					log.Warn("error while notifying subscription", "err", err)
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// newReceiptsResult - notification about receipts, nil if their block is not in db any more
+func (api *ErigonImpl) newReceiptsResult(tx kv.Tx, blockReceipts *privateapi.BlockReceipts, includeLogs bool) (*NewReceiptsResult, error) {
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	block, err := api.blockWithSenders(tx, blockReceipts.Hash, blockReceipts.Number)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	txs := block.Transactions()
+	if len(blockReceipts.Receipts) != len(txs) {
+		return nil, fmt.Errorf("block %d has %d transactions, but %d receipts", blockReceipts.Number, len(txs), len(blockReceipts.Receipts))
+	}
+	result := &NewReceiptsResult{
+		BlockNumber: hexutil.Uint64(block.NumberU64()),
+		BlockHash:   block.Hash(),
+		Receipts:    make([]map[string]interface{}, 0, len(blockReceipts.Receipts)),
+	}
+	for i, receipt := range blockReceipts.Receipts {
+		fields := marshalReceipt(receipt, txs[i], chainConfig, block)
+		if !includeLogs {
+			delete(fields, "logs")
+		}
+		result.Receipts = append(result.Receipts, fields)
+	}
+	return result, nil
+}
+
 // GetLogsByNumber implements erigon_getLogsByHash. Returns all the logs that appear in a block given the block's hash.
 // func (api *ErigonImpl) GetLogsByNumber(ctx context.Context, number rpc.BlockNumber) ([][]*types.Log, error) {
 // 	tx, err := api.db.Begin(ctx, false)
@@ -3,6 +3,7 @@ package commands
 import (
 	"context"
 
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/core/forkid"
 )
@@ -29,3 +30,19 @@ func (api *ErigonImpl) Forks(ctx context.Context) (Forks, error) {
 
 	return Forks{genesis.Hash(), forksBlocks}, nil
 }
+
+// SyncStatus - progress of staged sync, reported even after it's done
+type SyncStatus struct {
+	Syncing      bool   `json:"syncing"`
+	CurrentStage string `json:"currentStage"` // empty if all stages reached the highest block
+	*services.SyncProgress
+}
+
+// SyncStatus implements erigon_syncStatus. Returns progress of each stage and the stage which is lagging
+func (api *ErigonImpl) SyncStatus(ctx context.Context) (*SyncStatus, error) {
+	progress, err := syncProgress(ctx, api.db, api.ethBackend)
+	if err != nil {
+		return nil, err
+	}
+	return &SyncStatus{Syncing: progress.Syncing(), CurrentStage: progress.CurrentStage(), SyncProgress: progress}, nil
+}
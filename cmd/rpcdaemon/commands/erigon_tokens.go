@@ -0,0 +1,231 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/RoaringBitmap/roaring"
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/ethdb"
+	"github.com/ledgerwatch/erigon/ethdb/cbor"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/transactions"
+)
+
+// tokenTransfersPageSize - transfers in a page of erigon_getTokenTransfers
+const tokenTransfersPageSize = 100
+
+// tokenBalanceGas - gas of balanceOf call of erigon_getTokenBalanceAt
+const tokenBalanceGas = 1_000_000
+
+// transferTopic - Transfer(address,address,uint256) event of ERC-20 and ERC-721, tokenId of the last one is indexed
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// balanceOfSelector - balanceOf(address) of ERC-20 and ERC-721
+var balanceOfSelector = crypto.Keccak256([]byte("balanceOf(address)"))[:4]
+
+// TokenTransfer - Transfer event of ERC-20 (Value is set) or ERC-721 (TokenID is set) token
+type TokenTransfer struct {
+	Token       common.Address `json:"token"`
+	From        common.Address `json:"from"`
+	To          common.Address `json:"to"`
+	Value       *hexutil.Big   `json:"value,omitempty"`
+	TokenID     *hexutil.Big   `json:"tokenId,omitempty"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     hexutil.Uint   `json:"transactionIndex"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`
+}
+
+// TokenTransfers - page of erigon_getTokenTransfers, NextPage is nil if it's the last one
+type TokenTransfers struct {
+	Transfers []*TokenTransfer `json:"transfers"`
+	NextPage  *uint64          `json:"nextPage"`
+}
+
+// newTokenTransfer - nil if the log is not a Transfer event of ERC-20 or ERC-721
+func newTokenTransfer(log *types.Log) *TokenTransfer {
+	if len(log.Topics) < 3 || log.Topics[0] != transferTopic {
+		return nil
+	}
+	transfer := &TokenTransfer{
+		Token: log.Address,
+		From:  common.BytesToAddress(log.Topics[1][:]),
+		To:    common.BytesToAddress(log.Topics[2][:]),
+	}
+	switch {
+	case len(log.Topics) == 3 && len(log.Data) == 32:
+		transfer.Value = (*hexutil.Big)(new(big.Int).SetBytes(log.Data))
+	case len(log.Topics) == 4 && len(log.Data) == 0:
+		transfer.TokenID = (*hexutil.Big)(new(big.Int).SetBytes(log.Topics[3][:]))
+	default:
+		return nil
+	}
+	return transfer
+}
+
+// GetTokenTransfers implements erigon_getTokenTransfers. Returns transfers of ERC-20 and ERC-721 tokens from or to addr
+// in blocks [fromBlock, toBlock], oldest first, by pages of tokenTransfersPageSize starting with 0. Blocks are found by
+// LogTopicIndex, which indexes holders of Transfer events as topics, PrunedError if receipts of fromBlock are pruned
+func (api *ErigonImpl) GetTokenTransfers(ctx context.Context, addr common.Address, fromBlock rpc.BlockNumber, toBlock rpc.BlockNumber, page uint64) (*TokenTransfers, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	begin, err := getBlockNumber(fromBlock, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	end, err := getBlockNumber(toBlock, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	if end < begin {
+		return nil, fmt.Errorf("end (%d) < begin (%d)", end, begin)
+	}
+	if err = rpchelper.CheckPruned(tx, rpchelper.PrunedReceipts, begin); err != nil {
+		return nil, err
+	}
+
+	holder := common.BytesToHash(addr[:])
+	topicsBitmap, err := getTopicsBitmap(tx, [][]common.Hash{{transferTopic}, {holder}}, uint32(begin), uint32(end))
+	if err != nil {
+		return nil, err
+	}
+	blockNumbers := roaring.New()
+	blockNumbers.AddRange(begin, end+1) // [min,max)
+	blockNumbers.And(topicsBitmap)
+
+	skip := page * tokenTransfersPageSize
+	result := &TokenTransfers{Transfers: []*TokenTransfer{}}
+	iter := blockNumbers.Iterator()
+	for iter.HasNext() {
+		if err = libcommon.Stopped(ctx.Done()); err != nil {
+			return nil, err
+		}
+		blockNum := uint64(iter.Next())
+		transfers, err := api.blockTokenTransfers(tx, blockNum, holder)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(transfers)) <= skip {
+			skip -= uint64(len(transfers))
+			continue
+		}
+		transfers = transfers[skip:]
+		skip = 0
+		if len(result.Transfers)+len(transfers) > tokenTransfersPageSize {
+			result.Transfers = append(result.Transfers, transfers[:tokenTransfersPageSize-len(result.Transfers)]...)
+			next := page + 1
+			result.NextPage = &next
+			break
+		}
+		result.Transfers = append(result.Transfers, transfers...)
+	}
+	return result, nil
+}
+
+// blockTokenTransfers - transfers of the block from or to holder, in order of logs
+func (api *ErigonImpl) blockTokenTransfers(tx kv.Tx, blockNum uint64, holder common.Hash) ([]*TokenTransfer, error) {
+	var transfers []*TokenTransfer
+	var logIndex uint
+	if err := tx.ForPrefix(kv.Log, dbutils.EncodeBlockNumber(blockNum), func(k, v []byte) error {
+		var logs types.Logs
+		if err := cbor.Unmarshal(&logs, bytes.NewReader(v)); err != nil {
+			return fmt.Errorf("receipt unmarshal failed:  %w", err)
+		}
+		txIndex := uint(binary.BigEndian.Uint32(k[8:]))
+		for _, log := range logs {
+			if transfer := newTokenTransfer(log); transfer != nil && (log.Topics[1] == holder || log.Topics[2] == holder) {
+				transfer.TxIndex, transfer.LogIndex = hexutil.Uint(txIndex), hexutil.Uint(logIndex)
+				transfers = append(transfers, transfer)
+			}
+			logIndex++
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(transfers) == 0 {
+		return nil, nil
+	}
+	block, err := api.blockByNumberWithSenders(tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found %d", blockNum)
+	}
+	for _, transfer := range transfers {
+		transfer.BlockNumber = hexutil.Uint64(blockNum)
+		transfer.BlockHash = block.Hash()
+		transfer.TxHash = block.Transactions()[transfer.TxIndex].Hash()
+	}
+	return transfers, nil
+}
+
+// GetTokenBalanceAt implements erigon_getTokenBalanceAt. Returns balanceOf(holder) of ERC-20 or ERC-721 token at the
+// state of the block, PrunedError if history of the block is pruned
+func (api *ErigonImpl) GetTokenBalanceAt(ctx context.Context, token common.Address, holder common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	block, err := api.blockWithSenders(tx, hash, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block not found %d", blockNumber)
+	}
+	stateReader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, api.filters, api.stateCache)
+	if err != nil {
+		return nil, err
+	}
+	contractHasTEVM := func(contractHash common.Hash) (bool, error) { return false, nil }
+	if api.TevmEnabled {
+		contractHasTEVM = ethdb.GetHasTEVM(tx)
+	}
+
+	gas := hexutil.Uint64(tokenBalanceGas)
+	input := hexutil.Bytes(append(append([]byte{}, balanceOfSelector...), common.BytesToHash(holder[:]).Bytes()...))
+	args := ethapi.CallArgs{To: &token, Gas: &gas, Data: &input}
+	result, err := transactions.DoCallWithState(ctx, args, tx, stateReader, blockNrOrHash.RequireCanonical, block, nil, tokenBalanceGas, transactions.DefaultCallTimeout, chainConfig, contractHasTEVM)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Revert()) > 0 {
+		return nil, api.revertDecoder.NewRevertError(result)
+	}
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	if len(result.ReturnData) != 32 {
+		return nil, fmt.Errorf("balanceOf of %x returned %d bytes, it's not ERC-20 or ERC-721 token", token, len(result.ReturnData))
+	}
+	return (*hexutil.Big)(new(big.Int).SetBytes(result.ReturnData)), nil
+}
@@ -0,0 +1,24 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon/common"
+)
+
+// GetTransactionBySenderAndNonce implements erigon_getTransactionBySenderAndNonce. Returns mined transaction sent by addr
+// with given nonce, which may be a replacement of the one the caller sent, nil if there is no such transaction.
+// Block of the nonce is found by binary search over account history, PrunedError if it's before pruned history (see --prune=h)
+func (api *ErigonImpl) GetTransactionBySenderAndNonce(ctx context.Context, addr common.Address, nonce uint64) (*RPCTransaction, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	block, idx, err := api.transactionBySenderAndNonce(tx, addr, nonce)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	return newRPCTransaction(block.Transactions()[idx], block.Hash(), block.NumberU64(), uint64(idx), block.BaseFee()), nil
+}
@@ -5,9 +5,11 @@ import (
 	"context"
 	"math/big"
 	"sync"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/holiman/uint256"
+	jsoniter "github.com/json-iterator/go"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
@@ -19,10 +21,14 @@ import (
 	"github.com/ledgerwatch/erigon/consensus/misc"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	ethFilters "github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/eth/gasprice"
 	"github.com/ledgerwatch/erigon/internal/ethapi"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/snapshotsync"
+	"github.com/ledgerwatch/erigon/turbo/transactions"
 )
 
 // EthAPI is a collection of functions that are exposed in the
@@ -30,6 +36,8 @@ type EthAPI interface {
 	// Block related (proposed file: ./eth_blocks.go)
 	GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error)
 	GetBlockByHash(ctx context.Context, hash rpc.BlockNumberOrHash, fullTx bool) (map[string]interface{}, error)
+	GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error)
+	GetHeaderByHash(ctx context.Context, hash common.Hash) (map[string]interface{}, error)
 	GetBlockTransactionCountByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*hexutil.Uint, error)
 	GetBlockTransactionCountByHash(ctx context.Context, blockHash common.Hash) (*hexutil.Uint, error)
 
@@ -43,8 +51,8 @@ type EthAPI interface {
 
 	// Receipt related (see ./eth_receipts.go)
 	GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error)
-	GetLogs(ctx context.Context, crit ethFilters.FilterCriteria) ([]*types.Log, error)
-	GetBlockReceipts(ctx context.Context, number rpc.BlockNumber) ([]map[string]interface{}, error)
+	GetLogs(ctx context.Context, crit ethFilters.FilterCriteria, stream *jsoniter.Stream) error
+	GetBlockReceipts(ctx context.Context, numberOrHash rpc.BlockNumberOrHash) ([]map[string]interface{}, error)
 
 	// Uncle related (see ./eth_uncles.go)
 	GetUncleByBlockNumberAndIndex(ctx context.Context, blockNr rpc.BlockNumber, index hexutil.Uint) (map[string]interface{}, error)
@@ -53,11 +61,12 @@ type EthAPI interface {
 	GetUncleCountByBlockHash(ctx context.Context, hash common.Hash) (*hexutil.Uint, error)
 
 	// Filter related (see ./eth_filters.go)
-	NewPendingTransactionFilter(_ context.Context) (hexutil.Uint64, error)
-	NewBlockFilter(_ context.Context) (hexutil.Uint64, error)
-	NewFilter(_ context.Context, filter interface{}) (hexutil.Uint64, error)
-	UninstallFilter(_ context.Context, index hexutil.Uint64) (bool, error)
-	GetFilterChanges(_ context.Context, index hexutil.Uint64) ([]interface{}, error)
+	NewPendingTransactionFilter(_ context.Context) (rpc.ID, error)
+	NewBlockFilter(ctx context.Context) (rpc.ID, error)
+	NewFilter(ctx context.Context, crit ethFilters.FilterCriteria) (rpc.ID, error)
+	UninstallFilter(ctx context.Context, id rpc.ID) (bool, error)
+	GetFilterChanges(ctx context.Context, id rpc.ID) (interface{}, error)
+	GetFilterLogs(ctx context.Context, id rpc.ID) ([]*types.Log, error)
 
 	// Account related (see ./eth_accounts.go)
 	Accounts(ctx context.Context) ([]common.Address, error)
@@ -72,15 +81,19 @@ type EthAPI interface {
 	ChainId(ctx context.Context) (hexutil.Uint64, error) /* called eth_protocolVersion elsewhere */
 	ProtocolVersion(_ context.Context) (hexutil.Uint, error)
 	GasPrice(_ context.Context) (*hexutil.Big, error)
+	BlobBaseFee(ctx context.Context) (*hexutil.Big, error)
+	FeeHistory(ctx context.Context, blockCount rpc.DecimalOrHex, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*feeHistoryResult, error)
 
 	// Sending related (see ./eth_call.go)
 	Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi.StateOverrides) (hexutil.Bytes, error)
-	EstimateGas(ctx context.Context, args ethapi.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error)
+	EstimateGas(ctx context.Context, args ethapi.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *ethapi.StateOverrides) (hexutil.Uint64, error)
+	SimulateV1(ctx context.Context, req SimulationRequest, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error)
 	SendRawTransaction(ctx context.Context, encodedTx hexutil.Bytes) (common.Hash, error)
+	SendRawTransactionConditional(ctx context.Context, encodedTx hexutil.Bytes, conditions TransactionConditions) (common.Hash, error)
 	SendTransaction(_ context.Context, txObject interface{}) (common.Hash, error)
 	Sign(ctx context.Context, _ common.Address, _ hexutil.Bytes) (hexutil.Bytes, error)
 	SignTransaction(_ context.Context, txObject interface{}) (common.Hash, error)
-	GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*interface{}, error)
+	GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNrOrHash rpc.BlockNumberOrHash) (*ethapi.AccountResult, error)
 	CreateAccessList(ctx context.Context, args ethapi.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash, optimizeGas *bool) (*accessListResult, error)
 
 	// Mining related (see ./eth_mining.go)
@@ -99,12 +112,16 @@ type EthAPI interface {
 }
 
 type BaseAPI struct {
-	stateCache   kvcache.Cache // thread-safe
-	blocksLRU    *lru.Cache    // thread-safe
-	filters      *filters.Filters
-	_chainConfig *params.ChainConfig
-	_genesis     *types.Block
-	_genesisLock sync.RWMutex
+	stateCache    kvcache.Cache  // thread-safe
+	blocksLRU     *lru.Cache     // thread-safe
+	responseCache *responseCache // nil if disabled
+	filters       *filters.Filters
+	filterStore   filters.FilterStore
+	snapshots     *snapshotsync.BlockSnapshots // nil if disabled
+	revertDecoder *ethapi.RevertDecoder        // nil if only Error(string) and Panic(uint256) are decoded
+	_chainConfig  *params.ChainConfig
+	_genesis      *types.Block
+	_genesisLock  sync.RWMutex
 
 	TevmEnabled bool // experiment
 }
@@ -119,9 +136,20 @@ func NewBaseApi(f *filters.Filters, stateCache kvcache.Cache, singleNodeMode boo
 		panic(err)
 	}
 
-	return &BaseAPI{filters: f, stateCache: stateCache, blocksLRU: blocksLRU}
+	return &BaseAPI{filters: f, filterStore: filters.NewMemoryFilterStore(filters.DefaultFilterLimits()), stateCache: stateCache, blocksLRU: blocksLRU}
 }
 
+// setFilterStore - keeps filters of eth_newFilter and eth_newBlockFilter in `store` instead of memory of this rpcdaemon
+func (api *BaseAPI) setFilterStore(store filters.FilterStore) { api.filterStore = store }
+
+// setBlockSnapshots - blocks frozen in `snapshots` are read from their files instead of db
+func (api *BaseAPI) setBlockSnapshots(snapshots *snapshotsync.BlockSnapshots) {
+	api.snapshots = snapshots
+}
+
+// setRevertDecoder - reverts of calls are decoded also by custom errors known to `decoder`
+func (api *BaseAPI) setRevertDecoder(decoder *ethapi.RevertDecoder) { api.revertDecoder = decoder }
+
 func (api *BaseAPI) chainConfig(tx kv.Tx) (*params.ChainConfig, error) {
 	cfg, _, err := api.chainConfigWithGenesis(tx)
 	return cfg, err
@@ -136,6 +164,9 @@ func (api *BaseAPI) genesis(tx kv.Tx) (*types.Block, error) {
 }
 
 func (api *BaseAPI) blockByNumberWithSenders(tx kv.Tx, number uint64) (*types.Block, error) {
+	if api.snapshots != nil && number < api.snapshots.BlocksAvailable() {
+		return api.frozenBlock(number)
+	}
 	hash, hashErr := rawdb.ReadCanonicalHash(tx, number)
 	if hashErr != nil {
 		return nil, hashErr
@@ -160,6 +191,13 @@ func (api *BaseAPI) blockWithSenders(tx kv.Tx, hash common.Hash, number uint64)
 			return it.(*types.Block), nil
 		}
 	}
+	if api.snapshots != nil && number < api.snapshots.BlocksAvailable() {
+		block, err := api.frozenBlock(number)
+		if err != nil || block.Hash() == hash {
+			return block, err
+		}
+		// not canonical block of frozen height, it can be only in db
+	}
 	block, _, err := rawdb.ReadBlockWithSenders(tx, hash, number)
 	if err != nil {
 		return nil, err
@@ -173,6 +211,18 @@ func (api *BaseAPI) blockWithSenders(tx kv.Tx, hash common.Hash, number uint64)
 	return block, nil
 }
 
+// frozenBlock - canonical block from snapshots, without remote db round trips
+func (api *BaseAPI) frozenBlock(number uint64) (*types.Block, error) {
+	block, _, err := api.snapshots.BlockWithSenders(number)
+	if err != nil {
+		return nil, err
+	}
+	if api.blocksLRU != nil {
+		api.blocksLRU.Add(block.Hash(), block)
+	}
+	return block, nil
+}
+
 func (api *BaseAPI) chainConfigWithGenesis(tx kv.Tx) (*params.ChainConfig, *types.Block, error) {
 	api._genesisLock.RLock()
 	cc, genesisBlock := api._chainConfig, api._genesis
@@ -207,7 +257,7 @@ func (api *BaseAPI) blockByRPCNumber(number rpc.BlockNumber, tx kv.Tx) (*types.B
 		return api.pendingBlock(), nil
 	}
 
-	n, err := getBlockNumber(number, tx)
+	n, err := getBlockNumber(number, tx, api.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -224,8 +274,27 @@ type APIImpl struct {
 	mining     txpool.MiningClient
 	db         kv.RoDB
 	GasCap     uint64
+	EVMTimeout time.Duration // of eth_call and eth_estimateGas, 0 - unlimited
+
+	ReturnDataLimit int // eth_call fails instead of returning more bytes, 0 - unlimited
+
+	FeeHistoryMaxBlocks int // see gasprice.Config.MaxFeeHistory
+	feeHistoryCache     *gasprice.FeeHistoryCache
+	GasPriceConfig      gasprice.Config // of eth_gasPrice and eth_maxPriorityFeePerGas
+	gasPriceCache       *gasprice.TipCache
+	ProofMaxDepth       uint64        // eth_getProof is limited to blocks at most this deep below the head, 0 - unlimited
+	ProofTimeout        time.Duration // 0 - unlimited
+	LogsMaxRange        uint64        // eth_getLogs rejects ranges of more blocks, 0 - unlimited
+	LogsMaxResults      int           // eth_getLogs fails instead of returning more logs, 0 - unlimited
+	LogsTimeout         time.Duration // eth_getLogs fails after processing blocks for this long, 0 - unlimited
 }
 
+// feeHistoryCacheSize - amount of blocks processed by eth_feeHistory kept in memory
+const feeHistoryCacheSize = 2048
+
+// gasPriceCacheSize - amount of blocks sampled by eth_gasPrice kept in memory
+const gasPriceCacheSize = 1024
+
 // NewEthAPI returns APIImpl instance
 func NewEthAPI(base *BaseAPI, db kv.RoDB, eth services.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient, gascap uint64) *APIImpl {
 	if gascap == 0 {
@@ -239,6 +308,11 @@ func NewEthAPI(base *BaseAPI, db kv.RoDB, eth services.ApiBackend, txPool txpool
 		txPool:     txPool,
 		mining:     mining,
 		GasCap:     gascap,
+		EVMTimeout: transactions.DefaultCallTimeout,
+
+		feeHistoryCache: gasprice.NewFeeHistoryCache(feeHistoryCacheSize),
+		GasPriceConfig:  ethconfig.Defaults.GPO,
+		gasPriceCache:   gasprice.NewTipCache(gasPriceCacheSize),
 	}
 }
 
@@ -260,11 +334,18 @@ type RPCTransaction struct {
 	Type             hexutil.Uint64    `json:"type"`
 	Accesses         *types.AccessList `json:"accessList,omitempty"`
 	ChainID          *hexutil.Big      `json:"chainId,omitempty"`
+	MaxFeePerBlobGas *hexutil.Big      `json:"maxFeePerBlobGas,omitempty"`
+	BlobHashes       []common.Hash     `json:"blobVersionedHashes,omitempty"`
 	V                *hexutil.Big      `json:"v"`
 	R                *hexutil.Big      `json:"r"`
 	S                *hexutil.Big      `json:"s"`
 }
 
+// GethCompatible - see ethapi.RPCTransaction, the two have the same fields
+func (t *RPCTransaction) GethCompatible() interface{} {
+	return (*ethapi.RPCTransaction)(t).GethCompatible()
+}
+
 // newRPCTransaction returns a transaction that will serialize to the RPC
 // representation, with the given location metadata set (if available).
 func newRPCTransaction(tx types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64, baseFee *big.Int) *RPCTransaction {
@@ -282,7 +363,13 @@ func newRPCTransaction(tx types.Transaction, blockHash common.Hash, blockNumber
 		To:    tx.GetTo(),
 		Value: (*hexutil.Big)(tx.GetValue().ToBig()),
 	}
-	switch t := tx.(type) {
+	fields := tx
+	if blobTx, ok := tx.(*types.BlobTx); ok {
+		result.MaxFeePerBlobGas = (*hexutil.Big)(blobTx.MaxFeePerBlobGas.ToBig())
+		result.BlobHashes = blobTx.BlobVersionedHashes
+		fields = &blobTx.DynamicFeeTransaction // the rest are fields of dynamic fee transaction
+	}
+	switch t := fields.(type) {
 	case *types.LegacyTx:
 		chainId = types.DeriveChainId(&t.V).ToBig()
 		result.GasPrice = (*hexutil.Big)(t.GasPrice.ToBig())
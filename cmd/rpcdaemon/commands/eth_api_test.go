@@ -1,13 +1,40 @@
 package commands
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"testing"
-
+	"time"
+
+	"github.com/holiman/uint256"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/eth/gasprice"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
 	"github.com/ledgerwatch/erigon/internal/ethapi"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/stages"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
@@ -35,6 +62,114 @@ func TestGetTransactionReceiptUnprotected(t *testing.T) {
 	}
 }
 
+func TestBlobTransactionFields(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x1")
+	signed, err := types.SignNewTx(key, *types.LatestSignerForChainID(big.NewInt(1)), &types.BlobTx{
+		DynamicFeeTransaction: types.DynamicFeeTransaction{
+			ChainID:  uint256.NewInt(1),
+			CommonTx: types.CommonTx{To: &to, Gas: 21000, Value: uint256.NewInt(0)},
+			Tip:      uint256.NewInt(2),
+			FeeCap:   uint256.NewInt(100),
+		},
+		MaxFeePerBlobGas:    uint256.NewInt(5),
+		BlobVersionedHashes: []common.Hash{{0x01}},
+	})
+	require.NoError(t, err)
+	excessBlobGas, blobGasUsed := uint64(10*1024*1024), params.BlobGasPerBlob
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(0), BaseFee: big.NewInt(10), Eip1559: true, BlobGasUsed: &blobGasUsed, ExcessBlobGas: &excessBlobGas}
+	block := types.NewBlock(header, []types.Transaction{signed}, nil, nil)
+
+	rpcTx := newRPCTransaction(signed, block.Hash(), 1, 0, block.BaseFee())
+	require.Equal(t, hexutil.Uint64(types.BlobTxType), rpcTx.Type)
+	require.Equal(t, from, rpcTx.From)
+	require.Equal(t, big.NewInt(5), rpcTx.MaxFeePerBlobGas.ToInt())
+	require.Equal(t, []common.Hash{{0x01}}, rpcTx.BlobHashes)
+	require.Equal(t, big.NewInt(12), rpcTx.GasPrice.ToInt())
+	require.Equal(t, big.NewInt(100), rpcTx.FeeCap.ToInt())
+
+	receipt := &types.Receipt{Type: types.BlobTxType, Status: types.ReceiptStatusSuccessful, GasUsed: 21000, BlockNumber: big.NewInt(1), BlockHash: block.Hash()}
+	fields := marshalReceipt(receipt, signed, params.TestChainConfig, block)
+	require.Equal(t, from, fields["from"])
+	require.Equal(t, hexutil.Uint64(params.BlobGasPerBlob), fields["blobGasUsed"])
+	require.Equal(t, big.NewInt(23), fields["blobGasPrice"].(*hexutil.Big).ToInt())
+
+	marshalled := ethapi.RPCMarshalHeader(block.Header())
+	require.Equal(t, hexutil.Uint64(excessBlobGas), marshalled["excessBlobGas"])
+	require.Equal(t, hexutil.Uint64(blobGasUsed), marshalled["blobGasUsed"])
+	require.NotContains(t, marshalled, "withdrawalsRoot")
+}
+
+func TestGetBlockReceipts(t *testing.T) {
+	assert := assert.New(t)
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, false), db, nil, nil, nil, 5000000)
+
+	byNumber, err := api.GetBlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(6))
+	if err != nil {
+		t.Fatalf("calling GetBlockReceipts by number: %v", err)
+	}
+	assert.Len(byNumber, 32)
+	tx, err := db.BeginRo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := rawdb.ReadCanonicalHash(tx, 6)
+	tx.Rollback()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byHash, err := api.GetBlockReceipts(context.Background(), rpc.BlockNumberOrHashWithHash(hash, true))
+	if err != nil {
+		t.Fatalf("calling GetBlockReceipts by hash: %v", err)
+	}
+	assert.Equal(byNumber, byHash)
+	for i, receipt := range byHash {
+		assert.Equal(hash, receipt["blockHash"])
+		assert.Equal(hexutil.Uint64(i), receipt["transactionIndex"])
+	}
+
+	if _, err = api.GetBlockReceipts(context.Background(), rpc.BlockNumberOrHashWithHash(common.HexToHash("0x1"), false)); err == nil {
+		t.Errorf("expected error for unknown block hash")
+	}
+}
+
+func TestGetHeaderByNumber(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, nil, nil, nil, 5000000)
+
+	block, err := api.GetBlockByNumber(ctx, 6, false)
+	require.NoError(t, err)
+	byNumber, err := api.GetHeaderByNumber(ctx, 6)
+	require.NoError(t, err)
+	for field, value := range block {
+		switch field {
+		case "transactions", "uncles":
+			require.NotContains(t, byNumber, field)
+		case "size": // of header only
+			require.Less(t, uint64(byNumber[field].(hexutil.Uint64)), uint64(value.(hexutil.Uint64)))
+		default:
+			require.Equal(t, value, byNumber[field], field)
+		}
+	}
+	byHash, err := api.GetHeaderByHash(ctx, block["hash"].(common.Hash))
+	require.NoError(t, err)
+	require.Equal(t, byNumber, byHash)
+
+	latest, err := api.GetHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	require.NoError(t, err)
+	require.NotNil(t, latest["totalDifficulty"])
+	missing, err := api.GetHeaderByNumber(ctx, 1000)
+	require.NoError(t, err)
+	require.Nil(t, missing)
+	missing, err = api.GetHeaderByHash(ctx, common.HexToHash("0x1"))
+	require.NoError(t, err)
+	require.Nil(t, missing)
+}
+
 // EIP-1898 test cases
 
 func TestGetStorageAt_ByBlockNumber_WithRequireCanonicalDefault(t *testing.T) {
@@ -216,3 +351,505 @@ func TestCall_ByBlockHash_WithRequireCanonicalTrue_NonCanonicalBlock(t *testing.
 		t.Error("error expected")
 	}
 }
+
+func TestFeeHistory(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, false), db, nil, nil, nil, 5000000)
+	api.FeeHistoryMaxBlocks = 3
+	percentiles := []float64{10, 50, 90}
+	for i := 0; i < 2; i++ { // second time results are taken from cache
+		res, err := api.FeeHistory(context.Background(), 5, rpc.LatestBlockNumber, percentiles)
+		if err != nil {
+			t.Fatalf("calling FeeHistory: %v", err)
+		}
+		assert.Len(t, res.GasUsedRatio, 3)
+		assert.Len(t, res.BaseFee, 4)
+		assert.Len(t, res.Reward, 3)
+		for _, reward := range res.Reward {
+			assert.Len(t, reward, len(percentiles))
+		}
+	}
+	assert.Equal(t, 3, api.feeHistoryCache.Len())
+}
+
+func TestGasPrice(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, false), db, nil, nil, nil, 5000000)
+	tip, err := api.MaxPriorityFeePerGas(context.Background())
+	require.NoError(t, err)
+	sampled := api.gasPriceCache.Len()
+	require.NotZero(t, sampled)
+	price, err := api.GasPrice(context.Background())
+	require.NoError(t, err)
+	require.True(t, price.ToInt().Cmp(tip.ToInt()) >= 0)
+	again, err := api.MaxPriorityFeePerGas(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, tip, again)
+	require.Equal(t, sampled, api.gasPriceCache.Len())
+
+	// floor equal to ceiling - fixed tip
+	api = NewEthAPI(NewBaseApi(nil, stateCache, false), db, nil, nil, nil, 5000000)
+	api.GasPriceConfig.Strategy = gasprice.StrategyEIP1559
+	api.GasPriceConfig.MinPrice, api.GasPriceConfig.MaxPrice = big.NewInt(7), big.NewInt(7)
+	tip, err = api.MaxPriorityFeePerGas(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(7), tip.ToInt())
+}
+
+func TestGetLogsLimits(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, nil, nil, nil, 5000000)
+	ctx := context.Background()
+	crit := filters.FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(10)}
+	all, err := api.getLogs(ctx, crit)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+
+	api.LogsMaxRange = 5
+	_, err = api.getLogs(ctx, crit)
+	var limitErr *logsLimitError
+	require.True(t, errors.As(err, &limitErr), "%v", err)
+	require.Equal(t, -32005, limitErr.ErrorCode())
+	require.Equal(t, uint64(0), limitErr.from)
+	require.Equal(t, uint64(4), limitErr.to)
+	api.LogsMaxRange = 0
+
+	api.LogsMaxResults = len(all)
+	logs, err := api.getLogs(ctx, crit)
+	require.NoError(t, err)
+	require.Equal(t, all, logs)
+	api.LogsMaxResults = 0
+
+	api.LogsTimeout = time.Nanosecond
+	_, err = api.getLogs(ctx, crit)
+	require.True(t, errors.As(err, &limitErr), "%v", err)
+	require.Contains(t, err.Error(), "timed out")
+}
+
+func TestGetLogsStream(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, nil, nil, nil, 5000000)
+	ctx := context.Background()
+	for _, crit := range []filters.FilterCriteria{{}, {FromBlock: big.NewInt(0)}, {FromBlock: big.NewInt(0), Addresses: []common.Address{{1}}}} {
+		expected, err := api.getLogs(ctx, crit)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+		require.NoError(t, api.GetLogs(ctx, crit, stream))
+		require.NoError(t, stream.Flush())
+		expectedJSON, err := json.Marshal(expected)
+		require.NoError(t, err)
+		require.JSONEq(t, string(expectedJSON), buf.String())
+	}
+}
+
+type addOnlyTxPool struct {
+	txpool.TxpoolClient
+	added int
+}
+
+func (p *addOnlyTxPool) Add(ctx context.Context, in *txpool.AddRequest, opts ...grpc.CallOption) (*txpool.AddReply, error) {
+	p.added += len(in.RlpTxs)
+	return &txpool.AddReply{Imported: []txpool.ImportResult{txpool.ImportResult_SUCCESS}, Errors: []string{""}}, nil
+}
+
+func TestSendRawTransactionConditional(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	pool := &addOnlyTxPool{}
+	api := NewEthAPI(NewBaseApi(nil, stateCache, false), db, nil, pool, nil, 5000000)
+	ctx := context.Background()
+	sender := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	token := crypto.CreateAddress(sender, 2)
+
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	header := rawdb.ReadCurrentHeader(tx)
+	chainConfig, err := api.chainConfig(tx)
+	require.NoError(t, err)
+
+	// storage roots together with hashed accounts give state root of the latest block
+	tr := trie.New(trie.EmptyRoot)
+	require.NoError(t, tx.ForEach(kv.HashedAccounts, nil, func(k, v []byte) error {
+		var acc accounts.Account
+		if err := acc.DecodeForStorage(v); err != nil {
+			return err
+		}
+		root, err := storageRoot(tx, common.BytesToHash(k), acc.Incarnation)
+		if err != nil {
+			return err
+		}
+		acc.Root = root
+		tr.UpdateAccount(common.CopyBytes(k), &acc)
+		return nil
+	}))
+	require.Equal(t, header.Root, tr.Hash())
+	acc, err := state.NewPlainStateReader(tx).ReadAccountData(token)
+	require.NoError(t, err)
+	tokenRoot, err := storageRoot(tx, crypto.Keccak256Hash(token.Bytes()), acc.Incarnation)
+	require.NoError(t, err)
+
+	key, _ := crypto.HexToECDSA("49a7b37aa6f6645917e7b807e9d1c00d4fa71f18343b0d4122a4d2df64dd6fee")
+	txn, err := types.SignTx(types.NewTransaction(0, common.Address{1}, uint256.NewInt(1), params.TxGas, uint256.NewInt(params.GWei), nil), *types.LatestSignerForChainID(chainConfig.ChainID), key)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, txn.MarshalBinary(&buf))
+	encodedTx := hexutil.Bytes(buf.Bytes())
+
+	number := hexutil.Uint64(header.Number.Uint64())
+	earlier := number - 1
+	// totalSupply in slot 0 is what was minted in block 4
+	supply := common.BigToHash(big.NewInt(10))
+	var conditions TransactionConditions
+	require.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(`{"knownAccounts": {"%s": "%s", "%s": {"%s": "%s"}}, "blockNumberMin": "%s"}`,
+		common.Address{1}.Hex(), trie.EmptyRoot.Hex(), token.Hex(), common.Hash{}.Hex(), supply.Hex(), number)), &conditions))
+	require.Equal(t, trie.EmptyRoot, *conditions.KnownAccounts[common.Address{1}].StorageRoot)
+	require.Equal(t, supply, conditions.KnownAccounts[token].StorageSlots[common.Hash{}])
+
+	for _, c := range []TransactionConditions{
+		{BlockNumberMax: &earlier},
+		{TimestampMin: (*hexutil.Uint64)(&header.Time), TimestampMax: &earlier},
+		{KnownAccounts: map[common.Address]KnownAccount{token: {StorageRoot: &common.Hash{}}}},
+		{KnownAccounts: map[common.Address]KnownAccount{token: {StorageSlots: map[common.Hash]common.Hash{{}: {}}}}},
+	} {
+		_, err = api.SendRawTransactionConditional(ctx, encodedTx, c)
+		var notMet *conditionsNotMetError
+		require.True(t, errors.As(err, &notMet), "unexpected error %v", err)
+	}
+	require.Zero(t, pool.added)
+
+	for _, c := range []TransactionConditions{
+		conditions,
+		{KnownAccounts: map[common.Address]KnownAccount{token: {StorageRoot: &tokenRoot}}, BlockNumberMax: &number},
+	} {
+		hash, err := api.SendRawTransactionConditional(ctx, encodedTx, c)
+		require.NoError(t, err)
+		require.Equal(t, txn.Hash(), hash)
+	}
+	require.Equal(t, 2, pool.added)
+}
+
+func TestPrunedHistory(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	base := NewBaseApi(nil, stateCache, false)
+	api := NewEthAPI(base, db, nil, nil, nil, 5000000)
+	erigonAPI := NewErigonAPI(base, db, nil)
+	ctx := context.Background()
+	addr := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+
+	info, err := erigonAPI.PruneInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "archive", info.Mode)
+	require.Equal(t, hexutil.Uint64(0), info.EarliestBlocks[rpchelper.PrunedHistory])
+	_, err = api.GetBalance(ctx, addr, rpc.BlockNumberOrHashWithNumber(3))
+	require.NoError(t, err)
+	receipt, err := api.GetTransactionReceipt(ctx, common.Hash{1})
+	require.NoError(t, err)
+	require.Nil(t, receipt)
+
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		return prune.Override(tx, prune.Mode{History: prune.Before(5), Receipts: prune.Before(5), TxIndex: prune.Before(5), CallTraces: prune.Distance(math.MaxUint64)})
+	}))
+	info, err = erigonAPI.PruneInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]hexutil.Uint64{rpchelper.PrunedHistory: 5, rpchelper.PrunedReceipts: 5, rpchelper.PrunedTxIndex: 5, rpchelper.PrunedCallTraces: 0}, info.EarliestBlocks)
+	require.Contains(t, info.Mode, "--prune.h.before=5")
+
+	isPruned := func(err error) {
+		t.Helper()
+		var pruned *rpchelper.PrunedError
+		require.True(t, errors.As(err, &pruned), "expected pruned error, got %v", err)
+		require.Equal(t, uint64(5), pruned.Earliest)
+		require.Equal(t, 4444, pruned.ErrorCode())
+	}
+	_, err = api.GetBalance(ctx, addr, rpc.BlockNumberOrHashWithNumber(3))
+	isPruned(err)
+	_, err = api.GetBalance(ctx, addr, rpc.BlockNumberOrHashWithNumber(5))
+	require.NoError(t, err)
+	_, err = api.getLogs(ctx, filters.FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(10)})
+	isPruned(err)
+	_, err = api.getLogs(ctx, filters.FilterCriteria{FromBlock: big.NewInt(5), ToBlock: big.NewInt(10)})
+	require.NoError(t, err)
+	_, err = api.GetTransactionReceipt(ctx, common.Hash{1})
+	isPruned(err)
+}
+
+func TestErigonGetTransactionBySenderAndNonce(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewErigonAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, nil)
+	ctx := context.Background()
+	sender := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	block3, err := rawdb.ReadBlockByNumber(tx, 3)
+	require.NoError(t, err)
+	block6, err := rawdb.ReadBlockByNumber(tx, 6)
+	require.NoError(t, err)
+	tx.Rollback()
+
+	// token contract is deployed in block 3 with sender's nonce 2
+	txn, err := api.GetTransactionBySenderAndNonce(ctx, sender, 2)
+	require.NoError(t, err)
+	require.NotNil(t, txn)
+	require.Equal(t, block3.Transactions()[0].Hash(), txn.Hash)
+	require.Equal(t, uint64(3), txn.BlockNumber.ToInt().Uint64())
+	txn, err = api.GetTransactionBySenderAndNonce(ctx, sender, 1000)
+	require.NoError(t, err)
+	require.Nil(t, txn)
+
+	// history of blocks before 5 is pruned
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		return prune.Override(tx, prune.Mode{History: prune.Before(5), Receipts: prune.Distance(math.MaxUint64), TxIndex: prune.Distance(math.MaxUint64), CallTraces: prune.Distance(math.MaxUint64)})
+	}))
+	_, err = api.GetTransactionBySenderAndNonce(ctx, sender, 2)
+	var pruned *rpchelper.PrunedError
+	require.True(t, errors.As(err, &pruned), "expected pruned error, got %v", err)
+	last := block6.Transactions()[len(block6.Transactions())-1]
+	txn, err = api.GetTransactionBySenderAndNonce(ctx, sender, last.GetNonce())
+	require.NoError(t, err)
+	require.NotNil(t, txn)
+	require.Equal(t, last.Hash(), txn.Hash)
+}
+
+func TestErigonGetTokenTransfers(t *testing.T) {
+	m := stages.Mock(t)
+	defer m.DB.Close()
+	ctx := context.Background()
+	api := NewErigonAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), m.DB, nil)
+
+	// runtime code emits Transfer(msg.sender, calldata[0:32], calldata[32:64]), init code returns it
+	runtime := "602035600052600035337f" + transferTopic.Hex()[2:] + "60206000a300"
+	initCode := common.FromHex("6031600c6000396031" + "6000f3" + runtime)
+	token := crypto.CreateAddress(m.Address, 0)
+	holder := common.Address{7}
+	signer := types.LatestSigner(m.ChainConfig)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 4, func(i int, block *core.BlockGen) {
+		if i == 0 {
+			txn, err := types.SignTx(types.NewContractCreation(block.TxNonce(m.Address), new(uint256.Int), 100000, new(uint256.Int), initCode), *signer, m.Key)
+			require.NoError(t, err)
+			block.AddTx(txn)
+			return
+		}
+		for j := 0; j < 60; j++ {
+			data := append(common.BytesToHash(holder[:]).Bytes(), common.BigToHash(big.NewInt(int64(i*100+j))).Bytes()...)
+			txn, err := types.SignTx(types.NewTransaction(block.TxNonce(m.Address), token, new(uint256.Int), 30000, new(uint256.Int), data), *signer, m.Key)
+			require.NoError(t, err)
+			block.AddTx(txn)
+		}
+	}, false /* intemediateHashes */)
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	page, err := api.GetTokenTransfers(ctx, holder, 0, rpc.LatestBlockNumber, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, tokenTransfersPageSize)
+	require.NotNil(t, page.NextPage)
+	first := page.Transfers[0]
+	require.Equal(t, TokenTransfer{Token: token, From: m.Address, To: holder, Value: (*hexutil.Big)(big.NewInt(100)), BlockNumber: 2,
+		BlockHash: chain.Blocks[1].Hash(), TxHash: chain.Blocks[1].Transactions()[0].Hash()}, *first)
+	require.Equal(t, hexutil.Uint(39), page.Transfers[tokenTransfersPageSize-1].LogIndex)
+	page, err = api.GetTokenTransfers(ctx, holder, 0, rpc.LatestBlockNumber, *page.NextPage)
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, 80)
+	require.Nil(t, page.NextPage)
+	require.Equal(t, (*hexutil.Big)(big.NewInt(240)), page.Transfers[0].Value)
+	require.Equal(t, (*hexutil.Big)(big.NewInt(359)), page.Transfers[79].Value)
+
+	// sender's transfers of block 3
+	page, err = api.GetTokenTransfers(ctx, m.Address, 3, 3, 0)
+	require.NoError(t, err)
+	require.Len(t, page.Transfers, 60)
+	require.Equal(t, hexutil.Uint64(3), page.Transfers[59].BlockNumber)
+	page, err = api.GetTokenTransfers(ctx, common.Address{8}, 0, rpc.LatestBlockNumber, 0)
+	require.NoError(t, err)
+	require.Empty(t, page.Transfers)
+	_, err = api.GetTokenTransfers(ctx, holder, 3, 2, 0)
+	require.Error(t, err)
+
+	// tokenId of ERC-721 is indexed
+	nft := newTokenTransfer(&types.Log{Address: token, Topics: []common.Hash{transferTopic, {}, common.BytesToHash(holder[:]), common.BigToHash(big.NewInt(5))}})
+	require.NotNil(t, nft)
+	require.Nil(t, nft.Value)
+	require.Equal(t, (*hexutil.Big)(big.NewInt(5)), nft.TokenID)
+	require.Nil(t, newTokenTransfer(&types.Log{Topics: []common.Hash{transferTopic, {}, {}}}))
+}
+
+func TestErigonGetTokenBalanceAt(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewErigonAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, nil)
+	ctx := context.Background()
+	holder := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	// token deployed in block 3, 10 minted in block 4 to another account, which transfers 3 to holder in block 5
+	token := crypto.CreateAddress(holder, 2)
+	balance := func(number rpc.BlockNumber) (uint64, error) {
+		b, err := api.GetTokenBalanceAt(ctx, token, holder, rpc.BlockNumberOrHashWithNumber(number))
+		if err != nil {
+			return 0, err
+		}
+		return b.ToInt().Uint64(), nil
+	}
+	b, err := balance(4)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), b)
+	b, err = balance(5)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), b)
+	_, err = balance(2) // not deployed yet
+	require.Error(t, err)
+	_, err = api.GetTokenBalanceAt(ctx, common.Address{1}, holder, rpc.BlockNumberOrHashWithNumber(5))
+	require.Error(t, err)
+}
+
+func TestErigonGetInternalTransactions(t *testing.T) {
+	m := stages.Mock(t)
+	defer m.DB.Close()
+	ctx := context.Background()
+	api := NewErigonAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), m.DB, nil)
+
+	// runtime code sends msg.value to calldata[0:32], reverts afterwards if calldata[32:64] is not zero
+	initCode := common.FromHex("601c600c600039601c6000f3" + "6000600060006000346000355af15060203515601a576000" + "80fd5b00")
+	forwarder := crypto.CreateAddress(m.Address, 0)
+	rcv := common.Address{9}
+	signer := types.LatestSigner(m.ChainConfig)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 2, func(i int, block *core.BlockGen) {
+		if i == 0 {
+			txn, err := types.SignTx(types.NewContractCreation(block.TxNonce(m.Address), uint256.NewInt(1), 100000, new(uint256.Int), initCode), *signer, m.Key)
+			require.NoError(t, err)
+			block.AddTx(txn)
+			return
+		}
+		for _, revert := range []int64{0, 1} {
+			data := append(common.BytesToHash(rcv[:]).Bytes(), common.BigToHash(big.NewInt(revert)).Bytes()...)
+			txn, err := types.SignTx(types.NewTransaction(block.TxNonce(m.Address), forwarder, uint256.NewInt(5), 100000, new(uint256.Int), data), *signer, m.Key)
+			require.NoError(t, err)
+			block.AddTx(txn)
+		}
+	}, false /* intemediateHashes */)
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	target := func(param string) TxHashOrBlockNumber {
+		var p TxHashOrBlockNumber
+		require.NoError(t, json.Unmarshal([]byte(param), &p))
+		return p
+	}
+	txs := chain.Blocks[1].Transactions()
+	internal, err := api.GetInternalTransactions(ctx, target(`"`+txs[0].Hash().Hex()+`"`))
+	require.NoError(t, err)
+	require.Equal(t, []*InternalTransaction{{Type: "call", Depth: 1, From: forwarder, To: rcv, Value: (*hexutil.Big)(big.NewInt(5)), BlockNumber: 2, TxHash: txs[0].Hash()}}, internal)
+	internal, err = api.GetInternalTransactions(ctx, target(`"`+txs[1].Hash().Hex()+`"`)) // reverted
+	require.NoError(t, err)
+	require.Empty(t, internal)
+	internal, err = api.GetInternalTransactions(ctx, target(`"latest"`))
+	require.NoError(t, err)
+	require.Len(t, internal, 1)
+	internal, err = api.GetInternalTransactions(ctx, target(`"0x1"`)) // top-level creation is not internal
+	require.NoError(t, err)
+	require.Empty(t, internal)
+	_, err = api.GetInternalTransactions(ctx, target(`"`+common.Hash{1}.Hex()+`"`))
+	require.Error(t, err)
+}
+
+func TestErigonBalanceHistory(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewErigonAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, nil)
+	ctx := context.Background()
+	sender := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	// sender sends 0.001 ETH to theAddr in blocks 1 and 2
+	theAddr := common.Address{1}
+	milli := big.NewInt(1_000_000_000_000_000)
+
+	changes, err := api.GetBalanceChangesInBlock(ctx, rpc.BlockNumberOrHashWithNumber(1), nil)
+	require.NoError(t, err)
+	require.Contains(t, changes, sender)
+	require.Equal(t, hexutil.Uint64(0), changes[sender].Before.Nonce)
+	require.Equal(t, hexutil.Uint64(1), changes[sender].After.Nonce)
+	require.Equal(t, &AccountChange{After: &AccountState{Balance: (*hexutil.Big)(milli), CodeHash: trie.EmptyCodeHash}}, changes[theAddr])
+	changes, err = api.GetBalanceChangesInBlock(ctx, rpc.BlockNumberOrHashWithNumber(2), &theAddr)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, milli, changes[theAddr].Before.Balance.ToInt())
+	require.Equal(t, new(big.Int).Mul(milli, big.NewInt(2)), changes[theAddr].After.Balance.ToInt())
+
+	balances := func(history []*BalanceAt) map[uint64]uint64 {
+		result := map[uint64]uint64{}
+		for _, b := range history {
+			result[uint64(b.BlockNumber)] = new(big.Int).Div(b.Balance.ToInt(), milli).Uint64()
+		}
+		return result
+	}
+	history, err := api.GetBalanceHistory(ctx, theAddr, 0, rpc.LatestBlockNumber, 0)
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]uint64{0: 0, 1: 1, 2: 2}, balances(history))
+	history, err = api.GetBalanceHistory(ctx, theAddr, 0, 4, 1)
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]uint64{0: 0, 1: 1, 2: 2, 3: 2, 4: 2}, balances(history))
+	history, err = api.GetBalanceHistory(ctx, theAddr, 1, 6, 4)
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]uint64{1: 1, 5: 2}, balances(history))
+	_, err = api.GetBalanceHistory(ctx, theAddr, 0, 1000, 1)
+	require.Error(t, err)
+
+	// history of blocks before 5 is pruned
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		return prune.Override(tx, prune.Mode{History: prune.Before(5), Receipts: prune.Distance(math.MaxUint64), TxIndex: prune.Distance(math.MaxUint64), CallTraces: prune.Distance(math.MaxUint64)})
+	}))
+	var pruned *rpchelper.PrunedError
+	_, err = api.GetBalanceHistory(ctx, theAddr, 2, 6, 1)
+	require.True(t, errors.As(err, &pruned), "expected pruned error, got %v", err)
+	_, err = api.GetBalanceChangesInBlock(ctx, rpc.BlockNumberOrHashWithNumber(2), nil)
+	require.True(t, errors.As(err, &pruned), "expected pruned error, got %v", err)
+}
+
+func TestGethCompatFormatter(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, nil, nil, nil, 5000000)
+	formatter := rpchelper.GethCompatFormatter{}
+
+	// replay-protected legacy transaction has chainId
+	txn, err := api.GetTransactionByBlockNumberAndIndex(ctx, 3, 0)
+	require.NoError(t, err)
+	compat := formatter.FormatResult("eth_getTransactionByBlockNumberAndIndex", txn).(*ethapi.RPCTransaction)
+	require.Nil(t, txn.ChainID)
+	require.Equal(t, big.NewInt(1337), compat.ChainID.ToInt())
+	// unprotected one has not, block is copied
+	block, err := api.GetBlockByNumber(ctx, 1, true)
+	require.NoError(t, err)
+	compatBlock := formatter.FormatResult("eth_getBlockByNumber", block).(map[string]interface{})
+	require.Equal(t, block["hash"], compatBlock["hash"])
+	require.NotNil(t, block["transactions"].([]interface{})[0].(*ethapi.RPCTransaction).ChainID)
+	require.Nil(t, compatBlock["transactions"].([]interface{})[0].(*ethapi.RPCTransaction).ChainID)
+	var nilTxn *RPCTransaction
+	require.Nil(t, formatter.FormatResult("eth_getTransactionByHash", nilTxn))
+
+	check := func(err error, code int, message string, data interface{}) {
+		formatted := formatter.FormatError("eth_call", err)
+		require.Equal(t, message, formatted.Error())
+		var coded rpc.Error
+		if errors.As(formatted, &coded) {
+			require.Equal(t, code, coded.ErrorCode(), message)
+		} else {
+			require.Equal(t, -32000, code, message)
+		}
+		var withData rpc.DataError
+		if errors.As(formatted, &withData) {
+			require.Equal(t, data, withData.ErrorData(), message)
+		} else {
+			require.Nil(t, data, message)
+		}
+	}
+	check(fmt.Errorf("getReceipts error: %w", &rpchelper.PrunedError{Kind: rpchelper.PrunedHistory, Block: 5, Earliest: 10, Root: common.HexToHash("0x01")}),
+		-32000, "missing trie node 0000000000000000000000000000000000000000000000000000000000000001 (path )", nil)
+	check(&rpchelper.PrunedError{Kind: rpchelper.PrunedReceipts, Block: 5, Earliest: 10}, -32000, "receipts of block 5 is pruned, the earliest available block is 10", nil)
+	check(rpchelper.BlockNotFoundError{Hash: common.HexToHash("0x01")}, -32000, "header for hash not found", nil)
+	check(vm.ErrExecutionReverted, 3, "execution reverted", "0x")
+	panicData := append(common.FromHex("0x4e487b71"), common.LeftPadBytes([]byte{0x11}, 32)...)
+	check(ethapi.NewRevertError(&core.ExecutionResult{Err: vm.ErrExecutionReverted, ReturnData: panicData}), 3, "execution reverted: arithmetic underflow or overflow", hexutil.Encode(panicData))
+	check(ethapi.NewRevertError(&core.ExecutionResult{Err: vm.ErrExecutionReverted, ReturnData: common.FromHex("0x12345678")}), 3, "execution reverted", "0x12345678")
+	check(errors.New("nonce too low"), -32000, "nonce too low", nil)
+}
@@ -6,9 +6,12 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/common/math"
+	"github.com/ledgerwatch/erigon/consensus/misc"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/state"
@@ -23,7 +26,30 @@ import (
 	"golang.org/x/crypto/sha3"
 )
 
-func (api *APIImpl) CallBundle(ctx context.Context, txHashes []common.Hash, stateBlockNumberOrHash rpc.BlockNumberOrHash, timeoutMilliSecondsPtr *int64) (map[string]interface{}, error) {
+// CallBundleArgs represents the arguments of eth_callBundle, same as in Flashbots mev-geth
+type CallBundleArgs struct {
+	Txs                    []hexutil.Bytes       `json:"txs"`
+	BlockNumber            rpc.BlockNumber       `json:"blockNumber"`
+	StateBlockNumberOrHash rpc.BlockNumberOrHash `json:"stateBlockNumber"`
+	Coinbase               *common.Address       `json:"coinbase"`
+	Timestamp              *uint64               `json:"timestamp"`
+	Timeout                *int64                `json:"timeout"`
+	GasLimit               *uint64               `json:"gasLimit"`
+	Difficulty             *big.Int              `json:"difficulty"`
+	BaseFee                *big.Int              `json:"baseFee"`
+}
+
+// CallBundle implements eth_callBundle. Simulates ordered bundle of signed transactions on top of the state
+// block, as if they were included in the next block, and reports fees and payments received by its coinbase.
+func (api *APIImpl) CallBundle(ctx context.Context, args CallBundleArgs) (map[string]interface{}, error) {
+	if len(args.Txs) == 0 {
+		return nil, fmt.Errorf("bundle missing txs")
+	}
+	txs, err := types.DecodeTransactions(bundleTxs(args.Txs))
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
@@ -34,26 +60,9 @@ func (api *APIImpl) CallBundle(ctx context.Context, txHashes []common.Hash, stat
 	if err != nil {
 		return nil, err
 	}
-
-	if len(txHashes) == 0 {
-		return nil, nil
-	}
-
-	var txs types.Transactions
-
-	for _, txHash := range txHashes {
-		txn, _, _, _, err := rawdb.ReadTransaction(tx, txHash)
-		if err != nil {
-			return nil, err
-		}
-		if txn == nil {
-			return nil, nil // not error, see https://github.com/ledgerwatch/turbo-geth/issues/1645
-		}
-		txs = append(txs, txn)
-	}
 	defer func(start time.Time) { log.Trace("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
 
-	stateBlockNumber, hash, latest, err := rpchelper.GetBlockNumber(stateBlockNumberOrHash, tx, api.filters)
+	stateBlockNumber, hash, latest, err := rpchelper.GetCanonicalBlockNumber(args.StateBlockNumberOrHash, tx, api.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -76,22 +85,47 @@ func (api *APIImpl) CallBundle(ctx context.Context, txHashes []common.Hash, stat
 	}
 
 	blockNumber := stateBlockNumber + 1
-
-	timestamp := parent.Time // Dont care about the timestamp
-
-	coinbase := parent.Coinbase
+	if args.BlockNumber > 0 {
+		blockNumber = uint64(args.BlockNumber)
+	}
 	header := &types.Header{
 		ParentHash: parent.Hash(),
-		Number:     big.NewInt(int64(blockNumber)),
+		Number:     new(big.Int).SetUint64(blockNumber),
 		GasLimit:   parent.GasLimit,
-		Time:       timestamp,
+		Time:       parent.Time + 1,
 		Difficulty: parent.Difficulty,
-		Coinbase:   coinbase,
+		Coinbase:   parent.Coinbase,
+	}
+	if args.Coinbase != nil {
+		header.Coinbase = *args.Coinbase
+	}
+	if args.Timestamp != nil {
+		header.Time = *args.Timestamp
+	}
+	if args.GasLimit != nil {
+		header.GasLimit = *args.GasLimit
+	}
+	if args.Difficulty != nil {
+		header.Difficulty = args.Difficulty
+	}
+	if chainConfig.IsLondon(blockNumber) {
+		header.Eip1559 = true
+		header.BaseFee = misc.CalcBaseFee(chainConfig, parent)
+		if args.BaseFee != nil {
+			header.BaseFee = args.BaseFee
+		}
+	}
+	var baseFee *uint256.Int
+	if header.BaseFee != nil {
+		var overflow bool
+		if baseFee, overflow = uint256.FromBig(header.BaseFee); overflow {
+			return nil, fmt.Errorf("header.BaseFee uint256 overflow")
+		}
 	}
 
-	// Get a new instance of the EVM
 	signer := types.MakeSigner(chainConfig, blockNumber)
-	firstMsg, err := txs[0].AsMessage(*signer, nil)
+	rules := chainConfig.Rules(blockNumber)
+	firstMsg, err := txs[0].AsMessage(*signer, header.BaseFee)
 	if err != nil {
 		return nil, err
 	}
@@ -101,12 +135,12 @@ func (api *APIImpl) CallBundle(ctx context.Context, txHashes []common.Hash, stat
 		contractHasTEVM = ethdb.GetHasTEVM(tx)
 	}
 
-	blockCtx, txCtx := transactions.GetEvmContext(firstMsg, header, stateBlockNumberOrHash.RequireCanonical, tx, contractHasTEVM)
+	blockCtx, txCtx := transactions.GetEvmContext(firstMsg, header, args.StateBlockNumberOrHash.RequireCanonical, tx, contractHasTEVM)
 	evm := vm.NewEVM(blockCtx, txCtx, st, chainConfig, vm.Config{Debug: false})
 
 	timeoutMilliSeconds := int64(5000)
-	if timeoutMilliSecondsPtr != nil {
-		timeoutMilliSeconds = *timeoutMilliSecondsPtr
+	if args.Timeout != nil {
+		timeoutMilliSeconds = *args.Timeout
 	}
 	timeout := time.Millisecond * time.Duration(timeoutMilliSeconds)
 	// Setup context so it may be cancelled the call has completed
@@ -133,46 +167,99 @@ func (api *APIImpl) CallBundle(ctx context.Context, txHashes []common.Hash, stat
 	gp := new(core.GasPool).AddGas(math.MaxUint64)
 
 	results := []map[string]interface{}{}
+	coinbaseBalanceBefore := st.GetBalance(header.Coinbase).ToBig()
+	var totalGasUsed uint64
+	gasFees := new(big.Int)
 
 	bundleHash := sha3.NewLegacyKeccak256()
-	for _, txn := range txs {
-		msg, err := txn.AsMessage(*signer, nil)
+	for i, txn := range txs {
+		msg, err := txn.AsMessage(*signer, header.BaseFee)
 		if err != nil {
 			return nil, err
 		}
+		st.Prepare(txn.Hash(), common.Hash{}, i)
+		evm.Reset(core.NewEVMTxContext(msg), st)
+		coinbaseBalanceBeforeTx := st.GetBalance(header.Coinbase).ToBig()
+
 		// Execute the transaction message
 		result, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("err: %w; txhash %s", err, txn.Hash())
 		}
 		// If the timer caused an abort, return an appropriate error message
 		if evm.Cancelled() {
 			return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
 		}
+		if err = st.FinalizeTx(rules, state.NewNoopWriter()); err != nil {
+			return nil, err
+		}
+
+		// coinbase gets tip of every unit of gas and whatever transaction sends to it directly
+		gasPrice := txn.GetEffectiveGasTip(baseFee).ToBig()
+		gasFeesTx := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(result.UsedGas))
+		coinbaseDiffTx := new(big.Int).Sub(st.GetBalance(header.Coinbase).ToBig(), coinbaseBalanceBeforeTx)
+		totalGasUsed += result.UsedGas
+		gasFees.Add(gasFees, gasFeesTx)
 
-		txHash := txn.Hash().String()
 		jsonResult := map[string]interface{}{
-			"txHash":  txHash,
-			"gasUsed": result.UsedGas,
+			"txHash":            txn.Hash().String(),
+			"gasUsed":           result.UsedGas,
+			"fromAddress":       msg.From().String(),
+			"toAddress":         "",
+			"gasPrice":          gasPrice.String(),
+			"gasFees":           gasFeesTx.String(),
+			"coinbaseDiff":      coinbaseDiffTx.String(),
+			"ethSentToCoinbase": new(big.Int).Sub(coinbaseDiffTx, gasFeesTx).String(),
+		}
+		if to := txn.GetTo(); to != nil {
+			jsonResult["toAddress"] = to.String()
 		}
 		bundleHash.Write(txn.Hash().Bytes())
 		if result.Err != nil {
 			jsonResult["error"] = result.Err.Error()
+			if revert := result.Revert(); len(revert) > 0 {
+				jsonResult["revert"] = hexutil.Encode(revert)
+			}
 		} else {
-			jsonResult["value"] = common.BytesToHash(result.Return())
+			jsonResult["value"] = hexutil.Encode(result.Return())
 		}
 
 		results = append(results, jsonResult)
 	}
 
+	coinbaseDiff := new(big.Int).Sub(st.GetBalance(header.Coinbase).ToBig(), coinbaseBalanceBefore)
 	ret := map[string]interface{}{}
 	ret["results"] = results
+	ret["coinbaseDiff"] = coinbaseDiff.String()
+	ret["gasFees"] = gasFees.String()
+	ret["ethSentToCoinbase"] = new(big.Int).Sub(coinbaseDiff, gasFees).String()
+	ret["bundleGasPrice"] = new(big.Int).Div(coinbaseDiff, new(big.Int).SetUint64(totalGasUsed)).String()
+	ret["totalGasUsed"] = totalGasUsed
+	ret["stateBlockNumber"] = stateBlockNumber
 	ret["bundleHash"] = hexutil.Encode(bundleHash.Sum(nil))
 	return ret, nil
 }
 
+func bundleTxs(txs []hexutil.Bytes) [][]byte {
+	encoded := make([][]byte, len(txs))
+	for i, txn := range txs {
+		encoded[i] = txn
+	}
+	return encoded
+}
+
 // GetBlockByNumber implements eth_getBlockByNumber. Returns information about a block given the block's number.
 func (api *APIImpl) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	var cacheKey string
+	var cacheGen uint64
+	if number >= 0 {
+		cacheKey = fmt.Sprintf("eth_getBlockByNumber/%d/%t", number, fullTx)
+		cached, gen, ok := api.responseCache.get(cacheKey)
+		if ok {
+			return cached.(map[string]interface{}), nil
+		}
+		cacheGen = gen
+	}
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
@@ -199,6 +286,9 @@ func (api *APIImpl) GetBlockByNumber(ctx context.Context, number rpc.BlockNumber
 			response[field] = nil
 		}
 	}
+	if err == nil && cacheKey != "" {
+		api.responseCache.add(tx, cacheGen, cacheKey, b.NumberU64(), response)
+	}
 	return response, err
 }
 
@@ -248,6 +338,67 @@ func (api *APIImpl) GetBlockByHash(ctx context.Context, numberOrHash rpc.BlockNu
 	return response, err
 }
 
+// GetHeaderByNumber implements eth_getHeaderByNumber. Returns header of the block in format of eth_getBlockByNumber,
+// without transactions and uncles: only header is read
+func (api *APIImpl) GetHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error) {
+	if number == rpc.PendingBlockNumber {
+		block := api.pendingBlock()
+		if block == nil {
+			return nil, nil
+		}
+		response := ethapi.RPCMarshalHeader(block.Header())
+		// Pending blocks need to nil out a few fields
+		for _, field := range []string{"hash", "nonce", "miner"} {
+			response[field] = nil
+		}
+		return response, nil
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	blockNum, err := getBlockNumber(number, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	header := rawdb.ReadHeaderByNumber(tx, blockNum)
+	if header == nil {
+		return nil, nil
+	}
+	return api.rpcMarshalHeader(tx, header)
+}
+
+// GetHeaderByHash implements eth_getHeaderByHash. Returns header of the block in format of eth_getBlockByHash,
+// without transactions and uncles: only header is read
+func (api *APIImpl) GetHeaderByHash(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	header, err := rawdb.ReadHeaderByHash(tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, nil
+	}
+	return api.rpcMarshalHeader(tx, header)
+}
+
+// rpcMarshalHeader - header in RPC format with its total difficulty
+func (api *APIImpl) rpcMarshalHeader(tx kv.Tx, header *types.Header) (map[string]interface{}, error) {
+	td, err := rawdb.ReadTd(tx, header.Hash(), header.Number.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	response := ethapi.RPCMarshalHeader(header)
+	response["totalDifficulty"] = (*hexutil.Big)(td)
+	return response, nil
+}
+
 // GetBlockTransactionCountByNumber implements eth_getBlockTransactionCountByNumber. Returns the number of transactions in a block given the block's block number.
 func (api *APIImpl) GetBlockTransactionCountByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*hexutil.Uint, error) {
 	tx, err := api.db.BeginRo(ctx)
@@ -266,7 +417,7 @@ func (api *APIImpl) GetBlockTransactionCountByNumber(ctx context.Context, blockN
 		n := hexutil.Uint(len(b.Transactions()))
 		return &n, nil
 	}
-	blockNum, err := getBlockNumber(blockNr, tx)
+	blockNum, err := getBlockNumber(blockNr, tx, api.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	txpool_proto "github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core"
@@ -29,6 +31,34 @@ import (
 	"google.golang.org/grpc"
 )
 
+// callLimits - limits of the server (--rpc.gascap, --rpc.evmtimeout, --rpc.returndata.limit) overridden by the ones of
+// the caller's API key
+func (api *APIImpl) callLimits(ctx context.Context) (gasCap uint64, timeout time.Duration, returnDataLimit int) {
+	gasCap, timeout, returnDataLimit = api.GasCap, api.EVMTimeout, api.ReturnDataLimit
+	if limits := rpc.CallLimitsFromContext(ctx); limits != nil {
+		if limits.GasCap > 0 {
+			gasCap = limits.GasCap
+		}
+		if limits.EVMTimeout > 0 {
+			timeout = time.Duration(limits.EVMTimeout)
+		}
+		if limits.ReturnDataLimit > 0 {
+			returnDataLimit = limits.ReturnDataLimit
+		}
+	}
+	return gasCap, timeout, returnDataLimit
+}
+
+// returnDataLimitError - returned by eth_call instead of return data longer than allowed
+type returnDataLimitError struct {
+	limit, size int
+}
+
+func (e *returnDataLimitError) Error() string {
+	return fmt.Sprintf("return data size %d exceeds limit %d", e.size, e.limit)
+}
+func (e *returnDataLimitError) ErrorCode() int { return -32005 }
+
 // Call implements eth_call. Executes a new message call immediately without creating a transaction on the block chain.
 func (api *APIImpl) Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi.StateOverrides) (hexutil.Bytes, error) {
 	tx, err := api.db.BeginRo(ctx)
@@ -42,8 +72,9 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHas
 		return nil, err
 	}
 
+	gasCap, timeout, returnDataLimit := api.callLimits(ctx)
 	if args.Gas == nil || uint64(*args.Gas) == 0 {
-		args.Gas = (*hexutil.Uint64)(&api.GasCap)
+		args.Gas = (*hexutil.Uint64)(&gasCap)
 	}
 
 	contractHasTEVM := func(contractHash common.Hash) (bool, error) { return false, nil }
@@ -51,34 +82,53 @@ func (api *APIImpl) Call(ctx context.Context, args ethapi.CallArgs, blockNrOrHas
 		contractHasTEVM = ethdb.GetHasTEVM(tx)
 	}
 
-	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters) // DoCall cannot be executed on non-canonical blocks
-	if err != nil {
-		return nil, err
-	}
-	block, err := api.BaseAPI.blockWithSenders(tx, hash, blockNumber)
-	if err != nil {
-		return nil, err
+	// "pending" is executed on top of transactions of pending block, the latest block if there is none
+	var stateReader state.StateReader
+	var block *types.Block
+	if isPending(blockNrOrHash) {
+		if stateReader, block, err = api.pendingState(ctx, tx); err != nil {
+			return nil, err
+		}
+		if stateReader == nil {
+			blockNrOrHash = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		}
 	}
-	if block == nil {
-		return nil, nil
+	if stateReader == nil {
+		blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters) // DoCall cannot be executed on non-canonical blocks
+		if err != nil {
+			return nil, err
+		}
+		block, err = api.BaseAPI.blockWithSenders(tx, hash, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, nil
+		}
+		if stateReader, err = rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, api.filters, api.stateCache); err != nil {
+			return nil, err
+		}
 	}
 
-	result, err := transactions.DoCall(ctx, args, tx, blockNrOrHash, block, overrides, api.GasCap, chainConfig, api.filters, api.stateCache, contractHasTEVM)
+	result, err := transactions.DoCallWithState(ctx, args, tx, stateReader, blockNrOrHash.RequireCanonical, block, overrides, gasCap, timeout, chainConfig, contractHasTEVM)
 	if err != nil {
 		return nil, err
 	}
+	if returnDataLimit > 0 && len(result.ReturnData) > returnDataLimit {
+		return nil, &returnDataLimitError{limit: returnDataLimit, size: len(result.ReturnData)}
+	}
 
 	// If the result contains a revert reason, try to unpack and return it.
 	if len(result.Revert()) > 0 {
-		return nil, ethapi.NewRevertError(result)
+		return nil, api.revertDecoder.NewRevertError(result)
 	}
 
 	return result.Return(), result.Err
 }
 
-func HeaderByNumberOrHash(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.BlockNumberOrHash) (*types.Header, error) {
+func HeaderByNumberOrHash(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.BlockNumberOrHash, ff *filters.Filters) (*types.Header, error) {
 	if blockLabel, ok := blockNrOrHash.Number(); ok {
-		blockNum, err := getBlockNumber(blockLabel, tx)
+		blockNum, err := getBlockNumber(blockLabel, tx, ff)
 		if err != nil {
 			return nil, err
 		}
@@ -90,7 +140,7 @@ func HeaderByNumberOrHash(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.Block
 			return nil, err
 		}
 		if header == nil {
-			return nil, errors.New("header for hash not found")
+			return nil, rpchelper.BlockNotFoundError{Hash: hash}
 		}
 
 		if blockNrOrHash.RequireCanonical {
@@ -99,7 +149,7 @@ func HeaderByNumberOrHash(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.Block
 				return nil, err
 			}
 			if can != hash {
-				return nil, errors.New("hash is not currently canonical")
+				return nil, rpchelper.NonCanonicalHashError{Hash: hash}
 			}
 		}
 
@@ -113,7 +163,8 @@ func HeaderByNumberOrHash(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.Block
 }
 
 // EstimateGas implements eth_estimateGas. Returns an estimate of how much gas is necessary to allow the transaction to complete. The transaction will not be added to the blockchain.
-func (api *APIImpl) EstimateGas(ctx context.Context, args ethapi.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash) (hexutil.Uint64, error) {
+// Same as for eth_call, overrides replace balances, nonces, code and storage of accounts during estimation.
+func (api *APIImpl) EstimateGas(ctx context.Context, args ethapi.CallArgs, blockNrOrHash *rpc.BlockNumberOrHash, overrides *ethapi.StateOverrides) (hexutil.Uint64, error) {
 	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
 	if blockNrOrHash != nil {
 		bNrOrHash = *blockNrOrHash
@@ -141,7 +192,7 @@ func (api *APIImpl) EstimateGas(ctx context.Context, args ethapi.CallArgs, block
 		hi = uint64(*args.Gas)
 	} else {
 		// Retrieve the block to act as the gas ceiling
-		h, err := HeaderByNumberOrHash(ctx, dbtx, bNrOrHash)
+		h, err := HeaderByNumberOrHash(ctx, dbtx, bNrOrHash, api.filters)
 		if err != nil {
 			return 0, err
 		}
@@ -169,6 +220,11 @@ func (api *APIImpl) EstimateGas(ctx context.Context, args ethapi.CallArgs, block
 		if state == nil {
 			return 0, fmt.Errorf("can't get the current state")
 		}
+		if overrides != nil {
+			if err := overrides.Override(state); err != nil {
+				return 0, err
+			}
+		}
 
 		balance := state.GetBalance(*args.From) // from can't be nil
 		available := balance.ToBig()
@@ -193,9 +249,10 @@ func (api *APIImpl) EstimateGas(ctx context.Context, args ethapi.CallArgs, block
 	}
 
 	// Recap the highest gas allowance with specified gascap.
-	if hi > api.GasCap {
-		log.Warn("Caller gas above allowance, capping", "requested", hi, "cap", api.GasCap)
-		hi = api.GasCap
+	gasCap, timeout, _ := api.callLimits(ctx)
+	if hi > gasCap {
+		log.Warn("Caller gas above allowance, capping", "requested", hi, "cap", gasCap)
+		hi = gasCap
 	}
 	cap = hi
 	var lastBlockNum = rpc.LatestBlockNumber
@@ -227,8 +284,8 @@ func (api *APIImpl) EstimateGas(ctx context.Context, args ethapi.CallArgs, block
 			return false, nil, nil
 		}
 
-		result, err := transactions.DoCall(ctx, args, dbtx, numOrHash, block, nil,
-			api.GasCap, chainConfig, api.filters, api.stateCache, contractHasTEVM)
+		result, err := transactions.DoCall(ctx, args, dbtx, numOrHash, block, overrides,
+			gasCap, timeout, chainConfig, api.filters, api.stateCache, contractHasTEVM)
 		if err != nil {
 			if errors.Is(err, core.ErrIntrinsicGas) {
 				// Special case, raise gas limit
@@ -266,7 +323,7 @@ func (api *APIImpl) EstimateGas(ctx context.Context, args ethapi.CallArgs, block
 		if failed {
 			if result != nil && !errors.Is(result.Err, vm.ErrOutOfGas) {
 				if len(result.Revert()) > 0 {
-					return 0, ethapi.NewRevertError(result)
+					return 0, api.revertDecoder.NewRevertError(result)
 				}
 				return 0, result.Err
 			}
@@ -277,12 +334,6 @@ func (api *APIImpl) EstimateGas(ctx context.Context, args ethapi.CallArgs, block
 	return hexutil.Uint64(hi), nil
 }
 
-// GetProof not implemented
-func (api *APIImpl) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*interface{}, error) {
-	var stub interface{}
-	return &stub, fmt.Errorf(NotImplemented, "eth_getProof")
-}
-
 // accessListResult returns an optional accesslist
 // Its the result of the `eth_createAccessList` RPC call.
 // It contains an error if the transaction itself failed.
@@ -342,6 +393,9 @@ func (api *APIImpl) CreateAccessList(ctx context.Context, args ethapi.CallArgs,
 	// lists and we'll need to reestimate every time
 	nogas := args.Gas == nil
 
+	if args.From == nil {
+		args.From = &common.Address{}
+	}
 	var to common.Address
 	if args.To != nil {
 		to = *args.To
@@ -349,14 +403,24 @@ func (api *APIImpl) CreateAccessList(ctx context.Context, args ethapi.CallArgs,
 		// Require nonce to calculate address of created contract
 		if args.Nonce == nil {
 			var nonce uint64
-			reply, err := api.txPool.Nonce(ctx, &txpool_proto.NonceRequest{
-				Address: gointerfaces.ConvertAddressToH160(*args.From),
-			}, &grpc.EmptyCallOption{})
+			acc, err := stateReader.ReadAccountData(*args.From)
 			if err != nil {
 				return nil, err
 			}
-			if reply.Found {
-				nonce = reply.Nonce + 1
+			if acc != nil {
+				nonce = acc.Nonce
+			}
+			// transactions waiting in the pool are executed before the one on top of the latest block
+			if latest && api.txPool != nil {
+				reply, err := api.txPool.Nonce(ctx, &txpool_proto.NonceRequest{
+					Address: gointerfaces.ConvertAddressToH160(*args.From),
+				}, &grpc.EmptyCallOption{})
+				if err != nil {
+					return nil, err
+				}
+				if reply.Found {
+					nonce = reply.Nonce + 1
+				}
 			}
 			args.Nonce = (*hexutil.Uint64)(&nonce)
 		}
@@ -366,6 +430,14 @@ func (api *APIImpl) CreateAccessList(ctx context.Context, args ethapi.CallArgs,
 	// Retrieve the precompiles since they don't need to be added to the access list
 	precompiles := vm.ActivePrecompiles(chainConfig.Rules(blockNumber))
 
+	var baseFee *uint256.Int
+	if header.BaseFee != nil {
+		var overflow bool
+		if baseFee, overflow = uint256.FromBig(header.BaseFee); overflow {
+			return nil, fmt.Errorf("header.BaseFee uint256 overflow")
+		}
+	}
+
 	// Create an initial tracer
 	prevTracer := logger.NewAccessListTracer(nil, *args.From, to, precompiles)
 	if args.AccessList != nil {
@@ -385,7 +457,6 @@ func (api *APIImpl) CreateAccessList(ctx context.Context, args ethapi.CallArgs,
 		}
 		// Set the accesslist to the last al
 		args.AccessList = &accessList
-		baseFee, _ := uint256.FromBig(header.BaseFee)
 		msg, err := args.ToMessage(api.GasCap, baseFee)
 		if err != nil {
 			return nil, err
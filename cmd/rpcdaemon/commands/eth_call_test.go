@@ -1,16 +1,35 @@
 package commands
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands/contracts"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEstimateGas(t *testing.T) {
@@ -22,11 +41,268 @@ func TestEstimateGas(t *testing.T) {
 	if _, err := api.EstimateGas(context.Background(), ethapi.CallArgs{
 		From: &from,
 		To:   &to,
-	}, nil); err != nil {
+	}, nil, nil); err != nil {
 		t.Errorf("calling EstimateGas: %v", err)
 	}
 }
 
+func TestEstimateGasWithStateOverrides(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, false), db, nil, nil, nil, 5000000)
+	var from = common.HexToAddress("0x1234")
+	var to = common.HexToAddress("0x5678")
+	args := ethapi.CallArgs{
+		From:     &from,
+		To:       &to,
+		Value:    (*hexutil.Big)(big.NewInt(1)),
+		GasPrice: (*hexutil.Big)(big.NewInt(1)),
+	}
+	if _, err := api.EstimateGas(context.Background(), args, nil, nil); err == nil {
+		t.Errorf("expected insufficient funds error")
+	}
+
+	balance := (*hexutil.Big)(big.NewInt(params.Ether))
+	gas, err := api.EstimateGas(context.Background(), args, nil, &ethapi.StateOverrides{from: ethapi.Account{Balance: &balance}})
+	if err != nil {
+		t.Fatalf("calling EstimateGas with balance override: %v", err)
+	}
+	if uint64(gas) != params.TxGas {
+		t.Errorf("wrong gas estimation: %d", gas)
+	}
+
+	// PUSH1 0; PUSH1 0; REVERT
+	revertCode := hexutil.Bytes{0x60, 0x00, 0x60, 0x00, 0xfd}
+	if _, err = api.EstimateGas(context.Background(), args, nil, &ethapi.StateOverrides{
+		from: ethapi.Account{Balance: &balance},
+		to:   ethapi.Account{Code: &revertCode},
+	}); err == nil {
+		t.Errorf("expected error for reverting code override")
+	}
+}
+
+func TestEthCallWithStateOverrides(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, false), db, nil, nil, nil, 5000000)
+	var to = common.HexToAddress("0x5678")
+	// PUSH1 0; SLOAD; PUSH1 0; MSTORE; PUSH1 32; PUSH1 0; RETURN
+	code := hexutil.Bytes{0x60, 0x00, 0x54, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+	stateDiff := map[common.Hash]uint256.Int{{}: *uint256.NewInt(7)}
+	res, err := api.Call(context.Background(), ethapi.CallArgs{To: &to}, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), &ethapi.StateOverrides{
+		to: ethapi.Account{Code: &code, StateDiff: &stateDiff},
+	})
+	if err != nil {
+		t.Fatalf("calling Call with state overrides: %v", err)
+	}
+	if common.BytesToHash(res) != common.BigToHash(big.NewInt(7)) {
+		t.Errorf("wrong result: %x", res)
+	}
+}
+
+func TestEthCallRevertReason(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	base := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false)
+	api := NewEthAPI(base, db, nil, nil, nil, 5000000)
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	var to = common.HexToAddress("0x5678")
+	// contract reverting with the data: MSTORE of each 32 bytes of it, REVERT
+	reverting := func(data []byte) *ethapi.StateOverrides {
+		var code hexutil.Bytes
+		padded := common.RightPadBytes(data, (len(data)+31)/32*32)
+		for i := 0; i < len(padded); i += 32 {
+			code = append(append(append(code, 0x7f), padded[i:i+32]...), 0x60, byte(i), 0x52)
+		}
+		code = append(code, 0x60, byte(len(data)), 0x60, 0x00, 0xfd)
+		return &ethapi.StateOverrides{to: ethapi.Account{Code: &code}}
+	}
+	revertData := func(err error) *ethapi.RevertErrorData {
+		var revert *ethapi.RevertError
+		require.True(t, errors.As(err, &revert), "expected revert, got %v", err)
+		require.Equal(t, 3, revert.ErrorCode())
+		return revert.ErrorData().(*ethapi.RevertErrorData)
+	}
+
+	errorData := append(crypto.Keccak256([]byte("Error(string)"))[:4], common.FromHex("0x"+
+		"0000000000000000000000000000000000000000000000000000000000000020"+
+		"0000000000000000000000000000000000000000000000000000000000000003"+
+		"6e6f700000000000000000000000000000000000000000000000000000000000")...)
+	_, err := api.Call(context.Background(), ethapi.CallArgs{To: &to}, latest, reverting(errorData))
+	require.EqualError(t, err, "execution reverted: nop")
+	require.Equal(t, &ethapi.RevertErrorData{Data: hexutil.Encode(errorData), Reason: "nop"}, revertData(err))
+
+	panicData := append(crypto.Keccak256([]byte("Panic(uint256)"))[:4], common.LeftPadBytes([]byte{0x12}, 32)...)
+	_, err = api.Call(context.Background(), ethapi.CallArgs{To: &to}, latest, reverting(panicData))
+	require.EqualError(t, err, "execution reverted: division or modulo by zero")
+	require.Equal(t, "division or modulo by zero", revertData(err).Reason)
+
+	holder := common.HexToAddress("0x1234")
+	customData := append(append(crypto.Keccak256([]byte("InsufficientBalance(address,uint256)"))[:4], common.LeftPadBytes(holder[:], 32)...), common.LeftPadBytes([]byte{0x07}, 32)...)
+	_, err = api.Call(context.Background(), ethapi.CallArgs{To: &to}, latest, reverting(customData))
+	require.EqualError(t, err, "execution reverted")
+	require.Equal(t, &ethapi.RevertErrorData{Data: hexutil.Encode(customData)}, revertData(err))
+
+	decoder := ethapi.NewRevertDecoder()
+	require.NoError(t, decoder.Register(strings.NewReader(`[
+		{"type":"function","name":"balanceOf","inputs":[{"name":"holder","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"error","name":"InsufficientBalance","inputs":[{"name":"holder","type":"address"},{"name":"balance","type":"uint256"}]}
+	]`)))
+	base.setRevertDecoder(decoder)
+	_, err = api.Call(context.Background(), ethapi.CallArgs{To: &to}, latest, reverting(customData))
+	require.EqualError(t, err, "execution reverted: InsufficientBalance(holder: "+holder.Hex()+", balance: 7)")
+	require.Equal(t, "InsufficientBalance(holder: "+holder.Hex()+", balance: 7)", revertData(err).Reason)
+	gas := hexutil.Uint64(100_000)
+	_, err = api.EstimateGas(context.Background(), ethapi.CallArgs{To: &to, Gas: &gas}, nil, reverting(customData))
+	require.Equal(t, "InsufficientBalance(holder: "+holder.Hex()+", balance: 7)", revertData(err).Reason)
+	_, err = api.Call(context.Background(), ethapi.CallArgs{To: &to}, latest, reverting(common.FromHex("0x12345678")))
+	require.Equal(t, &ethapi.RevertErrorData{Data: "0x12345678"}, revertData(err))
+}
+
+func TestEthCallLimits(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, false), db, nil, nil, nil, 5000000)
+	api.ReturnDataLimit = 16
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	var to = common.HexToAddress("0x5678")
+	// PUSH1 32; PUSH1 0; RETURN
+	returning := hexutil.Bytes{0x60, 0x20, 0x60, 0x00, 0xf3}
+	// JUMPDEST; PUSH1 0; JUMP
+	looping := hexutil.Bytes{0x5b, 0x60, 0x00, 0x56}
+	call := func(ctx context.Context, code hexutil.Bytes) error {
+		_, err := api.Call(ctx, ethapi.CallArgs{To: &to}, latest, &ethapi.StateOverrides{to: ethapi.Account{Code: &code}})
+		return err
+	}
+
+	var rpcErr rpc.Error
+	err := call(context.Background(), returning)
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32005 {
+		t.Errorf("expected return data limit error, got %v", err)
+	}
+	if err = call(rpc.WithCallLimits(context.Background(), &rpc.CallLimits{ReturnDataLimit: 32}), returning); err != nil {
+		t.Errorf("call with raised return data limit: %v", err)
+	}
+
+	// the loop runs out of gas capped by the key, evm timeout of the key aborts it
+	ctx := rpc.WithCallLimits(context.Background(), &rpc.CallLimits{GasCap: 100000})
+	if err = call(ctx, looping); err == nil || !strings.Contains(err.Error(), "out of gas") {
+		t.Errorf("expected out of gas, got %v", err)
+	}
+	ctx = rpc.WithCallLimits(context.Background(), &rpc.CallLimits{GasCap: math.MaxUint64 / 2, EVMTimeout: rpc.Duration(50 * time.Millisecond)})
+	if err = call(ctx, looping); err == nil || !strings.Contains(err.Error(), "execution aborted") {
+		t.Errorf("expected timeout, got %v", err)
+	}
+}
+
+func TestCreateAccessList(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, false), db, nil, nil, nil, 5000000)
+	var from = common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	// token deployed in block 3
+	token := crypto.CreateAddress(from, 2)
+	tokenABI, err := abi.JSON(strings.NewReader(contracts.TokenABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := tokenABI.Pack("balanceOf", from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	input := hexutil.Bytes(data)
+	res, err := api.CreateAccessList(context.Background(), ethapi.CallArgs{From: &from, To: &token, Data: &input}, &latest, nil)
+	if err != nil {
+		t.Fatalf("calling CreateAccessList: %v", err)
+	}
+	if res.Error != "" || res.GasUsed == 0 {
+		t.Errorf("wrong result: %+v", res)
+	}
+	if len(*res.Accesslist) != 1 || (*res.Accesslist)[0].Address != token || len((*res.Accesslist)[0].StorageKeys) != 1 {
+		t.Fatalf("wrong access list: %+v", *res.Accesslist)
+	}
+	// single slot of recipient doesn't pay off listing it
+	optimizeGas := true
+	res, err = api.CreateAccessList(context.Background(), ethapi.CallArgs{From: &from, To: &token, Data: &input}, &latest, &optimizeGas)
+	if err != nil {
+		t.Fatalf("calling CreateAccessList: %v", err)
+	}
+	if len(*res.Accesslist) != 0 {
+		t.Errorf("wrong optimized access list: %+v", *res.Accesslist)
+	}
+
+	// contract creation without sender: PUSH20 token; BALANCE; STOP
+	code := hexutil.Bytes(append(append([]byte{0x73}, token.Bytes()...), 0x31, 0x00))
+	res, err = api.CreateAccessList(context.Background(), ethapi.CallArgs{Data: &code}, &latest, nil)
+	if err != nil {
+		t.Fatalf("calling CreateAccessList: %v", err)
+	}
+	if len(*res.Accesslist) != 1 || (*res.Accesslist)[0].Address != token || len((*res.Accesslist)[0].StorageKeys) != 0 {
+		t.Errorf("wrong access list of contract creation: %+v", *res.Accesslist)
+	}
+}
+
+func TestCallBundle(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, false), db, nil, nil, nil, 5000000)
+	ctx := context.Background()
+	key, _ := crypto.HexToECDSA("49a7b37aa6f6645917e7b807e9d1c00d4fa71f18343b0d4122a4d2df64dd6fee")
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	coinbase := common.Address{0xcb}
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+
+	nonce, err := api.GetTransactionCount(ctx, from, latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := rawdb.ReadCurrentHeader(tx)
+	tx.Rollback()
+	// enough to pay base fee of any next block
+	gasPrice := uint256.NewInt(params.GWei)
+	if parent.BaseFee != nil {
+		baseFee, _ := uint256.FromBig(parent.BaseFee)
+		gasPrice.Add(gasPrice, new(uint256.Int).Mul(uint256.NewInt(2), baseFee))
+	}
+	signer := types.LatestSignerForChainID(nil)
+	var rawTxs []hexutil.Bytes
+	for i, to := range []common.Address{coinbase, {1}} {
+		txn, err := types.SignTx(types.NewTransaction(uint64(*nonce)+uint64(i), to, uint256.NewInt(uint64(1000*(1-i))), params.TxGas, gasPrice, nil), *signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err = txn.MarshalBinary(&buf); err != nil {
+			t.Fatal(err)
+		}
+		rawTxs = append(rawTxs, buf.Bytes())
+	}
+
+	res, err := api.CallBundle(ctx, CallBundleArgs{Txs: rawTxs, StateBlockNumberOrHash: latest, Coinbase: &coinbase})
+	if err != nil {
+		t.Fatalf("calling CallBundle: %v", err)
+	}
+	results := res["results"].([]map[string]interface{})
+	if len(results) != 2 {
+		t.Fatalf("wrong number of results: %d", len(results))
+	}
+	if results[0]["ethSentToCoinbase"] != "1000" || results[1]["ethSentToCoinbase"] != "0" || results[0]["error"] != nil {
+		t.Errorf("wrong results: %+v", results)
+	}
+	if res["ethSentToCoinbase"] != "1000" || res["totalGasUsed"] != 2*params.TxGas || res["stateBlockNumber"] != parent.Number.Uint64() {
+		t.Errorf("wrong bundle result: %+v", res)
+	}
+
+	// nonce too high
+	if _, err = api.CallBundle(ctx, CallBundleArgs{Txs: rawTxs[1:], StateBlockNumberOrHash: latest}); err == nil {
+		t.Errorf("expected error for transaction with wrong nonce")
+	}
+}
+
 func TestEthCallNonCanonical(t *testing.T) {
 	db := rpcdaemontest.CreateTestKV(t)
 	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
@@ -70,7 +346,7 @@ func TestGetBlockByTimestampLatestTime(t *testing.T) {
 		}
 	}
 
-	block, err := api.GetBlockByTimestamp(ctx, rpc.Timestamp(latestBlock.Header().Time), false)
+	block, err := api.GetBlockByTimestamp(ctx, rpc.Timestamp(latestBlock.Header().Time), false, nil)
 	if err != nil {
 		t.Errorf("couldn't retrieve block %v", err)
 	}
@@ -111,7 +387,7 @@ func TestGetBlockByTimestampOldestTime(t *testing.T) {
 		}
 	}
 
-	block, err := api.GetBlockByTimestamp(ctx, rpc.Timestamp(oldestBlock.Header().Time), false)
+	block, err := api.GetBlockByTimestamp(ctx, rpc.Timestamp(oldestBlock.Header().Time), false, nil)
 	if err != nil {
 		t.Errorf("couldn't retrieve block %v", err)
 	}
@@ -149,7 +425,7 @@ func TestGetBlockByTimeHigherThanLatestBlock(t *testing.T) {
 		}
 	}
 
-	block, err := api.GetBlockByTimestamp(ctx, rpc.Timestamp(latestBlock.Header().Time+999999999999), false)
+	block, err := api.GetBlockByTimestamp(ctx, rpc.Timestamp(latestBlock.Header().Time+999999999999), false, nil)
 	if err != nil {
 		t.Errorf("couldn't retrieve block %v", err)
 	}
@@ -194,7 +470,7 @@ func TestGetBlockByTimeMiddle(t *testing.T) {
 		}
 	}
 
-	block, err := api.GetBlockByTimestamp(ctx, rpc.Timestamp(middleBlock.Header().Time), false)
+	block, err := api.GetBlockByTimestamp(ctx, rpc.Timestamp(middleBlock.Header().Time), false, nil)
 	if err != nil {
 		t.Errorf("couldn't retrieve block %v", err)
 	}
@@ -239,7 +515,7 @@ func TestGetBlockByTimestamp(t *testing.T) {
 		}
 	}
 
-	block, err := api.GetBlockByTimestamp(ctx, rpc.Timestamp(pickedBlock.Header().Time), false)
+	block, err := api.GetBlockByTimestamp(ctx, rpc.Timestamp(pickedBlock.Header().Time), false, nil)
 	if err != nil {
 		t.Errorf("couldn't retrieve block %v", err)
 	}
@@ -248,3 +524,170 @@ func TestGetBlockByTimestamp(t *testing.T) {
 		t.Errorf("Retrieved the wrong block.\nexpected block hash: %s expected timestamp: %d\nblock hash retrieved: %s timestamp retrieved: %d", response["hash"], response["timestamp"], block["hash"], block["timestamp"])
 	}
 }
+
+func TestGetBlockByTimestampClosest(t *testing.T) {
+	ctx := context.Background()
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewErigonAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, nil)
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	time5, time6 := rawdb.ReadHeaderByNumber(tx, 5).Time, rawdb.ReadHeaderByNumber(tx, 6).Time
+	head := rawdb.ReadCurrentHeader(tx)
+	require.Greater(t, time6-time5, uint64(2))
+
+	number := func(timestamp uint64, closest string) interface{} {
+		block, err := api.GetBlockByTimestamp(ctx, rpc.Timestamp(timestamp), false, &closest)
+		require.NoError(t, err)
+		if block == nil {
+			return nil
+		}
+		return block["number"]
+	}
+	at := func(n uint64) interface{} { return (*hexutil.Big)(new(big.Int).SetUint64(n)) }
+	require.Equal(t, at(5), number(time5, ClosestAfter))
+	require.Equal(t, at(5), number(time5+1, ClosestBefore))
+	require.Equal(t, at(6), number(time5+1, ClosestAfter))
+	require.Equal(t, at(5), number(time5+1, ClosestNearest))
+	require.Equal(t, at(6), number(time6-1, ClosestNearest))
+	require.Equal(t, at(head.Number.Uint64()), number(head.Time+1, ClosestNearest))
+	require.Nil(t, number(head.Time+1, ClosestAfter))
+
+	_, err = api.GetBlockByTimestamp(ctx, rpc.Timestamp(time5), false, new(string))
+	require.Error(t, err)
+}
+
+func TestGetProof(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(nil, stateCache, false), db, nil, nil, nil, 5000000)
+	ctx := context.Background()
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	var token common.Address // the first contract with storage
+	storageKeys := []string{common.Hash{0xff}.Hex()}
+	require.NoError(t, tx.ForEach(kv.PlainState, nil, func(k, v []byte) error {
+		if len(k) == common.AddressLength+common.IncarnationLength+common.HashLength && (token == common.Address{} || bytes.HasPrefix(k, token[:])) {
+			copy(token[:], k)
+			storageKeys = append(storageKeys, common.BytesToHash(k[common.AddressLength+common.IncarnationLength:]).Hex())
+		}
+		return nil
+	}))
+	require.NotEqual(t, common.Address{}, token)
+
+	addresses := []common.Address{common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7"), token, {1}, {0xff}}
+	for _, blockNumber := range []uint64{10, 9, 7, 4, 2, 0} {
+		header := rawdb.ReadHeaderByNumber(tx, blockNumber)
+		reader := state.NewPlainState(tx, blockNumber)
+		for _, address := range addresses {
+			res, err := api.GetProof(ctx, address, storageKeys, rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(blockNumber)))
+			require.NoError(t, err, "block %d, address %x", blockNumber, address)
+			acc, err := reader.ReadAccountData(address)
+			require.NoError(t, err)
+			if acc == nil {
+				acc = &accounts.Account{Root: trie.EmptyRoot, CodeHash: trie.EmptyCodeHash}
+			} else {
+				acc.Root = res.StorageHash
+				require.Equal(t, acc.Balance.ToBig(), res.Balance.ToInt(), "block %d, address %x", blockNumber, address)
+				require.Equal(t, acc.Nonce, uint64(res.Nonce))
+				require.Equal(t, acc.CodeHash, res.CodeHash)
+				enc := make([]byte, acc.EncodingLengthForHashing())
+				acc.EncodeForHashing(enc)
+				last := hexutil.MustDecode(res.AccountProof[len(res.AccountProof)-1])
+				require.True(t, bytes.Contains(last, enc), "proof ends with the account")
+			}
+			verifyTestProof(t, header.Root, res.AccountProof)
+			for i, sr := range res.StorageProof {
+				loc := common.HexToHash(storageKeys[i])
+				v, err := reader.ReadAccountStorage(address, acc.Incarnation, &loc)
+				require.NoError(t, err)
+				require.Equal(t, new(big.Int).SetBytes(v), sr.Value.ToInt(), "block %d, address %x, key %x", blockNumber, address, loc)
+				if res.StorageHash != trie.EmptyRoot {
+					verifyTestProof(t, res.StorageHash, sr.Proof)
+				}
+			}
+		}
+	}
+
+	api.ProofMaxDepth = 5
+	_, err = api.GetProof(ctx, token, nil, rpc.BlockNumberOrHashWithNumber(4))
+	require.Error(t, err)
+}
+
+// verifyTestProof - checks that the first node of proof has hash `root`, and every next one is referenced by hash from the previous one
+func verifyTestProof(t *testing.T, root common.Hash, proof []string) {
+	require.NotEmpty(t, proof)
+	require.Equal(t, root[:], crypto.Keccak256(hexutil.MustDecode(proof[0])))
+	for i := 1; i < len(proof); i++ {
+		require.True(t, bytes.Contains(hexutil.MustDecode(proof[i-1]), crypto.Keccak256(hexutil.MustDecode(proof[i]))), "node %d is not referenced", i)
+	}
+}
+
+func TestSimulateV1(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, nil, nil, nil, 5000000)
+	ctx := context.Background()
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	head := rawdb.ReadCurrentHeader(tx)
+	feeRecipient := common.HexToAddress("0xfee")
+
+	// 0xc0de logs and returns the timestamp, 0xdead reverts, code set in the first block is kept in the second one
+	var req SimulationRequest
+	require.NoError(t, json.Unmarshal([]byte(fmt.Sprintf(`{"blockStateCalls": [
+		{
+			"stateOverrides": {"0x000000000000000000000000000000000000c0de": {"code": "0x4260005260206000a060206000f3"}, "0x000000000000000000000000000000000000dead": {"code": "0x60006000fd"}},
+			"calls": [
+				{"from": "0x71562b71999873db5b286df957af199ec94617f7", "to": "0x0000000000000000000000000000000000001234", "value": "0x1"},
+				{"from": "0x71562b71999873db5b286df957af199ec94617f7", "to": "0x000000000000000000000000000000000000c0de"},
+				{"to": "0x000000000000000000000000000000000000dead"}
+			]
+		},
+		{
+			"blockOverrides": {"time": "0x%x", "feeRecipient": "%s"},
+			"calls": [{"from": "0x71562b71999873db5b286df957af199ec94617f7", "to": "0x000000000000000000000000000000000000c0de"}]
+		}
+	]}`, head.Time+100, feeRecipient.Hex())), &req))
+
+	blocks, err := api.SimulateV1(ctx, req, nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+	expectedTimes := []uint64{head.Time + 12, head.Time + 100}
+	parentHash := head.Hash()
+	for i, block := range blocks {
+		require.Equal(t, (*hexutil.Big)(new(big.Int).SetUint64(head.Number.Uint64()+uint64(i)+1)), block["number"])
+		require.Equal(t, parentHash, block["parentHash"])
+		require.Equal(t, hexutil.Uint64(expectedTimes[i]), block["timestamp"])
+		parentHash = block["hash"].(common.Hash)
+
+		calls := block["calls"].([]*SimulatedCallResult)
+		timestampCall := calls[len(calls)-1]
+		if i == 0 {
+			timestampCall = calls[1]
+		}
+		require.Equal(t, hexutil.Uint64(types.ReceiptStatusSuccessful), timestampCall.Status)
+		require.Equal(t, common.BigToHash(new(big.Int).SetUint64(expectedTimes[i])).Bytes(), []byte(timestampCall.ReturnData))
+		require.Len(t, timestampCall.Logs, 1)
+		require.Equal(t, block["hash"], timestampCall.Logs[0].BlockHash)
+		require.Equal(t, []byte(timestampCall.ReturnData), timestampCall.Logs[0].Data)
+		require.Len(t, block["transactions"], len(calls))
+	}
+	calls := blocks[0]["calls"].([]*SimulatedCallResult)
+	require.Equal(t, hexutil.Uint64(21000), calls[0].GasUsed)
+	require.Equal(t, hexutil.Uint64(types.ReceiptStatusFailed), calls[2].Status)
+	require.Equal(t, 3, calls[2].Error.Code, "reverted")
+	require.Equal(t, feeRecipient, blocks[1]["miner"])
+
+	// number and time of blocks only grow
+	number := hexutil.Big(*head.Number)
+	_, err = api.SimulateV1(ctx, SimulationRequest{BlockStateCalls: []SimulatedBlock{{BlockOverrides: &BlockOverrides{Number: &number}}}}, nil)
+	var rpcErr rpc.Error
+	require.True(t, errors.As(err, &rpcErr))
+	require.Equal(t, -38020, rpcErr.ErrorCode())
+	_, err = api.SimulateV1(ctx, SimulationRequest{BlockStateCalls: make([]SimulatedBlock, MaxSimulatedBlocks+1)}, nil)
+	require.True(t, errors.As(err, &rpcErr))
+	require.Equal(t, -38026, rpcErr.ErrorCode())
+}
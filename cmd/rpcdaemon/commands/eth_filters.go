@@ -2,40 +2,166 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
+	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/debug"
-	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
-	"github.com/ledgerwatch/erigon/eth/filters"
+	ethFilters "github.com/ledgerwatch/erigon/eth/filters"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/log/v3"
 )
 
+var errFilterNotFound = errors.New("filter not found")
+
 // NewPendingTransactionFilter new transaction filter
-func (api *APIImpl) NewPendingTransactionFilter(ctx context.Context) (hexutil.Uint64, error) {
-	return 0, fmt.Errorf(NotImplemented, "eth_newPendingTransactionFilter")
+func (api *APIImpl) NewPendingTransactionFilter(_ context.Context) (rpc.ID, error) {
+	return "", fmt.Errorf(NotImplemented, "eth_newPendingTransactionFilter")
+}
+
+// NewBlockFilter implements eth_newBlockFilter. Creates a filter reporting hashes of blocks following the latest one.
+func (api *APIImpl) NewBlockFilter(ctx context.Context) (rpc.ID, error) {
+	return api.installFilter(ctx, &filters.PollFilter{Type: filters.PollFilterBlocks})
+}
+
+// NewFilter implements eth_newFilter. Creates a filter reporting logs matching `crit` of blocks following the latest one.
+func (api *APIImpl) NewFilter(ctx context.Context, crit ethFilters.FilterCriteria) (rpc.ID, error) {
+	if crit.BlockHash != nil {
+		return "", fmt.Errorf("blockHash is not supported by filters, use eth_getLogs")
+	}
+	f := &filters.PollFilter{Type: filters.PollFilterLogs, Addresses: crit.Addresses, Topics: crit.Topics}
+	if crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 {
+		from := crit.FromBlock.Uint64()
+		f.FromBlock = &from
+	}
+	if crit.ToBlock != nil && crit.ToBlock.Sign() >= 0 {
+		to := crit.ToBlock.Uint64()
+		f.ToBlock = &to
+	}
+	return api.installFilter(ctx, f)
+}
+
+// installFilter - stores filter with the latest block as the last one reported, on behalf of the caller
+func (api *APIImpl) installFilter(ctx context.Context, f *filters.PollFilter) (rpc.ID, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	if f.LastBlock, err = getLatestBlockNumber(tx); err != nil {
+		return "", err
+	}
+	if f.LastHash, err = rawdb.ReadCanonicalHash(tx, f.LastBlock); err != nil {
+		return "", err
+	}
+	f.Client = rpc.CallerFromContext(ctx)
+	id := rpc.NewID()
+	if err := api.filterStore.Add(ctx, string(id), f); err != nil {
+		return "", err
+	}
+	return id, nil
 }
 
-// NewBlockFilter new transaction filter
-func (api *APIImpl) NewBlockFilter(_ context.Context) (hexutil.Uint64, error) {
-	return 0, fmt.Errorf(NotImplemented, "eth_newBlockFilter")
+// UninstallFilter implements eth_uninstallFilter. Removes filter, false if it wasn't found
+func (api *APIImpl) UninstallFilter(ctx context.Context, id rpc.ID) (bool, error) {
+	return api.filterStore.Remove(ctx, string(id))
 }
 
-// NewFilter implements eth_newFilter. Creates an arbitrary filter object, based on filter options, to notify when the state changes (logs).
-func (api *APIImpl) NewFilter(_ context.Context, filter interface{}) (hexutil.Uint64, error) {
-	return 0, fmt.Errorf(NotImplemented, "eth_newFilter")
+// GetFilterChanges implements eth_getFilterChanges. Polling method for a previously-created filter, which returns an array of logs or block hashes which occurred since last poll.
+func (api *APIImpl) GetFilterChanges(ctx context.Context, id rpc.ID) (interface{}, error) {
+	f, err := api.filterStore.Get(ctx, string(id))
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, errFilterNotFound
+	}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	begin, err := firstUnreportedBlock(tx, f.LastBlock, f.LastHash)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := getLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes interface{}
+	if f.Type == filters.PollFilterBlocks {
+		hashes := []common.Hash{}
+		for n := begin; n <= latest; n++ {
+			hash, err := rawdb.ReadCanonicalHash(tx, n)
+			if err != nil {
+				return nil, err
+			}
+			hashes = append(hashes, hash)
+		}
+		changes = hashes
+	} else {
+		end := latest
+		if f.FromBlock != nil && *f.FromBlock > begin {
+			begin = *f.FromBlock
+		}
+		if f.ToBlock != nil && *f.ToBlock < end {
+			end = *f.ToBlock
+		}
+		var logs []*types.Log
+		if begin <= end {
+			if logs, err = api.getLogsInRange(ctx, tx, begin, end, f.Criteria(), logsLimits{}); err != nil {
+				return nil, err
+			}
+		}
+		changes = returnLogs(logs)
+	}
+
+	f.LastBlock = latest
+	if f.LastHash, err = rawdb.ReadCanonicalHash(tx, latest); err != nil {
+		return nil, err
+	}
+	if err := api.filterStore.Update(ctx, string(id), f); err != nil {
+		return nil, err
+	}
+	return changes, nil
 }
 
-// UninstallFilter new transaction filter
-func (api *APIImpl) UninstallFilter(_ context.Context, index hexutil.Uint64) (bool, error) {
-	return false, fmt.Errorf(NotImplemented, "eth_uninstallFilter")
+// GetFilterLogs implements eth_getFilterLogs. Returns all logs matching criteria of filter created by eth_newFilter
+func (api *APIImpl) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*types.Log, error) {
+	f, err := api.filterStore.Get(ctx, string(id))
+	if err != nil {
+		return nil, err
+	}
+	if f == nil || f.Type != filters.PollFilterLogs {
+		return nil, errFilterNotFound
+	}
+	return api.getLogs(ctx, f.Criteria())
 }
 
-// GetFilterChanges implements eth_getFilterChanges. Polling method for a previously-created filter, which returns an array of logs which occurred since last poll.
-func (api *APIImpl) GetFilterChanges(_ context.Context, index hexutil.Uint64) ([]interface{}, error) {
-	var stub []interface{}
-	return stub, fmt.Errorf(NotImplemented, "eth_getFilterChanges")
+// firstUnreportedBlock - the block following the last reported one, or following the block where chain of the last
+// reported one forked off the canonical chain if it was replaced by reorg
+func firstUnreportedBlock(tx kv.Tx, number uint64, hash common.Hash) (uint64, error) {
+	for number > 0 {
+		canonical, err := rawdb.ReadCanonicalHash(tx, number)
+		if err != nil {
+			return 0, err
+		}
+		if canonical == hash {
+			break
+		}
+		header := rawdb.ReadHeader(tx, hash, number)
+		if header == nil {
+			return number, nil
+		}
+		number, hash = number-1, header.ParentHash
+	}
+	return number + 1, nil
 }
 
 // NewHeads send a notification each time a new (header) block is appended to the chain.
@@ -73,8 +199,9 @@ func (api *APIImpl) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
 	return rpcSub, nil
 }
 
-// NewPendingTransactions send a notification each time a new (header) block is appended to the chain.
-func (api *APIImpl) NewPendingTransactions(ctx context.Context) (*rpc.Subscription, error) {
+// NewPendingTransactions send a notification with hash of each transaction added to txpool,
+// or with whole transaction if `fullTx` is true
+func (api *APIImpl) NewPendingTransactions(ctx context.Context, fullTx *bool) (*rpc.Subscription, error) {
 	if api.filters == nil {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
@@ -95,6 +222,10 @@ func (api *APIImpl) NewPendingTransactions(ctx context.Context) (*rpc.Subscripti
 		for {
 			select {
 			case txs := <-txsCh:
+				if fullTx != nil && *fullTx {
+					api.notifyPendingTxs(ctx, notifier, rpcSub.ID, txs)
+					continue
+				}
 				for _, t := range txs {
 					if t != nil {
 						err := notifier.Notify(rpcSub.ID, t.Hash())
@@ -112,8 +243,33 @@ func (api *APIImpl) NewPendingTransactions(ctx context.Context) (*rpc.Subscripti
 	return rpcSub, nil
 }
 
-// SubscribeLogs send a notification each time a new log appears.
-func (api *APIImpl) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc.Subscription, error) {
+// notifyPendingTxs - sends whole transactions, in the same format as eth_getTransactionByHash returns pending ones
+func (api *APIImpl) notifyPendingTxs(ctx context.Context, notifier *rpc.Notifier, id rpc.ID, txs []types.Transaction) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		log.Warn("error while notifying subscription", "err", err)
+		return
+	}
+	defer tx.Rollback()
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		log.Warn("error while notifying subscription", "err", err)
+		return
+	}
+	curHeader := rawdb.ReadCurrentHeader(tx)
+	for _, t := range txs {
+		if t == nil {
+			continue
+		}
+		if err := notifier.Notify(id, newRPCPendingTransaction(t, curHeader, chainConfig)); err != nil {
+			log.Warn("error while notifying subscription", "err", err)
+		}
+	}
+}
+
+// SubscribeLogs send a notification each time a new log appears. If `crit.FromBlock` is a number, matching logs
+// of blocks from it up to the latest one are sent first
+func (api *APIImpl) Logs(ctx context.Context, crit ethFilters.FilterCriteria) (*rpc.Subscription, error) {
 	if api.filters == nil {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
@@ -121,23 +277,58 @@ func (api *APIImpl) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	backfill := crit.FromBlock != nil && crit.FromBlock.Sign() >= 0
 
 	rpcSub := notifier.CreateSubscription()
 
 	go func() {
 		defer debug.LogPanic()
-		logs := make(chan *types.Log, 1)
-		defer close(logs)
-		id := api.filters.SubscribeLogs(logs, crit)
+		id, logs, slow := api.filters.SubscribeLogs(crit)
 		defer api.filters.UnsubscribeLogs(id)
 
+		// live logs are held back until the historical ones are sent
+		var backfilled chan *logsBackfill
+		var pending []*types.Log
+		if backfill {
+			backfillCtx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			backfilled = make(chan *logsBackfill, 1)
+			go func() {
+				defer debug.LogPanic()
+				backfilled <- api.backfillLogs(backfillCtx, crit, func(l *types.Log) {
+					if err := notifier.Notify(rpcSub.ID, l); err != nil {
+						log.Warn("error while notifying subscription", "err", err)
+					}
+				})
+			}()
+		}
+
 		for {
 			select {
 			case h := <-logs:
+				if backfilled != nil {
+					pending = append(pending, h)
+					continue
+				}
 				err := notifier.Notify(rpcSub.ID, h)
 				if err != nil {
 					log.Warn("error while notifying subscription", "err", err)
 				}
+			case done := <-backfilled:
+				backfilled = nil
+				for _, h := range pending {
+					if done.sent(h) == !h.Removed {
+						continue
+					}
+					if err := notifier.Notify(rpcSub.ID, h); err != nil {
+						log.Warn("error while notifying subscription", "err", err)
+					}
+				}
+				pending = nil
+			case <-slow:
+				log.Debug("Closing connection of logs subscriber which doesn't read notifications fast enough", "id", rpcSub.ID)
+				notifier.CloseConnection()
+				return
 			case <-rpcSub.Err():
 				return
 			}
@@ -146,3 +337,71 @@ func (api *APIImpl) Logs(ctx context.Context, crit filters.FilterCriteria) (*rpc
 
 	return rpcSub, nil
 }
+
+// logsBackfillChunk - historical logs are read and sent by ranges of this many blocks
+const logsBackfillChunk = 1000
+
+// logsBackfillDepth - reorgs deeper than this are too rare to track at boundary of historical and live logs
+const logsBackfillDepth = 1024
+
+// logsBackfill - boundary of historical logs: canonical hashes of the latest blocks they were read from
+type logsBackfill struct {
+	latest uint64
+	hashes map[uint64]common.Hash
+}
+
+// sent - whether log was among historical ones. Live log of added block is skipped if it was sent, the one of removed
+// block is sent only if it was sent before. Live logs of blocks deeper than logsBackfillDepth are always sent
+func (b *logsBackfill) sent(l *types.Log) bool {
+	if l.BlockNumber > b.latest {
+		return false
+	}
+	hash, ok := b.hashes[l.BlockNumber]
+	if !ok {
+		return l.Removed
+	}
+	return hash == l.BlockHash
+}
+
+// backfillLogs - sends logs matching `crit` from `crit.FromBlock` up to the latest block, read in one transaction
+func (api *APIImpl) backfillLogs(ctx context.Context, crit ethFilters.FilterCriteria, send func(*types.Log)) *logsBackfill {
+	done := &logsBackfill{hashes: map[uint64]common.Hash{}}
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		log.Warn("historical logs of subscription are not sent", "err", err)
+		return done
+	}
+	defer tx.Rollback()
+	if done.latest, err = getLatestBlockNumber(tx); err != nil {
+		log.Warn("historical logs of subscription are not sent", "err", err)
+		return done
+	}
+	for number := done.latest; number+logsBackfillDepth > done.latest; number-- {
+		hash, err := rawdb.ReadCanonicalHash(tx, number)
+		if err != nil {
+			log.Warn("historical logs of subscription are not sent", "err", err)
+			return done
+		}
+		done.hashes[number] = hash
+		if number == 0 {
+			break
+		}
+	}
+	for begin := crit.FromBlock.Uint64(); begin <= done.latest; begin += logsBackfillChunk {
+		end := begin + logsBackfillChunk - 1
+		if end > done.latest {
+			end = done.latest
+		}
+		logs, err := api.getLogsInRange(ctx, tx, begin, end, crit, logsLimits{})
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Warn("historical logs of subscription are not sent", "from", begin, "err", err)
+			}
+			return done
+		}
+		for _, l := range logs {
+			send(l)
+		}
+	}
+	return done
+}
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	rpcfilters "github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfillLogs(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewEthAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db, nil, nil, nil, 5000000)
+	ctx := context.Background()
+
+	crit := filters.FilterCriteria{FromBlock: big.NewInt(2)}
+	expected, err := api.getLogs(ctx, crit)
+	require.NoError(t, err)
+	require.NotEmpty(t, expected)
+	var sent []*types.Log
+	done := api.backfillLogs(ctx, crit, func(l *types.Log) { sent = append(sent, l) })
+	require.Equal(t, expected, sent)
+	require.Equal(t, uint64(10), done.latest) // head of test chain
+
+	// live logs of blocks sent as historical ones are skipped, unless they are removed
+	last := sent[len(sent)-1]
+	require.True(t, done.sent(last))
+	require.True(t, done.sent(&types.Log{BlockNumber: last.BlockNumber, BlockHash: last.BlockHash, Removed: true}))
+	require.False(t, done.sent(&types.Log{BlockNumber: last.BlockNumber, BlockHash: common.Hash{1}}), "log of new chain")
+	require.False(t, done.sent(&types.Log{BlockNumber: done.latest + 1}))
+}
+
+func TestPollFilters(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	base := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false)
+	store := rpcfilters.NewMemoryFilterStore(rpcfilters.FilterLimits{TTL: time.Minute, PerClient: 2})
+	base.setFilterStore(store)
+	api := NewEthAPI(base, db, nil, nil, nil, 5000000)
+	ctx := context.Background()
+
+	blocksID, err := api.NewBlockFilter(ctx)
+	require.NoError(t, err)
+	logsID, err := api.NewFilter(ctx, filters.FilterCriteria{})
+	require.NoError(t, err)
+	_, err = api.NewFilter(ctx, filters.FilterCriteria{})
+	require.ErrorIs(t, err, rpcfilters.ErrFilterLimit)
+
+	changes, err := api.GetFilterChanges(ctx, blocksID)
+	require.NoError(t, err)
+	require.Empty(t, changes, "no blocks after the head of test chain")
+
+	// as if filters were polled last time at block 5
+	rewind := func(id rpc.ID, hash common.Hash) {
+		f, err := store.Get(ctx, string(id))
+		require.NoError(t, err)
+		f.LastBlock, f.LastHash = 5, hash
+		require.NoError(t, store.Update(ctx, string(id), f))
+	}
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	var hashes []common.Hash
+	for n := uint64(5); n <= 10; n++ {
+		hash, err := rawdb.ReadCanonicalHash(tx, n)
+		require.NoError(t, err)
+		hashes = append(hashes, hash)
+	}
+	rewind(blocksID, hashes[0])
+	changes, err = api.GetFilterChanges(ctx, blocksID)
+	require.NoError(t, err)
+	require.Equal(t, hashes[1:], changes)
+	changes, err = api.GetFilterChanges(ctx, blocksID)
+	require.NoError(t, err)
+	require.Empty(t, changes, "reported blocks are not repeated")
+	rewind(blocksID, common.Hash{1})
+	changes, err = api.GetFilterChanges(ctx, blocksID)
+	require.NoError(t, err)
+	require.Equal(t, hashes, changes, "replaced block is reported again")
+
+	rewind(logsID, hashes[0])
+	expected, err := api.getLogs(ctx, filters.FilterCriteria{FromBlock: big.NewInt(6)})
+	require.NoError(t, err)
+	require.NotEmpty(t, expected)
+	changes, err = api.GetFilterChanges(ctx, logsID)
+	require.NoError(t, err)
+	require.Equal(t, expected, changes)
+	all, err := api.GetFilterLogs(ctx, logsID)
+	require.NoError(t, err)
+	latest, err := api.getLogs(ctx, filters.FilterCriteria{})
+	require.NoError(t, err)
+	require.Equal(t, latest, all)
+
+	removed, err := api.UninstallFilter(ctx, logsID)
+	require.NoError(t, err)
+	require.True(t, removed)
+	removed, err = api.UninstallFilter(ctx, logsID)
+	require.NoError(t, err)
+	require.False(t, removed)
+	_, err = api.GetFilterChanges(ctx, logsID)
+	require.ErrorIs(t, err, errFilterNotFound)
+	_, err = api.NewFilter(ctx, filters.FilterCriteria{})
+	require.NoError(t, err, "uninstalled filter doesn't count against limit")
+}
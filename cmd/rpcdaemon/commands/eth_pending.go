@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/ethdb"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/transactions"
+)
+
+// latestPendingBlock - the latest block built by miner: the one sent by mining stream, or asked from Erigon if the
+// stream hasn't sent any yet. nil if Erigon isn't mining
+func (api *APIImpl) latestPendingBlock(ctx context.Context) (*types.Block, error) {
+	if api.filters != nil {
+		if block := api.filters.LastPendingBlock(); block != nil {
+			return block, nil
+		}
+	}
+	if api.ethBackend == nil {
+		return nil, nil
+	}
+	return api.ethBackend.PendingBlock(ctx)
+}
+
+// pendingState - state after transactions of pending block and the block itself. nil reader if there is no pending
+// block on top of the latest one
+func (api *APIImpl) pendingState(ctx context.Context, tx kv.Tx) (state.StateReader, *types.Block, error) {
+	block, err := api.latestPendingBlock(ctx)
+	if err != nil || block == nil {
+		return nil, nil, err
+	}
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	contractHasTEVM := func(contractHash common.Hash) (bool, error) { return false, nil }
+	if api.TevmEnabled {
+		contractHasTEVM = ethdb.GetHasTEVM(tx)
+	}
+	reader, err := transactions.PendingStateReader(ctx, tx, block, chainConfig, api.stateCache, contractHasTEVM)
+	if err != nil || reader == nil {
+		return nil, nil, err
+	}
+	return reader, block, nil
+}
+
+// stateReader - same as rpchelper.CreateStateReader, but "pending" is the state after transactions of pending block,
+// or the latest state if there is no pending block
+func (api *APIImpl) stateReader(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.BlockNumberOrHash) (state.StateReader, error) {
+	if isPending(blockNrOrHash) {
+		reader, _, err := api.pendingState(ctx, tx)
+		if err != nil || reader != nil {
+			return reader, err
+		}
+		blockNrOrHash = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	}
+	return rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, api.filters, api.stateCache)
+}
+
+func isPending(blockNrOrHash rpc.BlockNumberOrHash) bool {
+	return blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber
+}
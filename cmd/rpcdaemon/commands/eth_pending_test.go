@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingState(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	mining := txpool.NewMiningClient(conn)
+	ff := filters.New(ctx, nil, nil, mining)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewEthAPI(NewBaseApi(ff, stateCache, false), m.DB, nil, nil, nil, 5000000)
+
+	tx, err := m.DB.BeginRo(ctx)
+	require.NoError(t, err)
+	latest := rawdb.ReadCurrentBlock(tx)
+	tx.Rollback()
+
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	sender, recipient := crypto.PubkeyToAddress(key.PublicKey), common.Address{0xee}
+	nonce, err := api.GetTransactionCount(ctx, sender, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	require.NoError(t, err)
+	pendingBlock := func(parent common.Hash) []byte {
+		header := &types.Header{ParentHash: parent, Number: new(big.Int).Add(latest.Number(), big.NewInt(1)), GasLimit: latest.GasLimit(),
+			Time: latest.Time() + 1, Difficulty: big.NewInt(1), Coinbase: common.Address{0xcb}}
+		gasPrice := uint256.NewInt(1)
+		if m.ChainConfig.IsLondon(header.Number.Uint64()) {
+			header.Eip1559, header.BaseFee = true, misc.CalcBaseFee(m.ChainConfig, latest.Header())
+			gasPrice.SetFromBig(new(big.Int).Mul(header.BaseFee, big.NewInt(2)))
+		}
+		txn, err := types.SignTx(types.NewTransaction(uint64(*nonce), recipient, uint256.NewInt(1000), 21000, gasPrice, nil), *types.LatestSignerForChainID(m.ChainConfig.ChainID), key)
+		require.NoError(t, err)
+		encoded, err := rlp.EncodeToBytes(types.NewBlock(header, []types.Transaction{txn}, nil, nil))
+		require.NoError(t, err)
+		return encoded
+	}
+	pending := rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber)
+	value := hexutil.Big(*big.NewInt(500))
+	call := ethapi.CallArgs{From: &recipient, To: &common.Address{0xdd}, Value: &value}
+
+	// without pending block "pending" is the latest state
+	balance, err := api.GetBalance(ctx, recipient, pending)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), balance.ToInt().Int64())
+
+	ff.HandlePendingBlock(&txpool.OnPendingBlockReply{RplBlock: pendingBlock(latest.Hash())})
+	balance, err = api.GetBalance(ctx, recipient, pending)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), balance.ToInt().Int64())
+	balance, err = api.GetBalance(ctx, recipient, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	require.NoError(t, err)
+	require.Equal(t, int64(0), balance.ToInt().Int64())
+	// recipient can spend value received in pending block
+	_, err = api.Call(ctx, call, pending, nil)
+	require.NoError(t, err)
+	_, err = api.Call(ctx, call, rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil)
+	require.Error(t, err)
+
+	// stale pending block isn't applied
+	ff.HandlePendingBlock(&txpool.OnPendingBlockReply{RplBlock: pendingBlock(common.Hash{1})})
+	balance, err = api.GetBalance(ctx, recipient, pending)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), balance.ToInt().Int64())
+}
@@ -0,0 +1,412 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/changeset"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/trie"
+)
+
+// GetProof implements eth_getProof. Proofs of blocks below the head are made from the trie regenerated at that block:
+// state of the block is restored from changesets of later blocks, so it works for any block with unpruned history
+// not deeper than ProofMaxDepth blocks below the head
+func (api *APIImpl) GetProof(ctx context.Context, address common.Address, storageKeys []string, blockNrOrHash rpc.BlockNumberOrHash) (*ethapi.AccountResult, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters)
+	if err != nil {
+		return nil, err
+	}
+	// hashed state and intermediate hashes are at progress of this stage
+	head, err := stages.GetStageProgress(tx, stages.IntermediateHashes)
+	if err != nil {
+		return nil, err
+	}
+	if blockNumber > head {
+		return nil, fmt.Errorf("state trie of block %d is not built yet, the latest one is of block %d", blockNumber, head)
+	}
+	if api.ProofMaxDepth > 0 && head-blockNumber > api.ProofMaxDepth {
+		return nil, fmt.Errorf("block %d is %d blocks below the head, proofs are limited to %d blocks (--rpc.getproof.maxdepth)", blockNumber, head-blockNumber, api.ProofMaxDepth)
+	}
+	if err = rpchelper.CheckPruned(tx, rpchelper.PrunedHistory, blockNumber); err != nil {
+		return nil, err
+	}
+	header := rawdb.ReadHeader(tx, hash, blockNumber)
+	if header == nil {
+		return nil, fmt.Errorf("header of block %d not found", blockNumber)
+	}
+
+	if api.ProofTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, api.ProofTimeout)
+		defer cancel()
+	}
+	stateTx, changed, err := newHistoricalHashedStateTx(ctx, tx, blockNumber, head)
+	if err != nil {
+		return nil, proofError(ctx, api.ProofTimeout, err)
+	}
+
+	addrHash, err := common.HashData(address[:])
+	if err != nil {
+		return nil, err
+	}
+	var acc accounts.Account
+	found, err := stateTx.account(addrHash, &acc)
+	if err != nil {
+		return nil, err
+	}
+	locHashes := make([]common.Hash, len(storageKeys))
+	retain := trie.NewRetainList(0) // keys to make proofs of
+	retain.AddKey(addrHash[:])
+	changed.AddKey(addrHash[:])
+	for i, key := range storageKeys {
+		loc := common.HexToHash(key)
+		if locHashes[i], err = common.HashData(loc[:]); err != nil {
+			return nil, err
+		}
+		if found && acc.Incarnation > 0 {
+			storageKey := dbutils.GenerateCompositeStorageKey(addrHash, acc.Incarnation, locHashes[i])
+			retain.AddKey(storageKey)
+			changed.AddKey(storageKey)
+		}
+	}
+
+	loader := trie.NewFlatDBTrieLoader("getProof")
+	if err = loader.Reset(changed, nil, nil, false); err != nil {
+		return nil, err
+	}
+	receiver := trie.NewProofAggregator(retain)
+	receiver.Reset(nil, nil, false)
+	loader.SetStreamReceiver(receiver)
+	root, err := loader.CalcTrieRoot(stateTx, nil, ctx.Done())
+	if err != nil {
+		return nil, proofError(ctx, api.ProofTimeout, err)
+	}
+	if root != header.Root {
+		return nil, fmt.Errorf("regenerated state trie of block %d has root %x, expected %x", blockNumber, root, header.Root)
+	}
+
+	t := receiver.ProofTrie()
+	accountProof, err := t.Prove(addrHash[:], 0, false)
+	if err != nil {
+		return nil, err
+	}
+	result := &ethapi.AccountResult{
+		Address:      address,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      (*hexutil.Big)(new(big.Int)),
+		CodeHash:     trie.EmptyCodeHash,
+		StorageHash:  trie.EmptyRoot,
+		StorageProof: make([]ethapi.StorageResult, len(storageKeys)),
+	}
+	if a, ok := t.GetAccount(addrHash[:]); ok && a != nil {
+		result.Balance = (*hexutil.Big)(a.Balance.ToBig())
+		result.Nonce = hexutil.Uint64(a.Nonce)
+		result.CodeHash = a.CodeHash
+		result.StorageHash = a.Root
+	}
+	for i, key := range storageKeys {
+		storageKey := append(common.CopyBytes(addrHash[:]), locHashes[i][:]...)
+		proof, err := t.Prove(storageKey, 2*common.HashLength, true)
+		if err != nil {
+			return nil, err
+		}
+		v, _ := t.Get(storageKey)
+		result.StorageProof[i] = ethapi.StorageResult{Key: key, Value: (*hexutil.Big)(new(big.Int).SetBytes(v)), Proof: toHexSlice(proof)}
+	}
+	return result, nil
+}
+
+// proofError - explains errors caused by ProofTimeout
+func proofError(ctx context.Context, timeout time.Duration, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("proof is not made in %s (--rpc.getproof.timeout)", timeout)
+	}
+	return err
+}
+
+func toHexSlice(b [][]byte) []string {
+	r := make([]string, len(b))
+	for i := range b {
+		r[i] = hexutil.Encode(b[i])
+	}
+	return r
+}
+
+// historicalHashedStateTx - shows HashedAccounts and HashedStorage as they were at some block: values of keys changed
+// after the block are taken from changesets. Only cursor methods used by trie.FlatDBTrieLoader are overlaid,
+// other tables, including intermediate hashes, are of the latest state
+type historicalHashedStateTx struct {
+	kv.Tx
+	accounts []stateItem
+	storage  []stateItem
+}
+
+// stateItem - value of key at the block, nil - key didn't exist
+type stateItem struct {
+	k, v []byte
+}
+
+// newHistoricalHashedStateTx - state of `block` restored from changesets of following blocks up to `head`. The
+// returned retain list has all keys changed since the block, so intermediate hashes of them are not used
+func newHistoricalHashedStateTx(ctx context.Context, tx kv.Tx, block, head uint64) (*historicalHashedStateTx, *trie.RetainList, error) {
+	stateTx := &historicalHashedStateTx{Tx: tx}
+	changed := trie.NewRetainList(0)
+	if block == head {
+		return stateTx, changed, nil
+	}
+	seen := map[string]struct{}{}
+	if err := changeset.ForRange(tx, kv.AccountChangeSet, block+1, head+1, func(_ uint64, k, v []byte) error {
+		if _, ok := seen[string(k)]; ok { // the earliest change keeps value of the block
+			return nil
+		}
+		seen[string(k)] = struct{}{}
+		addrHash, err := common.HashData(k)
+		if err != nil {
+			return err
+		}
+		if v, err = accountWithCodeHash(tx, addrHash, common.CopyBytes(v)); err != nil {
+			return err
+		}
+		stateTx.accounts = append(stateTx.accounts, stateItem{k: addrHash[:], v: v})
+		return ctx.Err()
+	}); err != nil {
+		return nil, nil, err
+	}
+	if err := changeset.ForRange(tx, kv.StorageChangeSet, block+1, head+1, func(_ uint64, k, v []byte) error {
+		if _, ok := seen[string(k)]; ok {
+			return nil
+		}
+		seen[string(k)] = struct{}{}
+		addrHash, err := common.HashData(k[:common.AddressLength])
+		if err != nil {
+			return err
+		}
+		locHash, err := common.HashData(k[common.AddressLength+common.IncarnationLength:])
+		if err != nil {
+			return err
+		}
+		key := dbutils.GenerateCompositeStorageKey(addrHash, binary.BigEndian.Uint64(k[common.AddressLength:]), locHash)
+		item := stateItem{k: key}
+		if len(v) > 0 {
+			item.v = append(common.CopyBytes(locHash[:]), v...)
+		}
+		stateTx.storage = append(stateTx.storage, item)
+		return ctx.Err()
+	}); err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(stateTx.accounts, func(i, j int) bool { return bytes.Compare(stateTx.accounts[i].k, stateTx.accounts[j].k) < 0 })
+	sort.Slice(stateTx.storage, func(i, j int) bool { return bytes.Compare(stateTx.storage[i].k, stateTx.storage[j].k) < 0 })
+
+	// keys missing in the latest state are marked as created, like in unwind of intermediate hashes
+	for _, item := range stateTx.accounts {
+		v, err := tx.GetOne(kv.HashedAccounts, item.k)
+		if err != nil {
+			return nil, nil, err
+		}
+		changed.AddKeyWithMarker(item.k, len(v) == 0)
+	}
+	c, err := tx.CursorDupSort(kv.HashedStorage)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.Close()
+	for _, item := range stateTx.storage {
+		v, err := c.SeekBothRange(item.k[:common.HashLength+common.IncarnationLength], item.k[common.HashLength+common.IncarnationLength:])
+		if err != nil {
+			return nil, nil, err
+		}
+		changed.AddKeyWithMarker(item.k, !bytes.HasPrefix(v, item.k[common.HashLength+common.IncarnationLength:]))
+	}
+	return stateTx, changed, nil
+}
+
+// accountWithCodeHash - changesets don't keep code hashes of contracts, they are restored from ContractCode
+func accountWithCodeHash(tx kv.Tx, addrHash common.Hash, v []byte) ([]byte, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	var acc accounts.Account
+	if err := acc.DecodeForStorage(v); err != nil {
+		return nil, err
+	}
+	if !(acc.Incarnation > 0 && acc.IsEmptyCodeHash()) {
+		return v, nil
+	}
+	codeHash, err := tx.GetOne(kv.ContractCode, dbutils.GenerateStoragePrefix(addrHash[:], acc.Incarnation))
+	if err != nil {
+		return nil, err
+	}
+	copy(acc.CodeHash[:], codeHash)
+	value := make([]byte, acc.EncodingLengthForStorage())
+	acc.EncodeForStorage(value)
+	return value, nil
+}
+
+// account - account at the block, false if it didn't exist
+func (tx *historicalHashedStateTx) account(addrHash common.Hash, acc *accounts.Account) (bool, error) {
+	c, err := tx.Cursor(kv.HashedAccounts)
+	if err != nil {
+		return false, err
+	}
+	defer c.Close()
+	k, v, err := c.Seek(addrHash[:])
+	if err != nil || !bytes.Equal(k, addrHash[:]) {
+		return false, err
+	}
+	return true, acc.DecodeForStorage(v)
+}
+
+func (tx *historicalHashedStateTx) Cursor(bucket string) (kv.Cursor, error) {
+	c, err := tx.Tx.Cursor(bucket)
+	if err != nil || bucket != kv.HashedAccounts {
+		return c, err
+	}
+	return &historicalCursor{Cursor: c, items: tx.accounts}, nil
+}
+
+func (tx *historicalHashedStateTx) CursorDupSort(bucket string) (kv.CursorDupSort, error) {
+	c, err := tx.Tx.CursorDupSort(bucket)
+	if err != nil || bucket != kv.HashedStorage {
+		return c, err
+	}
+	return &historicalDupCursor{CursorDupSort: c, items: tx.storage}, nil
+}
+
+// historicalCursor - merges keys of the latest state with changed ones, supports Seek and Next
+type historicalCursor struct {
+	kv.Cursor
+	items    []stateItem
+	i        int
+	k, v     []byte // current key of the latest state
+	fromItem bool   // last returned key is items[i]
+}
+
+func (c *historicalCursor) Seek(seek []byte) ([]byte, []byte, error) {
+	var err error
+	if c.k, c.v, err = c.Cursor.Seek(seek); err != nil {
+		return nil, nil, err
+	}
+	c.i = sort.Search(len(c.items), func(i int) bool { return bytes.Compare(c.items[i].k, seek) >= 0 })
+	return c.current()
+}
+
+func (c *historicalCursor) Next() ([]byte, []byte, error) {
+	if c.fromItem {
+		c.i++
+	} else {
+		var err error
+		if c.k, c.v, err = c.Cursor.Next(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return c.current()
+}
+
+func (c *historicalCursor) current() ([]byte, []byte, error) {
+	for ; c.i < len(c.items); c.i++ {
+		item := c.items[c.i]
+		cmp := -1
+		if c.k != nil {
+			cmp = bytes.Compare(item.k, c.k)
+		}
+		if cmp > 0 {
+			break
+		}
+		if cmp == 0 { // value of the latest state is replaced
+			var err error
+			if c.k, c.v, err = c.Cursor.Next(); err != nil {
+				return nil, nil, err
+			}
+		}
+		if item.v != nil {
+			c.fromItem = true
+			return item.k, item.v, nil
+		}
+	}
+	c.fromItem = false
+	return c.k, c.v, nil
+}
+
+// historicalDupCursor - the same as historicalCursor for HashedStorage, supports SeekBothRange and NextDup
+type historicalDupCursor struct {
+	kv.CursorDupSort
+	items    []stateItem
+	i        int
+	key      []byte // address hash with incarnation
+	v        []byte // current value of the latest state, starts with location hash
+	fromItem bool
+}
+
+func (c *historicalDupCursor) SeekBothRange(key, value []byte) ([]byte, error) {
+	var err error
+	if c.v, err = c.CursorDupSort.SeekBothRange(key, value); err != nil {
+		return nil, err
+	}
+	c.key = common.CopyBytes(key)
+	seek := append(common.CopyBytes(key), value...)
+	c.i = sort.Search(len(c.items), func(i int) bool { return bytes.Compare(c.items[i].k, seek) >= 0 })
+	_, v, err := c.current()
+	return v, err
+}
+
+func (c *historicalDupCursor) NextDup() ([]byte, []byte, error) {
+	if c.fromItem {
+		c.i++
+	} else {
+		var err error
+		if _, c.v, err = c.CursorDupSort.NextDup(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return c.current()
+}
+
+func (c *historicalDupCursor) current() ([]byte, []byte, error) {
+	for ; c.i < len(c.items) && bytes.HasPrefix(c.items[c.i].k, c.key); c.i++ {
+		item := c.items[c.i]
+		cmp := -1
+		if c.v != nil {
+			cmp = bytes.Compare(item.k[len(c.key):], c.v[:common.HashLength])
+		}
+		if cmp > 0 {
+			break
+		}
+		if cmp == 0 {
+			var err error
+			if _, c.v, err = c.CursorDupSort.NextDup(); err != nil {
+				return nil, nil, err
+			}
+		}
+		if item.v != nil {
+			c.fromItem = true
+			return c.key, item.v, nil
+		}
+	}
+	c.fromItem = false
+	if c.v == nil {
+		return nil, nil, nil
+	}
+	return c.key, c.v, nil
+}
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/holiman/uint256"
+	jsoniter "github.com/json-iterator/go"
 	"github.com/ledgerwatch/erigon-lib/kv"
 
 	"github.com/RoaringBitmap/roaring"
@@ -16,6 +19,7 @@ import (
 	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/consensus/misc"
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/state"
@@ -27,6 +31,7 @@ import (
 	"github.com/ledgerwatch/erigon/ethdb/cbor"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 	"github.com/ledgerwatch/erigon/turbo/transactions"
 )
 
@@ -65,21 +70,124 @@ func getReceipts(ctx context.Context, tx kv.Tx, chainConfig *params.ChainConfig,
 	return receipts, nil
 }
 
-// GetLogs implements eth_getLogs. Returns an array of logs matching a given filter object.
-func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([]*types.Log, error) {
-	var begin, end uint64
+// logsLimits - limits of one eth_getLogs request, zero values - unlimited
+type logsLimits struct {
+	maxResults int
+	deadline   time.Time
+}
+
+// logsLimitError - request hit one of --rpc.logs.* limits, data suggests block range to retry with
+type logsLimitError struct {
+	reason   string
+	from, to uint64
+}
+
+func (e *logsLimitError) Error() string {
+	return fmt.Sprintf("%s, try with this block range [0x%x, 0x%x]", e.reason, e.from, e.to)
+}
+func (e *logsLimitError) ErrorCode() int { return -32005 }
+func (e *logsLimitError) ErrorData() interface{} {
+	return map[string]hexutil.Uint64{"from": hexutil.Uint64(e.from), "to": hexutil.Uint64(e.to)}
+}
+
+// GetLogs implements eth_getLogs. Returns an array of logs matching a given filter object. Requests are limited by
+// --rpc.logs.maxrange, --rpc.logs.maxresults and --rpc.logs.timeout. Logs are written to the client block by block,
+// unless all of them are needed first: to check --rpc.logs.maxresults or to be cached.
+func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria, stream *jsoniter.Stream) error {
+	arr := rpc.NewArrayStream(ctx, stream)
+	writeLogs := func(logs []*types.Log) {
+		for _, log := range logs {
+			arr.Next()
+			stream.WriteVal(log)
+		}
+	}
+	if api.LogsMaxResults > 0 || (api.responseCache != nil && logsCacheable(crit)) {
+		logs, err := api.getLogs(ctx, crit)
+		if err != nil {
+			return err
+		}
+		arr.Start()
+		writeLogs(logs)
+		arr.End()
+		return stream.Flush()
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	begin, end, err := api.logsRange(tx, crit)
+	if err != nil {
+		return err
+	}
+	arr.Start()
+	err = api.forEachLogInRange(ctx, tx, begin, end, crit, api.logsLimits(), func(blockLogs types.Logs) error {
+		writeLogs(blockLogs)
+		return stream.Flush()
+	})
+	arr.End()
+	if err != nil {
+		return err
+	}
+	return stream.Flush()
+}
+
+// logsCacheable - only ranges of explicit numbers are cached, "latest" moves with every block
+func logsCacheable(crit filters.FilterCriteria) bool {
+	return crit.BlockHash != nil || (crit.FromBlock != nil && crit.FromBlock.Sign() >= 0 && crit.ToBlock != nil && crit.ToBlock.Sign() >= 0)
+}
+
+func (api *APIImpl) logsLimits() logsLimits {
+	limits := logsLimits{maxResults: api.LogsMaxResults}
+	if api.LogsTimeout > 0 {
+		limits.deadline = time.Now().Add(api.LogsTimeout)
+	}
+	return limits
+}
+
+// getLogs - result of eth_getLogs as slice, served from response cache if possible
+func (api *APIImpl) getLogs(ctx context.Context, crit filters.FilterCriteria) ([]*types.Log, error) {
 	var logs []*types.Log //nolint:prealloc
 
+	var cacheKey string
+	var cacheGen uint64
+	if logsCacheable(crit) {
+		if key, err := json.Marshal(crit); err == nil {
+			cacheKey = "eth_getLogs/" + string(key)
+			cached, gen, ok := api.responseCache.get(cacheKey)
+			if ok {
+				return cached.([]*types.Log), nil
+			}
+			cacheGen = gen
+		}
+	}
+
 	tx, beginErr := api.db.BeginRo(ctx)
 	if beginErr != nil {
 		return returnLogs(logs), beginErr
 	}
 	defer tx.Rollback()
+	begin, end, err := api.logsRange(tx, crit)
+	if err != nil {
+		return nil, err
+	}
+	logs, err = api.getLogsInRange(ctx, tx, begin, end, crit, api.logsLimits())
+	if err != nil {
+		return nil, err
+	}
+	if cacheKey != "" {
+		api.responseCache.add(tx, cacheGen, cacheKey, end, returnLogs(logs))
+	}
+	return returnLogs(logs), nil
+}
 
+// logsRange - blocks eth_getLogs searches with `crit`, checked against --rpc.logs.maxrange
+func (api *APIImpl) logsRange(tx kv.Tx, crit filters.FilterCriteria) (begin, end uint64, err error) {
 	if crit.BlockHash != nil {
 		number := rawdb.ReadHeaderNumber(tx, *crit.BlockHash)
 		if number == nil {
-			return nil, fmt.Errorf("block not found: %x", *crit.BlockHash)
+			return 0, 0, fmt.Errorf("block not found: %x", *crit.BlockHash)
 		}
 		begin = *number
 		end = *number
@@ -87,36 +195,65 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([
 		// Convert the RPC block numbers into internal representations
 		latest, err := getLatestBlockNumber(tx)
 		if err != nil {
-			return nil, err
+			return 0, 0, err
 		}
 
-		begin = latest
-		if crit.FromBlock != nil {
-			if crit.FromBlock.Sign() >= 0 {
-				begin = crit.FromBlock.Uint64()
-			} else if !crit.FromBlock.IsInt64() || crit.FromBlock.Int64() != int64(rpc.LatestBlockNumber) {
-				return nil, fmt.Errorf("negative value for FromBlock: %v", crit.FromBlock)
+		blockNumber := func(number *big.Int, name string) (uint64, error) {
+			switch {
+			case number == nil:
+				return latest, nil
+			case number.Sign() >= 0:
+				return number.Uint64(), nil
+			case !number.IsInt64():
+			case number.Int64() == int64(rpc.LatestBlockNumber):
+				return latest, nil
+			case number.Int64() == int64(rpc.SafeBlockNumber) || number.Int64() == int64(rpc.FinalizedBlockNumber):
+				return rpchelper.ForkChoiceBlockNumber(rpc.BlockNumber(number.Int64()), api.filters)
 			}
+			return 0, fmt.Errorf("negative value for %s: %v", name, number)
 		}
-		end = latest
-		if crit.ToBlock != nil {
-			if crit.ToBlock.Sign() >= 0 {
-				end = crit.ToBlock.Uint64()
-			} else if !crit.ToBlock.IsInt64() || crit.ToBlock.Int64() != int64(rpc.LatestBlockNumber) {
-				return nil, fmt.Errorf("negative value for ToBlock: %v", crit.ToBlock)
-			}
+		if begin, err = blockNumber(crit.FromBlock, "FromBlock"); err != nil {
+			return 0, 0, err
+		}
+		if end, err = blockNumber(crit.ToBlock, "ToBlock"); err != nil {
+			return 0, 0, err
 		}
 	}
 	if end < begin {
-		return nil, fmt.Errorf("end (%d) < begin (%d)", end, begin)
+		return 0, 0, fmt.Errorf("end (%d) < begin (%d)", end, begin)
+	}
+	if api.LogsMaxRange > 0 && end-begin >= api.LogsMaxRange {
+		return 0, 0, &logsLimitError{reason: fmt.Sprintf("query spans more than %d blocks", api.LogsMaxRange), from: begin, to: begin + api.LogsMaxRange - 1}
 	}
+	if err := rpchelper.CheckPruned(tx, rpchelper.PrunedReceipts, begin); err != nil {
+		return 0, 0, err
+	}
+	return begin, end, nil
+}
 
+// getLogsInRange - logs of blocks from `begin` to `end` (inclusive) matching addresses and topics of `crit`. Returns
+// logsLimitError with blocks processed within `limits` if it can't return all of them
+func (api *APIImpl) getLogsInRange(ctx context.Context, tx kv.Tx, begin, end uint64, crit filters.FilterCriteria, limits logsLimits) ([]*types.Log, error) {
+	var logs []*types.Log //nolint:prealloc
+	err := api.forEachLogInRange(ctx, tx, begin, end, crit, limits, func(blockLogs types.Logs) error {
+		logs = append(logs, blockLogs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// forEachLogInRange - passes logs found by getLogsInRange to `each`, block by block
+func (api *APIImpl) forEachLogInRange(ctx context.Context, tx kv.Tx, begin, end uint64, crit filters.FilterCriteria, limits logsLimits, each func(blockLogs types.Logs) error) error {
+	found := 0
 	blockNumbers := roaring.New()
 	blockNumbers.AddRange(begin, end+1) // [min,max)
 
 	topicsBitmap, err := getTopicsBitmap(tx, crit.Topics, uint32(begin), uint32(end))
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if topicsBitmap != nil {
 		if blockNumbers == nil {
@@ -130,7 +267,7 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([
 	for _, addr := range crit.Addresses {
 		m, err := bitmapdb.Get(tx, kv.LogAddressIndex, addr[:], uint32(begin), uint32(end))
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if addrBitmap == nil {
 			addrBitmap = m
@@ -148,16 +285,23 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([
 	}
 
 	if blockNumbers.GetCardinality() == 0 {
-		return returnLogs(logs), nil
+		return nil
+	}
+	timedOut := func() bool { return !limits.deadline.IsZero() && time.Now().After(limits.deadline) }
+	if timedOut() {
+		return &logsLimitError{reason: "query timed out", from: begin, to: begin + (end-begin)/2}
 	}
 
 	iter := blockNumbers.Iterator()
 	for iter.HasNext() {
 		if err = libcommon.Stopped(ctx.Done()); err != nil {
-			return nil, err
+			return err
 		}
 
 		blockNToMatch := uint64(iter.Next())
+		if timedOut() {
+			return &logsLimitError{reason: "query timed out", from: begin, to: lastBlockBefore(begin, blockNToMatch)}
+		}
 		var logIndex uint
 		var blockLogs types.Logs
 		if err := tx.ForPrefix(kv.Log, dbutils.EncodeBlockNumber(blockNToMatch), func(k, v []byte) error {
@@ -179,16 +323,19 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([
 			}
 			return nil
 		}); err != nil {
-			return returnLogs(logs), err
+			return err
 		}
 
+		if limits.maxResults > 0 && found+len(blockLogs) > limits.maxResults {
+			return &logsLimitError{reason: fmt.Sprintf("query returned more than %d results", limits.maxResults), from: begin, to: lastBlockBefore(begin, blockNToMatch)}
+		}
 		if len(blockLogs) > 0 {
 			b, err := api.blockByNumberWithSenders(tx, blockNToMatch)
 			if err != nil {
-				return nil, err
+				return err
 			}
 			if b == nil {
-				return nil, fmt.Errorf("block not found %d", blockNToMatch)
+				return fmt.Errorf("block not found %d", blockNToMatch)
 			}
 			blockHash := b.Hash()
 			for _, log := range blockLogs {
@@ -196,10 +343,21 @@ func (api *APIImpl) GetLogs(ctx context.Context, crit filters.FilterCriteria) ([
 				log.BlockHash = blockHash
 				log.TxHash = b.Transactions()[log.TxIndex].Hash()
 			}
-			logs = append(logs, blockLogs...)
+			found += len(blockLogs)
+			if err := each(blockLogs); err != nil {
+				return err
+			}
 		}
 	}
-	return returnLogs(logs), nil
+	return nil
+}
+
+// lastBlockBefore - the last block of range starting with `begin` which doesn't include `block`, `begin` if there is none
+func lastBlockBefore(begin, block uint64) uint64 {
+	if block > begin {
+		return block - 1
+	}
+	return begin
 }
 
 // The Topic list restricts matches to particular event topics. Each event has a list
@@ -242,6 +400,11 @@ func getTopicsBitmap(c kv.Tx, topics [][]common.Hash, from, to uint32) (*roaring
 
 // GetTransactionReceipt implements eth_getTransactionReceipt. Returns the receipt of a transaction given the transaction's hash.
 func (api *APIImpl) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	cacheKey := "eth_getTransactionReceipt/" + hash.Hex()
+	cached, cacheGen, ok := api.responseCache.get(cacheKey)
+	if ok {
+		return cached.(map[string]interface{}), nil
+	}
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
@@ -253,7 +416,11 @@ func (api *APIImpl) GetTransactionReceipt(ctx context.Context, hash common.Hash)
 		return nil, err
 	}
 	if blockNumber == nil {
-		return nil, nil // not error, see https://github.com/ledgerwatch/erigon/issues/1645
+		// not error, see https://github.com/ledgerwatch/erigon/issues/1645, unless the transaction may be pruned
+		if err = txIndexPruned(tx, hash); err == nil || api.isPending(ctx, hash) {
+			return nil, nil
+		}
+		return nil, err
 	}
 
 	// Extract transactions from block
@@ -288,22 +455,24 @@ func (api *APIImpl) GetTransactionReceipt(ctx context.Context, hash common.Hash)
 	if len(receipts) <= int(txIndex) {
 		return nil, fmt.Errorf("block has less receipts than expected: %d <= %d, block: %d", len(receipts), int(txIndex), blockNumber)
 	}
-	return marshalReceipt(receipts[txIndex], block.Transactions()[txIndex], cc, block), nil
+	receipt := marshalReceipt(receipts[txIndex], block.Transactions()[txIndex], cc, block)
+	api.responseCache.add(tx, cacheGen, cacheKey, *blockNumber, receipt)
+	return receipt, nil
 }
 
-// GetBlockReceipts - receipts for individual block
-func (api *APIImpl) GetBlockReceipts(ctx context.Context, number rpc.BlockNumber) ([]map[string]interface{}, error) {
+// GetBlockReceipts implements eth_getBlockReceipts. Returns receipts of all transactions of the block, given by number or hash.
+func (api *APIImpl) GetBlockReceipts(ctx context.Context, numberOrHash rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	blockNum, err := getBlockNumber(number, tx)
+	blockNum, hash, _, err := rpchelper.GetCanonicalBlockNumber(numberOrHash, tx, api.filters)
 	if err != nil {
 		return nil, err
 	}
-	block, err := api.blockByNumberWithSenders(tx, blockNum)
+	block, err := api.blockWithSenders(tx, hash, blockNum)
 	if err != nil {
 		return nil, err
 	}
@@ -338,6 +507,8 @@ func marshalReceipt(receipt *types.Receipt, txn types.Transaction, chainConfig *
 		chainId = t.ChainID.ToBig()
 	case *types.DynamicFeeTransaction:
 		chainId = t.ChainID.ToBig()
+	case *types.BlobTx:
+		chainId = t.ChainID.ToBig()
 	}
 	signer := types.LatestSignerForChainID(chainId)
 	from, _ := txn.Sender(*signer)
@@ -364,6 +535,12 @@ func marshalReceipt(receipt *types.Receipt, txn types.Transaction, chainConfig *
 		gasPrice := new(big.Int).Add(block.BaseFee(), txn.GetEffectiveGasTip(baseFee).ToBig())
 		fields["effectiveGasPrice"] = hexutil.Uint64(gasPrice.Uint64())
 	}
+	if blobTx, ok := txn.(*types.BlobTx); ok {
+		fields["blobGasUsed"] = hexutil.Uint64(blobTx.GetBlobGas())
+		if excessBlobGas := block.Header().ExcessBlobGas; excessBlobGas != nil {
+			fields["blobGasPrice"] = (*hexutil.Big)(misc.CalcBlobFee(*excessBlobGas))
+		}
+	}
 	// Assign receipt status.
 	fields["status"] = hexutil.Uint64(receipt.Status)
 	if receipt.Logs == nil {
@@ -0,0 +1,316 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/ethdb"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+	"github.com/ledgerwatch/erigon/turbo/shards"
+	"github.com/ledgerwatch/erigon/turbo/transactions"
+)
+
+const (
+	// MaxSimulatedBlocks - maximum amount of blocks in one eth_simulateV1 request
+	MaxSimulatedBlocks = 256
+	// simulatedBlockTime - default time between simulated blocks, in seconds
+	simulatedBlockTime = 12
+)
+
+// SimulationRequest - the first parameter of eth_simulateV1
+type SimulationRequest struct {
+	BlockStateCalls []SimulatedBlock `json:"blockStateCalls"`
+	Validation      bool             `json:"validation"` // check nonces and fees like for real transactions
+}
+
+// SimulatedBlock - calls executed in one simulated block, after state overrides are applied
+type SimulatedBlock struct {
+	BlockOverrides *BlockOverrides        `json:"blockOverrides"`
+	StateOverrides *ethapi.StateOverrides `json:"stateOverrides"`
+	Calls          []ethapi.CallArgs      `json:"calls"`
+}
+
+// BlockOverrides - fields of simulated block header, which are taken from the previous one otherwise
+type BlockOverrides struct {
+	Number        *hexutil.Big    `json:"number"`
+	Time          *hexutil.Uint64 `json:"time"`
+	GasLimit      *hexutil.Uint64 `json:"gasLimit"`
+	FeeRecipient  *common.Address `json:"feeRecipient"`
+	BaseFeePerGas *hexutil.Big    `json:"baseFeePerGas"`
+}
+
+// SimulatedCallResult - result of one call of eth_simulateV1
+type SimulatedCallResult struct {
+	ReturnData hexutil.Bytes       `json:"returnData"`
+	Logs       []*types.Log        `json:"logs"`
+	GasUsed    hexutil.Uint64      `json:"gasUsed"`
+	Status     hexutil.Uint64      `json:"status"`
+	Error      *SimulatedCallError `json:"error,omitempty"`
+}
+
+// SimulatedCallError - reason of failed call, code 3 for reverted ones (data is revert data) and -32015 for other
+// errors of EVM
+type SimulatedCallError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+// simulationError - error of the whole eth_simulateV1 request, with error code of the spec
+type simulationError struct {
+	code    int
+	message string
+}
+
+func (e *simulationError) Error() string  { return e.message }
+func (e *simulationError) ErrorCode() int { return e.code }
+
+// SimulateV1 implements eth_simulateV1. Executes calls in a sequence of blocks on top of the given one, every block
+// sees state left by the previous ones. Returns the blocks (with hashes of transactions made of the calls) and
+// results of the calls
+func (api *APIImpl) SimulateV1(ctx context.Context, req SimulationRequest, blockNrOrHash *rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	if len(req.BlockStateCalls) > MaxSimulatedBlocks {
+		return nil, &simulationError{code: -38026, message: fmt.Sprintf("too many blocks, at most %d are allowed", MaxSimulatedBlocks)}
+	}
+	bNrOrHash := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	if blockNrOrHash != nil {
+		bNrOrHash = *blockNrOrHash
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	contractHasTEVM := func(contractHash common.Hash) (bool, error) { return false, nil }
+	if api.TevmEnabled {
+		contractHasTEVM = ethdb.GetHasTEVM(tx)
+	}
+
+	// "pending" is simulated on top of transactions of pending block, the latest block if there is none
+	var stateReader state.StateReader
+	var parent *types.Header
+	if isPending(bNrOrHash) {
+		var block *types.Block
+		if stateReader, block, err = api.pendingState(ctx, tx); err != nil {
+			return nil, err
+		}
+		if stateReader == nil {
+			bNrOrHash = rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		} else {
+			parent = block.Header()
+		}
+	}
+	if stateReader == nil {
+		blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(bNrOrHash, tx, api.filters)
+		if err != nil {
+			return nil, err
+		}
+		if parent = rawdb.ReadHeader(tx, hash, blockNumber); parent == nil {
+			return nil, fmt.Errorf("header %d(%x) not found", blockNumber, hash)
+		}
+		if stateReader, err = rpchelper.CreateStateReader(ctx, tx, bNrOrHash, api.filters, api.stateCache); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	// state written by every simulated block is kept here, for the next ones
+	stateCache := shards.NewStateCache(32, 0 /* no limit */)
+	cachedReader := state.NewCachedReader(stateReader, stateCache)
+	cachedWriter := state.NewCachedWriter(state.NewNoopWriter(), stateCache)
+	simulatedHashes := map[uint64]common.Hash{}
+	getHash := func(n uint64) common.Hash {
+		if hash, ok := simulatedHashes[n]; ok {
+			return hash
+		}
+		hash, _ := rawdb.ReadCanonicalHash(tx, n)
+		return hash
+	}
+
+	results := make([]map[string]interface{}, 0, len(req.BlockStateCalls))
+	for _, sb := range req.BlockStateCalls {
+		header, err := simulatedHeader(chainConfig, parent, sb.BlockOverrides, req.Validation)
+		if err != nil {
+			return nil, err
+		}
+		ibs := state.New(cachedReader)
+		if sb.StateOverrides != nil {
+			if err := sb.StateOverrides.Override(ibs); err != nil {
+				return nil, err
+			}
+		}
+
+		rules := chainConfig.Rules(header.Number.Uint64())
+		txs := make([]types.Transaction, 0, len(sb.Calls))
+		receipts := make(types.Receipts, 0, len(sb.Calls))
+		calls := make([]*SimulatedCallResult, 0, len(sb.Calls))
+		gp := new(core.GasPool).AddGas(header.GasLimit)
+		for i := range sb.Calls {
+			args := sb.Calls[i]
+			if args.Gas == nil {
+				gas := hexutil.Uint64(gp.Gas())
+				args.Gas = &gas
+			}
+			msg, err := api.simulatedMessage(ibs, &args, header, req.Validation)
+			if err != nil {
+				return nil, err
+			}
+			if msg.Gas() > gp.Gas() {
+				return nil, &simulationError{code: -38015, message: fmt.Sprintf("block gas limit reached: call %d needs %d gas, %d is left", i, msg.Gas(), gp.Gas())}
+			}
+			txn := simulatedTransaction(msg)
+			ibs.Prepare(txn.Hash(), common.Hash{}, i)
+
+			blockCtx, txCtx := transactions.GetEvmContext(msg, header, bNrOrHash.RequireCanonical, tx, contractHasTEVM)
+			blockCtx.GetHash = getHash
+			evm := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vm.Config{NoBaseFee: !req.Validation})
+			go func() {
+				<-ctx.Done()
+				evm.Cancel()
+			}()
+			result, err := core.ApplyMessage(evm, msg, gp, true /* refunds */, false /* gasBailout */)
+			if err != nil {
+				return nil, fmt.Errorf("call %d: %w", i, err)
+			}
+			if evm.Cancelled() {
+				return nil, fmt.Errorf("execution aborted (timeout = %v)", callTimeout)
+			}
+			if err = ibs.FinalizeTx(rules, state.NewNoopWriter()); err != nil {
+				return nil, err
+			}
+			header.GasUsed += result.UsedGas
+
+			call := &SimulatedCallResult{ReturnData: result.Return(), Logs: ibs.GetLogs(txn.Hash()), GasUsed: hexutil.Uint64(result.UsedGas), Status: hexutil.Uint64(types.ReceiptStatusSuccessful)}
+			if result.Failed() {
+				call.Status = hexutil.Uint64(types.ReceiptStatusFailed)
+				call.ReturnData = result.Revert()
+				if len(result.Revert()) > 0 || result.Err == vm.ErrExecutionReverted {
+					revertErr := api.revertDecoder.NewRevertError(result)
+					call.Error = &SimulatedCallError{Code: 3, Message: revertErr.Error(), Data: revertErr.HexData()}
+				} else {
+					call.Error = &SimulatedCallError{Code: -32015, Message: result.Err.Error()}
+				}
+			}
+			if call.Logs == nil {
+				call.Logs = []*types.Log{}
+			}
+			txs = append(txs, txn)
+			receipts = append(receipts, &types.Receipt{Type: txn.Type(), Status: uint64(call.Status), CumulativeGasUsed: header.GasUsed, Logs: call.Logs, Bloom: types.CreateBloom(types.Receipts{{Logs: call.Logs}})})
+			calls = append(calls, call)
+		}
+		if err = ibs.CommitBlock(rules, cachedWriter); err != nil {
+			return nil, err
+		}
+
+		block := types.NewBlock(header, txs, nil, receipts)
+		simulatedHashes[block.NumberU64()] = block.Hash()
+		for _, call := range calls {
+			for _, l := range call.Logs {
+				l.BlockNumber, l.BlockHash = block.NumberU64(), block.Hash()
+			}
+		}
+		fields, err := ethapi.RPCMarshalBlock(block, true, false)
+		if err != nil {
+			return nil, err
+		}
+		fields["calls"] = calls
+		results = append(results, fields)
+		parent = block.Header()
+	}
+	return results, nil
+}
+
+// simulatedHeader - header of the simulated block following parent. Without validation base fee is 0 unless
+// overridden, like gas price of calls
+func simulatedHeader(chainConfig *params.ChainConfig, parent *types.Header, overrides *BlockOverrides, validation bool) (*types.Header, error) {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   parent.Coinbase,
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + simulatedBlockTime,
+	}
+	if overrides != nil {
+		if overrides.Number != nil {
+			if overrides.Number.ToInt().Cmp(parent.Number) <= 0 {
+				return nil, &simulationError{code: -38020, message: fmt.Sprintf("block number %d is not greater than the previous %d", overrides.Number.ToInt(), parent.Number)}
+			}
+			header.Number = new(big.Int).Set(overrides.Number.ToInt())
+		}
+		if overrides.Time != nil {
+			if uint64(*overrides.Time) <= parent.Time {
+				return nil, &simulationError{code: -38021, message: fmt.Sprintf("block timestamp %d is not greater than the previous %d", *overrides.Time, parent.Time)}
+			}
+			header.Time = uint64(*overrides.Time)
+		}
+		if overrides.GasLimit != nil {
+			header.GasLimit = uint64(*overrides.GasLimit)
+		}
+		if overrides.FeeRecipient != nil {
+			header.Coinbase = *overrides.FeeRecipient
+		}
+	}
+	if chainConfig.IsLondon(header.Number.Uint64()) {
+		header.Eip1559 = true
+		switch {
+		case overrides != nil && overrides.BaseFeePerGas != nil:
+			header.BaseFee = new(big.Int).Set(overrides.BaseFeePerGas.ToInt())
+		case validation:
+			header.BaseFee = misc.CalcBaseFee(chainConfig, parent)
+		default:
+			header.BaseFee = new(big.Int)
+		}
+	}
+	return header, nil
+}
+
+// simulatedMessage - message of the call, with the current nonce of the sender unless it's given. Only with
+// validation the nonce is checked
+func (api *APIImpl) simulatedMessage(ibs *state.IntraBlockState, args *ethapi.CallArgs, header *types.Header, validation bool) (types.Message, error) {
+	var baseFee *uint256.Int
+	if header.BaseFee != nil {
+		var overflow bool
+		if baseFee, overflow = uint256.FromBig(header.BaseFee); overflow {
+			return types.Message{}, fmt.Errorf("header.BaseFee uint256 overflow")
+		}
+	}
+	msg, err := args.ToMessage(api.GasCap, baseFee)
+	if err != nil {
+		return types.Message{}, err
+	}
+	nonce := ibs.GetNonce(msg.From())
+	if args.Nonce != nil {
+		nonce = uint64(*args.Nonce)
+	}
+	return types.NewMessage(msg.From(), msg.To(), nonce, msg.Value(), msg.Gas(), msg.GasPrice(), msg.FeeCap(), msg.Tip(), msg.Data(), msg.AccessList(), validation), nil
+}
+
+// simulatedTransaction - unsigned transaction of the simulated call, its hash identifies logs of the call
+func simulatedTransaction(msg types.Message) types.Transaction {
+	if msg.To() == nil {
+		return types.NewContractCreation(msg.Nonce(), msg.Value(), msg.Gas(), msg.GasPrice(), msg.Data())
+	}
+	return types.NewTransaction(msg.Nonce(), *msg.To(), msg.Value(), msg.Gas(), msg.GasPrice(), msg.Data())
+}
@@ -5,8 +5,12 @@ import (
 	"fmt"
 
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/debug"
 	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/misc"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
@@ -14,6 +18,7 @@ import (
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/log/v3"
 )
 
 // BlockNumber implements eth_blockNumber. Returns the block number of most recent block.
@@ -32,45 +37,116 @@ func (api *APIImpl) BlockNumber(ctx context.Context) (hexutil.Uint64, error) {
 
 // Syncing implements eth_syncing. Returns a data object detaling the status of the sync process or false if not syncing.
 func (api *APIImpl) Syncing(ctx context.Context) (interface{}, error) {
-	tx, err := api.db.BeginRo(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer tx.Rollback()
-	highestBlock, err := stages.GetStageProgress(tx, stages.Headers)
+	progress, err := syncProgress(ctx, api.db, api.ethBackend)
 	if err != nil {
 		return false, err
 	}
-
-	currentBlock, err := stages.GetStageProgress(tx, stages.Finish)
-	if err != nil {
-		return false, err
-	}
-
-	if currentBlock > 0 && currentBlock >= highestBlock { // Return not syncing if the synchronisation already completed
+	if !progress.Syncing() { // Return not syncing if the synchronisation already completed
 		return false, nil
 	}
 
-	// Otherwise gather the block sync stats
+	return syncingStatus(progress), nil
+}
+
+// syncingStatus - progress as reported by eth_syncing and eth_subscribe("syncing") while the node is syncing
+func syncingStatus(progress *services.SyncProgress) map[string]interface{} {
 	type S struct {
-		StageName   string         `json:"stage_name"`
-		BlockNumber hexutil.Uint64 `json:"block_number"`
-	}
-	stagesMap := make([]S, len(stages.AllStages))
-	for i, stage := range stages.AllStages {
-		progress, err := stages.GetStageProgress(tx, stage)
-		if err != nil {
-			return nil, err
-		}
-		stagesMap[i].StageName = string(stage)
-		stagesMap[i].BlockNumber = hexutil.Uint64(progress)
+		StageName     string         `json:"stage_name"`
+		BlockNumber   hexutil.Uint64 `json:"block_number"`
+		PruneProgress hexutil.Uint64 `json:"prune_progress"`
+	}
+	stagesMap := make([]S, len(progress.Stages))
+	for i, stage := range progress.Stages {
+		stagesMap[i].StageName = stage.Name
+		stagesMap[i].BlockNumber = hexutil.Uint64(stage.BlockNumber)
+		stagesMap[i].PruneProgress = hexutil.Uint64(stage.PruneProgress)
 	}
 
 	return map[string]interface{}{
-		"currentBlock": hexutil.Uint64(currentBlock),
-		"highestBlock": hexutil.Uint64(highestBlock),
+		"currentBlock": hexutil.Uint64(progress.CurrentBlock),
+		"highestBlock": hexutil.Uint64(progress.HighestBlock),
+		"currentStage": progress.CurrentStage(),
 		"stages":       stagesMap,
-	}, nil
+	}
+}
+
+// SyncingResult - notification of eth_subscribe("syncing") sent when the node starts syncing and on each change of
+// its progress while syncing. Plain `false` is sent when it stops
+type SyncingResult struct {
+	Syncing bool                   `json:"syncing"`
+	Status  map[string]interface{} `json:"status"`
+}
+
+// SyncingAPI - serves eth_subscribe("syncing"), it can't be method of APIImpl, where Syncing is eth_syncing
+type SyncingAPI struct {
+	filters *filters.Filters
+}
+
+func NewSyncingAPI(base *BaseAPI) *SyncingAPI {
+	return &SyncingAPI{filters: base.filters}
+}
+
+// Syncing send a notification each time the node starts or stops syncing, and with progress of stages while it's syncing
+func (api *SyncingAPI) Syncing(ctx context.Context) (*rpc.Subscription, error) {
+	if api.filters == nil {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		defer debug.LogPanic()
+		statuses := make(chan *services.SyncStatusEvent, 1)
+		defer close(statuses)
+		id := api.filters.SubscribeSyncStatus(statuses)
+		defer api.filters.UnsubscribeSyncStatus(id)
+
+		var wasSyncing bool
+		for {
+			select {
+			case status := <-statuses:
+				var notification interface{}
+				switch {
+				case status.Syncing:
+					notification = &SyncingResult{Syncing: true, Status: syncingStatus(&status.SyncProgress)}
+				case wasSyncing:
+					notification = false
+				default:
+					continue
+				}
+				wasSyncing = status.Syncing
+				if err := notifier.Notify(rpcSub.ID, notification); err != nil {
+					log.Warn("error while notifying subscription", "err", err)
+				}
+			case <-rpcSub.Err():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// syncProgress - progress asked from Erigon, which knows also the highest block announced by peers. Read from db if
+// Erigon can't tell it (for example it's older than rpcdaemon)
+func syncProgress(ctx context.Context, db kv.RoDB, eth services.ApiBackend) (*services.SyncProgress, error) {
+	if eth != nil {
+		progress, err := eth.SyncProgress(ctx)
+		if err == nil {
+			return progress, nil
+		}
+		log.Debug("Reading sync progress from db", "reason", err)
+	}
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return services.ReadSyncProgress(tx)
 }
 
 // ChainId implements eth_chainId. Returns the current ethereum chainId.
@@ -109,19 +185,15 @@ func (api *APIImpl) GasPrice(ctx context.Context) (*hexutil.Big, error) {
 		return nil, err
 	}
 	defer tx.Rollback()
-	cc, err := api.chainConfig(tx)
+	oracle, err := api.gasPriceOracle(tx)
 	if err != nil {
 		return nil, err
 	}
-	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, cc, api.BaseAPI), ethconfig.Defaults.GPO)
-	tipcap, err := oracle.SuggestTipCap(ctx)
+	price, err := oracle.SuggestGasPrice(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if head := rawdb.ReadCurrentHeader(tx); head != nil && head.BaseFee != nil {
-		tipcap.Add(tipcap, head.BaseFee)
-	}
-	return (*hexutil.Big)(tipcap), err
+	return (*hexutil.Big)(price), err
 }
 
 // MaxPriorityFeePerGas returns a suggestion for a gas tip cap for dynamic fee transactions.
@@ -131,11 +203,10 @@ func (api *APIImpl) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, err
 		return nil, err
 	}
 	defer tx.Rollback()
-	cc, err := api.chainConfig(tx)
+	oracle, err := api.gasPriceOracle(tx)
 	if err != nil {
 		return nil, err
 	}
-	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, cc, api.BaseAPI), ethconfig.Defaults.GPO)
 	tipcap, err := oracle.SuggestTipCap(ctx)
 	if err != nil {
 		return nil, err
@@ -143,13 +214,93 @@ func (api *APIImpl) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, err
 	return (*hexutil.Big)(tipcap), err
 }
 
+// BlobBaseFee implements eth_blobBaseFee. Returns base fee per blob gas (EIP-4844) of the block following the latest one,
+// null if the latest block has no blob gas fields
+func (api *APIImpl) BlobBaseFee(ctx context.Context) (*hexutil.Big, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	latest, err := getLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	header := rawdb.ReadHeaderByNumber(tx, latest)
+	if header == nil {
+		return nil, fmt.Errorf("header not found: %d", latest)
+	}
+	if header.ExcessBlobGas == nil {
+		return nil, nil
+	}
+	return (*hexutil.Big)(misc.CalcBlobFee(misc.CalcExcessBlobGas(header))), nil
+}
+
+// gasPriceOracle - oracle reading tx, it shares sampled blocks and the last suggestion with all requests
+func (api *APIImpl) gasPriceOracle(tx kv.Tx) (*gasprice.Oracle, error) {
+	cc, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	return gasprice.NewOracle(NewGasPriceOracleBackend(tx, cc, api.BaseAPI), api.GasPriceConfig).WithTipCache(api.gasPriceCache), nil
+}
+
+// startGasPriceOracle - computes suggestion of eth_gasPrice and eth_maxPriorityFeePerGas on every new head, until ctx
+// is done, so that requests are served from cache
+func (api *APIImpl) startGasPriceOracle(ctx context.Context) {
+	if api.filters == nil {
+		return
+	}
+	heads := make(chan *types.Header, 8)
+	api.filters.SubscribeNewHeads(heads)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-heads:
+			}
+			// headers come in bursts during sync, only the latest head matters
+			for drained := false; !drained; {
+				select {
+				case <-heads:
+				default:
+					drained = true
+				}
+			}
+			if err := api.refreshGasPrice(ctx); err != nil {
+				log.Debug("Gas price oracle failed", "err", err)
+			}
+		}
+	}()
+}
+
+func (api *APIImpl) refreshGasPrice(ctx context.Context) error {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	oracle, err := api.gasPriceOracle(tx)
+	if err != nil {
+		return err
+	}
+	_, err = oracle.SuggestTipCap(ctx)
+	return err
+}
+
 type feeHistoryResult struct {
-	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
-	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
-	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
-	GasUsedRatio []float64        `json:"gasUsedRatio"`
+	OldestBlock      *hexutil.Big     `json:"oldestBlock"`
+	Reward           [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee          []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio     []float64        `json:"gasUsedRatio"`
+	BlobBaseFee      []*hexutil.Big   `json:"baseFeePerBlobGas,omitempty"`
+	BlobGasUsedRatio []float64        `json:"blobGasUsedRatio,omitempty"`
 }
 
+// FeeHistory implements eth_feeHistory. Returns base fees, gas used ratios and requested percentiles of priority fees
+// of up to --rpc.feehistory.maxblocks blocks ending with `lastBlock`, base fees and used ratios of blob gas if the blocks
+// have them
 func (api *APIImpl) FeeHistory(ctx context.Context, blockCount rpc.DecimalOrHex, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*feeHistoryResult, error) {
 	tx, err := api.db.BeginRo(ctx)
 	if err != nil {
@@ -160,15 +311,18 @@ func (api *APIImpl) FeeHistory(ctx context.Context, blockCount rpc.DecimalOrHex,
 	if err != nil {
 		return nil, err
 	}
-	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, cc, api.BaseAPI), ethconfig.Defaults.GPO)
+	gpoParams := ethconfig.Defaults.GPO
+	gpoParams.MaxFeeHistory = api.FeeHistoryMaxBlocks
+	oracle := gasprice.NewOracle(NewGasPriceOracleBackend(tx, cc, api.BaseAPI), gpoParams).WithFeeHistoryCache(api.feeHistoryCache)
 
-	oldest, reward, baseFee, gasUsed, err := oracle.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
+	oldest, reward, baseFee, gasUsed, blobBaseFee, blobGasUsed, err := oracle.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
 	if err != nil {
 		return nil, err
 	}
 	results := &feeHistoryResult{
-		OldestBlock:  (*hexutil.Big)(oldest),
-		GasUsedRatio: gasUsed,
+		OldestBlock:      (*hexutil.Big)(oldest),
+		GasUsedRatio:     gasUsed,
+		BlobGasUsedRatio: blobGasUsed,
 	}
 	if reward != nil {
 		results.Reward = make([][]*hexutil.Big, len(reward))
@@ -185,6 +339,12 @@ func (api *APIImpl) FeeHistory(ctx context.Context, blockCount rpc.DecimalOrHex,
 			results.BaseFee[i] = (*hexutil.Big)(v)
 		}
 	}
+	if blobBaseFee != nil {
+		results.BlobBaseFee = make([]*hexutil.Big, len(blobBaseFee))
+		for i, v := range blobBaseFee {
+			results.BlobBaseFee[i] = (*hexutil.Big)(v)
+		}
+	}
 	return results, nil
 }
 
@@ -199,7 +359,7 @@ func NewGasPriceOracleBackend(tx kv.Tx, cc *params.ChainConfig, baseApi *BaseAPI
 }
 
 func (b *GasPriceOracleBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
-	blockNum, err := getBlockNumber(number, b.tx)
+	blockNum, err := getBlockNumber(number, b.tx, b.baseApi.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,324 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncing(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	backend := services.NewRemoteBackend(conn)
+	defer backend.Close()
+	base := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false)
+	api, erigonAPI := NewEthAPI(base, m.DB, backend, nil, nil, 5000000), NewErigonAPI(base, m.DB, backend)
+
+	syncing, err := api.Syncing(ctx)
+	require.NoError(t, err)
+	require.Equal(t, false, syncing)
+	status, err := erigonAPI.SyncStatus(ctx)
+	require.NoError(t, err)
+	require.False(t, status.Syncing)
+	require.Empty(t, status.CurrentStage)
+	require.Len(t, status.Stages, len(stages.AllStages))
+
+	// headers of next blocks are downloaded, the rest of stages are behind
+	tx, err := m.DB.BeginRw(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Headers, status.HighestBlock+10))
+	require.NoError(t, tx.Commit())
+
+	for _, eth := range []services.ApiBackend{backend, nil} { // from Erigon and from db
+		api, erigonAPI = NewEthAPI(base, m.DB, eth, nil, nil, 5000000), NewErigonAPI(base, m.DB, eth)
+		syncing, err = api.Syncing(ctx)
+		require.NoError(t, err)
+		progress := syncing.(map[string]interface{})
+		require.Equal(t, string(stages.BlockHashes), progress["currentStage"])
+		status, err = erigonAPI.SyncStatus(ctx)
+		require.NoError(t, err)
+		require.True(t, status.Syncing)
+		require.Equal(t, string(stages.BlockHashes), status.CurrentStage)
+		require.Equal(t, status.CurrentBlock+10, status.HighestBlock)
+	}
+}
+
+func TestSyncStatusEvents(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	backend := services.NewRemoteBackend(conn)
+	defer backend.Close()
+	ff := filters.New(ctx, backend, nil, nil)
+
+	statuses := make(chan *services.SyncStatusEvent, 1)
+	id := ff.SubscribeSyncStatus(statuses)
+	defer ff.UnsubscribeSyncStatus(id)
+	status := <-statuses // sent on subscription
+	require.False(t, status.Syncing)
+
+	tx, err := m.DB.BeginRw(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stages.SaveStageProgress(tx, stages.Headers, status.HighestBlock+10))
+	require.NoError(t, tx.Commit())
+	m.Notifications.Events.OnNewHeader(nil)
+
+	status = <-statuses
+	require.True(t, status.Syncing)
+	require.Equal(t, status.CurrentBlock+10, status.HighestBlock)
+	require.Equal(t, string(stages.BlockHashes), syncingStatus(&status.SyncProgress)["currentStage"])
+}
+
+func TestNewReceiptsEvents(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	backend := services.NewRemoteBackend(conn)
+	defer backend.Close()
+	ff := filters.New(ctx, backend, nil, nil)
+	api := NewErigonAPI(NewBaseApi(ff, kvcache.New(kvcache.DefaultCoherentConfig), false), m.DB, backend)
+
+	tx, err := m.DB.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	header := rawdb.ReadHeaderByNumber(tx, 5)
+	headerRlp, err := rlp.EncodeToBytes(header)
+	require.NoError(t, err)
+
+	receipts := make(chan *privateapi.BlockReceipts, 1)
+	id := ff.SubscribeReceipts(receipts)
+	defer ff.UnsubscribeReceipts(id)
+	var blockReceipts *privateapi.BlockReceipts
+	for blockReceipts == nil { // header is sent when Erigon already streams receipts
+		m.Notifications.Events.OnNewHeader([][]byte{headerRlp})
+		select {
+		case blockReceipts = <-receipts:
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	require.Equal(t, uint64(5), blockReceipts.Number)
+	require.Equal(t, header.Hash(), blockReceipts.Hash)
+	require.Len(t, blockReceipts.Receipts, 1)
+	require.Equal(t, uint64(5), blockReceipts.Receipts[0].BlockNumber.Uint64())
+
+	result, err := api.newReceiptsResult(tx, blockReceipts, false)
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Uint64(5), result.BlockNumber)
+	require.Len(t, result.Receipts, 1)
+	require.Equal(t, blockReceipts.Receipts[0].TxHash, result.Receipts[0]["transactionHash"])
+	require.NotContains(t, result.Receipts[0], "logs")
+	result, err = api.newReceiptsResult(tx, blockReceipts, true)
+	require.NoError(t, err)
+	require.Contains(t, result.Receipts[0], "logs")
+}
+
+func TestGetReceipts(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	backend := services.NewRemoteBackend(conn)
+	defer backend.Close()
+
+	tx, err := m.DB.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	block, err := rawdb.ReadBlockByNumber(tx, 5)
+	require.NoError(t, err)
+
+	receipts, err := backend.GetReceipts(ctx, block.Hash())
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+	require.Equal(t, block.Transactions()[0].Hash(), receipts[0].TxHash)
+	require.Equal(t, uint64(5), receipts[0].BlockNumber.Uint64())
+
+	receipt, err := backend.GetReceipt(ctx, block.Transactions()[0].Hash())
+	require.NoError(t, err)
+	require.NotNil(t, receipt)
+	require.Equal(t, block.Hash(), receipt.BlockHash)
+
+	receipts, err = backend.GetReceipts(ctx, common.HexToHash("0x1"))
+	require.NoError(t, err)
+	require.Nil(t, receipts)
+	receipt, err = backend.GetReceipt(ctx, common.HexToHash("0x1"))
+	require.NoError(t, err)
+	require.Nil(t, receipt)
+}
+
+func TestChainConfigAndGenesis(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	backend := services.NewRemoteBackend(conn)
+	defer backend.Close()
+
+	cfg, err := backend.ChainConfig(ctx)
+	require.NoError(t, err)
+	require.Equal(t, m.ChainConfig.ChainID, cfg.ChainID)
+	genesis, err := backend.GenesisBlock(ctx)
+	require.NoError(t, err)
+	require.Equal(t, m.Genesis.Hash(), genesis.Hash())
+	backlog, err := backend.ImportBacklog(ctx)
+	require.NoError(t, err)
+	require.Zero(t, backlog)
+}
+
+func TestForkChoiceBlockTags(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	backend := services.NewRemoteBackend(conn)
+	defer backend.Close()
+	ff := filters.New(ctx, backend, nil, nil)
+	base := NewBaseApi(ff, kvcache.New(kvcache.DefaultCoherentConfig), false)
+	api := NewEthAPI(base, m.DB, backend, nil, nil, 5000000)
+
+	_, err := api.GetBlockByNumber(ctx, rpc.FinalizedBlockNumber, false)
+	require.EqualError(t, err, "finalized block not found")
+
+	tx, err := m.DB.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	ref := func(number uint64) privateapi.BlockRef {
+		hash, err := rawdb.ReadCanonicalHash(tx, number)
+		require.NoError(t, err)
+		return privateapi.BlockRef{Number: number, Hash: hash}
+	}
+	safe, finalized := ref(8), ref(6)
+	m.Notifications.Events.OnForkChoice(&privateapi.ForkChoice{Head: ref(10), Safe: &safe, Finalized: &finalized})
+	for ff.ForkChoice() == nil { // sent when filters subscribe
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	block, err := api.GetBlockByNumber(ctx, rpc.FinalizedBlockNumber, false)
+	require.NoError(t, err)
+	require.Equal(t, finalized.Hash, block["hash"])
+	block, err = api.GetBlockByNumber(ctx, rpc.SafeBlockNumber, false)
+	require.NoError(t, err)
+	require.Equal(t, safe.Hash, block["hash"])
+	header, err := HeaderByNumberOrHash(ctx, tx, rpc.BlockNumberOrHashWithNumber(rpc.SafeBlockNumber), ff)
+	require.NoError(t, err)
+	require.Equal(t, safe.Hash, header.Hash())
+
+	notFinal := ref(9)
+	m.Notifications.Events.OnForkChoice(&privateapi.ForkChoice{Head: ref(10), Safe: &notFinal, Finalized: &finalized})
+	for ff.ForkChoice().Safe.Number != 9 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	number, err := getBlockNumber(rpc.SafeBlockNumber, tx, ff)
+	require.NoError(t, err)
+	require.Equal(t, uint64(9), number)
+}
+
+func TestBlobBaseFee(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	backend := services.NewRemoteBackend(conn)
+	defer backend.Close()
+	base := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false)
+	api := NewEthAPI(base, m.DB, backend, nil, nil, 5000000)
+
+	// blocks of test chain are before EIP-4844
+	fee, err := api.BlobBaseFee(ctx)
+	require.NoError(t, err)
+	require.Nil(t, fee)
+	history, err := api.FeeHistory(ctx, 2, rpc.LatestBlockNumber, nil)
+	require.NoError(t, err)
+	require.Len(t, history.GasUsedRatio, 2)
+	require.Nil(t, history.BlobBaseFee)
+	require.Nil(t, history.BlobGasUsedRatio)
+}
+
+func TestBlobSidecars(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	backend := services.NewRemoteBackend(conn)
+	defer backend.Close()
+	base := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false)
+	erigonAPI := NewErigonAPI(base, m.DB, backend)
+
+	tx, err := m.DB.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	hash, err := rawdb.ReadCanonicalHash(tx, 5)
+	require.NoError(t, err)
+
+	sidecars, err := erigonAPI.GetBlobSidecars(ctx, rpc.BlockNumberOrHashWithNumber(5))
+	require.NoError(t, err)
+	require.Nil(t, sidecars)
+
+	m.Notifications.Events.OnBlobSidecars(hash, []*privateapi.BlobSidecar{
+		{BlockHash: hash, Index: 0, Blob: hexutil.Bytes{1, 2, 3}, KZGCommitment: hexutil.Bytes{4}, KZGProof: hexutil.Bytes{5}},
+		{BlockHash: hash, Index: 1, Blob: hexutil.Bytes{6}, KZGCommitment: hexutil.Bytes{7}, KZGProof: hexutil.Bytes{8}},
+	})
+	for _, blockNrOrHash := range []rpc.BlockNumberOrHash{rpc.BlockNumberOrHashWithNumber(5), rpc.BlockNumberOrHashWithHash(hash, true)} {
+		sidecars, err = erigonAPI.GetBlobSidecars(ctx, blockNrOrHash)
+		require.NoError(t, err)
+		require.Len(t, sidecars, 2)
+		require.Equal(t, hash, sidecars[1].BlockHash)
+		require.Equal(t, uint64(1), sidecars[1].Index)
+		require.Equal(t, hexutil.Bytes{1, 2, 3}, sidecars[0].Blob)
+		require.Equal(t, hexutil.Bytes{8}, sidecars[1].KZGProof)
+	}
+	sidecars, err = erigonAPI.GetBlobSidecars(ctx, rpc.BlockNumberOrHashWithNumber(6))
+	require.NoError(t, err)
+	require.Nil(t, sidecars)
+
+	_, err = NewErigonAPI(base, m.DB, nil).GetBlobSidecars(ctx, rpc.BlockNumberOrHashWithNumber(5))
+	require.Error(t, err)
+}
+
+func TestRebuildIndex(t *testing.T) {
+	m, _, _ := rpcdaemontest.CreateTestSentry(t)
+	ctx, conn := rpcdaemontest.CreateTestGrpcConn(t, m)
+	backend := services.NewRemoteBackend(conn)
+	defer backend.Close()
+	base := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false)
+	adminAPI := NewErigonAdminAPI(base, m.DB, backend)
+
+	var txHash common.Hash
+	require.NoError(t, m.DB.Update(ctx, func(tx kv.RwTx) error {
+		block, err := rawdb.ReadBlockByNumber(tx, 1)
+		if err != nil {
+			return err
+		}
+		txHash = block.Transactions()[0].Hash()
+		return tx.ClearBucket(kv.TxLookup)
+	}))
+	lookup := func() *uint64 {
+		tx, err := m.DB.BeginRo(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback()
+		blockNum, err := rawdb.ReadTxLookupEntry(tx, txHash)
+		require.NoError(t, err)
+		return blockNum
+	}
+	require.Nil(t, lookup())
+
+	_, err := adminAPI.RebuildIndex(ctx, "receipts", rpc.EarliestBlockNumber, rpc.LatestBlockNumber)
+	require.Error(t, err)
+
+	rebuild, err := adminAPI.RebuildIndex(ctx, "tx-lookup", rpc.EarliestBlockNumber, rpc.LatestBlockNumber)
+	require.NoError(t, err)
+	require.Equal(t, "tx-lookup", rebuild.Name)
+	require.Equal(t, uint64(0), rebuild.FromBlock)
+	require.Nil(t, rebuild.Finished)
+	require.Eventually(t, func() bool {
+		rebuilds, err := adminAPI.IndexRebuilds(ctx)
+		require.NoError(t, err)
+		require.Len(t, rebuilds, 1)
+		return rebuilds[0].Finished != nil
+	}, 10*time.Second, 10*time.Millisecond)
+	rebuilds, err := adminAPI.IndexRebuilds(ctx)
+	require.NoError(t, err)
+	require.Empty(t, rebuilds[0].Error)
+	require.Equal(t, rebuild.ToBlock, *rebuilds[0].CurrentBlock)
+	require.Equal(t, uint64(1), *lookup())
+}
@@ -8,12 +8,14 @@ import (
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	types2 "github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
 // GetTransactionByHash implements eth_getTransactionByHash. Returns information about a transaction given the transaction's hash.
@@ -74,7 +76,29 @@ func (api *APIImpl) GetTransactionByHash(ctx context.Context, hash common.Hash)
 	}
 
 	// Transaction unknown, return as such
-	return nil, nil
+	return nil, txIndexPruned(tx, hash)
+}
+
+// txIndexPruned - PrunedError for transaction not found by hash on node which doesn't index old transactions, nil if
+// it's not found on node indexing all of them
+func txIndexPruned(tx kv.Tx, hash common.Hash) error {
+	_, earliest, err := rpchelper.EarliestAvailableBlocks(tx)
+	if err != nil {
+		return err
+	}
+	if earliest[rpchelper.PrunedTxIndex] > 0 {
+		return &rpchelper.PrunedError{Kind: rpchelper.PrunedTxIndex, Hash: hash, Earliest: earliest[rpchelper.PrunedTxIndex]}
+	}
+	return nil
+}
+
+// isPending - true if the transaction is in the pool
+func (api *APIImpl) isPending(ctx context.Context, hash common.Hash) bool {
+	if api.txPool == nil {
+		return false
+	}
+	reply, err := api.txPool.Transactions(ctx, &txpool.TransactionsRequest{Hashes: []*types.H256{gointerfaces.ConvertHashToH256(hash)}})
+	return err == nil && len(reply.RlpTxs) > 0 && len(reply.RlpTxs[0]) > 0
 }
 
 // GetRawTransactionByHash returns the bytes of the transaction for the given hash.
@@ -104,7 +128,7 @@ func (api *APIImpl) GetRawTransactionByHash(ctx context.Context, hash common.Has
 	if len(reply.RlpTxs[0]) > 0 {
 		return reply.RlpTxs[0], nil
 	}
-	return nil, nil
+	return nil, txIndexPruned(tx, hash)
 }
 
 // GetTransactionByBlockHashAndIndex implements eth_getTransactionByBlockHashAndIndex. Returns information about a transaction given the block's hash and a transaction index.
@@ -161,7 +185,7 @@ func (api *APIImpl) GetTransactionByBlockNumberAndIndex(ctx context.Context, blo
 	defer tx.Rollback()
 
 	// https://infura.io/docs/ethereum/json-rpc/eth-getTransactionByBlockNumberAndIndex
-	blockNum, err := getBlockNumber(blockNr, tx)
+	blockNum, err := getBlockNumber(blockNr, tx, api.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/health"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
+)
+
+// HealthAPI the interface for the health_ RPC commands, also served at /health and /ready for load balancers and Kubernetes probes
+type HealthAPI interface {
+	Live(_ context.Context) *health.Report
+	Ready(_ context.Context) *health.Report
+}
+
+// HealthAPIImpl data structure to store things needed for health_ commands
+type HealthAPIImpl struct {
+	live  health.Checks
+	ready health.Checks
+}
+
+// NewHealthAPI returns HealthAPIImpl instance. Erigon must be reachable and serve compatible ETHBACKEND for liveness,
+// readiness also requires maxSyncLag and minPeers if they are not 0
+func NewHealthAPI(db kv.RoDB, eth services.ApiBackend, maxSyncLag, minPeers uint64) *HealthAPIImpl {
+	api := &HealthAPIImpl{}
+	if backend, ok := eth.(health.Backend); ok {
+		api.AddLivenessCheck("backend_reachable", health.BackendReachable(backend))
+		api.AddLivenessCheck("backend_version", health.BackendVersion(backend))
+	}
+	if maxSyncLag > 0 {
+		api.AddReadinessCheck("sync_lag", health.SyncLag(db, maxSyncLag))
+	}
+	if minPeers > 0 && eth != nil {
+		api.AddReadinessCheck("min_peers", health.MinPeers(minPeers, eth))
+	}
+	return api
+}
+
+// AddLivenessCheck - check is also run by Ready: daemon which isn't live isn't ready
+func (api *HealthAPIImpl) AddLivenessCheck(name string, check health.Check) {
+	api.live.Add(name, check)
+	api.ready.Add(name, check)
+}
+
+func (api *HealthAPIImpl) AddReadinessCheck(name string, check health.Check) {
+	api.ready.Add(name, check)
+}
+
+// Live implements health_live. Returns results of liveness checks.
+func (api *HealthAPIImpl) Live(ctx context.Context) *health.Report {
+	return api.live.Run(ctx)
+}
+
+// Ready implements health_ready. Returns results of readiness checks.
+func (api *HealthAPIImpl) Ready(ctx context.Context) *health.Report {
+	return api.ready.Run(ctx)
+}
@@ -29,9 +29,13 @@ func NewNetAPIImpl(eth services.ApiBackend) *NetAPIImpl {
 }
 
 // Listening implements net_listening. Returns true if client is actively listening for network connections.
-// TODO: Remove hard coded value
-func (api *NetAPIImpl) Listening(_ context.Context) (bool, error) {
-	return true, nil
+func (api *NetAPIImpl) Listening(ctx context.Context) (bool, error) {
+	if api.ethBackend == nil {
+		// We're running in --datadir mode or otherwise cannot get the backend
+		return false, fmt.Errorf(NotAvailableChainData, "net_listening")
+	}
+
+	return api.ethBackend.Listening(ctx)
 }
 
 // Version implements net_version. Returns the current network id.
@@ -0,0 +1,250 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/adapter/ethapi"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// otterscanApiLevel - Otterscan checks it on start to know which ots_ methods it can use
+const otterscanApiLevel = 8
+
+// OtterscanAPI the interface for the ots_* RPC commands, used by Otterscan block explorer (https://github.com/wmitsuda/otterscan)
+type OtterscanAPI interface {
+	GetApiLevel() uint8
+
+	// Blocks related (see ./otterscan_api.go)
+	GetBlockDetails(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error)
+	GetBlockDetailsByHash(ctx context.Context, hash common.Hash) (map[string]interface{}, error)
+	GetBlockTransactions(ctx context.Context, number rpc.BlockNumber, pageNumber uint8, pageSize uint8) (map[string]interface{}, error)
+	HasCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (bool, error)
+
+	// Transaction related (see ./otterscan_trace.go)
+	TraceTransaction(ctx context.Context, hash common.Hash) ([]*TraceEntry, error)
+	GetTransactionError(ctx context.Context, hash common.Hash) (hexutil.Bytes, error)
+	GetInternalOperations(ctx context.Context, hash common.Hash) ([]*InternalOperation, error)
+
+	// Search related (see ./otterscan_search.go)
+	SearchTransactionsBefore(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*TransactionsWithReceipts, error)
+	SearchTransactionsAfter(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*TransactionsWithReceipts, error)
+
+	// History related (see ./otterscan_history.go)
+	GetTransactionBySenderAndNonce(ctx context.Context, addr common.Address, nonce uint64) (*common.Hash, error)
+	GetContractCreator(ctx context.Context, addr common.Address) (*ContractCreatorData, error)
+}
+
+// OtterscanAPIImpl is implementation of the OtterscanAPI interface
+type OtterscanAPIImpl struct {
+	*BaseAPI
+	db kv.RoDB
+}
+
+// NewOtterscanAPI returns OtterscanAPIImpl instance
+func NewOtterscanAPI(base *BaseAPI, db kv.RoDB) *OtterscanAPIImpl {
+	return &OtterscanAPIImpl{
+		BaseAPI: base,
+		db:      db,
+	}
+}
+
+// GetApiLevel implements ots_getApiLevel. Returns version of ots_ namespace.
+func (api *OtterscanAPIImpl) GetApiLevel() uint8 {
+	return otterscanApiLevel
+}
+
+// GetBlockDetails implements ots_getBlockDetails. Returns block without transactions, its issuance and total fees.
+func (api *OtterscanAPIImpl) GetBlockDetails(ctx context.Context, number rpc.BlockNumber) (map[string]interface{}, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	block, err := api.blockByRPCNumber(number, tx)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	return api.blockDetails(ctx, tx, block)
+}
+
+// GetBlockDetailsByHash implements ots_getBlockDetailsByHash. Same as ots_getBlockDetails, block is found by hash.
+func (api *OtterscanAPIImpl) GetBlockDetailsByHash(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	block, err := api.blockByHashWithSenders(tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	return api.blockDetails(ctx, tx, block)
+}
+
+func (api *OtterscanAPIImpl) blockDetails(ctx context.Context, tx kv.Tx, block *types.Block) (map[string]interface{}, error) {
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := marshalBlockWithoutTxs(tx, block)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := getReceipts(ctx, tx, chainConfig, block, block.Body().SendersFromTxs())
+	if err != nil {
+		return nil, fmt.Errorf("getReceipts error: %w", err)
+	}
+	return map[string]interface{}{
+		"block":     fields,
+		"issuance":  blockIssuance(chainConfig, block),
+		"totalFees": (*hexutil.Big)(blockFees(block, receipts)),
+	}, nil
+}
+
+// marshalBlockWithoutTxs - block as eth_getBlockByNumber returns it, transactions are replaced by their amount
+func marshalBlockWithoutTxs(tx kv.Tx, block *types.Block) (map[string]interface{}, error) {
+	td, err := rawdb.ReadTd(tx, block.Hash(), block.NumberU64())
+	if err != nil {
+		return nil, err
+	}
+	fields, err := ethapi.RPCMarshalBlock(block, false, false, map[string]interface{}{"totalDifficulty": (*hexutil.Big)(td)})
+	if err != nil {
+		return nil, err
+	}
+	fields["transactionCount"] = block.Transactions().Len()
+	fields["logsBloom"] = nil // Otterscan doesn't use it, saves bandwidth
+	return fields, nil
+}
+
+func blockIssuance(chainConfig *params.ChainConfig, block *types.Block) map[string]interface{} {
+	if chainConfig.Ethash == nil {
+		// Clique for example has no issuance
+		return map[string]interface{}{}
+	}
+	minerReward, uncleRewards := ethash.AccumulateRewards(chainConfig, block.Header(), block.Uncles())
+	uncleReward := new(uint256.Int)
+	for i := range uncleRewards {
+		uncleReward.Add(uncleReward, &uncleRewards[i])
+	}
+	issuance := new(uint256.Int).Add(&minerReward, uncleReward)
+	return map[string]interface{}{
+		"blockReward": (*hexutil.Big)(minerReward.ToBig()),
+		"uncleReward": (*hexutil.Big)(uncleReward.ToBig()),
+		"issuance":    (*hexutil.Big)(issuance.ToBig()),
+	}
+}
+
+// blockFees - sum of gas used by transactions multiplied by their effective gas price, including burnt base fee
+func blockFees(block *types.Block, receipts types.Receipts) *big.Int {
+	var baseFee *uint256.Int
+	if block.BaseFee() != nil {
+		baseFee, _ = uint256.FromBig(block.BaseFee())
+	}
+	fees := new(big.Int)
+	for i, txn := range block.Transactions() {
+		price := txn.GetPrice().ToBig()
+		if baseFee != nil {
+			price = new(big.Int).Add(block.BaseFee(), txn.GetEffectiveGasTip(baseFee).ToBig())
+		}
+		fees.Add(fees, price.Mul(price, new(big.Int).SetUint64(receipts[i].GasUsed)))
+	}
+	return fees
+}
+
+// GetBlockTransactions implements ots_getBlockTransactions. Returns page of block transactions with their receipts,
+// page 0 contains last transactions of the block. Input of transactions is truncated to 4 bytes of method selector,
+// logs of receipts are omitted.
+func (api *OtterscanAPIImpl) GetBlockTransactions(ctx context.Context, number rpc.BlockNumber, pageNumber uint8, pageSize uint8) (map[string]interface{}, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	block, err := api.blockByRPCNumber(number, tx)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := marshalBlockWithoutTxs(tx, block)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := getReceipts(ctx, tx, chainConfig, block, block.Body().SendersFromTxs())
+	if err != nil {
+		return nil, fmt.Errorf("getReceipts error: %w", err)
+	}
+
+	pageEnd := block.Transactions().Len() - int(pageNumber)*int(pageSize)
+	if pageEnd < 0 {
+		pageEnd = 0
+	}
+	pageStart := pageEnd - int(pageSize)
+	if pageStart < 0 {
+		pageStart = 0
+	}
+	txs := make([]*RPCTransaction, 0, pageEnd-pageStart)
+	marshalledReceipts := make([]map[string]interface{}, 0, pageEnd-pageStart)
+	for i := pageStart; i < pageEnd; i++ {
+		txn := block.Transactions()[i]
+		rpcTx := newRPCTransaction(txn, block.Hash(), block.NumberU64(), uint64(i), block.BaseFee())
+		if len(rpcTx.Input) > 4 {
+			rpcTx.Input = rpcTx.Input[:4]
+		}
+		txs = append(txs, rpcTx)
+		marshalledReceipts = append(marshalledReceipts, marshalOtsReceipt(receipts[i], txn, chainConfig, block))
+	}
+	fields["transactions"] = txs
+	return map[string]interface{}{
+		"fullblock": fields,
+		"receipts":  marshalledReceipts,
+	}, nil
+}
+
+// HasCode implements ots_hasCode. Returns true if account has code at given block.
+func (api *OtterscanAPIImpl) HasCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (bool, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	reader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, api.filters, api.stateCache)
+	if err != nil {
+		return false, err
+	}
+	acc, err := reader.ReadAccountData(address)
+	if err != nil || acc == nil {
+		return false, err
+	}
+	code, err := reader.ReadAccountCode(address, acc.Incarnation, acc.CodeHash)
+	if err != nil {
+		return false, err
+	}
+	return len(code) > 0, nil
+}
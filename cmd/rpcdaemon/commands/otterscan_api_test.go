@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOtterscanAPI(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	api := NewOtterscanAPI(NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false), db)
+	ctx := context.Background()
+
+	sender := common.HexToAddress("0x71562b71999873db5b286df957af199ec94617f7")
+	theAddr := common.Address{1}
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	blocks := make([]*types.Block, 11)
+	for i := range blocks {
+		hash, err := rawdb.ReadCanonicalHash(tx, uint64(i))
+		require.NoError(t, err)
+		blocks[i] = rawdb.ReadBlock(tx, hash, uint64(i))
+		require.NotNil(t, blocks[i])
+	}
+	tx.Rollback()
+
+	require.EqualValues(t, otterscanApiLevel, api.GetApiLevel())
+
+	details, err := api.GetBlockDetails(ctx, rpc.BlockNumber(6))
+	require.NoError(t, err)
+	require.Equal(t, 32, details["block"].(map[string]interface{})["transactionCount"])
+	require.NotNil(t, details["totalFees"])
+
+	page, err := api.GetBlockTransactions(ctx, rpc.BlockNumber(6), 0, 10)
+	require.NoError(t, err)
+	pageTxs := page["fullblock"].(map[string]interface{})["transactions"].([]*RPCTransaction)
+	require.Len(t, pageTxs, 10)
+	require.Equal(t, blocks[6].Transactions()[31].Hash(), pageTxs[9].Hash)
+
+	// theAddr received ether in blocks 1 and 2
+	before, err := api.SearchTransactionsBefore(ctx, theAddr, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, before.Txs, 2)
+	require.Equal(t, blocks[2].Transactions()[0].Hash(), before.Txs[0].Hash)
+	require.Equal(t, blocks[1].Transactions()[0].Hash(), before.Txs[1].Hash)
+	require.True(t, before.FirstPage)
+	require.True(t, before.LastPage)
+
+	after, err := api.SearchTransactionsAfter(ctx, theAddr, 0, 1)
+	require.NoError(t, err)
+	require.Len(t, after.Txs, 1)
+	require.Equal(t, blocks[1].Transactions()[0].Hash(), after.Txs[0].Hash)
+	require.False(t, after.FirstPage)
+	require.True(t, after.LastPage)
+
+	// token contract is deployed in block 3 with sender's nonce 2
+	hash, err := api.GetTransactionBySenderAndNonce(ctx, sender, 2)
+	require.NoError(t, err)
+	require.NotNil(t, hash)
+	require.Equal(t, blocks[3].Transactions()[0].Hash(), *hash)
+	hash, err = api.GetTransactionBySenderAndNonce(ctx, sender, 1000)
+	require.NoError(t, err)
+	require.Nil(t, hash)
+
+	creator, err := api.GetContractCreator(ctx, crypto.CreateAddress(sender, 2))
+	require.NoError(t, err)
+	require.NotNil(t, creator)
+	require.Equal(t, blocks[3].Transactions()[0].Hash(), creator.Tx)
+	require.Equal(t, sender, creator.Creator)
+	creator, err = api.GetContractCreator(ctx, theAddr)
+	require.NoError(t, err)
+	require.Nil(t, creator)
+
+	hasCode, err := api.HasCode(ctx, crypto.CreateAddress(sender, 2), rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber))
+	require.NoError(t, err)
+	require.True(t, hasCode)
+
+	// Poly.deployAndDestruct creates contract with CREATE2, which self-destructs on call
+	ops, err := api.GetInternalOperations(ctx, blocks[10].Transactions()[0].Hash())
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+	require.Equal(t, OpCreate2, ops[0].Type)
+	require.Equal(t, OpSelfDestruct, ops[1].Type)
+
+	trace, err := api.TraceTransaction(ctx, blocks[10].Transactions()[0].Hash())
+	require.NoError(t, err)
+	require.Equal(t, "CALL", trace[0].Type)
+	require.Equal(t, 0, trace[0].Depth)
+
+	revert, err := api.GetTransactionError(ctx, blocks[10].Transactions()[0].Hash())
+	require.NoError(t, err)
+	require.Empty(t, revert)
+}
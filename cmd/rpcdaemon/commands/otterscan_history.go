@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/types/accounts"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
+)
+
+// ContractCreatorData - result of ots_getContractCreator
+type ContractCreatorData struct {
+	Tx      common.Hash    `json:"hash"`
+	Creator common.Address `json:"creator"`
+}
+
+// GetTransactionBySenderAndNonce implements ots_getTransactionBySenderAndNonce. Returns hash of transaction sent by
+// addr with given nonce, nil if there is no such transaction.
+func (api *OtterscanAPIImpl) GetTransactionBySenderAndNonce(ctx context.Context, addr common.Address, nonce uint64) (*common.Hash, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	block, idx, err := api.transactionBySenderAndNonce(tx, addr, nonce)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	hash := block.Transactions()[idx].Hash()
+	return &hash, nil
+}
+
+// transactionBySenderAndNonce - block and index in it of transaction sent by addr with given nonce, nil block if there
+// is no such transaction. There is no index of nonces: it costs O(log n) reads of account history, PrunedError if
+// nonce was used before the earliest history
+func (api *BaseAPI) transactionBySenderAndNonce(tx kv.Tx, addr common.Address, nonce uint64) (*types.Block, int, error) {
+	// nonce only grows, so the block with transaction is the first one after which nonce exceeds the given one
+	blockNum, found, err := searchAccountHistory(tx, addr, func(acc *accounts.Account) bool {
+		return acc != nil && acc.Nonce > nonce
+	})
+	if err != nil || !found {
+		return nil, 0, err
+	}
+	block, err := api.blockByNumberWithSenders(tx, blockNum)
+	if err != nil {
+		return nil, 0, err
+	}
+	if block == nil {
+		return nil, 0, fmt.Errorf("block %d not found", blockNum)
+	}
+	senders := block.Body().SendersFromTxs()
+	for i, txn := range block.Transactions() {
+		if senders[i] == addr && txn.GetNonce() == nonce {
+			return block, i, nil
+		}
+	}
+	// nonce of contract is incremented by CREATE opcode, not by transactions
+	return nil, 0, nil
+}
+
+// GetContractCreator implements ots_getContractCreator. Returns transaction which deployed contract at addr and
+// address which executed the deployment, nil if addr is not a contract or it was a part of genesis.
+func (api *OtterscanAPIImpl) GetContractCreator(ctx context.Context, addr common.Address) (*ContractCreatorData, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// binary search expects code to never disappear, for self-destructed and re-created contracts any of deployments may be found
+	blockNum, found, err := searchAccountHistory(tx, addr, func(acc *accounts.Account) bool {
+		return acc != nil && !acc.IsEmptyCodeHash()
+	})
+	if err != nil || !found || blockNum == 0 {
+		return nil, err
+	}
+	block, err := api.blockByNumberWithSenders(tx, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", blockNum)
+	}
+
+	var result *ContractCreatorData
+	err = api.traceBlockTxs(ctx, tx, block, func(int) vm.Tracer {
+		return &createTracer{target: addr}
+	}, func(idx int, tracer vm.Tracer) bool {
+		t := tracer.(*createTracer)
+		if !t.found {
+			return true
+		}
+		result = &ContractCreatorData{Tx: block.Transactions()[idx].Hash(), Creator: t.creator}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// searchAccountHistory - binary search of the first block after which account state satisfies pred, pred has to be
+// monotonic over blocks. Reports false if pred is not satisfied by the latest state, PrunedError if it's satisfied
+// already by the earliest state of pruned history.
+func searchAccountHistory(tx kv.Tx, addr common.Address, pred func(acc *accounts.Account) bool) (uint64, bool, error) {
+	latest, err := getLatestBlockNumber(tx)
+	if err != nil {
+		return 0, false, err
+	}
+	_, earliestBlocks, err := rpchelper.EarliestAvailableBlocks(tx)
+	if err != nil {
+		return 0, false, err
+	}
+	earliest := earliestBlocks[rpchelper.PrunedHistory]
+	if earliest > latest {
+		earliest = latest
+	}
+	var searchErr error
+	check := func(blockNum uint64) bool {
+		if searchErr != nil {
+			return true
+		}
+		acc, err := state.NewPlainState(tx, blockNum).ReadAccountData(addr)
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return pred(acc)
+	}
+	if !check(latest) {
+		return 0, false, searchErr
+	}
+	if earliest > 0 && check(earliest) {
+		if searchErr != nil {
+			return 0, false, searchErr
+		}
+		return 0, false, &rpchelper.PrunedError{Kind: rpchelper.PrunedHistory, Block: earliest, Earliest: earliest}
+	}
+	blockNum := earliest + uint64(sort.Search(int(latest-earliest), func(i int) bool { return check(earliest + uint64(i)) }))
+	if searchErr != nil {
+		return 0, false, searchErr
+	}
+	return blockNum, true, nil
+}
+
+// createTracer - finds call frame successfully deploying contract at target address
+type createTracer struct {
+	DefaultTracer
+	target  common.Address
+	found   bool
+	depth   int
+	creator common.Address
+}
+
+func (t *createTracer) CaptureStart(depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) error {
+	if create && to == t.target && !t.found {
+		t.found = true
+		t.depth = depth
+		t.creator = from
+	}
+	return nil
+}
+
+func (t *createTracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64, d time.Duration, err error) error {
+	// failure of the deploying frame or any of its parents reverts the deployment
+	if t.found && err != nil && depth <= t.depth {
+		t.found = false
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RoaringBitmap/roaring/roaring64"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+// TransactionsWithReceipts - page of ots_searchTransactionsBefore/After results, sorted from newest to oldest.
+// FirstPage is set if there are no newer transactions, LastPage - if there are no older ones.
+type TransactionsWithReceipts struct {
+	Txs       []*RPCTransaction        `json:"txs"`
+	Receipts  []map[string]interface{} `json:"receipts"`
+	FirstPage bool                     `json:"firstPage"`
+	LastPage  bool                     `json:"lastPage"`
+}
+
+// SearchTransactionsBefore implements ots_searchTransactionsBefore. Returns at least pageSize transactions
+// (whole blocks are returned) which touched given address in blocks before blockNum, 0 means search from the
+// latest block.
+func (api *OtterscanAPIImpl) SearchTransactionsBefore(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*TransactionsWithReceipts, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	isFirstPage := false
+	if blockNum == 0 {
+		isFirstPage = true
+		latest, err := getLatestBlockNumber(tx)
+		if err != nil {
+			return nil, err
+		}
+		blockNum = latest + 1
+	}
+	if blockNum == 0 {
+		return &TransactionsWithReceipts{FirstPage: true, LastPage: true}, nil
+	}
+	blocks, err := addressBlocks(tx, addr, 0, blockNum-1)
+	if err != nil {
+		return nil, err
+	}
+	result, hasMore, err := api.searchBlocks(ctx, tx, addr, blocks.ReverseIterator(), true /* newestFirst */, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	result.FirstPage = isFirstPage
+	result.LastPage = !hasMore
+	return result, nil
+}
+
+// SearchTransactionsAfter implements ots_searchTransactionsAfter. Returns at least pageSize transactions
+// (whole blocks are returned) which touched given address in blocks after blockNum, 0 means search from the
+// genesis. Same as ots_searchTransactionsBefore results are sorted from newest to oldest.
+func (api *OtterscanAPIImpl) SearchTransactionsAfter(ctx context.Context, addr common.Address, blockNum uint64, pageSize uint16) (*TransactionsWithReceipts, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	isLastPage := false
+	from := blockNum + 1
+	if blockNum == 0 {
+		isLastPage = true
+		from = 0
+	}
+	latest, err := getLatestBlockNumber(tx)
+	if err != nil {
+		return nil, err
+	}
+	if from > latest {
+		return &TransactionsWithReceipts{FirstPage: true, LastPage: isLastPage}, nil
+	}
+	blocks, err := addressBlocks(tx, addr, from, latest)
+	if err != nil {
+		return nil, err
+	}
+	result, hasMore, err := api.searchBlocks(ctx, tx, addr, blocks.Iterator(), false /* newestFirst */, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	// blocks were visited from oldest to newest, Otterscan expects newest first
+	for i, j := 0, len(result.Txs)-1; i < j; i, j = i+1, j-1 {
+		result.Txs[i], result.Txs[j] = result.Txs[j], result.Txs[i]
+		result.Receipts[i], result.Receipts[j] = result.Receipts[j], result.Receipts[i]
+	}
+	result.FirstPage = !hasMore
+	result.LastPage = isLastPage
+	return result, nil
+}
+
+// addressBlocks - numbers of blocks in [from, to] range where address was sender or recipient of any call. Blocks of
+// pruned call traces index are skipped, PrunedError if the whole range is pruned
+func addressBlocks(tx kv.Tx, addr common.Address, from, to uint64) (*roaring64.Bitmap, error) {
+	from, err := callTracesFrom(tx, from, to, true /* clamp */)
+	if err != nil {
+		return nil, err
+	}
+	blocks := roaring64.New()
+	for _, index := range []string{kv.CallFromIndex, kv.CallToIndex} {
+		b, err := callTraceBlocks(tx, index, addr, from, to)
+		if err != nil {
+			return nil, err
+		}
+		blocks.Or(b)
+	}
+	// Get64 operates on whole shards, so it may return blocks out of range
+	blocks.RemoveRange(0, from)
+	blocks.RemoveRange(to+1, ^uint64(0))
+	return blocks, nil
+}
+
+// searchBlocks - collects transactions touching address from blocks in iteration order until pageSize is reached,
+// reports if there are more blocks left. newestFirst tells if blocks are iterated in descending order.
+func (api *OtterscanAPIImpl) searchBlocks(ctx context.Context, tx kv.Tx, addr common.Address, it roaring64.IntIterable64, newestFirst bool, pageSize uint16) (*TransactionsWithReceipts, bool, error) {
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, false, err
+	}
+	result := &TransactionsWithReceipts{
+		Txs:      []*RPCTransaction{},
+		Receipts: []map[string]interface{}{},
+	}
+	for it.HasNext() {
+		if len(result.Txs) >= int(pageSize) {
+			return result, true, nil
+		}
+		blockNum := it.Next()
+		block, err := api.blockByNumberWithSenders(tx, blockNum)
+		if err != nil {
+			return nil, false, err
+		}
+		if block == nil {
+			return nil, false, fmt.Errorf("block %d not found", blockNum)
+		}
+		var found []int
+		err = api.traceBlockTxs(ctx, tx, block, func(int) vm.Tracer {
+			return &touchTracer{addr: addr}
+		}, func(idx int, tracer vm.Tracer) bool {
+			if tracer.(*touchTracer).touched {
+				found = append(found, idx)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		if len(found) == 0 {
+			continue
+		}
+		receipts, err := getReceipts(ctx, tx, chainConfig, block, block.Body().SendersFromTxs())
+		if err != nil {
+			return nil, false, fmt.Errorf("getReceipts error: %w", err)
+		}
+		if newestFirst {
+			for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+				found[i], found[j] = found[j], found[i]
+			}
+		}
+		for _, idx := range found {
+			txn := block.Transactions()[idx]
+			result.Txs = append(result.Txs, newRPCTransaction(txn, block.Hash(), blockNum, uint64(idx), block.BaseFee()))
+			result.Receipts = append(result.Receipts, marshalOtsReceipt(receipts[idx], txn, chainConfig, block))
+		}
+	}
+	return result, false, nil
+}
+
+// marshalOtsReceipt - receipt without logs and adding block timestamp, which Otterscan shows in lists of transactions
+func marshalOtsReceipt(receipt *types.Receipt, txn types.Transaction, chainConfig *params.ChainConfig, block *types.Block) map[string]interface{} {
+	fields := marshalReceipt(receipt, txn, chainConfig, block)
+	fields["logs"] = nil
+	fields["logsBloom"] = nil
+	fields["timestamp"] = block.Time()
+	return fields
+}
@@ -0,0 +1,278 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/core/vm/stack"
+	"github.com/ledgerwatch/erigon/ethdb"
+	"github.com/ledgerwatch/erigon/turbo/transactions"
+)
+
+// TraceEntry - one call frame of ots_traceTransaction
+type TraceEntry struct {
+	Type  string         `json:"type"`
+	Depth int            `json:"depth"`
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value *hexutil.Big   `json:"value"`
+	Input hexutil.Bytes  `json:"input"`
+}
+
+// Kinds of InternalOperation
+const (
+	OpTransfer     = 0
+	OpSelfDestruct = 1
+	OpCreate       = 2
+	OpCreate2      = 3
+)
+
+// InternalOperation - ETH movement or contract creation happened inside of transaction (not at its top level)
+type InternalOperation struct {
+	Type  int            `json:"type"`
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Value *hexutil.Big   `json:"value"`
+}
+
+// DefaultTracer - vm.Tracer which does nothing, embedded by ots_ tracers to implement only needed hooks
+type DefaultTracer struct{}
+
+func (t *DefaultTracer) CaptureStart(depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) error {
+	return nil
+}
+
+func (t *DefaultTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *stack.Stack, rData []byte, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *DefaultTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *stack.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *DefaultTracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64, d time.Duration, err error) error {
+	return nil
+}
+
+func (t *DefaultTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+}
+
+func (t *DefaultTracer) CaptureAccountRead(account common.Address) error {
+	return nil
+}
+
+func (t *DefaultTracer) CaptureAccountWrite(account common.Address) error {
+	return nil
+}
+
+// TransactionTracer - collects all call frames of transaction
+type TransactionTracer struct {
+	DefaultTracer
+	Results []*TraceEntry
+}
+
+func (t *TransactionTracer) CaptureStart(depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) error {
+	if precompile {
+		return nil
+	}
+	entry := &TraceEntry{Depth: depth, From: from, To: to, Input: common.CopyBytes(input)}
+	switch callType {
+	case vm.CALLT:
+		entry.Type = "CALL"
+	case vm.CALLCODET:
+		entry.Type = "CALLCODE"
+	case vm.DELEGATECALLT:
+		entry.Type = "DELEGATECALL"
+	case vm.STATICCALLT:
+		entry.Type = "STATICCALL"
+	case vm.CREATET:
+		entry.Type = "CREATE"
+	case vm.CREATE2T:
+		entry.Type = "CREATE2"
+	}
+	// DELEGATECALL and STATICCALL pass negative placeholders instead of value
+	if value != nil && value.Sign() >= 0 {
+		entry.Value = (*hexutil.Big)(new(big.Int).Set(value))
+	}
+	t.Results = append(t.Results, entry)
+	return nil
+}
+
+func (t *TransactionTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+	t.Results = append(t.Results, &TraceEntry{Type: "SELFDESTRUCT", From: from, To: to, Value: (*hexutil.Big)(new(big.Int).Set(value))})
+}
+
+// OperationsTracer - collects ETH transfers, self-destructs and contract creations made by nested calls
+type OperationsTracer struct {
+	DefaultTracer
+	Results []*InternalOperation
+}
+
+func (t *OperationsTracer) CaptureStart(depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) error {
+	if depth == 0 {
+		return nil
+	}
+	switch {
+	case callType == vm.CREATET:
+		t.Results = append(t.Results, &InternalOperation{Type: OpCreate, From: from, To: to, Value: (*hexutil.Big)(new(big.Int).Set(value))})
+	case callType == vm.CREATE2T:
+		t.Results = append(t.Results, &InternalOperation{Type: OpCreate2, From: from, To: to, Value: (*hexutil.Big)(new(big.Int).Set(value))})
+	case callType == vm.CALLT && value.Sign() > 0:
+		t.Results = append(t.Results, &InternalOperation{Type: OpTransfer, From: from, To: to, Value: (*hexutil.Big)(new(big.Int).Set(value))})
+	}
+	return nil
+}
+
+func (t *OperationsTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+	t.Results = append(t.Results, &InternalOperation{Type: OpSelfDestruct, From: from, To: to, Value: (*hexutil.Big)(new(big.Int).Set(value))})
+}
+
+// TraceTransaction implements ots_traceTransaction. Returns all call frames of transaction.
+func (api *OtterscanAPIImpl) TraceTransaction(ctx context.Context, hash common.Hash) ([]*TraceEntry, error) {
+	tracer := &TransactionTracer{}
+	if _, err := api.runTracer(ctx, hash, tracer); err != nil {
+		return nil, err
+	}
+	return tracer.Results, nil
+}
+
+// GetInternalOperations implements ots_getInternalOperations. Returns ETH transfers, self-destructs and
+// contract creations made by transaction below its top-level call.
+func (api *OtterscanAPIImpl) GetInternalOperations(ctx context.Context, hash common.Hash) ([]*InternalOperation, error) {
+	tracer := &OperationsTracer{}
+	if _, err := api.runTracer(ctx, hash, tracer); err != nil {
+		return nil, err
+	}
+	return tracer.Results, nil
+}
+
+// GetTransactionError implements ots_getTransactionError. Returns revert data of transaction, empty if it didn't revert.
+func (api *OtterscanAPIImpl) GetTransactionError(ctx context.Context, hash common.Hash) (hexutil.Bytes, error) {
+	result, err := api.runTracer(ctx, hash, &DefaultTracer{})
+	if err != nil {
+		return nil, err
+	}
+	return result.Revert(), nil
+}
+
+// runTracer - re-executes transaction on top of the state it was executed on
+func (api *OtterscanAPIImpl) runTracer(ctx context.Context, hash common.Hash, tracer vm.Tracer) (*core.ExecutionResult, error) {
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	txn, blockHash, blockNum, txIndex, err := rawdb.ReadTransaction(tx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if txn == nil {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	block, err := api.blockWithSenders(tx, blockHash, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d(%x) not found", blockNum, blockHash)
+	}
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	getHeader := func(hash common.Hash, number uint64) *types.Header {
+		return rawdb.ReadHeader(tx, hash, number)
+	}
+	contractHasTEVM := func(contractHash common.Hash) (bool, error) { return false, nil }
+	if api.TevmEnabled {
+		contractHasTEVM = ethdb.GetHasTEVM(tx)
+	}
+	msg, blockCtx, txCtx, ibs, _, err := transactions.ComputeTxEnv(ctx, block, chainConfig, getHeader, contractHasTEVM, ethash.NewFaker(), tx, blockHash, txIndex)
+	if err != nil {
+		return nil, err
+	}
+	vmenv := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vm.Config{Debug: true, Tracer: tracer})
+	result, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()), true /* refunds */, false /* gasBailout */)
+	if err != nil {
+		return nil, fmt.Errorf("tracing failed: %w", err)
+	}
+	return result, nil
+}
+
+// touchTracer - checks if address took part in any call frame of transaction
+type touchTracer struct {
+	DefaultTracer
+	addr    common.Address
+	touched bool
+}
+
+func (t *touchTracer) CaptureStart(depth int, from common.Address, to common.Address, precompile bool, create bool, callType vm.CallType, input []byte, gas uint64, value *big.Int, code []byte) error {
+	if from == t.addr || to == t.addr {
+		t.touched = true
+	}
+	return nil
+}
+
+func (t *touchTracer) CaptureSelfDestruct(from common.Address, to common.Address, value *big.Int) {
+	if from == t.addr || to == t.addr {
+		t.touched = true
+	}
+}
+
+// traceBlockTxs - executes all transactions of block, calls newTracer to get tracer for each of them and
+// onResult after each of them. Returning false from onResult stops execution of the rest of block.
+func (api *BaseAPI) traceBlockTxs(ctx context.Context, tx kv.Tx, block *types.Block, newTracer func(idx int) vm.Tracer, onResult func(idx int, tracer vm.Tracer) bool) error {
+	chainConfig, err := api.chainConfig(tx)
+	if err != nil {
+		return err
+	}
+	getHeader := func(hash common.Hash, number uint64) *types.Header {
+		return rawdb.ReadHeader(tx, hash, number)
+	}
+	contractHasTEVM := func(contractHash common.Hash) (bool, error) { return false, nil }
+	if api.TevmEnabled {
+		contractHasTEVM = ethdb.GetHasTEVM(tx)
+	}
+	_, blockCtx, _, ibs, reader, err := transactions.ComputeTxEnv(ctx, block, chainConfig, getHeader, contractHasTEVM, ethash.NewFaker(), tx, block.Hash(), 0)
+	if err != nil {
+		return err
+	}
+	signer := types.MakeSigner(chainConfig, block.NumberU64())
+	rules := chainConfig.Rules(block.NumberU64())
+	for idx, txn := range block.Transactions() {
+		select {
+		default:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		ibs.Prepare(txn.Hash(), block.Hash(), idx)
+		msg, err := txn.AsMessage(*signer, block.BaseFee())
+		if err != nil {
+			return err
+		}
+		tracer := newTracer(idx)
+		vmenv := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), ibs, chainConfig, vm.Config{Debug: true, Tracer: tracer})
+		if _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()), true /* refunds */, false /* gasBailout */); err != nil {
+			return fmt.Errorf("tracing failed: %w", err)
+		}
+		if err := ibs.FinalizeTx(rules, reader); err != nil {
+			return err
+		}
+		if !onResult(idx, tracer) {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"context"
+	"sync"
+
+	"github.com/VictoriaMetrics/metrics"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/core/types"
+)
+
+var (
+	responseCacheHits   = metrics.GetOrCreateCounter(`rpc_response_cache{result="hit"}`)
+	responseCacheMisses = metrics.GetOrCreateCounter(`rpc_response_cache{result="miss"}`)
+)
+
+// responseCache - LRU of responses about blocks at least depth blocks below the head, which change only on reorgs.
+// Entries of re-organized blocks are dropped on new headers of Subscribe stream. Cached values are shared by all
+// callers and must not be modified.
+type responseCache struct {
+	entries *lru.Cache // thread-safe
+	depth   uint64
+
+	lock sync.Mutex
+	gen  uint64 // incremented on every invalidation, responses computed before it are not added
+	head *types.Header
+}
+
+type cachedResponse struct {
+	blockNum uint64 // the highest block response depends on
+	value    interface{}
+}
+
+func newResponseCache(size int, depth uint64) *responseCache {
+	entries, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+	return &responseCache{entries: entries, depth: depth}
+}
+
+// enableResponseCache - caches up to size responses about blocks at least depth blocks old, until ctx is done
+func (api *BaseAPI) enableResponseCache(ctx context.Context, size int, depth uint64) {
+	cache := newResponseCache(size, depth)
+	api.responseCache = cache
+	if api.filters == nil {
+		return
+	}
+	heads := make(chan *types.Header, 8)
+	api.filters.SubscribeNewHeads(heads)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case header := <-heads:
+				cache.onNewHeader(header)
+			}
+		}
+	}()
+}
+
+// get - cached response for key and generation to pass to add if there is none. Must be called before opening the
+// transaction response is computed in, so that reorgs happening meanwhile are noticed by add.
+func (c *responseCache) get(key string) (interface{}, uint64, bool) {
+	if c == nil {
+		return nil, 0, false
+	}
+	c.lock.Lock()
+	gen := c.gen
+	c.lock.Unlock()
+	if it, ok := c.entries.Get(key); ok {
+		responseCacheHits.Inc()
+		return it.(cachedResponse).value, gen, true
+	}
+	responseCacheMisses.Inc()
+	return nil, gen, false
+}
+
+// add - caches value if blockNum is deep enough in the chain seen by tx and no reorg happened since get
+func (c *responseCache) add(tx kv.Tx, gen uint64, key string, blockNum uint64, value interface{}) {
+	if c == nil {
+		return
+	}
+	latest, err := getLatestBlockNumber(tx)
+	if err != nil || blockNum+c.depth > latest {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if gen != c.gen {
+		return
+	}
+	c.entries.Add(key, cachedResponse{blockNum: blockNum, value: value})
+}
+
+// onNewHeader - drops responses about blocks replaced by reorg. Erigon sends headers from the first changed block
+// after unwinding, so header not above the previous head starts a reorg.
+func (c *responseCache) onNewHeader(header *types.Header) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	defer func() { c.head = header }()
+	if c.head == nil {
+		return
+	}
+	number, headNumber := header.Number.Uint64(), c.head.Number.Uint64()
+	switch {
+	case number <= headNumber:
+		c.invalidate(number)
+	case number == headNumber+1 && header.ParentHash != c.head.Hash():
+		// fork point is unknown
+		c.invalidate(0)
+	}
+}
+
+func (c *responseCache) invalidate(from uint64) {
+	c.gen++
+	for _, key := range c.entries.Keys() {
+		if it, ok := c.entries.Peek(key); ok && it.(cachedResponse).blockNum >= from {
+			c.entries.Remove(key)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	base := NewBaseApi(nil, kvcache.New(kvcache.DefaultCoherentConfig), false)
+	base.enableResponseCache(context.Background(), 16, 5)
+	api := NewEthAPI(base, db, nil, nil, nil, 5000000)
+	cache := base.responseCache
+	ctx := context.Background()
+
+	tx, err := db.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	block3, err := rawdb.ReadBlockByNumber(tx, 3)
+	require.NoError(t, err)
+
+	// head is at block 10, only blocks up to 5 are deep enough
+	for i := 0; i < 2; i++ {
+		_, err = api.GetBlockByNumber(ctx, 3, false)
+		require.NoError(t, err)
+		_, err = api.GetBlockByNumber(ctx, 8, false)
+		require.NoError(t, err)
+		_, err = api.GetBlockByNumber(ctx, rpc.LatestBlockNumber, false)
+		require.NoError(t, err)
+	}
+	require.True(t, cache.entries.Contains("eth_getBlockByNumber/3/false"))
+	require.True(t, !cache.entries.Contains("eth_getBlockByNumber/8/false"))
+	require.Equal(t, 1, cache.entries.Len())
+
+	_, err = api.GetTransactionReceipt(ctx, block3.Transactions()[0].Hash())
+	require.NoError(t, err)
+	_, err = api.getLogs(ctx, filters.FilterCriteria{FromBlock: big.NewInt(0), ToBlock: big.NewInt(4)})
+	require.NoError(t, err)
+	_, err = api.getLogs(ctx, filters.FilterCriteria{FromBlock: big.NewInt(0)})
+	require.NoError(t, err)
+	require.Equal(t, 3, cache.entries.Len())
+
+	// reorg replacing blocks from 4 keeps responses about block 3
+	cache.onNewHeader(rawdb.ReadHeaderByNumber(tx, 10))
+	cache.onNewHeader(&types.Header{Number: big.NewInt(4), ParentHash: block3.Hash()})
+	require.Equal(t, 2, cache.entries.Len())
+	require.True(t, cache.entries.Contains("eth_getBlockByNumber/3/false"))
+
+	// next header on other fork
+	cache.onNewHeader(&types.Header{Number: big.NewInt(5), ParentHash: common.Hash{1}})
+	require.Equal(t, 0, cache.entries.Len())
+
+	// response computed before the reorg is not cached
+	_, gen, _ := cache.get("key")
+	cache.onNewHeader(&types.Header{Number: big.NewInt(5)})
+	cache.add(tx, gen, "key", 3, "value")
+	require.Equal(t, 0, cache.entries.Len())
+}
@@ -4,11 +4,14 @@ import (
 	"fmt"
 
 	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
-func getBlockNumber(number rpc.BlockNumber, tx kv.Tx) (uint64, error) {
+// getBlockNumber - "safe" and "finalized" are blocks of the latest fork choice from ff
+func getBlockNumber(number rpc.BlockNumber, tx kv.Tx, ff *filters.Filters) (uint64, error) {
 	var blockNum uint64
 	var err error
 	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
@@ -16,6 +19,8 @@ func getBlockNumber(number rpc.BlockNumber, tx kv.Tx) (uint64, error) {
 		if err != nil {
 			return 0, err
 		}
+	} else if number == rpc.SafeBlockNumber || number == rpc.FinalizedBlockNumber {
+		return rpchelper.ForkChoiceBlockNumber(number, ff)
 	} else if number == rpc.EarliestBlockNumber {
 		blockNum = 0
 	} else {
@@ -3,19 +3,24 @@ package commands
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 
 	txPoolProto "github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/crypto"
 	"github.com/ledgerwatch/erigon/eth/ethconfig"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/turbo/trie"
 	"github.com/ledgerwatch/log/v3"
 )
 
@@ -75,6 +80,143 @@ func (api *APIImpl) SendRawTransaction(ctx context.Context, encodedTx hexutil.By
 	return txn.Hash(), nil
 }
 
+// maxKnownAccountsCost - limit of account roots and storage slots checked by eth_sendRawTransactionConditional
+const maxKnownAccountsCost = 1000
+
+// KnownAccount - expected state of account storage: either root of its storage trie or values of some of its slots
+type KnownAccount struct {
+	StorageRoot  *common.Hash
+	StorageSlots map[common.Hash]common.Hash
+}
+
+func (a KnownAccount) MarshalJSON() ([]byte, error) {
+	if a.StorageRoot != nil {
+		return json.Marshal(a.StorageRoot)
+	}
+	return json.Marshal(a.StorageSlots)
+}
+
+func (a *KnownAccount) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		a.StorageRoot = new(common.Hash)
+		return json.Unmarshal(data, a.StorageRoot)
+	}
+	return json.Unmarshal(data, &a.StorageSlots)
+}
+
+// TransactionConditions - preconditions of eth_sendRawTransactionConditional, checked against the latest block
+type TransactionConditions struct {
+	KnownAccounts  map[common.Address]KnownAccount `json:"knownAccounts"`
+	BlockNumberMin *hexutil.Uint64                 `json:"blockNumberMin"`
+	BlockNumberMax *hexutil.Uint64                 `json:"blockNumberMax"`
+	TimestampMin   *hexutil.Uint64                 `json:"timestampMin"`
+	TimestampMax   *hexutil.Uint64                 `json:"timestampMax"`
+}
+
+// conditionsNotMetError - returned if state doesn't satisfy conditions of eth_sendRawTransactionConditional
+type conditionsNotMetError struct {
+	msg string
+}
+
+func (e *conditionsNotMetError) Error() string  { return "transaction conditions not met: " + e.msg }
+func (e *conditionsNotMetError) ErrorCode() int { return -32003 }
+
+// SendRawTransactionConditional implements eth_sendRawTransactionConditional. Same as eth_sendRawTransaction, but
+// rejects transaction if the latest block or state of known accounts don't match the given conditions.
+func (api *APIImpl) SendRawTransactionConditional(ctx context.Context, encodedTx hexutil.Bytes, conditions TransactionConditions) (common.Hash, error) {
+	if err := api.checkTransactionConditions(ctx, conditions); err != nil {
+		return common.Hash{}, err
+	}
+	return api.SendRawTransaction(ctx, encodedTx)
+}
+
+func (api *APIImpl) checkTransactionConditions(ctx context.Context, conditions TransactionConditions) error {
+	cost := 0
+	for _, known := range conditions.KnownAccounts {
+		if known.StorageRoot != nil {
+			cost++
+		} else {
+			cost += len(known.StorageSlots)
+		}
+	}
+	if cost > maxKnownAccountsCost {
+		return fmt.Errorf("too many known accounts and storage slots: %d, limit %d", cost, maxKnownAccountsCost)
+	}
+
+	tx, err := api.db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	header := rawdb.ReadCurrentHeader(tx)
+	if header == nil {
+		return fmt.Errorf("current header not found")
+	}
+	number := header.Number.Uint64()
+	if conditions.BlockNumberMin != nil && number < uint64(*conditions.BlockNumberMin) {
+		return &conditionsNotMetError{fmt.Sprintf("block number %d is less than %d", number, *conditions.BlockNumberMin)}
+	}
+	if conditions.BlockNumberMax != nil && number > uint64(*conditions.BlockNumberMax) {
+		return &conditionsNotMetError{fmt.Sprintf("block number %d is greater than %d", number, *conditions.BlockNumberMax)}
+	}
+	if conditions.TimestampMin != nil && header.Time < uint64(*conditions.TimestampMin) {
+		return &conditionsNotMetError{fmt.Sprintf("timestamp %d is less than %d", header.Time, *conditions.TimestampMin)}
+	}
+	if conditions.TimestampMax != nil && header.Time > uint64(*conditions.TimestampMax) {
+		return &conditionsNotMetError{fmt.Sprintf("timestamp %d is greater than %d", header.Time, *conditions.TimestampMax)}
+	}
+
+	reader := state.NewPlainStateReader(tx)
+	for addr, known := range conditions.KnownAccounts {
+		acc, err := reader.ReadAccountData(addr)
+		if err != nil {
+			return err
+		}
+		var incarnation uint64
+		if acc != nil {
+			incarnation = acc.Incarnation
+		}
+		if known.StorageRoot != nil {
+			root, err := storageRoot(tx, crypto.Keccak256Hash(addr.Bytes()), incarnation)
+			if err != nil {
+				return err
+			}
+			if root != *known.StorageRoot {
+				return &conditionsNotMetError{fmt.Sprintf("storage root of %x is %x, not %x", addr, root, *known.StorageRoot)}
+			}
+			continue
+		}
+		for slot, expected := range known.StorageSlots {
+			slot := slot
+			v, err := reader.ReadAccountStorage(addr, incarnation, &slot)
+			if err != nil {
+				return err
+			}
+			if value := common.BytesToHash(v); value != expected {
+				return &conditionsNotMetError{fmt.Sprintf("storage slot %x of %x is %x, not %x", slot, addr, value, expected)}
+			}
+		}
+	}
+	return nil
+}
+
+// storageRoot - computes root of account storage trie from hashed state, which corresponds to the latest block
+func storageRoot(tx kv.Tx, addrHash common.Hash, incarnation uint64) (common.Hash, error) {
+	if incarnation == 0 {
+		return trie.EmptyRoot, nil
+	}
+	prefix := dbutils.GenerateStoragePrefix(addrHash[:], incarnation)
+	t := trie.New(trie.EmptyRoot)
+	if err := tx.ForPrefix(kv.HashedStorage, prefix, func(k, v []byte) error {
+		t.Update(common.CopyBytes(k[len(prefix):]), common.CopyBytes(v))
+		return nil
+	}); err != nil {
+		return common.Hash{}, err
+	}
+	return t.Hash(), nil
+}
+
 // SendTransaction implements eth_sendTransaction. Creates new message call transaction or a contract creation if the data field contains code.
 func (api *APIImpl) SendTransaction(_ context.Context, txObject interface{}) (common.Hash, error) {
 	return common.Hash{0}, fmt.Errorf(NotImplemented, "eth_sendTransaction")
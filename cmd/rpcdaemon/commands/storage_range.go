@@ -1,7 +1,10 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
+	"math"
+	"sort"
 
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon/common"
@@ -23,20 +26,34 @@ type StorageEntry struct {
 	Value common.Hash  `json:"value"`
 }
 
-func StorageRangeAt(stateReader *state.PlainState, contractAddress common.Address, start []byte, maxResult int) (StorageRangeResult, error) {
-	result := StorageRangeResult{Storage: StorageMap{}}
-	resultCount := 0
+type storageRangeItem struct {
+	key, seckey common.Hash
+	value       uint256.Int
+}
 
-	if err := stateReader.ForEachStorage(contractAddress, common.BytesToHash(start), func(key, seckey common.Hash, value uint256.Int) bool {
-		if resultCount < maxResult {
-			result.Storage[seckey] = StorageEntry{Key: &key, Value: value.Bytes32()}
-		} else {
-			result.NextKey = &key
+// StorageRangeAt - up to maxResult storage entries of the contract in order of hashed locations, starting from the
+// hashed location `start`, like in geth (NextKey is hashed too). Plain state and history are ordered by plain
+// locations, so the whole storage of the contract is read and sorted
+func StorageRangeAt(stateReader *state.PlainState, contractAddress common.Address, start []byte, maxResult int) (StorageRangeResult, error) {
+	var items []storageRangeItem
+	if err := stateReader.ForEachStorage(contractAddress, common.Hash{}, func(key, seckey common.Hash, value uint256.Int) bool {
+		if bytes.Compare(seckey[:], start) >= 0 {
+			items = append(items, storageRangeItem{key: key, seckey: seckey, value: value})
 		}
-		resultCount++
-		return resultCount <= maxResult
-	}, maxResult+1); err != nil {
+		return true
+	}, math.MaxInt32); err != nil {
 		return StorageRangeResult{}, fmt.Errorf("error walking over storage: %w", err)
 	}
+	sort.Slice(items, func(i, j int) bool { return bytes.Compare(items[i].seckey[:], items[j].seckey[:]) < 0 })
+
+	result := StorageRangeResult{Storage: StorageMap{}}
+	for i := range items {
+		item := &items[i]
+		if i >= maxResult {
+			result.NextKey = &item.seckey
+			break
+		}
+		result.Storage[item.seckey] = StorageEntry{Key: &item.key, Value: item.value.Bytes32()}
+	}
 	return result, nil
 }
@@ -655,6 +655,12 @@ func (sd *StateDiff) CompareStates(initialIbs, ibs *state.IntraBlockState) {
 					m["-"] = hexutil.Uint64(initialIbs.GetNonce(addr))
 					accountDiff.Nonce = m
 				}
+				// Transform storage
+				for _, sm := range accountDiff.Storage {
+					str := sm["*"].(*StateDiffStorage)
+					delete(sm, "*")
+					sm["-"] = &str.From
+				}
 			}
 		} else if exist {
 			{
@@ -687,7 +693,17 @@ func (sd *StateDiff) CompareStates(initialIbs, ibs *state.IntraBlockState) {
 	}
 }
 
+// ReplayTransaction implements trace_replayTransaction. Transactions of the block are replayed up to the given one,
+// traces are collected only for it
 func (api *TraceAPIImpl) ReplayTransaction(ctx context.Context, txHash common.Hash, traceTypes []string) (*TraceCallResult, error) {
+	for _, traceType := range traceTypes {
+		switch traceType {
+		case TraceTypeTrace, TraceTypeStateDiff, TraceTypeVmTrace:
+		default:
+			return nil, fmt.Errorf("unrecognized trace type: %s", traceType)
+		}
+	}
+
 	tx, err := api.kv.BeginRo(ctx)
 	if err != nil {
 		return nil, err
@@ -714,13 +730,16 @@ func (api *TraceAPIImpl) ReplayTransaction(ctx context.Context, txHash common.Ha
 	if block == nil {
 		return nil, fmt.Errorf("could not find block  %d", *blockNumber)
 	}
-	var txIndex int
+	txIndex := -1
 	for idx, txn := range block.Transactions() {
 		if txn.Hash() == txHash {
 			txIndex = idx
 			break
 		}
 	}
+	if txIndex == -1 {
+		return nil, fmt.Errorf("transaction %#x not found in block %d", txHash, *blockNumber)
+	}
 	bn := hexutil.Uint64(*blockNumber)
 
 	parentNr := bn
@@ -728,48 +747,16 @@ func (api *TraceAPIImpl) ReplayTransaction(ctx context.Context, txHash common.Ha
 		parentNr -= 1
 	}
 
-	// Returns an array of trace arrays, one trace array for each transaction
-	traces, err := api.callManyTransactions(ctx, tx, block.Transactions(), traceTypes, block.ParentHash(), rpc.BlockNumber(parentNr), block.Header(), txIndex, types.MakeSigner(chainConfig, *blockNumber))
+	// Transactions after the needed one don't affect its traces
+	traces, err := api.callManyTransactions(ctx, tx, block.Transactions()[:txIndex+1], traceTypes, block.ParentHash(), rpc.BlockNumber(parentNr), block.Header(), txIndex, types.MakeSigner(chainConfig, *blockNumber))
 	if err != nil {
 		return nil, err
 	}
-
-	var traceTypeTrace, traceTypeStateDiff, traceTypeVmTrace bool
-	for _, traceType := range traceTypes {
-		switch traceType {
-		case TraceTypeTrace:
-			traceTypeTrace = true
-		case TraceTypeStateDiff:
-			traceTypeStateDiff = true
-		case TraceTypeVmTrace:
-			traceTypeVmTrace = true
-		default:
-			return nil, fmt.Errorf("unrecognized trace type: %s", traceType)
-		}
-	}
-	result := &TraceCallResult{}
-
-	for txno, trace := range traces {
-		// We're only looking for a specific transaction
-		if txno == txIndex {
-			result.Output = trace.Output
-			if traceTypeTrace {
-				result.Trace = trace.Trace
-			}
-			if traceTypeStateDiff {
-				result.StateDiff = trace.StateDiff
-			}
-			if traceTypeVmTrace {
-				result.VmTrace = trace.VmTrace
-			}
-
-			return trace, nil
-		}
-	}
-	return result, nil
-
+	return traces[txIndex], nil
 }
 
+// ReplayBlockTransactions implements trace_replayBlockTransactions. Every transaction of the block is replayed on
+// the state left by the previous ones
 func (api *TraceAPIImpl) ReplayBlockTransactions(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, traceTypes []string) ([]*TraceCallResult, error) {
 	tx, err := api.kv.BeginRo(ctx)
 	if err != nil {
@@ -781,7 +768,7 @@ func (api *TraceAPIImpl) ReplayBlockTransactions(ctx context.Context, blockNrOrH
 		return nil, err
 	}
 
-	blockNumber, _, _, err := rpchelper.GetBlockNumber(blockNrOrHash, tx, api.filters)
+	blockNumber, _, _, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, tx, api.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -859,7 +846,7 @@ func (api *TraceAPIImpl) Call(ctx context.Context, args TraceCallParam, traceTyp
 		blockNrOrHash = &rpc.BlockNumberOrHash{BlockNumber: &num}
 	}
 
-	blockNumber, hash, latest, err := rpchelper.GetBlockNumber(*blockNrOrHash, tx, api.filters)
+	blockNumber, hash, latest, err := rpchelper.GetCanonicalBlockNumber(*blockNrOrHash, tx, api.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -1029,7 +1016,7 @@ func (api *TraceAPIImpl) CallMany(ctx context.Context, calls json.RawMessage, pa
 		var num = rpc.LatestBlockNumber
 		parentNrOrHash = &rpc.BlockNumberOrHash{BlockNumber: &num}
 	}
-	blockNumber, hash, _, err := rpchelper.GetBlockNumber(*parentNrOrHash, dbtx, api.filters)
+	blockNumber, hash, _, err := rpchelper.GetCanonicalBlockNumber(*parentNrOrHash, dbtx, api.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -1065,7 +1052,7 @@ func (api *TraceAPIImpl) doCallMany(ctx context.Context, dbtx kv.Tx, msgs []type
 		var num = rpc.LatestBlockNumber
 		parentNrOrHash = &rpc.BlockNumberOrHash{BlockNumber: &num}
 	}
-	blockNumber, hash, latest, err := rpchelper.GetBlockNumber(*parentNrOrHash, dbtx, api.filters)
+	blockNumber, hash, latest, err := rpchelper.GetCanonicalBlockNumber(*parentNrOrHash, dbtx, api.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -1133,13 +1120,17 @@ func (api *TraceAPIImpl) doCallMany(ctx context.Context, dbtx kv.Tx, msgs []type
 				return nil, fmt.Errorf("unrecognized trace type: %s", traceType)
 			}
 		}
+		// Transactions before the needed one are executed only to get the state it runs on
+		if txIndexNeeded != -1 && txIndex != txIndexNeeded {
+			traceTypeTrace, traceTypeStateDiff, traceTypeVmTrace = false, false, false
+		}
 		vmConfig := vm.Config{}
-		if (traceTypeTrace && (txIndexNeeded == -1 || txIndex == txIndexNeeded)) || traceTypeVmTrace {
+		if traceTypeTrace || traceTypeVmTrace {
 			var ot OeTracer
 			ot.compat = api.compatibility
 			ot.r = traceResult
 			ot.idx = []string{fmt.Sprintf("%d-", txIndex)}
-			if traceTypeTrace && (txIndexNeeded == -1 || txIndex == txIndexNeeded) {
+			if traceTypeTrace {
 				ot.traceAddr = []int{}
 			}
 			if traceTypeVmTrace {
@@ -105,3 +105,49 @@ func TestReplayBlockTransactions(t *testing.T) {
 	v := addrDiff.Balance.(map[string]*hexutil.Big)["+"].ToInt().Uint64()
 	require.Equal(t, uint64(1_000_000_000_000_000), v)
 }
+
+func TestReplayTraceTypes(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := NewTraceAPI(NewBaseApi(nil, stateCache, false), db, &cli.Flags{})
+	ctx := context.Background()
+	traceTypes := []string{TraceTypeTrace, TraceTypeStateDiff, TraceTypeVmTrace}
+
+	var vmTraced bool
+	for n := rpc.BlockNumber(1); n <= 10; n++ {
+		number := n
+		blockResults, err := api.ReplayBlockTransactions(ctx, rpc.BlockNumberOrHash{BlockNumber: &number}, traceTypes)
+		require.NoError(t, err)
+		for i, blockResult := range blockResults {
+			require.NotEmpty(t, blockResult.Trace)
+			require.NotNil(t, blockResult.StateDiff)
+			require.NotNil(t, blockResult.VmTrace)
+			vmTraced = vmTraced || len(blockResult.VmTrace.Ops) > 0
+
+			// single transaction is replayed on the state left by the previous ones of its block
+			result, err := api.ReplayTransaction(ctx, *blockResult.TransactionHash, traceTypes)
+			require.NoError(t, err)
+			expected := *blockResult
+			expected.TransactionHash = nil
+			require.Equal(t, &expected, result, "block %d, transaction %d", n, i)
+		}
+	}
+	require.True(t, vmTraced, "some transactions run code")
+
+	var txnHash common.Hash
+	require.NoError(t, db.View(ctx, func(tx kv.Tx) error {
+		b, err := rawdb.ReadBlockByNumber(tx, 6)
+		if err != nil {
+			return err
+		}
+		txnHash = b.Transactions()[5].Hash()
+		return nil
+	}))
+	result, err := api.ReplayTransaction(ctx, txnHash, []string{TraceTypeTrace})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Trace)
+	require.Nil(t, result.StateDiff)
+	require.Nil(t, result.VmTrace)
+	_, err = api.ReplayTransaction(ctx, txnHash, []string{"unknown"})
+	require.Error(t, err)
+}
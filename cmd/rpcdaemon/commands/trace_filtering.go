@@ -1,22 +1,27 @@
 package commands
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 
 	"github.com/RoaringBitmap/roaring/roaring64"
 	jsoniter "github.com/json-iterator/go"
+	"github.com/ledgerwatch/erigon-lib/common/length"
 	"github.com/ledgerwatch/erigon-lib/kv"
 
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/dbutils"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/consensus/ethash"
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
 	"github.com/ledgerwatch/erigon/ethdb"
 	"github.com/ledgerwatch/erigon/ethdb/bitmapdb"
 	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
 // Transaction implements trace_transaction
@@ -117,7 +122,7 @@ func (api *TraceAPIImpl) Block(ctx context.Context, blockNr rpc.BlockNumber) (Pa
 		return nil, err
 	}
 	defer tx.Rollback()
-	blockNum, err := getBlockNumber(blockNr, tx)
+	blockNum, err := getBlockNumber(blockNr, tx, api.filters)
 	if err != nil {
 		return nil, err
 	}
@@ -237,13 +242,18 @@ func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, str
 		blocksTo  roaring64.Bitmap
 	)
 
+	if len(req.FromAddress) > 0 || len(req.ToAddress) > 0 {
+		// without explicit fromBlock traces of blocks where the index is pruned are skipped
+		var err error
+		if fromBlock, err = callTracesFrom(dbtx, fromBlock, toBlock, req.FromBlock == nil); err != nil {
+			stream.WriteNil()
+			return err
+		}
+	}
 	for _, addr := range req.FromAddress {
 		if addr != nil {
-			b, err := bitmapdb.Get64(dbtx, kv.CallFromIndex, addr.Bytes(), fromBlock, toBlock)
+			b, err := callTraceBlocks(dbtx, kv.CallFromIndex, *addr, fromBlock, toBlock)
 			if err != nil {
-				if errors.Is(err, ethdb.ErrKeyNotFound) {
-					continue
-				}
 				stream.WriteNil()
 				return err
 			}
@@ -254,11 +264,8 @@ func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, str
 
 	for _, addr := range req.ToAddress {
 		if addr != nil {
-			b, err := bitmapdb.Get64(dbtx, kv.CallToIndex, addr.Bytes(), fromBlock, toBlock)
+			b, err := callTraceBlocks(dbtx, kv.CallToIndex, *addr, fromBlock, toBlock)
 			if err != nil {
-				if errors.Is(err, ethdb.ErrKeyNotFound) {
-					continue
-				}
 				stream.WriteNil()
 				return err
 			}
@@ -291,10 +298,12 @@ func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, str
 	}
 
 	var json = jsoniter.ConfigCompatibleWithStandardLibrary
-	stream.WriteArrayStart()
-	first := true
+	arr := rpc.NewArrayStream(ctx, stream)
+	arr.Start()
 	// Execute all transactions in picked blocks
 
+	// traces are numbered over whole range, "after" skips first of them and "count" limits the page, blocks are not
+	// executed once the page is full
 	count := uint64(^uint(0)) // this just makes it easier to use below
 	if req.Count != nil {
 		count = *req.Count
@@ -307,22 +316,28 @@ func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, str
 	nExported := uint64(0)
 
 	it := allBlocks.Iterator()
-	for it.HasNext() {
+	for it.HasNext() && nExported < count {
+		select {
+		case <-ctx.Done():
+			arr.End()
+			return ctx.Err()
+		default:
+		}
 		b := uint64(it.Next())
 		// Extract transactions from block
 		hash, hashErr := rawdb.ReadCanonicalHash(dbtx, b)
 		if hashErr != nil {
-			stream.WriteNil()
+			arr.End()
 			return hashErr
 		}
 
 		block, bErr := api.blockWithSenders(dbtx, hash, b)
 		if bErr != nil {
-			stream.WriteNil()
+			arr.End()
 			return bErr
 		}
 		if block == nil {
-			stream.WriteNil()
+			arr.End()
 			return fmt.Errorf("could not find block %x %d", hash, b)
 		}
 
@@ -331,7 +346,7 @@ func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, str
 		txs := block.Transactions()
 		t, tErr := api.callManyTransactions(ctx, dbtx, txs, []string{TraceTypeTrace}, block.ParentHash(), rpc.BlockNumber(block.NumberU64()-1), block.Header(), -1 /* all tx indices */, types.MakeSigner(chainConfig, b))
 		if tErr != nil {
-			stream.WriteNil()
+			arr.End()
 			return tErr
 		}
 		includeAll := len(fromAddresses) == 0 && len(toAddresses) == 0
@@ -348,15 +363,11 @@ func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, str
 					pt.TransactionPosition = &txPosition
 					b, err := json.Marshal(pt)
 					if err != nil {
-						stream.WriteNil()
+						arr.End()
 						return err
 					}
 					if nSeen > after && nExported < count {
-						if first {
-							first = false
-						} else {
-							stream.WriteMore()
-						}
+						arr.Next()
 						stream.Write(b)
 						nExported++
 					}
@@ -380,15 +391,11 @@ func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, str
 			tr.TraceAddress = []int{}
 			b, err := json.Marshal(tr)
 			if err != nil {
-				stream.WriteNil()
+				arr.End()
 				return err
 			}
 			if nSeen > after && nExported < count {
-				if first {
-					first = false
-				} else {
-					stream.WriteMore()
-				}
+				arr.Next()
 				stream.Write(b)
 				nExported++
 			}
@@ -411,26 +418,83 @@ func (api *TraceAPIImpl) Filter(ctx context.Context, req TraceFilterRequest, str
 					tr.TraceAddress = []int{}
 					b, err := json.Marshal(tr)
 					if err != nil {
-						stream.WriteNil()
+						arr.End()
 						return err
 					}
 					if nSeen > after && nExported < count {
-						if first {
-							first = false
-						} else {
-							stream.WriteMore()
-						}
+						arr.Next()
 						stream.Write(b)
 						nExported++
 					}
 				}
 			}
 		}
+		// traces are sent to the client block by block, so wide ranges don't pile up in memory
+		if err := stream.Flush(); err != nil {
+			return err
+		}
 	}
-	stream.WriteArrayEnd()
+	arr.End()
 	return stream.Flush()
 }
 
+// callTracesFrom - PrunedError if call traces index of `from` is pruned, if `clamp` the earliest indexed block is
+// returned instead, unless whole range up to `to` is pruned
+func callTracesFrom(tx kv.Tx, from, to uint64, clamp bool) (uint64, error) {
+	err := rpchelper.CheckPruned(tx, rpchelper.PrunedCallTraces, from)
+	var pruned *rpchelper.PrunedError
+	if clamp && errors.As(err, &pruned) && pruned.Earliest <= to {
+		return pruned.Earliest, nil
+	}
+	return from, err
+}
+
+// callTraceBlocks - blocks in [from, to] where addr is sender (kv.CallFromIndex) or recipient (kv.CallToIndex) of
+// some call. Blocks after progress of CallTraces stage are not in the index yet, they are looked up in
+// kv.CallTraceSet, which is written during execution and which the stage turns into the index
+func callTraceBlocks(tx kv.Tx, index string, addr common.Address, from, to uint64) (*roaring64.Bitmap, error) {
+	blocks, err := bitmapdb.Get64(tx, index, addr.Bytes(), from, to)
+	if err != nil {
+		if !errors.Is(err, ethdb.ErrKeyNotFound) {
+			return nil, err
+		}
+		blocks = roaring64.New()
+	}
+	indexed, err := stages.GetStageProgress(tx, stages.CallTraces)
+	if err != nil {
+		return nil, err
+	}
+	if to <= indexed {
+		return blocks, nil
+	}
+	if from <= indexed {
+		from = indexed + 1
+	}
+	flag := byte(1)
+	if index == kv.CallToIndex {
+		flag = 2
+	}
+	c, err := tx.CursorDupSort(kv.CallTraceSet)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	for blockNum := from; blockNum <= to; blockNum++ {
+		// values are address and flags, sorted, address may be there twice
+		v, err := c.SeekBothRange(dbutils.EncodeBlockNumber(blockNum), addr.Bytes())
+		for ; err == nil && len(v) == length.Addr+1 && bytes.Equal(v[:length.Addr], addr.Bytes()); _, v, err = c.NextDup() {
+			if v[length.Addr]&flag != 0 {
+				blocks.Add(blockNum)
+				break
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
 func filter_trace(pt *ParityTrace, fromAddresses map[common.Address]struct{}, toAddresses map[common.Address]struct{}) bool {
 	switch action := pt.Action.(type) {
 	case *CallTraceAction:
@@ -73,14 +73,16 @@ func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rp
 	}
 
 	signer := types.MakeSigner(chainConfig, block.NumberU64())
-	stream.WriteArrayStart()
+	arr := rpc.NewArrayStream(ctx, stream)
+	arr.Start()
 	for idx, tx := range block.Transactions() {
 		select {
 		default:
 		case <-ctx.Done():
-			stream.WriteNil()
+			arr.End()
 			return ctx.Err()
 		}
+		arr.Next()
 		ibs.Prepare(tx.Hash(), block.Hash(), idx)
 		msg, _ := tx.AsMessage(*signer, block.BaseFee())
 		txCtx := vm.TxContext{
@@ -91,12 +93,9 @@ func (api *PrivateDebugAPIImpl) traceBlock(ctx context.Context, blockNrOrHash rp
 
 		transactions.TraceTx(ctx, msg, blockCtx, txCtx, ibs, config, chainConfig, stream)
 		_ = ibs.FinalizeTx(chainConfig.Rules(blockCtx.BlockNumber), reader)
-		if idx != len(block.Transactions())-1 {
-			stream.WriteMore()
-		}
 		stream.Flush()
 	}
-	stream.WriteArrayEnd()
+	arr.End()
 	stream.Flush()
 	return nil
 }
@@ -148,6 +147,9 @@ func (api *PrivateDebugAPIImpl) TraceTransaction(ctx context.Context, hash commo
 	return transactions.TraceTx(ctx, msg, blockCtx, txCtx, ibs, config, chainConfig, stream)
 }
 
+// TraceCall implements debug_traceCall. Executes call on top of the state of given block and returns its Geth style
+// trace, tracer config accepts struct logger options, built-in (callTracer, prestateTracer, ...) or custom JS tracers
+// and state overrides.
 func (api *PrivateDebugAPIImpl) TraceCall(ctx context.Context, args ethapi.CallArgs, blockNrOrHash rpc.BlockNumberOrHash, config *tracers.TraceConfig, stream *jsoniter.Stream) error {
 	dbtx, err := api.db.BeginRo(ctx)
 	if err != nil {
@@ -162,7 +164,7 @@ func (api *PrivateDebugAPIImpl) TraceCall(ctx context.Context, args ethapi.CallA
 		return err
 	}
 
-	blockNumber, hash, latest, err := rpchelper.GetBlockNumber(blockNrOrHash, dbtx, api.filters)
+	blockNumber, hash, latest, err := rpchelper.GetCanonicalBlockNumber(blockNrOrHash, dbtx, api.filters) // state is kept only for canonical blocks
 	if err != nil {
 		stream.WriteNil()
 		return err
@@ -171,6 +173,7 @@ func (api *PrivateDebugAPIImpl) TraceCall(ctx context.Context, args ethapi.CallA
 	if latest {
 		cacheView, err := api.stateCache.View(ctx, dbtx)
 		if err != nil {
+			stream.WriteNil()
 			return err
 		}
 		stateReader = state.NewCachedReader2(cacheView, dbtx)
@@ -186,6 +189,7 @@ func (api *PrivateDebugAPIImpl) TraceCall(ctx context.Context, args ethapi.CallA
 
 	if config != nil && config.StateOverrides != nil {
 		if err := config.StateOverrides.Override(ibs); err != nil {
+			stream.WriteNil()
 			return err
 		}
 	}
@@ -195,11 +199,13 @@ func (api *PrivateDebugAPIImpl) TraceCall(ctx context.Context, args ethapi.CallA
 		var overflow bool
 		baseFee, overflow = uint256.FromBig(header.BaseFee)
 		if overflow {
+			stream.WriteNil()
 			return fmt.Errorf("header.BaseFee uint256 overflow")
 		}
 	}
 	msg, err := args.ToMessage(api.GasCap, baseFee)
 	if err != nil {
+		stream.WriteNil()
 		return err
 	}
 
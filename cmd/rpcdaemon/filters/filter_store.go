@@ -0,0 +1,147 @@
+package filters
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/eth/filters"
+)
+
+// Types of PollFilter
+const (
+	PollFilterLogs   = "logs"
+	PollFilterBlocks = "blocks"
+)
+
+// PollFilter - filter installed by eth_newFilter or eth_newBlockFilter, polled by eth_getFilterChanges. It keeps only
+// the last block reported to the client, changes are read from db, so any rpcdaemon sharing the store can serve them
+type PollFilter struct {
+	Type      string           `json:"type"`
+	Client    string           `json:"client"`
+	FromBlock *uint64          `json:"fromBlock,omitempty"` // logs: explicit bounds of block range, nil - none
+	ToBlock   *uint64          `json:"toBlock,omitempty"`
+	Addresses []common.Address `json:"addresses,omitempty"`
+	Topics    [][]common.Hash  `json:"topics,omitempty"`
+	LastBlock uint64           `json:"lastBlock"` // the last block reported to the client
+	LastHash  common.Hash      `json:"lastHash"`
+}
+
+// Criteria - criteria of logs filter, the same as it was created with
+func (f *PollFilter) Criteria() filters.FilterCriteria {
+	crit := filters.FilterCriteria{Addresses: f.Addresses, Topics: f.Topics}
+	if f.FromBlock != nil {
+		crit.FromBlock = new(big.Int).SetUint64(*f.FromBlock)
+	}
+	if f.ToBlock != nil {
+		crit.ToBlock = new(big.Int).SetUint64(*f.ToBlock)
+	}
+	return crit
+}
+
+// FilterLimits - settings shared by all FilterStore implementations
+type FilterLimits struct {
+	TTL       time.Duration // filter not polled for this long is removed
+	PerClient int           // maximum amount of filters of one client (see rpc.CallerFromContext), 0 - unlimited
+}
+
+func DefaultFilterLimits() FilterLimits {
+	return FilterLimits{TTL: 5 * time.Minute, PerClient: 256}
+}
+
+// ErrFilterLimit - returned by FilterStore.Add when the client has FilterLimits.PerClient filters already
+var ErrFilterLimit = errors.New("too many installed filters, uninstall some of them or wait until they expire")
+
+// FilterStore - keeps installed filters. Filters are removed when they expire, every Update (done on every poll)
+// extends their life by FilterLimits.TTL
+type FilterStore interface {
+	Add(ctx context.Context, id string, f *PollFilter) error
+	Get(ctx context.Context, id string) (*PollFilter, error) // nil if not found or expired
+	Update(ctx context.Context, id string, f *PollFilter) error
+	Remove(ctx context.Context, id string) (bool, error) // false if not found
+}
+
+// memoryFilterStore - FilterStore of single rpcdaemon
+type memoryFilterStore struct {
+	limits  FilterLimits
+	lock    sync.Mutex
+	filters map[string]*storedFilter
+	clients map[string]int // amount of filters of each client
+}
+
+type storedFilter struct {
+	filter  PollFilter
+	expires time.Time
+}
+
+func NewMemoryFilterStore(limits FilterLimits) FilterStore {
+	return &memoryFilterStore{limits: limits, filters: map[string]*storedFilter{}, clients: map[string]int{}}
+}
+
+func (s *memoryFilterStore) Add(_ context.Context, id string, f *PollFilter) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	s.sweep(now)
+	if s.limits.PerClient > 0 && s.clients[f.Client] >= s.limits.PerClient {
+		return ErrFilterLimit
+	}
+	if _, ok := s.filters[id]; !ok {
+		s.clients[f.Client]++
+	}
+	s.filters[id] = &storedFilter{filter: *f, expires: now.Add(s.limits.TTL)}
+	return nil
+}
+
+func (s *memoryFilterStore) Get(_ context.Context, id string) (*PollFilter, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stored, ok := s.filters[id]
+	if !ok || time.Now().After(stored.expires) {
+		return nil, nil
+	}
+	f := stored.filter
+	return &f, nil
+}
+
+func (s *memoryFilterStore) Update(_ context.Context, id string, f *PollFilter) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	stored, ok := s.filters[id]
+	if !ok || now.After(stored.expires) {
+		return nil // expired or uninstalled during the poll
+	}
+	stored.filter, stored.expires = *f, now.Add(s.limits.TTL)
+	return nil
+}
+
+func (s *memoryFilterStore) Remove(_ context.Context, id string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stored, ok := s.filters[id]
+	if !ok {
+		return false, nil
+	}
+	s.remove(id, stored)
+	return !time.Now().After(stored.expires), nil
+}
+
+func (s *memoryFilterStore) remove(id string, stored *storedFilter) {
+	delete(s.filters, id)
+	if s.clients[stored.filter.Client]--; s.clients[stored.filter.Client] <= 0 {
+		delete(s.clients, stored.filter.Client)
+	}
+}
+
+// sweep - removes expired filters, so they don't count against limit of their clients
+func (s *memoryFilterStore) sweep(now time.Time) {
+	for id, stored := range s.filters {
+		if now.After(stored.expires) {
+			s.remove(id, stored)
+		}
+	}
+}
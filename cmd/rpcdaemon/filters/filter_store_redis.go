@@ -0,0 +1,119 @@
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+const (
+	redisFilterPrefix = "rpc_filter:"
+	redisClientPrefix = "rpc_filter_client:" // sorted set of ids of client filters, scored by expiration time
+)
+
+// addFilterScript - stores filter unless its client has too many unexpired ones, atomically so concurrent Add on
+// different rpcdaemons can't exceed the limit
+const addFilterScript = `
+local ttl = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local limit = tonumber(ARGV[4])
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', now)
+if limit > 0 and redis.call('ZCARD', KEYS[2]) >= limit then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+redis.call('ZADD', KEYS[2], now + ttl, ARGV[5])
+redis.call('PEXPIRE', KEYS[2], ttl)
+return 1
+`
+
+// updateFilterScript - replaces filter and extends its life, unless it's expired or removed already
+const updateFilterScript = `
+local ttl = tonumber(ARGV[2])
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+redis.call('ZADD', KEYS[2], tonumber(ARGV[3]) + ttl, ARGV[4])
+redis.call('PEXPIRE', KEYS[2], ttl)
+return 1
+`
+
+const removeFilterScript = `
+redis.call('ZREM', KEYS[2], ARGV[1])
+return redis.call('DEL', KEYS[1])
+`
+
+// redisFilterStore - FilterStore kept in Redis, so filter installed through one rpcdaemon can be polled through
+// any other one using the same Redis
+type redisFilterStore struct {
+	limits FilterLimits
+	redis  *rpc.RedisClient
+}
+
+// NewRedisFilterStore - filter store kept in Redis at addr
+func NewRedisFilterStore(addr string, limits FilterLimits) FilterStore {
+	return &redisFilterStore{limits: limits, redis: rpc.NewRedisClient(addr)}
+}
+
+func (s *redisFilterStore) Add(ctx context.Context, id string, f *PollFilter) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	added, err := s.redis.DoInt(ctx, "EVAL", addFilterScript, "2", redisFilterPrefix+id, redisClientPrefix+f.Client,
+		string(data), s.ttl(), nowMillis(), strconv.Itoa(s.limits.PerClient), id)
+	if err != nil {
+		return err
+	}
+	if added == 0 {
+		return ErrFilterLimit
+	}
+	return nil
+}
+
+func (s *redisFilterStore) Get(ctx context.Context, id string) (*PollFilter, error) {
+	reply, err := s.redis.Do(ctx, "GET", redisFilterPrefix+id)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	data, _ := reply.(string)
+	f := &PollFilter{}
+	if err := json.Unmarshal([]byte(data), f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *redisFilterStore) Update(ctx context.Context, id string, f *PollFilter) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	_, err = s.redis.DoInt(ctx, "EVAL", updateFilterScript, "2", redisFilterPrefix+id, redisClientPrefix+f.Client,
+		string(data), s.ttl(), nowMillis(), id)
+	return err
+}
+
+func (s *redisFilterStore) Remove(ctx context.Context, id string) (bool, error) {
+	f, err := s.Get(ctx, id)
+	if err != nil || f == nil {
+		return false, err
+	}
+	removed, err := s.redis.DoInt(ctx, "EVAL", removeFilterScript, "2", redisFilterPrefix+id, redisClientPrefix+f.Client, id)
+	if err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
+
+func (s *redisFilterStore) ttl() string {
+	return strconv.FormatInt(int64(s.limits.TTL/time.Millisecond), 10)
+}
+
+func nowMillis() string {
+	return strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+}
@@ -0,0 +1,44 @@
+package filters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryFilterStore(t *testing.T) {
+	store := NewMemoryFilterStore(FilterLimits{TTL: 200 * time.Millisecond, PerClient: 2})
+	ctx := context.Background()
+	require.NoError(t, store.Add(ctx, "a", &PollFilter{Type: PollFilterBlocks, Client: "1.2.3.4"}))
+	require.NoError(t, store.Add(ctx, "b", &PollFilter{Type: PollFilterBlocks, Client: "1.2.3.4"}))
+	require.ErrorIs(t, store.Add(ctx, "c", &PollFilter{Type: PollFilterBlocks, Client: "1.2.3.4"}), ErrFilterLimit)
+	require.NoError(t, store.Add(ctx, "c", &PollFilter{Type: PollFilterBlocks, Client: "key:secret"}), "limit is per client")
+
+	f, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	f.LastBlock = 10
+	require.NoError(t, store.Update(ctx, "a", f))
+	removed, err := store.Remove(ctx, "b")
+	require.NoError(t, err)
+	require.True(t, removed)
+	require.NoError(t, store.Add(ctx, "d", &PollFilter{Type: PollFilterBlocks, Client: "1.2.3.4"}))
+
+	time.Sleep(120 * time.Millisecond)
+	f, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), f.LastBlock)
+	require.NoError(t, store.Update(ctx, "a", f)) // polled, lives longer than others
+	time.Sleep(120 * time.Millisecond)
+	f, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	require.NotNil(t, f)
+	f, err = store.Get(ctx, "d")
+	require.NoError(t, err)
+	require.Nil(t, f, "expired")
+	require.NoError(t, store.Add(ctx, "e", &PollFilter{Type: PollFilterBlocks, Client: "1.2.3.4"}), "expired filters don't count")
+	removed, err = store.Remove(ctx, "d")
+	require.NoError(t, err)
+	require.False(t, removed)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -21,6 +22,7 @@ import (
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/services"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
 	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/log/v3"
 	"google.golang.org/grpc"
@@ -34,6 +36,10 @@ type (
 	PendingLogsSubID  SubscriptionID
 	PendingBlockSubID SubscriptionID
 	PendingTxsSubID   SubscriptionID
+	SyncStatusSubID   SubscriptionID
+	ReorgSubID        SubscriptionID
+	ReceiptsSubID     SubscriptionID
+	ForkChoiceSubID   SubscriptionID
 	LogsSubID         uint64
 )
 
@@ -41,13 +47,23 @@ type Filters struct {
 	mu sync.RWMutex
 
 	pendingBlock *types.Block
+	forkChoice   *privateapi.ForkChoice // the latest one of Erigon, nil until consensus layer sets it
 
 	headsSubs        map[HeadsSubID]chan *types.Header
 	pendingLogsSubs  map[PendingLogsSubID]chan types.Logs
 	pendingBlockSubs map[PendingBlockSubID]chan *types.Block
 	pendingTxsSubs   map[PendingTxsSubID]chan []types.Transaction
+	syncStatusSubs   map[SyncStatusSubID]chan *services.SyncStatusEvent
+	reorgSubs        map[ReorgSubID]chan *privateapi.Reorg
+	receiptsSubs     map[ReceiptsSubID]chan *privateapi.BlockReceipts
+	receiptsStop     context.CancelFunc // of subscription to privateapi.EventReceipts, open while there are subscribers
+	forkChoiceSubs   map[ForkChoiceSubID]chan *privateapi.ForkChoice
 	logsSubs         *LogsFilterAggregator
+	logsBuffer       SubscriberLogsBuffer
 	logsRequestor    atomic.Value
+
+	ctx        context.Context
+	ethBackend services.ApiBackend
 }
 
 func New(ctx context.Context, ethBackend services.ApiBackend, txPool txpool.TxpoolClient, mining txpool.MiningClient) *Filters {
@@ -58,7 +74,14 @@ func New(ctx context.Context, ethBackend services.ApiBackend, txPool txpool.Txpo
 		pendingTxsSubs:   make(map[PendingTxsSubID]chan []types.Transaction),
 		pendingLogsSubs:  make(map[PendingLogsSubID]chan types.Logs),
 		pendingBlockSubs: make(map[PendingBlockSubID]chan *types.Block),
+		syncStatusSubs:   make(map[SyncStatusSubID]chan *services.SyncStatusEvent),
+		reorgSubs:        make(map[ReorgSubID]chan *privateapi.Reorg),
+		receiptsSubs:     make(map[ReceiptsSubID]chan *privateapi.BlockReceipts),
+		forkChoiceSubs:   make(map[ForkChoiceSubID]chan *privateapi.ForkChoice),
 		logsSubs:         NewLogsFilterAggregator(),
+		logsBuffer:       DefaultSubscriberLogsBuffer(),
+		ctx:              ctx,
+		ethBackend:       ethBackend,
 	}
 
 	go func() {
@@ -72,7 +95,7 @@ func New(ctx context.Context, ethBackend services.ApiBackend, txPool txpool.Txpo
 			default:
 			}
 
-			if err := ethBackend.Subscribe(ctx, ff.OnNewEvent); err != nil {
+			if err := ethBackend.SubscribeTopics(ctx, []remote.Event{remote.Event_HEADER}, ff.OnNewEvent); err != nil {
 				select {
 				case <-ctx.Done():
 					return
@@ -93,6 +116,84 @@ func New(ctx context.Context, ethBackend services.ApiBackend, txPool txpool.Txpo
 		}
 	}()
 
+	go func() {
+		if ethBackend == nil {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			if err := ethBackend.SubscribeTopics(ctx, []remote.Event{privateapi.EventSyncStatus}, ff.OnSyncStatus); err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				if grpcutil.IsEndOfStream(err) || grpcutil.IsRetryLater(err) {
+					time.Sleep(3 * time.Second)
+					continue
+				}
+				log.Warn("rpc filters: error subscribing to sync status", "err", err)
+				time.Sleep(time.Second)
+			}
+		}
+	}()
+
+	go func() {
+		if ethBackend == nil {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			if err := ethBackend.SubscribeTopics(ctx, []remote.Event{privateapi.EventReorg}, ff.OnReorg); err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				if grpcutil.IsEndOfStream(err) || grpcutil.IsRetryLater(err) {
+					time.Sleep(3 * time.Second)
+					continue
+				}
+				log.Warn("rpc filters: error subscribing to reorgs", "err", err)
+				time.Sleep(time.Second)
+			}
+		}
+	}()
+
+	go func() {
+		if ethBackend == nil {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			if err := ethBackend.SubscribeTopics(ctx, []remote.Event{privateapi.EventForkChoice}, ff.OnForkChoice); err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				if grpcutil.IsEndOfStream(err) || grpcutil.IsRetryLater(err) {
+					time.Sleep(3 * time.Second)
+					continue
+				}
+				log.Warn("rpc filters: error subscribing to fork choice", "err", err)
+				time.Sleep(time.Second)
+			}
+		}
+	}()
+
 	go func() {
 		if ethBackend == nil {
 			return
@@ -210,6 +311,16 @@ func (ff *Filters) LastPendingBlock() *types.Block {
 	return ff.pendingBlock
 }
 
+// ForkChoice - the latest fork choice of consensus layer Erigon notified about, nil if there was none (or ff is nil)
+func (ff *Filters) ForkChoice() *privateapi.ForkChoice {
+	if ff == nil {
+		return nil
+	}
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+	return ff.forkChoice
+}
+
 func (ff *Filters) subscribeToPendingTransactions(ctx context.Context, txPool txpool.TxpoolClient) error {
 	subscription, err := txPool.OnAdd(ctx, &txpool.OnAddRequest{}, grpc.WaitForReady(true))
 	if err != nil {
@@ -372,8 +483,110 @@ func (ff *Filters) UnsubscribePendingTxs(id PendingTxsSubID) {
 	delete(ff.pendingTxsSubs, id)
 }
 
-func (ff *Filters) SubscribeLogs(out chan *types.Log, crit filters.FilterCriteria) LogsSubID {
-	id, f := ff.logsSubs.insertLogsFilter(out)
+func (ff *Filters) SubscribeSyncStatus(out chan *services.SyncStatusEvent) SyncStatusSubID {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	id := SyncStatusSubID(generateSubscriptionID())
+	ff.syncStatusSubs[id] = out
+	return id
+}
+
+func (ff *Filters) UnsubscribeSyncStatus(id SyncStatusSubID) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	delete(ff.syncStatusSubs, id)
+}
+
+func (ff *Filters) SubscribeReorgs(out chan *privateapi.Reorg) ReorgSubID {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	id := ReorgSubID(generateSubscriptionID())
+	ff.reorgSubs[id] = out
+	return id
+}
+
+func (ff *Filters) UnsubscribeReorgs(id ReorgSubID) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	delete(ff.reorgSubs, id)
+}
+
+func (ff *Filters) SubscribeForkChoice(out chan *privateapi.ForkChoice) ForkChoiceSubID {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	id := ForkChoiceSubID(generateSubscriptionID())
+	ff.forkChoiceSubs[id] = out
+	return id
+}
+
+func (ff *Filters) UnsubscribeForkChoice(id ForkChoiceSubID) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	delete(ff.forkChoiceSubs, id)
+}
+
+// SubscribeReceipts - the first subscriber opens subscription to receipts of new blocks of Erigon, Erigon reads them
+// only while there are subscribers
+func (ff *Filters) SubscribeReceipts(out chan *privateapi.BlockReceipts) ReceiptsSubID {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	id := ReceiptsSubID(generateSubscriptionID())
+	ff.receiptsSubs[id] = out
+	if ff.receiptsStop == nil && ff.ethBackend != nil {
+		var ctx context.Context
+		ctx, ff.receiptsStop = context.WithCancel(ff.ctx)
+		go ff.subscribeReceipts(ctx)
+	}
+	return id
+}
+
+func (ff *Filters) UnsubscribeReceipts(id ReceiptsSubID) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	delete(ff.receiptsSubs, id)
+	if len(ff.receiptsSubs) == 0 && ff.receiptsStop != nil {
+		ff.receiptsStop()
+		ff.receiptsStop = nil
+	}
+}
+
+func (ff *Filters) subscribeReceipts(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		if err := ff.ethBackend.SubscribeTopics(ctx, []remote.Event{privateapi.EventReceipts}, ff.OnReceipts); err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+			if grpcutil.IsEndOfStream(err) || grpcutil.IsRetryLater(err) {
+				time.Sleep(3 * time.Second)
+				continue
+			}
+			log.Warn("rpc filters: error subscribing to receipts", "err", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+// SetLogsBuffer - buffering of logs for subscriptions made after the call, DefaultSubscriberLogsBuffer by default
+func (ff *Filters) SetLogsBuffer(buf SubscriberLogsBuffer) {
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	ff.logsBuffer = buf
+}
+
+// SubscribeLogs - logs matching `crit` are buffered in `logs`. Subscriber not reading them fast enough loses the oldest
+// ones, or, if SubscriberLogsBuffer.Disconnect is set, the subscription is removed and `slow` closed
+func (ff *Filters) SubscribeLogs(crit filters.FilterCriteria) (id LogsSubID, logs <-chan *types.Log, slow <-chan struct{}) {
+	ff.mu.RLock()
+	buf := ff.logsBuffer
+	ff.mu.RUnlock()
+	id, f := ff.logsSubs.insertLogsFilter(buf)
 	f.addrs = map[common.Address]int{}
 	if len(crit.Addresses) == 0 {
 		f.allAddrs = 1
@@ -413,7 +626,7 @@ func (ff *Filters) SubscribeLogs(out chan *types.Log, crit filters.FilterCriteri
 			ff.logsSubs.removeLogsFilter(id)
 		}
 	}
-	return id
+	return id, f.sender, f.slow
 }
 
 func (ff *Filters) UnsubscribeLogs(id LogsSubID) {
@@ -490,6 +703,71 @@ func (ff *Filters) OnNewEvent(event *remote.SubscribeReply) {
 	}
 }
 
+// OnSyncStatus - handles privateapi.EventSyncStatus events, they are sent by a separate subscription
+func (ff *Filters) OnSyncStatus(event *remote.SubscribeReply) {
+	var status services.SyncStatusEvent
+	if err := json.Unmarshal(event.Data, &status); err != nil {
+		log.Warn("OnSyncStatus rpc filters, unprocessable payload", "err", err)
+		return
+	}
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+	for _, v := range ff.syncStatusSubs {
+		v <- &status
+	}
+}
+
+// OnReorg - handles privateapi.EventReorg events, they are sent by a separate subscription
+func (ff *Filters) OnReorg(event *remote.SubscribeReply) {
+	var reorg privateapi.Reorg
+	if err := json.Unmarshal(event.Data, &reorg); err != nil {
+		log.Warn("OnReorg rpc filters, unprocessable payload", "err", err)
+		return
+	}
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+	for _, v := range ff.reorgSubs {
+		v <- &reorg
+	}
+}
+
+// OnForkChoice - handles privateapi.EventForkChoice events, they are sent by a separate subscription
+func (ff *Filters) OnForkChoice(event *remote.SubscribeReply) {
+	var forkChoice privateapi.ForkChoice
+	if err := json.Unmarshal(event.Data, &forkChoice); err != nil {
+		log.Warn("OnForkChoice rpc filters, unprocessable payload", "err", err)
+		return
+	}
+	ff.mu.Lock()
+	defer ff.mu.Unlock()
+	ff.forkChoice = &forkChoice
+	for _, v := range ff.forkChoiceSubs {
+		select {
+		case v <- &forkChoice:
+		default: // subscriber gets the next one
+			log.Warn("Dropping fork choice of slow subscriber", "head", forkChoice.Head.Number)
+		}
+	}
+}
+
+// OnReceipts - handles privateapi.EventReceipts events, they are sent by a separate subscription
+func (ff *Filters) OnReceipts(event *remote.SubscribeReply) {
+	var receipts privateapi.BlockReceipts
+	if err := json.Unmarshal(event.Data, &receipts); err != nil {
+		log.Warn("OnReceipts rpc filters, unprocessable payload", "err", err)
+		return
+	}
+	ff.mu.RLock()
+	defer ff.mu.RUnlock()
+	for _, v := range ff.receiptsSubs {
+		select {
+		case v <- &receipts:
+		default: // subscriber lags by whole buffer, or is unsubscribing
+			log.Warn("Dropping receipts of slow subscriber", "block", receipts.Number)
+		}
+	}
+}
+
 func (ff *Filters) OnNewTx(reply *txpool.OnAddReply) {
 	ff.mu.RLock()
 	defer ff.mu.RUnlock()
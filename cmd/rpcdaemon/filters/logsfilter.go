@@ -3,12 +3,29 @@ package filters
 import (
 	"sync"
 
+	"github.com/VictoriaMetrics/metrics"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
 	"github.com/ledgerwatch/erigon/common"
 	types2 "github.com/ledgerwatch/erigon/core/types"
 )
 
+var (
+	droppedSubscriberLogs       = metrics.GetOrCreateCounter(`rpc_subscription_logs_dropped`)
+	disconnectedLogsSubscribers = metrics.GetOrCreateCounter(`rpc_subscription_logs_disconnected`)
+)
+
+// SubscriberLogsBuffer - how logs are buffered for each subscriber of Filters.SubscribeLogs, one slow subscriber
+// must not stop delivery to others
+type SubscriberLogsBuffer struct {
+	Size       int  // amount of logs buffered
+	Disconnect bool // when buffer is full: drop the subscription instead of the oldest buffered log
+}
+
+func DefaultSubscriberLogsBuffer() SubscriberLogsBuffer {
+	return SubscriberLogsBuffer{Size: 1024}
+}
+
 type LogsFilterAggregator struct {
 	aggLogsFilter  LogsFilter                // Aggregation of all current log filters
 	logsFilters    map[LogsSubID]*LogsFilter // Filter for each subscriber, keyed by filterID
@@ -28,6 +45,7 @@ type LogsFilter struct {
 	topics         map[common.Hash]int
 	topicsOriginal [][]common.Hash  // Original topic filters to be applied before distributing to individual subscribers
 	sender         chan *types2.Log // nil for aggregate subscriber, for appropriate stream server otherwise
+	slow           chan struct{}    // closed when subscriber is dropped for full buffer, nil if the oldest log is dropped instead
 }
 
 func NewLogsFilterAggregator() *LogsFilterAggregator {
@@ -41,12 +59,15 @@ func NewLogsFilterAggregator() *LogsFilterAggregator {
 	}
 }
 
-func (a *LogsFilterAggregator) insertLogsFilter(sender chan *types2.Log) (LogsSubID, *LogsFilter) {
+func (a *LogsFilterAggregator) insertLogsFilter(buf SubscriberLogsBuffer) (LogsSubID, *LogsFilter) {
 	a.logsFilterLock.Lock()
 	defer a.logsFilterLock.Unlock()
 	filterId := a.nextFilterId
 	a.nextFilterId++
-	filter := &LogsFilter{addrs: map[common.Address]int{}, topics: map[common.Hash]int{}, sender: sender}
+	filter := &LogsFilter{addrs: map[common.Address]int{}, topics: map[common.Hash]int{}, sender: make(chan *types2.Log, buf.Size)}
+	if buf.Disconnect {
+		filter.slow = make(chan struct{})
+	}
 	a.logsFilters[filterId] = filter
 	return filterId, filter
 }
@@ -92,7 +113,7 @@ func (a *LogsFilterAggregator) distributeLog(eventLog *remote.SubscribeLogsReply
 	a.logsFilterLock.Lock()
 	defer a.logsFilterLock.Unlock()
 	filtersToDelete := make(map[LogsSubID]*LogsFilter)
-	for _, filter := range a.logsFilters {
+	for filterId, filter := range a.logsFilters {
 		if filter.allAddrs == 0 {
 			_, addrOk := filter.addrs[gointerfaces.ConvertH160toAddress(eventLog.Address)]
 			if !addrOk {
@@ -119,7 +140,9 @@ func (a *LogsFilterAggregator) distributeLog(eventLog *remote.SubscribeLogsReply
 			Index:       uint(eventLog.LogIndex),
 			Removed:     eventLog.Removed,
 		}
-		filter.sender <- lg
+		if !filter.send(lg) {
+			filtersToDelete[filterId] = filter
+		}
 	}
 	// remove malfunctioned filters
 	for filterId, filter := range filtersToDelete {
@@ -158,3 +181,24 @@ func (a *LogsFilterAggregator) chooseTopics(filter *LogsFilter, logTopics []comm
 	}
 	return true
 }
+
+// send - false if subscriber is dropped: its buffer is full and oldest logs of it must not be dropped
+func (f *LogsFilter) send(lg *types2.Log) bool {
+	for {
+		select {
+		case f.sender <- lg:
+			return true
+		default:
+		}
+		if f.slow != nil {
+			close(f.slow)
+			disconnectedLogsSubscribers.Inc()
+			return false
+		}
+		select {
+		case <-f.sender:
+			droppedSubscriberLogs.Inc()
+		default:
+		}
+	}
+}
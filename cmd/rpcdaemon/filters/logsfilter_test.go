@@ -0,0 +1,40 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowLogsSubscriber(t *testing.T) {
+	a := NewLogsFilterAggregator()
+	dropping, dropped := a.insertLogsFilter(SubscriberLogsBuffer{Size: 2})
+	_, disconnected := a.insertLogsFilter(SubscriberLogsBuffer{Size: 2, Disconnect: true})
+	_, fast := a.insertLogsFilter(SubscriberLogsBuffer{Size: 2})
+	for _, f := range []*LogsFilter{dropped, disconnected, fast} {
+		f.allAddrs, f.allTopics = 1, 1
+	}
+
+	var received []uint64
+	for i := uint64(1); i <= 4; i++ {
+		require.NoError(t, a.distributeLog(&remote.SubscribeLogsReply{
+			Address:         gointerfaces.ConvertAddressToH160(common.Address{1}),
+			BlockHash:       gointerfaces.ConvertHashToH256(common.Hash{1}),
+			TransactionHash: gointerfaces.ConvertHashToH256(common.Hash{2}),
+			BlockNumber:     i,
+		}))
+		received = append(received, (<-fast.sender).BlockNumber)
+	}
+	require.Equal(t, []uint64{1, 2, 3, 4}, received, "slow subscribers don't stop the others")
+
+	require.Equal(t, uint64(3), (<-dropped.sender).BlockNumber, "the oldest logs are dropped")
+	require.Equal(t, uint64(4), (<-dropped.sender).BlockNumber)
+	require.Contains(t, a.logsFilters, dropping)
+
+	_, open := <-disconnected.slow
+	require.False(t, open)
+	require.Len(t, a.logsFilters, 2, "disconnected subscriber is removed")
+}
@@ -0,0 +1,654 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	ethFilters "github.com/ledgerwatch/erigon/eth/filters"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/erigon/turbo/transactions"
+)
+
+// getLogs - result of eth_getLogs, which writes logs to JSON stream as it finds them
+func getLogs(ctx context.Context, eth commands.EthAPI, crit ethFilters.FilterCriteria) ([]*types.Log, error) {
+	var buf bytes.Buffer
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, &buf, 4096)
+	if err := eth.GetLogs(ctx, crit, stream); err != nil {
+		return nil, err
+	}
+	if err := stream.Flush(); err != nil {
+		return nil, err
+	}
+	var logs []*types.Log
+	if err := json.Unmarshal(buf.Bytes(), &logs); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+var errBlockInvariant = errors.New("block objects must be instantiated with at least one of num or hash")
+
+// Resolver - root of the schema. Blocks and transactions are read from db directly,
+// state of accounts, logs and receipts - by same code as serves eth_ namespace
+type Resolver struct {
+	db         kv.RoDB
+	eth        commands.EthAPI
+	filters    *filters.Filters
+	stateCache kvcache.Cache
+	gasCap     uint64
+	evmTimeout time.Duration
+}
+
+func chainConfig(tx kv.Tx) (*params.ChainConfig, error) {
+	genesisHash, err := rawdb.ReadCanonicalHash(tx, 0)
+	if err != nil {
+		return nil, err
+	}
+	return rawdb.ReadChainConfig(tx, genesisHash)
+}
+
+// readBlock - canonical block by number or any block by hash, nil if block is not found. Senders of
+// transactions are recovered if they are not stored
+func (r *Resolver) readBlock(ctx context.Context, number *uint64, hash *common.Hash) (*Block, error) {
+	tx, err := r.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	switch {
+	case hash != nil:
+		if number = rawdb.ReadHeaderNumber(tx, *hash); number == nil {
+			return nil, nil
+		}
+	case number != nil:
+		canonical, err := rawdb.ReadCanonicalHash(tx, *number)
+		if err != nil {
+			return nil, err
+		}
+		if canonical == (common.Hash{}) {
+			return nil, nil
+		}
+		hash = &canonical
+	default:
+		return nil, errBlockInvariant
+	}
+	block, senders, err := rawdb.ReadBlockWithSenders(tx, *hash, *number)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	if len(senders) != block.Transactions().Len() {
+		config, err := chainConfig(tx)
+		if err != nil {
+			return nil, err
+		}
+		signer := types.MakeSigner(config, block.NumberU64())
+		senders = make([]common.Address, block.Transactions().Len())
+		for i, txn := range block.Transactions() {
+			if senders[i], err = txn.Sender(*signer); err != nil {
+				return nil, fmt.Errorf("could not recover sender of %x: %w", txn.Hash(), err)
+			}
+		}
+		block.SendersToTxs(senders)
+	}
+	return &Block{r: r, block: block}, nil
+}
+
+// readTransaction - nil if transaction is not found in canonical chain
+func (r *Resolver) readTransaction(ctx context.Context, hash common.Hash) (*Transaction, error) {
+	tx, err := r.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	number, err := rawdb.ReadTxLookupEntry(tx, hash)
+	tx.Rollback()
+	if err != nil || number == nil {
+		return nil, err
+	}
+	block, err := r.readBlock(ctx, number, nil)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	for i, txn := range block.block.Transactions() {
+		if txn.Hash() == hash {
+			return &Transaction{r: r, tx: txn, block: block, index: uint64(i)}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *Resolver) latest(ctx context.Context) (uint64, error) {
+	number, err := r.eth.BlockNumber(ctx)
+	return uint64(number), err
+}
+
+// BlockNumberArgs - optional block at which state of account is read, latest by default
+type BlockNumberArgs struct {
+	Block *hexutil.Uint64
+}
+
+func (a BlockNumberArgs) numberOrLatest() rpc.BlockNumberOrHash {
+	if a.Block != nil {
+		return rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(*a.Block))
+	}
+	return rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+}
+
+// Account - state of account at some block
+type Account struct {
+	r             *Resolver
+	address       common.Address
+	blockNrOrHash rpc.BlockNumberOrHash
+}
+
+func (a *Account) Address(ctx context.Context) (common.Address, error) {
+	return a.address, nil
+}
+
+func (a *Account) Balance(ctx context.Context) (hexutil.Big, error) {
+	balance, err := a.r.eth.GetBalance(ctx, a.address, a.blockNrOrHash)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return *balance, nil
+}
+
+func (a *Account) TransactionCount(ctx context.Context) (hexutil.Uint64, error) {
+	nonce, err := a.r.eth.GetTransactionCount(ctx, a.address, a.blockNrOrHash)
+	if err != nil {
+		return 0, err
+	}
+	return *nonce, nil
+}
+
+func (a *Account) Code(ctx context.Context) (hexutil.Bytes, error) {
+	return a.r.eth.GetCode(ctx, a.address, a.blockNrOrHash)
+}
+
+func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash }) (common.Hash, error) {
+	value, err := a.r.eth.GetStorageAt(ctx, a.address, args.Slot.Hex(), a.blockNrOrHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.HexToHash(value), nil
+}
+
+// Log - event emitted by transaction
+type Log struct {
+	r   *Resolver
+	log *types.Log
+}
+
+func (l *Log) Index(ctx context.Context) int32 {
+	return int32(l.log.Index)
+}
+
+func (l *Log) Account(ctx context.Context, args BlockNumberArgs) *Account {
+	return &Account{r: l.r, address: l.log.Address, blockNrOrHash: args.numberOrLatest()}
+}
+
+func (l *Log) Topics(ctx context.Context) []common.Hash {
+	return l.log.Topics
+}
+
+func (l *Log) Data(ctx context.Context) hexutil.Bytes {
+	return l.log.Data
+}
+
+func (l *Log) Transaction(ctx context.Context) (*Transaction, error) {
+	txn, err := l.r.readTransaction(ctx, l.log.TxHash)
+	if err == nil && txn == nil {
+		err = fmt.Errorf("transaction %x not found", l.log.TxHash)
+	}
+	return txn, err
+}
+
+func wrapLogs(r *Resolver, logs []*types.Log) []*Log {
+	res := make([]*Log, len(logs))
+	for i, log := range logs {
+		res[i] = &Log{r: r, log: log}
+	}
+	return res
+}
+
+// Transaction - transaction included into canonical chain
+type Transaction struct {
+	r       *Resolver
+	tx      types.Transaction
+	block   *Block
+	index   uint64
+	receipt map[string]interface{} // loaded on first access
+}
+
+func (t *Transaction) getReceipt(ctx context.Context) (map[string]interface{}, error) {
+	if t.receipt != nil {
+		return t.receipt, nil
+	}
+	receipt, err := t.r.eth.GetTransactionReceipt(ctx, t.tx.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, fmt.Errorf("receipt of %x not found", t.tx.Hash())
+	}
+	t.receipt = receipt
+	return receipt, nil
+}
+
+func (t *Transaction) receiptUint64(ctx context.Context, field string) (*hexutil.Uint64, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := receipt[field].(hexutil.Uint64)
+	if !ok {
+		return nil, nil
+	}
+	return &value, nil
+}
+
+func (t *Transaction) Hash(ctx context.Context) common.Hash {
+	return t.tx.Hash()
+}
+
+func (t *Transaction) Nonce(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(t.tx.GetNonce())
+}
+
+func (t *Transaction) Index(ctx context.Context) *int32 {
+	index := int32(t.index)
+	return &index
+}
+
+func (t *Transaction) From(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	from, ok := t.tx.GetSender()
+	if !ok {
+		return nil, fmt.Errorf("sender of %x is unknown", t.tx.Hash())
+	}
+	return &Account{r: t.r, address: from, blockNrOrHash: args.numberOrLatest()}, nil
+}
+
+func (t *Transaction) To(ctx context.Context, args BlockNumberArgs) *Account {
+	to := t.tx.GetTo()
+	if to == nil {
+		return nil
+	}
+	return &Account{r: t.r, address: *to, blockNrOrHash: args.numberOrLatest()}
+}
+
+func (t *Transaction) Value(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*t.tx.GetValue().ToBig())
+}
+
+func (t *Transaction) GasPrice(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*t.tx.GetPrice().ToBig())
+}
+
+func (t *Transaction) Gas(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(t.tx.GetGas())
+}
+
+func (t *Transaction) InputData(ctx context.Context) hexutil.Bytes {
+	return t.tx.GetData()
+}
+
+func (t *Transaction) Block(ctx context.Context) *Block {
+	return t.block
+}
+
+func (t *Transaction) Status(ctx context.Context) (*hexutil.Uint64, error) {
+	return t.receiptUint64(ctx, "status")
+}
+
+func (t *Transaction) GasUsed(ctx context.Context) (*hexutil.Uint64, error) {
+	return t.receiptUint64(ctx, "gasUsed")
+}
+
+func (t *Transaction) CumulativeGasUsed(ctx context.Context) (*hexutil.Uint64, error) {
+	return t.receiptUint64(ctx, "cumulativeGasUsed")
+}
+
+func (t *Transaction) CreatedContract(ctx context.Context, args BlockNumberArgs) (*Account, error) {
+	if t.tx.GetTo() != nil {
+		return nil, nil
+	}
+	receipt, err := t.getReceipt(ctx)
+	if err != nil {
+		return nil, err
+	}
+	address, ok := receipt["contractAddress"].(common.Address)
+	if !ok {
+		return nil, nil
+	}
+	return &Account{r: t.r, address: address, blockNrOrHash: args.numberOrLatest()}, nil
+}
+
+func (t *Transaction) Logs(ctx context.Context) (*[]*Log, error) {
+	receipt, err := t.getReceipt(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logs, _ := receipt["logs"].(types.Logs)
+	res := wrapLogs(t.r, logs)
+	return &res, nil
+}
+
+// Block - block of canonical or side chain
+type Block struct {
+	r     *Resolver
+	block *types.Block
+}
+
+func (b *Block) blockNrOrHash() rpc.BlockNumberOrHash {
+	return rpc.BlockNumberOrHashWithHash(b.block.Hash(), false)
+}
+
+func (b *Block) Number(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(b.block.NumberU64())
+}
+
+func (b *Block) Hash(ctx context.Context) common.Hash {
+	return b.block.Hash()
+}
+
+func (b *Block) Parent(ctx context.Context) (*Block, error) {
+	if b.block.NumberU64() == 0 {
+		return nil, nil
+	}
+	parentHash := b.block.ParentHash()
+	return b.r.readBlock(ctx, nil, &parentHash)
+}
+
+func (b *Block) Nonce(ctx context.Context) hexutil.Bytes {
+	nonce := b.block.Header().Nonce
+	return nonce[:]
+}
+
+func (b *Block) TransactionsRoot(ctx context.Context) common.Hash {
+	return b.block.TxHash()
+}
+
+func (b *Block) TransactionCount(ctx context.Context) *int32 {
+	count := int32(b.block.Transactions().Len())
+	return &count
+}
+
+func (b *Block) StateRoot(ctx context.Context) common.Hash {
+	return b.block.Root()
+}
+
+func (b *Block) ReceiptsRoot(ctx context.Context) common.Hash {
+	return b.block.ReceiptHash()
+}
+
+func (b *Block) Miner(ctx context.Context, args BlockNumberArgs) *Account {
+	return &Account{r: b.r, address: b.block.Coinbase(), blockNrOrHash: args.numberOrLatest()}
+}
+
+func (b *Block) ExtraData(ctx context.Context) hexutil.Bytes {
+	return b.block.Extra()
+}
+
+func (b *Block) GasLimit(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(b.block.GasLimit())
+}
+
+func (b *Block) GasUsed(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(b.block.GasUsed())
+}
+
+func (b *Block) BaseFeePerGas(ctx context.Context) *hexutil.Big {
+	baseFee := b.block.BaseFee()
+	if baseFee == nil {
+		return nil
+	}
+	return (*hexutil.Big)(baseFee)
+}
+
+func (b *Block) Timestamp(ctx context.Context) hexutil.Uint64 {
+	return hexutil.Uint64(b.block.Time())
+}
+
+func (b *Block) LogsBloom(ctx context.Context) hexutil.Bytes {
+	bloom := b.block.Bloom()
+	return bloom.Bytes()
+}
+
+func (b *Block) MixHash(ctx context.Context) common.Hash {
+	return b.block.MixDigest()
+}
+
+func (b *Block) Difficulty(ctx context.Context) hexutil.Big {
+	return hexutil.Big(*b.block.Difficulty())
+}
+
+func (b *Block) OmmerCount(ctx context.Context) *int32 {
+	count := int32(len(b.block.Uncles()))
+	return &count
+}
+
+func (b *Block) OmmerHash(ctx context.Context) common.Hash {
+	return b.block.UncleHash()
+}
+
+func (b *Block) Transactions(ctx context.Context) *[]*Transaction {
+	res := make([]*Transaction, b.block.Transactions().Len())
+	for i, txn := range b.block.Transactions() {
+		res[i] = &Transaction{r: b.r, tx: txn, block: b, index: uint64(i)}
+	}
+	return &res
+}
+
+func (b *Block) TransactionAt(ctx context.Context, args struct{ Index int32 }) *Transaction {
+	txs := b.block.Transactions()
+	if args.Index < 0 || int(args.Index) >= txs.Len() {
+		return nil
+	}
+	return &Transaction{r: b.r, tx: txs[args.Index], block: b, index: uint64(args.Index)}
+}
+
+// BlockFilterCriteria - filter of logs of single block
+type BlockFilterCriteria struct {
+	Addresses *[]common.Address
+	Topics    *[][]common.Hash
+}
+
+func (b *Block) Logs(ctx context.Context, args struct{ Filter BlockFilterCriteria }) ([]*Log, error) {
+	hash := b.block.Hash()
+	crit := ethFilters.FilterCriteria{BlockHash: &hash}
+	if args.Filter.Addresses != nil {
+		crit.Addresses = *args.Filter.Addresses
+	}
+	if args.Filter.Topics != nil {
+		crit.Topics = *args.Filter.Topics
+	}
+	logs, err := getLogs(ctx, b.r.eth, crit)
+	if err != nil {
+		return nil, err
+	}
+	return wrapLogs(b.r, logs), nil
+}
+
+func (b *Block) Account(ctx context.Context, args struct{ Address common.Address }) *Account {
+	return &Account{r: b.r, address: args.Address, blockNrOrHash: b.blockNrOrHash()}
+}
+
+// CallData - arguments of call
+type CallData struct {
+	From     *common.Address
+	To       *common.Address
+	Gas      *hexutil.Uint64
+	GasPrice *hexutil.Big
+	Value    *hexutil.Big
+	Data     *hexutil.Bytes
+}
+
+func (d CallData) callArgs() ethapi.CallArgs {
+	return ethapi.CallArgs{From: d.From, To: d.To, Gas: d.Gas, GasPrice: d.GasPrice, Value: d.Value, Data: d.Data}
+}
+
+// CallResult - outcome of call, failed execution is not an error
+type CallResult struct {
+	data    hexutil.Bytes
+	gasUsed hexutil.Uint64
+	status  hexutil.Uint64
+}
+
+func (c *CallResult) Data() hexutil.Bytes {
+	return c.data
+}
+
+func (c *CallResult) GasUsed() hexutil.Uint64 {
+	return c.gasUsed
+}
+
+func (c *CallResult) Status() hexutil.Uint64 {
+	return c.status
+}
+
+func (b *Block) Call(ctx context.Context, args struct{ Data CallData }) (*CallResult, error) {
+	tx, err := b.r.db.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	config, err := chainConfig(tx)
+	if err != nil {
+		return nil, err
+	}
+	callArgs := args.Data.callArgs()
+	if callArgs.Gas == nil || *callArgs.Gas == 0 {
+		callArgs.Gas = (*hexutil.Uint64)(&b.r.gasCap)
+	}
+	contractHasTEVM := func(contractHash common.Hash) (bool, error) { return false, nil }
+	result, err := transactions.DoCall(ctx, callArgs, tx, b.blockNrOrHash(), b.block, nil, b.r.gasCap, b.r.evmTimeout, config, b.r.filters, b.r.stateCache, contractHasTEVM)
+	if err != nil {
+		return nil, err
+	}
+	res := &CallResult{data: result.ReturnData, gasUsed: hexutil.Uint64(result.UsedGas), status: 1}
+	if result.Failed() {
+		res.status = 0
+	}
+	return res, nil
+}
+
+func (b *Block) EstimateGas(ctx context.Context, args struct{ Data CallData }) (hexutil.Uint64, error) {
+	blockNrOrHash := b.blockNrOrHash()
+	return b.r.eth.EstimateGas(ctx, args.Data.callArgs(), &blockNrOrHash, nil)
+}
+
+func (r *Resolver) Block(ctx context.Context, args struct {
+	Number *hexutil.Uint64
+	Hash   *common.Hash
+}) (*Block, error) {
+	if args.Hash != nil {
+		return r.readBlock(ctx, nil, args.Hash)
+	}
+	var number uint64
+	if args.Number != nil {
+		number = uint64(*args.Number)
+	} else {
+		latest, err := r.latest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		number = latest
+	}
+	return r.readBlock(ctx, &number, nil)
+}
+
+func (r *Resolver) Blocks(ctx context.Context, args struct {
+	From hexutil.Uint64
+	To   *hexutil.Uint64
+}) ([]*Block, error) {
+	var to uint64
+	if args.To != nil {
+		to = uint64(*args.To)
+	} else {
+		latest, err := r.latest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		to = latest
+	}
+	if uint64(args.From) > to {
+		return []*Block{}, nil
+	}
+	res := make([]*Block, 0, to-uint64(args.From)+1)
+	for number := uint64(args.From); number <= to; number++ {
+		block, err := r.readBlock(ctx, &number, nil)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			break
+		}
+		res = append(res, block)
+	}
+	return res, nil
+}
+
+func (r *Resolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*Transaction, error) {
+	return r.readTransaction(ctx, args.Hash)
+}
+
+// FilterCriteria - filter of logs of range of blocks
+type FilterCriteria struct {
+	FromBlock *hexutil.Uint64
+	ToBlock   *hexutil.Uint64
+	Addresses *[]common.Address
+	Topics    *[][]common.Hash
+}
+
+func (r *Resolver) Logs(ctx context.Context, args struct{ Filter FilterCriteria }) ([]*Log, error) {
+	var crit ethFilters.FilterCriteria
+	if args.Filter.FromBlock != nil {
+		crit.FromBlock = new(big.Int).SetUint64(uint64(*args.Filter.FromBlock))
+	}
+	if args.Filter.ToBlock != nil {
+		crit.ToBlock = new(big.Int).SetUint64(uint64(*args.Filter.ToBlock))
+	}
+	if args.Filter.Addresses != nil {
+		crit.Addresses = *args.Filter.Addresses
+	}
+	if args.Filter.Topics != nil {
+		crit.Topics = *args.Filter.Topics
+	}
+	logs, err := getLogs(ctx, r.eth, crit)
+	if err != nil {
+		return nil, err
+	}
+	return wrapLogs(r, logs), nil
+}
+
+func (r *Resolver) GasPrice(ctx context.Context) (hexutil.Big, error) {
+	price, err := r.eth.GasPrice(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return *price, nil
+}
+
+func (r *Resolver) ChainID(ctx context.Context) (hexutil.Big, error) {
+	id, err := r.eth.ChainId(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*new(big.Int).SetUint64(uint64(id))), nil
+}
@@ -0,0 +1,80 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/rpcdaemontest"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/turbo/transactions"
+	"github.com/stretchr/testify/require"
+)
+
+func query(t *testing.T, handler http.Handler, q string) map[string]interface{} {
+	body, err := json.Marshal(map[string]string{"query": q})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body))))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var res struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []interface{}          `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Empty(t, res.Errors)
+	return res.Data
+}
+
+func TestGraphQL(t *testing.T) {
+	db := rpcdaemontest.CreateTestKV(t)
+	stateCache := kvcache.New(kvcache.DefaultCoherentConfig)
+	api := commands.NewEthAPI(commands.NewBaseApi(nil, stateCache, false), db, nil, nil, nil, 5000000)
+	handler, err := New(db, api, nil, stateCache, 5000000, transactions.DefaultCallTimeout)
+	require.NoError(t, err)
+
+	tx, err := db.BeginRo(context.Background())
+	require.NoError(t, err)
+	head := rawdb.ReadCurrentHeader(tx)
+	require.NotNil(t, head)
+	block, senders, err := rawdb.ReadBlockWithSenders(tx, head.Hash(), head.Number.Uint64())
+	tx.Rollback()
+	require.NoError(t, err)
+
+	data := query(t, handler, `{ block { number hash parent { hash } transactionCount transactions { hash from { address } status } } }`)
+	b := data["block"].(map[string]interface{})
+	require.Equal(t, block.Hash().Hex(), b["hash"])
+	require.Equal(t, block.ParentHash().Hex(), b["parent"].(map[string]interface{})["hash"])
+	require.EqualValues(t, block.Transactions().Len(), b["transactionCount"])
+	txs := b["transactions"].([]interface{})
+	require.Len(t, txs, block.Transactions().Len())
+	for i, txn := range block.Transactions() {
+		got := txs[i].(map[string]interface{})
+		require.Equal(t, txn.Hash().Hex(), got["hash"])
+		require.Equal(t, strings.ToLower(senders[i].Hex()), got["from"].(map[string]interface{})["address"])
+		require.Equal(t, "0x1", got["status"])
+	}
+
+	if block.Transactions().Len() > 0 {
+		hash := block.Transactions()[0].Hash().Hex()
+		data = query(t, handler, `{ transaction(hash: "`+hash+`") { hash block { hash } } }`)
+		got := data["transaction"].(map[string]interface{})
+		require.Equal(t, hash, got["hash"])
+		require.Equal(t, block.Hash().Hex(), got["block"].(map[string]interface{})["hash"])
+	}
+
+	data = query(t, handler, `{ blocks(from: 0, to: 2) { number } }`)
+	require.Len(t, data["blocks"], 3)
+
+	data = query(t, handler, `{ block(number: 0) { account(address: "0x0000000000000000000000000000000000000001") { balance transactionCount } call(data: {to: "0x0000000000000000000000000000000000000001"}) { status } } }`)
+	genesis := data["block"].(map[string]interface{})
+	require.Equal(t, "0x1", genesis["call"].(map[string]interface{})["status"])
+
+	data = query(t, handler, `{ transaction(hash: "0x0000000000000000000000000000000000000000000000000000000000000000") { hash } }`)
+	require.Nil(t, data["transaction"])
+}
@@ -0,0 +1,26 @@
+package graphql
+
+import (
+	"net/http"
+	"time"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
+)
+
+// maxQueryDepth - protects from queries like `block { parent { parent { ... } } }`
+const maxQueryDepth = 16
+
+// New - HTTP handler of GraphQL queries (POST requests with JSON body {"query": ..., "variables": ...})
+func New(db kv.RoDB, eth commands.EthAPI, filters *filters.Filters, stateCache kvcache.Cache, gasCap uint64, evmTimeout time.Duration) (http.Handler, error) {
+	resolver := &Resolver{db: db, eth: eth, filters: filters, stateCache: stateCache, gasCap: gasCap, evmTimeout: evmTimeout}
+	s, err := graphqlgo.ParseSchema(schema, resolver, graphqlgo.MaxDepth(maxQueryDepth))
+	if err != nil {
+		return nil, err
+	}
+	return &relay.Handler{Schema: s}, nil
+}
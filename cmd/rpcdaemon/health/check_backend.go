@@ -0,0 +1,38 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+)
+
+// Backend - connection to Erigon, implemented by services.RemoteBackend
+type Backend interface {
+	ServerVersion(ctx context.Context) (gointerfaces.Version, error)
+	CompatibleVersion(server gointerfaces.Version) bool
+}
+
+// BackendReachable - Erigon answers calls over private api
+func BackendReachable(backend Backend) Check {
+	return func(ctx context.Context) error {
+		if _, err := backend.ServerVersion(ctx); err != nil {
+			return fmt.Errorf("Erigon is unreachable: %w", err)
+		}
+		return nil
+	}
+}
+
+// BackendVersion - Erigon serves ETHBACKEND interface of version compatible with the daemon
+func BackendVersion(backend Backend) Check {
+	return func(ctx context.Context) error {
+		version, err := backend.ServerVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if !backend.CompatibleVersion(version) {
+			return fmt.Errorf("incompatible ETHBACKEND version %s", version)
+		}
+		return nil
+	}
+}
@@ -21,3 +21,22 @@ func checkMinPeers(minPeerCount uint, api NetAPI) error {
 
 	return nil
 }
+
+// PeerCounter - implemented by services.ApiBackend
+type PeerCounter interface {
+	NetPeerCount(ctx context.Context) (uint64, error)
+}
+
+// MinPeers - Erigon has at least minPeerCount peers
+func MinPeers(minPeerCount uint64, backend PeerCounter) Check {
+	return func(ctx context.Context) error {
+		peerCount, err := backend.NetPeerCount(ctx)
+		if err != nil {
+			return err
+		}
+		if peerCount < minPeerCount {
+			return fmt.Errorf("not enough peers: %d (minimum %d)", peerCount, minPeerCount)
+		}
+		return nil
+	}
+}
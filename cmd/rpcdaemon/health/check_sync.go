@@ -0,0 +1,35 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+)
+
+// SyncLag - fully synced chain in db is at most maxLag blocks behind the highest header known to the node
+func SyncLag(db kv.RoDB, maxLag uint64) Check {
+	return func(ctx context.Context) error {
+		tx, err := db.BeginRo(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		highestBlock, err := stages.GetStageProgress(tx, stages.Headers)
+		if err != nil {
+			return err
+		}
+		currentBlock, err := stages.GetStageProgress(tx, stages.Finish)
+		if err != nil {
+			return err
+		}
+		if highestBlock == 0 {
+			return fmt.Errorf("sync hasn't started")
+		}
+		if currentBlock+maxLag < highestBlock {
+			return fmt.Errorf("synced to block %d, %d blocks behind (maximum %d)", currentBlock, highestBlock-currentBlock, maxLag)
+		}
+		return nil
+	}
+}
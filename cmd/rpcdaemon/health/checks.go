@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const checkTimeout = 5 * time.Second
+
+// Check - condition of health, nil error means it holds
+type Check func(ctx context.Context) error
+
+// Result - outcome of one check
+type Result struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Report - outcomes of all checks, healthy if each of them is
+type Report struct {
+	Healthy bool     `json:"healthy"`
+	Checks  []Result `json:"checks"`
+}
+
+// Checks - named checks run concurrently, each within checkTimeout. Safe for concurrent use
+type Checks struct {
+	lock   sync.RWMutex
+	names  []string
+	checks []Check
+}
+
+// Add - check is run by every following Run, in order of adding in the report
+func (c *Checks) Add(name string, check Check) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.names = append(c.names, name)
+	c.checks = append(c.checks, check)
+}
+
+func (c *Checks) Run(ctx context.Context) *Report {
+	c.lock.RLock()
+	names, checks := c.names, c.checks
+	c.lock.RUnlock()
+
+	report := &Report{Healthy: true, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			report.Checks[i] = Result{Name: names[i], Healthy: true}
+			if err := checks[i](ctx); err != nil {
+				report.Checks[i].Healthy = false
+				report.Checks[i].Error = err.Error()
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, result := range report.Checks {
+		report.Healthy = report.Healthy && result.Healthy
+	}
+	return report
+}
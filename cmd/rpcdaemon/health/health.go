@@ -1,10 +1,11 @@
 package health
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
@@ -19,7 +20,8 @@ type requestBody struct {
 }
 
 const (
-	urlPath = "/health"
+	urlPath      = "/health"
+	readyURLPath = "/ready"
 )
 
 var (
@@ -31,17 +33,31 @@ func ProcessHealthcheckIfNeeded(
 	r *http.Request,
 	rpcAPI []rpc.API,
 ) bool {
-	if !strings.EqualFold(r.URL.Path, urlPath) {
+	ready := strings.EqualFold(r.URL.Path, readyURLPath)
+	if !ready && !strings.EqualFold(r.URL.Path, urlPath) {
 		return false
 	}
 
-	netAPI, ethAPI := parseAPI(rpcAPI)
+	netAPI, ethAPI, healthAPI := parseAPI(rpcAPI)
+
+	bodyBytes, errParse := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	// probes without body (GET /health, GET /ready) get report of configured checks
+	if ready || (errParse == nil && len(bytes.TrimSpace(bodyBytes)) == 0) {
+		if err := reportChecks(r.Context(), healthAPI, ready, w); err != nil {
+			log.Root().Warn("unable to process healthcheck request", "error", err)
+		}
+		return true
+	}
 
 	var errMinPeerCount = errCheckDisabled
 	var errCheckBlock = errCheckDisabled
 
-	body, errParse := parseHealthCheckBody(r.Body)
-	defer r.Body.Close()
+	var body requestBody
+	if errParse == nil {
+		body, errParse = parseHealthCheckBody(bodyBytes)
+	}
 
 	if errParse != nil {
 		log.Root().Warn("unable to process healthcheck request", "error", errParse)
@@ -65,15 +81,10 @@ func ProcessHealthcheckIfNeeded(
 	return true
 }
 
-func parseHealthCheckBody(reader io.Reader) (requestBody, error) {
+func parseHealthCheckBody(bodyBytes []byte) (requestBody, error) {
 	var body requestBody
 
-	bodyBytes, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return body, err
-	}
-
-	err = json.Unmarshal(bodyBytes, &body)
+	err := json.Unmarshal(bodyBytes, &body)
 	if err != nil {
 		return body, err
 	}
@@ -115,6 +126,31 @@ func reportHealth(errParse, errMinPeerCount, errCheckBlock error, w http.Respons
 	return nil
 }
 
+// reportChecks - 200 OK if all checks of liveness (or readiness if ready) pass, 503 Service Unavailable otherwise,
+// with Report as body
+func reportChecks(ctx context.Context, api HealthAPI, ready bool, w http.ResponseWriter) error {
+	report := &Report{Healthy: true, Checks: []Result{}}
+	if api != nil && ready {
+		report = api.Ready(ctx)
+	} else if api != nil {
+		report = api.Live(ctx)
+	}
+
+	bodyJson, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if report.Healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, err = w.Write(bodyJson)
+	return err
+}
+
 func shouldChangeStatusCode(err error) bool {
 	return err != nil && !errors.Is(err, errCheckDisabled)
 }
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+type testHealthAPI struct{ live, ready Checks }
+
+func (api *testHealthAPI) Live(ctx context.Context) *Report  { return api.live.Run(ctx) }
+func (api *testHealthAPI) Ready(ctx context.Context) *Report { return api.ready.Run(ctx) }
+
+func TestProbes(t *testing.T) {
+	api := &testHealthAPI{}
+	api.live.Add("backend_reachable", func(context.Context) error { return nil })
+	api.ready.Add("backend_reachable", func(context.Context) error { return nil })
+	api.ready.Add("sync_lag", func(context.Context) error { return errors.New("100 blocks behind") })
+	rpcAPI := []rpc.API{{Namespace: "health", Service: api}}
+
+	probe := func(method, path, body string) (int, Report) {
+		w := httptest.NewRecorder()
+		if !ProcessHealthcheckIfNeeded(w, httptest.NewRequest(method, path, strings.NewReader(body)), rpcAPI) {
+			t.Fatalf("%s %s is not processed", method, path)
+		}
+		var report Report
+		if body == "" {
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, report
+	}
+
+	code, report := probe(http.MethodGet, "/health", "")
+	if code != http.StatusOK || !report.Healthy || len(report.Checks) != 1 {
+		t.Fatalf("unexpected liveness %d %+v", code, report)
+	}
+	code, report = probe(http.MethodGet, "/ready", "")
+	if code != http.StatusServiceUnavailable || report.Healthy || len(report.Checks) != 2 {
+		t.Fatalf("unexpected readiness %d %+v", code, report)
+	}
+	if r := report.Checks[1]; r.Name != "sync_lag" || r.Healthy || r.Error != "100 blocks behind" {
+		t.Fatalf("unexpected result %+v", r)
+	}
+	// checks of request body are still served
+	if code, _ = probe(http.MethodPost, "/health", `{"min_peer_count": 1}`); code != http.StatusInternalServerError {
+		t.Fatalf("expected failed check of peers without net API, got %d", code)
+	}
+	if code, _ = probe(http.MethodPost, "/health", `{}`); code != http.StatusOK {
+		t.Fatalf("expected no checks to pass, got %d", code)
+	}
+
+	if ProcessHealthcheckIfNeeded(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), rpcAPI) {
+		t.Fatal("other paths must not be processed")
+	}
+}
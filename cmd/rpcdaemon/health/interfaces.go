@@ -14,3 +14,8 @@ type NetAPI interface {
 type EthAPI interface {
 	GetBlockByNumber(_ context.Context, number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error)
 }
+
+type HealthAPI interface {
+	Live(_ context.Context) *Report
+	Ready(_ context.Context) *Report
+}
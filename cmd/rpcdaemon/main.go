@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
+	"net/http"
 	"os"
+	"sort"
 
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/cli"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/commands"
 	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/filters"
+	"github.com/ledgerwatch/erigon/cmd/rpcdaemon/graphql"
 	"github.com/ledgerwatch/erigon/cmd/utils"
 	"github.com/ledgerwatch/log/v3"
 	"github.com/spf13/cobra"
@@ -16,21 +20,68 @@ func main() {
 	rootCtx, rootCancel := utils.RootContext()
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		logger := log.New()
-		db, backend, txPool, mining, stateCache, err := cli.RemoteServices(cmd.Context(), *cfg, logger, rootCancel)
+		// gRPC streams outlive rootCtx: subscriptions are served until requests are drained by StartRpcServer
+		streamsCtx, stopStreams := context.WithCancel(context.Background())
+		db, backend, txPool, mining, stateCache, err := cli.RemoteServices(streamsCtx, *cfg, logger, rootCancel)
 		if err != nil {
+			stopStreams()
 			log.Error("Could not connect to DB", "error", err)
 			return nil
 		}
 		defer db.Close()
+		var closeChains []func()
+		defer func() {
+			stopStreams()
+			if closer, ok := backend.(interface{ Close() }); ok {
+				closer.Close()
+			}
+			for _, closeChain := range closeChains {
+				closeChain()
+			}
+		}()
 
 		var ff *filters.Filters
 		if backend != nil {
-			ff = filters.New(rootCtx, backend, txPool, mining)
+			ff = filters.New(streamsCtx, backend, txPool, mining)
+			ff.SetLogsBuffer(filters.SubscriberLogsBuffer{Size: cfg.SubscriberLogsBuffer, Disconnect: cfg.DisconnectSlowLogs})
 		} else {
 			log.Info("filters are not supported in chaindata mode")
 		}
 
-		if err := cli.StartRpcServer(cmd.Context(), *cfg, commands.APIList(cmd.Context(), db, backend, txPool, mining, ff, stateCache, *cfg, nil)); err != nil {
+		var graphQLHandler http.Handler
+		if cfg.GraphQLEnabled {
+			ethImpl := commands.NewEthAPI(commands.NewBaseApi(ff, stateCache, cfg.SingleNodeMode), db, backend, txPool, mining, cfg.Gascap)
+			if graphQLHandler, err = graphql.New(db, ethImpl, ff, stateCache, cfg.Gascap, cfg.EVMTimeout); err != nil {
+				log.Error("Could not create GraphQL handler", "error", err)
+				return nil
+			}
+		}
+
+		names := make([]string, 0, len(cfg.Chains))
+		for name := range cfg.Chains {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		chains := make([]cli.ChainAPI, 0, len(names))
+		for _, name := range names {
+			chainCfg := cfg.ChainFlags(name)
+			chainDB, chainBackend, chainTxPool, chainMining, chainStateCache, err := cli.RemoteServices(streamsCtx, chainCfg, logger.New("chain", name), rootCancel)
+			if err != nil {
+				log.Error("Could not connect to chain", "chain", name, "error", err)
+				return nil
+			}
+			closeChains = append(closeChains, func() {
+				if closer, ok := chainBackend.(interface{ Close() }); ok {
+					closer.Close()
+				}
+				chainDB.Close()
+			})
+			chainFilters := filters.New(streamsCtx, chainBackend, chainTxPool, chainMining)
+			chainFilters.SetLogsBuffer(filters.SubscriberLogsBuffer{Size: cfg.SubscriberLogsBuffer, Disconnect: cfg.DisconnectSlowLogs})
+			chains = append(chains, cli.ChainAPI{Name: name, API: commands.APIList(cmd.Context(), chainDB, chainBackend, chainTxPool, chainMining, chainFilters, chainStateCache, chainCfg, nil)})
+		}
+
+		if err := cli.StartRpcServer(cmd.Context(), *cfg, commands.APIList(cmd.Context(), db, backend, txPool, mining, ff, stateCache, *cfg, nil), graphQLHandler, chains...); err != nil {
 			log.Error(err.Error())
 			return nil
 		}
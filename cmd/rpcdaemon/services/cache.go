@@ -0,0 +1,37 @@
+package services
+
+import "sync"
+
+// immutableCache - values which can't change while the node is running (network id, chain config, genesis, ...)
+type immutableCache struct {
+	lock   sync.RWMutex
+	values map[string]interface{}
+}
+
+// get - returns cached value or caches result of `load`. Errors are not cached.
+func (c *immutableCache) get(key string, load func() (interface{}, error)) (interface{}, error) {
+	c.lock.RLock()
+	v, ok := c.values[key]
+	c.lock.RUnlock()
+	if ok {
+		return v, nil
+	}
+	v, err := load()
+	if err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.values == nil {
+		c.values = map[string]interface{}{}
+	}
+	c.values[key] = v
+	return v, nil
+}
+
+// reset - drops all values, for example when the backend may have been restarted with other config
+func (c *immutableCache) reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.values = nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/VictoriaMetrics/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// ClientConn - connection to one address: *grpc.ClientConn or *ConnPool
+type ClientConn interface {
+	grpc.ClientConnInterface
+	GetState() connectivity.State
+	Target() string
+	Close() error
+}
+
+// ConnPool - several connections to the same address. Calls and streams are spread over healthy ones round-robin, so
+// concurrent requests are not limited by max concurrent streams and bandwidth of one HTTP/2 connection
+type ConnPool struct {
+	conns []*grpc.ClientConn
+	next  uint32 // atomic
+}
+
+// poolReady - latest pool of each target, exported by rpc_backend_pool_ready metric
+var poolReady sync.Map
+
+// NewConnPool - `conns` must be connections to the same address, ConnPool owns them
+func NewConnPool(conns []*grpc.ClientConn) *ConnPool {
+	p := &ConnPool{conns: conns}
+	v, loaded := poolReady.LoadOrStore(p.Target(), &atomic.Value{})
+	latest := v.(*atomic.Value)
+	latest.Store(p)
+	if !loaded {
+		metrics.GetOrCreateGauge(fmt.Sprintf(`rpc_backend_pool_ready{target=%q}`, p.Target()), func() float64 {
+			return float64(latest.Load().(*ConnPool).ready())
+		})
+	}
+	return p
+}
+
+// pick - next healthy connection, the first one if all are broken: call waits for it or fails with codes.Unavailable
+func (p *ConnPool) pick() *grpc.ClientConn {
+	start := int(atomic.AddUint32(&p.next, 1) - 1)
+	for i := range p.conns {
+		if conn := p.conns[(start+i)%len(p.conns)]; isHealthy(conn) {
+			return conn
+		}
+	}
+	return p.conns[0]
+}
+
+// ready - amount of connections in Ready state
+func (p *ConnPool) ready() int {
+	var n int
+	for _, conn := range p.conns {
+		if conn.GetState() == connectivity.Ready {
+			n++
+		}
+	}
+	return n
+}
+
+func (p *ConnPool) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	return p.pick().Invoke(ctx, method, args, reply, opts...)
+}
+
+func (p *ConnPool) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return p.pick().NewStream(ctx, desc, method, opts...)
+}
+
+// GetState - the best state of its connections: Ready if at least one of them is ready
+func (p *ConnPool) GetState() connectivity.State {
+	best := connectivity.Shutdown
+	for _, conn := range p.conns {
+		switch state := conn.GetState(); state {
+		case connectivity.Ready:
+			return state
+		case connectivity.Idle, connectivity.Connecting:
+			best = state
+		case connectivity.TransientFailure:
+			if best == connectivity.Shutdown {
+				best = state
+			}
+		}
+	}
+	return best
+}
+
+func (p *ConnPool) Target() string { return p.conns[0].Target() }
+
+// Close - closes all connections
+func (p *ConnPool) Close() error {
+	var res error
+	for _, conn := range p.conns {
+		if err := conn.Close(); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
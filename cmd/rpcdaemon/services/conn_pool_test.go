@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+func TestConnPool(t *testing.T) {
+	var calls [2]int
+	countingPeerCount := func(i int) func(context.Context) (*remote.NetPeerCountReply, error) {
+		return func(context.Context) (*remote.NetPeerCountReply, error) {
+			calls[i]++
+			return &remote.NetPeerCountReply{Count: uint64(i)}, nil
+		}
+	}
+	conn1 := newTestConn(t, &mockEthBackend{netPeerCount: countingPeerCount(0)})
+	conn2 := newTestConn(t, &mockEthBackend{netPeerCount: countingPeerCount(1)})
+	pool := NewConnPool([]*grpc.ClientConn{conn1, conn2})
+	client := remote.NewETHBACKENDClient(pool)
+
+	for i := 0; i < 4; i++ {
+		_, err := client.NetPeerCount(context.Background(), &remote.NetPeerCountRequest{})
+		require.NoError(t, err)
+	}
+	require.Equal(t, [2]int{2, 2}, calls, "calls are spread round-robin")
+	require.Equal(t, connectivity.Ready, pool.GetState())
+	require.Equal(t, 2, pool.ready())
+
+	// broken connection is skipped
+	conn1.Close()
+	for i := 0; i < 2; i++ {
+		_, err := client.NetPeerCount(context.Background(), &remote.NetPeerCountRequest{})
+		require.NoError(t, err)
+	}
+	require.Equal(t, [2]int{2, 4}, calls)
+	require.Equal(t, connectivity.Ready, pool.GetState())
+	require.Equal(t, 1, pool.ready())
+
+	conn2.Close()
+	require.Equal(t, connectivity.Shutdown, pool.GetState())
+}
+
+func TestCallTimeout(t *testing.T) {
+	slow := &mockEthBackend{netPeerCount: func(ctx context.Context) (*remote.NetPeerCountReply, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+			return &remote.NetPeerCountReply{Count: 1}, nil
+		}
+	}}
+	client := remote.NewETHBACKENDClient(newTestConn(t, slow, callMetricsOption(10*time.Millisecond)))
+	_, err := client.NetPeerCount(context.Background(), &remote.NetPeerCountRequest{})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+
+	// deadline of the context is kept if there is no call timeout
+	client = remote.NewETHBACKENDClient(newTestConn(t, slow, callMetricsOption(0)))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.NetPeerCount(ctx, &remote.NetPeerCountRequest{})
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	count, err := client.NetPeerCount(context.Background(), &remote.NetPeerCountRequest{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), count.Count)
+}
+
+func TestConnectPool(t *testing.T) {
+	opts := DefaultConnOptions()
+	conn, err := Connect(nil, "127.0.0.1:1", opts)
+	require.NoError(t, err)
+	require.IsType(t, &grpc.ClientConn{}, conn)
+	conn.Close()
+
+	opts.PoolSize = 3
+	conn, err = Connect(nil, "127.0.0.1:1", opts)
+	require.NoError(t, err)
+	pool, ok := conn.(*ConnPool)
+	require.True(t, ok)
+	require.Len(t, pool.conns, 3)
+	require.Equal(t, "127.0.0.1:1", pool.Target())
+	require.NoError(t, pool.Close())
+	require.Equal(t, connectivity.Shutdown, pool.GetState())
+}
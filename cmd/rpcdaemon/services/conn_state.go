@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/VictoriaMetrics/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// stateWatcher - notifies about connectivity state changes of *grpc.ClientConn
+type stateWatcher struct {
+	lock      sync.Mutex
+	callbacks []func(connectivity.State)
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func watchState(conn *grpc.ClientConn, onChange func(connectivity.State)) *stateWatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &stateWatcher{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		state := conn.GetState()
+		for conn.WaitForStateChange(ctx, state) {
+			state = conn.GetState()
+			onChange(state)
+			w.lock.Lock()
+			callbacks := w.callbacks
+			w.lock.Unlock()
+			for _, cb := range callbacks {
+				cb(state)
+			}
+		}
+	}()
+	return w
+}
+
+func (w *stateWatcher) add(cb func(connectivity.State)) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.callbacks = append(w.callbacks[:len(w.callbacks):len(w.callbacks)], cb)
+}
+
+func (w *stateWatcher) stop() {
+	w.cancel()
+	<-w.done
+}
+
+// cacheInvalidator - drops immutable values after connection was lost and established again:
+// there may be another node (or same node with another config) on other side
+func cacheInvalidator(cache *immutableCache) func(connectivity.State) {
+	failed := false
+	return func(state connectivity.State) {
+		switch state {
+		case connectivity.TransientFailure:
+			failed = true
+		case connectivity.Ready:
+			if failed {
+				cache.reset()
+				failed = false
+			}
+		}
+	}
+}
+
+// connStates - last connectivity state of each target, exported by rpc_backend_connection_state metric
+var connStates sync.Map
+
+// stateGauge - exports state of conn as rpc_backend_connection_state{target="..."}: 0 - idle, 1 - connecting,
+// 2 - ready, 3 - transient failure, 4 - shutdown
+func stateGauge(conn *grpc.ClientConn) func(connectivity.State) {
+	v, loaded := connStates.LoadOrStore(conn.Target(), new(int32))
+	state := v.(*int32)
+	atomic.StoreInt32(state, int32(conn.GetState()))
+	if !loaded {
+		metrics.GetOrCreateGauge(fmt.Sprintf(`rpc_backend_connection_state{target=%q}`, conn.Target()), func() float64 {
+			return float64(atomic.LoadInt32(state))
+		})
+	}
+	return func(s connectivity.State) {
+		atomic.StoreInt32(state, int32(s))
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestCacheInvalidatedAfterReconnect(t *testing.T) {
+	var lock sync.Mutex
+	var listener *bufconn.Listener
+	serve := func(netVersion uint64) *grpc.Server {
+		server := grpc.NewServer()
+		remote.RegisterETHBACKENDServer(server, &mockEthBackend{netVersion: func() (*remote.NetVersionReply, error) {
+			return &remote.NetVersionReply{Id: netVersion}, nil
+		}})
+		lock.Lock()
+		listener = bufconn.Listen(1024 * 1024)
+		go func(l net.Listener) { _ = server.Serve(l) }(listener)
+		lock.Unlock()
+		return server
+	}
+
+	server := serve(1)
+	conn, err := grpc.Dial("", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return listener.Dial()
+	}))
+	require.NoError(t, err)
+	defer conn.Close()
+	back := NewRemoteBackend(conn)
+	defer back.Close()
+
+	states := make(chan connectivity.State, 100)
+	back.OnStateChange(func(state connectivity.State) { states <- state })
+	waitFor := func(expected connectivity.State) {
+		t.Helper()
+		for {
+			select {
+			case state := <-states:
+				if state == expected {
+					return
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatalf("no %s state", expected)
+			}
+		}
+	}
+
+	id, err := back.NetVersion(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+
+	server.Stop()
+	// cached value doesn't need server, but any other call triggers reconnect
+	_, err = back.NetPeerCount(context.Background())
+	require.Error(t, err)
+	waitFor(connectivity.TransientFailure)
+	id, err = back.NetVersion(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+
+	server = serve(2)
+	defer server.Stop()
+	// connection is idle after failure, until next call
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, _ = back.remoteEthBackend.NetPeerCount(ctx, &remote.NetPeerCountRequest{}, grpc.WaitForReady(true))
+	waitFor(connectivity.Ready)
+	id, err = back.NetVersion(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), id)
+}
+
+func TestOnStateChangeWithoutClientConn(t *testing.T) {
+	back := NewRemoteBackend(&taggingConn{})
+	back.OnStateChange(func(connectivity.State) { t.Fatal("must not be called") })
+	back.Close()
+}
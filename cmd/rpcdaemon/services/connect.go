@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/c2h5oh/datasize"
+	"github.com/ledgerwatch/erigon/internal/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultKeepaliveInterval = 30 * time.Second
+	DefaultKeepaliveTimeout  = 10 * time.Second
+	DefaultMaxMsgSize        = int(200 * datasize.MB)
+	DefaultCallTimeout       = time.Minute
+)
+
+// KeepaliveParams - client pings idle connection every `interval` and closes it if no ack received during `timeout`.
+// Without pings a connection silently dropped by stateful firewall (no RST sent) is never detected
+// and stream's Recv() blocks forever. gRPC doesn't allow interval less than 10 seconds.
+func KeepaliveParams(interval, timeout time.Duration) keepalive.ClientParameters {
+	return keepalive.ClientParameters{
+		Time:                interval,
+		Timeout:             timeout,
+		PermitWithoutStream: true, // streams may be idle for hours - ping also when no active RPCs
+	}
+}
+
+// ConnOptions - tuning of gRPC connections of rpcdaemon to Erigon, txpool and sentries
+type ConnOptions struct {
+	Keepalive   keepalive.ClientParameters
+	MaxMsgSize  int           // max size of sent and received messages
+	CallTimeout time.Duration // deadline of each unary call, 0 - only deadline of its context. Streams have none
+	PoolSize    int           // connections to each address, calls and streams are spread over them
+}
+
+func DefaultConnOptions() ConnOptions {
+	return ConnOptions{
+		Keepalive:   KeepaliveParams(DefaultKeepaliveInterval, DefaultKeepaliveTimeout),
+		MaxMsgSize:  DefaultMaxMsgSize,
+		CallTimeout: DefaultCallTimeout,
+		PoolSize:    1,
+	}
+}
+
+// DialOptions - same options as grpcutil.Connect uses, plus configurable keepalive, message size and call timeout
+func DialOptions(creds credentials.TransportCredentials, opts ConnOptions) []grpc.DialOption {
+	backoffCfg := backoff.DefaultConfig
+	backoffCfg.BaseDelay = 500 * time.Millisecond
+	backoffCfg.MaxDelay = 10 * time.Second
+	dialOpts := []grpc.DialOption{
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoffCfg, MinConnectTimeout: 10 * time.Minute}),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(opts.MaxMsgSize), grpc.MaxCallSendMsgSize(opts.MaxMsgSize)),
+		grpc.WithKeepaliveParams(opts.Keepalive),
+		callMetricsOption(opts.CallTimeout),
+	}
+	if creds == nil {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	} else {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(creds))
+	}
+	dialOpts = append(dialOpts, grpcTimeOptions()...)
+	dialOpts = append(dialOpts, requestIDOptions()...)
+	return append(dialOpts, tracing.DialOptions()...)
+}
+
+// Connect - *grpc.ClientConn to dialAddress, or *ConnPool of opts.PoolSize connections to it
+func Connect(creds credentials.TransportCredentials, dialAddress string, opts ConnOptions) (ClientConn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if opts.PoolSize <= 1 {
+		return grpc.DialContext(ctx, dialAddress, DialOptions(creds, opts)...)
+	}
+	conns := make([]*grpc.ClientConn, 0, opts.PoolSize)
+	for i := 0; i < opts.PoolSize; i++ {
+		conn, err := grpc.DialContext(ctx, dialAddress, DialOptions(creds, opts)...)
+		if err != nil {
+			for _, c := range conns {
+				c.Close()
+			}
+			return nil, err
+		}
+		conns = append(conns, conn)
+	}
+	return NewConnPool(conns), nil
+}
+
+// callMetricsOption - interceptor limiting each unary call by `timeout` (if it's not 0) and exporting its duration as
+// rpc_backend_call_duration_seconds{method} and failures as rpc_backend_call_errors{method,code}
+func callMetricsOption(timeout time.Duration) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		metrics.GetOrCreateSummary(fmt.Sprintf(`rpc_backend_call_duration_seconds{method=%q}`, method)).UpdateDuration(start)
+		if err != nil {
+			metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_backend_call_errors{method=%q,code=%q}`, method, status.Code(err))).Inc()
+		}
+		return err
+	})
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// blackholeConn - after drop() behaves like connection lost without RST: writes are swallowed, reads never return
+type blackholeConn struct {
+	net.Conn
+	dropOnce, closeOnce sync.Once
+	dropped, closed     chan struct{}
+}
+
+func newBlackholeConn() *blackholeConn {
+	return &blackholeConn{dropped: make(chan struct{}), closed: make(chan struct{})}
+}
+
+func (c *blackholeConn) drop() { c.dropOnce.Do(func() { close(c.dropped) }) }
+
+func (c *blackholeConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return c.Conn.Close()
+}
+
+func (c *blackholeConn) Read(b []byte) (int, error) {
+	type result struct {
+		n   int
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		n, err := c.Conn.Read(b)
+		ch <- result{n, err}
+	}()
+	select {
+	case <-c.dropped:
+	case r := <-ch:
+		select {
+		case <-c.dropped:
+		default:
+			return r.n, r.err
+		}
+	}
+	<-c.closed // bytes read after drop are lost
+	return 0, net.ErrClosed
+}
+
+func (c *blackholeConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.dropped:
+		return len(b), nil
+	default:
+		return c.Conn.Write(b)
+	}
+}
+
+type silentEthBackend struct {
+	remote.UnimplementedETHBACKENDServer
+}
+
+func (*silentEthBackend) Subscribe(_ *remote.SubscribeRequest, server remote.ETHBACKEND_SubscribeServer) error {
+	if err := server.Send(&remote.SubscribeReply{Type: remote.Event_HEADER}); err != nil {
+		return err
+	}
+	<-server.Context().Done()
+	return server.Context().Err()
+}
+
+func TestKeepaliveDetectsDeadConnection(t *testing.T) {
+	if testing.Short() {
+		t.Skip("gRPC doesn't allow keepalive interval less than 10 seconds")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := grpc.NewServer()
+	remote.RegisterETHBACKENDServer(server, &silentEthBackend{})
+	listener := bufconn.Listen(1024 * 1024)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	conn := newBlackholeConn()
+	dialOpts := append(DialOptions(nil, ConnOptions{Keepalive: KeepaliveParams(10*time.Second, time.Second), MaxMsgSize: DefaultMaxMsgSize}),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			c, err := listener.Dial()
+			conn.Conn = c
+			return conn, err
+		}))
+	cc, err := grpc.DialContext(ctx, "", dialOpts...)
+	require.NoError(t, err)
+	defer cc.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- NewRemoteBackend(cc).Subscribe(ctx, func(*remote.SubscribeReply) { conn.drop() })
+	}()
+
+	select {
+	case err := <-done:
+		require.Error(t, err)
+	case <-time.After(time.Minute):
+		t.Fatal("subscription hangs on dead connection")
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// DecodedLog - log with parameters of ABI event it matches. Event is nil if log doesn't match any of known events
+type DecodedLog struct {
+	Log    *types.Log
+	Event  *abi.Event
+	Params map[string]interface{} // by names of event inputs, indexed ones too
+}
+
+// eventsByID - non-anonymous events by their topic0
+func eventsByID(events []abi.Event) map[common.Hash]*abi.Event {
+	byID := make(map[common.Hash]*abi.Event, len(events))
+	for i := range events {
+		if !events[i].Anonymous {
+			byID[events[i].ID] = &events[i]
+		}
+	}
+	return byID
+}
+
+func logFromReply(reply *remote.SubscribeLogsReply) *types.Log {
+	topics := make([]common.Hash, 0, len(reply.Topics))
+	for _, topic := range reply.Topics {
+		topics = append(topics, gointerfaces.ConvertH256ToHash(topic))
+	}
+	return &types.Log{
+		Address:     gointerfaces.ConvertH160toAddress(reply.Address),
+		Topics:      topics,
+		Data:        reply.Data,
+		BlockNumber: reply.BlockNumber,
+		TxHash:      gointerfaces.ConvertH256ToHash(reply.TransactionHash),
+		TxIndex:     uint(reply.TransactionIndex),
+		BlockHash:   gointerfaces.ConvertH256ToHash(reply.BlockHash),
+		Index:       uint(reply.LogIndex),
+		Removed:     reply.Removed,
+	}
+}
+
+// decodeLog - log which can't be decoded by its matching event is passed as raw one
+func decodeLog(byID map[common.Hash]*abi.Event, lg *types.Log) DecodedLog {
+	decoded := DecodedLog{Log: lg}
+	if len(lg.Topics) == 0 {
+		return decoded
+	}
+	event, ok := byID[lg.Topics[0]]
+	if !ok {
+		return decoded
+	}
+	params := map[string]interface{}{}
+	if err := event.Inputs.NonIndexed().UnpackIntoMap(params, lg.Data); err != nil {
+		log.Warn("rpcdaemon: cannot decode log data", "event", event.Sig, "tx", lg.TxHash, "err", err)
+		return decoded
+	}
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if err := abi.ParseTopicsIntoMap(params, indexed, lg.Topics[1:]); err != nil {
+		log.Warn("rpcdaemon: cannot decode log topics", "event", event.Sig, "tx", lg.TxHash, "err", err)
+		return decoded
+	}
+	decoded.Event, decoded.Params = event, params
+	return decoded
+}
+
+// SubscribeDecodedLogs - logs matching `filter` (all logs if nil), decoded by `events` they match by topic0
+func (back *RemoteBackend) SubscribeDecodedLogs(ctx context.Context, events []abi.Event, filter *remote.LogsFilterRequest, onLog func(DecodedLog)) error {
+	if filter == nil {
+		filter = &remote.LogsFilterRequest{AllAddresses: true, AllTopics: true}
+	}
+	byID := eventsByID(events)
+	return back.subscribeLogs(ctx, func(reply *remote.SubscribeLogsReply) {
+		onLog(decodeLog(byID, logFromReply(reply)))
+	}, func(send func(*remote.LogsFilterRequest) error) error {
+		return send(filter)
+	})
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const erc20TransferABI = `[{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Transfer","type":"event"}]`
+
+func TestSubscribeDecodedLogs(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(erc20TransferABI))
+	require.NoError(t, err)
+	transfer := parsed.Events["Transfer"]
+	from, to := common.HexToAddress("0x1111"), common.HexToAddress("0x2222")
+	data, err := transfer.Inputs.NonIndexed().Pack(big.NewInt(1000))
+	require.NoError(t, err)
+	token := common.HexToAddress("0xc0ffee")
+	txHash, blockHash := gointerfaces.ConvertHashToH256(common.HexToHash("0x01")), gointerfaces.ConvertHashToH256(common.HexToHash("0x02"))
+
+	back := newTestRemoteBackend(t, &mockEthBackend{subscribeLogs: func(server remote.ETHBACKEND_SubscribeLogsServer) error {
+		filter, err := server.Recv()
+		if err != nil {
+			return err
+		}
+		if !filter.AllAddresses || !filter.AllTopics {
+			return status.Error(codes.InvalidArgument, "unexpected filter")
+		}
+		for _, reply := range []*remote.SubscribeLogsReply{
+			{Address: gointerfaces.ConvertAddressToH160(token), Data: data, BlockNumber: 10, BlockHash: blockHash, TransactionHash: txHash, LogIndex: 3, Topics: []*types2.H256{
+				gointerfaces.ConvertHashToH256(transfer.ID), gointerfaces.ConvertHashToH256(from.Hash()), gointerfaces.ConvertHashToH256(to.Hash()),
+			}},
+			{Address: gointerfaces.ConvertAddressToH160(token), Data: []byte{1}, BlockNumber: 10, BlockHash: blockHash, TransactionHash: txHash, LogIndex: 4, Topics: []*types2.H256{
+				gointerfaces.ConvertHashToH256(common.HexToHash("0xabcd")),
+			}},
+		} {
+			if err := server.Send(reply); err != nil {
+				return err
+			}
+		}
+		return nil
+	}})
+
+	var logs []DecodedLog
+	err = back.SubscribeDecodedLogs(context.Background(), []abi.Event{transfer}, nil, func(lg DecodedLog) {
+		logs = append(logs, lg)
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+
+	require.Equal(t, "Transfer", logs[0].Event.Name)
+	require.Equal(t, map[string]interface{}{"from": from, "to": to, "value": big.NewInt(1000)}, logs[0].Params)
+	require.Equal(t, token, logs[0].Log.Address)
+	require.Equal(t, uint(3), logs[0].Log.Index)
+
+	require.Nil(t, logs[1].Event)
+	require.Nil(t, logs[1].Params)
+	require.Equal(t, []byte{1}, logs[1].Log.Data)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+)
+
+// ExecutionPayload - block as Engine API transfers it, see https://github.com/ethereum/execution-apis/blob/main/src/engine/specification.md
+type ExecutionPayload struct {
+	ParentHash    common.Hash     `json:"parentHash"`
+	FeeRecipient  common.Address  `json:"feeRecipient"`
+	StateRoot     common.Hash     `json:"stateRoot"`
+	ReceiptsRoot  common.Hash     `json:"receiptsRoot"`
+	LogsBloom     hexutil.Bytes   `json:"logsBloom"`
+	PrevRandao    common.Hash     `json:"prevRandao"`
+	BlockNumber   hexutil.Uint64  `json:"blockNumber"`
+	GasLimit      hexutil.Uint64  `json:"gasLimit"`
+	GasUsed       hexutil.Uint64  `json:"gasUsed"`
+	Timestamp     hexutil.Uint64  `json:"timestamp"`
+	ExtraData     hexutil.Bytes   `json:"extraData"`
+	BaseFeePerGas *hexutil.Big    `json:"baseFeePerGas"`
+	BlockHash     common.Hash     `json:"blockHash"`
+	Transactions  []hexutil.Bytes `json:"transactions"`
+}
+
+// PayloadStatus - result of payload validation, Status is one of VALID, INVALID, SYNCING, ACCEPTED, INVALID_BLOCK_HASH
+type PayloadStatus struct {
+	Status          string       `json:"status"`
+	LatestValidHash *common.Hash `json:"latestValidHash"`
+	ValidationError *string      `json:"validationError"`
+}
+
+type ForkChoiceState struct {
+	HeadBlockHash      common.Hash `json:"headBlockHash"`
+	SafeBlockHash      common.Hash `json:"safeBlockHash"`
+	FinalizedBlockHash common.Hash `json:"finalizedBlockHash"`
+}
+
+// PayloadAttributes - request to start building of payload on top of new head
+type PayloadAttributes struct {
+	Timestamp             hexutil.Uint64 `json:"timestamp"`
+	PrevRandao            common.Hash    `json:"prevRandao"`
+	SuggestedFeeRecipient common.Address `json:"suggestedFeeRecipient"`
+}
+
+type ForkChoiceUpdatedResult struct {
+	PayloadStatus PayloadStatus  `json:"payloadStatus"`
+	PayloadID     *hexutil.Bytes `json:"payloadId"` // nil if building of payload wasn't requested or didn't start
+}
+
+type forkChoiceUpdatedRequest struct {
+	ForkChoiceState   *ForkChoiceState   `json:"forkchoiceState"`
+	PayloadAttributes *PayloadAttributes `json:"payloadAttributes,omitempty"`
+}
+
+type payloadIDRequest struct {
+	PayloadID hexutil.Bytes `json:"payloadId"`
+}
+
+// EngineNewPayloadV1 - passes block received by consensus client to the node for validation and execution
+func (back *RemoteBackend) EngineNewPayloadV1(ctx context.Context, payload *ExecutionPayload) (*PayloadStatus, error) {
+	var res PayloadStatus
+	if err := back.invoke(ctx, "EngineNewPayloadV1", payload, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// EngineForkchoiceUpdatedV1 - updates head, safe and finalized blocks of the node. If `attrs` is not nil,
+// the node also starts building of payload, which can be retrieved by EngineGetPayloadV1
+func (back *RemoteBackend) EngineForkchoiceUpdatedV1(ctx context.Context, state *ForkChoiceState, attrs *PayloadAttributes) (*ForkChoiceUpdatedResult, error) {
+	var res ForkChoiceUpdatedResult
+	if err := back.invoke(ctx, "EngineForkchoiceUpdatedV1", forkChoiceUpdatedRequest{ForkChoiceState: state, PayloadAttributes: attrs}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// EngineGetPayloadV1 - payload built by the node since EngineForkchoiceUpdatedV1 returned `payloadID`
+func (back *RemoteBackend) EngineGetPayloadV1(ctx context.Context, payloadID hexutil.Bytes) (*ExecutionPayload, error) {
+	var res ExecutionPayload
+	if err := back.invoke(ctx, "EngineGetPayloadV1", payloadIDRequest{PayloadID: payloadID}, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineAPI(t *testing.T) {
+	payload := &ExecutionPayload{
+		ParentHash:    common.HexToHash("0x01"),
+		LogsBloom:     make(hexutil.Bytes, 256),
+		BlockNumber:   100,
+		GasLimit:      30_000_000,
+		Timestamp:     1650000000,
+		BaseFeePerGas: (*hexutil.Big)(big.NewInt(7)),
+		ExtraData:     hexutil.Bytes("erigon"),
+		BlockHash:     common.HexToHash("0x02"),
+		Transactions:  []hexutil.Bytes{{0x01, 0x02}},
+	}
+	payloadID := hexutil.Bytes{0, 0, 0, 0, 0, 0, 0, 1}
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"EngineNewPayloadV1": func(args json.RawMessage) (interface{}, error) {
+			var got ExecutionPayload
+			if err := json.Unmarshal(args, &got); err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(payload, &got) {
+				return nil, fmt.Errorf("unexpected payload %+v", got)
+			}
+			return PayloadStatus{Status: "VALID", LatestValidHash: &got.BlockHash}, nil
+		},
+		"EngineForkchoiceUpdatedV1": func(args json.RawMessage) (interface{}, error) {
+			var req forkChoiceUpdatedRequest
+			if err := json.Unmarshal(args, &req); err != nil {
+				return nil, err
+			}
+			if req.ForkChoiceState.HeadBlockHash != payload.BlockHash {
+				return nil, fmt.Errorf("unexpected head %x", req.ForkChoiceState.HeadBlockHash)
+			}
+			res := ForkChoiceUpdatedResult{PayloadStatus: PayloadStatus{Status: "VALID"}}
+			if req.PayloadAttributes != nil {
+				res.PayloadID = &payloadID
+			}
+			return res, nil
+		},
+		"EngineGetPayloadV1": func(args json.RawMessage) (interface{}, error) {
+			var req payloadIDRequest
+			if err := json.Unmarshal(args, &req); err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(payloadID, req.PayloadID) {
+				return nil, fmt.Errorf("unknown payload %x", req.PayloadID)
+			}
+			return payload, nil
+		},
+	}})
+	ctx := context.Background()
+
+	status, err := back.EngineNewPayloadV1(ctx, payload)
+	require.NoError(t, err)
+	require.Equal(t, "VALID", status.Status)
+	require.Equal(t, payload.BlockHash, *status.LatestValidHash)
+
+	state := &ForkChoiceState{HeadBlockHash: payload.BlockHash}
+	updated, err := back.EngineForkchoiceUpdatedV1(ctx, state, nil)
+	require.NoError(t, err)
+	require.Equal(t, "VALID", updated.PayloadStatus.Status)
+	require.Nil(t, updated.PayloadID)
+	updated, err = back.EngineForkchoiceUpdatedV1(ctx, state, &PayloadAttributes{Timestamp: 1650000012})
+	require.NoError(t, err)
+	require.Equal(t, payloadID, *updated.PayloadID)
+
+	built, err := back.EngineGetPayloadV1(ctx, payloadID)
+	require.NoError(t, err)
+	require.Equal(t, payload, built)
+}
@@ -1,20 +1,37 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon/accounts/abi"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
 	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/log/v3"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
@@ -29,31 +46,226 @@ type ApiBackend interface {
 	ProtocolVersion(ctx context.Context) (uint64, error)
 	ClientVersion(ctx context.Context) (string, error)
 	Subscribe(ctx context.Context, cb func(*remote.SubscribeReply)) error
+	SubscribeTopics(ctx context.Context, topics []remote.Event, cb func(*remote.SubscribeReply)) error
 	SubscribeLogs(ctx context.Context, cb func(*remote.SubscribeLogsReply), requestor *atomic.Value) error
+	SubscribeDecodedLogs(ctx context.Context, events []abi.Event, filter *remote.LogsFilterRequest, cb func(DecodedLog)) error
+	SubscribePendingTxs(ctx context.Context, cb func([]types.Transaction)) error
 	NodeInfo(ctx context.Context, limit uint32) ([]p2p.NodeInfo, error)
+	NodeInfoStream(ctx context.Context, limit uint32) (<-chan NodeInfoItem, error)
+	ListenPorts(ctx context.Context) (p2pTCP, p2pUDP, rpcHTTP, rpcWS int, err error)
+	ReorgHistory(ctx context.Context, limit uint32) ([]ReorgRecord, error)
+	MaxAcceptedReorgDepth(ctx context.Context) (uint64, error)
+	TotalSupply(ctx context.Context, blockNum uint64) (*big.Int, error)
+	ExecCacheHitRatio(ctx context.Context) (float64, error)
+	GoroutineBreakdown(ctx context.Context) (map[string]uint64, error)
+	ExecutionTxRate(ctx context.Context) (txPerSec float64, err error)
+	MissedProposals(ctx context.Context, window uint64) (uint64, error)
+	Listening(ctx context.Context) (bool, error)
+	SelfNodeInfo(ctx context.Context) (p2p.NodeInfo, error)
+	StateSyncPeers(ctx context.Context) ([]p2p.NodeInfo, error)
+	DiscoveryNetworkName(ctx context.Context) (string, error)
+	DialBackoffPeers(ctx context.Context) (map[string]time.Time, error)
+	AddPeer(ctx context.Context, url string) (bool, error)
+	RemovePeer(ctx context.Context, url string) (bool, error)
+	Peers(ctx context.Context) ([]*p2p.PeerInfo, error)
+	SubscribePeerBans(ctx context.Context, cb func(enode string, reason string, until time.Time)) error
+	SubscribePeerEvents(ctx context.Context, cb func(PeerEvent)) error
+	SubscribeUnwinds(ctx context.Context, cb func(stage string, fromBlock, toBlock uint64)) error
+	SubscribeFinalized(ctx context.Context, cb func(blockNum uint64, hash common.Hash)) error
+	SubscribeExecutionState(ctx context.Context, cb func(paused bool, reason string)) error
+	SubscribeDiskWarnings(ctx context.Context, cb func(freeBytes uint64, threshold uint64)) error
+	SubscribeConfigReloads(ctx context.Context, cb func(ts time.Time, changed []string)) error
+	SubscribeStateRootMismatches(ctx context.Context, cb func(blockNum uint64, expected, got common.Hash)) error
+	SubscribeMempoolPressure(ctx context.Context, thresholds []float64, cb func(level float64)) error
+	GasPrice(ctx context.Context) (*big.Int, error)
+	GasPriceCap(ctx context.Context) (*big.Int, error)
+	MinGasPrice(ctx context.Context) (*big.Int, error)
+	FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*FeeHistoryResult, error)
+	HeaderCacheStats(ctx context.Context) (entries, capacity uint64, err error)
+	ReceiptCacheStats(ctx context.Context) (entries, capacity uint64, err error)
+	TxpoolTransitions(ctx context.Context, window uint64) (promotions, demotions uint64, err error)
+	TxPropagationBatchSize(ctx context.Context) (uint64, error)
+	MaxReceiptSize(ctx context.Context) (uint64, error)
+	RPCBatchLimit(ctx context.Context) (uint64, error)
+	AllowedRPCOrigins(ctx context.Context) ([]string, error)
+	MaxConcurrentTraces(ctx context.Context) (uint64, error)
+	MaxTraceDuration(ctx context.Context) (time.Duration, error)
+	DBGrowthAlarmThreshold(ctx context.Context) (bytesPerDay uint64, err error)
+	EngineJWTWindow(ctx context.Context) (time.Duration, error)
+	HeaderBatchSize(ctx context.Context) (uint64, error)
+	ImportBacklog(ctx context.Context) (uint64, error)
+	ChainConfig(ctx context.Context) (*params.ChainConfig, error)
+	GenesisBlock(ctx context.Context) (*types.Block, error)
+	PendingBlock(ctx context.Context) (*types.Block, error)
+	SyncProgress(ctx context.Context) (*SyncProgress, error)
+	Mining(ctx context.Context) (bool, error)
+	HashRate(ctx context.Context) (uint64, error)
+	GetWork(ctx context.Context) ([4]string, error)
+	SubmitWork(ctx context.Context, nonce types.BlockNonce, powHash, digest common.Hash) (bool, error)
+	SubmitHashRate(ctx context.Context, rate uint64, id common.Hash) (bool, error)
+	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
+	GetReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	SnapshotManifest(ctx context.Context) ([]SnapshotFile, error)
+	BlobSidecars(ctx context.Context, blockHash common.Hash) ([]*BlobSidecar, error)
+	RebuildIndex(ctx context.Context, name string, from, to uint64) (*IndexRebuild, error)
+	IndexRebuilds(ctx context.Context) ([]*IndexRebuild, error)
+	EngineNewPayloadV1(ctx context.Context, payload *ExecutionPayload) (*PayloadStatus, error)
+	EngineForkchoiceUpdatedV1(ctx context.Context, state *ForkChoiceState, attrs *PayloadAttributes) (*ForkChoiceUpdatedResult, error)
+	EngineGetPayloadV1(ctx context.Context, payloadID hexutil.Bytes) (*ExecutionPayload, error)
 }
 
+// RemoteBackend - ApiBackend over gRPC. Safe for concurrent use: one instance is shared by the whole daemon.
+// Mutable state (cache of immutable values, active subscriptions, state change callbacks, counters) is guarded internally,
+// rest of fields are not changed after NewRemoteBackend. After Close all subscriptions are cancelled and new ones fail with ErrBackendClosed,
+// unary calls still work while the connection is open
 type RemoteBackend struct {
 	remoteEthBackend remote.ETHBACKENDClient
+	cc               grpc.ClientConnInterface
 	log              log.Logger
 	version          gointerfaces.Version
+	cache            immutableCache
+	logsBuffer       LogsBuffer
+	droppedLogs      uint64 // atomic
+	txPool           txpool.TxpoolClient
+	state            *stateWatcher // nil if connection state is unknown
+	subscriptions    subscriptions
+	strictProtocols  bool
+	totalSupply      bool
+	reconnect        *ReconnectPolicy
+	resync           resyncNotifier
+	sentries         []grpc.ClientConnInterface
 }
 
-func NewRemoteBackend(cc grpc.ClientConnInterface) *RemoteBackend {
-	return &RemoteBackend{
+type remoteBackendOpts struct {
+	retry           *RetryPolicy
+	log             log.Logger
+	logsBuffer      LogsBuffer
+	txPool          txpool.TxpoolClient
+	strictProtocols bool
+	totalSupply     bool
+	reconnect       *ReconnectPolicy
+	sentries        []grpc.ClientConnInterface
+}
+
+type RemoteBackendOption func(*remoteBackendOpts)
+
+// WithRetry - retry unary calls failed with codes.Unavailable or codes.Aborted. Subscriptions are not retried, see WithReconnect.
+func WithRetry(policy RetryPolicy) RemoteBackendOption {
+	return func(o *remoteBackendOpts) { o.retry = &policy }
+}
+
+// WithReconnect - re-establish broken Subscribe and SubscribeLogs streams instead of returning error, see OnResync
+func WithReconnect(policy ReconnectPolicy) RemoteBackendOption {
+	return func(o *remoteBackendOpts) { o.reconnect = &policy }
+}
+
+// WithLogger - logger to use instead of default one. Log lines of calls made on behalf of
+// some request are tagged by its ctxutil.RequestID, which is also passed to the server in gRPC metadata
+func WithLogger(logger log.Logger) RemoteBackendOption {
+	return func(o *remoteBackendOpts) { o.log = logger }
+}
+
+// WithLogsBuffer - buffering of SubscribeLogs replies between reading of the stream and the callback. Panics if buf
+// is not valid, see LogsBuffer.Validate
+func WithLogsBuffer(buf LogsBuffer) RemoteBackendOption {
+	if err := buf.Validate(); err != nil {
+		panic(err)
+	}
+	return func(o *remoteBackendOpts) { o.logsBuffer = buf }
+}
+
+// WithTxPool - txpool to subscribe to pending transactions, by default txpool is served by the same connection
+func WithTxPool(txPool txpool.TxpoolClient) RemoteBackendOption {
+	return func(o *remoteBackendOpts) { o.txPool = txPool }
+}
+
+// WithStrictProtocols - fail whole NodeInfo (SelfNodeInfo, ...) response if protocols metadata of some peer can't be decoded.
+// By default such metadata is skipped with warning
+func WithStrictProtocols() RemoteBackendOption {
+	return func(o *remoteBackendOpts) { o.strictProtocols = true }
+}
+
+// WithTotalSupply - enable TotalSupply. Disabled by default: node has to iterate over all accounts of the block's state to answer it
+func WithTotalSupply() RemoteBackendOption {
+	return func(o *remoteBackendOpts) { o.totalSupply = true }
+}
+
+// WithSentries - manage peers (Peers, AddPeer, RemovePeer) and get node info directly from sentries, instead of through the node.
+// Peers is available only with sentries
+func WithSentries(conns ...grpc.ClientConnInterface) RemoteBackendOption {
+	return func(o *remoteBackendOpts) { o.sentries = conns }
+}
+
+// NewRemoteBackend - connection state is watched only if `cc` is *grpc.ClientConn or *ConnPool (state of its first
+// connection, see OnStateChange), if so RemoteBackend must be closed
+func NewRemoteBackend(cc grpc.ClientConnInterface, opts ...RemoteBackendOption) *RemoteBackend {
+	o := remoteBackendOpts{log: log.New("remote_service", "eth_backend"), logsBuffer: DefaultLogsBuffer()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	conn, _ := cc.(*grpc.ClientConn)
+	if pool, ok := cc.(*ConnPool); ok {
+		conn = pool.conns[0]
+	}
+	if o.retry != nil && o.retry.MaxAttempts > 1 {
+		cc = &retryConn{ClientConnInterface: cc, policy: *o.retry}
+	}
+	cc = &taggingConn{ClientConnInterface: cc, log: o.log}
+	if o.txPool == nil {
+		o.txPool = txpool.NewTxpoolClient(cc)
+	}
+	back := &RemoteBackend{
 		remoteEthBackend: remote.NewETHBACKENDClient(cc),
+		cc:               cc,
 		version:          gointerfaces.VersionFromProto(privateapi.EthBackendAPIVersion),
-		log:              log.New("remote_service", "eth_backend"),
+		log:              o.log,
+		logsBuffer:       o.logsBuffer,
+		txPool:           o.txPool,
+		strictProtocols:  o.strictProtocols,
+		totalSupply:      o.totalSupply,
+		reconnect:        o.reconnect,
 	}
+	for _, sentry := range o.sentries {
+		back.sentries = append(back.sentries, &taggingConn{ClientConnInterface: sentry, log: o.log})
+	}
+	if conn != nil {
+		invalidate, gauge := cacheInvalidator(&back.cache), stateGauge(conn)
+		back.state = watchState(conn, func(state connectivity.State) {
+			invalidate(state)
+			gauge(state)
+		})
+	}
+	return back
+}
+
+// OnStateChange - `cb` is called on each connectivity state change of the connection.
+// Works only when RemoteBackend was created with *grpc.ClientConn or *ConnPool, otherwise `cb` is never called
+func (back *RemoteBackend) OnStateChange(cb func(connectivity.State)) {
+	if back.state == nil {
+		return
+	}
+	back.state.add(cb)
+}
+
+// Close - cancels active subscriptions and stops watching of connection state, connection itself is not closed: it's owned by caller
+func (back *RemoteBackend) Close() {
+	back.subscriptions.closeAll()
+	if back.state == nil {
+		return
+	}
+	back.state.stop()
 }
 
 func (back *RemoteBackend) EnsureVersionCompatibility() bool {
-	versionReply, err := back.remoteEthBackend.Version(context.Background(), &emptypb.Empty{}, grpc.WaitForReady(true))
+	reply, err := back.cache.get("Version", func() (interface{}, error) {
+		return back.remoteEthBackend.Version(context.Background(), &emptypb.Empty{}, grpc.WaitForReady(true))
+	})
 	if err != nil {
 
 		back.log.Error("getting Version", "error", err)
 		return false
 	}
+	versionReply := reply.(*types2.VersionReply)
 	if !gointerfaces.EnsureVersion(back.version, versionReply) {
 		back.log.Error("incompatible interface versions", "client", back.version.String(),
 			"server", fmt.Sprintf("%d.%d.%d", versionReply.Major, versionReply.Minor, versionReply.Patch))
@@ -64,6 +276,21 @@ func (back *RemoteBackend) EnsureVersionCompatibility() bool {
 	return true
 }
 
+// ServerVersion - interface version of ETHBACKEND served by the node. Not cached and doesn't wait for connection,
+// so fails while the node is unreachable
+func (back *RemoteBackend) ServerVersion(ctx context.Context) (gointerfaces.Version, error) {
+	reply, err := back.remoteEthBackend.Version(ctx, &emptypb.Empty{})
+	if err != nil {
+		return gointerfaces.Version{}, err
+	}
+	return gointerfaces.VersionFromProto(reply), nil
+}
+
+// CompatibleVersion - whether the daemon can work with ETHBACKEND of server version, see EnsureVersionCompatibility
+func (back *RemoteBackend) CompatibleVersion(server gointerfaces.Version) bool {
+	return gointerfaces.EnsureVersion(back.version, &types2.VersionReply{Major: server.Major, Minor: server.Minor, Patch: server.Patch})
+}
+
 func (back *RemoteBackend) Etherbase(ctx context.Context) (common.Address, error) {
 	res, err := back.remoteEthBackend.Etherbase(ctx, &remote.EtherbaseRequest{})
 	if err != nil {
@@ -77,15 +304,21 @@ func (back *RemoteBackend) Etherbase(ctx context.Context) (common.Address, error
 }
 
 func (back *RemoteBackend) NetVersion(ctx context.Context) (uint64, error) {
-	res, err := back.remoteEthBackend.NetVersion(ctx, &remote.NetVersionRequest{})
-	if err != nil {
-		if s, ok := status.FromError(err); ok {
-			return 0, errors.New(s.Message())
+	id, err := back.cache.get("NetVersion", func() (interface{}, error) {
+		res, err := back.remoteEthBackend.NetVersion(ctx, &remote.NetVersionRequest{})
+		if err != nil {
+			if s, ok := status.FromError(err); ok {
+				return nil, errors.New(s.Message())
+			}
+			return nil, err
 		}
+		return res.Id, nil
+	})
+	if err != nil {
 		return 0, err
 	}
 
-	return res.Id, nil
+	return id.(uint64), nil
 }
 
 func (back *RemoteBackend) NetPeerCount(ctx context.Context) (uint64, error) {
@@ -124,91 +357,374 @@ func (back *RemoteBackend) ClientVersion(ctx context.Context) (string, error) {
 	return res.NodeName, nil
 }
 
+// Subscribe - delivers events of all types
 func (back *RemoteBackend) Subscribe(ctx context.Context, onNewEvent func(*remote.SubscribeReply)) error {
-	subscription, err := back.remoteEthBackend.Subscribe(ctx, &remote.SubscribeRequest{}, grpc.WaitForReady(true))
+	return back.SubscribeTopics(ctx, nil, onNewEvent)
+}
+
+// SubscribeTopics - delivers only events of given types, empty `topics` means all types.
+// remote.SubscribeRequest holds only 1 type, which server filters events by, so each of several topics has its own
+// stream and `onNewEvent` is called by one of them at a time. Older servers may ignore requested type (sending headers
+// instead), so events are filtered here as well
+func (back *RemoteBackend) SubscribeTopics(ctx context.Context, topics []remote.Event, onNewEvent func(*remote.SubscribeReply)) error {
+	ctx, done, err := back.subscriptions.add(ctx)
 	if err != nil {
-		if s, ok := status.FromError(err); ok {
-			return errors.New(s.Message())
-		}
 		return err
 	}
-	for {
-		event, err := subscription.Recv()
-		if err == io.EOF {
-			log.Info("rpcdaemon: the subscription channel was closed")
-			break
-		}
+	defer done()
+	if len(topics) <= 1 {
+		return back.subscribeTopic(ctx, topics, onNewEvent)
+	}
+	var lock sync.Mutex
+	g, ctx := errgroup.WithContext(ctx)
+	for _, topic := range topics {
+		topic := []remote.Event{topic}
+		g.Go(func() error {
+			return back.subscribeTopic(ctx, topic, func(event *remote.SubscribeReply) {
+				lock.Lock()
+				defer lock.Unlock()
+				onNewEvent(event)
+			})
+		})
+	}
+	return g.Wait()
+}
+
+// subscribeTopic - stream of events of the only type of `topics`, or of server's default type if it's empty
+func (back *RemoteBackend) subscribeTopic(ctx context.Context, topics []remote.Event, onNewEvent func(*remote.SubscribeReply)) error {
+	req := &remote.SubscribeRequest{}
+	if len(topics) == 1 {
+		req.Type = topics[0]
+	}
+	return back.reconnecting(ctx, "Subscribe", func(onOpen func()) error {
+		subscription, err := back.remoteEthBackend.Subscribe(ctx, req, grpc.WaitForReady(true))
 		if err != nil {
+			if s, ok := status.FromError(err); ok {
+				return errors.New(s.Message())
+			}
 			return err
 		}
+		onOpen()
+		for {
+			event, err := subscription.Recv()
+			if err == io.EOF {
+				loggerFor(ctx, back.log).Info("rpcdaemon: the subscription channel was closed")
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if !hasTopic(topics, event.Type) {
+				continue
+			}
 
-		onNewEvent(event)
+			onNewEvent(event)
+		}
+		return nil
+	})
+}
+
+func hasTopic(topics []remote.Event, topic remote.Event) bool {
+	if len(topics) == 0 {
+		return true
 	}
-	return nil
+	for _, t := range topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
 }
 
 func (back *RemoteBackend) SubscribeLogs(ctx context.Context, onNewLogs func(reply *remote.SubscribeLogsReply), requestor *atomic.Value) error {
-	subscription, err := back.remoteEthBackend.SubscribeLogs(ctx, grpc.WaitForReady(true))
+	return back.subscribeLogs(ctx, onNewLogs, func(send func(*remote.LogsFilterRequest) error) error {
+		requestor.Store(send)
+		return nil
+	})
+}
+
+// subscribeLogs - `onStart` receives function to send (and later change) logs filter, before any logs are received.
+// Last sent filter is sent again after reconnect
+func (back *RemoteBackend) subscribeLogs(ctx context.Context, onNewLogs func(reply *remote.SubscribeLogsReply), onStart func(send func(*remote.LogsFilterRequest) error) error) error {
+	ctx, done, err := back.subscriptions.add(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	var lock sync.Mutex // guards current stream and last filter
+	var current remote.ETHBACKEND_SubscribeLogsClient
+	var filter *remote.LogsFilterRequest
+	send := func(req *remote.LogsFilterRequest) error {
+		lock.Lock()
+		defer lock.Unlock()
+		filter = req
+		return current.Send(req)
+	}
+
+	// callback runs in separate goroutine: slow consumer must not stop reading of the stream
+	buf := make(chan *remote.SubscribeLogsReply, back.logsBuffer.Size)
+	delivered := make(chan struct{})
+	go func() {
+		defer close(delivered)
+		for logs := range buf {
+			onNewLogs(logs)
+		}
+	}()
+	defer func() {
+		close(buf)
+		<-delivered
+	}()
+
+	started := false
+	return back.reconnecting(ctx, "SubscribeLogs", func(onOpen func()) error {
+		subscription, err := back.remoteEthBackend.SubscribeLogs(ctx, grpc.WaitForReady(true))
+		if err != nil {
+			if s, ok := status.FromError(err); ok {
+				return errors.New(s.Message())
+			}
+			return err
+		}
+		lock.Lock()
+		current = subscription
+		if started && filter != nil {
+			// under lock: `send` can't write to the same stream meanwhile
+			err = subscription.Send(filter)
+		}
+		lock.Unlock()
+		if err != nil {
+			return err
+		}
+		if !started {
+			started = true
+			if err = onStart(send); err != nil {
+				return err
+			}
+		}
+		onOpen()
+
+		for {
+			logs, err := subscription.Recv()
+			if errors.Is(err, io.EOF) {
+				loggerFor(ctx, back.log).Info("rpcdaemon: the logs subscription channel was closed")
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if !back.logsBuffer.DropOldest {
+				select {
+				case buf <- logs:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				continue
+			}
+			for pushed := false; !pushed; {
+				select {
+				case buf <- logs:
+					pushed = true
+				default:
+					select {
+					case <-buf:
+						atomic.AddUint64(&back.droppedLogs, 1)
+					default:
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// DroppedLogs - amount of SubscribeLogs replies dropped because of full buffer, see LogsBuffer.DropOldest
+func (back *RemoteBackend) DroppedLogs() uint64 {
+	return atomic.LoadUint64(&back.droppedLogs)
+}
+
+// SubscribePendingTxs - batches of transactions added to txpool, as they are delivered by txpool's OnAdd stream.
+// Transactions which can't be decoded are skipped
+func (back *RemoteBackend) SubscribePendingTxs(ctx context.Context, onNewTxs func([]types.Transaction)) error {
+	ctx, done, err := back.subscriptions.add(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	subscription, err := back.txPool.OnAdd(ctx, &txpool.OnAddRequest{}, grpc.WaitForReady(true))
 	if err != nil {
 		if s, ok := status.FromError(err); ok {
 			return errors.New(s.Message())
 		}
 		return err
 	}
-	requestor.Store(subscription.Send)
 	for {
-		logs, err := subscription.Recv()
+		event, err := subscription.Recv()
 		if errors.Is(err, io.EOF) {
-			log.Info("rpcdaemon: the logs subscription channel was closed")
+			loggerFor(ctx, back.log).Info("rpcdaemon: the pending txs subscription channel was closed")
 			break
 		}
 		if err != nil {
 			return err
 		}
-		onNewLogs(logs)
+		txs := make([]types.Transaction, 0, len(event.RplTxs))
+		for _, rlpTx := range event.RplTxs {
+			if len(rlpTx) == 0 {
+				continue
+			}
+			txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(rlpTx), uint64(len(rlpTx))))
+			if err != nil {
+				loggerFor(ctx, back.log).Warn("rpcdaemon: skipping unprocessable pending tx", "err", err, "data", fmt.Sprintf("%x", rlpTx))
+				continue
+			}
+			txs = append(txs, txn)
+		}
+		onNewTxs(txs)
 	}
 	return nil
 }
 
 func (back *RemoteBackend) NodeInfo(ctx context.Context, limit uint32) ([]p2p.NodeInfo, error) {
-	nodes, err := back.remoteEthBackend.NodeInfo(ctx, &remote.NodesInfoRequest{Limit: limit})
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	items, err := back.NodeInfoStream(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []p2p.NodeInfo
+	for item := range items {
+		if item.Err != nil {
+			return nil, item.Err
+		}
+		ret = append(ret, item.Info)
+	}
+
+	return ret, nil
+}
+
+// NodeInfoItem - element of NodeInfoStream: decoded peer info or error of decoding
+type NodeInfoItem struct {
+	Info p2p.NodeInfo
+	Err  error
+}
+
+// NodeInfoStream - yields peers one by one, as they are decoded. Channel is closed after last peer or first error.
+// To stop early - cancel `ctx`
+func (back *RemoteBackend) NodeInfoStream(ctx context.Context, limit uint32) (<-chan NodeInfoItem, error) {
+	nodes, err := back.nodesInfo(ctx, limit)
 	if err != nil {
 		return nil, fmt.Errorf("nodes info request error: %w", err)
 	}
 
-	if nodes == nil || len(nodes.NodesInfo) == 0 {
+	if len(nodes) == 0 {
 		return nil, errors.New("empty nodesInfo response")
 	}
 
-	ret := make([]p2p.NodeInfo, 0, len(nodes.NodesInfo))
-	for _, node := range nodes.NodesInfo {
-		var rawProtocols map[string]json.RawMessage
-		if err = json.Unmarshal(node.Protocols, &rawProtocols); err != nil {
-			return nil, fmt.Errorf("cannot decode protocols metadata: %w", err)
-		}
-
-		protocols := make(map[string]interface{}, len(rawProtocols))
-		for k, v := range rawProtocols {
-			protocols[k] = v
-		}
-
-		ret = append(ret, p2p.NodeInfo{
-			Enode:      node.Enode,
-			ID:         node.Id,
-			IP:         node.Enode,
-			ENR:        node.Enr,
-			ListenAddr: node.ListenerAddr,
-			Name:       node.Name,
-			Ports: struct {
-				Discovery int `json:"discovery"`
-				Listener  int `json:"listener"`
-			}{
-				Discovery: int(node.Ports.Discovery),
-				Listener:  int(node.Ports.Listener),
-			},
-			Protocols: protocols,
-		})
+	items := make(chan NodeInfoItem)
+	go func() {
+		defer close(items)
+		for _, node := range nodes {
+			nodeInfo, err := back.decodeNodeInfo(ctx, node)
+			select {
+			case items <- NodeInfoItem{Info: nodeInfo, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return items, nil
+}
+
+// nodesInfo - info of sentries of the node, or of sentries set by WithSentries. At most `limit` of them, 0 - all
+func (back *RemoteBackend) nodesInfo(ctx context.Context, limit uint32) ([]*types2.NodeInfoReply, error) {
+	if len(back.sentries) > 0 {
+		return back.sentriesNodeInfo(ctx, limit)
 	}
+	nodes, err := back.remoteEthBackend.NodeInfo(ctx, &remote.NodesInfoRequest{Limit: limit})
+	if err != nil || nodes == nil {
+		return nil, err
+	}
+	return nodes.NodesInfo, nil
+}
 
-	return ret, nil
+func (back *RemoteBackend) decodeNodeInfo(ctx context.Context, node *types2.NodeInfoReply) (p2p.NodeInfo, error) {
+	var rawProtocols map[string]json.RawMessage
+	var err error
+	if len(node.Protocols) == 0 {
+		err = errors.New("missing protocols metadata")
+	} else if err = json.Unmarshal(node.Protocols, &rawProtocols); err != nil {
+		err = fmt.Errorf("cannot decode protocols metadata: %w", err)
+	}
+	if err != nil {
+		if back.strictProtocols {
+			return p2p.NodeInfo{}, err
+		}
+		loggerFor(ctx, back.log).Warn("rpcdaemon: skipping protocols of node", "id", node.Id, "err", err)
+	}
+
+	protocols := make(map[string]interface{}, len(rawProtocols))
+	for k, v := range rawProtocols {
+		protocols[k] = v
+	}
+
+	info := p2p.NodeInfo{
+		Enode:      node.Enode,
+		ID:         node.Id,
+		ENR:        node.Enr,
+		ListenAddr: node.ListenerAddr,
+		Name:       node.Name,
+		Protocols:  protocols,
+	}
+	if node.Ports != nil {
+		info.Ports.Discovery, info.Ports.Listener = int(node.Ports.Discovery), int(node.Ports.Listener)
+	}
+	// ports not reported by sentry are the ones advertised in enode
+	ip, tcp, udp := enodeAddr(node.Enode)
+	info.IP = ip
+	if info.Ports.Listener == 0 {
+		info.Ports.Listener = tcp
+	}
+	if info.Ports.Discovery == 0 {
+		info.Ports.Discovery = udp
+	}
+	return info, nil
+}
+
+// enodeAddr - IP and ports of enode URL (zero values if it's malformed). Unlike enode.ParseV4 doesn't resolve host names
+// and doesn't validate node ID
+func enodeAddr(rawurl string) (ip string, tcp, udp int) {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.Scheme != "enode" {
+		return "", 0, 0
+	}
+	if addr := net.ParseIP(u.Hostname()); addr != nil {
+		ip = addr.String()
+	}
+	tcp, _ = strconv.Atoi(u.Port())
+	udp = tcp
+	if discport := u.Query().Get("discport"); discport != "" {
+		udp, _ = strconv.Atoi(discport)
+	}
+	return ip, tcp, udp
+}
+
+// MergeNodeInfos - info of the node running several sentries: identity of the first one, with protocols of all of them.
+// If several sentries run the same protocol, metadata of the first one is used
+func MergeNodeInfos(nodes []p2p.NodeInfo) (p2p.NodeInfo, error) {
+	if len(nodes) == 0 {
+		return p2p.NodeInfo{}, errors.New("empty nodesInfo response")
+	}
+	merged := nodes[0]
+	merged.Protocols = make(map[string]interface{}, len(nodes[0].Protocols))
+	for _, node := range nodes {
+		for name, protocol := range node.Protocols {
+			if _, ok := merged.Protocols[name]; !ok {
+				merged.Protocols[name] = protocol
+			}
+		}
+	}
+	return merged, nil
 }
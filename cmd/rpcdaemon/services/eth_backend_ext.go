@@ -0,0 +1,932 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"time"
+
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/gasprice"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// ethBackendMethodPrefix - full name prefix of ETHBACKEND service methods.
+// Some ETHBACKEND methods are not (yet) part of generated remote.ETHBACKENDClient - such methods are invoked by name,
+// their arguments and replies are JSON documents wrapped into wrapperspb.BytesValue
+const ethBackendMethodPrefix = "/remote.ETHBACKEND/"
+
+func (back *RemoteBackend) invoke(ctx context.Context, method string, args interface{}, reply interface{}) error {
+	return invokeByName(ctx, back.cc, ethBackendMethodPrefix, method, args, reply)
+}
+
+// invokeByName - invokes method `prefix+method` of any service following that convention (ETHBACKEND, Sentry)
+func invokeByName(ctx context.Context, cc grpc.ClientConnInterface, prefix string, method string, args interface{}, reply interface{}) error {
+	in := &wrapperspb.BytesValue{}
+	if args != nil {
+		var err error
+		if in.Value, err = json.Marshal(args); err != nil {
+			return fmt.Errorf("%s: cannot encode request: %w", method, err)
+		}
+	}
+	out := &wrapperspb.BytesValue{}
+	if err := cc.Invoke(ctx, prefix+method, in, out); err != nil {
+		if s, ok := status.FromError(err); ok {
+			return errors.New(s.Message())
+		}
+		return err
+	}
+	if reply == nil || len(out.Value) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(out.Value, reply); err != nil {
+		return fmt.Errorf("%s: cannot decode reply: %w", method, err)
+	}
+	return nil
+}
+
+// subscribe - server-streaming counterpart of invoke, `onEvent` receives JSON document of each event
+func (back *RemoteBackend) subscribe(ctx context.Context, method string, args interface{}, onEvent func(data []byte) error) error {
+	return back.subscribeByName(ctx, back.cc, ethBackendMethodPrefix, method, args, onEvent)
+}
+
+// subscribeByName - server-streaming counterpart of invokeByName
+func (back *RemoteBackend) subscribeByName(ctx context.Context, cc grpc.ClientConnInterface, prefix string, method string, args interface{}, onEvent func(data []byte) error) error {
+	ctx, done, err := back.subscriptions.add(ctx)
+	if err != nil {
+		return err
+	}
+	defer done()
+	in := &wrapperspb.BytesValue{}
+	if args != nil {
+		var err error
+		if in.Value, err = json.Marshal(args); err != nil {
+			return fmt.Errorf("%s: cannot encode request: %w", method, err)
+		}
+	}
+	desc := &grpc.StreamDesc{StreamName: method, ServerStreams: true}
+	stream, err := cc.NewStream(ctx, desc, prefix+method, grpc.WaitForReady(true))
+	if err != nil {
+		if s, ok := status.FromError(err); ok {
+			return errors.New(s.Message())
+		}
+		return err
+	}
+	if err = stream.SendMsg(in); err != nil {
+		return err
+	}
+	if err = stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		event := &wrapperspb.BytesValue{}
+		err := stream.RecvMsg(event)
+		if errors.Is(err, io.EOF) {
+			loggerFor(ctx, back.log).Info("rpcdaemon: the subscription channel was closed", "method", method)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = onEvent(event.Value); err != nil {
+			return fmt.Errorf("%s: cannot decode event: %w", method, err)
+		}
+	}
+}
+
+type listenPortsReply struct {
+	P2PTCP  int `json:"p2pTcp"`
+	P2PUDP  int `json:"p2pUdp"`
+	RPCHTTP int `json:"rpcHttp"`
+	RPCWS   int `json:"rpcWs"`
+}
+
+func (back *RemoteBackend) ListenPorts(ctx context.Context) (p2pTCP, p2pUDP, rpcHTTP, rpcWS int, err error) {
+	var res listenPortsReply
+	if err = back.invoke(ctx, "ListenPorts", nil, &res); err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return res.P2PTCP, res.P2PUDP, res.RPCHTTP, res.RPCWS, nil
+}
+
+// ReorgRecord - one chain reorganisation observed by the node
+type ReorgRecord struct {
+	OldHead     common.Hash `json:"oldHead"`
+	NewHead     common.Hash `json:"newHead"`
+	Depth       uint64      `json:"depth"`
+	BlockNumber uint64      `json:"blockNumber"` // number of the first replaced block
+}
+
+type limitRequest struct {
+	Limit uint32 `json:"limit"`
+}
+
+// ReorgHistory - returns up to `limit` most recent reorgs, newest first
+func (back *RemoteBackend) ReorgHistory(ctx context.Context, limit uint32) ([]ReorgRecord, error) {
+	var res []ReorgRecord
+	if err := back.invoke(ctx, "ReorgHistory", limitRequest{Limit: limit}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ExecCacheHitRatio - share of state reads served from cache during execution, in [0, 1]
+func (back *RemoteBackend) ExecCacheHitRatio(ctx context.Context) (float64, error) {
+	var res float64
+	if err := back.invoke(ctx, "ExecCacheHitRatio", nil, &res); err != nil {
+		return 0, err
+	}
+	return res, nil
+}
+
+// ExecutionTxRate - transactions per second executed by Execution stage, 0 when the stage is idle
+func (back *RemoteBackend) ExecutionTxRate(ctx context.Context) (txPerSec float64, err error) {
+	if err = back.invoke(ctx, "ExecutionTxRate", nil, &txPerSec); err != nil {
+		return 0, err
+	}
+	return txPerSec, nil
+}
+
+func (back *RemoteBackend) Listening(ctx context.Context) (bool, error) {
+	var res bool
+	if err := back.invoke(ctx, "Listening", nil, &res); err != nil {
+		return false, err
+	}
+	return res, nil
+}
+
+// SelfNodeInfo - info of the local node (its first sentry), not of the peers
+func (back *RemoteBackend) SelfNodeInfo(ctx context.Context) (p2p.NodeInfo, error) {
+	if len(back.sentries) > 0 {
+		return back.sentryNodeInfo(ctx)
+	}
+	var res *types2.NodeInfoReply
+	if err := back.invoke(ctx, "SelfNodeInfo", nil, &res); err != nil {
+		return p2p.NodeInfo{}, fmt.Errorf("self node info request error: %w", err)
+	}
+	if res == nil {
+		return p2p.NodeInfo{}, errors.New("empty nodeInfo response")
+	}
+	return back.decodeNodeInfo(ctx, res)
+}
+
+// StateSyncPeers - peers currently serving state to the node during snap sync, empty when node isn't snap-syncing
+func (back *RemoteBackend) StateSyncPeers(ctx context.Context) ([]p2p.NodeInfo, error) {
+	var res []*types2.NodeInfoReply
+	if err := back.invoke(ctx, "StateSyncPeers", nil, &res); err != nil {
+		return nil, fmt.Errorf("state sync peers request error: %w", err)
+	}
+	peers := make([]p2p.NodeInfo, 0, len(res))
+	for _, node := range res {
+		if node == nil {
+			continue
+		}
+		peer, err := back.decodeNodeInfo(ctx, node)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, peer)
+	}
+	return peers, nil
+}
+
+// DiscoveryNetworkName - network name the node advertises in ENR and uses for discovery
+func (back *RemoteBackend) DiscoveryNetworkName(ctx context.Context) (string, error) {
+	var res string
+	if err := back.invoke(ctx, "DiscoveryNetworkName", nil, &res); err != nil {
+		return "", err
+	}
+	return res, nil
+}
+
+// DialBackoffPeers - peers the node failed to dial recently, by enode URL: time of next dial attempt
+func (back *RemoteBackend) DialBackoffPeers(ctx context.Context) (map[string]time.Time, error) {
+	var res map[string]time.Time
+	if err := back.invoke(ctx, "DialBackoffPeers", nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type peerRequest struct {
+	URL string `json:"url"`
+}
+
+// AddPeer - adds static peer, returns false if it was already added. With WithSentries - adds it to every sentry,
+// without them - Erigon adds it to its own sentries
+func (back *RemoteBackend) AddPeer(ctx context.Context, url string) (bool, error) {
+	return back.changePeers(ctx, "AddPeer", url)
+}
+
+// RemovePeer - removes static peer, returns false if there was no such peer. With WithSentries - removes it from every
+// sentry, without them - Erigon removes it from its own sentries
+func (back *RemoteBackend) RemovePeer(ctx context.Context, url string) (bool, error) {
+	return back.changePeers(ctx, "RemovePeer", url)
+}
+
+func (back *RemoteBackend) changePeers(ctx context.Context, method string, url string) (bool, error) {
+	if _, err := enode.ParseV4(url); err != nil {
+		return false, fmt.Errorf("invalid enode: %w", err)
+	}
+	if len(back.sentries) > 0 {
+		return back.changeSentryPeers(ctx, method, url)
+	}
+	var changed bool
+	if err := back.invoke(ctx, method, peerRequest{URL: url}, &changed); err != nil {
+		return false, err
+	}
+	return changed, nil
+}
+
+type unwindEvent struct {
+	Stage     string `json:"stage"`
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+}
+
+// SubscribeUnwinds - notifies about each stage unwound by staged sync (for example on reorg), from `fromBlock` down to `toBlock`
+func (back *RemoteBackend) SubscribeUnwinds(ctx context.Context, onUnwind func(stage string, fromBlock, toBlock uint64)) error {
+	return back.subscribe(ctx, "SubscribeUnwinds", nil, func(data []byte) error {
+		var event unwindEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		onUnwind(event.Stage, event.FromBlock, event.ToBlock)
+		return nil
+	})
+}
+
+type finalizedEvent struct {
+	BlockNumber uint64      `json:"blockNumber"`
+	BlockHash   common.Hash `json:"blockHash"`
+}
+
+// SubscribeFinalized - notifies about each block which became finalized, in order of finalization
+func (back *RemoteBackend) SubscribeFinalized(ctx context.Context, onFinalized func(blockNum uint64, hash common.Hash)) error {
+	return back.subscribe(ctx, "SubscribeFinalized", nil, func(data []byte) error {
+		var event finalizedEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		onFinalized(event.BlockNumber, event.BlockHash)
+		return nil
+	})
+}
+
+// GasPrice - suggested price per gas in wei (tip plus base fee of the current head)
+func (back *RemoteBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	var res hexutil.Big
+	if err := back.invoke(ctx, "GasPrice", nil, &res); err != nil {
+		return nil, err
+	}
+	return res.ToInt(), nil
+}
+
+// ErrGasPriceUncapped - returned by GasPriceCap when node has no limit on suggested gas price
+var ErrGasPriceUncapped = errors.New("gas price is not capped")
+
+// GasPriceCap - max gas price the node will suggest, null reply means "no cap"
+func (back *RemoteBackend) GasPriceCap(ctx context.Context) (*big.Int, error) {
+	var res *hexutil.Big
+	if err := back.invoke(ctx, "GasPriceCap", nil, &res); err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, ErrGasPriceUncapped
+	}
+	return res.ToInt(), nil
+}
+
+// MinGasPrice - configured floor of gas price, transactions with lower price are not accepted by the node
+func (back *RemoteBackend) MinGasPrice(ctx context.Context) (*big.Int, error) {
+	var res hexutil.Big
+	if err := back.invoke(ctx, "MinGasPrice", nil, &res); err != nil {
+		return nil, err
+	}
+	return res.ToInt(), nil
+}
+
+// FeeHistoryResult - reply of eth_feeHistory, see gasprice.Oracle.FeeHistory for meaning of fields
+type FeeHistoryResult struct {
+	OldestBlock      *hexutil.Big     `json:"oldestBlock"`
+	Reward           [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee          []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio     []float64        `json:"gasUsedRatio"`
+	BlobBaseFee      []*hexutil.Big   `json:"baseFeePerBlobGas,omitempty"`
+	BlobGasUsedRatio []float64        `json:"blobGasUsedRatio,omitempty"`
+}
+
+type feeHistoryRequest struct {
+	BlockCount        uint64    `json:"blockCount"`
+	LastBlock         int64     `json:"lastBlock"`
+	RewardPercentiles []float64 `json:"rewardPercentiles,omitempty"`
+}
+
+func (back *RemoteBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*FeeHistoryResult, error) {
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("%w: %f", gasprice.ErrInvalidPercentile, p)
+		}
+		if i > 0 && p < rewardPercentiles[i-1] {
+			return nil, fmt.Errorf("%w: #%d:%f > #%d:%f", gasprice.ErrInvalidPercentile, i-1, rewardPercentiles[i-1], i, p)
+		}
+	}
+	req := feeHistoryRequest{BlockCount: blockCount, LastBlock: lastBlock.Int64(), RewardPercentiles: rewardPercentiles}
+	var res FeeHistoryResult
+	if err := back.invoke(ctx, "FeeHistory", req, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+type cacheStatsReply struct {
+	Entries  uint64 `json:"entries"`
+	Capacity uint64 `json:"capacity"`
+}
+
+func (back *RemoteBackend) HeaderCacheStats(ctx context.Context) (entries, capacity uint64, err error) {
+	var res cacheStatsReply
+	if err = back.invoke(ctx, "HeaderCacheStats", nil, &res); err != nil {
+		return 0, 0, err
+	}
+	return res.Entries, res.Capacity, nil
+}
+
+// ReceiptCacheStats - amount of receipts the node keeps in memory and max amount it can keep
+func (back *RemoteBackend) ReceiptCacheStats(ctx context.Context) (entries, capacity uint64, err error) {
+	var res cacheStatsReply
+	if err = back.invoke(ctx, "ReceiptCacheStats", nil, &res); err != nil {
+		return 0, 0, err
+	}
+	return res.Entries, res.Capacity, nil
+}
+
+type txpoolTransitionsRequest struct {
+	Window uint64 `json:"window"`
+}
+
+type txpoolTransitionsReply struct {
+	Promotions uint64 `json:"promotions"`
+	Demotions  uint64 `json:"demotions"`
+}
+
+// TxpoolTransitions - amount of txpool transactions moved from queued to pending (promotions) and back (demotions)
+// during last `window` blocks
+func (back *RemoteBackend) TxpoolTransitions(ctx context.Context, window uint64) (promotions, demotions uint64, err error) {
+	var res txpoolTransitionsReply
+	if err = back.invoke(ctx, "TxpoolTransitions", txpoolTransitionsRequest{Window: window}, &res); err != nil {
+		return 0, 0, err
+	}
+	return res.Promotions, res.Demotions, nil
+}
+
+// MaxReceiptSize - limit on size (in bytes) of receipts the node serves in one reply
+func (back *RemoteBackend) MaxReceiptSize(ctx context.Context) (uint64, error) {
+	var res uint64
+	if err := back.invoke(ctx, "MaxReceiptSize", nil, &res); err != nil {
+		return 0, err
+	}
+	return res, nil
+}
+
+// RPCBatchLimit - max amount of requests in one JSON-RPC batch the node accepts
+func (back *RemoteBackend) RPCBatchLimit(ctx context.Context) (uint64, error) {
+	var res uint64
+	if err := back.invoke(ctx, "RPCBatchLimit", nil, &res); err != nil {
+		return 0, err
+	}
+	return res, nil
+}
+
+// ChainConfig - config of the chain the node runs, cached: it can't change without restart of the node
+func (back *RemoteBackend) ChainConfig(ctx context.Context) (*params.ChainConfig, error) {
+	cfg, err := back.cache.get("ChainConfig", func() (interface{}, error) {
+		var res *params.ChainConfig
+		if err := back.invoke(ctx, "ChainConfig", nil, &res); err != nil {
+			return nil, err
+		}
+		if res == nil {
+			return nil, errors.New("empty chain config response")
+		}
+		return res, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cfg.(*params.ChainConfig), nil
+}
+
+// GenesisBlock - genesis block of the chain, transferred RLP-encoded. Cached same way as ChainConfig
+func (back *RemoteBackend) GenesisBlock(ctx context.Context) (*types.Block, error) {
+	block, err := back.cache.get("GenesisBlock", func() (interface{}, error) {
+		var res hexutil.Bytes
+		if err := back.invoke(ctx, "GenesisBlock", nil, &res); err != nil {
+			return nil, err
+		}
+		if len(res) == 0 {
+			return nil, errors.New("empty genesis block response")
+		}
+		genesis := new(types.Block)
+		if err := rlp.DecodeBytes(res, genesis); err != nil {
+			return nil, fmt.Errorf("cannot decode genesis block: %w", err)
+		}
+		return genesis, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return block.(*types.Block), nil
+}
+
+// PendingBlock - the latest block built by miner of the node, transferred RLP-encoded. Returns nil if node isn't mining
+// or hasn't built any block yet. Not cached: it's replaced on every new transaction and head
+func (back *RemoteBackend) PendingBlock(ctx context.Context) (*types.Block, error) {
+	var res *hexutil.Bytes // null if there is no pending block
+	if err := back.invoke(ctx, "PendingBlock", nil, &res); err != nil {
+		return nil, err
+	}
+	if res == nil || len(*res) == 0 {
+		return nil, nil
+	}
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(*res, block); err != nil {
+		return nil, fmt.Errorf("cannot decode pending block: %w", err)
+	}
+	return block, nil
+}
+
+// Mining - node is mining, with ethash consensus engine. Mining methods are proxied to mining server of the node, so
+// external miners can work with rpcdaemon connected only to its private api
+func (back *RemoteBackend) Mining(ctx context.Context) (enabled bool, err error) {
+	err = back.invoke(ctx, "Mining", nil, &enabled)
+	return enabled, err
+}
+
+// HashRate - hashes per second of the node's miner and of external miners which submitted their hash rate
+func (back *RemoteBackend) HashRate(ctx context.Context) (rate uint64, err error) {
+	err = back.invoke(ctx, "HashRate", nil, &rate)
+	return rate, err
+}
+
+// GetWork - work package for external miner: header pow-hash, seed hash, boundary condition and block number
+func (back *RemoteBackend) GetWork(ctx context.Context) (work [4]string, err error) {
+	err = back.invoke(ctx, "GetWork", nil, &work)
+	return work, err
+}
+
+type submitWorkRequest struct {
+	Nonce   types.BlockNonce `json:"nonce"`
+	PowHash common.Hash      `json:"powHash"`
+	Digest  common.Hash      `json:"digest"`
+}
+
+// SubmitWork - PoW solution of external miner, false if it's invalid or the work is stale or unknown
+func (back *RemoteBackend) SubmitWork(ctx context.Context, nonce types.BlockNonce, powHash, digest common.Hash) (ok bool, err error) {
+	err = back.invoke(ctx, "SubmitWork", submitWorkRequest{Nonce: nonce, PowHash: powHash, Digest: digest}, &ok)
+	return ok, err
+}
+
+type submitHashRateRequest struct {
+	Rate uint64      `json:"rate"`
+	ID   common.Hash `json:"id"`
+}
+
+// SubmitHashRate - hash rate of external miner identified by id, included in HashRate
+func (back *RemoteBackend) SubmitHashRate(ctx context.Context, rate uint64, id common.Hash) (ok bool, err error) {
+	err = back.invoke(ctx, "SubmitHashRate", submitHashRateRequest{Rate: rate, ID: id}, &ok)
+	return ok, err
+}
+
+type hashRequest struct {
+	Hash common.Hash `json:"hash"`
+}
+
+// GetReceipts - receipts of the block, with all derived fields (log indices, cumulative gas, ...)
+// as node computed them. Returns nil for unknown block
+func (back *RemoteBackend) GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error) {
+	var res types.Receipts
+	if err := back.invoke(ctx, "GetReceipts", hashRequest{Hash: blockHash}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// BlobSidecar - blob of EIP-4844 transaction of the block with its KZG commitment and proof
+type BlobSidecar struct {
+	BlockHash     common.Hash   `json:"blockHash"`
+	Index         uint64        `json:"index"`
+	Blob          hexutil.Bytes `json:"blob"`
+	KZGCommitment hexutil.Bytes `json:"kzgCommitment"`
+	KZGProof      hexutil.Bytes `json:"kzgProof"`
+}
+
+type blobSidecarsRequest struct {
+	BlockHash common.Hash `json:"blockHash"`
+}
+
+// BlobSidecars - sidecars of the block which node received from consensus layer, nil if node doesn't have them: block
+// has no blobs, is too old or no consensus layer feeds them
+func (back *RemoteBackend) BlobSidecars(ctx context.Context, blockHash common.Hash) ([]*BlobSidecar, error) {
+	var res []*BlobSidecar
+	if err := back.invoke(ctx, "BlobSidecars", blobSidecarsRequest{BlockHash: blockHash}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// IndexRebuild - progress of rebuild of index of the node, Finished is set once it's done or failed with Error
+type IndexRebuild struct {
+	Name         string     `json:"name"`
+	FromBlock    uint64     `json:"fromBlock"`
+	ToBlock      uint64     `json:"toBlock"`
+	CurrentBlock *uint64    `json:"currentBlock"` // the last indexed one, nil until the first batch of blocks is indexed
+	Started      time.Time  `json:"started"`
+	Finished     *time.Time `json:"finished"`
+	Error        string     `json:"error,omitempty"`
+}
+
+type rebuildIndexRequest struct {
+	Name      string `json:"name"`
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+}
+
+// RebuildIndex - starts rebuild of index `name` of blocks [from, to] in background, returns its range adjusted by the
+// node. Fails if the index is being rebuilt already
+func (back *RemoteBackend) RebuildIndex(ctx context.Context, name string, from, to uint64) (*IndexRebuild, error) {
+	var res *IndexRebuild
+	if err := back.invoke(ctx, "RebuildIndex", rebuildIndexRequest{Name: name, FromBlock: from, ToBlock: to}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// IndexRebuilds - the latest rebuild of each index since start of the node
+func (back *RemoteBackend) IndexRebuilds(ctx context.Context) ([]*IndexRebuild, error) {
+	var res []*IndexRebuild
+	if err := back.invoke(ctx, "IndexRebuilds", nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetReceipt - receipt of the transaction, nil for unknown transaction
+func (back *RemoteBackend) GetReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	var res *types.Receipt
+	if err := back.invoke(ctx, "GetReceipt", hashRequest{Hash: txHash}, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SnapshotFile - one segment file of snapshots, blocks [From, To)
+type SnapshotFile struct {
+	Name     string        `json:"name"`
+	From     uint64        `json:"from"`
+	To       uint64        `json:"to"`
+	Checksum hexutil.Bytes `json:"checksum"`
+}
+
+// SnapshotManifest - snapshot files the node has, with their checksums
+func (back *RemoteBackend) SnapshotManifest(ctx context.Context) ([]SnapshotFile, error) {
+	var res []SnapshotFile
+	if err := back.invoke(ctx, "SnapshotManifest", nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// MaxConcurrentTraces - max amount of traces the node runs concurrently
+func (back *RemoteBackend) MaxConcurrentTraces(ctx context.Context) (uint64, error) {
+	var res uint64
+	if err := back.invoke(ctx, "MaxConcurrentTraces", nil, &res); err != nil {
+		return 0, err
+	}
+	return res, nil
+}
+
+type stageProgressRequest struct {
+	Stages []stages.SyncStage `json:"stages"`
+}
+
+// ImportBacklog - amount of blocks with downloaded headers which are not executed yet, 0 when node caught up
+func (back *RemoteBackend) ImportBacklog(ctx context.Context) (uint64, error) {
+	var progress map[stages.SyncStage]uint64
+	if err := back.invoke(ctx, "StageProgress", stageProgressRequest{Stages: []stages.SyncStage{stages.Headers, stages.Execution}}, &progress); err != nil {
+		return 0, err
+	}
+	headers, executed := progress[stages.Headers], progress[stages.Execution]
+	if executed >= headers {
+		return 0, nil
+	}
+	return headers - executed, nil
+}
+
+// AllowedRPCOrigins - CORS origins the node accepts JSON-RPC requests from, "*" allows any origin
+func (back *RemoteBackend) AllowedRPCOrigins(ctx context.Context) ([]string, error) {
+	var res []string
+	if err := back.invoke(ctx, "AllowedRPCOrigins", nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type mempoolOccupancyEvent struct {
+	Occupancy float64 `json:"occupancy"` // fraction of txpool capacity in use, 0..1
+}
+
+// SubscribeMempoolPressure - notifies with the threshold each time txpool occupancy crosses it, up or down.
+// Occupancy is considered below all thresholds before the first event.
+func (back *RemoteBackend) SubscribeMempoolPressure(ctx context.Context, thresholds []float64, onCross func(level float64)) error {
+	sorted := make([]float64, len(thresholds))
+	copy(sorted, thresholds)
+	sort.Float64s(sorted)
+	var reached int // amount of thresholds at or below current occupancy
+	return back.subscribe(ctx, "SubscribeMempoolOccupancy", nil, func(data []byte) error {
+		var event mempoolOccupancyEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		now := sort.Search(len(sorted), func(i int) bool { return sorted[i] > event.Occupancy })
+		for ; reached < now; reached++ {
+			onCross(sorted[reached])
+		}
+		for ; reached > now; reached-- {
+			onCross(sorted[reached-1])
+		}
+		return nil
+	})
+}
+
+// DBGrowthAlarmThreshold - configured database growth rate in bytes per day above which the node raises an alarm
+func (back *RemoteBackend) DBGrowthAlarmThreshold(ctx context.Context) (bytesPerDay uint64, err error) {
+	if err = back.invoke(ctx, "DBGrowthAlarmThreshold", nil, &bytesPerDay); err != nil {
+		return 0, err
+	}
+	return bytesPerDay, nil
+}
+
+// EngineJWTWindow - clock skew allowed between `iat` of engine-API JWT and the node's clock
+func (back *RemoteBackend) EngineJWTWindow(ctx context.Context) (time.Duration, error) {
+	var res time.Duration
+	if err := back.invoke(ctx, "EngineJWTWindow", nil, &res); err != nil {
+		return 0, err
+	}
+	return res, nil
+}
+
+type stateRootMismatchEvent struct {
+	BlockNumber uint64      `json:"blockNumber"`
+	Expected    common.Hash `json:"expected"`
+	Got         common.Hash `json:"got"`
+}
+
+// SubscribeStateRootMismatches - notifies about each executed block whose computed state root differs from the one in its header
+func (back *RemoteBackend) SubscribeStateRootMismatches(ctx context.Context, onMismatch func(blockNum uint64, expected, got common.Hash)) error {
+	return back.subscribe(ctx, "SubscribeStateRootMismatches", nil, func(data []byte) error {
+		var event stateRootMismatchEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		onMismatch(event.BlockNumber, event.Expected, event.Got)
+		return nil
+	})
+}
+
+// HeaderBatchSize - max amount of headers the node requests from a peer in one batch during sync
+func (back *RemoteBackend) HeaderBatchSize(ctx context.Context) (uint64, error) {
+	var res uint64
+	if err := back.invoke(ctx, "HeaderBatchSize", nil, &res); err != nil {
+		return 0, err
+	}
+	return res, nil
+}
+
+type peerBanEvent struct {
+	Enode  string    `json:"enode"`
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
+}
+
+// SubscribePeerBans - notifies about each peer banned by the node, with the reason and time when the ban expires
+func (back *RemoteBackend) SubscribePeerBans(ctx context.Context, onBan func(enode string, reason string, until time.Time)) error {
+	return back.subscribe(ctx, "SubscribePeerBans", nil, func(data []byte) error {
+		var event peerBanEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		onBan(event.Enode, event.Reason, event.Until)
+		return nil
+	})
+}
+
+// MaxTraceDuration - time after which the node aborts a trace request
+func (back *RemoteBackend) MaxTraceDuration(ctx context.Context) (time.Duration, error) {
+	var res time.Duration
+	if err := back.invoke(ctx, "MaxTraceDuration", nil, &res); err != nil {
+		return 0, err
+	}
+	return res, nil
+}
+
+type missedProposalsRequest struct {
+	Window uint64 `json:"window"` // amount of latest slots
+}
+
+// MissedProposals - amount of block production slots the node's validator missed among latest `window` slots
+func (back *RemoteBackend) MissedProposals(ctx context.Context, window uint64) (uint64, error) {
+	var res uint64
+	if err := back.invoke(ctx, "MissedProposals", missedProposalsRequest{Window: window}, &res); err != nil {
+		return 0, err
+	}
+	return res, nil
+}
+
+type configReloadEvent struct {
+	Time    time.Time `json:"time"`
+	Changed []string  `json:"changed"`
+}
+
+// SubscribeConfigReloads - notifies each time the node reloads its config, with the keys whose values changed
+func (back *RemoteBackend) SubscribeConfigReloads(ctx context.Context, onReload func(ts time.Time, changed []string)) error {
+	return back.subscribe(ctx, "SubscribeConfigReloads", nil, func(data []byte) error {
+		var event configReloadEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		onReload(event.Time, event.Changed)
+		return nil
+	})
+}
+
+// MaxAcceptedReorgDepth - max amount of blocks the node unwinds on reorg, deeper reorgs are rejected
+func (back *RemoteBackend) MaxAcceptedReorgDepth(ctx context.Context) (uint64, error) {
+	var res uint64
+	if err := back.invoke(ctx, "MaxAcceptedReorgDepth", nil, &res); err != nil {
+		return 0, err
+	}
+	return res, nil
+}
+
+// GoroutineBreakdown - amount of running goroutines of the node per subsystem (p2p, txpool, sync, rpc, ...)
+func (back *RemoteBackend) GoroutineBreakdown(ctx context.Context) (map[string]uint64, error) {
+	var res map[string]uint64
+	if err := back.invoke(ctx, "GoroutineBreakdown", nil, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+type diskWarningEvent struct {
+	FreeBytes uint64 `json:"freeBytes"`
+	Threshold uint64 `json:"threshold"`
+}
+
+// SubscribeDiskWarnings - notifies each time free space of the datadir drops below configured threshold
+func (back *RemoteBackend) SubscribeDiskWarnings(ctx context.Context, onWarning func(freeBytes uint64, threshold uint64)) error {
+	return back.subscribe(ctx, "SubscribeDiskWarnings", nil, func(data []byte) error {
+		var event diskWarningEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		onWarning(event.FreeBytes, event.Threshold)
+		return nil
+	})
+}
+
+// TxPropagationBatchSize - max amount of transactions the node announces to a peer in one message
+func (back *RemoteBackend) TxPropagationBatchSize(ctx context.Context) (uint64, error) {
+	var res uint64
+	if err := back.invoke(ctx, "TxPropagationBatchSize", nil, &res); err != nil {
+		return 0, err
+	}
+	return res, nil
+}
+
+// ErrTotalSupplyDisabled - returned by TotalSupply unless RemoteBackend is created WithTotalSupply
+var ErrTotalSupplyDisabled = errors.New("total supply queries are disabled")
+
+type blockNumRequest struct {
+	BlockNum uint64 `json:"blockNum"`
+}
+
+// TotalSupply - sum of balances of all accounts at block `blockNum`.
+// Expensive: node iterates over the whole state of the block, so it's available only WithTotalSupply
+func (back *RemoteBackend) TotalSupply(ctx context.Context, blockNum uint64) (*big.Int, error) {
+	if !back.totalSupply {
+		return nil, ErrTotalSupplyDisabled
+	}
+	var res hexutil.Big
+	if err := back.invoke(ctx, "TotalSupply", blockNumRequest{BlockNum: blockNum}, &res); err != nil {
+		return nil, err
+	}
+	return res.ToInt(), nil
+}
+
+type executionStateEvent struct {
+	Paused bool   `json:"paused"`
+	Reason string `json:"reason"`
+}
+
+// SubscribeExecutionState - notifies each time the node pauses execution (for example waiting for consensus client) or resumes it
+func (back *RemoteBackend) SubscribeExecutionState(ctx context.Context, onChange func(paused bool, reason string)) error {
+	return back.subscribe(ctx, "SubscribeExecutionState", nil, func(data []byte) error {
+		var event executionStateEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return err
+		}
+		onChange(event.Paused, event.Reason)
+		return nil
+	})
+}
+
+// StageProgress - progress of one stage of staged sync
+type StageProgress struct {
+	Name          string `json:"name"`
+	BlockNumber   uint64 `json:"blockNumber"`
+	PruneProgress uint64 `json:"pruneProgress"`
+}
+
+// SyncProgress - progress of every stage, in order they run. CurrentBlock is progress of the last stage, HighestBlock is
+// the highest block known to the node: downloaded header or announced by peers
+type SyncProgress struct {
+	CurrentBlock uint64          `json:"currentBlock"`
+	HighestBlock uint64          `json:"highestBlock"`
+	Stages       []StageProgress `json:"stages"`
+}
+
+// Syncing - same condition as of eth_syncing
+func (p *SyncProgress) Syncing() bool {
+	return p.CurrentBlock == 0 || p.CurrentBlock < p.HighestBlock
+}
+
+// SyncStatusEvent - data of privateapi.EventSyncStatus events, sent when Erigon starts or stops syncing and on each
+// change of progress while it's syncing
+type SyncStatusEvent struct {
+	Syncing bool `json:"syncing"`
+	SyncProgress
+}
+
+// CurrentStage - the first stage behind HighestBlock: stages run in order, so it's the one running now or the next one
+// to run. Stages which never ran while later ones did are disabled and skipped. Empty if all stages reached HighestBlock
+func (p *SyncProgress) CurrentStage() string {
+	ranLater := make([]bool, len(p.Stages))
+	for i := len(p.Stages) - 2; i >= 0; i-- {
+		ranLater[i] = ranLater[i+1] || p.Stages[i+1].BlockNumber > 0
+	}
+	for i, stage := range p.Stages {
+		if stage.BlockNumber >= p.HighestBlock || (stage.BlockNumber == 0 && ranLater[i]) {
+			continue
+		}
+		return stage.Name
+	}
+	return ""
+}
+
+// ReadSyncProgress - SyncProgress as stored in db, HighestBlock is progress of Headers stage
+func ReadSyncProgress(tx kv.Getter) (*SyncProgress, error) {
+	p := &SyncProgress{Stages: make([]StageProgress, 0, len(stages.AllStages))}
+	for _, stage := range stages.AllStages {
+		progress, err := stages.GetStageProgress(tx, stage)
+		if err != nil {
+			return nil, err
+		}
+		pruned, err := stages.GetStagePruneProgress(tx, stage)
+		if err != nil {
+			return nil, err
+		}
+		p.Stages = append(p.Stages, StageProgress{Name: string(stage), BlockNumber: progress, PruneProgress: pruned})
+		switch stage {
+		case stages.Headers:
+			p.HighestBlock = progress
+		case stages.Finish:
+			p.CurrentBlock = progress
+		}
+	}
+	return p, nil
+}
+
+// SyncProgress - progress of staged sync of the node, including the highest block announced by peers
+func (back *RemoteBackend) SyncProgress(ctx context.Context) (*SyncProgress, error) {
+	var res SyncProgress
+	if err := back.invoke(ctx, "SyncProgress", nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,1260 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/gasprice"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// mockEthBackend - serves generated ETHBACKEND methods through embedded server
+// and methods invoked by name through `replies` and `streams`
+type mockEthBackend struct {
+	remote.UnimplementedETHBACKENDServer
+	replies       map[string]func(args json.RawMessage) (interface{}, error)
+	streams       map[string]func(args json.RawMessage, send func(event interface{}) error) error
+	subscribe     func(*remote.SubscribeRequest, remote.ETHBACKEND_SubscribeServer) error
+	subscribeLogs func(remote.ETHBACKEND_SubscribeLogsServer) error
+	netPeerCount  func(ctx context.Context) (*remote.NetPeerCountReply, error)
+	netVersion    func() (*remote.NetVersionReply, error)
+	nodeInfo      *remote.NodesInfoReply
+	txPool        txpool.TxpoolServer // served by the same connection, if set
+}
+
+type mockTxPool struct {
+	txpool.UnimplementedTxpoolServer
+	onAdd func(*txpool.OnAddRequest, txpool.Txpool_OnAddServer) error
+}
+
+func (s *mockTxPool) OnAdd(r *txpool.OnAddRequest, server txpool.Txpool_OnAddServer) error {
+	return s.onAdd(r, server)
+}
+
+func (s *mockEthBackend) SubscribeLogs(server remote.ETHBACKEND_SubscribeLogsServer) error {
+	if s.subscribeLogs == nil {
+		return s.UnimplementedETHBACKENDServer.SubscribeLogs(server)
+	}
+	return s.subscribeLogs(server)
+}
+
+func (s *mockEthBackend) NetVersion(ctx context.Context, r *remote.NetVersionRequest) (*remote.NetVersionReply, error) {
+	if s.netVersion == nil {
+		return s.UnimplementedETHBACKENDServer.NetVersion(ctx, r)
+	}
+	return s.netVersion()
+}
+
+func (s *mockEthBackend) NodeInfo(ctx context.Context, r *remote.NodesInfoRequest) (*remote.NodesInfoReply, error) {
+	if s.nodeInfo == nil {
+		return s.UnimplementedETHBACKENDServer.NodeInfo(ctx, r)
+	}
+	return s.nodeInfo, nil
+}
+
+func (s *mockEthBackend) NetPeerCount(ctx context.Context, r *remote.NetPeerCountRequest) (*remote.NetPeerCountReply, error) {
+	if s.netPeerCount == nil {
+		return s.UnimplementedETHBACKENDServer.NetPeerCount(ctx, r)
+	}
+	return s.netPeerCount(ctx)
+}
+
+func (s *mockEthBackend) Subscribe(r *remote.SubscribeRequest, server remote.ETHBACKEND_SubscribeServer) error {
+	if s.subscribe == nil {
+		return s.UnimplementedETHBACKENDServer.Subscribe(r, server)
+	}
+	return s.subscribe(r, server)
+}
+
+func (s *mockEthBackend) handle(_ interface{}, stream grpc.ServerStream) error {
+	return serveByName(stream, ethBackendMethodPrefix, s.replies, s.streams)
+}
+
+// serveByName - serves methods invoked by name, see invokeByName
+func serveByName(stream grpc.ServerStream, prefix string, replies map[string]func(args json.RawMessage) (interface{}, error),
+	streams map[string]func(args json.RawMessage, send func(event interface{}) error) error) error {
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+	method := strings.TrimPrefix(fullMethod, prefix)
+	reply, isUnary := replies[method]
+	events, isStream := streams[method]
+	if !isUnary && !isStream {
+		return status.Errorf(codes.Unimplemented, "method %s not implemented", fullMethod)
+	}
+	in := &wrapperspb.BytesValue{}
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	send := func(v interface{}) (err error) {
+		out := &wrapperspb.BytesValue{}
+		if out.Value, err = json.Marshal(v); err != nil {
+			return err
+		}
+		return stream.SendMsg(out)
+	}
+	if isStream {
+		return events(in.Value, send)
+	}
+	res, err := reply(in.Value)
+	if err != nil {
+		return err
+	}
+	return send(res)
+}
+
+// replyWith - handler of method invoked by name, which always returns `v`
+func replyWith(v interface{}) func(json.RawMessage) (interface{}, error) {
+	return func(json.RawMessage) (interface{}, error) { return v, nil }
+}
+
+func newTestRemoteBackend(t *testing.T, srv *mockEthBackend, opts ...RemoteBackendOption) *RemoteBackend {
+	back := NewRemoteBackend(newTestConn(t, srv), opts...)
+	t.Cleanup(back.Close)
+	return back
+}
+
+// newTestConn - connection to `srv` served in memory
+func newTestConn(t *testing.T, srv *mockEthBackend, opts ...grpc.DialOption) *grpc.ClientConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := grpc.NewServer(grpc.UnknownServiceHandler(srv.handle))
+	remote.RegisterETHBACKENDServer(server, srv)
+	if srv.txPool != nil {
+		txpool.RegisterTxpoolServer(server, srv.txPool)
+	}
+	listener := bufconn.Listen(1024 * 1024)
+	go func() { _ = server.Serve(listener) }()
+
+	conn, err := grpc.DialContext(ctx, "", append([]grpc.DialOption{grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	})}, opts...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		cancel()
+		conn.Close()
+		server.Stop()
+	})
+	return conn
+}
+
+func TestListenPorts(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"ListenPorts": replyWith(listenPortsReply{P2PTCP: 30303, P2PUDP: 30304, RPCHTTP: 8545, RPCWS: 8546}),
+	}})
+
+	p2pTCP, p2pUDP, rpcHTTP, rpcWS, err := back.ListenPorts(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 30303, p2pTCP)
+	require.Equal(t, 30304, p2pUDP)
+	require.Equal(t, 8545, rpcHTTP)
+	require.Equal(t, 8546, rpcWS)
+}
+
+func TestSubscribeTopics(t *testing.T) {
+	events := []remote.Event{remote.Event_PENDING_BLOCK, remote.Event_HEADER, remote.Event_PENDING_LOGS, remote.Event_PENDING_BLOCK, remote.Event_HEADER}
+	back := newTestRemoteBackend(t, &mockEthBackend{subscribe: func(_ *remote.SubscribeRequest, server remote.ETHBACKEND_SubscribeServer) error {
+		for i, e := range events {
+			if err := server.Send(&remote.SubscribeReply{Type: e, Data: []byte{byte(i)}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}})
+
+	var headers []byte
+	err := back.SubscribeTopics(context.Background(), []remote.Event{remote.Event_HEADER}, func(reply *remote.SubscribeReply) {
+		require.Equal(t, remote.Event_HEADER, reply.Type)
+		headers = append(headers, reply.Data...)
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 4}, headers)
+
+	var all int
+	require.NoError(t, back.Subscribe(context.Background(), func(*remote.SubscribeReply) { all++ }))
+	require.Equal(t, len(events), all)
+}
+
+func TestSubscribeTopicsFilteredByServer(t *testing.T) {
+	var lock sync.Mutex
+	var requested []remote.Event
+	back := newTestRemoteBackend(t, &mockEthBackend{subscribe: func(r *remote.SubscribeRequest, server remote.ETHBACKEND_SubscribeServer) error {
+		lock.Lock()
+		requested = append(requested, r.Type)
+		lock.Unlock()
+		for i := 0; i < 3; i++ {
+			if err := server.Send(&remote.SubscribeReply{Type: r.Type, Data: []byte{byte(r.Type)}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}})
+
+	received := map[remote.Event]int{}
+	err := back.SubscribeTopics(context.Background(), []remote.Event{remote.Event_PENDING_LOGS, privateapi.EventBlock}, func(reply *remote.SubscribeReply) {
+		received[reply.Type]++ // callback is not called concurrently
+		require.Equal(t, []byte{byte(reply.Type)}, reply.Data)
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[remote.Event]int{remote.Event_PENDING_LOGS: 3, privateapi.EventBlock: 3}, received)
+	require.ElementsMatch(t, []remote.Event{remote.Event_PENDING_LOGS, privateapi.EventBlock}, requested, "each topic is requested by its own stream")
+}
+
+func TestReorgHistory(t *testing.T) {
+	history := []ReorgRecord{
+		{OldHead: common.HexToHash("0x03"), NewHead: common.HexToHash("0x13"), Depth: 1, BlockNumber: 300},
+		{OldHead: common.HexToHash("0x02"), NewHead: common.HexToHash("0x12"), Depth: 3, BlockNumber: 200},
+		{OldHead: common.HexToHash("0x01"), NewHead: common.HexToHash("0x11"), Depth: 2, BlockNumber: 100},
+	}
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"ReorgHistory": func(args json.RawMessage) (interface{}, error) {
+			var req limitRequest
+			if err := json.Unmarshal(args, &req); err != nil {
+				return nil, err
+			}
+			return history[:req.Limit], nil
+		},
+	}})
+
+	res, err := back.ReorgHistory(context.Background(), 2)
+	require.NoError(t, err)
+	require.Equal(t, history[:2], res)
+	require.Greater(t, res[0].BlockNumber, res[1].BlockNumber)
+}
+
+func TestExecCacheHitRatio(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"ExecCacheHitRatio": replyWith(0.875),
+	}})
+
+	ratio, err := back.ExecCacheHitRatio(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0.875, ratio)
+}
+
+func TestExecutionTxRate(t *testing.T) {
+	t.Run("executing", func(t *testing.T) {
+		back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+			"ExecutionTxRate": replyWith(1234.5),
+		}})
+		rate, err := back.ExecutionTxRate(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1234.5, rate)
+	})
+	t.Run("idle", func(t *testing.T) {
+		back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+			"ExecutionTxRate": replyWith(0),
+		}})
+		rate, err := back.ExecutionTxRate(context.Background())
+		require.NoError(t, err)
+		require.Zero(t, rate)
+	})
+}
+
+func TestSelfNodeInfo(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"Listening": replyWith(true),
+		"SelfNodeInfo": replyWith(&types2.NodeInfoReply{
+			Id:           "abc",
+			Name:         "erigon",
+			Enode:        "enode://abc@127.0.0.1:30303",
+			Ports:        &types2.NodeInfoPorts{Discovery: 30304, Listener: 30303},
+			ListenerAddr: "[::]:30303",
+			Protocols:    []byte(`{"eth":{"network":1}}`),
+		}),
+	}})
+
+	listening, err := back.Listening(context.Background())
+	require.NoError(t, err)
+	require.True(t, listening)
+
+	node, err := back.SelfNodeInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "abc", node.ID)
+	require.Equal(t, "[::]:30303", node.ListenAddr)
+	require.Equal(t, "127.0.0.1", node.IP)
+	require.Equal(t, 30304, node.Ports.Discovery)
+	require.Equal(t, 30303, node.Ports.Listener)
+	require.Equal(t, json.RawMessage(`{"network":1}`), node.Protocols["eth"])
+}
+
+func TestNodeInfoAddress(t *testing.T) {
+	for _, tt := range []struct {
+		enode     string
+		ports     *types2.NodeInfoPorts
+		ip        string
+		tcp, disc int
+	}{
+		{"enode://abc@10.0.0.1:30303", nil, "10.0.0.1", 30303, 30303},
+		{"enode://abc@10.0.0.1:30303?discport=30301", &types2.NodeInfoPorts{}, "10.0.0.1", 30303, 30301},
+		{"enode://abc@[::1]:30303", &types2.NodeInfoPorts{Listener: 30305}, "::1", 30305, 30303},
+		{"enode://abc@example.com:30303", nil, "", 30303, 30303},
+		{"not a url", nil, "", 0, 0},
+	} {
+		back := newTestRemoteBackend(t, &mockEthBackend{nodeInfo: &remote.NodesInfoReply{NodesInfo: []*types2.NodeInfoReply{
+			{Id: "abc", Enode: tt.enode, Ports: tt.ports, Protocols: []byte(`{}`)},
+		}}})
+		nodes, err := back.NodeInfo(context.Background(), 0)
+		require.NoError(t, err)
+		require.Equal(t, tt.ip, nodes[0].IP, tt.enode)
+		require.Equal(t, tt.tcp, nodes[0].Ports.Listener, tt.enode)
+		require.Equal(t, tt.disc, nodes[0].Ports.Discovery, tt.enode)
+	}
+}
+
+func TestMergeNodeInfos(t *testing.T) {
+	_, err := MergeNodeInfos(nil)
+	require.Error(t, err)
+
+	first := p2p.NodeInfo{ID: "aa", Name: "erigon", Protocols: map[string]interface{}{"eth": json.RawMessage(`{"version":66}`)}}
+	second := p2p.NodeInfo{ID: "bb", Protocols: map[string]interface{}{"eth": json.RawMessage(`{"version":65}`), "snap": json.RawMessage(`{}`)}}
+	merged, err := MergeNodeInfos([]p2p.NodeInfo{first, second})
+	require.NoError(t, err)
+	require.Equal(t, "aa", merged.ID)
+	require.Equal(t, map[string]interface{}{"eth": json.RawMessage(`{"version":66}`), "snap": json.RawMessage(`{}`)}, merged.Protocols)
+	require.Len(t, first.Protocols, 1, "merged info doesn't share protocols with the first one")
+}
+
+func TestSelfNodeInfoEmpty(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"SelfNodeInfo": replyWith(nil),
+	}})
+
+	_, err := back.SelfNodeInfo(context.Background())
+	require.Error(t, err)
+}
+
+func TestStateSyncPeers(t *testing.T) {
+	t.Run("snap-syncing", func(t *testing.T) {
+		back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+			"StateSyncPeers": replyWith([]*types2.NodeInfoReply{
+				{Id: "aa", Name: "geth", Enode: "enode://aa@10.0.0.1:30303", Ports: &types2.NodeInfoPorts{}, Protocols: []byte(`{"snap":{}}`)},
+				{Id: "bb", Name: "erigon", Enode: "enode://bb@10.0.0.2:30303", Ports: &types2.NodeInfoPorts{}, Protocols: []byte(`{"snap":{}}`)},
+			}),
+		}})
+		peers, err := back.StateSyncPeers(context.Background())
+		require.NoError(t, err)
+		require.Len(t, peers, 2)
+		require.Equal(t, "aa", peers[0].ID)
+		require.Equal(t, "erigon", peers[1].Name)
+		require.Equal(t, json.RawMessage(`{}`), peers[1].Protocols["snap"])
+	})
+	t.Run("not syncing", func(t *testing.T) {
+		back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+			"StateSyncPeers": replyWith(nil),
+		}})
+		peers, err := back.StateSyncPeers(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, peers)
+		require.Empty(t, peers)
+	})
+}
+
+func TestDiscoveryNetworkName(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"DiscoveryNetworkName": replyWith("goerli"),
+	}})
+
+	name, err := back.DiscoveryNetworkName(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "goerli", name)
+}
+
+func TestDialBackoffPeers(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"DialBackoffPeers": replyWith(json.RawMessage(`{
+			"enode://aa@10.0.0.1:30303": "2021-11-20T10:00:30Z",
+			"enode://bb@10.0.0.2:30303": "2021-11-20T10:01:00Z"
+		}`)),
+	}})
+
+	peers, err := back.DialBackoffPeers(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]time.Time{
+		"enode://aa@10.0.0.1:30303": time.Date(2021, 11, 20, 10, 0, 30, 0, time.UTC),
+		"enode://bb@10.0.0.2:30303": time.Date(2021, 11, 20, 10, 1, 0, 0, time.UTC),
+	}, peers)
+}
+
+func TestAddRemovePeer(t *testing.T) {
+	const url = "enode://a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c@52.16.188.185:30303"
+	peers := map[string]bool{}
+	changePeers := func(add bool) func(json.RawMessage) (interface{}, error) {
+		return func(args json.RawMessage) (interface{}, error) {
+			var req peerRequest
+			if err := json.Unmarshal(args, &req); err != nil {
+				return nil, err
+			}
+			changed := peers[req.URL] != add
+			peers[req.URL] = add
+			return changed, nil
+		}
+	}
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"AddPeer":    changePeers(true),
+		"RemovePeer": changePeers(false),
+	}})
+	ctx := context.Background()
+
+	for _, step := range []struct {
+		call    func(context.Context, string) (bool, error)
+		changed bool
+	}{{back.AddPeer, true}, {back.AddPeer, false}, {back.RemovePeer, true}, {back.RemovePeer, false}} {
+		changed, err := step.call(ctx, url)
+		require.NoError(t, err)
+		require.Equal(t, step.changed, changed)
+	}
+
+	_, err := back.AddPeer(ctx, "enode://not-a-node")
+	require.ErrorContains(t, err, "invalid enode")
+	_, err = back.RemovePeer(ctx, "127.0.0.1:30303")
+	require.ErrorContains(t, err, "invalid enode")
+	require.Len(t, peers, 1)
+}
+
+func TestSubscribeUnwinds(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{streams: map[string]func(json.RawMessage, func(interface{}) error) error{
+		"SubscribeUnwinds": func(_ json.RawMessage, send func(interface{}) error) error {
+			return send(unwindEvent{Stage: "Execution", FromBlock: 110, ToBlock: 100})
+		},
+	}})
+
+	var got []unwindEvent
+	err := back.SubscribeUnwinds(context.Background(), func(stage string, fromBlock, toBlock uint64) {
+		got = append(got, unwindEvent{Stage: stage, FromBlock: fromBlock, ToBlock: toBlock})
+	})
+	require.NoError(t, err)
+	require.Equal(t, []unwindEvent{{Stage: "Execution", FromBlock: 110, ToBlock: 100}}, got)
+}
+
+func TestSubscribeFinalized(t *testing.T) {
+	events := []finalizedEvent{
+		{BlockNumber: 100, BlockHash: common.HexToHash("0x64")},
+		{BlockNumber: 101, BlockHash: common.HexToHash("0x65")},
+	}
+	back := newTestRemoteBackend(t, &mockEthBackend{streams: map[string]func(json.RawMessage, func(interface{}) error) error{
+		"SubscribeFinalized": func(_ json.RawMessage, send func(interface{}) error) error {
+			for _, event := range events {
+				if err := send(event); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+
+	var got []finalizedEvent
+	err := back.SubscribeFinalized(context.Background(), func(blockNum uint64, hash common.Hash) {
+		got = append(got, finalizedEvent{BlockNumber: blockNum, BlockHash: hash})
+	})
+	require.NoError(t, err)
+	require.Equal(t, events, got)
+}
+
+func TestGasPrice(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"GasPrice": replyWith((*hexutil.Big)(big.NewInt(1_500_000_000))),
+	}})
+
+	price, err := back.GasPrice(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1_500_000_000), price)
+}
+
+func TestGasPriceCap(t *testing.T) {
+	t.Run("capped", func(t *testing.T) {
+		back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+			"GasPriceCap": replyWith((*hexutil.Big)(big.NewInt(500_000_000_000))),
+		}})
+		priceCap, err := back.GasPriceCap(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(500_000_000_000), priceCap)
+	})
+	t.Run("uncapped", func(t *testing.T) {
+		back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+			"GasPriceCap": replyWith((*hexutil.Big)(nil)),
+		}})
+		priceCap, err := back.GasPriceCap(context.Background())
+		require.ErrorIs(t, err, ErrGasPriceUncapped)
+		require.Nil(t, priceCap)
+	})
+}
+
+func TestMinGasPrice(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"MinGasPrice": replyWith((*hexutil.Big)(big.NewInt(1_000_000_000))),
+	}})
+
+	price, err := back.MinGasPrice(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1_000_000_000), price)
+}
+
+func TestFeeHistory(t *testing.T) {
+	var requests int
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"FeeHistory": func(args json.RawMessage) (interface{}, error) {
+			requests++
+			var req feeHistoryRequest
+			if err := json.Unmarshal(args, &req); err != nil {
+				return nil, err
+			}
+			if req.BlockCount != 2 || req.LastBlock != int64(rpc.LatestBlockNumber) || len(req.RewardPercentiles) != 2 {
+				return nil, status.Error(codes.InvalidArgument, "unexpected request")
+			}
+			return FeeHistoryResult{
+				OldestBlock:      (*hexutil.Big)(big.NewInt(99)),
+				Reward:           [][]*hexutil.Big{{(*hexutil.Big)(big.NewInt(1)), (*hexutil.Big)(big.NewInt(2))}, {(*hexutil.Big)(big.NewInt(3)), (*hexutil.Big)(big.NewInt(4))}},
+				BaseFee:          []*hexutil.Big{(*hexutil.Big)(big.NewInt(10)), (*hexutil.Big)(big.NewInt(11)), (*hexutil.Big)(big.NewInt(12))},
+				GasUsedRatio:     []float64{0.5, 0.25},
+				BlobBaseFee:      []*hexutil.Big{(*hexutil.Big)(big.NewInt(1)), (*hexutil.Big)(big.NewInt(1)), (*hexutil.Big)(big.NewInt(2))},
+				BlobGasUsedRatio: []float64{0, 1},
+			}, nil
+		},
+	}})
+
+	res, err := back.FeeHistory(context.Background(), 2, rpc.LatestBlockNumber, []float64{25, 75})
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(99), res.OldestBlock.ToInt())
+	require.Equal(t, big.NewInt(4), res.Reward[1][1].ToInt())
+	require.Len(t, res.BaseFee, 3)
+	require.Equal(t, []float64{0.5, 0.25}, res.GasUsedRatio)
+	require.Equal(t, big.NewInt(2), res.BlobBaseFee[2].ToInt())
+	require.Equal(t, []float64{0, 1}, res.BlobGasUsedRatio)
+
+	for _, percentiles := range [][]float64{{75, 25}, {-1}, {50, 101}} {
+		_, err = back.FeeHistory(context.Background(), 2, rpc.LatestBlockNumber, percentiles)
+		require.ErrorIs(t, err, gasprice.ErrInvalidPercentile)
+	}
+	require.Equal(t, 1, requests)
+}
+
+func TestHeaderCacheStats(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"HeaderCacheStats": replyWith(cacheStatsReply{Entries: 1500, Capacity: 4096}),
+	}})
+
+	entries, capacity, err := back.HeaderCacheStats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1500), entries)
+	require.Equal(t, uint64(4096), capacity)
+}
+
+func TestReceiptCacheStats(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"ReceiptCacheStats": replyWith(cacheStatsReply{Entries: 320, Capacity: 1024}),
+	}})
+
+	entries, capacity, err := back.ReceiptCacheStats(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(320), entries)
+	require.Equal(t, uint64(1024), capacity)
+}
+
+func TestTxpoolTransitions(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"TxpoolTransitions": func(args json.RawMessage) (interface{}, error) {
+			var req txpoolTransitionsRequest
+			if err := json.Unmarshal(args, &req); err != nil {
+				return nil, err
+			}
+			if req.Window != 64 {
+				return nil, status.Error(codes.InvalidArgument, "unexpected window")
+			}
+			return json.RawMessage(`{"promotions":120,"demotions":7}`), nil
+		},
+	}})
+
+	promotions, demotions, err := back.TxpoolTransitions(context.Background(), 64)
+	require.NoError(t, err)
+	require.Equal(t, uint64(120), promotions)
+	require.Equal(t, uint64(7), demotions)
+}
+
+func TestMaxReceiptSize(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"MaxReceiptSize": replyWith(uint64(2 * 1024 * 1024)),
+	}})
+
+	size, err := back.MaxReceiptSize(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2*1024*1024), size)
+}
+
+func TestRPCBatchLimit(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"RPCBatchLimit": replyWith(uint64(100)),
+	}})
+
+	limit, err := back.RPCBatchLimit(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), limit)
+}
+
+func TestChainConfigAndGenesisAreCached(t *testing.T) {
+	genesis := types.NewBlock(&types.Header{Number: big.NewInt(0), GasLimit: 5000, Difficulty: big.NewInt(1), Extra: []byte("genesis")}, nil, nil, nil)
+	genesisRlp, err := rlp.EncodeToBytes(genesis)
+	require.NoError(t, err)
+
+	calls := map[string]int{}
+	back := newTestRemoteBackend(t, &mockEthBackend{
+		replies: map[string]func(json.RawMessage) (interface{}, error){
+			"ChainConfig": func(json.RawMessage) (interface{}, error) {
+				calls["ChainConfig"]++
+				return params.GoerliChainConfig, nil
+			},
+			"GenesisBlock": func(json.RawMessage) (interface{}, error) {
+				calls["GenesisBlock"]++
+				return hexutil.Bytes(genesisRlp), nil
+			},
+		},
+		netVersion: func() (*remote.NetVersionReply, error) {
+			calls["NetVersion"]++
+			return &remote.NetVersionReply{Id: 5}, nil
+		},
+	})
+
+	for i := 0; i < 3; i++ {
+		cfg, err := back.ChainConfig(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, params.GoerliChainConfig.ChainID, cfg.ChainID)
+		require.Equal(t, params.GoerliChainConfig.LondonBlock, cfg.LondonBlock)
+
+		block, err := back.GenesisBlock(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, genesis.Hash(), block.Hash())
+
+		id, err := back.NetVersion(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), id)
+	}
+	require.Equal(t, map[string]int{"ChainConfig": 1, "GenesisBlock": 1, "NetVersion": 1}, calls)
+}
+
+func TestPendingBlock(t *testing.T) {
+	pending := types.NewBlock(&types.Header{Number: big.NewInt(7), GasLimit: 5000, Difficulty: big.NewInt(1)}, nil, nil, nil)
+	pendingRlp, err := rlp.EncodeToBytes(pending)
+	require.NoError(t, err)
+	var reply interface{}
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"PendingBlock": func(json.RawMessage) (interface{}, error) { return reply, nil },
+	}})
+
+	block, err := back.PendingBlock(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, block, "node isn't mining")
+
+	reply = hexutil.Bytes(pendingRlp)
+	block, err = back.PendingBlock(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, pending.Hash(), block.Hash())
+}
+
+func TestMiningProxy(t *testing.T) {
+	var submitted submitWorkRequest
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"Mining":   replyWith(true),
+		"HashRate": replyWith(uint64(300)),
+		"GetWork":  replyWith([4]string{"0x01", "0x02", "0x03", "0x4"}),
+		"SubmitWork": func(args json.RawMessage) (interface{}, error) {
+			return true, json.Unmarshal(args, &submitted)
+		},
+		"SubmitHashRate": func(json.RawMessage) (interface{}, error) {
+			return nil, status.Error(codes.Unavailable, "mining is not available")
+		},
+	}})
+	ctx := context.Background()
+
+	mining, err := back.Mining(ctx)
+	require.NoError(t, err)
+	require.True(t, mining)
+	rate, err := back.HashRate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(300), rate)
+	work, err := back.GetWork(ctx)
+	require.NoError(t, err)
+	require.Equal(t, [4]string{"0x01", "0x02", "0x03", "0x4"}, work)
+
+	nonce := types.EncodeNonce(42)
+	ok, err := back.SubmitWork(ctx, nonce, common.Hash{1}, common.Hash{2})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, submitWorkRequest{Nonce: nonce, PowHash: common.Hash{1}, Digest: common.Hash{2}}, submitted)
+
+	_, err = back.SubmitHashRate(ctx, 100, common.Hash{3})
+	require.EqualError(t, err, "mining is not available")
+}
+
+// logsStream - sends first reply, waits till callback started to process it, then sends the rest
+func logsStream(total int, started <-chan struct{}) func(remote.ETHBACKEND_SubscribeLogsServer) error {
+	return func(server remote.ETHBACKEND_SubscribeLogsServer) error {
+		for i := 0; i < total; i++ {
+			if err := server.Send(&remote.SubscribeLogsReply{BlockNumber: uint64(i)}); err != nil {
+				return err
+			}
+			if i == 0 {
+				<-started
+			}
+		}
+		return nil
+	}
+}
+
+func TestSubscribeLogsSlowCallback(t *testing.T) {
+	// callback is stuck on the 1st reply, but stream must be drained anyway
+	t.Run("drop oldest", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		back := newTestRemoteBackend(t, &mockEthBackend{subscribeLogs: logsStream(10, started)},
+			WithLogsBuffer(LogsBuffer{Size: 1, DropOldest: true}))
+
+		var received []uint64
+		done := make(chan error, 1)
+		go func() {
+			done <- back.SubscribeLogs(context.Background(), func(reply *remote.SubscribeLogsReply) {
+				if len(received) == 0 {
+					close(started)
+					<-release
+				}
+				received = append(received, reply.BlockNumber)
+			}, &atomic.Value{})
+		}()
+
+		// 1st reply is in the callback, 10th in the buffer, all between are dropped
+		require.Eventually(t, func() bool { return back.DroppedLogs() == 8 }, 5*time.Second, 10*time.Millisecond)
+		close(release)
+		require.NoError(t, <-done)
+		require.Equal(t, []uint64{0, 9}, received)
+	})
+	t.Run("block", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		var sent uint64
+		back := newTestRemoteBackend(t, &mockEthBackend{subscribeLogs: func(server remote.ETHBACKEND_SubscribeLogsServer) error {
+			defer atomic.StoreUint64(&sent, 1)
+			return logsStream(10, started)(server)
+		}}, WithLogsBuffer(LogsBuffer{Size: 16}))
+
+		var received []uint64
+		done := make(chan error, 1)
+		go func() {
+			done <- back.SubscribeLogs(context.Background(), func(reply *remote.SubscribeLogsReply) {
+				if len(received) == 0 {
+					close(started)
+					<-release
+				}
+				received = append(received, reply.BlockNumber)
+			}, &atomic.Value{})
+		}()
+
+		require.Eventually(t, func() bool { return atomic.LoadUint64(&sent) == 1 }, 5*time.Second, 10*time.Millisecond)
+		close(release)
+		require.NoError(t, <-done)
+		require.Equal(t, []uint64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, received)
+		require.Zero(t, back.DroppedLogs())
+	})
+}
+
+func TestLogsBufferValidate(t *testing.T) {
+	require.NoError(t, DefaultLogsBuffer().Validate())
+	require.NoError(t, LogsBuffer{Size: 0}.Validate())
+	require.NoError(t, LogsBuffer{Size: 1, DropOldest: true}.Validate())
+	require.Error(t, LogsBuffer{Size: -1}.Validate())
+	// unbuffered channel has nothing to drop: reading of the stream would spin while callback is busy
+	require.Error(t, LogsBuffer{Size: 0, DropOldest: true}.Validate())
+	require.Panics(t, func() { WithLogsBuffer(LogsBuffer{Size: -1}) })
+}
+
+func TestSubscribePendingTxs(t *testing.T) {
+	tx1 := types.NewTransaction(1, common.HexToAddress("0x1"), uint256.NewInt(10), 21000, uint256.NewInt(1), nil)
+	tx2 := types.NewTransaction(2, common.HexToAddress("0x2"), uint256.NewInt(20), 21000, uint256.NewInt(1), []byte{0x01})
+	encode := func(txn types.Transaction) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, txn.MarshalBinary(&buf))
+		return buf.Bytes()
+	}
+
+	back := newTestRemoteBackend(t, &mockEthBackend{txPool: &mockTxPool{onAdd: func(_ *txpool.OnAddRequest, server txpool.Txpool_OnAddServer) error {
+		if err := server.Send(&txpool.OnAddReply{RplTxs: [][]byte{encode(tx1), {0xc1, 0xff}, nil, encode(tx2)}}); err != nil {
+			return err
+		}
+		return server.Send(&txpool.OnAddReply{RplTxs: [][]byte{encode(tx2)}})
+	}}})
+
+	var batches [][]common.Hash
+	err := back.SubscribePendingTxs(context.Background(), func(txs []types.Transaction) {
+		var hashes []common.Hash
+		for _, txn := range txs {
+			hashes = append(hashes, txn.Hash())
+		}
+		batches = append(batches, hashes)
+	})
+	require.NoError(t, err)
+	// broken and empty entries are skipped, rest of the batch is delivered
+	require.Equal(t, [][]common.Hash{{tx1.Hash(), tx2.Hash()}, {tx2.Hash()}}, batches)
+}
+
+func TestGetReceipts(t *testing.T) {
+	blockHash, blockNum := common.HexToHash("0xb1"), big.NewInt(7)
+	var receipts types.Receipts
+	var cumulativeGas uint64
+	var logIndex uint
+	for txIndex := uint(0); txIndex < 3; txIndex++ {
+		txHash := common.BigToHash(big.NewInt(int64(txIndex + 1)))
+		// empty (not nil) PostState: JSON doesn't distinguish them
+		receipt := &types.Receipt{Type: types.DynamicFeeTxType, PostState: []byte{}, Status: types.ReceiptStatusSuccessful, GasUsed: 21000 + uint64(txIndex),
+			TxHash: txHash, BlockHash: blockHash, BlockNumber: blockNum, TransactionIndex: txIndex}
+		cumulativeGas += receipt.GasUsed
+		receipt.CumulativeGasUsed = cumulativeGas
+		for i := uint(0); i <= txIndex; i++ {
+			receipt.Logs = append(receipt.Logs, &types.Log{Address: common.HexToAddress("0xaa"), Topics: []common.Hash{{1}}, Data: []byte{byte(i)},
+				BlockNumber: blockNum.Uint64(), TxHash: txHash, TxIndex: txIndex, BlockHash: blockHash, Index: logIndex})
+			logIndex++
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts = append(receipts, receipt)
+	}
+
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"GetReceipts": func(args json.RawMessage) (interface{}, error) {
+			var req hashRequest
+			if err := json.Unmarshal(args, &req); err != nil {
+				return nil, err
+			}
+			if req.Hash != blockHash {
+				return nil, nil
+			}
+			return receipts, nil
+		},
+		"GetReceipt": func(args json.RawMessage) (interface{}, error) {
+			var req hashRequest
+			if err := json.Unmarshal(args, &req); err != nil {
+				return nil, err
+			}
+			for _, receipt := range receipts {
+				if receipt.TxHash == req.Hash {
+					return receipt, nil
+				}
+			}
+			return nil, nil
+		},
+	}})
+
+	got, err := back.GetReceipts(context.Background(), blockHash)
+	require.NoError(t, err)
+	require.Equal(t, receipts, got)
+	var indices []uint
+	for _, receipt := range got {
+		for _, l := range receipt.Logs {
+			indices = append(indices, l.Index)
+		}
+	}
+	require.Equal(t, []uint{0, 1, 2, 3, 4, 5}, indices)
+	require.Equal(t, uint64(3*21000+3), got[2].CumulativeGasUsed)
+
+	receipt, err := back.GetReceipt(context.Background(), receipts[1].TxHash)
+	require.NoError(t, err)
+	require.Equal(t, receipts[1], receipt)
+
+	got, err = back.GetReceipts(context.Background(), common.HexToHash("0xdead"))
+	require.NoError(t, err)
+	require.Nil(t, got)
+	receipt, err = back.GetReceipt(context.Background(), common.HexToHash("0xdead"))
+	require.NoError(t, err)
+	require.Nil(t, receipt)
+}
+
+func TestSnapshotManifest(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"SnapshotManifest": replyWith(json.RawMessage(`[
+			{"name":"v1-000000-000500-headers.seg","from":0,"to":500000,"checksum":"0x0102"},
+			{"name":"v1-000000-000500-bodies.seg","from":0,"to":500000,"checksum":"0x0304"}
+		]`)),
+	}})
+
+	files, err := back.SnapshotManifest(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []SnapshotFile{
+		{Name: "v1-000000-000500-headers.seg", From: 0, To: 500000, Checksum: hexutil.Bytes{1, 2}},
+		{Name: "v1-000000-000500-bodies.seg", From: 0, To: 500000, Checksum: hexutil.Bytes{3, 4}},
+	}, files)
+}
+
+func TestNodeInfoStream(t *testing.T) {
+	peer := func(id string, protocols string) *types2.NodeInfoReply {
+		return &types2.NodeInfoReply{Id: id, Ports: &types2.NodeInfoPorts{}, Protocols: []byte(protocols)}
+	}
+	back := newTestRemoteBackend(t, &mockEthBackend{nodeInfo: &remote.NodesInfoReply{NodesInfo: []*types2.NodeInfoReply{
+		peer("aa", `{"eth":{}}`), peer("bb", `{"eth":{}}`), peer("cc", `broken`), peer("dd", `{"eth":{}}`),
+	}}}, WithStrictProtocols())
+
+	t.Run("stop early", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		items, err := back.NodeInfoStream(ctx, 0)
+		require.NoError(t, err)
+		item := <-items
+		require.NoError(t, item.Err)
+		require.Equal(t, "aa", item.Info.ID)
+		cancel()
+		for range items { // producer must stop and close channel
+		}
+	})
+	t.Run("decode error", func(t *testing.T) {
+		items, err := back.NodeInfoStream(context.Background(), 0)
+		require.NoError(t, err)
+		var ids []string
+		for item := range items {
+			if item.Err != nil {
+				require.Contains(t, item.Err.Error(), "cannot decode protocols metadata")
+				continue
+			}
+			ids = append(ids, item.Info.ID)
+		}
+		require.Equal(t, []string{"aa", "bb"}, ids)
+
+		_, err = back.NodeInfo(context.Background(), 0)
+		require.Error(t, err)
+	})
+}
+
+func TestNodeInfoLenientProtocols(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{nodeInfo: &remote.NodesInfoReply{NodesInfo: []*types2.NodeInfoReply{
+		{Id: "aa", Ports: &types2.NodeInfoPorts{}, Protocols: []byte(`{"eth":{"network":1}}`)},
+		{Id: "bb", Ports: &types2.NodeInfoPorts{}, Protocols: []byte(`garbage`)},
+		{Id: "cc", Ports: &types2.NodeInfoPorts{}},
+		{Id: "dd", Ports: &types2.NodeInfoPorts{}, Protocols: []byte(`{"eth":{"network":5}}`)},
+	}}})
+
+	nodes, err := back.NodeInfo(context.Background(), 0)
+	require.NoError(t, err)
+	require.Len(t, nodes, 4)
+	require.Equal(t, json.RawMessage(`{"network":1}`), nodes[0].Protocols["eth"])
+	require.Equal(t, "bb", nodes[1].ID)
+	require.Empty(t, nodes[1].Protocols)
+	require.Equal(t, "cc", nodes[2].ID)
+	require.Empty(t, nodes[2].Protocols)
+	require.Equal(t, json.RawMessage(`{"network":5}`), nodes[3].Protocols["eth"])
+
+	strict := newTestRemoteBackend(t, &mockEthBackend{nodeInfo: &remote.NodesInfoReply{NodesInfo: []*types2.NodeInfoReply{
+		{Id: "cc", Ports: &types2.NodeInfoPorts{}},
+	}}}, WithStrictProtocols())
+	_, err = strict.NodeInfo(context.Background(), 0)
+	require.ErrorContains(t, err, "missing protocols metadata")
+}
+
+func TestNodeInfo(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{nodeInfo: &remote.NodesInfoReply{NodesInfo: []*types2.NodeInfoReply{
+		{Id: "aa", Ports: &types2.NodeInfoPorts{}, Protocols: []byte(`{}`)},
+		{Id: "bb", Ports: &types2.NodeInfoPorts{}, Protocols: []byte(`{}`)},
+	}}})
+
+	nodes, err := back.NodeInfo(context.Background(), 0)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2)
+	require.Equal(t, "aa", nodes[0].ID)
+	require.Equal(t, "bb", nodes[1].ID)
+}
+
+func TestMaxConcurrentTraces(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"MaxConcurrentTraces": replyWith(uint64(16)),
+	}})
+
+	limit, err := back.MaxConcurrentTraces(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(16), limit)
+}
+
+func TestImportBacklog(t *testing.T) {
+	for _, tc := range []struct {
+		name               string
+		headers, execution uint64
+		backlog            uint64
+	}{
+		{name: "backlog", headers: 1500, execution: 1200, backlog: 300},
+		{name: "caught up", headers: 1500, execution: 1500, backlog: 0},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+				"StageProgress": func(args json.RawMessage) (interface{}, error) {
+					var req stageProgressRequest
+					if err := json.Unmarshal(args, &req); err != nil {
+						return nil, err
+					}
+					require.Equal(t, []stages.SyncStage{stages.Headers, stages.Execution}, req.Stages)
+					return map[stages.SyncStage]uint64{stages.Headers: tc.headers, stages.Execution: tc.execution}, nil
+				},
+			}})
+
+			backlog, err := back.ImportBacklog(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tc.backlog, backlog)
+		})
+	}
+}
+
+func TestAllowedRPCOrigins(t *testing.T) {
+	for _, origins := range [][]string{
+		{"https://app.example.com", "http://localhost:3000"},
+		{"*"},
+	} {
+		origins := origins
+		back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+			"AllowedRPCOrigins": replyWith(origins),
+		}})
+
+		got, err := back.AllowedRPCOrigins(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, origins, got)
+	}
+}
+
+func TestSubscribeMempoolPressure(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{streams: map[string]func(json.RawMessage, func(interface{}) error) error{
+		"SubscribeMempoolOccupancy": func(_ json.RawMessage, send func(interface{}) error) error {
+			for _, occupancy := range []float64{0.3, 0.6, 0.7, 0.95, 0.85, 0.4, 0.4} {
+				if err := send(mempoolOccupancyEvent{Occupancy: occupancy}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}})
+
+	var levels []float64
+	require.NoError(t, back.SubscribeMempoolPressure(context.Background(), []float64{0.9, 0.5}, func(level float64) {
+		levels = append(levels, level)
+	}))
+	require.Equal(t, []float64{0.5, 0.9, 0.9, 0.5}, levels)
+}
+
+func TestDBGrowthAlarmThreshold(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"DBGrowthAlarmThreshold": replyWith(uint64(10 << 30)),
+	}})
+
+	threshold, err := back.DBGrowthAlarmThreshold(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(10<<30), threshold)
+}
+
+func TestEngineJWTWindow(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"EngineJWTWindow": replyWith(5 * time.Second),
+	}})
+
+	window, err := back.EngineJWTWindow(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Second, window)
+}
+
+func TestSubscribeStateRootMismatches(t *testing.T) {
+	expected, got := common.HexToHash("0xaa"), common.HexToHash("0xbb")
+	back := newTestRemoteBackend(t, &mockEthBackend{streams: map[string]func(json.RawMessage, func(interface{}) error) error{
+		"SubscribeStateRootMismatches": func(_ json.RawMessage, send func(interface{}) error) error {
+			return send(stateRootMismatchEvent{BlockNumber: 1000, Expected: expected, Got: got})
+		},
+	}})
+
+	var events []stateRootMismatchEvent
+	require.NoError(t, back.SubscribeStateRootMismatches(context.Background(), func(blockNum uint64, expected, got common.Hash) {
+		events = append(events, stateRootMismatchEvent{BlockNumber: blockNum, Expected: expected, Got: got})
+	}))
+	require.Equal(t, []stateRootMismatchEvent{{BlockNumber: 1000, Expected: expected, Got: got}}, events)
+}
+
+func TestHeaderBatchSize(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"HeaderBatchSize": replyWith(uint64(1024)),
+	}})
+
+	size, err := back.HeaderBatchSize(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1024), size)
+}
+
+func TestSubscribePeerBans(t *testing.T) {
+	const peer = "enode://a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c@52.16.188.185:30303"
+	until := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	back := newTestRemoteBackend(t, &mockEthBackend{streams: map[string]func(json.RawMessage, func(interface{}) error) error{
+		"SubscribePeerBans": func(_ json.RawMessage, send func(interface{}) error) error {
+			return send(peerBanEvent{Enode: peer, Reason: "useless peer", Until: until})
+		},
+	}})
+
+	var events []peerBanEvent
+	require.NoError(t, back.SubscribePeerBans(context.Background(), func(enode string, reason string, until time.Time) {
+		events = append(events, peerBanEvent{Enode: enode, Reason: reason, Until: until})
+	}))
+	require.Len(t, events, 1)
+	require.Equal(t, peer, events[0].Enode)
+	require.Equal(t, "useless peer", events[0].Reason)
+	require.True(t, until.Equal(events[0].Until))
+}
+
+func TestMaxTraceDuration(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"MaxTraceDuration": replyWith(30 * time.Second),
+	}})
+
+	timeout, err := back.MaxTraceDuration(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Second, timeout)
+}
+
+func TestMissedProposals(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"MissedProposals": func(args json.RawMessage) (interface{}, error) {
+			var req missedProposalsRequest
+			if err := json.Unmarshal(args, &req); err != nil {
+				return nil, err
+			}
+			return req.Window / 100, nil
+		},
+	}})
+
+	missed, err := back.MissedProposals(context.Background(), 1000)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), missed)
+}
+
+func TestSubscribeConfigReloads(t *testing.T) {
+	ts := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	back := newTestRemoteBackend(t, &mockEthBackend{streams: map[string]func(json.RawMessage, func(interface{}) error) error{
+		"SubscribeConfigReloads": func(_ json.RawMessage, send func(interface{}) error) error {
+			return send(configReloadEvent{Time: ts, Changed: []string{"txpool.pricelimit", "maxpeers"}})
+		},
+	}})
+
+	var events []configReloadEvent
+	require.NoError(t, back.SubscribeConfigReloads(context.Background(), func(ts time.Time, changed []string) {
+		events = append(events, configReloadEvent{Time: ts, Changed: changed})
+	}))
+	require.Len(t, events, 1)
+	require.True(t, ts.Equal(events[0].Time))
+	require.Equal(t, []string{"txpool.pricelimit", "maxpeers"}, events[0].Changed)
+}
+
+func TestMaxAcceptedReorgDepth(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"MaxAcceptedReorgDepth": replyWith(uint64(90_000)),
+	}})
+
+	depth, err := back.MaxAcceptedReorgDepth(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(90_000), depth)
+}
+
+func TestGoroutineBreakdown(t *testing.T) {
+	breakdown := map[string]uint64{"p2p": 120, "txpool": 8, "sync": 15, "rpc": 40}
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"GoroutineBreakdown": replyWith(breakdown),
+	}})
+
+	got, err := back.GoroutineBreakdown(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, breakdown, got)
+}
+
+func TestSubscribeDiskWarnings(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{streams: map[string]func(json.RawMessage, func(interface{}) error) error{
+		"SubscribeDiskWarnings": func(_ json.RawMessage, send func(interface{}) error) error {
+			return send(diskWarningEvent{FreeBytes: 5 << 30, Threshold: 10 << 30})
+		},
+	}})
+
+	var events []diskWarningEvent
+	require.NoError(t, back.SubscribeDiskWarnings(context.Background(), func(freeBytes uint64, threshold uint64) {
+		events = append(events, diskWarningEvent{FreeBytes: freeBytes, Threshold: threshold})
+	}))
+	require.Equal(t, []diskWarningEvent{{FreeBytes: 5 << 30, Threshold: 10 << 30}}, events)
+}
+
+func TestTxPropagationBatchSize(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"TxPropagationBatchSize": replyWith(uint64(4096)),
+	}})
+
+	size, err := back.TxPropagationBatchSize(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(4096), size)
+}
+
+func TestTotalSupply(t *testing.T) {
+	supply, _ := new(big.Int).SetString("120000000000000000000000000", 10)
+	srv := &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"TotalSupply": func(args json.RawMessage) (interface{}, error) {
+			var req blockNumRequest
+			if err := json.Unmarshal(args, &req); err != nil {
+				return nil, err
+			}
+			require.Equal(t, uint64(15_000_000), req.BlockNum)
+			return (*hexutil.Big)(supply), nil
+		},
+	}}
+
+	got, err := newTestRemoteBackend(t, srv, WithTotalSupply()).TotalSupply(context.Background(), 15_000_000)
+	require.NoError(t, err)
+	require.Equal(t, supply, got)
+
+	_, err = newTestRemoteBackend(t, srv).TotalSupply(context.Background(), 15_000_000)
+	require.ErrorIs(t, err, ErrTotalSupplyDisabled)
+}
+
+func TestSubscribeExecutionState(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{streams: map[string]func(json.RawMessage, func(interface{}) error) error{
+		"SubscribeExecutionState": func(_ json.RawMessage, send func(interface{}) error) error {
+			if err := send(executionStateEvent{Paused: true, Reason: "waiting for forkchoice update"}); err != nil {
+				return err
+			}
+			return send(executionStateEvent{Paused: false, Reason: "forkchoice updated"})
+		},
+	}})
+
+	var events []executionStateEvent
+	require.NoError(t, back.SubscribeExecutionState(context.Background(), func(paused bool, reason string) {
+		events = append(events, executionStateEvent{Paused: paused, Reason: reason})
+	}))
+	require.Equal(t, []executionStateEvent{
+		{Paused: true, Reason: "waiting for forkchoice update"},
+		{Paused: false, Reason: "forkchoice updated"},
+	}, events)
+}
+
+func TestSyncProgressCurrentStage(t *testing.T) {
+	progress := func(highest uint64, blocks ...uint64) *SyncProgress {
+		p := &SyncProgress{HighestBlock: highest, CurrentBlock: blocks[len(blocks)-1]}
+		for i, block := range blocks {
+			p.Stages = append(p.Stages, StageProgress{Name: fmt.Sprintf("stage%d", i), BlockNumber: block})
+		}
+		return p
+	}
+	require.Equal(t, "stage2", progress(100, 100, 100, 50, 0).CurrentStage())
+	require.Equal(t, "stage3", progress(100, 100, 0, 100, 50).CurrentStage(), "disabled stage is skipped")
+	require.Equal(t, "", progress(100, 100, 0, 100, 100).CurrentStage())
+	require.False(t, progress(100, 100, 0, 100, 100).Syncing())
+	require.True(t, progress(0, 0, 0).Syncing(), "nothing is synced yet")
+}
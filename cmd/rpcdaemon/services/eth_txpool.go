@@ -1,12 +1,16 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/ethdb/privateapi"
+	"github.com/ledgerwatch/erigon/rlp"
 	"github.com/ledgerwatch/log/v3"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -41,3 +45,73 @@ func (s *TxPoolService) EnsureVersionCompatibility() bool {
 		"server", fmt.Sprintf("%d.%d.%d", versionReply.Major, versionReply.Minor, versionReply.Patch))
 	return true
 }
+
+// TxPoolBackend - read access to the transaction pool, used by txpool_ namespace. Works with remote txpool, so
+// rpcdaemon doesn't need local access to the pool.
+type TxPoolBackend interface {
+	Content(ctx context.Context) (*TxPoolContent, error)
+	Status(ctx context.Context) (*TxPoolStatus, error)
+}
+
+// TxPoolContent - transactions of the pool grouped by sub-pool and sender
+type TxPoolContent struct {
+	Pending map[common.Address][]types.Transaction
+	BaseFee map[common.Address][]types.Transaction
+	Queued  map[common.Address][]types.Transaction
+}
+
+// TxPoolStatus - amount of transactions in each sub-pool
+type TxPoolStatus struct {
+	Pending uint64
+	BaseFee uint64
+	Queued  uint64
+}
+
+// RemoteTxPool - TxPoolBackend proxying to txpool gRPC service
+type RemoteTxPool struct {
+	pool txpool.TxpoolClient
+}
+
+func NewRemoteTxPool(pool txpool.TxpoolClient) *RemoteTxPool {
+	return &RemoteTxPool{pool: pool}
+}
+
+func (r *RemoteTxPool) Content(ctx context.Context) (*TxPoolContent, error) {
+	reply, err := r.pool.All(ctx, &txpool.AllRequest{})
+	if err != nil {
+		return nil, err
+	}
+	content := &TxPoolContent{
+		Pending: make(map[common.Address][]types.Transaction, 8),
+		BaseFee: make(map[common.Address][]types.Transaction, 8),
+		Queued:  make(map[common.Address][]types.Transaction, 8),
+	}
+	for i := range reply.Txs {
+		txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(reply.Txs[i].RlpTx), 0))
+		if err != nil {
+			return nil, err
+		}
+		addr := common.BytesToAddress(reply.Txs[i].Sender)
+		switch reply.Txs[i].Type {
+		case txpool.AllReply_PENDING:
+			content.Pending[addr] = append(content.Pending[addr], txn)
+		case txpool.AllReply_BASE_FEE:
+			content.BaseFee[addr] = append(content.BaseFee[addr], txn)
+		case txpool.AllReply_QUEUED:
+			content.Queued[addr] = append(content.Queued[addr], txn)
+		}
+	}
+	return content, nil
+}
+
+func (r *RemoteTxPool) Status(ctx context.Context) (*TxPoolStatus, error) {
+	reply, err := r.pool.Status(ctx, &txpool.StatusRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return &TxPoolStatus{
+		Pending: uint64(reply.PendingCount),
+		BaseFee: uint64(reply.BaseFeeCount),
+		Queued:  uint64(reply.QueuedCount),
+	}, nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type mockTxpoolClient struct {
+	txpool.TxpoolClient
+	all    *txpool.AllReply
+	status *txpool.StatusReply
+}
+
+func (m *mockTxpoolClient) All(ctx context.Context, in *txpool.AllRequest, opts ...grpc.CallOption) (*txpool.AllReply, error) {
+	return m.all, nil
+}
+
+func (m *mockTxpoolClient) Status(ctx context.Context, in *txpool.StatusRequest, opts ...grpc.CallOption) (*txpool.StatusReply, error) {
+	return m.status, nil
+}
+
+func TestRemoteTxPool(t *testing.T) {
+	sender := common.Address{1}
+	var rlpTxs [][]byte
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, types.NewTransaction(nonce, common.Address{2}, uint256.NewInt(1), 21000, uint256.NewInt(1), nil).MarshalBinary(buf))
+		rlpTxs = append(rlpTxs, buf.Bytes())
+	}
+	pool := NewRemoteTxPool(&mockTxpoolClient{
+		all: &txpool.AllReply{Txs: []*txpool.AllReply_Tx{
+			{Type: txpool.AllReply_PENDING, Sender: sender.Bytes(), RlpTx: rlpTxs[0]},
+			{Type: txpool.AllReply_PENDING, Sender: sender.Bytes(), RlpTx: rlpTxs[1]},
+			{Type: txpool.AllReply_QUEUED, Sender: sender.Bytes(), RlpTx: rlpTxs[2]},
+		}},
+		status: &txpool.StatusReply{PendingCount: 2, QueuedCount: 1},
+	})
+
+	content, err := pool.Content(context.Background())
+	require.NoError(t, err)
+	require.Len(t, content.Pending[sender], 2)
+	require.EqualValues(t, 1, content.Pending[sender][1].GetNonce())
+	require.Empty(t, content.BaseFee)
+	require.Len(t, content.Queued[sender], 1)
+	require.EqualValues(t, 2, content.Queued[sender][0].GetNonce())
+
+	status, err := pool.Status(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, TxPoolStatus{Pending: 2, Queued: 1}, *status)
+}
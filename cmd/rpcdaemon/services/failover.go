@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/ledgerwatch/log/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// FailoverConn - connections to several nodes serving same chain. Calls go to the first healthy connection
+// (in order of `conns`), idempotent unary calls (see idempotentMethods) failed with transient error are repeated on
+// next healthy connection. Other unary calls are sent once: they change state of the node, which may be per-node.
+// Health is connectivity state of the connection, which gRPC updates by keepalive pings and reconnects.
+// Streams are opened on the first healthy connection and are not moved if it breaks later, see WithReconnect
+type FailoverConn struct {
+	conns      []ClientConn
+	roundRobin bool
+	next       uint32 // atomic
+	log        log.Logger
+}
+
+// NewFailoverConn - if `roundRobin` is set, idempotent unary calls are spread over all healthy connections
+func NewFailoverConn(conns []ClientConn, roundRobin bool, logger log.Logger) *FailoverConn {
+	return &FailoverConn{conns: conns, roundRobin: roundRobin, log: logger}
+}
+
+func isHealthy(conn ClientConn) bool {
+	state := conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
+}
+
+// candidates - healthy connections in order they should be tried. If all connections are broken - only the primary one:
+// call waits for it or fails with codes.Unavailable, as with single connection
+func (c *FailoverConn) candidates(method string) []ClientConn {
+	start := 0
+	if c.roundRobin && idempotentMethods[method] {
+		start = int(atomic.AddUint32(&c.next, 1)-1) % len(c.conns)
+	}
+	res := make([]ClientConn, 0, len(c.conns))
+	for i := range c.conns {
+		if conn := c.conns[(start+i)%len(c.conns)]; isHealthy(conn) {
+			res = append(res, conn)
+		}
+	}
+	if len(res) == 0 {
+		res = append(res, c.conns[0])
+	}
+	return res
+}
+
+func (c *FailoverConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	var err error
+	for _, conn := range c.candidates(method) {
+		err = conn.Invoke(ctx, method, args, reply, opts...)
+		if err == nil || !isTransient(err) || ctx.Err() != nil || !idempotentMethods[method] {
+			return err
+		}
+		c.log.Warn("rpcdaemon: private api call failed, trying next node", "method", method, "target", conn.Target(), "err", err)
+	}
+	return err
+}
+
+func (c *FailoverConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.candidates("")[0].NewStream(ctx, desc, method, opts...)
+}
+
+// Close - closes all connections
+func (c *FailoverConn) Close() error {
+	var res error
+	for _, conn := range c.conns {
+		if err := conn.Close(); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
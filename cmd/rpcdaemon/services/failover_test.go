@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func countingNode(calls *int) *mockEthBackend {
+	return &mockEthBackend{
+		netPeerCount: func(context.Context) (*remote.NetPeerCountReply, error) {
+			*calls++
+			return &remote.NetPeerCountReply{Count: 42}, nil
+		},
+		replies: map[string]func(json.RawMessage) (interface{}, error){
+			"AddPeer": func(json.RawMessage) (interface{}, error) {
+				*calls++
+				return true, nil
+			},
+		},
+	}
+}
+
+func TestFailoverOnTransientError(t *testing.T) {
+	var calls1, calls2 int
+	conn1 := newTestConn(t, &mockEthBackend{netPeerCount: failingPeerCount(1, codes.Unavailable, &calls1)})
+	conn2 := newTestConn(t, countingNode(&calls2))
+	back := NewRemoteBackend(NewFailoverConn([]ClientConn{conn1, conn2}, false, log.New()))
+	defer back.Close()
+
+	count, err := back.NetPeerCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), count)
+	require.Equal(t, []int{1, 1}, []int{calls1, calls2})
+}
+
+func TestFailoverSkipsBrokenConn(t *testing.T) {
+	var calls1, calls2 int
+	conn1 := newTestConn(t, countingNode(&calls1))
+	conn2 := newTestConn(t, countingNode(&calls2))
+	back := NewRemoteBackend(NewFailoverConn([]ClientConn{conn1, conn2}, false, log.New()))
+	defer back.Close()
+
+	require.NoError(t, conn1.Close())
+	_, err := back.NetPeerCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 1}, []int{calls1, calls2})
+}
+
+func TestFailoverRoundRobin(t *testing.T) {
+	var calls1, calls2 int
+	conn1 := newTestConn(t, countingNode(&calls1))
+	conn2 := newTestConn(t, countingNode(&calls2))
+	back := NewRemoteBackend(NewFailoverConn([]ClientConn{conn1, conn2}, true, log.New()))
+	defer back.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		_, err := back.NetPeerCount(ctx)
+		require.NoError(t, err)
+	}
+	require.Equal(t, []int{2, 2}, []int{calls1, calls2})
+
+	// changes of node state are not balanced
+	const url = "enode://a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c@52.16.188.185:30303"
+	for i := 0; i < 2; i++ {
+		_, err := back.AddPeer(ctx, url)
+		require.NoError(t, err)
+	}
+	require.Equal(t, []int{4, 2}, []int{calls1, calls2})
+}
+
+func TestFailoverDoesNotRepeatMutatingCalls(t *testing.T) {
+	var calls1, calls2 int
+	submitWork := func(calls *int) *mockEthBackend {
+		return &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+			"SubmitWork": func(json.RawMessage) (interface{}, error) {
+				*calls++
+				return nil, status.Error(codes.Unavailable, "core is restarting")
+			},
+		}}
+	}
+	conn1 := newTestConn(t, submitWork(&calls1))
+	conn2 := newTestConn(t, submitWork(&calls2))
+	back := NewRemoteBackend(NewFailoverConn([]ClientConn{conn1, conn2}, true, log.New()))
+	defer back.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err := back.SubmitWork(context.Background(), types.BlockNonce{}, common.Hash{}, common.Hash{})
+		require.Error(t, err)
+	}
+	require.Equal(t, []int{2, 0}, []int{calls1, calls2}, "pinned to the first node and not repeated on the next one")
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common/ctxutil"
+	"google.golang.org/grpc"
+)
+
+// grpcTimeOptions - interceptors adding time spent waiting for Erigon to ctxutil.GRPCTime of the calling request, so
+// slow calls can be told apart from slow local execution
+func grpcTimeOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			start := time.Now()
+			defer func() { ctxutil.AddGRPCTime(ctx, time.Since(start)) }()
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}),
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			start := time.Now()
+			stream, err := streamer(ctx, desc, cc, method, opts...)
+			ctxutil.AddGRPCTime(ctx, time.Since(start))
+			if err != nil {
+				return nil, err
+			}
+			return &timedStream{ClientStream: stream, ctx: ctx}, nil
+		}),
+	}
+}
+
+// timedStream - stream of which only waiting in SendMsg and RecvMsg is counted, not its whole lifetime
+type timedStream struct {
+	grpc.ClientStream
+	ctx context.Context
+}
+
+func (s *timedStream) SendMsg(m interface{}) error {
+	start := time.Now()
+	defer func() { ctxutil.AddGRPCTime(s.ctx, time.Since(start)) }()
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *timedStream) RecvMsg(m interface{}) error {
+	start := time.Now()
+	defer func() { ctxutil.AddGRPCTime(s.ctx, time.Since(start)) }()
+	return s.ClientStream.RecvMsg(m)
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"google.golang.org/grpc"
+)
+
+// DefaultKvPrefetch - max amount of pairs prefetched by one round trip of remote cursor
+const DefaultKvPrefetch = 256
+
+// maxKvPrefetch - all Op_NEXT requests of one round trip are sent before reading replies, they must fit into
+// flow-control window of the stream (64KB) to not block on server busy with sending replies
+const maxKvPrefetch = 2048
+
+// PrefetchKVClient - remote KV client with prefetching cursors. Requests of Tx stream are answered by server one by one
+// in order, so Op_NEXT of a cursor is pipelined: sent several times in one round trip, next Op_NEXT calls of the cursor
+// are answered from prefetched pairs. Amount of pairs prefetched by round trip doubles while cursor is moved only by
+// Op_NEXT, up to `size`, so point reads don't make server do useless work. Any other operation of the cursor first moves
+// it on server (which is ahead) back to the last pair returned to client. Protocol and server are not changed
+type PrefetchKVClient struct {
+	remote.KVClient
+	size int
+}
+
+// NewPrefetchKVClient - `size` is max amount of pairs prefetched by one round trip, at most 2048
+func NewPrefetchKVClient(client remote.KVClient, size int) *PrefetchKVClient {
+	if size > maxKvPrefetch {
+		size = maxKvPrefetch
+	}
+	return &PrefetchKVClient{KVClient: client, size: size}
+}
+
+func (c *PrefetchKVClient) Tx(ctx context.Context, opts ...grpc.CallOption) (remote.KV_TxClient, error) {
+	stream, err := c.KVClient.Tx(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &prefetchTx{KV_TxClient: stream, size: c.size, cursors: map[uint32]*prefetchCursor{}}, nil
+}
+
+type prefetchCursor struct {
+	seekBoth bool           // cursor of dupsort table: position is key and value
+	pairs    []*remote.Pair // prefetched, not returned to client yet
+	last     *remote.Pair   // returned to client by last Op_NEXT
+	batch    int            // amount of pairs prefetched by last round trip
+}
+
+type prefetchTx struct {
+	remote.KV_TxClient
+	size    int
+	cursors map[uint32]*prefetchCursor
+	opening string       // bucket of Op_OPEN waiting for reply with cursor id
+	reply   *remote.Pair // prefetched answer of last Op_NEXT, returned by next Recv
+}
+
+func (s *prefetchTx) Send(in *remote.Cursor) error {
+	c, ok := s.cursors[in.Cursor]
+	switch {
+	case in.Op == remote.Op_OPEN:
+		s.opening = in.BucketName
+		return s.KV_TxClient.Send(in)
+	case !ok: // server will fail
+		return s.KV_TxClient.Send(in)
+	case in.Op == remote.Op_NEXT:
+		return s.next(in.Cursor, c)
+	case in.Op == remote.Op_CLOSE:
+		delete(s.cursors, in.Cursor)
+	default:
+		if err := s.sync(in.Cursor, c); err != nil {
+			return err
+		}
+		c.batch = 0
+	}
+	return s.KV_TxClient.Send(in)
+}
+
+func (s *prefetchTx) Recv() (*remote.Pair, error) {
+	if s.reply != nil {
+		reply := s.reply
+		s.reply = nil
+		return reply, nil
+	}
+	pair, err := s.KV_TxClient.Recv()
+	if err == nil && s.opening != "" {
+		cfg := kv.ChaindataTablesCfg[s.opening]
+		s.cursors[pair.CursorID] = &prefetchCursor{seekBoth: cfg.Flags&kv.DupSort != 0 && !cfg.AutoDupSortKeysConversion}
+		s.opening = ""
+	}
+	return pair, err
+}
+
+// next - answers Op_NEXT from prefetched pairs, prefetches them if there are none
+func (s *prefetchTx) next(id uint32, c *prefetchCursor) error {
+	if len(c.pairs) == 0 {
+		if c.batch *= 2; c.batch == 0 {
+			c.batch = 1
+		}
+		if c.batch > s.size {
+			c.batch = s.size
+		}
+		for i := 0; i < c.batch; i++ {
+			if err := s.KV_TxClient.Send(&remote.Cursor{Cursor: id, Op: remote.Op_NEXT}); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < c.batch; i++ {
+			pair, err := s.KV_TxClient.Recv()
+			if err != nil {
+				return err
+			}
+			// Op_NEXT at the end of table doesn't move cursor, pairs after the first nil key are nil too
+			if len(c.pairs) == 0 || c.pairs[len(c.pairs)-1].K != nil {
+				c.pairs = append(c.pairs, pair)
+			}
+		}
+	}
+	s.reply, c.pairs = c.pairs[0], c.pairs[1:]
+	c.last = s.reply
+	return nil
+}
+
+// sync - moves cursor on server, which is ahead because of prefetching, back to the last pair returned to client
+func (s *prefetchTx) sync(id uint32, c *prefetchCursor) error {
+	if len(c.pairs) == 0 {
+		return nil
+	}
+	c.pairs = nil
+	seek := &remote.Cursor{Cursor: id, Op: remote.Op_SEEK_EXACT, K: c.last.K}
+	if c.seekBoth {
+		seek.Op, seek.V = remote.Op_SEEK_BOTH_EXACT, c.last.V
+	}
+	if err := s.KV_TxClient.Send(seek); err != nil {
+		return err
+	}
+	_, err := s.KV_TxClient.Recv()
+	return err
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon-lib/kv/remotedb"
+	"github.com/ledgerwatch/erigon-lib/kv/remotedbserver"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestPrefetchKVClient(t *testing.T) {
+	ctx := context.Background()
+	db := memdb.NewTestDB(t)
+	require.NoError(t, db.Update(ctx, func(tx kv.RwTx) error {
+		for i := uint64(0); i < 100; i++ {
+			k := make([]byte, 8)
+			binary.BigEndian.PutUint64(k, i)
+			if err := tx.Put(kv.HeaderCanonical, k, []byte{byte(i)}); err != nil {
+				return err
+			}
+			for j := byte(0); j < 3; j++ {
+				if err := tx.Put(kv.AccountChangeSet, k, []byte{j, byte(i)}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}))
+
+	server := grpc.NewServer()
+	remote.RegisterKVServer(server, remotedbserver.NewKvServer(ctx, db))
+	listener := bufconn.Listen(1024 * 1024)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+	conn, err := grpc.Dial("", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	require.NoError(t, err)
+	defer conn.Close()
+	remoteDB, err := remotedb.NewRemote(gointerfaces.VersionFromProto(remotedbserver.KvServiceAPIVersion), log.New(), NewPrefetchKVClient(remote.NewKVClient(conn), 16)).Open()
+	require.NoError(t, err)
+
+	tx, err := remoteDB.BeginRo(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	var n int
+	require.NoError(t, tx.ForEach(kv.HeaderCanonical, nil, func(k, v []byte) error {
+		require.Equal(t, uint64(n), binary.BigEndian.Uint64(k))
+		n++
+		return nil
+	}))
+	require.Equal(t, 100, n)
+
+	c, err := tx.Cursor(kv.HeaderCanonical)
+	require.NoError(t, err)
+	defer c.Close()
+	k, _, err := c.First()
+	require.NoError(t, err)
+	for i := 1; i <= 10; i++ { // server is ahead
+		k, _, err = c.Next()
+		require.NoError(t, err)
+		require.Equal(t, uint64(i), binary.BigEndian.Uint64(k))
+	}
+	k, v, err := c.Current()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), binary.BigEndian.Uint64(k))
+	require.Equal(t, []byte{10}, v)
+	k, _, err = c.Prev()
+	require.NoError(t, err)
+	require.Equal(t, uint64(9), binary.BigEndian.Uint64(k))
+	for i := 10; i < 100; i++ {
+		k, _, err = c.Next()
+		require.NoError(t, err)
+		require.Equal(t, uint64(i), binary.BigEndian.Uint64(k))
+	}
+	k, _, err = c.Next()
+	require.NoError(t, err)
+	require.Nil(t, k)
+
+	dup, err := tx.CursorDupSort(kv.AccountChangeSet)
+	require.NoError(t, err)
+	defer dup.Close()
+	_, _, err = dup.First()
+	require.NoError(t, err)
+	for i := 0; i < 6; i++ { // block 2, 1st value, server is ahead
+		_, _, err = dup.Next()
+		require.NoError(t, err)
+	}
+	k, v, err = dup.NextDup()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), binary.BigEndian.Uint64(k))
+	require.Equal(t, []byte{1, 2}, v)
+	_, _, err = dup.Next()
+	require.NoError(t, err)
+	k, v, err = dup.Next()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), binary.BigEndian.Uint64(k))
+	require.Equal(t, []byte{0, 3}, v)
+
+	// point reads between scans
+	v, err = tx.GetOne(kv.HeaderCanonical, k)
+	require.NoError(t, err)
+	require.Equal(t, []byte{3}, v)
+}
@@ -0,0 +1,24 @@
+package services
+
+import "fmt"
+
+// LogsBuffer - how SubscribeLogs buffers replies which are not yet consumed by the callback
+type LogsBuffer struct {
+	Size       int  // amount of replies buffered, 0 means no buffering (but callback still doesn't run in the reading goroutine)
+	DropOldest bool // when buffer is full: drop oldest reply instead of waiting for the callback. See RemoteBackend.DroppedLogs
+}
+
+func DefaultLogsBuffer() LogsBuffer {
+	return LogsBuffer{Size: 256}
+}
+
+// Validate - Size can't be negative, and DropOldest needs buffer to drop replies from
+func (b LogsBuffer) Validate() error {
+	if b.Size < 0 {
+		return fmt.Errorf("logs buffer size can't be negative: %d", b.Size)
+	}
+	if b.Size == 0 && b.DropOldest {
+		return fmt.Errorf("dropping of oldest logs requires logs buffer")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/grpcutil"
+)
+
+// ReconnectPolicy - re-establishing of Subscribe and SubscribeLogs streams broken by transient error (for example core restart).
+// Reconnects continue until context of subscription is done
+type ReconnectPolicy struct {
+	BaseDelay time.Duration // delay before 1st reconnect, doubled on each next failed attempt
+	MaxDelay  time.Duration // 0 means no limit
+}
+
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{BaseDelay: 500 * time.Millisecond, MaxDelay: 30 * time.Second}
+}
+
+// isReconnectable - stream was closed by server (nil error), or broken by transient error
+func isReconnectable(err error) bool {
+	return err == nil || isTransient(err) || grpcutil.IsEndOfStream(err)
+}
+
+// resyncNotifier - callbacks of OnResync
+type resyncNotifier struct {
+	lock      sync.Mutex
+	callbacks []func(stream string)
+}
+
+func (n *resyncNotifier) add(cb func(stream string)) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.callbacks = append(n.callbacks[:len(n.callbacks):len(n.callbacks)], cb)
+}
+
+func (n *resyncNotifier) notify(stream string) {
+	n.lock.Lock()
+	callbacks := n.callbacks
+	n.lock.Unlock()
+	for _, cb := range callbacks {
+		cb(stream)
+	}
+}
+
+// OnResync - `cb` is called each time broken stream ("Subscribe" or "SubscribeLogs") is re-established WithReconnect.
+// Events sent by the node while stream was broken are lost, so consumers should re-read state they track (for example latest header)
+func (back *RemoteBackend) OnResync(cb func(stream string)) {
+	back.resync.add(cb)
+}
+
+// reconnecting - runs `run` again each time it fails with reconnectable error, if RemoteBackend is created WithReconnect.
+// `run` calls `onOpen` once stream is established
+func (back *RemoteBackend) reconnecting(ctx context.Context, stream string, run func(onOpen func()) error) error {
+	if back.reconnect == nil {
+		return run(func() {})
+	}
+	delay := back.reconnect.BaseDelay
+	for attempt := 0; ; attempt++ {
+		opened := false
+		err := run(func() {
+			opened = true
+			if attempt > 0 {
+				back.resync.notify(stream)
+			}
+		})
+		if ctx.Err() != nil || !isReconnectable(err) {
+			return err
+		}
+		if opened {
+			delay = back.reconnect.BaseDelay
+		}
+		loggerFor(ctx, back.log).Warn("rpcdaemon: stream is broken, reconnecting", "stream", stream, "err", err, "delay", delay)
+		metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_backend_stream_restarts{stream="%s"}`, stream)).Inc()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if back.reconnect.MaxDelay > 0 && delay > back.reconnect.MaxDelay {
+			delay = back.reconnect.MaxDelay
+		}
+	}
+}
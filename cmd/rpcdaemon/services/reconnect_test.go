@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var testReconnectPolicy = ReconnectPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+func TestSubscribeReconnects(t *testing.T) {
+	var calls int32
+	back := newTestRemoteBackend(t, &mockEthBackend{
+		subscribe: func(_ *remote.SubscribeRequest, server remote.ETHBACKEND_SubscribeServer) error {
+			call := atomic.AddInt32(&calls, 1)
+			if err := server.Send(&remote.SubscribeReply{Type: remote.Event_HEADER, Data: []byte{byte(call)}}); err != nil {
+				return err
+			}
+			if call < 3 {
+				return status.Error(codes.Unavailable, "node is restarting")
+			}
+			<-server.Context().Done()
+			return nil
+		},
+	}, WithReconnect(testReconnectPolicy))
+
+	var resyncs []string
+	back.OnResync(func(stream string) { resyncs = append(resyncs, stream) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var received []byte
+	err := back.Subscribe(ctx, func(reply *remote.SubscribeReply) {
+		received = append(received, reply.Data...)
+		if len(received) == 3 {
+			cancel()
+		}
+	})
+	require.Error(t, err)
+	require.Equal(t, []byte{1, 2, 3}, received)
+	require.Equal(t, []string{"Subscribe", "Subscribe"}, resyncs)
+}
+
+func TestSubscribeLogsReplaysFilter(t *testing.T) {
+	var calls int32
+	filters := make(chan *remote.LogsFilterRequest, 2)
+	back := newTestRemoteBackend(t, &mockEthBackend{
+		subscribeLogs: func(server remote.ETHBACKEND_SubscribeLogsServer) error {
+			filter, err := server.Recv()
+			if err != nil {
+				return err
+			}
+			filters <- filter
+			if err = server.Send(&remote.SubscribeLogsReply{BlockNumber: uint64(atomic.AddInt32(&calls, 1))}); err != nil {
+				return err
+			}
+			if atomic.LoadInt32(&calls) == 1 {
+				return status.Error(codes.Unavailable, "node is restarting")
+			}
+			<-server.Context().Done()
+			return nil
+		},
+	}, WithReconnect(testReconnectPolicy))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	filter := &remote.LogsFilterRequest{AllAddresses: true, AllTopics: true}
+	var blocks []uint64
+	err := back.subscribeLogs(ctx, func(reply *remote.SubscribeLogsReply) {
+		blocks = append(blocks, reply.BlockNumber)
+		if len(blocks) == 2 {
+			cancel()
+		}
+	}, func(send func(*remote.LogsFilterRequest) error) error {
+		return send(filter)
+	})
+	require.Error(t, err)
+	require.Equal(t, []uint64{1, 2}, blocks)
+	require.Len(t, filters, 2)
+	for i := 0; i < 2; i++ {
+		got := <-filters
+		require.True(t, got.AllAddresses && got.AllTopics)
+	}
+}
+
+func TestSubscribeWithoutReconnect(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{
+		subscribe: func(*remote.SubscribeRequest, remote.ETHBACKEND_SubscribeServer) error {
+			return status.Error(codes.Unavailable, "node is restarting")
+		},
+	})
+
+	err := back.Subscribe(context.Background(), func(*remote.SubscribeReply) {})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
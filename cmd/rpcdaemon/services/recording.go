@@ -0,0 +1,661 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// RecordingVersion - version of recording format written by RecordingBackend.
+// ReplayBackend reads recordings of this and all previous versions, so format changes must be backward compatible
+const RecordingVersion = 1
+
+// Recording - calls of ApiBackend methods captured by RecordingBackend, served by ReplayBackend.
+// Arguments (except ctx and callbacks) and results (except error) of each call are JSON arrays.
+// Values which don't survive JSON round-trip (blocks, transactions) are stored RLP-encoded
+type Recording struct {
+	Version int             `json:"version"`
+	Calls   []*RecordedCall `json:"calls"`
+}
+
+type RecordedCall struct {
+	Method string          `json:"method"`
+	Args   json.RawMessage `json:"args"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Events []RecordedEvent `json:"events,omitempty"` // subscriptions (and NodeInfoStream) only
+}
+
+// RecordedEvent - arguments of subscription callback
+type RecordedEvent struct {
+	Offset time.Duration   `json:"offset"` // nanoseconds since start of subscription
+	Data   json.RawMessage `json:"data"`
+}
+
+func encodeValues(values []interface{}) json.RawMessage {
+	if values == nil {
+		values = []interface{}{}
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		log.Warn("backend recording: cannot encode values", "err", err)
+		return json.RawMessage("null")
+	}
+	return data
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// RecordingBackend - passes all calls to underlying backend and records them, see Save
+type RecordingBackend struct {
+	backend ApiBackend
+	lock    sync.Mutex
+	calls   []*RecordedCall
+}
+
+var _ ApiBackend = (*RecordingBackend)(nil)
+
+func NewRecordingBackend(backend ApiBackend) *RecordingBackend {
+	return &RecordingBackend{backend: backend}
+}
+
+// Save - writes all calls recorded so far, subscriptions which are still running are saved with events received so far
+func (r *RecordingBackend) Save(path string) error {
+	r.lock.Lock()
+	data, err := json.MarshalIndent(Recording{Version: RecordingVersion, Calls: r.calls}, "", "  ")
+	r.lock.Unlock()
+	if err != nil {
+		return fmt.Errorf("cannot encode recording: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func (r *RecordingBackend) record(method string, args []interface{}, results []interface{}, err error) {
+	call := &RecordedCall{Method: method, Args: encodeValues(args), Error: errString(err)}
+	if err == nil {
+		call.Result = encodeValues(results)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+// subscription - call is recorded immediately, events are added to it as they happen
+func (r *RecordingBackend) subscription(method string, args []interface{}) (onEvent func(values ...interface{}), done func(err error)) {
+	call := &RecordedCall{Method: method, Args: encodeValues(args)}
+	r.lock.Lock()
+	r.calls = append(r.calls, call)
+	r.lock.Unlock()
+	start := time.Now()
+	onEvent = func(values ...interface{}) {
+		event := RecordedEvent{Offset: time.Since(start), Data: encodeValues(values)}
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		call.Events = append(call.Events, event)
+	}
+	done = func(err error) {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		call.Error = errString(err)
+	}
+	return onEvent, done
+}
+
+func (r *RecordingBackend) Etherbase(ctx context.Context) (common.Address, error) {
+	res, err := r.backend.Etherbase(ctx)
+	r.record("Etherbase", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) NetVersion(ctx context.Context) (uint64, error) {
+	res, err := r.backend.NetVersion(ctx)
+	r.record("NetVersion", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) NetPeerCount(ctx context.Context) (uint64, error) {
+	res, err := r.backend.NetPeerCount(ctx)
+	r.record("NetPeerCount", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) ProtocolVersion(ctx context.Context) (uint64, error) {
+	res, err := r.backend.ProtocolVersion(ctx)
+	r.record("ProtocolVersion", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) ClientVersion(ctx context.Context) (string, error) {
+	res, err := r.backend.ClientVersion(ctx)
+	r.record("ClientVersion", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) Subscribe(ctx context.Context, cb func(*remote.SubscribeReply)) error {
+	onEvent, done := r.subscription("Subscribe", nil)
+	err := r.backend.Subscribe(ctx, func(reply *remote.SubscribeReply) {
+		onEvent(reply)
+		cb(reply)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) SubscribeTopics(ctx context.Context, topics []remote.Event, cb func(*remote.SubscribeReply)) error {
+	onEvent, done := r.subscription("SubscribeTopics", []interface{}{topics})
+	err := r.backend.SubscribeTopics(ctx, topics, func(reply *remote.SubscribeReply) {
+		onEvent(reply)
+		cb(reply)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) SubscribeLogs(ctx context.Context, cb func(*remote.SubscribeLogsReply), requestor *atomic.Value) error {
+	onEvent, done := r.subscription("SubscribeLogs", nil)
+	err := r.backend.SubscribeLogs(ctx, func(reply *remote.SubscribeLogsReply) {
+		onEvent(reply)
+		cb(reply)
+	}, requestor)
+	done(err)
+	return err
+}
+
+// SubscribeDecodedLogs - raw logs are recorded, they are decoded again on replay
+func (r *RecordingBackend) SubscribeDecodedLogs(ctx context.Context, events []abi.Event, filter *remote.LogsFilterRequest, cb func(DecodedLog)) error {
+	ids := make([]common.Hash, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	onEvent, done := r.subscription("SubscribeDecodedLogs", []interface{}{ids, filter})
+	err := r.backend.SubscribeDecodedLogs(ctx, events, filter, func(decoded DecodedLog) {
+		onEvent(decoded.Log)
+		cb(decoded)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) SubscribePendingTxs(ctx context.Context, cb func([]types.Transaction)) error {
+	onEvent, done := r.subscription("SubscribePendingTxs", nil)
+	err := r.backend.SubscribePendingTxs(ctx, func(txs []types.Transaction) {
+		encoded := make([]hexutil.Bytes, 0, len(txs))
+		for _, txn := range txs {
+			var buf bytes.Buffer
+			if err := txn.MarshalBinary(&buf); err != nil {
+				log.Warn("backend recording: cannot encode pending tx", "hash", txn.Hash(), "err", err)
+				continue
+			}
+			encoded = append(encoded, buf.Bytes())
+		}
+		onEvent(encoded)
+		cb(txs)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) NodeInfo(ctx context.Context, limit uint32) ([]p2p.NodeInfo, error) {
+	res, err := r.backend.NodeInfo(ctx, limit)
+	r.record("NodeInfo", []interface{}{limit}, []interface{}{res}, err)
+	return res, err
+}
+
+type recordedNodeInfoItem struct {
+	Info  p2p.NodeInfo `json:"info"`
+	Error string       `json:"error,omitempty"`
+}
+
+func (r *RecordingBackend) NodeInfoStream(ctx context.Context, limit uint32) (<-chan NodeInfoItem, error) {
+	onEvent, done := r.subscription("NodeInfoStream", []interface{}{limit})
+	items, err := r.backend.NodeInfoStream(ctx, limit)
+	done(err)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan NodeInfoItem)
+	go func() {
+		defer close(out)
+		for item := range items {
+			onEvent(recordedNodeInfoItem{Info: item.Info, Error: errString(item.Err)})
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (r *RecordingBackend) ListenPorts(ctx context.Context) (p2pTCP, p2pUDP, rpcHTTP, rpcWS int, err error) {
+	p2pTCP, p2pUDP, rpcHTTP, rpcWS, err = r.backend.ListenPorts(ctx)
+	r.record("ListenPorts", nil, []interface{}{p2pTCP, p2pUDP, rpcHTTP, rpcWS}, err)
+	return p2pTCP, p2pUDP, rpcHTTP, rpcWS, err
+}
+
+func (r *RecordingBackend) ReorgHistory(ctx context.Context, limit uint32) ([]ReorgRecord, error) {
+	res, err := r.backend.ReorgHistory(ctx, limit)
+	r.record("ReorgHistory", []interface{}{limit}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) ExecCacheHitRatio(ctx context.Context) (float64, error) {
+	res, err := r.backend.ExecCacheHitRatio(ctx)
+	r.record("ExecCacheHitRatio", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) ExecutionTxRate(ctx context.Context) (float64, error) {
+	res, err := r.backend.ExecutionTxRate(ctx)
+	r.record("ExecutionTxRate", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) Listening(ctx context.Context) (bool, error) {
+	res, err := r.backend.Listening(ctx)
+	r.record("Listening", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SelfNodeInfo(ctx context.Context) (p2p.NodeInfo, error) {
+	res, err := r.backend.SelfNodeInfo(ctx)
+	r.record("SelfNodeInfo", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) StateSyncPeers(ctx context.Context) ([]p2p.NodeInfo, error) {
+	res, err := r.backend.StateSyncPeers(ctx)
+	r.record("StateSyncPeers", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) DiscoveryNetworkName(ctx context.Context) (string, error) {
+	res, err := r.backend.DiscoveryNetworkName(ctx)
+	r.record("DiscoveryNetworkName", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) DialBackoffPeers(ctx context.Context) (map[string]time.Time, error) {
+	res, err := r.backend.DialBackoffPeers(ctx)
+	r.record("DialBackoffPeers", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) AddPeer(ctx context.Context, url string) (bool, error) {
+	res, err := r.backend.AddPeer(ctx, url)
+	r.record("AddPeer", []interface{}{url}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) RemovePeer(ctx context.Context, url string) (bool, error) {
+	res, err := r.backend.RemovePeer(ctx, url)
+	r.record("RemovePeer", []interface{}{url}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
+	res, err := r.backend.Peers(ctx)
+	r.record("Peers", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SubscribeUnwinds(ctx context.Context, cb func(stage string, fromBlock, toBlock uint64)) error {
+	onEvent, done := r.subscription("SubscribeUnwinds", nil)
+	err := r.backend.SubscribeUnwinds(ctx, func(stage string, fromBlock, toBlock uint64) {
+		onEvent(stage, fromBlock, toBlock)
+		cb(stage, fromBlock, toBlock)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) SubscribeFinalized(ctx context.Context, cb func(blockNum uint64, hash common.Hash)) error {
+	onEvent, done := r.subscription("SubscribeFinalized", nil)
+	err := r.backend.SubscribeFinalized(ctx, func(blockNum uint64, hash common.Hash) {
+		onEvent(blockNum, hash)
+		cb(blockNum, hash)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) GasPrice(ctx context.Context) (*big.Int, error) {
+	res, err := r.backend.GasPrice(ctx)
+	r.record("GasPrice", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) GasPriceCap(ctx context.Context) (*big.Int, error) {
+	res, err := r.backend.GasPriceCap(ctx)
+	r.record("GasPriceCap", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) MinGasPrice(ctx context.Context) (*big.Int, error) {
+	res, err := r.backend.MinGasPrice(ctx)
+	r.record("MinGasPrice", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*FeeHistoryResult, error) {
+	res, err := r.backend.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+	r.record("FeeHistory", []interface{}{blockCount, lastBlock, rewardPercentiles}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) HeaderCacheStats(ctx context.Context) (entries, capacity uint64, err error) {
+	entries, capacity, err = r.backend.HeaderCacheStats(ctx)
+	r.record("HeaderCacheStats", nil, []interface{}{entries, capacity}, err)
+	return entries, capacity, err
+}
+
+func (r *RecordingBackend) ReceiptCacheStats(ctx context.Context) (entries, capacity uint64, err error) {
+	entries, capacity, err = r.backend.ReceiptCacheStats(ctx)
+	r.record("ReceiptCacheStats", nil, []interface{}{entries, capacity}, err)
+	return entries, capacity, err
+}
+
+func (r *RecordingBackend) TxpoolTransitions(ctx context.Context, window uint64) (promotions, demotions uint64, err error) {
+	promotions, demotions, err = r.backend.TxpoolTransitions(ctx, window)
+	r.record("TxpoolTransitions", []interface{}{window}, []interface{}{promotions, demotions}, err)
+	return promotions, demotions, err
+}
+
+func (r *RecordingBackend) MaxReceiptSize(ctx context.Context) (uint64, error) {
+	res, err := r.backend.MaxReceiptSize(ctx)
+	r.record("MaxReceiptSize", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) RPCBatchLimit(ctx context.Context) (uint64, error) {
+	res, err := r.backend.RPCBatchLimit(ctx)
+	r.record("RPCBatchLimit", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) ChainConfig(ctx context.Context) (*params.ChainConfig, error) {
+	res, err := r.backend.ChainConfig(ctx)
+	r.record("ChainConfig", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) GenesisBlock(ctx context.Context) (*types.Block, error) {
+	res, err := r.backend.GenesisBlock(ctx)
+	var encoded hexutil.Bytes
+	if err == nil && res != nil {
+		var encodeErr error
+		if encoded, encodeErr = rlp.EncodeToBytes(res); encodeErr != nil {
+			log.Warn("backend recording: cannot encode genesis block", "err", encodeErr)
+		}
+	}
+	r.record("GenesisBlock", nil, []interface{}{encoded}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) PendingBlock(ctx context.Context) (*types.Block, error) {
+	res, err := r.backend.PendingBlock(ctx)
+	var encoded hexutil.Bytes
+	if err == nil && res != nil {
+		var encodeErr error
+		if encoded, encodeErr = rlp.EncodeToBytes(res); encodeErr != nil {
+			log.Warn("backend recording: cannot encode pending block", "err", encodeErr)
+		}
+	}
+	r.record("PendingBlock", nil, []interface{}{encoded}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SyncProgress(ctx context.Context) (*SyncProgress, error) {
+	res, err := r.backend.SyncProgress(ctx)
+	r.record("SyncProgress", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) Mining(ctx context.Context) (bool, error) {
+	res, err := r.backend.Mining(ctx)
+	r.record("Mining", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) HashRate(ctx context.Context) (uint64, error) {
+	res, err := r.backend.HashRate(ctx)
+	r.record("HashRate", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) GetWork(ctx context.Context) ([4]string, error) {
+	res, err := r.backend.GetWork(ctx)
+	r.record("GetWork", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SubmitWork(ctx context.Context, nonce types.BlockNonce, powHash, digest common.Hash) (bool, error) {
+	res, err := r.backend.SubmitWork(ctx, nonce, powHash, digest)
+	r.record("SubmitWork", []interface{}{nonce, powHash, digest}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SubmitHashRate(ctx context.Context, rate uint64, id common.Hash) (bool, error) {
+	res, err := r.backend.SubmitHashRate(ctx, rate, id)
+	r.record("SubmitHashRate", []interface{}{rate, id}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error) {
+	res, err := r.backend.GetReceipts(ctx, blockHash)
+	r.record("GetReceipts", []interface{}{blockHash}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) GetReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	res, err := r.backend.GetReceipt(ctx, txHash)
+	r.record("GetReceipt", []interface{}{txHash}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) BlobSidecars(ctx context.Context, blockHash common.Hash) ([]*BlobSidecar, error) {
+	res, err := r.backend.BlobSidecars(ctx, blockHash)
+	r.record("BlobSidecars", []interface{}{blockHash}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) RebuildIndex(ctx context.Context, name string, from, to uint64) (*IndexRebuild, error) {
+	res, err := r.backend.RebuildIndex(ctx, name, from, to)
+	r.record("RebuildIndex", []interface{}{name, from, to}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) IndexRebuilds(ctx context.Context) ([]*IndexRebuild, error) {
+	res, err := r.backend.IndexRebuilds(ctx)
+	r.record("IndexRebuilds", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SnapshotManifest(ctx context.Context) ([]SnapshotFile, error) {
+	res, err := r.backend.SnapshotManifest(ctx)
+	r.record("SnapshotManifest", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) MaxConcurrentTraces(ctx context.Context) (uint64, error) {
+	res, err := r.backend.MaxConcurrentTraces(ctx)
+	r.record("MaxConcurrentTraces", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) ImportBacklog(ctx context.Context) (uint64, error) {
+	res, err := r.backend.ImportBacklog(ctx)
+	r.record("ImportBacklog", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) AllowedRPCOrigins(ctx context.Context) ([]string, error) {
+	res, err := r.backend.AllowedRPCOrigins(ctx)
+	r.record("AllowedRPCOrigins", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SubscribeMempoolPressure(ctx context.Context, thresholds []float64, cb func(level float64)) error {
+	onEvent, done := r.subscription("SubscribeMempoolPressure", []interface{}{thresholds})
+	err := r.backend.SubscribeMempoolPressure(ctx, thresholds, func(level float64) {
+		onEvent(level)
+		cb(level)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) DBGrowthAlarmThreshold(ctx context.Context) (uint64, error) {
+	res, err := r.backend.DBGrowthAlarmThreshold(ctx)
+	r.record("DBGrowthAlarmThreshold", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) EngineJWTWindow(ctx context.Context) (time.Duration, error) {
+	res, err := r.backend.EngineJWTWindow(ctx)
+	r.record("EngineJWTWindow", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SubscribeStateRootMismatches(ctx context.Context, cb func(blockNum uint64, expected, got common.Hash)) error {
+	onEvent, done := r.subscription("SubscribeStateRootMismatches", nil)
+	err := r.backend.SubscribeStateRootMismatches(ctx, func(blockNum uint64, expected, got common.Hash) {
+		onEvent(blockNum, expected, got)
+		cb(blockNum, expected, got)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) HeaderBatchSize(ctx context.Context) (uint64, error) {
+	res, err := r.backend.HeaderBatchSize(ctx)
+	r.record("HeaderBatchSize", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SubscribePeerBans(ctx context.Context, cb func(enode string, reason string, until time.Time)) error {
+	onEvent, done := r.subscription("SubscribePeerBans", nil)
+	err := r.backend.SubscribePeerBans(ctx, func(enode string, reason string, until time.Time) {
+		onEvent(enode, reason, until)
+		cb(enode, reason, until)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) SubscribePeerEvents(ctx context.Context, cb func(PeerEvent)) error {
+	onEvent, done := r.subscription("SubscribePeerEvents", nil)
+	err := r.backend.SubscribePeerEvents(ctx, func(event PeerEvent) {
+		onEvent(event)
+		cb(event)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) MaxTraceDuration(ctx context.Context) (time.Duration, error) {
+	res, err := r.backend.MaxTraceDuration(ctx)
+	r.record("MaxTraceDuration", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) MissedProposals(ctx context.Context, window uint64) (uint64, error) {
+	res, err := r.backend.MissedProposals(ctx, window)
+	r.record("MissedProposals", []interface{}{window}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SubscribeConfigReloads(ctx context.Context, cb func(ts time.Time, changed []string)) error {
+	onEvent, done := r.subscription("SubscribeConfigReloads", nil)
+	err := r.backend.SubscribeConfigReloads(ctx, func(ts time.Time, changed []string) {
+		onEvent(ts, changed)
+		cb(ts, changed)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) MaxAcceptedReorgDepth(ctx context.Context) (uint64, error) {
+	res, err := r.backend.MaxAcceptedReorgDepth(ctx)
+	r.record("MaxAcceptedReorgDepth", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) GoroutineBreakdown(ctx context.Context) (map[string]uint64, error) {
+	res, err := r.backend.GoroutineBreakdown(ctx)
+	r.record("GoroutineBreakdown", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SubscribeDiskWarnings(ctx context.Context, cb func(freeBytes uint64, threshold uint64)) error {
+	onEvent, done := r.subscription("SubscribeDiskWarnings", nil)
+	err := r.backend.SubscribeDiskWarnings(ctx, func(freeBytes uint64, threshold uint64) {
+		onEvent(freeBytes, threshold)
+		cb(freeBytes, threshold)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) TxPropagationBatchSize(ctx context.Context) (uint64, error) {
+	res, err := r.backend.TxPropagationBatchSize(ctx)
+	r.record("TxPropagationBatchSize", nil, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) TotalSupply(ctx context.Context, blockNum uint64) (*big.Int, error) {
+	res, err := r.backend.TotalSupply(ctx, blockNum)
+	r.record("TotalSupply", []interface{}{blockNum}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) SubscribeExecutionState(ctx context.Context, cb func(paused bool, reason string)) error {
+	onEvent, done := r.subscription("SubscribeExecutionState", nil)
+	err := r.backend.SubscribeExecutionState(ctx, func(paused bool, reason string) {
+		onEvent(paused, reason)
+		cb(paused, reason)
+	})
+	done(err)
+	return err
+}
+
+func (r *RecordingBackend) EngineNewPayloadV1(ctx context.Context, payload *ExecutionPayload) (*PayloadStatus, error) {
+	res, err := r.backend.EngineNewPayloadV1(ctx, payload)
+	r.record("EngineNewPayloadV1", []interface{}{payload}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) EngineForkchoiceUpdatedV1(ctx context.Context, state *ForkChoiceState, attrs *PayloadAttributes) (*ForkChoiceUpdatedResult, error) {
+	res, err := r.backend.EngineForkchoiceUpdatedV1(ctx, state, attrs)
+	r.record("EngineForkchoiceUpdatedV1", []interface{}{state, attrs}, []interface{}{res}, err)
+	return res, err
+}
+
+func (r *RecordingBackend) EngineGetPayloadV1(ctx context.Context, payloadID hexutil.Bytes) (*ExecutionPayload, error) {
+	res, err := r.backend.EngineGetPayloadV1(ctx, payloadID)
+	r.record("EngineGetPayloadV1", []interface{}{payloadID}, []interface{}{res}, err)
+	return res, err
+}
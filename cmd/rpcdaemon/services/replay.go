@@ -0,0 +1,661 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// replayedErrors - sentinel errors which callers check by errors.Is, other errors are replayed by message only
+var replayedErrors = []error{ErrGasPriceUncapped, ErrTotalSupplyDisabled, context.Canceled, context.DeadlineExceeded}
+
+func replayedError(msg string) error {
+	for _, err := range replayedErrors {
+		if err.Error() == msg {
+			return err
+		}
+	}
+	return errors.New(msg)
+}
+
+func decodeValues(data json.RawMessage, values ...interface{}) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != len(values) {
+		return fmt.Errorf("expected %d values, recorded %d", len(values), len(raw))
+	}
+	for i := range raw {
+		if err := json.Unmarshal(raw[i], values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReplayBackend - serves calls recorded by RecordingBackend. Calls are matched by method and arguments,
+// repeated calls get recorded replies in order of recording (last one is repeated when they are over).
+// Subscription events are replayed with the same delays as they were recorded
+type ReplayBackend struct {
+	lock   sync.Mutex
+	calls  map[string][]*RecordedCall
+	served map[string]int
+}
+
+var _ ApiBackend = (*ReplayBackend)(nil)
+
+func NewReplayBackend(path string) (*ReplayBackend, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var recording Recording
+	if err = json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("cannot decode recording %s: %w", path, err)
+	}
+	if recording.Version < 1 || recording.Version > RecordingVersion {
+		return nil, fmt.Errorf("unsupported recording version %d, supported up to %d", recording.Version, RecordingVersion)
+	}
+	r := &ReplayBackend{calls: map[string][]*RecordedCall{}, served: map[string]int{}}
+	for _, call := range recording.Calls {
+		var args bytes.Buffer // recording is indented
+		if err = json.Compact(&args, call.Args); err != nil {
+			return nil, fmt.Errorf("cannot decode recording %s: %w", path, err)
+		}
+		key := call.Method + args.String()
+		r.calls[key] = append(r.calls[key], call)
+	}
+	return r, nil
+}
+
+func (r *ReplayBackend) next(method string, args []interface{}) (*RecordedCall, error) {
+	key := method + string(encodeValues(args))
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	calls := r.calls[key]
+	if len(calls) == 0 {
+		return nil, fmt.Errorf("replay: no recorded %s call with arguments %s", method, encodeValues(args))
+	}
+	i := r.served[key]
+	if i < len(calls)-1 {
+		r.served[key]++
+	}
+	return calls[i], nil
+}
+
+func (r *ReplayBackend) replay(method string, args []interface{}, results ...interface{}) error {
+	call, err := r.next(method, args)
+	if err != nil {
+		return err
+	}
+	if call.Error != "" {
+		return replayedError(call.Error)
+	}
+	if err = decodeValues(call.Result, results...); err != nil {
+		return fmt.Errorf("replay: cannot decode %s results: %w", method, err)
+	}
+	return nil
+}
+
+// play - calls `onEvent` with arguments of each recorded event, keeping recorded delays between them
+func (r *ReplayBackend) play(ctx context.Context, call *RecordedCall, onEvent func(data json.RawMessage) error) error {
+	start := time.Now()
+	for _, event := range call.Events {
+		if wait := event.Offset - time.Since(start); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		if err := onEvent(event.Data); err != nil {
+			return fmt.Errorf("replay: cannot decode %s event: %w", call.Method, err)
+		}
+	}
+	if call.Error != "" {
+		return replayedError(call.Error)
+	}
+	return nil
+}
+
+func (r *ReplayBackend) subscription(ctx context.Context, method string, args []interface{}, onEvent func(data json.RawMessage) error) error {
+	call, err := r.next(method, args)
+	if err != nil {
+		return err
+	}
+	return r.play(ctx, call, onEvent)
+}
+
+func (r *ReplayBackend) Etherbase(context.Context) (res common.Address, err error) {
+	err = r.replay("Etherbase", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) NetVersion(context.Context) (res uint64, err error) {
+	err = r.replay("NetVersion", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) NetPeerCount(context.Context) (res uint64, err error) {
+	err = r.replay("NetPeerCount", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) ProtocolVersion(context.Context) (res uint64, err error) {
+	err = r.replay("ProtocolVersion", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) ClientVersion(context.Context) (res string, err error) {
+	err = r.replay("ClientVersion", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) Subscribe(ctx context.Context, cb func(*remote.SubscribeReply)) error {
+	return r.subscription(ctx, "Subscribe", nil, func(data json.RawMessage) error {
+		reply := &remote.SubscribeReply{}
+		if err := decodeValues(data, reply); err != nil {
+			return err
+		}
+		cb(reply)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) SubscribeTopics(ctx context.Context, topics []remote.Event, cb func(*remote.SubscribeReply)) error {
+	return r.subscription(ctx, "SubscribeTopics", []interface{}{topics}, func(data json.RawMessage) error {
+		reply := &remote.SubscribeReply{}
+		if err := decodeValues(data, reply); err != nil {
+			return err
+		}
+		cb(reply)
+		return nil
+	})
+}
+
+// SubscribeLogs - changes of logs filter sent through `requestor` are ignored: recorded logs are replayed as is
+func (r *ReplayBackend) SubscribeLogs(ctx context.Context, cb func(*remote.SubscribeLogsReply), requestor *atomic.Value) error {
+	requestor.Store(func(*remote.LogsFilterRequest) error { return nil })
+	return r.subscription(ctx, "SubscribeLogs", nil, func(data json.RawMessage) error {
+		reply := &remote.SubscribeLogsReply{}
+		if err := decodeValues(data, reply); err != nil {
+			return err
+		}
+		cb(reply)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) SubscribeDecodedLogs(ctx context.Context, events []abi.Event, filter *remote.LogsFilterRequest, cb func(DecodedLog)) error {
+	ids := make([]common.Hash, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	byID := eventsByID(events)
+	return r.subscription(ctx, "SubscribeDecodedLogs", []interface{}{ids, filter}, func(data json.RawMessage) error {
+		lg := &types.Log{}
+		if err := decodeValues(data, lg); err != nil {
+			return err
+		}
+		cb(decodeLog(byID, lg))
+		return nil
+	})
+}
+
+func (r *ReplayBackend) SubscribePendingTxs(ctx context.Context, cb func([]types.Transaction)) error {
+	return r.subscription(ctx, "SubscribePendingTxs", nil, func(data json.RawMessage) error {
+		var encoded []hexutil.Bytes
+		if err := decodeValues(data, &encoded); err != nil {
+			return err
+		}
+		txs := make([]types.Transaction, 0, len(encoded))
+		for _, rlpTx := range encoded {
+			txn, err := types.DecodeTransaction(rlp.NewStream(bytes.NewReader(rlpTx), uint64(len(rlpTx))))
+			if err != nil {
+				return err
+			}
+			txs = append(txs, txn)
+		}
+		cb(txs)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) NodeInfo(_ context.Context, limit uint32) (res []p2p.NodeInfo, err error) {
+	err = r.replay("NodeInfo", []interface{}{limit}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) NodeInfoStream(ctx context.Context, limit uint32) (<-chan NodeInfoItem, error) {
+	call, err := r.next("NodeInfoStream", []interface{}{limit})
+	if err != nil {
+		return nil, err
+	}
+	if call.Error != "" {
+		// error of the request itself, errors of items are recorded in events
+		return nil, replayedError(call.Error)
+	}
+	items := make(chan NodeInfoItem)
+	go func() {
+		defer close(items)
+		err := r.play(ctx, call, func(data json.RawMessage) error {
+			var item recordedNodeInfoItem
+			if err := decodeValues(data, &item); err != nil {
+				return err
+			}
+			replayed := NodeInfoItem{Info: item.Info}
+			if item.Error != "" {
+				replayed.Err = replayedError(item.Error)
+			}
+			select {
+			case items <- replayed:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && !errors.Is(err, ctx.Err()) {
+			log.Warn("replay of NodeInfoStream", "err", err)
+		}
+	}()
+	return items, nil
+}
+
+func (r *ReplayBackend) ListenPorts(context.Context) (p2pTCP, p2pUDP, rpcHTTP, rpcWS int, err error) {
+	err = r.replay("ListenPorts", nil, &p2pTCP, &p2pUDP, &rpcHTTP, &rpcWS)
+	return p2pTCP, p2pUDP, rpcHTTP, rpcWS, err
+}
+
+func (r *ReplayBackend) ReorgHistory(_ context.Context, limit uint32) (res []ReorgRecord, err error) {
+	err = r.replay("ReorgHistory", []interface{}{limit}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) ExecCacheHitRatio(context.Context) (res float64, err error) {
+	err = r.replay("ExecCacheHitRatio", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) ExecutionTxRate(context.Context) (res float64, err error) {
+	err = r.replay("ExecutionTxRate", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) Listening(context.Context) (res bool, err error) {
+	err = r.replay("Listening", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) SelfNodeInfo(context.Context) (res p2p.NodeInfo, err error) {
+	err = r.replay("SelfNodeInfo", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) StateSyncPeers(context.Context) (res []p2p.NodeInfo, err error) {
+	err = r.replay("StateSyncPeers", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) DiscoveryNetworkName(context.Context) (res string, err error) {
+	err = r.replay("DiscoveryNetworkName", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) DialBackoffPeers(context.Context) (res map[string]time.Time, err error) {
+	err = r.replay("DialBackoffPeers", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) AddPeer(_ context.Context, url string) (res bool, err error) {
+	err = r.replay("AddPeer", []interface{}{url}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) RemovePeer(_ context.Context, url string) (res bool, err error) {
+	err = r.replay("RemovePeer", []interface{}{url}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) Peers(context.Context) (res []*p2p.PeerInfo, err error) {
+	err = r.replay("Peers", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) SubscribeUnwinds(ctx context.Context, cb func(stage string, fromBlock, toBlock uint64)) error {
+	return r.subscription(ctx, "SubscribeUnwinds", nil, func(data json.RawMessage) error {
+		var stage string
+		var fromBlock, toBlock uint64
+		if err := decodeValues(data, &stage, &fromBlock, &toBlock); err != nil {
+			return err
+		}
+		cb(stage, fromBlock, toBlock)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) SubscribeFinalized(ctx context.Context, cb func(blockNum uint64, hash common.Hash)) error {
+	return r.subscription(ctx, "SubscribeFinalized", nil, func(data json.RawMessage) error {
+		var blockNum uint64
+		var hash common.Hash
+		if err := decodeValues(data, &blockNum, &hash); err != nil {
+			return err
+		}
+		cb(blockNum, hash)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) GasPrice(context.Context) (res *big.Int, err error) {
+	err = r.replay("GasPrice", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) GasPriceCap(context.Context) (res *big.Int, err error) {
+	err = r.replay("GasPriceCap", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) MinGasPrice(context.Context) (res *big.Int, err error) {
+	err = r.replay("MinGasPrice", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) FeeHistory(_ context.Context, blockCount uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (res *FeeHistoryResult, err error) {
+	err = r.replay("FeeHistory", []interface{}{blockCount, lastBlock, rewardPercentiles}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) HeaderCacheStats(context.Context) (entries, capacity uint64, err error) {
+	err = r.replay("HeaderCacheStats", nil, &entries, &capacity)
+	return entries, capacity, err
+}
+
+func (r *ReplayBackend) ReceiptCacheStats(context.Context) (entries, capacity uint64, err error) {
+	err = r.replay("ReceiptCacheStats", nil, &entries, &capacity)
+	return entries, capacity, err
+}
+
+func (r *ReplayBackend) TxpoolTransitions(_ context.Context, window uint64) (promotions, demotions uint64, err error) {
+	err = r.replay("TxpoolTransitions", []interface{}{window}, &promotions, &demotions)
+	return promotions, demotions, err
+}
+
+func (r *ReplayBackend) MaxReceiptSize(context.Context) (res uint64, err error) {
+	err = r.replay("MaxReceiptSize", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) RPCBatchLimit(context.Context) (res uint64, err error) {
+	err = r.replay("RPCBatchLimit", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) ChainConfig(context.Context) (res *params.ChainConfig, err error) {
+	err = r.replay("ChainConfig", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) GenesisBlock(context.Context) (*types.Block, error) {
+	var encoded hexutil.Bytes
+	if err := r.replay("GenesisBlock", nil, &encoded); err != nil {
+		return nil, err
+	}
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	genesis := new(types.Block)
+	if err := rlp.DecodeBytes(encoded, genesis); err != nil {
+		return nil, fmt.Errorf("replay: cannot decode genesis block: %w", err)
+	}
+	return genesis, nil
+}
+
+func (r *ReplayBackend) SyncProgress(context.Context) (res *SyncProgress, err error) {
+	err = r.replay("SyncProgress", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) Mining(context.Context) (res bool, err error) {
+	err = r.replay("Mining", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) HashRate(context.Context) (res uint64, err error) {
+	err = r.replay("HashRate", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) GetWork(context.Context) (res [4]string, err error) {
+	err = r.replay("GetWork", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) SubmitWork(_ context.Context, nonce types.BlockNonce, powHash, digest common.Hash) (res bool, err error) {
+	err = r.replay("SubmitWork", []interface{}{nonce, powHash, digest}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) SubmitHashRate(_ context.Context, rate uint64, id common.Hash) (res bool, err error) {
+	err = r.replay("SubmitHashRate", []interface{}{rate, id}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) PendingBlock(context.Context) (*types.Block, error) {
+	var encoded hexutil.Bytes
+	if err := r.replay("PendingBlock", nil, &encoded); err != nil {
+		return nil, err
+	}
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(encoded, block); err != nil {
+		return nil, fmt.Errorf("replay: cannot decode pending block: %w", err)
+	}
+	return block, nil
+}
+
+func (r *ReplayBackend) GetReceipts(_ context.Context, blockHash common.Hash) (res types.Receipts, err error) {
+	err = r.replay("GetReceipts", []interface{}{blockHash}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) GetReceipt(_ context.Context, txHash common.Hash) (res *types.Receipt, err error) {
+	err = r.replay("GetReceipt", []interface{}{txHash}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) BlobSidecars(_ context.Context, blockHash common.Hash) (res []*BlobSidecar, err error) {
+	err = r.replay("BlobSidecars", []interface{}{blockHash}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) RebuildIndex(_ context.Context, name string, from, to uint64) (res *IndexRebuild, err error) {
+	err = r.replay("RebuildIndex", []interface{}{name, from, to}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) IndexRebuilds(context.Context) (res []*IndexRebuild, err error) {
+	err = r.replay("IndexRebuilds", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) SnapshotManifest(context.Context) (res []SnapshotFile, err error) {
+	err = r.replay("SnapshotManifest", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) MaxConcurrentTraces(context.Context) (res uint64, err error) {
+	err = r.replay("MaxConcurrentTraces", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) ImportBacklog(context.Context) (res uint64, err error) {
+	err = r.replay("ImportBacklog", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) AllowedRPCOrigins(context.Context) (res []string, err error) {
+	err = r.replay("AllowedRPCOrigins", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) SubscribeMempoolPressure(ctx context.Context, thresholds []float64, cb func(level float64)) error {
+	return r.subscription(ctx, "SubscribeMempoolPressure", []interface{}{thresholds}, func(data json.RawMessage) error {
+		var level float64
+		if err := decodeValues(data, &level); err != nil {
+			return err
+		}
+		cb(level)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) DBGrowthAlarmThreshold(context.Context) (res uint64, err error) {
+	err = r.replay("DBGrowthAlarmThreshold", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) EngineJWTWindow(context.Context) (res time.Duration, err error) {
+	err = r.replay("EngineJWTWindow", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) SubscribeStateRootMismatches(ctx context.Context, cb func(blockNum uint64, expected, got common.Hash)) error {
+	return r.subscription(ctx, "SubscribeStateRootMismatches", nil, func(data json.RawMessage) error {
+		var blockNum uint64
+		var expected, got common.Hash
+		if err := decodeValues(data, &blockNum, &expected, &got); err != nil {
+			return err
+		}
+		cb(blockNum, expected, got)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) HeaderBatchSize(context.Context) (res uint64, err error) {
+	err = r.replay("HeaderBatchSize", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) SubscribePeerBans(ctx context.Context, cb func(enode string, reason string, until time.Time)) error {
+	return r.subscription(ctx, "SubscribePeerBans", nil, func(data json.RawMessage) error {
+		var enode, reason string
+		var until time.Time
+		if err := decodeValues(data, &enode, &reason, &until); err != nil {
+			return err
+		}
+		cb(enode, reason, until)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) SubscribePeerEvents(ctx context.Context, cb func(PeerEvent)) error {
+	return r.subscription(ctx, "SubscribePeerEvents", nil, func(data json.RawMessage) error {
+		var event PeerEvent
+		if err := decodeValues(data, &event); err != nil {
+			return err
+		}
+		cb(event)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) MaxTraceDuration(context.Context) (res time.Duration, err error) {
+	err = r.replay("MaxTraceDuration", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) MissedProposals(_ context.Context, window uint64) (res uint64, err error) {
+	err = r.replay("MissedProposals", []interface{}{window}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) SubscribeConfigReloads(ctx context.Context, cb func(ts time.Time, changed []string)) error {
+	return r.subscription(ctx, "SubscribeConfigReloads", nil, func(data json.RawMessage) error {
+		var ts time.Time
+		var changed []string
+		if err := decodeValues(data, &ts, &changed); err != nil {
+			return err
+		}
+		cb(ts, changed)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) MaxAcceptedReorgDepth(context.Context) (res uint64, err error) {
+	err = r.replay("MaxAcceptedReorgDepth", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) GoroutineBreakdown(context.Context) (res map[string]uint64, err error) {
+	err = r.replay("GoroutineBreakdown", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) SubscribeDiskWarnings(ctx context.Context, cb func(freeBytes uint64, threshold uint64)) error {
+	return r.subscription(ctx, "SubscribeDiskWarnings", nil, func(data json.RawMessage) error {
+		var freeBytes, threshold uint64
+		if err := decodeValues(data, &freeBytes, &threshold); err != nil {
+			return err
+		}
+		cb(freeBytes, threshold)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) TxPropagationBatchSize(context.Context) (res uint64, err error) {
+	err = r.replay("TxPropagationBatchSize", nil, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) TotalSupply(_ context.Context, blockNum uint64) (res *big.Int, err error) {
+	err = r.replay("TotalSupply", []interface{}{blockNum}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) SubscribeExecutionState(ctx context.Context, cb func(paused bool, reason string)) error {
+	return r.subscription(ctx, "SubscribeExecutionState", nil, func(data json.RawMessage) error {
+		var paused bool
+		var reason string
+		if err := decodeValues(data, &paused, &reason); err != nil {
+			return err
+		}
+		cb(paused, reason)
+		return nil
+	})
+}
+
+func (r *ReplayBackend) EngineNewPayloadV1(_ context.Context, payload *ExecutionPayload) (res *PayloadStatus, err error) {
+	err = r.replay("EngineNewPayloadV1", []interface{}{payload}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) EngineForkchoiceUpdatedV1(_ context.Context, state *ForkChoiceState, attrs *PayloadAttributes) (res *ForkChoiceUpdatedResult, err error) {
+	err = r.replay("EngineForkchoiceUpdatedV1", []interface{}{state, attrs}, &res)
+	return res, err
+}
+
+func (r *ReplayBackend) EngineGetPayloadV1(_ context.Context, payloadID hexutil.Bytes) (res *ExecutionPayload, err error) {
+	err = r.replay("EngineGetPayloadV1", []interface{}{payloadID}, &res)
+	return res, err
+}
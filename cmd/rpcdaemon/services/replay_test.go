@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	genesis := types.NewBlock(&types.Header{Number: big.NewInt(0), GasLimit: 5000, Difficulty: big.NewInt(1)}, nil, nil, nil)
+	genesisRlp, err := rlp.EncodeToBytes(genesis)
+	require.NoError(t, err)
+	const spacing = 100 * time.Millisecond
+
+	back := newTestRemoteBackend(t, &mockEthBackend{
+		replies: map[string]func(json.RawMessage) (interface{}, error){
+			"ChainConfig":  replyWith(params.GoerliChainConfig),
+			"GenesisBlock": replyWith(hexutil.Bytes(genesisRlp)),
+			"GasPriceCap":  replyWith((*hexutil.Big)(nil)),
+			"TxpoolTransitions": func(args json.RawMessage) (interface{}, error) {
+				var req txpoolTransitionsRequest
+				if err := json.Unmarshal(args, &req); err != nil {
+					return nil, err
+				}
+				return txpoolTransitionsReply{Promotions: req.Window * 2, Demotions: req.Window}, nil
+			},
+		},
+		streams: map[string]func(json.RawMessage, func(interface{}) error) error{
+			"SubscribeFinalized": func(_ json.RawMessage, send func(interface{}) error) error {
+				if err := send(finalizedEvent{BlockNumber: 1, BlockHash: common.HexToHash("0x01")}); err != nil {
+					return err
+				}
+				time.Sleep(spacing)
+				return send(finalizedEvent{BlockNumber: 2, BlockHash: common.HexToHash("0x02")})
+			},
+		},
+		netVersion: func() (*remote.NetVersionReply, error) { return &remote.NetVersionReply{Id: 5}, nil },
+	})
+
+	// record
+	rec := NewRecordingBackend(back)
+	ctx := context.Background()
+	_, err = rec.NetVersion(ctx)
+	require.NoError(t, err)
+	_, err = rec.ChainConfig(ctx)
+	require.NoError(t, err)
+	_, err = rec.GenesisBlock(ctx)
+	require.NoError(t, err)
+	_, err = rec.GasPriceCap(ctx)
+	require.ErrorIs(t, err, ErrGasPriceUncapped)
+	_, _, err = rec.TxpoolTransitions(ctx, 10)
+	require.NoError(t, err)
+	_, _, err = rec.TxpoolTransitions(ctx, 20)
+	require.NoError(t, err)
+	require.NoError(t, rec.SubscribeFinalized(ctx, func(uint64, common.Hash) {}))
+	path := filepath.Join(t.TempDir(), "recording.json")
+	require.NoError(t, rec.Save(path))
+
+	// replay
+	replay, err := NewReplayBackend(path)
+	require.NoError(t, err)
+	id, err := replay.NetVersion(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), id)
+	cfg, err := replay.ChainConfig(ctx)
+	require.NoError(t, err)
+	require.Equal(t, params.GoerliChainConfig.ChainID, cfg.ChainID)
+	block, err := replay.GenesisBlock(ctx)
+	require.NoError(t, err)
+	require.Equal(t, genesis.Hash(), block.Hash())
+	_, err = replay.GasPriceCap(ctx)
+	require.ErrorIs(t, err, ErrGasPriceUncapped)
+	promotions, demotions, err := replay.TxpoolTransitions(ctx, 20)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{40, 20}, []uint64{promotions, demotions})
+	promotions, demotions, err = replay.TxpoolTransitions(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{20, 10}, []uint64{promotions, demotions})
+	_, _, err = replay.TxpoolTransitions(ctx, 30)
+	require.Error(t, err)
+
+	var finalized []uint64
+	var times []time.Time
+	require.NoError(t, replay.SubscribeFinalized(ctx, func(blockNum uint64, hash common.Hash) {
+		require.Equal(t, common.BigToHash(new(big.Int).SetUint64(blockNum)), hash)
+		finalized = append(finalized, blockNum)
+		times = append(times, time.Now())
+	}))
+	require.Equal(t, []uint64{1, 2}, finalized)
+	require.GreaterOrEqual(t, times[1].Sub(times[0]), spacing*9/10)
+}
+
+func TestReplayVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version":100,"calls":[]}`), 0600))
+	_, err := NewReplayBackend(path)
+	require.ErrorContains(t, err, "unsupported recording version")
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy - retries of idempotent unary calls which failed with transient error (for example during core
+// restart), see idempotentMethods
+type RetryPolicy struct {
+	MaxAttempts int           // total amount of attempts, values less than 2 mean "no retries"
+	BaseDelay   time.Duration // delay before 2nd attempt, doubled on each next attempt
+	MaxDelay    time.Duration // 0 means no limit
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 3, BaseDelay: 200 * time.Millisecond, MaxDelay: 2 * time.Second}
+}
+
+// idempotentMethods - unary calls which only read state of the node, it's safe to repeat them. Calls not listed here
+// (ones changing the node, like SubmitWork, RebuildIndex, AddPeer or txpool Add, and any method added later) are
+// never repeated
+var idempotentMethods = map[string]bool{
+	"/remote.KV/Version": true,
+
+	ethBackendMethodPrefix + "Version":         true,
+	ethBackendMethodPrefix + "Etherbase":       true,
+	ethBackendMethodPrefix + "NetVersion":      true,
+	ethBackendMethodPrefix + "NetPeerCount":    true,
+	ethBackendMethodPrefix + "ProtocolVersion": true,
+	ethBackendMethodPrefix + "ClientVersion":   true,
+	ethBackendMethodPrefix + "NodeInfo":        true,
+
+	ethBackendMethodPrefix + "AllowedRPCOrigins":      true,
+	ethBackendMethodPrefix + "BlobSidecars":           true,
+	ethBackendMethodPrefix + "ChainConfig":            true,
+	ethBackendMethodPrefix + "DBGrowthAlarmThreshold": true,
+	ethBackendMethodPrefix + "DialBackoffPeers":       true,
+	ethBackendMethodPrefix + "DiscoveryNetworkName":   true,
+	ethBackendMethodPrefix + "EngineJWTWindow":        true,
+	ethBackendMethodPrefix + "ExecCacheHitRatio":      true,
+	ethBackendMethodPrefix + "ExecutionTxRate":        true,
+	ethBackendMethodPrefix + "FeeHistory":             true,
+	ethBackendMethodPrefix + "GasPrice":               true,
+	ethBackendMethodPrefix + "GasPriceCap":            true,
+	ethBackendMethodPrefix + "GenesisBlock":           true,
+	ethBackendMethodPrefix + "GetReceipt":             true,
+	ethBackendMethodPrefix + "GetReceipts":            true,
+	ethBackendMethodPrefix + "GetWork":                true,
+	ethBackendMethodPrefix + "GoroutineBreakdown":     true,
+	ethBackendMethodPrefix + "HashRate":               true,
+	ethBackendMethodPrefix + "HeaderBatchSize":        true,
+	ethBackendMethodPrefix + "HeaderCacheStats":       true,
+	ethBackendMethodPrefix + "IndexRebuilds":          true,
+	ethBackendMethodPrefix + "ListenPorts":            true,
+	ethBackendMethodPrefix + "Listening":              true,
+	ethBackendMethodPrefix + "MaxAcceptedReorgDepth":  true,
+	ethBackendMethodPrefix + "MaxConcurrentTraces":    true,
+	ethBackendMethodPrefix + "MaxReceiptSize":         true,
+	ethBackendMethodPrefix + "MaxTraceDuration":       true,
+	ethBackendMethodPrefix + "MinGasPrice":            true,
+	ethBackendMethodPrefix + "Mining":                 true,
+	ethBackendMethodPrefix + "MissedProposals":        true,
+	ethBackendMethodPrefix + "PendingBlock":           true,
+	ethBackendMethodPrefix + "RPCBatchLimit":          true,
+	ethBackendMethodPrefix + "ReceiptCacheStats":      true,
+	ethBackendMethodPrefix + "ReorgHistory":           true,
+	ethBackendMethodPrefix + "SelfNodeInfo":           true,
+	ethBackendMethodPrefix + "SnapshotManifest":       true,
+	ethBackendMethodPrefix + "StageProgress":          true,
+	ethBackendMethodPrefix + "StateSyncPeers":         true,
+	ethBackendMethodPrefix + "SyncProgress":           true,
+	ethBackendMethodPrefix + "TotalSupply":            true,
+	ethBackendMethodPrefix + "TxPropagationBatchSize": true,
+	ethBackendMethodPrefix + "TxpoolTransitions":      true,
+
+	"/txpool.Txpool/Version":      true,
+	"/txpool.Txpool/FindUnknown":  true,
+	"/txpool.Txpool/Transactions": true,
+	"/txpool.Txpool/All":          true,
+	"/txpool.Txpool/Status":       true,
+	"/txpool.Txpool/Nonce":        true,
+
+	"/txpool.Mining/Version":  true,
+	"/txpool.Mining/GetWork":  true,
+	"/txpool.Mining/HashRate": true,
+	"/txpool.Mining/Mining":   true,
+}
+
+func isTransient(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	return s.Code() == codes.Unavailable || s.Code() == codes.Aborted
+}
+
+// retryConn - retries only idempotent unary calls (Invoke), streams (NewStream) are passed as is:
+// re-establishing of subscriptions is responsibility of their owners
+type retryConn struct {
+	grpc.ClientConnInterface
+	policy RetryPolicy
+}
+
+func (c *retryConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	delay := c.policy.BaseDelay
+	for attempt := 1; ; attempt++ {
+		err := c.ClientConnInterface.Invoke(ctx, method, args, reply, opts...)
+		if err == nil || attempt >= c.policy.MaxAttempts || !isTransient(err) || !idempotentMethods[method] {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if c.policy.MaxDelay > 0 && delay > c.policy.MaxDelay {
+			delay = c.policy.MaxDelay
+		}
+	}
+}
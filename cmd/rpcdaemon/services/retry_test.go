@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func failingPeerCount(failures int, code codes.Code, calls *int) func(context.Context) (*remote.NetPeerCountReply, error) {
+	return func(context.Context) (*remote.NetPeerCountReply, error) {
+		*calls++
+		if *calls <= failures {
+			return nil, status.Error(code, "core is restarting")
+		}
+		return &remote.NetPeerCountReply{Count: 42}, nil
+	}
+}
+
+func TestRetryTransient(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	var calls int
+	back := newTestRemoteBackend(t, &mockEthBackend{netPeerCount: failingPeerCount(2, codes.Unavailable, &calls)}, WithRetry(policy))
+	count, err := back.NetPeerCount(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), count)
+	require.Equal(t, 3, calls)
+
+	calls = 0
+	back = newTestRemoteBackend(t, &mockEthBackend{netPeerCount: failingPeerCount(3, codes.Aborted, &calls)}, WithRetry(policy))
+	_, err = back.NetPeerCount(context.Background())
+	require.Error(t, err)
+	require.Equal(t, 3, calls)
+}
+
+func TestRetrySkipsPermanentErrors(t *testing.T) {
+	for _, code := range []codes.Code{codes.InvalidArgument, codes.NotFound} {
+		var calls int
+		back := newTestRemoteBackend(t, &mockEthBackend{netPeerCount: failingPeerCount(1, code, &calls)}, WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+		_, err := back.NetPeerCount(context.Background())
+		require.Error(t, err)
+		require.Equal(t, 1, calls, code.String())
+	}
+}
+
+func TestRetryDoesNotRepeatSubscribe(t *testing.T) {
+	var calls int
+	back := newTestRemoteBackend(t, &mockEthBackend{subscribe: func(*remote.SubscribeRequest, remote.ETHBACKEND_SubscribeServer) error {
+		calls++
+		return status.Error(codes.Unavailable, "core is restarting")
+	}}, WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+
+	require.Error(t, back.Subscribe(context.Background(), func(*remote.SubscribeReply) {}))
+	require.Equal(t, 1, calls)
+}
+
+func TestRetryDoesNotRepeatMutatingCalls(t *testing.T) {
+	var calls int
+	back := newTestRemoteBackend(t, &mockEthBackend{replies: map[string]func(json.RawMessage) (interface{}, error){
+		"SubmitWork": func(json.RawMessage) (interface{}, error) {
+			calls++
+			return nil, status.Error(codes.Unavailable, "core is restarting")
+		},
+	}}, WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+
+	_, err := back.SubmitWork(context.Background(), types.BlockNonce{}, common.Hash{}, common.Hash{})
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+}
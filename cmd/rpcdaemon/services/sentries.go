@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon/p2p"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// sentryMethodPrefix - full name prefix of Sentry service methods. Peer management methods are not part of generated
+// sentry.SentryClient, they are invoked by name like ETHBACKEND ones
+const sentryMethodPrefix = "/sentry.Sentry/"
+
+// ErrNoSentries - returned by Peers when RemoteBackend was created without WithSentries
+var ErrNoSentries = errors.New("peers are known only to sentries, set --sentry.api.addr")
+
+// Peers - peers connected to every sentry, sorted by ID. Peer connected to several sentries is listed once
+func (back *RemoteBackend) Peers(ctx context.Context) ([]*p2p.PeerInfo, error) {
+	if len(back.sentries) == 0 {
+		return nil, ErrNoSentries
+	}
+	seen := map[string]struct{}{}
+	peers := []*p2p.PeerInfo{}
+	for i, cc := range back.sentries {
+		var res []*p2p.PeerInfo
+		if err := invokeByName(ctx, cc, sentryMethodPrefix, "PeersInfo", nil, &res); err != nil {
+			return nil, fmt.Errorf("peers request to sentry %d error: %w", i, err)
+		}
+		for _, peer := range res {
+			if peer == nil {
+				continue
+			}
+			if _, ok := seen[peer.ID]; ok {
+				continue
+			}
+			seen[peer.ID] = struct{}{}
+			peers = append(peers, peer)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].ID < peers[j].ID })
+	return peers, nil
+}
+
+// changeSentryPeers - AddPeer or RemovePeer on every sentry, true if it changed peers of any of them
+func (back *RemoteBackend) changeSentryPeers(ctx context.Context, method string, url string) (bool, error) {
+	var changed bool
+	for i, cc := range back.sentries {
+		var res bool
+		if err := invokeByName(ctx, cc, sentryMethodPrefix, method, peerRequest{URL: url}, &res); err != nil {
+			return false, fmt.Errorf("%s request to sentry %d error: %w", method, i, err)
+		}
+		changed = changed || res
+	}
+	return changed, nil
+}
+
+// sentryNodeInfo - SelfNodeInfo asked from the first sentry
+func (back *RemoteBackend) sentryNodeInfo(ctx context.Context) (p2p.NodeInfo, error) {
+	res, err := back.sentriesNodeInfo(ctx, 1)
+	if err != nil {
+		return p2p.NodeInfo{}, fmt.Errorf("sentry node info request error: %w", err)
+	}
+	return back.decodeNodeInfo(ctx, res[0])
+}
+
+// sentriesNodeInfo - NodeInfo of sentries set by WithSentries, at most `limit` of them (0 - all)
+func (back *RemoteBackend) sentriesNodeInfo(ctx context.Context, limit uint32) ([]*types2.NodeInfoReply, error) {
+	sentries := back.sentries
+	if limit > 0 && int(limit) < len(sentries) {
+		sentries = sentries[:limit]
+	}
+	nodes := make([]*types2.NodeInfoReply, 0, len(sentries))
+	for i, cc := range sentries {
+		res, err := sentry.NewSentryClient(cc).NodeInfo(ctx, &emptypb.Empty{})
+		if err != nil {
+			return nil, fmt.Errorf("sentry %d: %w", i, err)
+		}
+		nodes = append(nodes, res)
+	}
+	return nodes, nil
+}
+
+// Types of PeerEvent
+const (
+	PeerEventConnect         = "connect"
+	PeerEventDisconnect      = "disconnect"
+	PeerEventHandshakeFailed = "handshakeFailed"
+)
+
+// PeerEvent - change of connection of some sentry to peer. Error is set for failed handshake and for disconnect caused by error
+type PeerEvent struct {
+	Type   string    `json:"type"`
+	ID     string    `json:"id"`
+	Enode  string    `json:"enode"`
+	Name   string    `json:"name,omitempty"`
+	Remote string    `json:"remoteAddress,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// SubscribePeerEvents - notifies about peers connecting to and disconnecting from each sentry, `onEvent` is not called
+// concurrently. Returns when stream of any sentry ends, or ctx.Err() when `ctx` is done
+func (back *RemoteBackend) SubscribePeerEvents(parent context.Context, onEvent func(PeerEvent)) error {
+	if len(back.sentries) == 0 {
+		return ErrNoSentries
+	}
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	var lock sync.Mutex
+	errs := make(chan error, len(back.sentries))
+	for i, cc := range back.sentries {
+		go func(i int, cc grpc.ClientConnInterface) {
+			err := back.subscribeByName(ctx, cc, sentryMethodPrefix, "SubscribePeerEvents", nil, func(data []byte) error {
+				var event PeerEvent
+				if err := json.Unmarshal(data, &event); err != nil {
+					return err
+				}
+				lock.Lock()
+				defer lock.Unlock()
+				onEvent(event)
+				return nil
+			})
+			if err != nil {
+				err = fmt.Errorf("sentry %d: %w", i, err)
+			}
+			errs <- err
+		}(i, cc)
+	}
+	err := <-errs
+	cancel()
+	for range back.sentries[1:] {
+		<-errs
+	}
+	if parent.Err() != nil {
+		return parent.Err()
+	}
+	return err
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// mockSentry - serves generated Sentry methods through embedded server and methods invoked by name through `replies`
+type mockSentry struct {
+	sentry.UnimplementedSentryServer
+	replies  map[string]func(args json.RawMessage) (interface{}, error)
+	streams  map[string]func(args json.RawMessage, send func(event interface{}) error) error
+	nodeInfo *types2.NodeInfoReply
+}
+
+func (s *mockSentry) NodeInfo(ctx context.Context, r *emptypb.Empty) (*types2.NodeInfoReply, error) {
+	if s.nodeInfo == nil {
+		return s.UnimplementedSentryServer.NodeInfo(ctx, r)
+	}
+	return s.nodeInfo, nil
+}
+
+func newTestSentryConn(t *testing.T, srv *mockSentry) *grpc.ClientConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		return serveByName(stream, sentryMethodPrefix, srv.replies, srv.streams)
+	}))
+	sentry.RegisterSentryServer(server, srv)
+	listener := bufconn.Listen(1024 * 1024)
+	go func() { _ = server.Serve(listener) }()
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		cancel()
+		conn.Close()
+		server.Stop()
+	})
+	return conn
+}
+
+func TestSentryPeers(t *testing.T) {
+	const url = "enode://a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c@52.16.188.185:30303"
+	added := make([]map[string]bool, 2)
+	newSentry := func(i int, peers ...string) *mockSentry {
+		added[i] = map[string]bool{}
+		changePeers := func(add bool) func(json.RawMessage) (interface{}, error) {
+			return func(args json.RawMessage) (interface{}, error) {
+				var req peerRequest
+				if err := json.Unmarshal(args, &req); err != nil {
+					return nil, err
+				}
+				changed := added[i][req.URL] != add
+				added[i][req.URL] = add
+				return changed, nil
+			}
+		}
+		infos := make([]*p2p.PeerInfo, len(peers))
+		for j, id := range peers {
+			infos[j] = &p2p.PeerInfo{ID: id, Name: "erigon"}
+		}
+		return &mockSentry{replies: map[string]func(json.RawMessage) (interface{}, error){
+			"PeersInfo":  replyWith(infos),
+			"AddPeer":    changePeers(true),
+			"RemovePeer": changePeers(false),
+		}, nodeInfo: &types2.NodeInfoReply{Id: "self", Enode: url, Ports: &types2.NodeInfoPorts{Listener: 30303}, Protocols: []byte(`{"eth":{}}`)}}
+	}
+	// node itself doesn't know peers
+	back := newTestRemoteBackend(t, &mockEthBackend{}, WithSentries(newTestSentryConn(t, newSentry(0, "cc", "aa")), newTestSentryConn(t, newSentry(1, "bb", "aa"))))
+	ctx := context.Background()
+
+	peers, err := back.Peers(ctx)
+	require.NoError(t, err)
+	var ids []string
+	for _, peer := range peers {
+		ids = append(ids, peer.ID)
+	}
+	require.Equal(t, []string{"aa", "bb", "cc"}, ids)
+
+	changed, err := back.AddPeer(ctx, url)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.True(t, added[0][url] && added[1][url], "peer is added to every sentry")
+	added[1][url] = false
+	changed, err = back.AddPeer(ctx, url)
+	require.NoError(t, err)
+	require.True(t, changed, "changed on one of sentries")
+	changed, err = back.RemovePeer(ctx, url)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.False(t, added[0][url] || added[1][url])
+
+	self, err := back.SelfNodeInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "self", self.ID)
+	require.Equal(t, 30303, self.Ports.Listener)
+
+	nodes, err := back.NodeInfo(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, nodes, 2, "info of every sentry")
+	require.Equal(t, "52.16.188.185", nodes[1].IP)
+	nodes, err = back.NodeInfo(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+
+	_, err = newTestRemoteBackend(t, &mockEthBackend{}).Peers(ctx)
+	require.ErrorIs(t, err, ErrNoSentries)
+}
+
+func TestSubscribePeerEvents(t *testing.T) {
+	newSentry := func(events ...PeerEvent) *mockSentry {
+		return &mockSentry{streams: map[string]func(json.RawMessage, func(interface{}) error) error{
+			"SubscribePeerEvents": func(_ json.RawMessage, send func(interface{}) error) error {
+				for _, event := range events {
+					if err := send(event); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		}}
+	}
+	back := newTestRemoteBackend(t, &mockEthBackend{}, WithSentries(newTestSentryConn(t, newSentry(
+		PeerEvent{Type: PeerEventConnect, ID: "aa"},
+		PeerEvent{Type: PeerEventDisconnect, ID: "aa", Error: "useless peer"},
+	))))
+
+	var events []PeerEvent
+	require.NoError(t, back.SubscribePeerEvents(context.Background(), func(event PeerEvent) { events = append(events, event) }))
+	require.Equal(t, []PeerEvent{{Type: PeerEventConnect, ID: "aa"}, {Type: PeerEventDisconnect, ID: "aa", Error: "useless peer"}}, events)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	blocking := &mockSentry{streams: map[string]func(json.RawMessage, func(interface{}) error) error{
+		"SubscribePeerEvents": func(_ json.RawMessage, send func(interface{}) error) error {
+			if err := send(PeerEvent{Type: PeerEventHandshakeFailed, ID: "bb"}); err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return nil
+		},
+	}}
+	back = newTestRemoteBackend(t, &mockEthBackend{}, WithSentries(newTestSentryConn(t, blocking), newTestSentryConn(t, blocking)))
+	var failed int
+	err := back.SubscribePeerEvents(ctx, func(event PeerEvent) {
+		if failed++; failed == 2 {
+			cancel() // both sentries sent their event
+		}
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 2, failed)
+
+	require.ErrorIs(t, newTestRemoteBackend(t, &mockEthBackend{}).SubscribePeerEvents(context.Background(), func(PeerEvent) {}), ErrNoSentries)
+}
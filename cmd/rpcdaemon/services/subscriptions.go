@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrBackendClosed = errors.New("remote backend is closed")
+
+// subscriptions - cancel functions of active subscriptions, all of them are cancelled by RemoteBackend.Close
+type subscriptions struct {
+	lock    sync.Mutex
+	closed  bool
+	nextID  uint64
+	cancels map[uint64]context.CancelFunc
+}
+
+// add - returns context of new subscription and function which must be called when subscription is over
+func (s *subscriptions) add(ctx context.Context) (context.Context, func(), error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return nil, nil, ErrBackendClosed
+	}
+	if s.cancels == nil {
+		s.cancels = map[uint64]context.CancelFunc{}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	id := s.nextID
+	s.nextID++
+	s.cancels[id] = cancel
+	return ctx, func() {
+		cancel()
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		delete(s.cancels, id)
+	}, nil
+}
+
+func (s *subscriptions) closeAll() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	for _, cancel := range s.cancels {
+		cancel()
+	}
+	s.cancels = nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestRemoteBackendConcurrentUse(t *testing.T) {
+	back := newTestRemoteBackend(t, &mockEthBackend{
+		netVersion: func() (*remote.NetVersionReply, error) { return &remote.NetVersionReply{Id: 1}, nil },
+		subscribe: func(_ *remote.SubscribeRequest, server remote.ETHBACKEND_SubscribeServer) error {
+			for {
+				if err := server.Send(&remote.SubscribeReply{Type: remote.Event_HEADER}); err != nil {
+					return err
+				}
+				select {
+				case <-server.Context().Done():
+					return nil
+				case <-time.After(time.Millisecond):
+				}
+			}
+		},
+	})
+
+	var wg sync.WaitGroup
+	var events uint64
+	started := make(chan struct{})
+	for i := 0; i < 20; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			<-started
+			for j := 0; j < 20; j++ {
+				if id, err := back.NetVersion(context.Background()); err != nil || id != 1 {
+					t.Errorf("unexpected NetVersion: %d, %v", id, err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			<-started
+			// ends when backend is closed, or fails if is started after that
+			_ = back.Subscribe(context.Background(), func(*remote.SubscribeReply) { atomic.AddUint64(&events, 1) })
+		}()
+		go func() {
+			defer wg.Done()
+			<-started
+			back.OnStateChange(func(connectivity.State) {})
+		}()
+	}
+	close(started)
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			back.Close()
+		}()
+	}
+	wg.Wait()
+
+	require.NotZero(t, atomic.LoadUint64(&events))
+	require.ErrorIs(t, back.Subscribe(context.Background(), func(*remote.SubscribeReply) {}), ErrBackendClosed)
+	id, err := back.NetVersion(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), id)
+}
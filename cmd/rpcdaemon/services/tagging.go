@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common/ctxutil"
+	"github.com/ledgerwatch/log/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// taggingConn - passes request id from context to the server in gRPC metadata and logs each call with it
+type taggingConn struct {
+	grpc.ClientConnInterface
+	log log.Logger
+}
+
+// withRequestID - adds request id to outgoing metadata, unless it's there already
+func withRequestID(ctx context.Context) context.Context {
+	id := ctxutil.RequestID(ctx)
+	if id == "" {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(ctxutil.RequestIDKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, ctxutil.RequestIDKey, id)
+}
+
+// requestIDOptions - interceptors passing request id in metadata of all calls of the connection (KV, txpool, mining),
+// not only of ones made through RemoteBackend
+func requestIDOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			return invoker(withRequestID(ctx), method, req, reply, cc, opts...)
+		}),
+		grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return streamer(withRequestID(ctx), desc, cc, method, opts...)
+		}),
+	}
+}
+
+func loggerFor(ctx context.Context, logger log.Logger) log.Logger {
+	if id := ctxutil.RequestID(ctx); id != "" {
+		return logger.New("requestId", id)
+	}
+	return logger
+}
+
+func (c *taggingConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := c.ClientConnInterface.Invoke(withRequestID(ctx), method, args, reply, opts...)
+	loggerFor(ctx, c.log).Trace("backend call", "method", method, "took", time.Since(start), "err", err)
+	return err
+}
+
+func (c *taggingConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	loggerFor(ctx, c.log).Trace("backend stream", "method", method)
+	return c.ClientConnInterface.NewStream(withRequestID(ctx), desc, method, opts...)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon/common/ctxutil"
+	"github.com/ledgerwatch/log/v3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestRequestIDPropagation(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		records []*log.Record
+	)
+	logger := log.New()
+	logger.SetHandler(log.FuncHandler(func(r *log.Record) error {
+		lock.Lock()
+		defer lock.Unlock()
+		records = append(records, r)
+		return nil
+	}))
+
+	var serverSide []string
+	back := newTestRemoteBackend(t, &mockEthBackend{netPeerCount: func(ctx context.Context) (*remote.NetPeerCountReply, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		serverSide = md.Get(ctxutil.RequestIDKey)
+		return &remote.NetPeerCountReply{Count: 1}, nil
+	}}, WithLogger(logger))
+
+	_, err := back.NetPeerCount(ctxutil.WithRequestID(context.Background(), "req-42"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"req-42"}, serverSide)
+
+	lock.Lock()
+	require.NotEmpty(t, records)
+	require.Contains(t, records[len(records)-1].Ctx, "req-42")
+	lock.Unlock()
+
+	_, err = back.NetPeerCount(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, serverSide)
+}
+
+func TestRequestIDInterceptors(t *testing.T) {
+	var serverSide []string
+	server := grpc.NewServer()
+	remote.RegisterETHBACKENDServer(server, &mockEthBackend{netPeerCount: func(ctx context.Context) (*remote.NetPeerCountReply, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		serverSide = md.Get(ctxutil.RequestIDKey)
+		return &remote.NetPeerCountReply{Count: 1}, nil
+	}})
+	listener := bufconn.Listen(1024 * 1024)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+	cc, err := grpc.Dial("", append(DialOptions(nil, ConnOptions{Keepalive: KeepaliveParams(time.Minute, time.Minute), MaxMsgSize: DefaultMaxMsgSize}),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }))...)
+	require.NoError(t, err)
+	defer cc.Close()
+	ctx := ctxutil.WithRequestID(context.Background(), "req-43")
+
+	// plain clients of the connection, as KV and txpool ones
+	_, err = remote.NewETHBACKENDClient(cc).NetPeerCount(ctx, &remote.NetPeerCountRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"req-43"}, serverSide)
+	// not duplicated by RemoteBackend
+	_, err = NewRemoteBackend(cc).NetPeerCount(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"req-43"}, serverSide)
+}
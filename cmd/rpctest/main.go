@@ -252,6 +252,16 @@ func main() {
 	}
 	with(replayCmd, withErigonUrl, withRecord)
 
+	var replayRecordingCmd = &cobra.Command{
+		Use:   "replayRecording",
+		Short: "Replay calls recorded by rpcdaemon --rpc.record, comparing results and latencies with the recorded ones",
+		Long:  ``,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rpctest.ReplayRecording(erigonURL, recordFile, errorFile)
+		},
+	}
+	with(replayRecordingCmd, withErigonUrl, withRecord, withErrorFile)
+
 	var tmpDataDir, tmpDataDirOrig string
 	var notRegenerateGethData bool
 	var compareAccountRange = &cobra.Command{
@@ -294,6 +304,7 @@ func main() {
 		compareAccountRange,
 		benchTraceReplayTransactionCmd,
 		replayCmd,
+		replayRecordingCmd,
 	)
 	if err := rootCmd.ExecuteContext(rootContext()); err != nil {
 		fmt.Println(err)
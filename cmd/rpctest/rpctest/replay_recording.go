@@ -0,0 +1,144 @@
+package rpctest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ledgerwatch/erigon/rpc"
+	"github.com/valyala/fastjson"
+)
+
+// replayStats - calls of one method replayed by ReplayRecording, latencies in milliseconds
+type replayStats struct {
+	calls, mismatches  int
+	recorded, replayed []float64
+}
+
+// ReplayRecording - sends calls recorded by `rpcdaemon --rpc.record` to erigonURL in order they were served, compares
+// results and errors with the recorded ones and prints latencies of both by methods. Mismatches are written to
+// errorFile (if set), replay goes on after them
+func ReplayRecording(erigonURL string, recordFile string, errorFile string) error {
+	setRoutes(erigonURL, "")
+	var client = &http.Client{
+		Timeout: time.Second * 600,
+	}
+	f, err := os.Open(recordFile)
+	if err != nil {
+		fmt.Printf("Cannot open file %s for replay: %v\n", recordFile, err)
+		return err
+	}
+	defer f.Close()
+	var errs *bufio.Writer
+	if errorFile != "" {
+		ferr, err := os.Create(errorFile)
+		if err != nil {
+			return fmt.Errorf("cannot create file %s for errors: %w", errorFile, err)
+		}
+		defer ferr.Close()
+		errs = bufio.NewWriter(ferr)
+		defer errs.Flush()
+	}
+	s := bufio.NewScanner(f)
+	var buf [64 * 1024 * 1024]byte // 64 Mb line buffer
+	s.Buffer(buf[:], len(buf))
+	reqGen := &RequestGenerator{
+		client: client,
+	}
+	stats := map[string]*replayStats{}
+	var mismatches int
+	for s.Scan() {
+		var rec rpc.RequestRecord
+		if err = json.Unmarshal(s.Bytes(), &rec); err != nil {
+			return fmt.Errorf("could not parse record %s: %w", s.Bytes(), err)
+		}
+		var req struct {
+			Method string `json:"method"`
+		}
+		if err = json.Unmarshal(rec.Request, &req); err != nil {
+			return fmt.Errorf("could not parse recorded request %s: %w", rec.Request, err)
+		}
+		st, ok := stats[req.Method]
+		if !ok {
+			st = &replayStats{}
+			stats[req.Method] = st
+		}
+		st.calls++
+		st.recorded = append(st.recorded, rec.Duration)
+
+		res := reqGen.Erigon2(req.Method, string(rec.Request))
+		st.replayed = append(st.replayed, float64(res.Took)/float64(time.Millisecond))
+		if err = compareRecorded(res, rec.Response); err != nil {
+			st.mismatches++
+			mismatches++
+			fmt.Printf("Different results for %s:\n %v\n", rec.Request, err)
+			if errs != nil {
+				fmt.Fprintf(errs, "Different results for %s:\n %v\n", rec.Request, err)
+				fmt.Fprintf(errs, "\n\nReplayed response=================================\n%s\n", res.Response)
+				fmt.Fprintf(errs, "\n\nRecorded response=================================\n%s\n", rec.Response)
+				errs.Flush() // nolint:errcheck
+			}
+		}
+	}
+	if err = s.Err(); err != nil {
+		return fmt.Errorf("could not read %s: %w", recordFile, err)
+	}
+	printReplayStats(stats)
+	if mismatches > 0 {
+		return fmt.Errorf("%d replayed calls have different results", mismatches)
+	}
+	return nil
+}
+
+// compareRecorded - compares result and error of replayed call with recorded response
+func compareRecorded(res CallResult, recorded []byte) error {
+	if res.Err != nil {
+		return fmt.Errorf("could not replay: %w", res.Err)
+	}
+	expected, err := fastjson.ParseBytes(recorded)
+	if err != nil {
+		return fmt.Errorf("could not parse recorded response: %w", err)
+	}
+	if err = compareResults(res.Result, expected); err != nil {
+		return err
+	}
+	return compareJsonValues("error", res.Result.Get("error"), expected.Get("error"))
+}
+
+func printReplayStats(stats map[string]*replayStats) {
+	methods := make([]string, 0, len(stats))
+	for method := range stats {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	fmt.Printf("%-40s %8s %10s %14s %14s %14s %14s\n", "method", "calls", "mismatches", "recorded ms", "replayed ms", "recorded p95", "replayed p95")
+	for _, method := range methods {
+		st := stats[method]
+		fmt.Printf("%-40s %8d %10d %14.2f %14.2f %14.2f %14.2f\n", method, st.calls, st.mismatches,
+			mean(st.recorded), mean(st.replayed), percentile(st.recorded, 0.95), percentile(st.replayed, 0.95))
+	}
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	return sorted[int(p*float64(len(sorted)-1))]
+}
@@ -514,7 +514,7 @@ func grpcSentryServer(ctx context.Context, sentryAddr string, ss *SentryServerIm
 		return nil, fmt.Errorf("could not create Sentry P2P listener: %w, addr=%s", err, sentryAddr)
 	}
 	grpcServer := grpcutil.NewServer(100, nil)
-	proto_sentry.RegisterSentryServer(grpcServer, ss)
+	RegisterSentryServer(grpcServer, ss)
 	var healthServer *health.Server
 	if healthCheck {
 		healthServer = health.NewServer()
@@ -559,17 +559,25 @@ func NewSentryServer(ctx context.Context, dialCandidates enode.Iterator, readNod
 			peerInfo := NewPeerInfo(peer, rw)
 
 			defer ss.GoodPeers.Delete(peerID)
+			connected := false
 			err := handShake(ctx, ss.GetStatus(), peerID, rw, protocol, protocol, func(bestHash common.Hash) error {
 				ss.GoodPeers.Store(peerID, peerInfo)
 				ss.sendNewPeerToClients(gointerfaces.ConvertBytesToH512([]byte(peerID)))
+				connected = true
+				ss.publishPeerEvent(PeerEventConnect, peer, nil)
 				return ss.startSync(ctx, bestHash, peerID)
 			})
 			if err != nil {
+				if connected {
+					ss.publishPeerEvent(PeerEventDisconnect, peer, err)
+				} else {
+					ss.publishPeerEvent(PeerEventHandshakeFailed, peer, err)
+				}
 				return fmt.Errorf("handshake to peer %s: %w", peerID, err)
 			}
 			log.Trace(fmt.Sprintf("[%s] Received status message OK", peerID), "name", peer.Name())
 
-			if err := runPeer(
+			if err = runPeer(
 				ctx,
 				peerID,
 				protocol,
@@ -580,6 +588,7 @@ func NewSentryServer(ctx context.Context, dialCandidates enode.Iterator, readNod
 			); err != nil {
 				log.Trace(fmt.Sprintf("[%s] Error while running peer: %v", peerID, err))
 			}
+			ss.publishPeerEvent(PeerEventDisconnect, peer, err)
 			ss.sendGonePeerToClients(gointerfaces.ConvertBytesToH512([]byte(peerID)))
 			return nil
 		},
@@ -633,6 +642,7 @@ type SentryServerImpl struct {
 	messagesSubscriberID uint64
 	messageStreamsLock   sync.RWMutex
 	peersStreams         *PeersStreams
+	peerEvents           peerEventStreams
 	p2p                  *p2p.Config
 }
 
@@ -0,0 +1,263 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	proto_sentry "github.com/ledgerwatch/erigon-lib/gointerfaces/sentry"
+	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
+	"github.com/ledgerwatch/log/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Some Sentry methods are not (yet) part of generated proto_sentry.SentryServer.
+// They are registered in the same gRPC service, their arguments and replies are
+// JSON documents wrapped into wrapperspb.BytesValue (same as ETHBACKEND ones)
+type sentryExtMethod func(ss *SentryServerImpl, ctx context.Context, args []byte) (interface{}, error)
+
+var sentryExtMethods = map[string]sentryExtMethod{
+	"PeersInfo":  (*SentryServerImpl).peersInfo,
+	"AddPeer":    (*SentryServerImpl).addStaticPeer,
+	"RemovePeer": (*SentryServerImpl).removeStaticPeer,
+}
+
+// RegisterSentryServer - must be used instead of proto_sentry.RegisterSentryServer, to serve also methods invoked by name
+func RegisterSentryServer(s grpc.ServiceRegistrar, ss *SentryServerImpl) {
+	desc := proto_sentry.Sentry_ServiceDesc
+	desc.Methods = append([]grpc.MethodDesc{}, desc.Methods...)
+	for name, method := range sentryExtMethods {
+		desc.Methods = append(desc.Methods, sentryExtMethodDesc(name, method))
+	}
+	desc.Streams = append([]grpc.StreamDesc{}, desc.Streams...)
+	for name, stream := range sentryExtStreams {
+		desc.Streams = append(desc.Streams, sentryExtStreamDesc(name, stream))
+	}
+	s.RegisterService(&desc, ss)
+}
+
+// sentryExtStream - server-streaming counterpart of sentryExtMethod, `send` encodes each event as JSON document
+type sentryExtStream func(ss *SentryServerImpl, ctx context.Context, args []byte, send func(event interface{}) error) error
+
+var sentryExtStreams = map[string]sentryExtStream{
+	"SubscribePeerEvents": (*SentryServerImpl).subscribePeerEvents,
+}
+
+func sentryExtStreamDesc(name string, stream sentryExtStream) grpc.StreamDesc {
+	return grpc.StreamDesc{
+		StreamName:    name,
+		ServerStreams: true,
+		Handler: func(srv interface{}, serverStream grpc.ServerStream) error {
+			in := new(wrapperspb.BytesValue)
+			if err := serverStream.RecvMsg(in); err != nil {
+				return err
+			}
+			return stream(srv.(*SentryServerImpl), serverStream.Context(), in.Value, func(event interface{}) (err error) {
+				out := &wrapperspb.BytesValue{}
+				if out.Value, err = json.Marshal(event); err != nil {
+					return err
+				}
+				return serverStream.SendMsg(out)
+			})
+		},
+	}
+}
+
+func sentryExtMethodDesc(name string, method sentryExtMethod) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(wrapperspb.BytesValue)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				res, err := method(srv.(*SentryServerImpl), ctx, req.(*wrapperspb.BytesValue).Value)
+				if err != nil {
+					return nil, err
+				}
+				out := &wrapperspb.BytesValue{}
+				if out.Value, err = json.Marshal(res); err != nil {
+					return nil, err
+				}
+				return out, nil
+			}
+			if interceptor == nil {
+				return handler(ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/" + proto_sentry.Sentry_ServiceDesc.ServiceName + "/" + name,
+			}
+			return interceptor(ctx, in, info, handler)
+		},
+	}
+}
+
+var errNoP2PServer = errors.New("p2p server was not started")
+
+// peersInfo - connected peers, same as admin_peers of the node
+func (ss *SentryServerImpl) peersInfo(context.Context, []byte) (interface{}, error) {
+	if ss.P2pServer == nil {
+		return nil, errNoP2PServer
+	}
+	return ss.P2pServer.PeersInfo(), nil
+}
+
+type peerRequest struct {
+	URL string `json:"url"`
+}
+
+func (ss *SentryServerImpl) parsePeer(url string) (*enode.Node, error) {
+	if ss.P2pServer == nil {
+		return nil, errNoP2PServer
+	}
+	node, err := enode.Parse(enode.ValidSchemes, url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enode: %w", err)
+	}
+	return node, nil
+}
+
+func (ss *SentryServerImpl) connected(id enode.ID) bool {
+	for _, peer := range ss.P2pServer.Peers() {
+		if peer.ID() == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (ss *SentryServerImpl) addStaticPeer(_ context.Context, args []byte) (interface{}, error) {
+	var req peerRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return ss.AddStaticPeer(req.URL)
+}
+
+func (ss *SentryServerImpl) removeStaticPeer(_ context.Context, args []byte) (interface{}, error) {
+	var req peerRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return ss.RemoveStaticPeer(req.URL)
+}
+
+// AddStaticPeer - adds static peer: sentry keeps connection to it, reconnecting if lost. false if the peer is already connected
+func (ss *SentryServerImpl) AddStaticPeer(url string) (bool, error) {
+	node, err := ss.parsePeer(url)
+	if err != nil {
+		return false, err
+	}
+	connected := ss.connected(node.ID())
+	ss.P2pServer.AddPeer(node)
+	return !connected, nil
+}
+
+// RemoveStaticPeer - removes static peer and disconnects from it. false if the peer wasn't connected
+func (ss *SentryServerImpl) RemoveStaticPeer(url string) (bool, error) {
+	node, err := ss.parsePeer(url)
+	if err != nil {
+		return false, err
+	}
+	connected := ss.connected(node.ID())
+	ss.P2pServer.RemovePeer(node)
+	return connected, nil
+}
+
+// Types of PeerEvent
+const (
+	PeerEventConnect         = "connect"
+	PeerEventDisconnect      = "disconnect"
+	PeerEventHandshakeFailed = "handshakeFailed"
+)
+
+// PeerEvent - change of connection to peer, sent to subscribers of SubscribePeerEvents
+type PeerEvent struct {
+	Type   string    `json:"type"`
+	ID     string    `json:"id"`
+	Enode  string    `json:"enode"`
+	Name   string    `json:"name,omitempty"`
+	Remote string    `json:"remoteAddress,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// peerEventsBuffer - events buffered for each subscriber, events of subscriber lagging more are dropped
+const peerEventsBuffer = 256
+
+// peerEventStreams - subscribers of SubscribePeerEvents, zero value is ready to use
+type peerEventStreams struct {
+	lock sync.Mutex
+	id   uint
+	subs map[uint]chan *PeerEvent
+}
+
+func (s *peerEventStreams) add() (events <-chan *PeerEvent, remove func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.subs == nil {
+		s.subs = map[uint]chan *PeerEvent{}
+	}
+	s.id++
+	id, ch := s.id, make(chan *PeerEvent, peerEventsBuffer)
+	s.subs[id] = ch
+	return ch, func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		delete(s.subs, id)
+	}
+}
+
+func (s *peerEventStreams) broadcast(event *PeerEvent) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, ch := range s.subs {
+		select {
+		case ch <- event:
+		default:
+			log.Warn("Dropping peer event of slow subscriber", "type", event.Type, "peer", event.ID)
+		}
+	}
+}
+
+func (ss *SentryServerImpl) publishPeerEvent(eventType string, peer *p2p.Peer, err error) {
+	event := &PeerEvent{
+		Type:  eventType,
+		ID:    peer.ID().String(),
+		Enode: peer.Node().URLv4(),
+		Name:  peer.Fullname(),
+		Time:  time.Now(),
+	}
+	if addr := peer.RemoteAddr(); addr != nil {
+		event.Remote = addr.String()
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	ss.peerEvents.broadcast(event)
+}
+
+// subscribePeerEvents - sends PeerEvent of each connect, disconnect and failed handshake until the stream is closed
+func (ss *SentryServerImpl) subscribePeerEvents(ctx context.Context, _ []byte, send func(event interface{}) error) error {
+	events, remove := ss.peerEvents.add()
+	defer remove()
+	for {
+		select {
+		case event := <-events:
+			if err := send(event); err != nil {
+				return err
+			}
+		case <-ss.ctx.Done():
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
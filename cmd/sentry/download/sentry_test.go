@@ -2,6 +2,7 @@ package download
 
 import (
 	"context"
+	"errors"
 	"math/big"
 	"testing"
 	"time"
@@ -17,6 +18,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/eth/protocols/eth"
 	"github.com/ledgerwatch/erigon/p2p"
+	"github.com/ledgerwatch/erigon/p2p/enode"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/stretchr/testify/require"
 )
@@ -177,3 +179,40 @@ func TestSentryServerImpl_SetStatusInitPanic(t *testing.T) {
 		t.Fatalf("error expected")
 	}
 }
+
+func TestPeerEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ss := &SentryServerImpl{ctx: context.Background()}
+	received := make(chan *PeerEvent)
+	done := make(chan error)
+	go func() {
+		done <- ss.subscribePeerEvents(ctx, nil, func(event interface{}) error {
+			received <- event.(*PeerEvent)
+			return nil
+		})
+	}()
+	peer := p2p.NewPeer(enode.ID{1}, "erigon", nil)
+	require.Eventually(t, func() bool {
+		ss.peerEvents.lock.Lock()
+		defer ss.peerEvents.lock.Unlock()
+		return len(ss.peerEvents.subs) == 1
+	}, time.Second, time.Millisecond)
+
+	ss.publishPeerEvent(PeerEventHandshakeFailed, peer, errors.New("network id mismatch"))
+	event := <-received
+	require.Equal(t, PeerEventHandshakeFailed, event.Type)
+	require.Equal(t, peer.ID().String(), event.ID)
+	require.Equal(t, "network id mismatch", event.Error)
+
+	// events of lagging subscriber are dropped instead of blocking the peer
+	events, remove := ss.peerEvents.add()
+	for i := 0; i < peerEventsBuffer+1; i++ {
+		ss.peerEvents.broadcast(&PeerEvent{Type: PeerEventConnect})
+		<-received
+	}
+	require.Len(t, events, peerEventsBuffer)
+	remove()
+
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,146 @@
+package ctxutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common/hexutil"
+)
+
+// RequestIDKey - name of gRPC metadata entry (and HTTP header) carrying request id
+const RequestIDKey = "x-request-id"
+
+type requestIDCtxKey struct{}
+
+// WithRequestID - attaches id of the originating request, to correlate with it all work done on its behalf
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey{}, id)
+}
+
+// RequestID - returns id attached by WithRequestID or "" if there is no such
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+// maxRequestIDLength - longer ids given by clients are replaced by generated ones
+const maxRequestIDLength = 128
+
+// NewRequestID - random id for request which came without one
+func NewRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ValidRequestID - whether id given by client can be used as is: not empty, not too long and of printable ASCII only,
+// so it can't break log lines and headers
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+type grpcTimeCtxKey struct{}
+
+// GRPCTime - total time gRPC calls made on behalf of one request waited for the server
+type GRPCTime struct {
+	nanos int64
+}
+
+// WithGRPCTime - attaches new GRPCTime, to which AddGRPCTime adds time of calls made with the returned context
+func WithGRPCTime(ctx context.Context) (context.Context, *GRPCTime) {
+	t := &GRPCTime{}
+	return context.WithValue(ctx, grpcTimeCtxKey{}, t), t
+}
+
+// AddGRPCTime - adds d to GRPCTime attached to ctx, if there is one
+func AddGRPCTime(ctx context.Context, d time.Duration) {
+	if t, ok := ctx.Value(grpcTimeCtxKey{}).(*GRPCTime); ok {
+		atomic.AddInt64(&t.nanos, int64(d))
+	}
+}
+
+// Get - time accumulated so far, 0 for nil GRPCTime
+func (t *GRPCTime) Get() time.Duration {
+	if t == nil {
+		return 0
+	}
+	return time.Duration(atomic.LoadInt64(&t.nanos))
+}
+
+type kvReadsCtxKey struct{}
+
+// maxKVReads - reads of one request over it are dropped, only counted
+const maxKVReads = 100_000
+
+// KVRead - read of db made on behalf of request: operation on table with its argument and the entry it returned
+type KVRead struct {
+	Table string        `json:"table"`
+	Op    string        `json:"op"`
+	Arg   hexutil.Bytes `json:"arg,omitempty"`
+	Key   hexutil.Bytes `json:"key,omitempty"`
+	Value hexutil.Bytes `json:"value,omitempty"`
+	Error string        `json:"error,omitempty"`
+}
+
+// KVReads - reads of db made on behalf of one request, in order they were made
+type KVReads struct {
+	lock    sync.Mutex
+	reads   []KVRead
+	dropped int
+}
+
+// WithKVReads - attaches new KVReads, to which AddKVRead adds reads made with the returned context
+func WithKVReads(ctx context.Context) (context.Context, *KVReads) {
+	r := &KVReads{}
+	return context.WithValue(ctx, kvReadsCtxKey{}, r), r
+}
+
+// KVReadsFromContext - KVReads attached to ctx, nil if reads made with ctx are not collected
+func KVReadsFromContext(ctx context.Context) *KVReads {
+	r, _ := ctx.Value(kvReadsCtxKey{}).(*KVReads)
+	return r
+}
+
+// Add - appends read, key and value are copied
+func (r *KVReads) Add(table, op string, arg, k, v []byte, err error) {
+	read := KVRead{Table: table, Op: op, Arg: copyBytes(arg), Key: copyBytes(k), Value: copyBytes(v)}
+	if err != nil {
+		read.Error = err.Error()
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.reads) >= maxKVReads {
+		r.dropped++
+		return
+	}
+	r.reads = append(r.reads, read)
+}
+
+// Get - reads collected so far and amount of reads dropped over the limit, nil for nil KVReads
+func (r *KVReads) Get() ([]KVRead, int) {
+	if r == nil {
+		return nil, 0
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.reads, r.dropped
+}
+
+func copyBytes(b []byte) hexutil.Bytes {
+	if b == nil {
+		return nil
+	}
+	return append(hexutil.Bytes{}, b...)
+}
@@ -0,0 +1,45 @@
+package misc
+
+import (
+	"math/big"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+// CalcBlobFee calculates price of blob gas of a block with the given excess blob gas (EIP-4844)
+func CalcBlobFee(excessBlobGas uint64) *big.Int {
+	return fakeExponential(new(big.Int).SetUint64(params.MinBlobGasPrice), new(big.Int).SetUint64(excessBlobGas), new(big.Int).SetUint64(params.BlobGasPriceUpdateFraction))
+}
+
+// CalcExcessBlobGas calculates excess blob gas of a block following the parent (EIP-4844)
+func CalcExcessBlobGas(parent *types.Header) uint64 {
+	var parentExcessBlobGas, parentBlobGasUsed uint64
+	if parent.ExcessBlobGas != nil {
+		parentExcessBlobGas = *parent.ExcessBlobGas
+	}
+	if parent.BlobGasUsed != nil {
+		parentBlobGasUsed = *parent.BlobGasUsed
+	}
+	target := params.MaxBlobGasPerBlock / 2
+	if parentExcessBlobGas+parentBlobGasUsed < target {
+		return 0
+	}
+	return parentExcessBlobGas + parentBlobGasUsed - target
+}
+
+// fakeExponential approximates factor * e ** (numerator / denominator) using Taylor expansion
+func fakeExponential(factor, numerator, denominator *big.Int) *big.Int {
+	var (
+		output = new(big.Int)
+		accum  = new(big.Int).Mul(factor, denominator)
+	)
+	for i := 1; accum.Sign() > 0; i++ {
+		output.Add(output, accum)
+
+		accum.Mul(accum, numerator)
+		accum.Div(accum, denominator)
+		accum.Div(accum, big.NewInt(int64(i)))
+	}
+	return output.Div(output, denominator)
+}
@@ -0,0 +1,47 @@
+package misc
+
+import (
+	"testing"
+
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
+)
+
+func TestCalcBlobFee(t *testing.T) {
+	tests := []struct {
+		excessBlobGas uint64
+		blobfee       int64
+	}{
+		{0, 1},
+		{2314057, 1},
+		{2314058, 2},
+		{10 * 1024 * 1024, 23},
+	}
+	for i, tt := range tests {
+		if have := CalcBlobFee(tt.excessBlobGas); have.Int64() != tt.blobfee {
+			t.Errorf("test %d: blobfee mismatch: have %v want %v", i, have, tt.blobfee)
+		}
+	}
+}
+
+func TestCalcExcessBlobGas(t *testing.T) {
+	u64 := func(v uint64) *uint64 { return &v }
+	target := params.MaxBlobGasPerBlock / 2
+	tests := []struct {
+		excess, used *uint64
+		want         uint64
+	}{
+		{nil, nil, 0}, // parent before EIP-4844
+		{u64(0), u64(0), 0},
+		{u64(0), u64(target), 0},
+		{u64(0), u64(params.MaxBlobGasPerBlock), target},
+		{u64(target), u64(target - params.BlobGasPerBlob), target - params.BlobGasPerBlob},
+		{u64(params.BlobGasPerBlob), u64(0), 0},
+	}
+	for i, tt := range tests {
+		parent := &types.Header{ExcessBlobGas: tt.excess, BlobGasUsed: tt.used}
+		if have := CalcExcessBlobGas(parent); have != tt.want {
+			t.Errorf("test %d: excess blob gas mismatch: have %d want %d", i, have, tt.want)
+		}
+	}
+}
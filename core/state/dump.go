@@ -143,17 +143,13 @@ func (d *Dumper) DumpToCollector(c DumpCollector, excludeCode, excludeStorage bo
 	numberOfResults := 0
 
 	if err := WalkAsOfAccounts(d.db, startAddress, d.blockNumber+1, func(k, v []byte) (bool, error) {
+		if len(k) > 32 {
+			return true, nil // storage of plain state, next key has to be an address
+		}
 		if maxResults > 0 && numberOfResults >= maxResults {
-			if nextKey == nil {
-				nextKey = make([]byte, len(k))
-			}
-			copy(nextKey, k)
+			nextKey = common.CopyBytes(k)
 			return false, nil
 		}
-
-		if len(k) > 32 {
-			return true, nil
-		}
 		if e := acc.DecodeForStorage(v); e != nil {
 			return false, fmt.Errorf("decoding %x for %x: %w", v, k, e)
 		}
@@ -206,6 +202,9 @@ func (d *Dumper) DumpToCollector(c DumpCollector, excludeCode, excludeStorage bo
 				common.Hash{}, /* startLocation */
 				d.blockNumber,
 				func(_, loc, vs []byte) (bool, error) {
+					if len(vs) == 0 {
+						return true, nil // location was empty at the block
+					}
 					account.Storage[common.BytesToHash(loc).String()] = common.Bytes2Hex(vs)
 					h, _ := common.HashData(loc)
 					t.Update(h.Bytes(), common.CopyBytes(vs))
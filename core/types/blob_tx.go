@@ -0,0 +1,273 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/u256"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// BlobTx - EIP-4844 transaction, DynamicFeeTransaction with fee cap of blob gas and versioned hashes of KZG
+// commitments to its blobs. Blobs themselves travel in sidecars outside of blocks
+type BlobTx struct {
+	DynamicFeeTransaction
+	MaxFeePerBlobGas    *uint256.Int
+	BlobVersionedHashes []common.Hash
+}
+
+func (tx BlobTx) Type() byte { return BlobTxType }
+
+// GetBlobGas - blob gas used by blobs of the transaction
+func (tx BlobTx) GetBlobGas() uint64 {
+	return params.BlobGasPerBlob * uint64(len(tx.BlobVersionedHashes))
+}
+
+func (tx BlobTx) Cost() *uint256.Int {
+	total := tx.DynamicFeeTransaction.Cost()
+	blobFee := new(uint256.Int).SetUint64(tx.GetBlobGas())
+	blobFee.Mul(blobFee, tx.MaxFeePerBlobGas)
+	return total.Add(total, blobFee)
+}
+
+func (tx BlobTx) copy() *BlobTx {
+	cpy := &BlobTx{
+		DynamicFeeTransaction: *tx.DynamicFeeTransaction.copy(),
+		MaxFeePerBlobGas:      new(uint256.Int),
+		BlobVersionedHashes:   make([]common.Hash, len(tx.BlobVersionedHashes)),
+	}
+	if tx.MaxFeePerBlobGas != nil {
+		cpy.MaxFeePerBlobGas.Set(tx.MaxFeePerBlobGas)
+	}
+	copy(cpy.BlobVersionedHashes, tx.BlobVersionedHashes)
+	return cpy
+}
+
+func (tx *BlobTx) Size() common.StorageSize {
+	if size := tx.size.Load(); size != nil {
+		return size.(common.StorageSize)
+	}
+	c := tx.EncodingSize()
+	tx.size.Store(common.StorageSize(c))
+	return common.StorageSize(c)
+}
+
+// payload - encoding of fields without signature if `signed` is false, chain id of the signer replaces ChainID then
+func (tx BlobTx) payload(chainID interface{}, signed bool) []interface{} {
+	fields := []interface{}{
+		chainID,
+		tx.Nonce,
+		tx.Tip,
+		tx.FeeCap,
+		tx.Gas,
+		tx.To,
+		tx.Value,
+		tx.Data,
+		tx.AccessList,
+		tx.MaxFeePerBlobGas,
+		tx.BlobVersionedHashes,
+	}
+	if signed {
+		fields = append(fields, tx.V, tx.R, tx.S)
+	}
+	return fields
+}
+
+func (tx BlobTx) encodedPayload() []byte {
+	b, err := rlp.EncodeToBytes(tx.payload(tx.ChainID, true))
+	if err != nil {
+		panic(err) // fields of the payload always encode
+	}
+	return b
+}
+
+// EncodingSize - size of TxType and payload, without envelope
+func (tx BlobTx) EncodingSize() int {
+	return 1 + len(tx.encodedPayload())
+}
+
+func (tx BlobTx) MarshalBinary(w io.Writer) error {
+	if _, err := w.Write([]byte{BlobTxType}); err != nil {
+		return err
+	}
+	_, err := w.Write(tx.encodedPayload())
+	return err
+}
+
+func (tx BlobTx) EncodeRLP(w io.Writer) error {
+	payload := tx.encodedPayload()
+	var b [33]byte
+	// envelope
+	if err := EncodeStringSizePrefix(1+len(payload), w, b[:]); err != nil {
+		return err
+	}
+	b[0] = BlobTxType
+	if _, err := w.Write(b[:1]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func (tx *BlobTx) DecodeRLP(s *rlp.Stream) error {
+	_, err := s.List()
+	if err != nil {
+		return err
+	}
+	var b []byte
+	if b, err = s.Uint256Bytes(); err != nil {
+		return err
+	}
+	tx.ChainID = new(uint256.Int).SetBytes(b)
+	if tx.Nonce, err = s.Uint(); err != nil {
+		return err
+	}
+	if b, err = s.Uint256Bytes(); err != nil {
+		return err
+	}
+	tx.Tip = new(uint256.Int).SetBytes(b)
+	if b, err = s.Uint256Bytes(); err != nil {
+		return err
+	}
+	tx.FeeCap = new(uint256.Int).SetBytes(b)
+	if tx.Gas, err = s.Uint(); err != nil {
+		return err
+	}
+	if b, err = s.Bytes(); err != nil {
+		return err
+	}
+	if len(b) != 20 { // blob transactions can't create contracts
+		return fmt.Errorf("wrong size for To: %d", len(b))
+	}
+	tx.To = &common.Address{}
+	copy((*tx.To)[:], b)
+	if b, err = s.Uint256Bytes(); err != nil {
+		return err
+	}
+	tx.Value = new(uint256.Int).SetBytes(b)
+	if tx.Data, err = s.Bytes(); err != nil {
+		return err
+	}
+	// decode AccessList
+	tx.AccessList = AccessList{}
+	if err = decodeAccessList(&tx.AccessList, s); err != nil {
+		return err
+	}
+	if b, err = s.Uint256Bytes(); err != nil {
+		return err
+	}
+	tx.MaxFeePerBlobGas = new(uint256.Int).SetBytes(b)
+	// decode BlobVersionedHashes
+	if _, err = s.List(); err != nil {
+		return fmt.Errorf("open BlobVersionedHashes: %w", err)
+	}
+	tx.BlobVersionedHashes = []common.Hash{}
+	for b, err = s.Bytes(); err == nil; b, err = s.Bytes() {
+		if len(b) != 32 {
+			return fmt.Errorf("wrong size for blob versioned hash: %d", len(b))
+		}
+		tx.BlobVersionedHashes = append(tx.BlobVersionedHashes, common.BytesToHash(b))
+	}
+	if !errors.Is(err, rlp.EOL) {
+		return fmt.Errorf("read blob versioned hash: %w", err)
+	}
+	if err = s.ListEnd(); err != nil {
+		return fmt.Errorf("close BlobVersionedHashes: %w", err)
+	}
+	// decode V
+	if b, err = s.Uint256Bytes(); err != nil {
+		return err
+	}
+	tx.V.SetBytes(b)
+	if b, err = s.Uint256Bytes(); err != nil {
+		return err
+	}
+	tx.R.SetBytes(b)
+	if b, err = s.Uint256Bytes(); err != nil {
+		return err
+	}
+	tx.S.SetBytes(b)
+	return s.ListEnd()
+}
+
+func (tx *BlobTx) WithSignature(signer Signer, sig []byte) (Transaction, error) {
+	cpy := tx.copy()
+	r, s, v, err := signer.SignatureValues(tx, sig)
+	if err != nil {
+		return nil, err
+	}
+	cpy.R.Set(r)
+	cpy.S.Set(s)
+	cpy.V.Set(v)
+	cpy.ChainID = signer.ChainID()
+	return cpy, nil
+}
+
+func (tx *BlobTx) FakeSign(address common.Address) (Transaction, error) {
+	cpy := tx.copy()
+	cpy.R.Set(u256.Num1)
+	cpy.S.Set(u256.Num1)
+	cpy.V.Set(u256.Num4)
+	cpy.from.Store(address)
+	return cpy, nil
+}
+
+// AsMessage returns the transaction as a core.Message, blob gas is not charged by it
+func (tx BlobTx) AsMessage(s Signer, baseFee *big.Int) (Message, error) {
+	msg := Message{
+		nonce:      tx.Nonce,
+		gasLimit:   tx.Gas,
+		tip:        *tx.Tip,
+		feeCap:     *tx.FeeCap,
+		to:         tx.To,
+		amount:     *tx.Value,
+		data:       tx.Data,
+		accessList: tx.AccessList,
+		checkNonce: true,
+	}
+	if baseFee != nil {
+		overflow := msg.gasPrice.SetFromBig(baseFee)
+		if overflow {
+			return msg, fmt.Errorf("gasPrice higher than 2^256-1")
+		}
+	}
+	msg.gasPrice.Add(&msg.gasPrice, tx.Tip)
+	if msg.gasPrice.Gt(tx.FeeCap) {
+		msg.gasPrice.Set(tx.FeeCap)
+	}
+
+	var err error
+	msg.from, err = tx.Sender(s)
+	return msg, err
+}
+
+// Hash computes the hash (but not for signatures!)
+func (tx *BlobTx) Hash() common.Hash {
+	if hash := tx.hash.Load(); hash != nil {
+		return *hash.(*common.Hash)
+	}
+	hash := prefixedRlpHash(BlobTxType, tx.payload(tx.ChainID, true))
+	tx.hash.Store(&hash)
+	return hash
+}
+
+func (tx BlobTx) SigningHash(chainID *big.Int) common.Hash {
+	return prefixedRlpHash(BlobTxType, tx.payload(chainID, false))
+}
+
+func (tx *BlobTx) Sender(signer Signer) (common.Address, error) {
+	if sc := tx.from.Load(); sc != nil {
+		return sc.(common.Address), nil
+	}
+	addr, err := signer.Sender(tx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	tx.from.Store(addr)
+	return addr, nil
+}
@@ -99,6 +99,11 @@ type Header struct {
 	Eip1559     bool           // to avoid relying on BaseFee != nil for that
 	Seal        []rlp.RawValue // AuRa POA network field
 	WithSeal    bool           // to avoid relying on Seal != nil for that
+
+	WithdrawalsHash       *common.Hash `json:"withdrawalsRoot,omitempty"`       // EIP-4895, precedes blob gas fields in encoding
+	BlobGasUsed           *uint64      `json:"blobGasUsed,omitempty"`           // EIP-4844
+	ExcessBlobGas         *uint64      `json:"excessBlobGas,omitempty"`         // EIP-4844
+	ParentBeaconBlockRoot *common.Hash `json:"parentBeaconBlockRoot,omitempty"` // EIP-4788
 }
 
 func (h Header) EncodingSize() int {
@@ -167,6 +172,7 @@ func (h Header) EncodingSize() int {
 		}
 		encodingSize += baseFeeLen
 	}
+	encodingSize += h.optionalFieldsSize()
 
 	return encodingSize
 }
@@ -242,6 +248,7 @@ func (h Header) EncodeRLP(w io.Writer) error {
 		}
 		encodingSize += baseFeeLen
 	}
+	encodingSize += h.optionalFieldsSize()
 
 	var b [33]byte
 	// Prefix
@@ -401,6 +408,107 @@ func (h Header) EncodeRLP(w io.Writer) error {
 			}
 		}
 	}
+	return h.encodeOptionalFields(w, b[:])
+}
+
+// optionalFieldsSize - size of encoding of fields following BaseFee, each of them is set only if the previous ones are
+func (h Header) optionalFieldsSize() int {
+	var size int
+	if h.WithdrawalsHash != nil {
+		size += 33
+	}
+	for _, field := range []*uint64{h.BlobGasUsed, h.ExcessBlobGas} {
+		if field != nil {
+			size++
+			if *field >= 128 {
+				size += (bits.Len64(*field) + 7) / 8
+			}
+		}
+	}
+	if h.ParentBeaconBlockRoot != nil {
+		size += 33
+	}
+	return size
+}
+
+func (h Header) encodeOptionalFields(w io.Writer, b []byte) error {
+	if h.WithdrawalsHash != nil {
+		b[0] = 128 + 32
+		if _, err := w.Write(b[:1]); err != nil {
+			return err
+		}
+		if _, err := w.Write(h.WithdrawalsHash.Bytes()); err != nil {
+			return err
+		}
+	}
+	for _, field := range []*uint64{h.BlobGasUsed, h.ExcessBlobGas} {
+		if field == nil {
+			continue
+		}
+		if *field > 0 && *field < 128 {
+			b[0] = byte(*field)
+			if _, err := w.Write(b[:1]); err != nil {
+				return err
+			}
+		} else {
+			fieldLen := (bits.Len64(*field) + 7) / 8
+			binary.BigEndian.PutUint64(b[1:], *field)
+			b[8-fieldLen] = 128 + byte(fieldLen)
+			if _, err := w.Write(b[8-fieldLen : 9]); err != nil {
+				return err
+			}
+		}
+	}
+	if h.ParentBeaconBlockRoot != nil {
+		b[0] = 128 + 32
+		if _, err := w.Write(b[:1]); err != nil {
+			return err
+		}
+		if _, err := w.Write(h.ParentBeaconBlockRoot.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeOptionalFields - reads fields following BaseFee until the end of header list
+func (h *Header) decodeOptionalFields(s *rlp.Stream) error {
+	b, err := s.Bytes()
+	if err != nil {
+		if errors.Is(err, rlp.EOL) {
+			return nil
+		}
+		return fmt.Errorf("read WithdrawalsHash: %w", err)
+	}
+	if len(b) != 32 {
+		return fmt.Errorf("wrong size for WithdrawalsHash: %d", len(b))
+	}
+	h.WithdrawalsHash = new(common.Hash)
+	copy(h.WithdrawalsHash[:], b)
+	for _, field := range []struct {
+		name string
+		dst  **uint64
+	}{{"BlobGasUsed", &h.BlobGasUsed}, {"ExcessBlobGas", &h.ExcessBlobGas}} {
+		v, err := s.Uint()
+		if err != nil {
+			if errors.Is(err, rlp.EOL) {
+				return nil
+			}
+			return fmt.Errorf("read %s: %w", field.name, err)
+		}
+		*field.dst = &v
+	}
+	if b, err = s.Bytes(); err != nil {
+		if errors.Is(err, rlp.EOL) {
+			return nil
+		}
+		return fmt.Errorf("read ParentBeaconBlockRoot: %w", err)
+	}
+	if len(b) != 32 {
+		return fmt.Errorf("wrong size for ParentBeaconBlockRoot: %d", len(b))
+	}
+	h.ParentBeaconBlockRoot = new(common.Hash)
+	copy(h.ParentBeaconBlockRoot[:], b)
 	return nil
 }
 
@@ -520,6 +628,9 @@ func (h *Header) DecodeRLP(s *rlp.Stream) error {
 		}
 		h.Eip1559 = true
 		h.BaseFee = new(big.Int).SetBytes(b)
+		if err = h.decodeOptionalFields(s); err != nil {
+			return err
+		}
 	}
 	if err := s.ListEnd(); err != nil {
 		return fmt.Errorf("close header struct: %w", err)
@@ -529,14 +640,16 @@ func (h *Header) DecodeRLP(s *rlp.Stream) error {
 
 // field type overrides for gencodec
 type headerMarshaling struct {
-	Difficulty *hexutil.Big
-	Number     *hexutil.Big
-	GasLimit   hexutil.Uint64
-	GasUsed    hexutil.Uint64
-	Time       hexutil.Uint64
-	Extra      hexutil.Bytes
-	BaseFee    *hexutil.Big
-	Hash       common.Hash `json:"hash"` // adds call to Hash() in MarshalJSON
+	Difficulty    *hexutil.Big
+	Number        *hexutil.Big
+	GasLimit      hexutil.Uint64
+	GasUsed       hexutil.Uint64
+	Time          hexutil.Uint64
+	Extra         hexutil.Bytes
+	BaseFee       *hexutil.Big
+	BlobGasUsed   *hexutil.Uint64
+	ExcessBlobGas *hexutil.Uint64
+	Hash          common.Hash `json:"hash"` // adds call to Hash() in MarshalJSON
 }
 
 // Hash returns the block hash of the header, which is simply the keccak256 hash of its
@@ -796,6 +909,8 @@ func (bb Body) payloadSize() (payloadSize int, txsLen, unclesLen int) {
 			txLen = t.EncodingSize()
 		case *DynamicFeeTransaction:
 			txLen = t.EncodingSize()
+		case *BlobTx:
+			txLen = t.EncodingSize()
 		}
 		if txLen >= 56 {
 			txsLen += (bits.Len(uint(txLen)) + 7) / 8
@@ -848,6 +963,10 @@ func (bb Body) EncodeRLP(w io.Writer) error {
 			if err := t.EncodeRLP(w); err != nil {
 				return err
 			}
+		case *BlobTx:
+			if err := t.EncodeRLP(w); err != nil {
+				return err
+			}
 		}
 	}
 	// encode Uncles
@@ -981,6 +1100,22 @@ func CopyHeader(h *Header) *Header {
 			cpy.Seal[i] = common.CopyBytes(h.Seal[i])
 		}
 	}
+	if h.WithdrawalsHash != nil {
+		withdrawalsHash := *h.WithdrawalsHash
+		cpy.WithdrawalsHash = &withdrawalsHash
+	}
+	if h.BlobGasUsed != nil {
+		blobGasUsed := *h.BlobGasUsed
+		cpy.BlobGasUsed = &blobGasUsed
+	}
+	if h.ExcessBlobGas != nil {
+		excessBlobGas := *h.ExcessBlobGas
+		cpy.ExcessBlobGas = &excessBlobGas
+	}
+	if h.ParentBeaconBlockRoot != nil {
+		parentBeaconBlockRoot := *h.ParentBeaconBlockRoot
+		cpy.ParentBeaconBlockRoot = &parentBeaconBlockRoot
+	}
 	return &cpy
 }
 
@@ -1056,6 +1191,8 @@ func (bb Block) payloadSize() (payloadSize int, txsLen, unclesLen int) {
 			txLen = t.EncodingSize()
 		case *DynamicFeeTransaction:
 			txLen = t.EncodingSize()
+		case *BlobTx:
+			txLen = t.EncodingSize()
 		}
 		if txLen >= 56 {
 			txsLen += (bits.Len(uint(txLen)) + 7) / 8
@@ -1118,6 +1255,10 @@ func (bb Block) EncodeRLP(w io.Writer) error {
 			if err := t.EncodeRLP(w); err != nil {
 				return err
 			}
+		case *BlobTx:
+			if err := t.EncodeRLP(w); err != nil {
+				return err
+			}
 		}
 	}
 	// encode Uncles
@@ -18,6 +18,7 @@ package types
 
 import (
 	"bytes"
+	"encoding/json"
 	"math/big"
 	"reflect"
 	"testing"
@@ -268,3 +269,70 @@ func makeBenchBlock() *Block {
 	}
 	return NewBlock(header, txs, uncles, receipts)
 }
+
+func TestHeaderOptionalFieldsEncoding(t *testing.T) {
+	withdrawalsHash, beaconRoot := common.HexToHash("0x01"), common.HexToHash("0x02")
+	blobGasUsed, excessBlobGas := uint64(2*params.BlobGasPerBlob), uint64(0)
+	header := &Header{
+		ParentHash:            common.HexToHash("0x03"),
+		Difficulty:            big.NewInt(0),
+		Number:                big.NewInt(100),
+		GasLimit:              30_000_000,
+		Time:                  1681338455,
+		Extra:                 []byte{},
+		BaseFee:               big.NewInt(7),
+		Eip1559:               true,
+		WithdrawalsHash:       &withdrawalsHash,
+		BlobGasUsed:           &blobGasUsed,
+		ExcessBlobGas:         &excessBlobGas,
+		ParentBeaconBlockRoot: &beaconRoot,
+	}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	if len(enc) != 3+header.EncodingSize() { // list prefix of 2 bytes of length
+		t.Fatalf("encoding size %d of %d bytes", header.EncodingSize(), len(enc))
+	}
+	var decoded Header
+	if err = rlp.DecodeBytes(enc, &decoded); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if !reflect.DeepEqual(&decoded, header) {
+		t.Errorf("decoded header mismatch:\ngot:  %+v\nwant: %+v", &decoded, header)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Errorf("hash mismatch: %x != %x", decoded.Hash(), header.Hash())
+	}
+	jsonEnc, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal("json encode error: ", err)
+	}
+	decoded = Header{}
+	if err = json.Unmarshal(jsonEnc, &decoded); err != nil {
+		t.Fatal("json decode error: ", err)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Errorf("hash mismatch after json: %s", jsonEnc)
+	}
+
+	// headers of earlier forks encode as before
+	header.WithdrawalsHash, header.BlobGasUsed, header.ExcessBlobGas, header.ParentBeaconBlockRoot = nil, nil, nil, nil
+	londonEnc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	if len(londonEnc) != len(enc)-2*33-1-4 { // without 2 hashes, zero and 2^18
+		t.Errorf("unexpected size of london header: %d", len(londonEnc))
+	}
+	decoded = Header{}
+	if err = rlp.DecodeBytes(londonEnc, &decoded); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if decoded.WithdrawalsHash != nil || decoded.BlobGasUsed != nil || decoded.ExcessBlobGas != nil || decoded.ParentBeaconBlockRoot != nil {
+		t.Errorf("london header decoded with optional fields: %+v", &decoded)
+	}
+	if decoded.Hash() != header.Hash() {
+		t.Errorf("hash mismatch: %x != %x", decoded.Hash(), header.Hash())
+	}
+}
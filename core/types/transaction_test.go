@@ -561,3 +561,85 @@ func assertEqual(orig Transaction, cpy Transaction) error {
 	}
 	return nil
 }
+
+func TestBlobTransactionCoding(t *testing.T) {
+	key, addr := defaultTestKey()
+	recipient := common.HexToAddress("095e7baea6a6c7c4c2dfeb977efac326af552d87")
+	signer := LatestSignerForChainID(common.Big1)
+	tx, err := SignNewTx(key, *signer, &BlobTx{
+		DynamicFeeTransaction: DynamicFeeTransaction{
+			ChainID: u256.Num1,
+			CommonTx: CommonTx{
+				Nonce: 7,
+				To:    &recipient,
+				Value: uint256.NewInt(10),
+				Gas:   21000,
+				Data:  []byte{},
+			},
+			Tip:        uint256.NewInt(1),
+			FeeCap:     uint256.NewInt(100),
+			AccessList: AccessList{{Address: recipient, StorageKeys: []common.Hash{{1}}}},
+		},
+		MaxFeePerBlobGas:    uint256.NewInt(3),
+		BlobVersionedHashes: []common.Hash{{0x01, 0xaa}, {0x01, 0xbb}},
+	})
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	if tx.Type() != BlobTxType {
+		t.Fatalf("type %d", tx.Type())
+	}
+	if from, err := tx.Sender(*LatestSignerForChainID(common.Big1)); err != nil || from != addr {
+		t.Fatalf("sender %x, err %v", from, err)
+	}
+	// cost of dynamic fee transaction (value + gas*tip) + blob gas*max fee per blob gas
+	if cost := tx.Cost(); cost.Uint64() != 10+21000*1+2*131072*3 {
+		t.Errorf("cost %d", cost)
+	}
+
+	check := func(parsedTx Transaction) {
+		if err = assertEqual(parsedTx, tx); err != nil {
+			t.Fatal(err)
+		}
+		blobTx, ok := parsedTx.(*BlobTx)
+		if !ok {
+			t.Fatalf("parsed %T", parsedTx)
+		}
+		if blobTx.MaxFeePerBlobGas.Uint64() != 3 || !reflect.DeepEqual(blobTx.BlobVersionedHashes, tx.(*BlobTx).BlobVersionedHashes) {
+			t.Errorf("blob fields mismatch: %d %x", blobTx.MaxFeePerBlobGas, blobTx.BlobVersionedHashes)
+		}
+	}
+	parsedTx, err := encodeDecodeBinary(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(parsedTx)
+	parsedTx, err = encodeDecodeJSON(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(parsedTx)
+	// in envelope, as in block bodies
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enc) != int(tx.Size())+2 {
+		t.Errorf("envelope of %d bytes, size %v", len(enc), tx.Size())
+	}
+	if parsedTx, err = DecodeTransaction(rlp.NewStream(bytes.NewReader(enc), 0)); err != nil {
+		t.Fatal(err)
+	}
+	check(parsedTx)
+
+	// blob transactions can't create contracts
+	creation := tx.(*BlobTx).copy()
+	creation.To = nil
+	var buf bytes.Buffer
+	if err = creation.MarshalBinary(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = UnmarshalTransactionFromBinary(buf.Bytes()); err == nil {
+		t.Error("decoded blob transaction without recipient")
+	}
+}
@@ -385,6 +385,15 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 
 	ethBackendRPC := privateapi.NewEthBackendServer(ctx, backend, backend.notifications.Events)
 	miningRPC = privateapi.NewMiningServer(ctx, backend, ethashApi)
+	ethBackendRPC.SetMiningServer(miningRPC)
+	ethBackendRPC.SetSyncProgress(chainKv, backend.downloadServer.Hd.TopSeenHeight)
+	ethBackendRPC.SetIndexRebuilder(stagedsync.NewIndexRebuilder(chainKv, tmpdir))
+	ethBackendRPC.SetEngine(func(block *types.Block) error {
+		if err := backend.downloadServer.Hd.AddMinedBlock(block); err != nil {
+			return err
+		}
+		return backend.downloadServer.Bd.AddMinedBlock(block)
+	})
 	if stack.Config().PrivateApiAddr != "" {
 		var creds credentials.TransportCredentials
 		if stack.Config().TLSConnection {
@@ -464,6 +473,7 @@ func New(stack *node.Node, config *ethconfig.Config, logger log.Logger) (*Ethere
 				}
 
 			case b := <-backend.pendingBlocks:
+				backend.notifications.Events.OnNewPendingBlock(b)
 				if err := miningRPC.(*privateapi.MiningServer).BroadcastPendingBlock(b); err != nil {
 					log.Error("txpool rpc pending block broadcast", "err", err)
 				}
@@ -689,6 +699,33 @@ func (s *Ethereum) NodesInfo(limit int) (*remote.NodesInfoReply, error) {
 	return nodesInfo, nil
 }
 
+var errExternalSentries = errors.New("node doesn't change peers of external sentries, set --sentry.api.addr of rpcdaemon")
+
+// AddPeer - adds static peer to every sentry of the node
+func (s *Ethereum) AddPeer(url string) (bool, error) {
+	return s.changePeers(url, (*download.SentryServerImpl).AddStaticPeer)
+}
+
+// RemovePeer - removes static peer from every sentry of the node
+func (s *Ethereum) RemovePeer(url string) (bool, error) {
+	return s.changePeers(url, (*download.SentryServerImpl).RemoveStaticPeer)
+}
+
+func (s *Ethereum) changePeers(url string, change func(*download.SentryServerImpl, string) (bool, error)) (bool, error) {
+	if len(s.sentryServers) == 0 {
+		return false, errExternalSentries
+	}
+	var changed bool
+	for _, srv := range s.sentryServers {
+		ok, err := change(srv, url)
+		if err != nil {
+			return false, err
+		}
+		changed = changed || ok
+	}
+	return changed, nil
+}
+
 // Protocols returns all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
@@ -18,17 +18,21 @@ package gasprice
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"sort"
 	"sync/atomic"
 
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/holiman/uint256"
 	libcommon "github.com/ledgerwatch/erigon-lib/common"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/consensus/misc"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/log/v3"
 )
@@ -39,9 +43,9 @@ var (
 )
 
 const (
-	// maxFeeHistory is the maximum number of blocks that can be retrieved for a
-	// fee history request.
-	maxFeeHistory = 1024
+	// DefaultMaxFeeHistory is the maximum number of blocks that can be retrieved for a
+	// fee history request, unless Config.MaxFeeHistory is set.
+	DefaultMaxFeeHistory = 1024
 )
 
 // blockFees represents a single block for processing
@@ -51,11 +55,73 @@ type blockFees struct {
 	header      *types.Header
 	block       *types.Block // only set if reward percentiles are requested
 	receipts    types.Receipts
-	// filled by processBlock
-	reward               []*big.Int
-	baseFee, nextBaseFee *big.Int
-	gasUsedRatio         float64
-	err                  error
+	// filled by processBlock or taken from FeeHistoryCache
+	results processedFees
+	err     error
+}
+
+// processedFees contains the results of a processed block and is also used for caching
+type processedFees struct {
+	reward                       []*big.Int
+	baseFee, nextBaseFee         *big.Int
+	gasUsedRatio                 float64
+	blobBaseFee, nextBlobBaseFee *big.Int // zero before EIP-4844
+	blobGasUsedRatio             float64
+	withBlobs                    bool // header has EIP-4844 fields
+}
+
+// feeHistoryCacheKey - results of block depend on requested percentiles, block is identified by hash to survive reorgs
+type feeHistoryCacheKey struct {
+	hash        common.Hash
+	percentiles string
+}
+
+// FeeHistoryCache keeps results of processed blocks between FeeHistory requests, it's safe for concurrent use.
+// Oracle is usually created per request, cache lives as long as the API serving requests
+type FeeHistoryCache struct {
+	lru *lru.Cache
+}
+
+// NewFeeHistoryCache returns cache of results of `size` most recently processed blocks
+func NewFeeHistoryCache(size int) *FeeHistoryCache {
+	cache, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+	return &FeeHistoryCache{lru: cache}
+}
+
+func (c *FeeHistoryCache) get(key feeHistoryCacheKey) (processedFees, bool) {
+	if c == nil {
+		return processedFees{}, false
+	}
+	res, ok := c.lru.Get(key)
+	if !ok {
+		return processedFees{}, false
+	}
+	return res.(processedFees), true
+}
+
+func (c *FeeHistoryCache) add(key feeHistoryCacheKey, fees processedFees) {
+	if c != nil {
+		c.lru.Add(key, fees)
+	}
+}
+
+// Len - amount of cached blocks
+func (c *FeeHistoryCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	return c.lru.Len()
+}
+
+func percentilesKey(percentiles []float64) string {
+	key := make([]byte, 8*len(percentiles))
+	for i, p := range percentiles {
+		binary.LittleEndian.PutUint64(key[i*8:], math.Float64bits(p))
+	}
+	return string(key)
 }
 
 // txGasAndReward is sorted in ascending order based on reward
@@ -80,15 +146,25 @@ func (s sortGasAndReward) Less(i, j int) bool {
 // fills in the rest of the fields.
 func (oracle *Oracle) processBlock(bf *blockFees, percentiles []float64) {
 	chainconfig := oracle.backend.ChainConfig()
-	if bf.baseFee = bf.header.BaseFee; bf.baseFee == nil {
-		bf.baseFee = new(big.Int)
+	if bf.results.baseFee = bf.header.BaseFee; bf.results.baseFee == nil {
+		bf.results.baseFee = new(big.Int)
 	}
 	if chainconfig.IsLondon(uint64(bf.blockNumber + 1)) {
-		bf.nextBaseFee = misc.CalcBaseFee(chainconfig, bf.header)
+		bf.results.nextBaseFee = misc.CalcBaseFee(chainconfig, bf.header)
 	} else {
-		bf.nextBaseFee = new(big.Int)
+		bf.results.nextBaseFee = new(big.Int)
+	}
+	bf.results.gasUsedRatio = float64(bf.header.GasUsed) / float64(bf.header.GasLimit)
+	if excessBlobGas := bf.header.ExcessBlobGas; excessBlobGas != nil {
+		bf.results.withBlobs = true
+		bf.results.blobBaseFee = misc.CalcBlobFee(*excessBlobGas)
+		bf.results.nextBlobBaseFee = misc.CalcBlobFee(misc.CalcExcessBlobGas(bf.header))
+	} else {
+		bf.results.blobBaseFee, bf.results.nextBlobBaseFee = new(big.Int), new(big.Int)
+	}
+	if blobGasUsed := bf.header.BlobGasUsed; blobGasUsed != nil {
+		bf.results.blobGasUsedRatio = float64(*blobGasUsed) / float64(params.MaxBlobGasPerBlock)
 	}
-	bf.gasUsedRatio = float64(bf.header.GasUsed) / float64(bf.header.GasLimit)
 	if len(percentiles) == 0 {
 		// rewards were not requested, return null
 		return
@@ -98,11 +174,11 @@ func (oracle *Oracle) processBlock(bf *blockFees, percentiles []float64) {
 		return
 	}
 
-	bf.reward = make([]*big.Int, len(percentiles))
+	bf.results.reward = make([]*big.Int, len(percentiles))
 	if len(bf.block.Transactions()) == 0 {
 		// return an all zero row if there are no transactions to gather data from
-		for i := range bf.reward {
-			bf.reward[i] = new(big.Int)
+		for i := range bf.results.reward {
+			bf.results.reward[i] = new(big.Int)
 		}
 		return
 	}
@@ -127,7 +203,7 @@ func (oracle *Oracle) processBlock(bf *blockFees, percentiles []float64) {
 			txIndex++
 			sumGasUsed += sorter[txIndex].gasUsed
 		}
-		bf.reward[i] = sorter[txIndex].reward
+		bf.results.reward[i] = sorter[txIndex].reward
 	}
 }
 
@@ -193,27 +269,31 @@ func (oracle *Oracle) resolveBlockRange(ctx context.Context, lastBlock rpc.Block
 // or blocks older than a certain age (specified in maxHistory). The first block of the
 // actually processed range is returned to avoid ambiguity when parts of the requested range
 // are not available or when the head has changed during processing this request.
-// Three arrays are returned based on the processed blocks:
-// - reward: the requested percentiles of effective priority fees per gas of transactions in each
-//   block, sorted in ascending order and weighted by gas used.
-// - baseFee: base fee per gas in the given block
-// - gasUsedRatio: gasUsed/gasLimit in the given block
-// Note: baseFee includes the next block after the newest of the returned range, because this
-// value can be derived from the newest block.
-func (oracle *Oracle) FeeHistory(ctx context.Context, blocks int, unresolvedLastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, error) {
+// Five arrays are returned based on the processed blocks:
+//   - reward: the requested percentiles of effective priority fees per gas of transactions in each
+//     block, sorted in ascending order and weighted by gas used.
+//   - baseFee: base fee per gas in the given block
+//   - gasUsedRatio: gasUsed/gasLimit in the given block
+//   - blobBaseFee: base fee per blob gas in the given block (EIP-4844)
+//   - blobGasUsedRatio: blobGasUsed/maxBlobGasPerBlock in the given block
+//
+// Note: baseFee and blobBaseFee include the next block after the newest of the returned range,
+// because this value can be derived from the newest block. Blob arrays are nil if no block of
+// the range has EIP-4844 fields.
+func (oracle *Oracle) FeeHistory(ctx context.Context, blocks int, unresolvedLastBlock rpc.BlockNumber, rewardPercentiles []float64) (*big.Int, [][]*big.Int, []*big.Int, []float64, []*big.Int, []float64, error) {
 	if blocks < 1 {
-		return common.Big0, nil, nil, nil, nil // returning with no data and no error means there are no retrievable blocks
+		return common.Big0, nil, nil, nil, nil, nil, nil // returning with no data and no error means there are no retrievable blocks
 	}
-	if blocks > maxFeeHistory {
-		log.Warn("Sanitizing fee history length", "requested", blocks, "truncated", maxFeeHistory)
-		blocks = maxFeeHistory
+	if blocks > oracle.maxFeeHistory {
+		log.Warn("Sanitizing fee history length", "requested", blocks, "truncated", oracle.maxFeeHistory)
+		blocks = oracle.maxFeeHistory
 	}
 	for i, p := range rewardPercentiles {
 		if p < 0 || p > 100 {
-			return common.Big0, nil, nil, nil, fmt.Errorf("%w: %f", ErrInvalidPercentile, p)
+			return common.Big0, nil, nil, nil, nil, nil, fmt.Errorf("%w: %f", ErrInvalidPercentile, p)
 		}
 		if i > 0 && p < rewardPercentiles[i-1] {
-			return common.Big0, nil, nil, nil, fmt.Errorf("%w: #%d:%f > #%d:%f", ErrInvalidPercentile, i-1, rewardPercentiles[i-1], i, p)
+			return common.Big0, nil, nil, nil, nil, nil, fmt.Errorf("%w: #%d:%f > #%d:%f", ErrInvalidPercentile, i-1, rewardPercentiles[i-1], i, p)
 		}
 	}
 	// Only process blocks if reward percentiles were requested
@@ -228,22 +308,26 @@ func (oracle *Oracle) FeeHistory(ctx context.Context, blocks int, unresolvedLast
 	)
 	pendingBlock, pendingReceipts, lastBlock, blocks, err := oracle.resolveBlockRange(ctx, unresolvedLastBlock, blocks, maxHistory)
 	if err != nil || blocks == 0 {
-		return common.Big0, nil, nil, nil, err
+		return common.Big0, nil, nil, nil, nil, nil, err
 	}
 	oldestBlock := lastBlock + 1 - uint64(blocks)
 
 	var (
-		next = oldestBlock
+		next           = oldestBlock
+		percentilesKey = percentilesKey(rewardPercentiles)
 	)
 	var (
-		reward       = make([][]*big.Int, blocks)
-		baseFee      = make([]*big.Int, blocks+1)
-		gasUsedRatio = make([]float64, blocks)
-		firstMissing = blocks
+		reward           = make([][]*big.Int, blocks)
+		baseFee          = make([]*big.Int, blocks+1)
+		gasUsedRatio     = make([]float64, blocks)
+		blobBaseFee      = make([]*big.Int, blocks+1)
+		blobGasUsedRatio = make([]float64, blocks)
+		withBlobs        bool
+		firstMissing     = blocks
 	)
 	for ; blocks > 0; blocks-- {
 		if err = libcommon.Stopped(ctx.Done()); err != nil {
-			return common.Big0, nil, nil, nil, err
+			return common.Big0, nil, nil, nil, nil, nil, err
 		}
 		// Retrieve the next block number to fetch with this goroutine
 		blockNumber := atomic.AddUint64(&next, 1) - 1
@@ -252,31 +336,42 @@ func (oracle *Oracle) FeeHistory(ctx context.Context, blocks int, unresolvedLast
 		}
 
 		fees := &blockFees{blockNumber: blockNumber}
-		if pendingBlock != nil && blockNumber >= pendingBlock.NumberU64() {
+		pending := pendingBlock != nil && blockNumber >= pendingBlock.NumberU64()
+		cached := false
+		if pending {
 			fees.block, fees.receipts = pendingBlock, pendingReceipts
 		} else {
-			if len(rewardPercentiles) != 0 {
+			if oracle.historyCache != nil || len(rewardPercentiles) == 0 {
+				fees.header, fees.err = oracle.backend.HeaderByNumber(ctx, rpc.BlockNumber(blockNumber))
+				if fees.header != nil && fees.err == nil {
+					fees.results, cached = oracle.historyCache.get(feeHistoryCacheKey{fees.header.Hash(), percentilesKey})
+				}
+			}
+			if len(rewardPercentiles) != 0 && !cached && fees.err == nil {
 				fees.block, fees.err = oracle.backend.BlockByNumber(ctx, rpc.BlockNumber(blockNumber))
 				if fees.block != nil && fees.err == nil {
 					fees.receipts, fees.err = oracle.backend.GetReceipts(ctx, fees.block.Hash())
 				}
-			} else {
-				fees.header, fees.err = oracle.backend.HeaderByNumber(ctx, rpc.BlockNumber(blockNumber))
 			}
 		}
 		if fees.block != nil {
 			fees.header = fees.block.Header()
 		}
-		if fees.header != nil {
+		if fees.header != nil && !cached && fees.err == nil {
 			oracle.processBlock(fees, rewardPercentiles)
+			if !pending {
+				oracle.historyCache.add(feeHistoryCacheKey{fees.header.Hash(), percentilesKey}, fees.results)
+			}
 		}
 
 		if fees.err != nil {
-			return common.Big0, nil, nil, nil, fees.err
+			return common.Big0, nil, nil, nil, nil, nil, fees.err
 		}
 		i := int(fees.blockNumber - oldestBlock)
 		if fees.header != nil {
-			reward[i], baseFee[i], baseFee[i+1], gasUsedRatio[i] = fees.reward, fees.baseFee, fees.nextBaseFee, fees.gasUsedRatio
+			reward[i], baseFee[i], baseFee[i+1], gasUsedRatio[i] = fees.results.reward, fees.results.baseFee, fees.results.nextBaseFee, fees.results.gasUsedRatio
+			blobBaseFee[i], blobBaseFee[i+1], blobGasUsedRatio[i] = fees.results.blobBaseFee, fees.results.nextBlobBaseFee, fees.results.blobGasUsedRatio
+			withBlobs = withBlobs || fees.results.withBlobs
 		} else {
 			// getting no block and no error means we are requesting into the future (might happen because of a reorg)
 			if i < firstMissing {
@@ -285,7 +380,7 @@ func (oracle *Oracle) FeeHistory(ctx context.Context, blocks int, unresolvedLast
 		}
 	}
 	if firstMissing == 0 {
-		return common.Big0, nil, nil, nil, nil
+		return common.Big0, nil, nil, nil, nil, nil, nil
 	}
 	if len(rewardPercentiles) != 0 {
 		reward = reward[:firstMissing]
@@ -293,5 +388,10 @@ func (oracle *Oracle) FeeHistory(ctx context.Context, blocks int, unresolvedLast
 		reward = nil
 	}
 	baseFee, gasUsedRatio = baseFee[:firstMissing+1], gasUsedRatio[:firstMissing]
-	return new(big.Int).SetUint64(oldestBlock), reward, baseFee, gasUsedRatio, nil
+	if withBlobs {
+		blobBaseFee, blobGasUsedRatio = blobBaseFee[:firstMissing+1], blobGasUsedRatio[:firstMissing]
+	} else {
+		blobBaseFee, blobGasUsedRatio = nil, nil
+	}
+	return new(big.Int).SetUint64(oldestBlock), reward, baseFee, gasUsedRatio, blobBaseFee, blobGasUsedRatio, nil
 }
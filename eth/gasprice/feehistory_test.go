@@ -19,9 +19,15 @@ package gasprice_test
 import (
 	"context"
 	"errors"
+	"math/big"
+	"reflect"
 	"testing"
 
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus/misc"
+	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/eth/gasprice"
+	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
 )
 
@@ -59,7 +65,7 @@ func TestFeeHistory(t *testing.T) {
 		backend := newTestBackend(t) //, big.NewInt(16), c.pending)
 		oracle := gasprice.NewOracle(backend, config)
 
-		first, reward, baseFee, ratio, err := oracle.FeeHistory(context.Background(), c.count, c.last, c.percent)
+		first, reward, baseFee, ratio, blobBaseFee, blobRatio, err := oracle.FeeHistory(context.Background(), c.count, c.last, c.percent)
 
 		expReward := c.expCount
 		if len(c.percent) == 0 {
@@ -82,8 +88,120 @@ func TestFeeHistory(t *testing.T) {
 		if len(ratio) != c.expCount {
 			t.Fatalf("Test case %d: gasUsedRatio array length mismatch, want %d, got %d", i, c.expCount, len(ratio))
 		}
+		if blobBaseFee != nil || blobRatio != nil {
+			t.Fatalf("Test case %d: blob fields are returned for blocks without them", i)
+		}
 		if err != c.expErr && !errors.Is(err, c.expErr) {
 			t.Fatalf("Test case %d: error mismatch, want %v, got %v", i, c.expErr, err)
 		}
 	}
 }
+
+func TestFeeHistoryMaxBlocks(t *testing.T) {
+	oracle := gasprice.NewOracle(newTestBackend(t), gasprice.Config{MaxFeeHistory: 5})
+	first, _, _, ratio, _, _, err := oracle.FeeHistory(context.Background(), 10, 30, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Uint64() != 26 || len(ratio) != 5 {
+		t.Fatalf("expected 5 blocks from 26, got %d from %d", len(ratio), first)
+	}
+}
+
+func TestFeeHistoryCache(t *testing.T) {
+	backend := newTestBackend(t)
+	cache := gasprice.NewFeeHistoryCache(100)
+	percentiles := []float64{0, 50, 100}
+
+	_, expReward, expBaseFee, expRatio, _, _, err := gasprice.NewOracle(backend, gasprice.Config{}).FeeHistory(context.Background(), 10, 30, percentiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		_, reward, baseFee, ratio, _, _, err := gasprice.NewOracle(backend, gasprice.Config{}).WithFeeHistoryCache(cache).FeeHistory(context.Background(), 10, 30, percentiles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(reward, expReward) || !reflect.DeepEqual(baseFee, expBaseFee) || !reflect.DeepEqual(ratio, expRatio) {
+			t.Fatalf("attempt %d: results differ from uncached ones", i)
+		}
+		if cache.Len() != 10 {
+			t.Fatalf("attempt %d: expected 10 cached blocks, got %d", i, cache.Len())
+		}
+	}
+	// same blocks with other percentiles are cached separately
+	if _, _, _, _, _, _, err = gasprice.NewOracle(backend, gasprice.Config{}).WithFeeHistoryCache(cache).FeeHistory(context.Background(), 10, 30, nil); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 20 {
+		t.Fatalf("expected 20 cached blocks, got %d", cache.Len())
+	}
+}
+
+// headersBackend - chain of headers without transactions
+type headersBackend []*types.Header
+
+func (b headersBackend) HeaderByNumber(_ context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber {
+		number = rpc.BlockNumber(len(b) - 1)
+	}
+	if int(number) >= len(b) {
+		return nil, nil
+	}
+	return b[number], nil
+}
+
+func (b headersBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	header, err := b.HeaderByNumber(ctx, number)
+	if header == nil || err != nil {
+		return nil, err
+	}
+	return types.NewBlockWithHeader(header), nil
+}
+
+func (b headersBackend) ChainConfig() *params.ChainConfig { return params.TestChainConfig }
+
+func (b headersBackend) GetReceipts(context.Context, common.Hash) (types.Receipts, error) {
+	return types.Receipts{}, nil
+}
+
+func (b headersBackend) PendingBlockAndReceipts() (*types.Block, types.Receipts) { return nil, nil }
+
+func TestFeeHistoryBlobs(t *testing.T) {
+	var backend headersBackend
+	for i := 0; i < 4; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), GasLimit: 30_000_000, GasUsed: 15_000_000, BaseFee: big.NewInt(params.InitialBaseFee), Eip1559: true}
+		if i >= 2 { // blocks after EIP-4844
+			blobGasUsed, excessBlobGas := uint64(i)*params.BlobGasPerBlob, uint64(0)
+			if i > 2 {
+				excessBlobGas = misc.CalcExcessBlobGas(backend[i-1]) + 10*1024*1024
+			}
+			header.BlobGasUsed, header.ExcessBlobGas = &blobGasUsed, &excessBlobGas
+		}
+		backend = append(backend, header)
+	}
+	oracle := gasprice.NewOracle(backend, gasprice.Config{})
+	first, _, baseFee, ratio, blobBaseFee, blobRatio, err := oracle.FeeHistory(context.Background(), 4, rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Uint64() != 0 || len(baseFee) != 5 || len(ratio) != 4 {
+		t.Fatalf("expected 4 blocks from 0, got %d from %d", len(ratio), first)
+	}
+	expBlobBaseFee := []*big.Int{big.NewInt(0), big.NewInt(0), big.NewInt(1), big.NewInt(23), misc.CalcBlobFee(misc.CalcExcessBlobGas(backend[3]))}
+	if !reflect.DeepEqual(blobBaseFee, expBlobBaseFee) {
+		t.Fatalf("blob base fees mismatch, want %v, got %v", expBlobBaseFee, blobBaseFee)
+	}
+	if expBlobRatio := []float64{0, 0, 2.0 / 6, 3.0 / 6}; !reflect.DeepEqual(blobRatio, expBlobRatio) {
+		t.Fatalf("blob gas used ratios mismatch, want %v, got %v", expBlobRatio, blobRatio)
+	}
+
+	// blocks before EIP-4844 only
+	_, _, _, _, blobBaseFee, blobRatio, err = oracle.FeeHistory(context.Background(), 1, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blobBaseFee != nil || blobRatio != nil {
+		t.Fatalf("blob fields are returned for blocks without them")
+	}
+}
@@ -17,14 +17,16 @@
 package gasprice
 
 import (
-	"container/heap"
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 
 	"github.com/holiman/uint256"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus/misc"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/rpc"
@@ -38,13 +40,22 @@ var (
 	DefaultIgnorePrice = big.NewInt(2 * params.Wei)
 )
 
+// Strategies of choosing tips SuggestTipCap takes the percentile of
+const (
+	StrategyGeth    = "geth"    // sampleNumber lowest tips of each block, from as many recent blocks as needed to get Blocks*sampleNumber of them
+	StrategyEIP1559 = "eip1559" // all tips of the last Blocks blocks, SuggestGasPrice adds base fee of the next block
+)
+
 type Config struct {
+	Strategy         string // StrategyGeth if empty
 	Blocks           int
 	Percentile       int
 	MaxHeaderHistory int
 	MaxBlockHistory  int
+	MaxFeeHistory    int      // max amount of blocks in one FeeHistory request, DefaultMaxFeeHistory if 0
 	Default          *big.Int `toml:",omitempty"`
-	MaxPrice         *big.Int `toml:",omitempty"`
+	MaxPrice         *big.Int `toml:",omitempty"` // suggested tip is never above it
+	MinPrice         *big.Int `toml:",omitempty"` // suggested tip is never below it, nil - no floor. Equal to MaxPrice - fixed tip
 	IgnorePrice      *big.Int `toml:",omitempty"`
 }
 
@@ -65,12 +76,17 @@ type Oracle struct {
 	lastHead    common.Hash
 	lastPrice   *big.Int
 	maxPrice    *big.Int
+	minPrice    *big.Int
 	ignorePrice *big.Int
 	cacheLock   sync.RWMutex
+	strategy    string
+	tipCache    *TipCache
 
 	checkBlocks                       int
 	percentile                        int
 	maxHeaderHistory, maxBlockHistory int
+	maxFeeHistory                     int
+	historyCache                      *FeeHistoryCache
 }
 
 // NewOracle returns a new gasprice oracle which can recommend suitable
@@ -95,186 +111,205 @@ func NewOracle(backend OracleBackend, params Config) *Oracle {
 		maxPrice = DefaultMaxPrice
 		log.Warn("Sanitizing invalid gasprice oracle price cap", "provided", params.MaxPrice, "updated", maxPrice)
 	}
+	minPrice := params.MinPrice
+	if minPrice != nil && (minPrice.Sign() < 0 || minPrice.Cmp(maxPrice) > 0) {
+		minPrice = nil
+		log.Warn("Sanitizing invalid gasprice oracle price floor", "provided", params.MinPrice, "updated", minPrice)
+	}
+	strategy := params.Strategy
+	switch strategy {
+	case StrategyGeth, StrategyEIP1559:
+	default:
+		if strategy != "" {
+			log.Warn("Sanitizing invalid gasprice oracle strategy", "provided", params.Strategy, "updated", StrategyGeth)
+		}
+		strategy = StrategyGeth
+	}
 	ignorePrice := params.IgnorePrice
 	if ignorePrice == nil || ignorePrice.Int64() < 0 {
 		ignorePrice = DefaultIgnorePrice
 		log.Warn("Sanitizing invalid gasprice oracle ignore price", "provided", params.IgnorePrice, "updated", ignorePrice)
 	}
+	maxFeeHistory := params.MaxFeeHistory
+	if maxFeeHistory <= 0 {
+		maxFeeHistory = DefaultMaxFeeHistory
+	}
 	return &Oracle{
 		backend:          backend,
 		lastPrice:        params.Default,
 		maxPrice:         maxPrice,
+		minPrice:         minPrice,
 		ignorePrice:      ignorePrice,
+		strategy:         strategy,
 		checkBlocks:      blocks,
 		percentile:       percent,
 		maxHeaderHistory: params.MaxHeaderHistory,
 		maxBlockHistory:  params.MaxBlockHistory,
+		maxFeeHistory:    maxFeeHistory,
 	}
 }
 
+// WithFeeHistoryCache - FeeHistory takes results of already processed blocks from `cache` and adds new ones to it
+func (gpo *Oracle) WithFeeHistoryCache(cache *FeeHistoryCache) *Oracle {
+	gpo.historyCache = cache
+	return gpo
+}
+
+// WithTipCache - SuggestTipCap reuses tips of blocks sampled by earlier oracles and their suggestion for the same head.
+// All oracles sharing `cache` must be created with the same Config
+func (gpo *Oracle) WithTipCache(cache *TipCache) *Oracle {
+	gpo.tipCache = cache
+	return gpo
+}
+
 // SuggestTipCap returns a TipCap so that newly created transaction can
 // have a very high chance to be included in the following blocks.
 // NODE: if caller wants legacy tx SuggestedPrice, we need to add
-// baseFee to the returned bigInt
+// baseFee to the returned bigInt, see SuggestGasPrice
 func (gpo *Oracle) SuggestTipCap(ctx context.Context) (*big.Int, error) {
-	head, _ := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	head, err := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
 	headHash := head.Hash()
 
+	// Concurrent callers sharing the cache wait for one computation instead of repeating it
+	if gpo.tipCache != nil {
+		gpo.tipCache.lock.Lock()
+		defer gpo.tipCache.lock.Unlock()
+		if gpo.tipCache.lastHead == headHash {
+			return new(big.Int).Set(gpo.tipCache.lastPrice), nil
+		}
+	}
+
 	// If the latest gasprice is still available, return it.
 	gpo.cacheLock.RLock()
 	lastHead, lastPrice := gpo.lastHead, gpo.lastPrice
 	gpo.cacheLock.RUnlock()
 	if headHash == lastHead {
-		return lastPrice, nil
+		return new(big.Int).Set(lastPrice), nil
+	}
+	price, err := gpo.suggestTipCap(ctx, head)
+	if err != nil {
+		return lastPrice, err
 	}
+	gpo.cacheLock.Lock()
+	gpo.lastHead = headHash
+	gpo.lastPrice = price
+	gpo.cacheLock.Unlock()
+	if gpo.tipCache != nil {
+		gpo.tipCache.lastHead, gpo.tipCache.lastPrice = headHash, price
+	}
+	return new(big.Int).Set(price), nil
+}
 
-	// Try checking the cache again, maybe the last fetch fetched what we need
-	gpo.cacheLock.RLock()
-	lastHead, lastPrice = gpo.lastHead, gpo.lastPrice
-	gpo.cacheLock.RUnlock()
-	if headHash == lastHead {
-		return lastPrice, nil
+// SuggestGasPrice returns a gas price for legacy transactions: SuggestTipCap plus base fee of the head block, or, with
+// StrategyEIP1559, base fee the next block will have
+func (gpo *Oracle) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	tip, err := gpo.SuggestTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+	head, err := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
 	}
-	number := head.Number.Uint64()
-	txPrices := make(sortingHeap, 0, sampleNumber*gpo.checkBlocks)
-	for txPrices.Len() < sampleNumber*gpo.checkBlocks && number > 0 {
-		err := gpo.getBlockPrices(ctx, number, sampleNumber, gpo.ignorePrice, &txPrices)
+	chainConfig := gpo.backend.ChainConfig()
+	switch {
+	case gpo.strategy == StrategyEIP1559 && chainConfig.IsLondon(head.Number.Uint64()+1):
+		tip.Add(tip, misc.CalcBaseFee(chainConfig, head))
+	case head.BaseFee != nil:
+		tip.Add(tip, head.BaseFee)
+	}
+	return tip, nil
+}
+
+// suggestTipCap - percentile of tips sampled from blocks ending with head, bounded by MinPrice and MaxPrice
+func (gpo *Oracle) suggestTipCap(ctx context.Context, head *types.Header) (*big.Int, error) {
+	limit, enough := sampleNumber, sampleNumber*gpo.checkBlocks
+	if gpo.strategy == StrategyEIP1559 {
+		limit = 0
+	}
+	var txPrices []*uint256.Int
+	header := head
+	for blocks := 0; header.Number.Uint64() > 0; blocks++ {
+		if gpo.strategy == StrategyEIP1559 && blocks >= gpo.checkBlocks || gpo.strategy == StrategyGeth && len(txPrices) >= enough {
+			break
+		}
+		tips, err := gpo.getBlockPrices(ctx, header, limit)
 		if err != nil {
-			return lastPrice, err
+			return nil, err
 		}
-		number--
-	}
-	price := lastPrice
-	if txPrices.Len() > 0 {
-		// Item with this position needs to be extracted from the sorting heap
-		// so we pop all the items before it
-		percentilePosition := (txPrices.Len() - 1) * gpo.percentile / 100
-		for i := 0; i < percentilePosition; i++ {
-			heap.Pop(&txPrices)
+		txPrices = append(txPrices, tips...)
+		if header, err = gpo.backend.HeaderByNumber(ctx, rpc.BlockNumber(header.Number.Uint64()-1)); err != nil {
+			return nil, err
 		}
 	}
-	if txPrices.Len() > 0 {
-		// Don't need to pop it, just take from the top of the heap
-		price = txPrices[0].ToBig()
+	price := gpo.lastPrice
+	if len(txPrices) > 0 {
+		sort.Slice(txPrices, func(i, j int) bool { return txPrices[i].Lt(txPrices[j]) })
+		price = txPrices[(len(txPrices)-1)*gpo.percentile/100].ToBig()
+	}
+	if price == nil {
+		price = new(big.Int)
+	}
+	if gpo.minPrice != nil && price.Cmp(gpo.minPrice) < 0 {
+		price = new(big.Int).Set(gpo.minPrice)
 	}
 	if price.Cmp(gpo.maxPrice) > 0 {
 		price = new(big.Int).Set(gpo.maxPrice)
 	}
-	gpo.cacheLock.Lock()
-	gpo.lastHead = headHash
-	gpo.lastPrice = price
-	gpo.cacheLock.Unlock()
 	return price, nil
 }
 
-type transactionsByGasPrice struct {
-	txs     []types.Transaction
-	baseFee *uint256.Int
-}
-
-func newTransactionsByGasPrice(txs []types.Transaction,
-	baseFee *uint256.Int) transactionsByGasPrice {
-	return transactionsByGasPrice{
-		txs:     txs,
-		baseFee: baseFee,
+// getBlockPrices returns the lowest `limit` (all if 0) effective tips of transactions in the block, in ascending
+// order. Tips below ignorePrice and transactions sent by the miner itself (it doesn't make any sense to include this
+// kind of transaction prices for sampling) are skipped.
+func (gpo *Oracle) getBlockPrices(ctx context.Context, header *types.Header, limit int) ([]*uint256.Int, error) {
+	key := tipCacheKey{hash: header.Hash(), limit: limit}
+	if tips, ok := gpo.tipCache.get(key); ok {
+		return tips, nil
 	}
-}
-
-func (t transactionsByGasPrice) Len() int      { return len(t.txs) }
-func (t transactionsByGasPrice) Swap(i, j int) { t.txs[i], t.txs[j] = t.txs[j], t.txs[i] }
-func (t transactionsByGasPrice) Less(i, j int) bool {
-	tip1 := t.txs[i].GetEffectiveGasTip(t.baseFee)
-	tip2 := t.txs[j].GetEffectiveGasTip(t.baseFee)
-	return tip1.Lt(tip2)
-}
-
-// Push (part of heap.Interface) places a new link onto the end of queue
-func (t *transactionsByGasPrice) Push(x interface{}) {
-	// Push and Pop use pointer receivers because they modify the slice's length,
-	// not just its contents.
-	l := x.(types.Transaction)
-	t.txs = append(t.txs, l)
-}
-
-// Pop (part of heap.Interface) removes the first link from the queue
-func (t *transactionsByGasPrice) Pop() interface{} {
-	old := t.txs
-	n := len(old)
-	x := old[n-1]
-	t.txs = old[0 : n-1]
-	return x
-}
-
-// getBlockPrices calculates the lowest transaction gas price in a given block.
-// the block is empty or all transactions are sent by the miner
-// itself(it doesn't make any sense to include this kind of transaction prices for sampling),
-// nil gasprice is returned.
-func (gpo *Oracle) getBlockPrices(ctx context.Context, blockNum uint64, limit int,
-	ingoreUnderBig *big.Int, s *sortingHeap) error {
-	ignoreUnder, overflow := uint256.FromBig(ingoreUnderBig)
+	ignoreUnder, overflow := uint256.FromBig(gpo.ignorePrice)
 	if overflow {
 		err := errors.New("overflow in getBlockPrices, gasprice.go: ignoreUnder too large")
 		log.Error("gasprice.go: getBlockPrices", "error", err)
-		return err
+		return nil, err
 	}
-	block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(blockNum))
-	if block == nil {
+	block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(header.Number.Uint64()))
+	if err != nil {
 		log.Error("gasprice.go: getBlockPrices", "error", err)
-		return err
+		return nil, err
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block %d not found", header.Number.Uint64())
 	}
-	blockTxs := block.Transactions()
-	plainTxs := make([]types.Transaction, len(blockTxs))
-	copy(plainTxs, blockTxs)
 	var baseFee *uint256.Int
-	if block.BaseFee() == nil {
-		baseFee = nil
-	} else {
+	if block.BaseFee() != nil {
 		baseFee, overflow = uint256.FromBig(block.BaseFee())
 		if overflow {
 			err := errors.New("overflow in getBlockPrices, gasprice.go: baseFee > 2^256-1")
 			log.Error("gasprice.go: getBlockPrices", "error", err)
-			return err
+			return nil, err
 		}
 	}
-	txs := newTransactionsByGasPrice(plainTxs, baseFee)
-	heap.Init(&txs)
-
-	for txs.Len() > 0 {
-		tx := heap.Pop(&txs).(types.Transaction)
+	tips := make([]*uint256.Int, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
 		tip := tx.GetEffectiveGasTip(baseFee)
 		if ignoreUnder != nil && tip.Lt(ignoreUnder) {
 			continue
 		}
-		sender, _ := tx.GetSender()
-		if err == nil && sender != block.Coinbase() {
-			heap.Push(s, tip)
-			if s.Len() >= limit {
-				break
-			}
+		if sender, _ := tx.GetSender(); sender == block.Coinbase() {
+			continue
 		}
+		tips = append(tips, tip)
 	}
-	return nil
-}
-
-type sortingHeap []*uint256.Int
-
-func (s sortingHeap) Len() int           { return len(s) }
-func (s sortingHeap) Less(i, j int) bool { return s[i].Lt(s[j]) }
-func (s sortingHeap) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
-
-// Push (part of heap.Interface) places a new link onto the end of queue
-func (s *sortingHeap) Push(x interface{}) {
-	// Push and Pop use pointer receivers because they modify the slice's length,
-	// not just its contents.
-	l := x.(*uint256.Int)
-	*s = append(*s, l)
-}
-
-// Pop (part of heap.Interface) removes the first link from the queue
-func (s *sortingHeap) Pop() interface{} {
-	old := *s
-	n := len(old)
-	x := old[n-1]
-	*s = old[0 : n-1]
-	return x
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Lt(tips[j]) })
+	if limit > 0 && len(tips) > limit {
+		tips = tips[:limit]
+	}
+	if block.Hash() == key.hash {
+		gpo.tipCache.add(key, tips)
+	}
+	return tips, nil
 }
@@ -156,3 +156,54 @@ func TestSuggestPrice(t *testing.T) {
 		t.Fatalf("Gas price mismatch, want %d, got %d", expect, got)
 	}
 }
+
+func TestSuggestPriceStrategies(t *testing.T) {
+	backend := newTestBackend(t)
+	for _, tt := range []struct {
+		name   string
+		config gasprice.Config
+		expect int64
+	}{
+		// all tips of the last 2 blocks: 32G, 31G
+		{"eip1559", gasprice.Config{Strategy: gasprice.StrategyEIP1559, Blocks: 2, Percentile: 100}, 32},
+		{"eip1559 low percentile", gasprice.Config{Strategy: gasprice.StrategyEIP1559, Blocks: 2, Percentile: 0}, 31},
+		{"floor", gasprice.Config{Blocks: 2, Percentile: 60, MinPrice: big.NewInt(40 * params.GWei), MaxPrice: big.NewInt(50 * params.GWei)}, 40},
+		{"ceiling", gasprice.Config{Blocks: 2, Percentile: 60, MaxPrice: big.NewInt(25 * params.GWei)}, 25},
+		{"fixed", gasprice.Config{Blocks: 2, Percentile: 60, MinPrice: big.NewInt(7 * params.GWei), MaxPrice: big.NewInt(7 * params.GWei)}, 7},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gasprice.NewOracle(backend, tt.config).SuggestTipCap(context.Background())
+			if err != nil {
+				t.Fatalf("Failed to retrieve recommended gas price: %v", err)
+			}
+			if expect := big.NewInt(params.GWei * tt.expect); got.Cmp(expect) != 0 {
+				t.Fatalf("Gas price mismatch, want %d, got %d", expect, got)
+			}
+		})
+	}
+}
+
+func TestSuggestPriceCache(t *testing.T) {
+	config := gasprice.Config{
+		Blocks:     2,
+		Percentile: 60,
+		Default:    big.NewInt(params.GWei),
+	}
+	backend := newTestBackend(t)
+	cache := gasprice.NewTipCache(16)
+	expect := big.NewInt(params.GWei * int64(30))
+	for i := 0; i < 2; i++ {
+		got, err := gasprice.NewOracle(backend, config).WithTipCache(cache).SuggestTipCap(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to retrieve recommended gas price: %v", err)
+		}
+		if got.Cmp(expect) != 0 {
+			t.Fatalf("Gas price mismatch, want %d, got %d", expect, got)
+		}
+		// callers may modify the result
+		got.SetUint64(0)
+		if cache.Len() != 6 {
+			t.Fatalf("Cached blocks mismatch, want 6, got %d", cache.Len())
+		}
+	}
+}
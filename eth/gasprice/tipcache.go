@@ -0,0 +1,61 @@
+package gasprice
+
+import (
+	"math/big"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon/common"
+)
+
+// tipCacheKey - tips of block depend on how many of them are sampled, block is identified by hash to survive reorgs
+type tipCacheKey struct {
+	hash  common.Hash
+	limit int
+}
+
+// TipCache keeps tips sampled from recent blocks and the last suggestion between SuggestTipCap calls, it's safe for
+// concurrent use. When the head moves only new blocks are read, bursts of requests for the same head are served
+// by one computation.
+type TipCache struct {
+	blocks *lru.Cache // thread-safe, tipCacheKey -> sorted []*uint256.Int, shared and must not be modified
+
+	lock      sync.Mutex // held by SuggestTipCap while it computes suggestion
+	lastHead  common.Hash
+	lastPrice *big.Int
+}
+
+// NewTipCache returns cache of tips of `size` most recently sampled blocks
+func NewTipCache(size int) *TipCache {
+	blocks, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+	return &TipCache{blocks: blocks}
+}
+
+func (c *TipCache) get(key tipCacheKey) ([]*uint256.Int, bool) {
+	if c == nil {
+		return nil, false
+	}
+	tips, ok := c.blocks.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return tips.([]*uint256.Int), true
+}
+
+func (c *TipCache) add(key tipCacheKey, tips []*uint256.Int) {
+	if c != nil {
+		c.blocks.Add(key, tips)
+	}
+}
+
+// Len - amount of cached blocks
+func (c *TipCache) Len() int {
+	if c == nil {
+		return 0
+	}
+	return c.blocks.Len()
+}
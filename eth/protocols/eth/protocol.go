@@ -233,6 +233,8 @@ func (tp TransactionsPacket) EncodeRLP(w io.Writer) error {
 			txLen = t.EncodingSize()
 		case *types.DynamicFeeTransaction:
 			txLen = t.EncodingSize()
+		case *types.BlobTx:
+			txLen = t.EncodingSize()
 		}
 		if txLen >= 56 {
 			txsLen += (bits.Len(uint(txLen)) + 7) / 8
@@ -262,6 +264,10 @@ func (tp TransactionsPacket) EncodeRLP(w io.Writer) error {
 			if err := t.EncodeRLP(w); err != nil {
 				return err
 			}
+		case *types.BlobTx:
+			if err := t.EncodeRLP(w); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -490,6 +496,8 @@ func (bb BlockBody) EncodeRLP(w io.Writer) error {
 			txLen = t.EncodingSize()
 		case *types.DynamicFeeTransaction:
 			txLen = t.EncodingSize()
+		case *types.BlobTx:
+			txLen = t.EncodingSize()
 		}
 		if txLen >= 56 {
 			txsLen += (bits.Len(uint(txLen)) + 7) / 8
@@ -538,6 +546,10 @@ func (bb BlockBody) EncodeRLP(w io.Writer) error {
 			if err := t.EncodeRLP(w); err != nil {
 				return err
 			}
+		case *types.BlobTx:
+			if err := t.EncodeRLP(w); err != nil {
+				return err
+			}
 		}
 	}
 	// encode Uncles
@@ -795,6 +807,8 @@ func (ptp PooledTransactionsPacket) EncodeRLP(w io.Writer) error {
 			txLen = t.EncodingSize()
 		case *types.DynamicFeeTransaction:
 			txLen = t.EncodingSize()
+		case *types.BlobTx:
+			txLen = t.EncodingSize()
 		}
 		if txLen >= 56 {
 			txsLen += (bits.Len(uint(txLen)) + 7) / 8
@@ -824,6 +838,10 @@ func (ptp PooledTransactionsPacket) EncodeRLP(w io.Writer) error {
 			if err := t.EncodeRLP(w); err != nil {
 				return err
 			}
+		case *types.BlobTx:
+			if err := t.EncodeRLP(w); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -872,6 +890,8 @@ func (ptp66 PooledTransactionsPacket66) EncodeRLP(w io.Writer) error {
 			txLen = t.EncodingSize()
 		case *types.DynamicFeeTransaction:
 			txLen = t.EncodingSize()
+		case *types.BlobTx:
+			txLen = t.EncodingSize()
 		}
 		if txLen >= 56 {
 			txsLen += (bits.Len(uint(txLen)) + 7) / 8
@@ -918,6 +938,10 @@ func (ptp66 PooledTransactionsPacket66) EncodeRLP(w io.Writer) error {
 			if err := t.EncodeRLP(w); err != nil {
 				return err
 			}
+		case *types.BlobTx:
+			if err := t.EncodeRLP(w); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
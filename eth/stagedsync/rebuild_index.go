@@ -0,0 +1,147 @@
+package stagedsync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common/dbutils"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// Indices rebuilt by IndexRebuilder
+const (
+	IndexTxLookup   = "tx-lookup"        // kv.TxLookup of TxLookup stage
+	IndexLogs       = "log-index"        // kv.LogTopicIndex and kv.LogAddressIndex of LogIndex stage
+	IndexCallTraces = "call-trace-index" // kv.CallFromIndex and kv.CallToIndex of CallTraces stage
+)
+
+var indexStages = map[string]stages.SyncStage{
+	IndexTxLookup:   stages.TxLookup,
+	IndexLogs:       stages.LogIndex,
+	IndexCallTraces: stages.CallTraces,
+}
+
+// rebuildIndexBatch - blocks indexed between reports of progress
+const rebuildIndexBatch = 10_000
+
+// IndexRebuilder - rebuilds indices of blocks already processed by their stages, for recovery from corrupted index
+// without resync. Stage of the index keeps indexing new blocks meanwhile
+type IndexRebuilder struct {
+	db     kv.RwDB
+	tmpdir string
+}
+
+func NewIndexRebuilder(db kv.RwDB, tmpdir string) *IndexRebuilder {
+	return &IndexRebuilder{db: db, tmpdir: tmpdir}
+}
+
+// Range - blocks which Rebuild of index `name` would index for [from, to]. `to` is limited by progress of stage of
+// the index. Bitmaps of log and call trace indices can be only appended to, so blocks of them are always indexed up to
+// progress of their stage
+func (r *IndexRebuilder) Range(ctx context.Context, name string, from, to uint64) (uint64, uint64, error) {
+	stage, ok := indexStages[name]
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown index %q, known are %s, %s and %s", name, IndexTxLookup, IndexLogs, IndexCallTraces)
+	}
+	var progress uint64
+	if err := r.db.View(ctx, func(tx kv.Tx) (err error) {
+		progress, err = stages.GetStageProgress(tx, stage)
+		return err
+	}); err != nil {
+		return 0, 0, err
+	}
+	if name != IndexTxLookup || to > progress {
+		to = progress
+	}
+	if from > to {
+		return 0, 0, fmt.Errorf("fromBlock %d is after block %d, the last one indexed by %s stage", from, to, stage)
+	}
+	return from, to, nil
+}
+
+// Rebuild - (re)builds index `name` of blocks of Range, `onProgress` receives the last indexed block after each batch
+// of them. tx-lookup is written by batches, each in its own transaction. Log and call trace indices are truncated to
+// `from` and rebuilt in one transaction: their stage waits for it
+func (r *IndexRebuilder) Rebuild(ctx context.Context, name string, from, to uint64, onProgress func(block uint64)) error {
+	from, to, err := r.Range(ctx, name, from, to)
+	if err != nil {
+		return err
+	}
+	logPrefix := "RebuildIndex " + name
+	log.Info(fmt.Sprintf("[%s] Started", logPrefix), "from", from, "to", to)
+	switch name {
+	case IndexTxLookup:
+		cfg := StageTxLookupCfg(r.db, prune.Mode{}, r.tmpdir)
+		for start := from; start <= to; start += rebuildIndexBatch {
+			end := batchEnd(start, to)
+			if err = r.db.Update(ctx, func(tx kv.RwTx) error {
+				return TxLookupTransform(logPrefix, tx, dbutils.EncodeBlockNumber(start), dbutils.EncodeBlockNumber(end), ctx.Done(), cfg)
+			}); err != nil {
+				return err
+			}
+			onProgress(end)
+		}
+	case IndexLogs:
+		cfg := StageLogIndexCfg(r.db, prune.Mode{}, r.tmpdir)
+		err = r.db.Update(ctx, func(tx kv.RwTx) error {
+			if from == 0 {
+				if err := clearBuckets(tx, kv.LogTopicIndex, kv.LogAddressIndex); err != nil {
+					return err
+				}
+			} else if err := unwindLogIndex(logPrefix, tx, from-1, cfg, ctx.Done()); err != nil {
+				return err
+			}
+			for start := from; start <= to; start += rebuildIndexBatch {
+				end := batchEnd(start, to)
+				if err := promoteLogIndex(logPrefix, tx, start, end, cfg, ctx); err != nil {
+					return err
+				}
+				onProgress(end)
+			}
+			return nil
+		})
+	case IndexCallTraces:
+		err = r.db.Update(ctx, func(tx kv.RwTx) error {
+			if from == 0 {
+				if err := clearBuckets(tx, kv.CallFromIndex, kv.CallToIndex); err != nil {
+					return err
+				}
+			} else if err := DoUnwindCallTraces(logPrefix, tx, to+1, from-1, ctx, r.tmpdir); err != nil {
+				return err
+			}
+			for start := from; start <= to; start += rebuildIndexBatch {
+				end := batchEnd(start, to)
+				if err := promoteCallTraces(logPrefix, tx, start, end, bitmapsBufLimit, bitmapsFlushEvery, ctx.Done(), r.tmpdir); err != nil {
+					return err
+				}
+				onProgress(end)
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+	log.Info(fmt.Sprintf("[%s] Done", logPrefix), "from", from, "to", to)
+	return nil
+}
+
+// batchEnd - the last block of batch starting with `start`, not after `to`
+func batchEnd(start, to uint64) uint64 {
+	if to-start >= rebuildIndexBatch {
+		return start + rebuildIndexBatch - 1
+	}
+	return to
+}
+
+func clearBuckets(tx kv.RwTx, buckets ...string) error {
+	for _, bucket := range buckets {
+		if err := tx.ClearBucket(bucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
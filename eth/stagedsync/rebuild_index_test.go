@@ -0,0 +1,94 @@
+package stagedsync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/bitmapdb"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebuildCallTraceIndex(t *testing.T) {
+	ctx, require := context.Background(), require.New(t)
+	db := memdb.NewTestDB(t)
+	require.NoError(db.Update(ctx, func(tx kv.RwTx) error {
+		genTestCallTraceSet(t, tx, 30)
+		if err := promoteCallTraces("test", tx, 0, 29, 0, time.Nanosecond, ctx.Done(), ""); err != nil {
+			return err
+		}
+		return stages.SaveStageProgress(tx, stages.CallTraces, 29)
+	}))
+	addr := [20]byte{19: 1}
+	froms := func() []uint64 {
+		var res []uint64
+		require.NoError(db.View(ctx, func(tx kv.Tx) error {
+			b, err := bitmapdb.Get64(tx, kv.CallFromIndex, addr[:], 0, 30)
+			res = b.ToArray()
+			return err
+		}))
+		return res
+	}
+	require.Equal([]uint64{6, 16, 26}, froms())
+
+	r := NewIndexRebuilder(db, t.TempDir())
+	from, to, err := r.Range(ctx, IndexCallTraces, 10, 15)
+	require.NoError(err)
+	require.Equal([2]uint64{10, 29}, [2]uint64{from, to}, "bitmaps are rebuilt up to progress of the stage")
+	_, _, err = r.Range(ctx, IndexCallTraces, 30, 40)
+	require.Error(err)
+	_, _, err = r.Range(ctx, "receipts", 0, 10)
+	require.Error(err)
+
+	// corrupted index is rebuilt from scratch
+	require.NoError(db.Update(ctx, func(tx kv.RwTx) error { return tx.ClearBucket(kv.CallFromIndex) }))
+	var progress []uint64
+	require.NoError(r.Rebuild(ctx, IndexCallTraces, 0, 29, func(block uint64) { progress = append(progress, block) }))
+	require.Equal([]uint64{6, 16, 26}, froms())
+	require.Equal([]uint64{29}, progress)
+
+	// rebuild of the tail keeps the rest of index
+	require.NoError(r.Rebuild(ctx, IndexCallTraces, 10, 29, func(uint64) {}))
+	require.Equal([]uint64{6, 16, 26}, froms())
+}
+
+func TestRebuildLogIndex(t *testing.T) {
+	ctx, require := context.Background(), require.New(t)
+	db := memdb.NewTestDB(t)
+	cfg := StageLogIndexCfg(db, prune.DefaultMode, "")
+	tx, err := db.BeginRw(ctx)
+	require.NoError(err)
+	defer tx.Rollback()
+	expectAddrs, expectTopics := genReceipts(t, tx, 100)
+	require.NoError(promoteLogIndex("test", tx, 0, 99, cfg, ctx))
+	require.NoError(stages.SaveStageProgress(tx, stages.LogIndex, 99))
+	require.NoError(tx.Commit())
+
+	check := func() {
+		require.NoError(db.View(ctx, func(tx kv.Tx) error {
+			for addr, expect := range expectAddrs {
+				m, err := bitmapdb.Get(tx, kv.LogAddressIndex, addr[:], 0, 1000)
+				require.NoError(err)
+				require.Equal(expect, m.GetCardinality())
+			}
+			for topic, expect := range expectTopics {
+				m, err := bitmapdb.Get(tx, kv.LogTopicIndex, topic[:], 0, 1000)
+				require.NoError(err)
+				require.Equal(expect, m.GetCardinality())
+			}
+			return nil
+		}))
+	}
+	check()
+
+	r := NewIndexRebuilder(db, t.TempDir())
+	require.NoError(r.Rebuild(ctx, IndexLogs, 50, 60, func(uint64) {}))
+	check()
+	require.NoError(db.Update(ctx, func(tx kv.RwTx) error { return tx.ClearBucket(kv.LogTopicIndex) }))
+	require.NoError(r.Rebuild(ctx, IndexLogs, 0, 99, func(uint64) {}))
+	check()
+}
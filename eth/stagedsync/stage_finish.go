@@ -17,6 +17,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/rawdb"
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/ethdb/cbor"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/log/v3"
 )
@@ -86,6 +87,14 @@ func UnwindFinish(u *UnwindState, tx kv.RwTx, cfg FinishCfg, ctx context.Context
 		defer tx.Rollback()
 	}
 
+	// logs of unwound blocks are still there: Execution stage unwinds after this one
+	if u.state != nil && u.CurrentBlockNumber > u.UnwindPoint {
+		unwound, err := readUnwoundChain(tx, u.UnwindPoint, u.CurrentBlockNumber)
+		if err != nil {
+			return err
+		}
+		u.state.addUnwound(unwound)
+	}
 	if err = u.Done(tx); err != nil {
 		return err
 	}
@@ -97,6 +106,71 @@ func UnwindFinish(u *UnwindState, tx kv.RwTx, cfg FinishCfg, ctx context.Context
 	return nil
 }
 
+// maxUnwoundLogsBlocks - logs of longer unwinds (for example manual ones) are not read, only the reorg is notified
+const maxUnwoundLogsBlocks = 1024
+
+// UnwoundChain - canonical blocks removed by unwind: the ones after CommonAncestor up to OldHead, with their logs
+type UnwoundChain struct {
+	OldHead        privateapi.BlockRef
+	CommonAncestor privateapi.BlockRef
+	RemovedLogs    []*remote.SubscribeLogsReply
+}
+
+// readUnwoundChain - blocks of canonical chain from `unwindPoint` up to `head`. Canonical hashes may already point to
+// new chain, so blocks are found by parent hashes from head block
+func readUnwoundChain(tx kv.Tx, unwindPoint, head uint64) (*UnwoundChain, error) {
+	unwound := &UnwoundChain{OldHead: privateapi.BlockRef{Number: head, Hash: rawdb.ReadHeadBlockHash(tx)}}
+	hashes := make(map[uint64]common2.Hash, head-unwindPoint)
+	hash := unwound.OldHead.Hash
+	for number := head; number > unwindPoint; number-- {
+		header := rawdb.ReadHeader(tx, hash, number)
+		if header == nil {
+			log.Warn("Unwound block not found, its logs are not notified", "number", number, "hash", hash)
+			return unwound, nil
+		}
+		hashes[number] = hash
+		hash = header.ParentHash
+	}
+	unwound.CommonAncestor = privateapi.BlockRef{Number: unwindPoint, Hash: hash}
+	if head-unwindPoint > maxUnwoundLogsBlocks {
+		log.Warn("Too many blocks unwound, their logs are not notified", "from", unwindPoint+1, "to", head)
+		return unwound, nil
+	}
+	var err error
+	unwound.RemovedLogs, err = readLogs(tx, unwindPoint+1, true, func(number uint64) (*types.Block, error) {
+		hash, ok := hashes[number]
+		if !ok {
+			return nil, nil
+		}
+		return rawdb.ReadBlock(tx, hash, number), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return unwound, nil
+}
+
+// addUnwound - merges unwinds of one cycle: later unwind may remove also blocks added by this cycle, they are skipped
+// as they weren't notified yet
+func (s *Sync) addUnwound(unwound *UnwoundChain) {
+	prev := s.prevUnwound
+	if prev == nil {
+		s.prevUnwound = unwound
+		return
+	}
+	if unwound.CommonAncestor.Number >= prev.CommonAncestor.Number {
+		return
+	}
+	var older []*remote.SubscribeLogsReply
+	for _, l := range unwound.RemovedLogs {
+		if l.BlockNumber <= prev.CommonAncestor.Number {
+			older = append(older, l)
+		}
+	}
+	prev.CommonAncestor = unwound.CommonAncestor
+	prev.RemovedLogs = append(older, prev.RemovedLogs...)
+}
+
 func PruneFinish(u *PruneState, tx kv.RwTx, cfg FinishCfg, ctx context.Context) (err error) {
 	useExternalTx := tx != nil
 	if !useExternalTx {
@@ -115,7 +189,7 @@ func PruneFinish(u *PruneState, tx kv.RwTx, cfg FinishCfg, ctx context.Context)
 	return nil
 }
 
-func NotifyNewHeaders(ctx context.Context, finishStageBeforeSync uint64, finishStageAfterSync uint64, unwindTo *uint64, notifier ChainEventNotifier, tx kv.Tx) error {
+func NotifyNewHeaders(ctx context.Context, finishStageBeforeSync uint64, finishStageAfterSync uint64, unwindTo *uint64, unwound *UnwoundChain, notifier ChainEventNotifier, tx kv.Tx) error {
 	t := time.Now()
 	if notifier == nil {
 		log.Trace("RPC Daemon notification channel not set. No headers notifications will be sent")
@@ -123,10 +197,8 @@ func NotifyNewHeaders(ctx context.Context, finishStageBeforeSync uint64, finishS
 	}
 	// Notify all headers we have (either canonical or not) in a maximum range span of 1024
 	var notifyFrom uint64
-	var isUnwind bool
 	if unwindTo != nil && *unwindTo != 0 && (*unwindTo) < finishStageBeforeSync {
 		notifyFrom = *unwindTo
-		isUnwind = true
 	} else {
 		heightSpan := finishStageAfterSync - finishStageBeforeSync
 		if heightSpan > 1024 {
@@ -149,14 +221,25 @@ func NotifyNewHeaders(ctx context.Context, finishStageBeforeSync uint64, finishS
 		log.Error("RPC Daemon notification failed", "error", err)
 		return err
 	}
+	if unwound != nil {
+		notifier.OnReorg(&privateapi.Reorg{
+			OldHead:        unwound.OldHead,
+			NewHead:        privateapi.BlockRef{Number: finishStageAfterSync, Hash: rawdb.ReadHeadBlockHash(tx)},
+			CommonAncestor: unwound.CommonAncestor,
+		})
+	}
 	notifier.OnNewHeader(headersRlp)
 	headerTiming := time.Since(t)
 	t = time.Now()
 	if notifier.HasLogSubsriptions() {
-		logs, err := ReadLogs(tx, notifyFrom, isUnwind)
+		// logs of the new chain aren't removed ones, those were read before unwind
+		logs, err := ReadLogs(tx, notifyFrom, false)
 		if err != nil {
 			return err
 		}
+		if unwound != nil {
+			logs = append(unwound.RemovedLogs, logs...)
+		}
 		notifier.OnLogs(logs)
 	}
 	logTiming := time.Since(t)
@@ -165,6 +248,13 @@ func NotifyNewHeaders(ctx context.Context, finishStageBeforeSync uint64, finishS
 }
 
 func ReadLogs(tx kv.Tx, from uint64, isUnwind bool) ([]*remote.SubscribeLogsReply, error) {
+	return readLogs(tx, from, isUnwind, func(number uint64) (*types.Block, error) {
+		return rawdb.ReadBlockByNumber(tx, number)
+	})
+}
+
+// readLogs - logs of blocks from `from`, up to the last one found by `blockByNumber`
+func readLogs(tx kv.Tx, from uint64, isUnwind bool, blockByNumber func(number uint64) (*types.Block, error)) ([]*remote.SubscribeLogsReply, error) {
 	logs, err := tx.Cursor(kv.Log)
 	if err != nil {
 		return nil, err
@@ -184,9 +274,12 @@ func ReadLogs(tx kv.Tx, from uint64, isUnwind bool) ([]*remote.SubscribeLogsRepl
 		if block == nil || blockNum != prevBlockNum {
 			logIndex = 0
 			prevBlockNum = blockNum
-			if block, err = rawdb.ReadBlockByNumber(tx, blockNum); err != nil {
+			if block, err = blockByNumber(blockNum); err != nil {
 				return nil, err
 			}
+			if block == nil {
+				break
+			}
 		}
 		txIndex := uint64(binary.BigEndian.Uint32(k[8:]))
 		txHash := block.Transactions()[txIndex].Hash()
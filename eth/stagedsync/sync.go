@@ -14,8 +14,9 @@ import (
 )
 
 type Sync struct {
-	unwindPoint     *uint64 // used to run stages
-	prevUnwindPoint *uint64 // used to get value from outside of staged sync after cycle (for example to notify RPCDaemon)
+	unwindPoint     *uint64       // used to run stages
+	prevUnwindPoint *uint64       // used to get value from outside of staged sync after cycle (for example to notify RPCDaemon)
+	prevUnwound     *UnwoundChain // canonical blocks removed during cycle, used to notify RPCDaemon about reorg
 	badBlock        common.Hash
 
 	stages       []*Stage
@@ -32,8 +33,9 @@ type Timing struct {
 	took     time.Duration
 }
 
-func (s *Sync) Len() int                 { return len(s.stages) }
-func (s *Sync) PrevUnwindPoint() *uint64 { return s.prevUnwindPoint }
+func (s *Sync) Len() int                   { return len(s.stages) }
+func (s *Sync) PrevUnwindPoint() *uint64   { return s.prevUnwindPoint }
+func (s *Sync) PrevUnwound() *UnwoundChain { return s.prevUnwound }
 
 func (s *Sync) NewUnwindState(id stages.SyncStage, unwindPoint, currentProgress uint64) *UnwindState {
 	return &UnwindState{id, unwindPoint, currentProgress, common.Hash{}, s}
@@ -190,6 +192,7 @@ func (s *Sync) StageState(stage stages.SyncStage, tx kv.Tx, db kv.RoDB) (*StageS
 
 func (s *Sync) Run(db kv.RwDB, tx kv.RwTx, firstCycle bool) error {
 	s.prevUnwindPoint = nil
+	s.prevUnwound = nil
 	s.timings = s.timings[:0]
 	for !s.IsDone() {
 		var badBlockUnwind bool
@@ -6,7 +6,7 @@
 // evmdis_tracer.js (4.195kB)
 // noop_tracer.js (1.271kB)
 // opcount_tracer.js (1.372kB)
-// prestate_tracer.js (4.287kB)
+// prestate_tracer.js (4.463kB)
 // trigram_tracer.js (1.788kB)
 // unigram_tracer.js (1.469kB)
 
@@ -197,7 +197,7 @@ func opcount_tracerJs() (*asset, error) {
 	return a, nil
 }
 
-var _prestate_tracerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x57\xdd\x6f\xdb\x38\x12\x7f\xb6\xfe\x8a\x41\x5f\x6c\x5d\x5d\xb9\xcd\x02\x7b\x80\x73\x39\x40\x75\xdd\x36\x40\x36\x09\x6c\xe7\x72\xb9\xc5\x3e\x50\xe4\x48\xe6\x9a\x26\x05\x92\xb2\xe3\x2b\xf2\xbf\x1f\x86\xfa\xf0\x47\x93\xa6\x7b\x6f\x16\x39\xfc\xcd\xf7\x6f\xc6\xa3\x11\x4c\x4c\xb9\xb3\xb2\x58\x7a\x38\x7b\xff\xe1\xef\xb0\x58\x22\x14\xe6\x1d\xfa\x25\x5a\xac\xd6\x90\x56\x7e\x69\xac\x8b\x46\x23\x58\x2c\xa5\x83\x5c\x2a\x04\xe9\xa0\x64\xd6\x83\xc9\xc1\x9f\xc8\x2b\x99\x59\x66\x77\x49\x34\x1a\xd5\x6f\x9e\xbd\x26\x84\xdc\x22\x82\x33\xb9\xdf\x32\x8b\x63\xd8\x99\x0a\x38\xd3\x60\x51\x48\xe7\xad\xcc\x2a\x8f\x20\x3d\x30\x2d\x46\xc6\xc2\xda\x08\x99\xef\x08\x52\x7a\xa8\xb4\x40\x1b\x54\x7b\xb4\x6b\xd7\xda\xf1\xe5\xfa\x0e\xae\xd0\x39\xb4\xf0\x05\x35\x5a\xa6\xe0\xb6\xca\x94\xe4\x70\x25\x39\x6a\x87\xc0\x1c\x94\x74\xe2\x96\x28\x20\x0b\x70\xf4\xf0\x33\x99\x32\x6f\x4c\x81\xcf\xa6\xd2\x82\x79\x69\xf4\x10\x50\x92\xe5\xb0\x41\xeb\xa4\xd1\xf0\x4b\xab\xaa\x01\x1c\x82\xb1\x04\x32\x60\x9e\x1c\xb0\x60\x4a\x7a\x17\x03\xd3\x3b\x50\xcc\xef\x9f\xfe\x44\x40\xf6\x7e\x0b\x90\x3a\xa8\x59\x9a\x12\xc1\x2f\x99\x27\xaf\xb7\x52\x29\xc8\x10\x2a\x87\x79\xa5\x86\x84\x96\x55\x1e\xee\x2f\x17\x5f\x6f\xee\x16\x90\x5e\x3f\xc0\x7d\x3a\x9b\xa5\xd7\x8b\x87\x73\xd8\x4a\xbf\x34\x95\x07\xdc\x60\x0d\x25\xd7\xa5\x92\x28\x60\xcb\xac\x65\xda\xef\xc0\xe4\x84\xf0\xdb\x74\x36\xf9\x9a\x5e\x2f\xd2\x8f\x97\x57\x97\x8b\x07\x30\x16\x3e\x5f\x2e\xae\xa7\xf3\x39\x7c\xbe\x99\x41\x0a\xb7\xe9\x6c\x71\x39\xb9\xbb\x4a\x67\x70\x7b\x37\xbb\xbd\x99\x4f\x13\x98\x23\x59\x85\xf4\xfe\xf5\x98\xe7\x21\x7b\x16\x41\xa0\x67\x52\xb9\x36\x12\x0f\xa6\x02\xb7\x34\x95\x12\xb0\x64\x1b\x04\x8b\x1c\xe5\x06\x05\x30\xe0\xa6\xdc\xfd\x74\x52\x09\x8b\x29\xa3\x8b\xe0\xf3\x8b\x05\x09\x97\x39\x68\xe3\x87\xe0\x10\xe1\x1f\x4b\xef\xcb\xf1\x68\xb4\xdd\x6e\x93\x42\x57\x89\xb1\xc5\x48\xd5\x70\x6e\xf4\xcf\x24\x22\xcc\xd2\xa2\xf3\xcc\xe3\xc2\x32\x8e\x16\x4c\xe5\xcb\xca\x3b\x70\x55\x9e\x4b\x2e\x51\x7b\x90\x3a\x37\x76\x1d\x2a\x05\xbc\x01\x6e\x91\x79\x04\x06\xca\x70\xa6\x00\x1f\x91\x57\xe1\xae\x8e\x74\x28\x57\xcb\xb4\x63\x3c\x9c\xe6\xd6\xac\xc9\xd7\xca\x79\xfa\xe1\x1c\xae\x33\x85\x02\x0a\xd4\xe8\xa4\x83\x4c\x19\xbe\x4a\xa2\x6f\x51\xef\xc0\x18\xaa\x93\xe0\x61\x23\x14\x6a\x63\x8b\x7d\x8b\x90\x55\x52\x09\xa9\x8b\x24\xea\xb5\xd2\x63\xd0\x95\x52\xc3\x28\x40\x28\x63\x56\x55\x99\x72\x6e\xaa\x60\xfb\x9f\xc8\x7d\x0d\xe6\x4a\xe4\x32\xa7\xe2\x60\xdd\xad\x37\xe1\xaa\xd3\x6b\x32\x92\x4f\xa2\xde\x11\xcc\x18\xf2\x4a\x07\x77\x06\x4c\x08\x3b\x04\x91\xc5\xdf\xa2\x5e\x6f\xc3\x2c\x61\xc1\x05\x78\xf3\x15\x1f\xc3\x65\x7c\x1e\xf5\x7a\x32\x87\x81\x5f\x4a\x97\xb4\xc0\xbf\x33\xce\xff\x80\x8b\x8b\x8b\xd0\xd4\xb9\xd4\x28\x62\x20\x88\xde\x73\x62\xf5\x4d\x2f\x63\x8a\x69\x8e\x63\xe8\xbf\x7f\xec\xc3\x5b\x10\x59\x52\xa0\xff\x58\x9f\xd6\xca\x12\x6f\xe6\xde\x4a\x5d\x0c\x3e\xfc\x1a\x0f\xc3\x2b\x6d\xc2\x1b\x68\xc4\xaf\x4d\x27\x5c\xdf\x73\x23\xc2\x75\x63\x73\x2d\x35\x31\xa2\x11\x6a\xa4\x9c\x37\x96\x15\x38\x86\x6f\x4f\xf4\xfd\x44\x5e\x3d\x45\xbd\xa7\xa3\x28\xcf\x6b\xa1\x17\xa2\xdc\x40\x00\x6a\x6f\xbb\x3a\x2f\x24\x75\xea\x61\x02\x02\xde\x8f\x92\x30\x6f\x4d\x39\x49\xc2\x0a\x77\xaf\x67\x82\x2e\xa4\x78\xec\x2e\x56\xb8\x8b\xcf\xa3\x17\x53\x94\x34\x46\xff\x2e\xc5\xe3\xcf\xe6\xeb\xe4\xcd\x51\x5c\xe7\x24\xb5\xb7\x37\x8e\x4f\xe2\x68\xd1\x55\xca\x53\xb9\x4b\xbd\x31\x2b\x22\xae\x25\xc5\x47\xa9\x10\x12\x53\x52\xb6\x5c\xcd\x1c\x19\xa2\x06\xe9\xd1\x32\xa2\x4e\xb3\x41\x4b\x53\x03\x2c\xfa\xca\x6a\xd7\x85\x31\x97\x9a\xa9\x16\xb8\x89\xba\xb7\x8c\xd7\x3d\x53\x9f\x1f\xc4\x92\xfb\xc7\x10\xc5\xe0\xdd\x68\x04\xa9\x07\x72\x11\x4a\x23\xb5\x1f\xc2\x16\x41\x23\x0a\x6a\x7c\x81\xa2\xe2\x3e\xe0\xf5\x37\x4c\x55\xd8\xaf\x9b\x9b\x28\x32\x3c\x35\x15\x4d\x82\x83\xe6\x1f\x06\x03\xd7\x66\x13\x46\x5c\xc6\xf8\x0a\x9a\x86\x33\x56\x16\x52\x47\x4d\x38\x8f\x9a\x8d\x2c\x4a\x08\x38\x98\x15\x72\x45\x49\xa4\x93\x8f\x4c\xc1\x05\x64\xb2\xb8\xd4\xfe\x24\x79\x75\xd0\xdb\xa7\xf1\x1f\x49\xd3\x3c\x89\x23\xc2\x1b\x9c\xc5\x43\xf8\xf0\x6b\x57\x11\xde\x10\x14\xbc\x0e\xe6\xcd\xcb\x50\xd1\x69\x31\x3c\xff\x2c\xa8\xa1\x0e\x7e\x1b\xb4\x26\xae\xca\x28\x1d\xb5\x9f\x21\x8e\xc7\x5d\x7c\xfe\x03\xdc\x63\xdf\x5a\xdc\x26\x34\x09\x13\xe2\x10\x94\x3e\xc3\x77\xc1\xdc\x9d\x43\x01\x6f\x81\xbe\xa4\x26\x55\x4e\xf2\x2f\xcc\xc5\xf0\x37\x68\x24\x6e\xad\xe4\xdf\x59\x52\xe7\xf5\x13\x72\x8b\x6b\x1a\x05\x94\x3a\xce\x94\x42\xdb\x77\x10\x88\x66\xd8\xd4\x60\x48\x32\xae\x4b\xbf\x6b\x07\x84\x67\xb6\x40\xef\x5e\xf7\x26\xe0\xbc\x7b\xd7\xf2\x66\x88\xdf\xae\x44\xb8\xb8\x80\xfe\x64\x36\x4d\x17\xd3\x7e\xd3\x7b\xa3\x11\xdc\x63\x58\x9f\x32\x25\x33\xa1\x76\x20\x50\xa1\xc7\xda\x2e\xa3\x43\x5c\x3b\x1e\x19\xd2\x1e\x44\x1b\x0a\x3e\x4a\xe7\xa5\x2e\xa0\xa6\x97\x2d\x0d\xe3\x06\x2e\x34\x16\x67\x15\x85\xe7\x74\x72\x79\x43\x6b\x88\x45\x22\x23\x1a\x1a\xa1\x47\x99\x92\xdd\xda\x92\x4b\xeb\x3c\x94\x8a\x71\x4c\x08\xaf\x33\xe6\xe5\xa2\x68\xda\x9f\x54\xcf\x42\xdf\x06\xa0\xfd\x54\x64\x8a\xa6\x2a\xa9\x77\x30\x68\x31\xe2\xa8\xd7\xb3\xad\xf4\x01\xf6\xf9\x9e\x47\x9c\xc7\xf2\x90\x45\x68\x1b\xc1\x0d\x12\xef\x06\x0a\xa9\x27\x28\xe9\xfa\xd7\x6f\xcd\xc8\x46\x97\x44\x3d\x7a\x77\x40\x06\xca\x14\xc7\x64\x20\xea\xb0\xf0\xca\x5a\xca\x7f\xc7\xdb\x39\x11\xc3\x9f\x95\xf3\x14\x53\x4b\xe1\x69\x28\xe6\x39\x66\x0d\x3c\x4a\x23\x3a\xfe\x9e\x41\x69\xd8\x85\xe1\x42\xea\x9a\xd1\x56\xaf\x80\xa5\xf1\xa8\xbd\x64\x4a\xed\x28\x0f\x5b\x4b\xbb\x0f\x6d\x3b\x43\x70\x92\xa4\x02\x4d\x05\x51\xa9\xb9\xaa\x44\x5d\x06\xa1\xf8\x1b\x3c\x17\x6c\x3e\x5e\x9a\xd6\xe8\x1c\x2b\x30\xa1\x4a\xca\xe5\x63\xb3\x76\x6a\xe8\xd7\xcc\x38\x88\xfb\x49\x67\xe4\x31\x2f\x29\x53\x24\x6d\x91\x11\xb7\xa7\x42\x58\x74\x6e\x10\x37\x44\xd5\x65\xf6\x7e\x89\x9a\x82\x0f\x1a\xb7\xd0\xed\x33\x8c\x73\xda\xef\xc4\x10\x98\x10\xc4\x87\x27\xbb\x47\xd4\xeb\xb9\xad\xf4\x7c\x09\x41\x93\x29\xf7\xbd\x18\x37\xf5\xcf\x99\x43\x78\x33\xfd\xf7\x62\x72\xf3\x69\x3a\xb9\xb9\x7d\x78\x33\x86\xa3\xb3\xf9\xe5\x7f\xa6\xdd\xd9\xc7\xf4\x2a\xbd\x9e\x4c\xdf\x8c\xc3\x40\x7f\xc6\x21\x6f\x5a\x17\x48\xa1\xf3\x8c\xaf\x92\x12\x71\x35\x78\x7f\xcc\x03\x7b\x07\x7b\xbd\xcc\x22\x5b\x9d\xef\x8d\xa9\x1b\xb4\xd1\xd1\xf2\x34\x5c\xc0\x8b\xc1\x3a\x7f\xd9\x9a\x49\x23\x3f\x68\xd9\x7f\xbf\xbf\x04\xaa\x78\xdd\x8e\xb3\xbf\x6c\x48\xe8\x1d\xc6\x57\x63\x70\x4c\xd1\xda\x2c\xff\x4b\x7f\x77\xf2\xdc\xa1\x1f\x02\x6a\x61\xb6\xc4\x7c\x1d\x6a\x7d\xd3\xe0\x1e\x84\xec\x43\x5c\xd3\xee\x4d\x3e\x88\x3b\x61\x02\xfb\x5e\xf4\xec\x39\x51\xd4\x02\x2e\x5a\xf4\xb7\xe1\xe5\xeb\x81\x3a\x6b\x22\x75\xa2\xe0\x97\x93\xb5\x30\xdc\xaf\x71\x6d\xec\xae\x99\x61\x07\xfe\xfd\x38\xaa\xe9\xd5\x55\x57\x4f\xf4\x41\x45\xd6\x1d\x7c\x9a\x5e\x4d\xbf\xa4\x8b\xe9\x91\xd4\x7c\x91\x2e\x2e\x27\xf5\xd1\x5f\x2e\xbc\x0f\x3f\x5d\x78\xfd\xf9\x7c\x71\x33\x9b\xf6\xc7\xcd\xd7\xd5\x4d\xfa\xa9\xff\x9d\xc2\x66\x75\xfc\x51\xeb\x7a\x73\x6f\xac\xf8\x7f\x3a\xe0\x60\x8d\xcb\xd9\x73\x5b\x5c\xa0\x76\xee\xab\x93\x7f\x49\xc0\x74\xcb\xca\x79\xfd\x4f\xb1\x17\xde\x3f\xcb\xc3\x4f\xd1\x53\xf4\xbf\x00\x00\x00\xff\xff\x3a\xb7\x37\x41\xbf\x10\x00\x00")
+var _prestate_tracerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\x03\x9d\x57\xdb\x6e\x1b\x39\x12\x7d\xb6\xbe\xa2\x90\x17\x49\x13\xa5\x95\x78\x80\x1d\xc0\xde\x2c\xa0\x28\x4a\x62\x40\x63\x1b\x92\x3c\x19\xef\x60\x1e\xfa\xc2\x96\x38\x6e\x35\x1b\x24\xdb\xb2\x76\xe0\x7f\x9f\x53\x24\x5b\xb7\x58\x76\xb2\x4f\x76\x93\x75\x3d\x55\x75\x58\xea\xf7\x69\xa8\xaa\xb5\x96\xf3\x85\xa5\xd3\xb7\xef\x7e\xa1\xd9\x42\xd0\x5c\xbd\x11\x76\x21\xb4\xa8\x97\x34\xa8\xed\x42\x69\xd3\xea\xf7\x71\x25\x0d\xe5\xb2\x10\x84\xbf\x55\xac\x2d\xa9\x9c\xec\x81\x7c\x21\x13\x1d\xeb\x75\x04\x05\xaf\xf3\xe4\x35\x5b\xc8\xb5\x10\x64\x54\x6e\x57\xb1\x16\x67\xb4\x56\x35\xa5\x71\x49\x5a\x64\xd2\x58\x2d\x93\xda\xc2\x91\xa5\xb8\xcc\xfa\x4a\xd3\x52\x65\x32\x5f\xb3\x49\x9c\xd5\x65\x26\xb4\x73\x6d\x85\x5e\x9a\x26\x8e\xcf\x97\x37\x34\x16\xc6\xe0\xee\xb3\x28\x85\x8e\x0b\xba\xae\x93\x42\xa6\x34\x96\xa9\x28\x8d\xa0\x18\x81\xf3\x89\x59\x88\x8c\x12\x67\x8e\x15\x3f\x71\x28\xd3\x10\x0a\x7d\x52\xb0\x1f\x5b\xa9\xca\x1e\x09\xc9\x91\xd3\xbd\xd0\x06\xdf\xf4\x73\xe3\x2a\x18\xec\x91\xd2\x6c\xa4\x13\x5b\x4e\x40\x93\xaa\x58\xaf\x8b\xa8\xd7\x54\xc4\x76\xab\xfa\x1d\x80\x6c\xf3\xce\x48\x96\xce\xcd\x42\x55\xc8\x71\x01\xeb\xc8\x7a\x25\x8b\x82\x12\x41\xb5\x11\x79\x5d\xf4\xd8\x1a\x84\xe9\xeb\xc5\xec\xcb\xd5\xcd\x8c\x06\x97\xb7\xf4\x75\x30\x99\x0c\x2e\x67\xb7\xe7\x10\x46\xdd\x70\x2b\xee\x85\x37\x25\x97\x55\x21\x61\x19\x29\xea\xb8\xb4\x6b\x64\xc2\x16\x7e\x1d\x4d\x86\x5f\xa0\x32\xf8\x70\x31\xbe\x98\xdd\x22\x1f\xfa\x74\x31\xbb\x1c\x4d\xa7\xf4\xe9\x6a\x42\x03\xba\x1e\x4c\x66\x17\xc3\x9b\xf1\x60\x42\xd7\x37\x93\xeb\xab\xe9\x28\xa2\xa9\xe0\xa8\x04\xeb\xbf\x8c\x79\xee\xaa\x07\x5c\x33\x61\x63\x59\x98\x06\x89\x5b\x14\xdc\x20\xc6\x22\xa3\x45\x7c\x2f\x50\xf8\x54\xc8\x7b\x44\x18\x53\x8a\x9e\xfc\xee\xa2\xb2\xad\xb8\x50\xe5\xdc\xe5\x7c\xb4\x21\xe9\x22\xa7\x52\xd9\x1e\x19\x04\xff\xef\x85\xb5\xd5\x59\xbf\xbf\x5a\xad\xa2\x79\x59\x47\x4a\xcf\xfb\x85\x37\x67\xfa\xff\x89\x5a\x6c\xb3\xd2\xc2\x58\x94\x70\xa6\xe3\x14\xce\x01\x66\x55\x5b\x43\xa6\xce\x73\x99\x4a\x51\xa2\x26\x25\x72\x5b\xba\x4e\x21\xab\x28\xd5\x02\xe2\x08\xbf\x50\x29\xa2\x14\x0f\x22\xad\xdd\x9d\x47\xda\xb5\x2b\xa0\x37\x71\xea\x4e\x73\xad\x96\x9c\x6b\x6d\x2c\xff\x83\x0c\x97\x49\x81\xf4\xe7\xc8\xd2\xa0\x1d\x12\x98\xb9\x8b\x5a\x7f\xb7\x4e\x76\x82\xe1\x3e\x71\x19\x06\x21\xd7\x1b\x2b\xd1\x06\xbc\x49\x2d\x8b\x4c\x96\xf3\xa8\x75\xd2\x48\x9f\x51\x59\x17\xe8\x14\x67\xa2\x50\xea\xae\xae\x06\x69\x8a\xf6\xe6\xd8\xff\x12\xa9\xf5\xc6\x4c\x25\x52\x99\x73\x73\xc4\x9b\x5b\xe4\xc3\x57\x1b\xbf\x2a\x61\x79\xd8\xde\x33\x73\x46\x79\x5d\xba\x74\x3a\x71\x96\xe9\x1e\x65\x49\x17\x01\x9f\xdc\xc7\x9a\x6d\xd1\x7b\xe0\xf2\x45\x3c\xb8\xcb\xee\x39\x2e\x64\x4e\x1d\x0b\x1e\x89\x1a\xc3\x7f\x40\xec\x4f\x7a\xff\xfe\xbd\x1b\xea\x5c\x96\x22\xeb\x12\x9b\x38\x79\x4a\xcc\xdf\x9c\x24\x71\x11\x97\x29\xd2\x6b\xbf\x7d\x68\xd3\x6b\x78\x8d\xe6\xc2\x7e\xf0\xa7\xde\x59\x64\xd5\x14\xd3\x54\xce\x3b\xef\xfe\xd5\xed\x39\xad\x52\x39\x1d\x0a\xe2\x97\x6a\x23\xec\xef\x53\x95\xb9\xeb\x10\xb3\x97\x1a\xe2\xd0\x0b\x05\x29\x54\x4b\xc7\x73\x08\xfe\xfd\xc8\xdf\x8f\x9c\x15\xfe\x7b\xdc\x43\x79\xea\x85\x8e\xa0\x1c\x4c\x10\x7a\x48\x6f\xfa\x7c\x2e\x79\x52\x77\x0b\xe0\xec\x3d\x57\x84\x69\x13\xca\x41\x11\xee\xc4\xfa\xe5\x4a\xf0\x85\xcc\x1e\x36\x17\x50\xc2\xf9\xd1\x12\x45\x21\xe8\x3f\xa0\xf3\xbd\xf5\x3a\xd0\xd9\xc3\x75\xca\x52\xdb\x78\xbb\xdd\x03\x1c\x61\xa7\x2e\x2c\xb7\xbb\x2c\xef\xd5\x1d\x13\xd7\x82\xf1\x01\x05\x32\x24\xaa\xe2\x6a\x19\xcf\x1c\x89\xc0\x8d\x04\xd9\xc6\x4c\x9d\x0a\x8c\xcb\xaf\x06\x4c\xd8\x5a\x97\x66\x03\x23\x82\xc5\x58\x06\xc3\x01\x75\x0c\x64\xea\x67\xc6\x9f\xef\x60\x99\xda\x07\x87\xa2\xcb\xee\x1b\x50\x1c\x04\x3c\x5d\x4f\x65\xcf\x8d\xea\x1a\x83\x5d\x83\x77\xec\x83\x9b\x5b\x1e\xfe\x5c\xe8\x37\xaa\x2c\x50\x20\x76\x0f\xce\x93\x95\xe3\x92\x50\xf8\xa0\xb3\x88\x4d\xd9\xb6\x3e\xb1\x4a\x55\x35\x3f\x25\x59\xb4\xf1\xb3\x37\x83\x1c\x28\xba\xdd\xc5\xea\x41\x6c\x39\x1b\x03\x4b\x2c\x0c\x7d\x34\x53\x0f\x24\x41\xa5\x00\x3c\x18\xec\x4c\x64\x75\x6a\x5d\x00\xed\xfb\xb8\xa8\x45\xdb\x93\x11\x53\xba\x53\x05\xd7\xf1\xfb\xba\x25\xab\x9e\x03\x74\x09\x68\xf9\x21\x4a\xe2\xf4\x8e\x02\x41\x28\xec\x0e\xb2\x6c\x1d\x0d\x8c\x0d\x87\xd0\x42\xd3\xf1\x09\x46\x15\x18\x25\x72\x7e\x01\xb1\xfd\xc6\xf1\x4d\xd2\xa8\x76\xff\x8c\xc2\xb0\x47\x86\x09\xba\x73\xda\xed\x11\x26\xba\xe9\x60\xab\xd8\x14\xbd\x6c\xcc\xaa\xe3\xa6\x5a\x87\xcd\xfb\xb4\x9a\x73\xc3\x8c\xf3\xda\x79\x8d\x4c\x9d\x70\xfb\xf8\x3c\x1d\x8e\xfb\xac\x73\xfe\x8c\xdd\xfd\xdc\x1a\xbb\x01\x9a\x08\x63\xb1\x6b\x94\x3f\xdd\xf7\x3c\x36\x37\x06\x35\x7c\x4d\xfc\x85\xb2\xc2\x95\x91\xe9\xe7\xd8\x74\xe9\x27\x0a\x12\xd7\x1a\xc9\x1d\x46\xe2\xeb\xfa\x51\xe0\x8d\x5a\x72\xbb\x71\xe9\xf0\x48\x15\x42\xb7\x0d\x39\x62\xec\x85\x99\x71\x45\x16\xcb\x0a\x1b\x42\x78\xd0\x6c\xac\x31\xb0\xe6\xe5\x6c\x9c\x9d\x37\x6f\x1a\x9e\x77\xf8\xad\x2b\x1e\x15\x6a\x0f\x27\xa3\xc1\x6c\xd4\x0e\xd3\x82\x58\xbe\x0a\xb7\xee\xe1\x25\x4f\xb2\x62\x8d\x9e\x2c\x84\x15\x3e\x2e\x55\x3a\x5c\x37\xbc\xd7\xe3\xbd\x8d\x37\x2a\xf1\x80\x15\x09\x39\x91\x9f\xb2\x15\x2f\x0f\x9b\x89\xb9\x67\x83\x35\xc3\x73\xf8\xd2\xa2\x55\x13\x9e\x35\x26\x4f\x7e\xe4\x1c\xa7\xc4\x85\xdc\xac\x59\xb9\xd4\x06\xee\x0a\x3c\xf4\x6e\xc8\x36\xc1\x1c\x6f\x8a\x40\x57\xec\x7a\xe2\x78\xc6\x19\xda\xbe\xe2\xc0\x16\x5b\x00\xbb\x37\xd4\x69\x6c\x74\xa1\xa0\x1b\xe9\x1d\xdb\xe7\x5b\xde\x33\x56\x54\xbb\xac\xc7\xdb\x13\x76\x38\x7e\x27\x1c\xe5\xf9\x17\x9f\x7d\xfd\xf6\x6b\x58\x31\x04\x56\xaa\x13\xd6\xdb\x21\xaf\x42\xcd\xb7\xe4\xc5\x64\x90\x79\x58\xd2\x5a\xeb\x1d\xba\x21\xd4\x09\xc4\xf0\x17\x76\x10\xc6\x54\x33\x3c\x81\x12\x9f\x27\xbd\x17\x38\x2f\x3c\xc5\x7e\x65\xad\x94\x85\x4b\x09\x44\xd6\x5c\x87\x95\xe6\x5d\x8d\xb7\x33\xec\x62\x92\xa5\x1c\x4d\x39\x51\x7c\x16\x75\xe6\xdb\xc0\x35\x7f\xb0\x67\x5c\xcc\xfb\x4b\xde\x12\x4b\x21\x1e\x97\x88\x3b\x29\x97\x0f\x61\x4d\x2e\xa9\xed\x99\xbc\xd3\x6d\x1f\x23\x4c\x80\x13\x35\x4d\xc6\x6f\x11\xc0\x81\x8e\xe9\x74\x77\x38\xd4\xf7\x28\x9e\x1c\x06\x1f\xcc\xb9\xa2\xcd\xfe\x05\xec\x78\x1f\xcd\xd0\x96\x40\x15\x7c\x78\xb0\x2b\x41\xd7\x20\xca\x74\x41\xce\x93\xaa\xb6\xb3\xd8\x0d\xfd\x9f\xc6\x58\x8c\x5f\x8d\x7e\x9f\x0d\xaf\x3e\x8e\x86\x57\xd7\xb7\xaf\xce\x68\xef\x6c\x7a\xf1\xdf\xd1\xe6\xec\xc3\x60\x3c\xb8\x1c\xe2\xdb\x2d\x20\x4f\x24\x64\x55\x93\x02\x3b\x44\x10\x58\x1c\x2b\x21\xee\x3a\x6f\xf7\x79\x60\x9b\x20\x76\x28\x0c\xf7\xdd\xf9\x36\x18\x3f\xa0\xc1\x47\xc3\xd3\x28\xea\x51\xb0\xce\x8f\x47\x33\x0c\xf2\x9d\x86\xfd\xb7\xfb\x96\xa3\x8a\x97\xe3\x38\xfd\xe1\x40\xdc\xec\x20\xf1\x33\x32\x71\xc1\x6b\xbe\xfc\x1f\xff\x3c\xcb\x73\x23\xf0\x25\xca\x4c\xad\x98\xf9\x36\x56\xfd\x4d\xb0\xbb\x03\xd9\xbb\xae\xa7\xdd\xab\xbc\xd3\xdd\x08\xb3\xb1\x6f\x45\x4f\x9f\x12\x85\x27\x48\x06\xeb\xaf\x9d\xe6\xcb\x40\x9d\x06\xa4\x0e\x1c\xfc\x7c\xb0\xc6\xba\xfb\x25\x28\x1a\xbf\x67\xfc\x1b\xb6\x93\xdf\xf3\xa8\x0e\xc6\xe3\x4d\x3f\xf1\x07\x37\xd9\xe6\xe0\xe3\x68\x3c\xfa\x0c\xd4\xf7\xa4\xa6\xb3\x01\x7e\xf8\xf9\xa3\x1f\x6e\xbc\x77\xdf\xdd\x78\xed\xe9\x74\x76\x35\x19\xb5\xcf\xc2\xd7\xf8\x6a\xf0\xb1\xfd\x8d\xc3\xb0\xea\x3e\x37\xba\x56\x7d\x55\x3a\xfb\x7f\x26\x60\x67\xed\xcc\xe3\xa7\xb6\x4e\x47\xed\xa9\xad\x0f\x7e\xd5\xe1\x4d\x6a\x58\x39\xf7\xbf\x6c\x4f\x9c\xfe\x93\x3c\xfc\xd8\x7a\x6c\xfd\x03\x85\x98\x9f\x7d\x6f\x11\x00\x00")
 
 func prestate_tracerJsBytes() ([]byte, error) {
 	return bindataRead(
@@ -213,7 +213,7 @@ func prestate_tracerJs() (*asset, error) {
 	}
 
 	info := bindataFileInfo{name: "prestate_tracer.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
-	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xd4, 0x9, 0xf9, 0x44, 0x13, 0x31, 0x89, 0xf7, 0x35, 0x9a, 0xc6, 0xf0, 0x86, 0x9d, 0xb2, 0xe3, 0x57, 0xe2, 0xc0, 0xde, 0xc9, 0x3a, 0x4c, 0x4a, 0x94, 0x90, 0xa5, 0x92, 0x2f, 0xbf, 0xc0, 0xb8}}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xdd, 0x37, 0x92, 0xd, 0xbc, 0xa8, 0xc3, 0x3d, 0x62, 0x1, 0x41, 0x4b, 0xc2, 0xfb, 0x92, 0x2, 0x2b, 0x9f, 0x92, 0x67, 0x53, 0xe4, 0xe9, 0xb4, 0xeb, 0x79, 0xee, 0xbe, 0xfe, 0x74, 0x70, 0xe9}}
 	return a, nil
 }
 
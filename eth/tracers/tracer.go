@@ -319,6 +319,8 @@ type Tracer struct {
 	reason    error  // Textual reason for the interruption
 
 	activePrecompiles []common.Address // Updated on CaptureStart based on given rules
+
+	env *vm.EVM // Set by SetEVM, used to initialize the context if no opcode was executed
 }
 
 // New instantiates a new tracer instance. code specifies a Javascript snippet,
@@ -562,29 +564,44 @@ func (jst *Tracer) CaptureStart(depth int, from common.Address, to common.Addres
 	return nil
 }
 
+// init fills the context with block related fields, once per transaction
+func (jst *Tracer) init(env *vm.EVM) error {
+	// Update list of precompiles based on current block
+	rules := env.ChainConfig().Rules(env.Context.BlockNumber)
+	jst.activePrecompiles = vm.ActivePrecompiles(rules)
+
+	jst.ctx["block"] = env.Context.BlockNumber
+	// Compute intrinsic gas
+	isHomestead := env.ChainRules.IsHomestead
+	isIstanbul := env.ChainRules.IsIstanbul
+	var input []byte
+	if data, ok := jst.ctx["input"].([]byte); ok {
+		input = data
+	}
+	intrinsicGas, err := core.IntrinsicGas(input, nil, jst.ctx["type"] == "CREATE", isHomestead, isIstanbul)
+	if err != nil {
+		return err
+	}
+	jst.ctx["intrinsicGas"] = intrinsicGas
+	jst.inited = true
+	return nil
+}
+
+// SetEVM gives the tracer access to the EVM before execution starts, so 'result' has the block context and
+// the state even if no opcode was executed (e.g. plain ether transfers).
+func (jst *Tracer) SetEVM(env *vm.EVM) {
+	jst.env = env
+	jst.dbWrapper.db = env.IntraBlockState
+}
+
 // CaptureState implements the Tracer interface to trace a single step of VM execution.
 func (jst *Tracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *stack.Stack, rdata []byte, contract *vm.Contract, depth int, err error) error {
 	if jst.err == nil {
 		// Initialize the context if it wasn't done yet
 		if !jst.inited {
-			// Update list of precompiles based on current block
-			rules := env.ChainConfig().Rules(env.Context.BlockNumber)
-			jst.activePrecompiles = vm.ActivePrecompiles(rules)
-
-			jst.ctx["block"] = env.Context.BlockNumber
-			// Compute intrinsic gas
-			isHomestead := env.ChainRules.IsHomestead
-			isIstanbul := env.ChainRules.IsIstanbul
-			var input []byte
-			if data, ok := jst.ctx["input"].([]byte); ok {
-				input = data
-			}
-			intrinsicGas, err1 := core.IntrinsicGas(input, nil, jst.ctx["type"] == "CREATE", isHomestead, isIstanbul)
-			if err1 != nil {
+			if err1 := jst.init(env); err1 != nil {
 				return err1
 			}
-			jst.ctx["intrinsicGas"] = intrinsicGas
-			jst.inited = true
 		}
 		// If tracing was interrupted, set the error and stop
 		if atomic.LoadUint32(&jst.interrupt) > 0 {
@@ -640,6 +657,11 @@ func (jst *Tracer) CaptureEnd(depth int, output []byte, startGas, endGas uint64,
 	jst.ctx["output"] = output
 	jst.ctx["time"] = t.String()
 	jst.ctx["gasUsed"] = startGas - endGas
+	if !jst.inited && jst.env != nil {
+		if err1 := jst.init(jst.env); err1 != nil {
+			return err1
+		}
+	}
 
 	if err != nil {
 		jst.ctx["error"] = err.Error()
@@ -3,9 +3,13 @@ package privateapi
 import (
 	"fmt"
 	"net"
+	"time"
 
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/grpcutil"
 	"github.com/ledgerwatch/erigon-lib/kv/remotedbserver"
+	"github.com/ledgerwatch/erigon/internal/tracing"
 
 	//grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
@@ -15,6 +19,8 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
 )
 
 func StartGrpc(kv *remotedbserver.KvServer, ethBackendSrv *EthBackendServer, txPoolServer txpool_proto.TxpoolServer,
@@ -26,8 +32,13 @@ func StartGrpc(kv *remotedbserver.KvServer, ethBackendSrv *EthBackendServer, txP
 		return nil, fmt.Errorf("could not create listener: %w, addr=%s", err, addr)
 	}
 
-	grpcServer := grpcutil.NewServer(rateLimit, creds)
-	remote.RegisterETHBACKENDServer(grpcServer, ethBackendSrv)
+	var grpcServer *grpc.Server
+	if tracing.Enabled() {
+		grpcServer = newTracingServer(rateLimit, creds)
+	} else {
+		grpcServer = grpcutil.NewServer(rateLimit, creds)
+	}
+	RegisterEthBackendServer(grpcServer, ethBackendSrv)
 	if txPoolServer != nil {
 		txpool_proto.RegisterTxpoolServer(grpcServer, txPoolServer)
 	}
@@ -51,3 +62,23 @@ func StartGrpc(kv *remotedbserver.KvServer, ethBackendSrv *EthBackendServer, txP
 
 	return grpcServer, nil
 }
+
+// newTracingServer - same as grpcutil.NewServer, plus interceptors continuing traces of rpcdaemon calls
+func newTracingServer(rateLimit uint32, creds credentials.TransportCredentials) *grpc.Server {
+	unaryInterceptors, streamInterceptors := tracing.ServerInterceptors()
+	streamInterceptors = append(streamInterceptors, grpc_recovery.StreamServerInterceptor())
+	unaryInterceptors = append(unaryInterceptors, grpc_recovery.UnaryServerInterceptor())
+	opts := []grpc.ServerOption{
+		grpc.MaxConcurrentStreams(rateLimit), // to force clients reduce concurrency level
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             10 * time.Second,
+			PermitWithoutStream: true,
+		}),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(streamInterceptors...)),
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(unaryInterceptors...)),
+		grpc.Creds(creds),
+	}
+	grpcServer := grpc.NewServer(opts...)
+	reflection.Register(grpcServer)
+	return grpcServer
+}
@@ -0,0 +1,242 @@
+package privateapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+)
+
+// Engine API of consensus layer, see https://github.com/ethereum/execution-apis/blob/main/src/engine/specification.md
+
+// Statuses of payloadStatus
+const (
+	payloadValid            = "VALID"
+	payloadInvalid          = "INVALID"
+	payloadSyncing          = "SYNCING"
+	payloadAccepted         = "ACCEPTED"
+	payloadInvalidBlockHash = "INVALID_BLOCK_HASH"
+)
+
+type executionPayload struct {
+	ParentHash    common.Hash     `json:"parentHash"`
+	FeeRecipient  common.Address  `json:"feeRecipient"`
+	StateRoot     common.Hash     `json:"stateRoot"`
+	ReceiptsRoot  common.Hash     `json:"receiptsRoot"`
+	LogsBloom     hexutil.Bytes   `json:"logsBloom"`
+	PrevRandao    common.Hash     `json:"prevRandao"`
+	BlockNumber   hexutil.Uint64  `json:"blockNumber"`
+	GasLimit      hexutil.Uint64  `json:"gasLimit"`
+	GasUsed       hexutil.Uint64  `json:"gasUsed"`
+	Timestamp     hexutil.Uint64  `json:"timestamp"`
+	ExtraData     hexutil.Bytes   `json:"extraData"`
+	BaseFeePerGas *hexutil.Big    `json:"baseFeePerGas"`
+	BlockHash     common.Hash     `json:"blockHash"`
+	Transactions  []hexutil.Bytes `json:"transactions"`
+}
+
+type payloadStatus struct {
+	Status          string       `json:"status"`
+	LatestValidHash *common.Hash `json:"latestValidHash"`
+	ValidationError *string      `json:"validationError"`
+}
+
+type forkChoiceState struct {
+	HeadBlockHash      common.Hash `json:"headBlockHash"`
+	SafeBlockHash      common.Hash `json:"safeBlockHash"`
+	FinalizedBlockHash common.Hash `json:"finalizedBlockHash"`
+}
+
+type forkChoiceUpdatedRequest struct {
+	ForkChoiceState   *forkChoiceState `json:"forkchoiceState"`
+	PayloadAttributes json.RawMessage  `json:"payloadAttributes,omitempty"`
+}
+
+type forkChoiceUpdatedReply struct {
+	PayloadStatus payloadStatus  `json:"payloadStatus"`
+	PayloadID     *hexutil.Bytes `json:"payloadId"`
+}
+
+var (
+	errNoEngine       = errors.New("engine api is not available")
+	errUnknownPayload = errors.New("unknown payload")
+	// errInvalidForkChoice - safe or finalized block of valid head isn't canonical
+	errInvalidForkChoice = errors.New("invalid forkchoice state")
+)
+
+// SetEngine - engine methods are served only if it's set. `insertBlock` passes block of consensus layer to staged sync,
+// which validates and executes it like blocks of peers
+func (s *EthBackendServer) SetEngine(insertBlock func(block *types.Block) error) {
+	s.insertBlock = insertBlock
+}
+
+// payloadBlock - block of the payload, its hash is computed from the payload, not taken from BlockHash
+func payloadBlock(payload *executionPayload) (*types.Block, error) {
+	txsRlp := make([][]byte, len(payload.Transactions))
+	for i, tx := range payload.Transactions {
+		txsRlp[i] = tx
+	}
+	txs, err := types.DecodeTransactions(txsRlp)
+	if err != nil {
+		return nil, err
+	}
+	header := &types.Header{
+		ParentHash:  payload.ParentHash,
+		UncleHash:   types.EmptyUncleHash,
+		Coinbase:    payload.FeeRecipient,
+		Root:        payload.StateRoot,
+		TxHash:      types.DeriveSha(types.Transactions(txs)),
+		ReceiptHash: payload.ReceiptsRoot,
+		Bloom:       types.BytesToBloom(payload.LogsBloom),
+		Difficulty:  new(big.Int),
+		Number:      new(big.Int).SetUint64(uint64(payload.BlockNumber)),
+		GasLimit:    uint64(payload.GasLimit),
+		GasUsed:     uint64(payload.GasUsed),
+		Time:        uint64(payload.Timestamp),
+		Extra:       payload.ExtraData,
+		MixDigest:   payload.PrevRandao,
+	}
+	if payload.BaseFeePerGas != nil {
+		header.BaseFee, header.Eip1559 = payload.BaseFeePerGas.ToInt(), true
+	}
+	return types.NewBlockFromStorage(header.Hash(), header, txs, nil), nil
+}
+
+// executed - block is canonical and executed by the node
+func executed(tx kv.Tx, number uint64, hash common.Hash) (bool, error) {
+	canonical, err := rawdb.ReadCanonicalHash(tx, number)
+	if err != nil || canonical != hash {
+		return false, err
+	}
+	progress, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return false, err
+	}
+	return progress >= number, nil
+}
+
+// engineNewPayloadV1 - VALID for block the node already executed, otherwise passes it to staged sync: ACCEPTED if
+// its parent is known, SYNCING if the node has to download parents first
+func (s *EthBackendServer) engineNewPayloadV1(ctx context.Context, args []byte) (interface{}, error) {
+	if s.insertBlock == nil || s.db == nil {
+		return nil, errNoEngine
+	}
+	var payload executionPayload
+	if err := json.Unmarshal(args, &payload); err != nil {
+		return nil, err
+	}
+	block, err := payloadBlock(&payload)
+	if err != nil {
+		validationError := err.Error()
+		return &payloadStatus{Status: payloadInvalid, ValidationError: &validationError}, nil
+	}
+	if block.Hash() != payload.BlockHash {
+		return &payloadStatus{Status: payloadInvalidBlockHash}, nil
+	}
+	number, hash := block.NumberU64(), block.Hash()
+	var done, parentKnown bool
+	if err = s.db.View(ctx, func(tx kv.Tx) error {
+		if done, err = executed(tx, number, hash); err != nil || done {
+			return err
+		}
+		parentKnown = number > 0 && rawdb.ReadHeader(tx, block.ParentHash(), number-1) != nil
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if done {
+		return &payloadStatus{Status: payloadValid, LatestValidHash: &hash}, nil
+	}
+	if err = s.insertBlock(block); err != nil {
+		return nil, err
+	}
+	if !parentKnown {
+		return &payloadStatus{Status: payloadSyncing}, nil
+	}
+	return &payloadStatus{Status: payloadAccepted}, nil
+}
+
+// canonicalRef - canonical block of the hash, nil for zero hash (block isn't chosen yet) and error if the block isn't
+// canonical
+func canonicalRef(tx kv.Tx, hash common.Hash) (*BlockRef, error) {
+	if hash == (common.Hash{}) {
+		return nil, nil
+	}
+	number := rawdb.ReadHeaderNumber(tx, hash)
+	if number == nil {
+		return nil, fmt.Errorf("%w: unknown block %x", errInvalidForkChoice, hash)
+	}
+	canonical, err := rawdb.ReadCanonicalHash(tx, *number)
+	if err != nil {
+		return nil, err
+	}
+	if canonical != hash {
+		return nil, fmt.Errorf("%w: block %x is not canonical", errInvalidForkChoice, hash)
+	}
+	return &BlockRef{Number: *number, Hash: hash}, nil
+}
+
+// engineForkchoiceUpdatedV1 - VALID if head is canonical and executed by the node, SYNCING otherwise. The node chooses
+// its head itself, so fork choice doesn't change it, but valid one is sent to subscribers of EventForkChoice: it gives
+// safe and finalized blocks to rpcdaemons. Payloads are not built: payloadId is always null
+func (s *EthBackendServer) engineForkchoiceUpdatedV1(ctx context.Context, args []byte) (interface{}, error) {
+	if s.insertBlock == nil || s.db == nil {
+		return nil, errNoEngine
+	}
+	var req forkChoiceUpdatedRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	if req.ForkChoiceState == nil {
+		return nil, errors.New("forkchoice state is required")
+	}
+	state := req.ForkChoiceState
+	var forkChoice *ForkChoice
+	if err := s.db.View(ctx, func(tx kv.Tx) error {
+		number := rawdb.ReadHeaderNumber(tx, state.HeadBlockHash)
+		if number == nil {
+			return nil
+		}
+		valid, err := executed(tx, *number, state.HeadBlockHash)
+		if err != nil || !valid {
+			return err
+		}
+		forkChoice = &ForkChoice{Head: BlockRef{Number: *number, Hash: state.HeadBlockHash}}
+		if forkChoice.Safe, err = canonicalRef(tx, state.SafeBlockHash); err != nil {
+			return err
+		}
+		forkChoice.Finalized, err = canonicalRef(tx, state.FinalizedBlockHash)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if forkChoice == nil {
+		return &forkChoiceUpdatedReply{PayloadStatus: payloadStatus{Status: payloadSyncing}}, nil
+	}
+	s.events.OnForkChoice(forkChoice)
+	return &forkChoiceUpdatedReply{PayloadStatus: payloadStatus{Status: payloadValid, LatestValidHash: &state.HeadBlockHash}}, nil
+}
+
+type payloadIDRequest struct {
+	PayloadID hexutil.Bytes `json:"payloadId"`
+}
+
+// engineGetPayloadV1 - the node doesn't build payloads, engineForkchoiceUpdatedV1 gives no payload ids
+func (s *EthBackendServer) engineGetPayloadV1(_ context.Context, args []byte) (interface{}, error) {
+	if s.insertBlock == nil {
+		return nil, errNoEngine
+	}
+	var req payloadIDRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %s", errUnknownPayload, req.PayloadID)
+}
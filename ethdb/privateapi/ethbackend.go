@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
 	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/log/v3"
 	"google.golang.org/protobuf/types/known/emptypb"
@@ -19,7 +23,22 @@ import (
 // 2.1.0 - add NetPeerCount function
 // 2.2.0 - add NodesInfo function
 // 2.3.0 - add Subscribe to logs
-var EthBackendAPIVersion = &types2.VersionReply{Major: 2, Minor: 3, Patch: 0}
+// 2.4.0 - add Listening, SelfNodeInfo functions
+// 2.5.0 - add PendingBlock function
+// 2.6.0 - add Mining, HashRate, GetWork, SubmitWork, SubmitHashRate functions
+// 2.7.0 - add SyncProgress function
+// 2.8.0 - add EventSyncStatus events to Subscribe
+// 2.9.0 - add EventReorg events to Subscribe
+// 2.10.0 - add EventReceipts events to Subscribe
+// 2.11.0 - add EventForkChoice events to Subscribe
+// 2.12.0 - add BlobSidecars function
+// 2.13.0 - Subscribe sends only events of requested type: PENDING_LOGS, PENDING_BLOCK and EventBlock events
+// 2.14.0 - add RebuildIndex, IndexRebuilds functions
+// 2.15.0 - add GetReceipts, GetReceipt functions
+// 2.16.0 - add StageProgress, ChainConfig, GenesisBlock functions
+// 2.17.0 - add AddPeer, RemovePeer functions
+// 2.18.0 - add EngineNewPayloadV1, EngineForkchoiceUpdatedV1, EngineGetPayloadV1 functions
+var EthBackendAPIVersion = &types2.VersionReply{Major: 2, Minor: 18, Patch: 0}
 
 type EthBackendServer struct {
 	remote.UnimplementedETHBACKENDServer // must be embedded to have forward compatible implementations.
@@ -27,6 +46,13 @@ type EthBackendServer struct {
 	eth                                  EthBackend
 	events                               *Events
 	logsFilter                           *LogsFilterAggregator
+	pendingBlock                         atomic.Value // *types.Block, the latest one built by miner
+	mining                               txpool.MiningServer
+	db                                   kv.RoDB
+	topSeenHeight                        func() uint64
+	rebuilder                            IndexRebuilder
+	rebuilds                             indexRebuilds
+	insertBlock                          func(block *types.Block) error
 }
 
 type EthBackend interface {
@@ -34,10 +60,17 @@ type EthBackend interface {
 	NetVersion() (uint64, error)
 	NetPeerCount() (uint64, error)
 	NodesInfo(limit int) (*remote.NodesInfoReply, error)
+	// AddPeer, RemovePeer - change static peers of every sentry, true if any of them changed connection to the peer
+	AddPeer(url string) (bool, error)
+	RemovePeer(url string) (bool, error)
 }
 
 func NewEthBackendServer(ctx context.Context, eth EthBackend, events *Events) *EthBackendServer {
 	s := &EthBackendServer{ctx: ctx, eth: eth, events: events, logsFilter: NewLogsFilterAggregator(events)}
+	s.events.AddPendingBlockSubscription(func(block *types.Block) error {
+		s.pendingBlock.Store(block)
+		return nil
+	})
 
 	ch, clean := s.events.AddLogsSubscription()
 	go func() {
@@ -66,6 +99,17 @@ func NewEthBackendServer(ctx context.Context, eth EthBackend, events *Events) *E
 	return s
 }
 
+// SetMiningServer - mining methods of ETHBACKEND are served by mining, so external miners can use rpcdaemon connected
+// only to private api of the node
+func (s *EthBackendServer) SetMiningServer(mining txpool.MiningServer) {
+	s.mining = mining
+}
+
+// SetSyncProgress - SyncProgress is read from db, `topSeenHeight` is the highest block announced by peers
+func (s *EthBackendServer) SetSyncProgress(db kv.RoDB, topSeenHeight func() uint64) {
+	s.db, s.topSeenHeight = db, topSeenHeight
+}
+
 func (s *EthBackendServer) Version(context.Context, *emptypb.Empty) (*types2.VersionReply, error) {
 	return EthBackendAPIVersion, nil
 }
@@ -99,6 +143,25 @@ func (s *EthBackendServer) NetPeerCount(_ context.Context, _ *remote.NetPeerCoun
 }
 
 func (s *EthBackendServer) Subscribe(r *remote.SubscribeRequest, subscribeServer remote.ETHBACKEND_SubscribeServer) (err error) {
+	switch r.Type {
+	case EventSyncStatus:
+		return s.subscribeSyncStatus(subscribeServer)
+	case EventReorg:
+		return s.subscribeReorgs(subscribeServer)
+	case EventReceipts:
+		return s.subscribeReceipts(subscribeServer)
+	case EventForkChoice:
+		return s.subscribeForkChoice(subscribeServer)
+	case EventBlock:
+		return s.subscribeBlocks(subscribeServer)
+	case remote.Event_PENDING_LOGS:
+		return s.subscribePendingLogs(subscribeServer)
+	case remote.Event_PENDING_BLOCK:
+		return s.subscribePendingBlock(subscribeServer)
+	case remote.Event_HEADER:
+	default:
+		return fmt.Errorf("unsupported event type %d", r.Type)
+	}
 	log.Trace("Establishing event subscription channel with the RPC daemon ...")
 	ch, clean := s.events.AddHeaderSubscription()
 	defer clean()
@@ -0,0 +1,755 @@
+package privateapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces/txpool"
+	types2 "github.com/ledgerwatch/erigon-lib/gointerfaces/types"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/rlp"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Some ETHBACKEND methods are not (yet) part of generated remote.ETHBACKENDServer.
+// They are registered in the same gRPC service, their arguments and replies are
+// JSON documents wrapped into wrapperspb.BytesValue
+type ethBackendExtMethod func(s *EthBackendServer, ctx context.Context, args []byte) (interface{}, error)
+
+var ethBackendExtMethods = map[string]ethBackendExtMethod{
+	"Listening":    (*EthBackendServer).listening,
+	"SelfNodeInfo": (*EthBackendServer).selfNodeInfo,
+	"PendingBlock": (*EthBackendServer).pendingBlockRLP,
+	"AddPeer":      (*EthBackendServer).addPeer,
+	"RemovePeer":   (*EthBackendServer).removePeer,
+
+	"Mining":         (*EthBackendServer).miningEnabled,
+	"HashRate":       (*EthBackendServer).hashRate,
+	"GetWork":        (*EthBackendServer).getWork,
+	"SubmitWork":     (*EthBackendServer).submitWork,
+	"SubmitHashRate": (*EthBackendServer).submitHashRate,
+
+	"SyncProgress":  (*EthBackendServer).syncProgress,
+	"StageProgress": (*EthBackendServer).stageProgress,
+	"ChainConfig":   (*EthBackendServer).chainConfig,
+	"GenesisBlock":  (*EthBackendServer).genesisBlockRLP,
+
+	"BlobSidecars": (*EthBackendServer).blobSidecars,
+	"GetReceipts":  (*EthBackendServer).getReceipts,
+	"GetReceipt":   (*EthBackendServer).getReceipt,
+
+	"RebuildIndex":  (*EthBackendServer).rebuildIndex,
+	"IndexRebuilds": (*EthBackendServer).indexRebuilds,
+
+	"EngineNewPayloadV1":        (*EthBackendServer).engineNewPayloadV1,
+	"EngineForkchoiceUpdatedV1": (*EthBackendServer).engineForkchoiceUpdatedV1,
+	"EngineGetPayloadV1":        (*EthBackendServer).engineGetPayloadV1,
+}
+
+// RegisterEthBackendServer - must be used instead of remote.RegisterETHBACKENDServer, to serve also methods invoked by name
+func RegisterEthBackendServer(s grpc.ServiceRegistrar, srv *EthBackendServer) {
+	desc := remote.ETHBACKEND_ServiceDesc
+	desc.Methods = append([]grpc.MethodDesc{}, desc.Methods...)
+	for name, method := range ethBackendExtMethods {
+		desc.Methods = append(desc.Methods, ethBackendExtMethodDesc(name, method))
+	}
+	s.RegisterService(&desc, srv)
+}
+
+func ethBackendExtMethodDesc(name string, method ethBackendExtMethod) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(wrapperspb.BytesValue)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				res, err := method(srv.(*EthBackendServer), ctx, req.(*wrapperspb.BytesValue).Value)
+				if err != nil {
+					return nil, err
+				}
+				out := &wrapperspb.BytesValue{}
+				if out.Value, err = json.Marshal(res); err != nil {
+					return nil, err
+				}
+				return out, nil
+			}
+			if interceptor == nil {
+				return handler(ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/" + remote.ETHBACKEND_ServiceDesc.ServiceName + "/" + name,
+			}
+			return interceptor(ctx, in, info, handler)
+		},
+	}
+}
+
+// listening - node is listening while at least one sentry reports listener address
+func (s *EthBackendServer) listening(context.Context, []byte) (interface{}, error) {
+	nodes, err := s.eth.NodesInfo(0)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes.NodesInfo {
+		if node != nil && node.ListenerAddr != "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// selfNodeInfo - info of the first sentry, nil when there are no sentries
+func (s *EthBackendServer) selfNodeInfo(context.Context, []byte) (interface{}, error) {
+	nodes, err := s.eth.NodesInfo(1)
+	if err != nil {
+		return nil, err
+	}
+	var self *types2.NodeInfoReply
+	if len(nodes.NodesInfo) > 0 {
+		self = nodes.NodesInfo[0]
+	}
+	return self, nil
+}
+
+type peerRequest struct {
+	URL string `json:"url"`
+}
+
+// addPeer - adds static peer to sentries of the node, false if all of them were connected to it already
+func (s *EthBackendServer) addPeer(_ context.Context, args []byte) (interface{}, error) {
+	var req peerRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.eth.AddPeer(req.URL)
+}
+
+// removePeer - removes static peer from sentries of the node, false if none of them was connected to it
+func (s *EthBackendServer) removePeer(_ context.Context, args []byte) (interface{}, error) {
+	var req peerRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.eth.RemovePeer(req.URL)
+}
+
+// pendingBlockRLP - the latest block built by miner, RLP-encoded. nil when mining isn't enabled or no block is built yet
+func (s *EthBackendServer) pendingBlockRLP(context.Context, []byte) (interface{}, error) {
+	block, ok := s.pendingBlock.Load().(*types.Block)
+	if !ok {
+		return nil, nil
+	}
+	encoded, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(encoded), nil
+}
+
+var errNoMining = errors.New("mining is not available")
+
+func (s *EthBackendServer) miningEnabled(ctx context.Context, _ []byte) (interface{}, error) {
+	if s.mining == nil {
+		return nil, errNoMining
+	}
+	reply, err := s.mining.Mining(ctx, &txpool.MiningRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Enabled && reply.Running, nil
+}
+
+func (s *EthBackendServer) hashRate(ctx context.Context, _ []byte) (interface{}, error) {
+	if s.mining == nil {
+		return nil, errNoMining
+	}
+	reply, err := s.mining.HashRate(ctx, &txpool.HashRateRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return reply.HashRate, nil
+}
+
+// getWork - header pow-hash, seed hash, boundary condition and block number of work package for external miner
+func (s *EthBackendServer) getWork(ctx context.Context, _ []byte) (interface{}, error) {
+	if s.mining == nil {
+		return nil, errNoMining
+	}
+	reply, err := s.mining.GetWork(ctx, &txpool.GetWorkRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return [4]string{reply.HeaderHash, reply.SeedHash, reply.Target, reply.BlockNumber}, nil
+}
+
+type submitWorkRequest struct {
+	Nonce   types.BlockNonce `json:"nonce"`
+	PowHash common.Hash      `json:"powHash"`
+	Digest  common.Hash      `json:"digest"`
+}
+
+func (s *EthBackendServer) submitWork(ctx context.Context, args []byte) (interface{}, error) {
+	if s.mining == nil {
+		return nil, errNoMining
+	}
+	var req submitWorkRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	reply, err := s.mining.SubmitWork(ctx, &txpool.SubmitWorkRequest{BlockNonce: req.Nonce[:], PowHash: req.PowHash.Bytes(), Digest: req.Digest.Bytes()})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Ok, nil
+}
+
+type submitHashRateRequest struct {
+	Rate uint64      `json:"rate"`
+	ID   common.Hash `json:"id"`
+}
+
+func (s *EthBackendServer) submitHashRate(ctx context.Context, args []byte) (interface{}, error) {
+	if s.mining == nil {
+		return nil, errNoMining
+	}
+	var req submitHashRateRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	reply, err := s.mining.SubmitHashRate(ctx, &txpool.SubmitHashRateRequest{Rate: req.Rate, Id: req.ID.Bytes()})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Ok, nil
+}
+
+type stageProgress struct {
+	Name          string `json:"name"`
+	BlockNumber   uint64 `json:"blockNumber"`
+	PruneProgress uint64 `json:"pruneProgress"`
+}
+
+type syncProgressReply struct {
+	CurrentBlock uint64          `json:"currentBlock"` // progress of the last stage
+	HighestBlock uint64          `json:"highestBlock"`
+	Stages       []stageProgress `json:"stages"`
+}
+
+var errNoSyncProgress = errors.New("sync progress is not available")
+
+// syncProgress - progress of each stage in order of staged sync. Highest block is the highest one known: downloaded
+// header or announced by peers
+func (s *EthBackendServer) syncProgress(ctx context.Context, _ []byte) (interface{}, error) {
+	if s.db == nil {
+		return nil, errNoSyncProgress
+	}
+	reply := &syncProgressReply{Stages: make([]stageProgress, 0, len(stages.AllStages))}
+	if err := s.db.View(ctx, func(tx kv.Tx) error {
+		for _, stage := range stages.AllStages {
+			progress, err := stages.GetStageProgress(tx, stage)
+			if err != nil {
+				return err
+			}
+			pruned, err := stages.GetStagePruneProgress(tx, stage)
+			if err != nil {
+				return err
+			}
+			reply.Stages = append(reply.Stages, stageProgress{Name: string(stage), BlockNumber: progress, PruneProgress: pruned})
+			switch stage {
+			case stages.Headers:
+				reply.HighestBlock = progress
+			case stages.Finish:
+				reply.CurrentBlock = progress
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if s.topSeenHeight != nil {
+		if top := s.topSeenHeight(); top > reply.HighestBlock {
+			reply.HighestBlock = top
+		}
+	}
+	return reply, nil
+}
+
+type stageProgressRequest struct {
+	Stages []stages.SyncStage `json:"stages"`
+}
+
+// stageProgress - progress of requested stages by their names
+func (s *EthBackendServer) stageProgress(ctx context.Context, args []byte) (interface{}, error) {
+	if s.db == nil {
+		return nil, errNoSyncProgress
+	}
+	var req stageProgressRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	reply := make(map[stages.SyncStage]uint64, len(req.Stages))
+	if err := s.db.View(ctx, func(tx kv.Tx) error {
+		for _, stage := range req.Stages {
+			progress, err := stages.GetStageProgress(tx, stage)
+			if err != nil {
+				return err
+			}
+			reply[stage] = progress
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+var errNoChainData = errors.New("chain data is not available")
+
+// chainConfig - config of the chain, stored in db by hash of its genesis block. nil before genesis is written
+func (s *EthBackendServer) chainConfig(ctx context.Context, _ []byte) (interface{}, error) {
+	if s.db == nil {
+		return nil, errNoChainData
+	}
+	var cfg *params.ChainConfig
+	if err := s.db.View(ctx, func(tx kv.Tx) error {
+		genesisHash, err := rawdb.ReadCanonicalHash(tx, 0)
+		if err != nil {
+			return err
+		}
+		cfg, err = rawdb.ReadChainConfig(tx, genesisHash)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// genesisBlockRLP - genesis block of the chain, RLP-encoded. nil before it's written
+func (s *EthBackendServer) genesisBlockRLP(ctx context.Context, _ []byte) (interface{}, error) {
+	if s.db == nil {
+		return nil, errNoChainData
+	}
+	var encoded hexutil.Bytes
+	if err := s.db.View(ctx, func(tx kv.Tx) error {
+		genesis, err := rawdb.ReadBlockByNumber(tx, 0)
+		if err != nil || genesis == nil {
+			return err
+		}
+		encoded, err = rlp.EncodeToBytes(genesis)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return encoded, nil
+}
+
+type blobSidecarsRequest struct {
+	BlockHash common.Hash `json:"blockHash"`
+}
+
+// blobSidecars - sidecars of the block received from consensus layer, nil if they are not known
+func (s *EthBackendServer) blobSidecars(_ context.Context, args []byte) (interface{}, error) {
+	var req blobSidecarsRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	return s.events.BlobSidecars(req.BlockHash), nil
+}
+
+// syncStatusInterval - how often sync progress is checked for changes, for subscribers of EventSyncStatus
+var syncStatusInterval = 3 * time.Second
+
+type syncStatusEvent struct {
+	Syncing bool `json:"syncing"`
+	*syncProgressReply
+}
+
+// subscribeSyncStatus - sends EventSyncStatus with current status, then each time node starts or stops syncing and
+// on each change of progress while it's syncing
+func (s *EthBackendServer) subscribeSyncStatus(subscribeServer remote.ETHBACKEND_SubscribeServer) error {
+	ctx := subscribeServer.Context()
+	headers, clean := s.events.AddHeaderSubscription()
+	defer clean()
+	ticker := time.NewTicker(syncStatusInterval)
+	defer ticker.Stop()
+	var last []byte
+	var wasSyncing bool
+	for {
+		progress, err := s.syncProgress(ctx, nil)
+		if err != nil {
+			return err
+		}
+		reply := progress.(*syncProgressReply)
+		event := syncStatusEvent{Syncing: reply.CurrentBlock == 0 || reply.CurrentBlock < reply.HighestBlock, syncProgressReply: reply}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if last == nil || event.Syncing != wasSyncing || (event.Syncing && !bytes.Equal(data, last)) {
+			if err = subscribeServer.Send(&remote.SubscribeReply{Type: EventSyncStatus, Data: data}); err != nil {
+				return err
+			}
+			last, wasSyncing = data, event.Syncing
+		}
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-headers:
+		case <-ticker.C:
+		}
+	}
+}
+
+// subscribeReorgs - sends EventReorg each time canonical chain changes, after removed logs of the reorg were sent to
+// subscribers of logs
+func (s *EthBackendServer) subscribeReorgs(subscribeServer remote.ETHBACKEND_SubscribeServer) error {
+	ch, clean := s.events.AddReorgSubscription()
+	defer clean()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-subscribeServer.Context().Done():
+			return subscribeServer.Context().Err()
+		case reorg := <-ch:
+			data, err := json.Marshal(reorg)
+			if err != nil {
+				return err
+			}
+			if err = subscribeServer.Send(&remote.SubscribeReply{Type: EventReorg, Data: data}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// subscribeReceipts - sends EventReceipts with receipts of each new canonical block, read from db after Erigon notified
+// its header. Blocks without receipts in db (pruned) are skipped, as are headers dropped for slow subscribers
+func (s *EthBackendServer) subscribeReceipts(subscribeServer remote.ETHBACKEND_SubscribeServer) error {
+	if s.db == nil {
+		return errNoReceipts
+	}
+	ctx := subscribeServer.Context()
+	headers, clean := s.events.AddHeaderSubscription()
+	defer clean()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-ctx.Done():
+			return ctx.Err()
+		case headersRlp := <-headers:
+			for _, headerRlp := range headersRlp {
+				event, err := s.blockReceipts(ctx, headerRlp)
+				if err != nil {
+					return err
+				}
+				if event == nil {
+					continue
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					return err
+				}
+				if err = subscribeServer.Send(&remote.SubscribeReply{Type: EventReceipts, Data: data}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// blockReceipts - receipts of block of the header, nil if it's not canonical or its receipts are not in db
+func (s *EthBackendServer) blockReceipts(ctx context.Context, headerRlp []byte) (*BlockReceipts, error) {
+	header := &types.Header{}
+	if err := rlp.DecodeBytes(headerRlp, header); err != nil {
+		return nil, err
+	}
+	var event *BlockReceipts
+	if err := s.db.View(ctx, func(tx kv.Tx) error {
+		var err error
+		event, err = readBlockReceipts(tx, header.Number.Uint64(), header.Hash())
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// readBlockReceipts - receipts of the block, nil if it's not canonical or its receipts are not in db
+func readBlockReceipts(tx kv.Tx, number uint64, hash common.Hash) (*BlockReceipts, error) {
+	canonical, err := rawdb.ReadCanonicalHash(tx, number)
+	if err != nil || canonical != hash {
+		return nil, err
+	}
+	block, senders, err := rawdb.ReadBlockWithSenders(tx, hash, number)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	receipts := rawdb.ReadReceipts(tx, block, senders)
+	if receipts == nil {
+		if len(block.Transactions()) > 0 {
+			return nil, nil
+		}
+		receipts = types.Receipts{}
+	}
+	for _, receipt := range receipts { // required fields of JSON encoding
+		if receipt.Logs == nil {
+			receipt.Logs = []*types.Log{}
+		}
+		for _, l := range receipt.Logs {
+			if l.Topics == nil {
+				l.Topics = []common.Hash{}
+			}
+		}
+	}
+	return &BlockReceipts{Number: number, Hash: hash, Receipts: receipts}, nil
+}
+
+type hashRequest struct {
+	Hash common.Hash `json:"hash"`
+}
+
+var errNoReceipts = errors.New("receipts are not available")
+
+// getReceipts - receipts of canonical block by its hash, nil for unknown block or block without receipts in db
+func (s *EthBackendServer) getReceipts(ctx context.Context, args []byte) (interface{}, error) {
+	if s.db == nil {
+		return nil, errNoReceipts
+	}
+	var req hashRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	var receipts types.Receipts
+	if err := s.db.View(ctx, func(tx kv.Tx) error {
+		number := rawdb.ReadHeaderNumber(tx, req.Hash)
+		if number == nil {
+			return nil
+		}
+		block, err := readBlockReceipts(tx, *number, req.Hash)
+		if err != nil || block == nil {
+			return err
+		}
+		receipts = block.Receipts
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// getReceipt - receipt of canonical transaction by its hash, nil for unknown transaction
+func (s *EthBackendServer) getReceipt(ctx context.Context, args []byte) (interface{}, error) {
+	if s.db == nil {
+		return nil, errNoReceipts
+	}
+	var req hashRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	var receipt *types.Receipt
+	if err := s.db.View(ctx, func(tx kv.Tx) error {
+		number, err := rawdb.ReadTxLookupEntry(tx, req.Hash)
+		if err != nil || number == nil {
+			return err
+		}
+		hash, err := rawdb.ReadCanonicalHash(tx, *number)
+		if err != nil {
+			return err
+		}
+		block, err := readBlockReceipts(tx, *number, hash)
+		if err != nil || block == nil {
+			return err
+		}
+		for _, r := range block.Receipts {
+			if r.TxHash == req.Hash {
+				receipt = r
+				break
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// subscribeForkChoice - sends EventForkChoice with the latest fork choice of consensus layer, if there is one, and then
+// with each new one
+func (s *EthBackendServer) subscribeForkChoice(subscribeServer remote.ETHBACKEND_SubscribeServer) error {
+	ch, latest, clean := s.events.AddForkChoiceSubscription()
+	defer clean()
+	send := func(forkChoice *ForkChoice) error {
+		data, err := json.Marshal(forkChoice)
+		if err != nil {
+			return err
+		}
+		return subscribeServer.Send(&remote.SubscribeReply{Type: EventForkChoice, Data: data})
+	}
+	if latest != nil {
+		if err := send(latest); err != nil {
+			return err
+		}
+	}
+	ctx := subscribeServer.Context()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-ctx.Done():
+			return ctx.Err()
+		case forkChoice := <-ch:
+			if err := send(forkChoice); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// subscribeBlocks - sends EventBlock with RLP of each new canonical block, read from db after Erigon notified its
+// header. Headers dropped for slow subscribers are skipped
+func (s *EthBackendServer) subscribeBlocks(subscribeServer remote.ETHBACKEND_SubscribeServer) error {
+	if s.db == nil {
+		return errors.New("blocks are not available")
+	}
+	ctx := subscribeServer.Context()
+	headers, clean := s.events.AddHeaderSubscription()
+	defer clean()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-ctx.Done():
+			return ctx.Err()
+		case headersRlp := <-headers:
+			for _, headerRlp := range headersRlp {
+				data, err := s.canonicalBlockRLP(ctx, headerRlp)
+				if err != nil {
+					return err
+				}
+				if data == nil {
+					continue
+				}
+				if err = subscribeServer.Send(&remote.SubscribeReply{Type: EventBlock, Data: data}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// canonicalBlockRLP - RLP of block of the header, nil if it's not canonical (anymore)
+func (s *EthBackendServer) canonicalBlockRLP(ctx context.Context, headerRlp []byte) ([]byte, error) {
+	header := &types.Header{}
+	if err := rlp.DecodeBytes(headerRlp, header); err != nil {
+		return nil, err
+	}
+	var data []byte
+	if err := s.db.View(ctx, func(tx kv.Tx) error {
+		number, hash := header.Number.Uint64(), header.Hash()
+		canonical, err := rawdb.ReadCanonicalHash(tx, number)
+		if err != nil || canonical != hash {
+			return err
+		}
+		block := rawdb.ReadBlock(tx, hash, number)
+		if block == nil {
+			return nil
+		}
+		data, err = rlp.EncodeToBytes(block)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// subscribePendingLogs - sends PENDING_LOGS with RLP of logs of each block built by miner. Slow subscriber misses
+// the oldest of them
+func (s *EthBackendServer) subscribePendingLogs(subscribeServer remote.ETHBACKEND_SubscribeServer) error {
+	ch := make(chan types.Logs, 8)
+	clean := s.events.AddPendingLogsSubscription(func(logs types.Logs) error {
+		for {
+			select {
+			case ch <- logs:
+				return nil
+			default: // slow subscriber, drop the oldest
+				select {
+				case <-ch:
+				default:
+				}
+			}
+		}
+	})
+	defer clean()
+	ctx := subscribeServer.Context()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-ctx.Done():
+			return ctx.Err()
+		case logs := <-ch:
+			data, err := rlp.EncodeToBytes(logs)
+			if err != nil {
+				return err
+			}
+			if err = subscribeServer.Send(&remote.SubscribeReply{Type: remote.Event_PENDING_LOGS, Data: data}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// subscribePendingBlock - sends PENDING_BLOCK with RLP of each block built by miner. Only the latest one matters, so
+// slow subscriber gets only it
+func (s *EthBackendServer) subscribePendingBlock(subscribeServer remote.ETHBACKEND_SubscribeServer) error {
+	ch := make(chan *types.Block, 1)
+	clean := s.events.AddPendingBlockSubscription(func(block *types.Block) error {
+		for {
+			select {
+			case ch <- block:
+				return nil
+			default: // slow subscriber, drop the oldest
+				select {
+				case <-ch:
+				default:
+				}
+			}
+		}
+	})
+	defer clean()
+	ctx := subscribeServer.Context()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-ctx.Done():
+			return ctx.Err()
+		case block := <-ch:
+			data, err := rlp.EncodeToBytes(block)
+			if err != nil {
+				return err
+			}
+			if err = subscribeServer.Send(&remote.SubscribeReply{Type: remote.Event_PENDING_BLOCK, Data: data}); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -4,11 +4,74 @@ import (
 	"sync"
 
 	"github.com/ledgerwatch/erigon-lib/gointerfaces/remote"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/log/v3"
 )
 
 type RpcEventType uint64
 
+// EventSyncStatus - type of Subscribe events with JSON document of sync status, not (yet) part of remote.Event.
+// Sent only to subscribers requesting this type
+const EventSyncStatus remote.Event = 3
+
+// EventReorg - type of Subscribe events with JSON document of Reorg, not (yet) part of remote.Event.
+// Sent only to subscribers requesting this type
+const EventReorg remote.Event = 4
+
+// EventReceipts - type of Subscribe events with JSON document of BlockReceipts of each new canonical block, not (yet)
+// part of remote.Event. Sent only to subscribers requesting this type
+const EventReceipts remote.Event = 5
+
+// EventForkChoice - type of Subscribe events with JSON document of ForkChoice, sent on subscription and on each change of
+// fork choice, not (yet) part of remote.Event. Sent only to subscribers requesting this type
+const EventForkChoice remote.Event = 6
+
+// EventBlock - type of Subscribe events with RLP of each new canonical block with its body, not (yet) part of
+// remote.Event. Sent only to subscribers requesting this type, instead of headers which they would fetch bodies for
+const EventBlock remote.Event = 7
+
+// BlockRef - number and hash of block
+type BlockRef struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+}
+
+// Reorg - change of canonical chain: blocks after CommonAncestor up to OldHead were replaced by blocks up to NewHead
+type Reorg struct {
+	OldHead        BlockRef `json:"oldHead"`
+	NewHead        BlockRef `json:"newHead"`
+	CommonAncestor BlockRef `json:"commonAncestor"`
+}
+
+// BlockReceipts - receipts of all transactions of executed block, with logs
+type BlockReceipts struct {
+	Number   uint64         `json:"number"`
+	Hash     common.Hash    `json:"hash"`
+	Receipts types.Receipts `json:"receipts"`
+}
+
+// ForkChoice - head, safe and finalized blocks chosen by consensus layer, Safe and Finalized are nil until it sets them
+type ForkChoice struct {
+	Head      BlockRef  `json:"head"`
+	Safe      *BlockRef `json:"safe"`
+	Finalized *BlockRef `json:"finalized"`
+}
+
+// BlobSidecar - blob of EIP-4844 transaction of the block with its KZG commitment and proof, as consensus layer
+// received it
+type BlobSidecar struct {
+	BlockHash     common.Hash   `json:"blockHash"`
+	Index         uint64        `json:"index"`
+	Blob          hexutil.Bytes `json:"blob"`
+	KZGCommitment hexutil.Bytes `json:"kzgCommitment"`
+	KZGProof      hexutil.Bytes `json:"kzgProof"`
+}
+
+// blobSidecarsBlocks - sidecars of how many latest blocks are kept in memory, up to 768KB per block
+const blobSidecarsBlocks = 64
+
 type HeaderSubscription func(headerRLP []byte) error
 type PendingLogsSubscription func(types.Logs) error
 type PendingBlockSubscription func(*types.Block) error
@@ -23,6 +86,11 @@ type Events struct {
 	pendingBlockSubscriptions map[int]PendingBlockSubscription
 	pendingTxsSubscriptions   map[int]PendingTxsSubscription
 	logsSubscriptions         map[int]chan []*remote.SubscribeLogsReply
+	reorgSubscriptions        map[int]chan *Reorg
+	forkChoiceSubscriptions   map[int]chan *ForkChoice
+	forkChoice                *ForkChoice // the latest one
+	blobSidecars              map[common.Hash][]*BlobSidecar
+	blobSidecarsOrder         []common.Hash // blocks of blobSidecars, the oldest first
 	hasLogSubscriptions       bool
 	lock                      sync.RWMutex
 }
@@ -34,6 +102,9 @@ func NewEvents() *Events {
 		pendingBlockSubscriptions: map[int]PendingBlockSubscription{},
 		pendingTxsSubscriptions:   map[int]PendingTxsSubscription{},
 		logsSubscriptions:         map[int]chan []*remote.SubscribeLogsReply{},
+		reorgSubscriptions:        map[int]chan *Reorg{},
+		forkChoiceSubscriptions:   map[int]chan *ForkChoice{},
+		blobSidecars:              map[common.Hash][]*BlobSidecar{},
 	}
 }
 
@@ -63,6 +134,37 @@ func (e *Events) AddLogsSubscription() (chan []*remote.SubscribeLogsReply, func(
 	}
 }
 
+func (e *Events) AddReorgSubscription() (chan *Reorg, func()) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	ch := make(chan *Reorg, 8)
+	e.id++
+	id := e.id
+	e.reorgSubscriptions[id] = ch
+	return ch, func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		delete(e.reorgSubscriptions, id)
+		close(ch)
+	}
+}
+
+// AddForkChoiceSubscription - also returns the latest fork choice, nil if there was none yet
+func (e *Events) AddForkChoiceSubscription() (chan *ForkChoice, *ForkChoice, func()) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	ch := make(chan *ForkChoice, 8)
+	e.id++
+	id := e.id
+	e.forkChoiceSubscriptions[id] = ch
+	return ch, e.forkChoice, func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		delete(e.forkChoiceSubscriptions, id)
+		close(ch)
+	}
+}
+
 func (e *Events) EmptyLogSubsctiption(empty bool) {
 	e.lock.Lock()
 	defer e.lock.Unlock()
@@ -75,16 +177,34 @@ func (e *Events) HasLogSubsriptions() bool {
 	return e.hasLogSubscriptions
 }
 
-func (e *Events) AddPendingLogsSubscription(s PendingLogsSubscription) {
+// AddPendingLogsSubscription - `s` is called under lock of Events and must not block, it's removed when it returns error
+// or by returned func
+func (e *Events) AddPendingLogsSubscription(s PendingLogsSubscription) func() {
 	e.lock.Lock()
 	defer e.lock.Unlock()
-	e.pendingLogsSubscriptions[len(e.pendingLogsSubscriptions)] = s
+	e.id++
+	id := e.id
+	e.pendingLogsSubscriptions[id] = s
+	return func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		delete(e.pendingLogsSubscriptions, id)
+	}
 }
 
-func (e *Events) AddPendingBlockSubscription(s PendingBlockSubscription) {
+// AddPendingBlockSubscription - `s` is called under lock of Events and must not block, it's removed when it returns error
+// or by returned func
+func (e *Events) AddPendingBlockSubscription(s PendingBlockSubscription) func() {
 	e.lock.Lock()
 	defer e.lock.Unlock()
-	e.pendingBlockSubscriptions[len(e.pendingBlockSubscriptions)] = s
+	e.id++
+	id := e.id
+	e.pendingBlockSubscriptions[id] = s
+	return func() {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		delete(e.pendingBlockSubscriptions, id)
+	}
 }
 
 func (e *Events) OnNewHeader(newHeadersRlp [][]byte) {
@@ -105,6 +225,16 @@ func (e *Events) OnNewHeader(newHeadersRlp [][]byte) {
 	}
 }
 
+func (e *Events) OnNewPendingBlock(block *types.Block) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for i, sub := range e.pendingBlockSubscriptions {
+		if err := sub(block); err != nil {
+			delete(e.pendingBlockSubscriptions, i)
+		}
+	}
+}
+
 func (e *Events) OnNewPendingLogs(logs types.Logs) {
 	e.lock.Lock()
 	defer e.lock.Unlock()
@@ -132,3 +262,58 @@ func (e *Events) OnLogs(logs []*remote.SubscribeLogsReply) {
 		}
 	}
 }
+
+// OnReorg - unlike other events, reorgs are not dropped to make room for newer ones: consumer would have no other way
+// to find out which of its data to unwind. Reorg is dropped only if consumer lags by whole buffer of them
+func (e *Events) OnReorg(reorg *Reorg) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	for _, ch := range e.reorgSubscriptions {
+		select {
+		case ch <- reorg:
+		default:
+			log.Warn("Dropping reorg event of slow subscriber", "oldHead", reorg.OldHead.Number, "newHead", reorg.NewHead.Number)
+		}
+	}
+}
+
+// OnForkChoice - called by EngineForkchoiceUpdatedV1 on each valid fork choice of consensus layer. Only the latest
+// fork choice matters, so the oldest one is dropped for slow consumer
+func (e *Events) OnForkChoice(forkChoice *ForkChoice) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.forkChoice = forkChoice
+	for _, ch := range e.forkChoiceSubscriptions {
+		select {
+		case ch <- forkChoice:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- forkChoice
+		}
+	}
+}
+
+// OnBlobSidecars - called by driver of consensus layer with sidecars of each block with blobs. Sidecars of only
+// blobSidecarsBlocks latest blocks are kept
+func (e *Events) OnBlobSidecars(blockHash common.Hash, sidecars []*BlobSidecar) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if _, ok := e.blobSidecars[blockHash]; !ok {
+		e.blobSidecarsOrder = append(e.blobSidecarsOrder, blockHash)
+	}
+	e.blobSidecars[blockHash] = sidecars
+	for len(e.blobSidecarsOrder) > blobSidecarsBlocks {
+		delete(e.blobSidecars, e.blobSidecarsOrder[0])
+		e.blobSidecarsOrder = e.blobSidecarsOrder[1:]
+	}
+}
+
+// BlobSidecars - sidecars of the block, nil if they are unknown or not kept anymore
+func (e *Events) BlobSidecars(blockHash common.Hash) []*BlobSidecar {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return e.blobSidecars[blockHash]
+}
@@ -72,7 +72,7 @@ func (s *MiningServer) SubmitHashRate(_ context.Context, req *proto_txpool.Submi
 	return &proto_txpool.SubmitHashRateReply{Ok: ok}, nil
 }
 
-func (s *MiningServer) GetHashRate(_ context.Context, req *proto_txpool.HashRateRequest) (*proto_txpool.HashRateReply, error) {
+func (s *MiningServer) HashRate(_ context.Context, req *proto_txpool.HashRateRequest) (*proto_txpool.HashRateReply, error) {
 	if s.ethash == nil {
 		return nil, errors.New("not supported, consensus engine is not ethash")
 	}
@@ -0,0 +1,108 @@
+package privateapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/log/v3"
+)
+
+// IndexRebuilder - rebuilds indices of node's db, implemented by stagedsync.IndexRebuilder
+type IndexRebuilder interface {
+	// Range - blocks Rebuild would index for [from, to], error if index is unknown or there are no such blocks
+	Range(ctx context.Context, name string, from, to uint64) (uint64, uint64, error)
+	Rebuild(ctx context.Context, name string, from, to uint64, onProgress func(block uint64)) error
+}
+
+// IndexRebuild - progress of rebuild of index, Finished is set once it's done or failed with Error
+type IndexRebuild struct {
+	Name         string     `json:"name"`
+	FromBlock    uint64     `json:"fromBlock"`
+	ToBlock      uint64     `json:"toBlock"`
+	CurrentBlock *uint64    `json:"currentBlock"` // the last indexed one, nil until the first batch of blocks is indexed
+	Started      time.Time  `json:"started"`
+	Finished     *time.Time `json:"finished"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// indexRebuilds - rebuilds running in background, the latest one of each index
+type indexRebuilds struct {
+	lock     sync.Mutex
+	rebuilds map[string]*IndexRebuild
+}
+
+// SetIndexRebuilder - RebuildIndex and IndexRebuilds are served only if it's set
+func (s *EthBackendServer) SetIndexRebuilder(rebuilder IndexRebuilder) {
+	s.rebuilder = rebuilder
+}
+
+type rebuildIndexRequest struct {
+	Name      string `json:"name"`
+	FromBlock uint64 `json:"fromBlock"`
+	ToBlock   uint64 `json:"toBlock"`
+}
+
+// rebuildIndex - starts rebuild of index in background, fails if the index is being rebuilt already
+func (s *EthBackendServer) rebuildIndex(ctx context.Context, args []byte) (interface{}, error) {
+	if s.rebuilder == nil {
+		return nil, errors.New("index rebuild is not available")
+	}
+	var req rebuildIndexRequest
+	if err := json.Unmarshal(args, &req); err != nil {
+		return nil, err
+	}
+	from, to, err := s.rebuilder.Range(ctx, req.Name, req.FromBlock, req.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	s.rebuilds.lock.Lock()
+	defer s.rebuilds.lock.Unlock()
+	if s.rebuilds.rebuilds == nil {
+		s.rebuilds.rebuilds = map[string]*IndexRebuild{}
+	}
+	if running, ok := s.rebuilds.rebuilds[req.Name]; ok && running.Finished == nil {
+		return nil, fmt.Errorf("index %s is being rebuilt already, since %s", req.Name, running.Started.Format(time.RFC3339))
+	}
+	rebuild := &IndexRebuild{Name: req.Name, FromBlock: from, ToBlock: to, Started: time.Now()}
+	s.rebuilds.rebuilds[req.Name] = rebuild
+	reply := *rebuild
+	go func() {
+		err := s.rebuilder.Rebuild(s.ctx, req.Name, from, to, func(block uint64) {
+			s.rebuilds.lock.Lock()
+			defer s.rebuilds.lock.Unlock()
+			rebuild.CurrentBlock = &block
+		})
+		if err != nil {
+			log.Warn("Index rebuild failed", "index", req.Name, "from", from, "to", to, "err", err)
+		}
+		s.rebuilds.lock.Lock()
+		defer s.rebuilds.lock.Unlock()
+		finished := time.Now()
+		rebuild.Finished = &finished
+		if err != nil {
+			rebuild.Error = err.Error()
+		}
+	}()
+	return reply, nil
+}
+
+// indexRebuilds - the latest rebuild of each index, by names of indices
+func (s *EthBackendServer) indexRebuilds(context.Context, []byte) (interface{}, error) {
+	if s.rebuilder == nil {
+		return nil, errors.New("index rebuild is not available")
+	}
+	s.rebuilds.lock.Lock()
+	defer s.rebuilds.lock.Unlock()
+	reply := make([]IndexRebuild, 0, len(s.rebuilds.rebuilds))
+	for _, rebuild := range s.rebuilds.rebuilds {
+		reply = append(reply, *rebuild)
+	}
+	sort.Slice(reply, func(i, j int) bool { return reply[i].Name < reply[j].Name })
+	return reply, nil
+}
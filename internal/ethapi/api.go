@@ -22,7 +22,6 @@ import (
 	"math/big"
 
 	"github.com/holiman/uint256"
-	"github.com/ledgerwatch/erigon/accounts/abi"
 	"github.com/ledgerwatch/erigon/common"
 	"github.com/ledgerwatch/erigon/common/hexutil"
 	"github.com/ledgerwatch/erigon/common/math"
@@ -160,23 +159,24 @@ type Account struct {
 	StateDiff *map[common.Hash]uint256.Int `json:"stateDiff"`
 }
 
+// NewRevertError - revert error with reason of Error(string) or Panic(uint256), see RevertDecoder for custom errors
 func NewRevertError(result *core.ExecutionResult) *RevertError {
-	reason, errUnpack := abi.UnpackRevert(result.Revert())
-	err := errors.New("execution reverted")
-	if errUnpack == nil {
-		err = fmt.Errorf("execution reverted: %v", reason)
-	}
-	return &RevertError{
-		error:  err,
-		reason: hexutil.Encode(result.Revert()),
-	}
+	var d *RevertDecoder
+	return d.NewRevertError(result)
 }
 
 // RevertError is an API error that encompassas an EVM revertal with JSON error
 // code and a binary data blob.
 type RevertError struct {
 	error
-	reason string // revert reason hex encoded
+	data    string // revert data hex encoded
+	decoded string // reason decoded from data, empty if its ABI is not known
+}
+
+// RevertErrorData - data of revert error: hex of revert data and reason decoded from it
+type RevertErrorData struct {
+	Data   string `json:"data"`
+	Reason string `json:"reason,omitempty"`
 }
 
 // ErrorCode returns the JSON error code for a revertal.
@@ -185,9 +185,14 @@ func (e *RevertError) ErrorCode() int {
 	return 3
 }
 
-// ErrorData returns the hex encoded revert reason.
+// ErrorData returns the hex encoded revert data and decoded reason.
 func (e *RevertError) ErrorData() interface{} {
-	return e.reason
+	return &RevertErrorData{Data: e.data, Reason: e.decoded}
+}
+
+// HexData - hex encoded revert data
+func (e *RevertError) HexData() string {
+	return e.data
 }
 
 // ExecutionResult groups all structured logs emitted by the EVM
@@ -275,6 +280,18 @@ func RPCMarshalHeader(head *types.Header) map[string]interface{} {
 	if head.BaseFee != nil {
 		result["baseFeePerGas"] = (*hexutil.Big)(head.BaseFee)
 	}
+	if head.WithdrawalsHash != nil {
+		result["withdrawalsRoot"] = head.WithdrawalsHash
+	}
+	if head.BlobGasUsed != nil {
+		result["blobGasUsed"] = hexutil.Uint64(*head.BlobGasUsed)
+	}
+	if head.ExcessBlobGas != nil {
+		result["excessBlobGas"] = hexutil.Uint64(*head.ExcessBlobGas)
+	}
+	if head.ParentBeaconBlockRoot != nil {
+		result["parentBeaconBlockRoot"] = head.ParentBeaconBlockRoot
+	}
 
 	return result
 }
@@ -357,11 +374,39 @@ type RPCTransaction struct {
 	Type             hexutil.Uint64    `json:"type"`
 	Accesses         *types.AccessList `json:"accessList,omitempty"`
 	ChainID          *hexutil.Big      `json:"chainId,omitempty"`
+	MaxFeePerBlobGas *hexutil.Big      `json:"maxFeePerBlobGas,omitempty"`
+	BlobHashes       []common.Hash     `json:"blobVersionedHashes,omitempty"`
 	V                *hexutil.Big      `json:"v"`
 	R                *hexutil.Big      `json:"r"`
 	S                *hexutil.Big      `json:"s"`
 }
 
+// GethCompatible returns copy of the transaction with fields present as geth sends them: chainId only of
+// replay-protected transactions, accessList of typed transactions also if it's empty, gasPrice of pending dynamic
+// fee transaction is its fee cap
+func (t *RPCTransaction) GethCompatible() interface{} {
+	if t == nil {
+		return t
+	}
+	compat := *t
+	switch {
+	case t.Type != types.LegacyTxType:
+		if compat.Accesses == nil {
+			compat.Accesses = &types.AccessList{}
+		}
+		if compat.GasPrice == nil {
+			compat.GasPrice = compat.FeeCap
+		}
+	case t.V != nil && t.V.ToInt().Cmp(big.NewInt(35)) >= 0:
+		// v = chainId * 2 + 35 + parity of replay-protected transaction
+		chainId := new(big.Int).Sub(t.V.ToInt(), big.NewInt(35))
+		compat.ChainID = (*hexutil.Big)(chainId.Rsh(chainId, 1))
+	default:
+		compat.ChainID = nil
+	}
+	return &compat
+}
+
 // newRPCTransaction returns a transaction that will serialize to the RPC
 // representation, with the given location metadata set (if available).
 func newRPCTransaction(tx types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64, baseFee *big.Int) *RPCTransaction {
@@ -379,7 +424,13 @@ func newRPCTransaction(tx types.Transaction, blockHash common.Hash, blockNumber
 		To:    tx.GetTo(),
 		Value: (*hexutil.Big)(tx.GetValue().ToBig()),
 	}
-	switch t := tx.(type) {
+	fields := tx
+	if blobTx, ok := tx.(*types.BlobTx); ok {
+		result.MaxFeePerBlobGas = (*hexutil.Big)(blobTx.MaxFeePerBlobGas.ToBig())
+		result.BlobHashes = blobTx.BlobVersionedHashes
+		fields = &blobTx.DynamicFeeTransaction // the rest are fields of dynamic fee transaction
+	}
+	switch t := fields.(type) {
 	case *types.LegacyTx:
 		chainId = types.DeriveChainId(&t.V)
 		result.ChainID = (*hexutil.Big)(chainId.ToBig())
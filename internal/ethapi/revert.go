@@ -0,0 +1,162 @@
+package ethapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/crypto"
+)
+
+// panicSelector - selector of Panic(uint256) of solidity, which reverts failed assert, overflow, etc.
+var panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+
+var panicReasons = map[uint64]string{
+	0x00: "generic panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "enum overflow",
+	0x22: "invalid encoded storage byte array accessed",
+	0x31: "out-of-bounds array access; popping on an empty array",
+	0x32: "out-of-bounds access of an array or bytesN",
+	0x41: "out of memory",
+	0x51: "uninitialized function",
+}
+
+// PanicReason - reason of Panic(uint256) revert data, false if data is not a panic
+func PanicReason(data []byte) (string, bool) {
+	if len(data) != 4+32 || !bytes.Equal(data[:4], panicSelector) {
+		return "", false
+	}
+	code := new(big.Int).SetBytes(data[4:])
+	if reason, ok := panicReasons[code.Uint64()]; ok && code.IsUint64() {
+		return reason, true
+	}
+	return fmt.Sprintf("unknown panic code: %#x", code), true
+}
+
+// customError - `error` entry of contract ABI
+type customError struct {
+	name   string
+	inputs abi.Arguments
+}
+
+// RevertDecoder - decodes revert data of Error(string), Panic(uint256) and of custom errors of registered ABIs. nil
+// decoder knows only Error and Panic
+type RevertDecoder struct {
+	errors map[[4]byte]customError
+}
+
+func NewRevertDecoder() *RevertDecoder {
+	return &RevertDecoder{errors: map[[4]byte]customError{}}
+}
+
+// LoadRevertDecoder - decoder of custom errors of JSON ABI (array of ABI entries, only ones of `error` type are used)
+// in the file
+func LoadRevertDecoder(path string) (*RevertDecoder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d := NewRevertDecoder()
+	if err = d.Register(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Register - adds custom errors of JSON ABI
+func (d *RevertDecoder) Register(r io.Reader) error {
+	var entries []struct {
+		Type   string
+		Name   string
+		Inputs abi.Arguments
+	}
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Type != "error" {
+			continue
+		}
+		types := make([]string, len(entry.Inputs))
+		for i, input := range entry.Inputs {
+			types[i] = input.Type.String()
+		}
+		var selector [4]byte
+		copy(selector[:], crypto.Keccak256([]byte(entry.Name+"("+strings.Join(types, ",")+")")))
+		d.errors[selector] = customError{name: entry.Name, inputs: entry.Inputs}
+	}
+	return nil
+}
+
+// Decode - reason of revert, for custom error its name and arguments: `Name(arg: value, ...)`. False if ABI of data is
+// not known
+func (d *RevertDecoder) Decode(data []byte) (string, bool) {
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason, true
+	}
+	if reason, ok := PanicReason(data); ok {
+		return reason, true
+	}
+	if d == nil || len(data) < 4 {
+		return "", false
+	}
+	var selector [4]byte
+	copy(selector[:], data)
+	custom, ok := d.errors[selector]
+	if !ok {
+		return "", false
+	}
+	values, err := custom.inputs.Unpack(data[4:])
+	if err != nil {
+		return "", false
+	}
+	args := make([]string, len(values))
+	for i, value := range values {
+		if name := custom.inputs[i].Name; name != "" {
+			args[i] = name + ": " + formatRevertArg(value)
+		} else {
+			args[i] = formatRevertArg(value)
+		}
+	}
+	return custom.name + "(" + strings.Join(args, ", ") + ")", true
+}
+
+// formatRevertArg - bytes as hex, the rest as fmt prints them (addresses as hex, integers as decimal)
+func formatRevertArg(value interface{}) string {
+	v := reflect.ValueOf(value)
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() == reflect.Uint8 {
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return hexutil.Encode(b)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// NewRevertError - revert error with reason decoded by d
+func (d *RevertDecoder) NewRevertError(result *core.ExecutionResult) *RevertError {
+	reason, ok := d.Decode(result.Revert())
+	err := errors.New("execution reverted")
+	if ok {
+		err = fmt.Errorf("execution reverted: %v", reason)
+	} else {
+		reason = ""
+	}
+	return &RevertError{
+		error:   err,
+		data:    hexutil.Encode(result.Revert()),
+		decoded: reason,
+	}
+}
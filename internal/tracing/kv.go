@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapDB - read transactions of db are recorded as "kv.Tx" spans of the call they are opened for, with amounts of
+// opened cursors and of reads (cursor moves and Getter calls) as attributes. Returns db itself if tracing is not
+// enabled.
+func WrapDB(db kv.RoDB) kv.RoDB {
+	if !Enabled() {
+		return db
+	}
+	return &tracedDB{RoDB: db, tracer: Tracer("kv")}
+}
+
+type tracedDB struct {
+	kv.RoDB
+	tracer trace.Tracer
+}
+
+func (db *tracedDB) BeginRo(ctx context.Context) (kv.Tx, error) {
+	ctx, span := db.tracer.Start(ctx, "kv.Tx")
+	tx, err := db.RoDB.BeginRo(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		return nil, err
+	}
+	return &tracedTx{Tx: tx, span: span}, nil
+}
+
+func (db *tracedDB) View(ctx context.Context, f func(tx kv.Tx) error) error {
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return f(tx)
+}
+
+type tracedTx struct {
+	kv.Tx
+	span    trace.Span
+	cursors int64
+	reads   int64
+	ended   int32
+}
+
+func (tx *tracedTx) end() {
+	if !atomic.CompareAndSwapInt32(&tx.ended, 0, 1) {
+		return
+	}
+	tx.span.SetAttributes(
+		attribute.Int64("kv.cursors", atomic.LoadInt64(&tx.cursors)),
+		attribute.Int64("kv.reads", atomic.LoadInt64(&tx.reads)),
+	)
+	tx.span.End()
+}
+
+func (tx *tracedTx) read() { atomic.AddInt64(&tx.reads, 1) }
+
+func (tx *tracedTx) Commit() error {
+	defer tx.end()
+	return tx.Tx.Commit()
+}
+
+func (tx *tracedTx) Rollback() {
+	defer tx.end()
+	tx.Tx.Rollback()
+}
+
+func (tx *tracedTx) Has(bucket string, key []byte) (bool, error) {
+	tx.read()
+	return tx.Tx.Has(bucket, key)
+}
+
+func (tx *tracedTx) GetOne(bucket string, key []byte) ([]byte, error) {
+	tx.read()
+	return tx.Tx.GetOne(bucket, key)
+}
+
+func (tx *tracedTx) ForEach(bucket string, fromPrefix []byte, walker func(k, v []byte) error) error {
+	return tx.Tx.ForEach(bucket, fromPrefix, func(k, v []byte) error {
+		tx.read()
+		return walker(k, v)
+	})
+}
+
+func (tx *tracedTx) ForPrefix(bucket string, prefix []byte, walker func(k, v []byte) error) error {
+	return tx.Tx.ForPrefix(bucket, prefix, func(k, v []byte) error {
+		tx.read()
+		return walker(k, v)
+	})
+}
+
+func (tx *tracedTx) ForAmount(bucket string, prefix []byte, amount uint32, walker func(k, v []byte) error) error {
+	return tx.Tx.ForAmount(bucket, prefix, amount, func(k, v []byte) error {
+		tx.read()
+		return walker(k, v)
+	})
+}
+
+func (tx *tracedTx) Cursor(bucket string) (kv.Cursor, error) {
+	c, err := tx.Tx.Cursor(bucket)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&tx.cursors, 1)
+	return &tracedCursor{Cursor: c, tx: tx}, nil
+}
+
+func (tx *tracedTx) CursorDupSort(bucket string) (kv.CursorDupSort, error) {
+	c, err := tx.Tx.CursorDupSort(bucket)
+	if err != nil {
+		return nil, err
+	}
+	atomic.AddInt64(&tx.cursors, 1)
+	return &tracedCursorDupSort{CursorDupSort: c, tx: tx}, nil
+}
+
+type tracedCursor struct {
+	kv.Cursor
+	tx *tracedTx
+}
+
+func (c *tracedCursor) First() ([]byte, []byte, error) {
+	c.tx.read()
+	return c.Cursor.First()
+}
+
+func (c *tracedCursor) Seek(seek []byte) ([]byte, []byte, error) {
+	c.tx.read()
+	return c.Cursor.Seek(seek)
+}
+
+func (c *tracedCursor) SeekExact(key []byte) ([]byte, []byte, error) {
+	c.tx.read()
+	return c.Cursor.SeekExact(key)
+}
+
+func (c *tracedCursor) Next() ([]byte, []byte, error) {
+	c.tx.read()
+	return c.Cursor.Next()
+}
+
+func (c *tracedCursor) Prev() ([]byte, []byte, error) {
+	c.tx.read()
+	return c.Cursor.Prev()
+}
+
+func (c *tracedCursor) Last() ([]byte, []byte, error) {
+	c.tx.read()
+	return c.Cursor.Last()
+}
+
+type tracedCursorDupSort struct {
+	kv.CursorDupSort
+	tx *tracedTx
+}
+
+func (c *tracedCursorDupSort) First() ([]byte, []byte, error) {
+	c.tx.read()
+	return c.CursorDupSort.First()
+}
+
+func (c *tracedCursorDupSort) Seek(seek []byte) ([]byte, []byte, error) {
+	c.tx.read()
+	return c.CursorDupSort.Seek(seek)
+}
+
+func (c *tracedCursorDupSort) SeekExact(key []byte) ([]byte, []byte, error) {
+	c.tx.read()
+	return c.CursorDupSort.SeekExact(key)
+}
+
+func (c *tracedCursorDupSort) Next() ([]byte, []byte, error) {
+	c.tx.read()
+	return c.CursorDupSort.Next()
+}
+
+func (c *tracedCursorDupSort) Prev() ([]byte, []byte, error) {
+	c.tx.read()
+	return c.CursorDupSort.Prev()
+}
+
+func (c *tracedCursorDupSort) Last() ([]byte, []byte, error) {
+	c.tx.read()
+	return c.CursorDupSort.Last()
+}
+
+func (c *tracedCursorDupSort) SeekBothExact(key, value []byte) ([]byte, []byte, error) {
+	c.tx.read()
+	return c.CursorDupSort.SeekBothExact(key, value)
+}
+
+func (c *tracedCursorDupSort) SeekBothRange(key, value []byte) ([]byte, error) {
+	c.tx.read()
+	return c.CursorDupSort.SeekBothRange(key, value)
+}
+
+func (c *tracedCursorDupSort) NextDup() ([]byte, []byte, error) {
+	c.tx.read()
+	return c.CursorDupSort.NextDup()
+}
+
+func (c *tracedCursorDupSort) NextNoDup() ([]byte, []byte, error) {
+	c.tx.read()
+	return c.CursorDupSort.NextNoDup()
+}
@@ -0,0 +1,202 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common/ctxutil"
+)
+
+// RecordKVReads - reads (cursor moves and Getter calls) of read transactions opened with context carrying
+// ctxutil.KVReads are added to it with the entries they returned. Transactions opened with other contexts are not
+// wrapped.
+func RecordKVReads(db kv.RoDB) kv.RoDB {
+	return &kvReadsDB{RoDB: db}
+}
+
+type kvReadsDB struct {
+	kv.RoDB
+}
+
+func (db *kvReadsDB) BeginRo(ctx context.Context) (kv.Tx, error) {
+	tx, err := db.RoDB.BeginRo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reads := ctxutil.KVReadsFromContext(ctx)
+	if reads == nil {
+		return tx, nil
+	}
+	return &kvReadsTx{Tx: tx, reads: reads}, nil
+}
+
+func (db *kvReadsDB) View(ctx context.Context, f func(tx kv.Tx) error) error {
+	tx, err := db.BeginRo(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return f(tx)
+}
+
+type kvReadsTx struct {
+	kv.Tx
+	reads *ctxutil.KVReads
+}
+
+func (tx *kvReadsTx) Has(bucket string, key []byte) (bool, error) {
+	has, err := tx.Tx.Has(bucket, key)
+	var v []byte
+	if has {
+		v = []byte{}
+	}
+	tx.reads.Add(bucket, "Has", key, nil, v, err)
+	return has, err
+}
+
+func (tx *kvReadsTx) GetOne(bucket string, key []byte) ([]byte, error) {
+	v, err := tx.Tx.GetOne(bucket, key)
+	tx.reads.Add(bucket, "GetOne", key, nil, v, err)
+	return v, err
+}
+
+func (tx *kvReadsTx) ForEach(bucket string, fromPrefix []byte, walker func(k, v []byte) error) error {
+	return tx.Tx.ForEach(bucket, fromPrefix, func(k, v []byte) error {
+		tx.reads.Add(bucket, "ForEach", fromPrefix, k, v, nil)
+		return walker(k, v)
+	})
+}
+
+func (tx *kvReadsTx) ForPrefix(bucket string, prefix []byte, walker func(k, v []byte) error) error {
+	return tx.Tx.ForPrefix(bucket, prefix, func(k, v []byte) error {
+		tx.reads.Add(bucket, "ForPrefix", prefix, k, v, nil)
+		return walker(k, v)
+	})
+}
+
+func (tx *kvReadsTx) ForAmount(bucket string, prefix []byte, amount uint32, walker func(k, v []byte) error) error {
+	return tx.Tx.ForAmount(bucket, prefix, amount, func(k, v []byte) error {
+		tx.reads.Add(bucket, "ForAmount", prefix, k, v, nil)
+		return walker(k, v)
+	})
+}
+
+func (tx *kvReadsTx) Cursor(bucket string) (kv.Cursor, error) {
+	c, err := tx.Tx.Cursor(bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &kvReadsCursor{Cursor: c, bucket: bucket, reads: tx.reads}, nil
+}
+
+func (tx *kvReadsTx) CursorDupSort(bucket string) (kv.CursorDupSort, error) {
+	c, err := tx.Tx.CursorDupSort(bucket)
+	if err != nil {
+		return nil, err
+	}
+	return &kvReadsCursorDupSort{CursorDupSort: c, bucket: bucket, reads: tx.reads}, nil
+}
+
+type kvReadsCursor struct {
+	kv.Cursor
+	bucket string
+	reads  *ctxutil.KVReads
+}
+
+func (c *kvReadsCursor) add(op string, arg []byte, k, v []byte, err error) ([]byte, []byte, error) {
+	c.reads.Add(c.bucket, op, arg, k, v, err)
+	return k, v, err
+}
+
+func (c *kvReadsCursor) First() ([]byte, []byte, error) {
+	k, v, err := c.Cursor.First()
+	return c.add("First", nil, k, v, err)
+}
+
+func (c *kvReadsCursor) Seek(seek []byte) ([]byte, []byte, error) {
+	k, v, err := c.Cursor.Seek(seek)
+	return c.add("Seek", seek, k, v, err)
+}
+
+func (c *kvReadsCursor) SeekExact(key []byte) ([]byte, []byte, error) {
+	k, v, err := c.Cursor.SeekExact(key)
+	return c.add("SeekExact", key, k, v, err)
+}
+
+func (c *kvReadsCursor) Next() ([]byte, []byte, error) {
+	k, v, err := c.Cursor.Next()
+	return c.add("Next", nil, k, v, err)
+}
+
+func (c *kvReadsCursor) Prev() ([]byte, []byte, error) {
+	k, v, err := c.Cursor.Prev()
+	return c.add("Prev", nil, k, v, err)
+}
+
+func (c *kvReadsCursor) Last() ([]byte, []byte, error) {
+	k, v, err := c.Cursor.Last()
+	return c.add("Last", nil, k, v, err)
+}
+
+type kvReadsCursorDupSort struct {
+	kv.CursorDupSort
+	bucket string
+	reads  *ctxutil.KVReads
+}
+
+func (c *kvReadsCursorDupSort) add(op string, arg []byte, k, v []byte, err error) ([]byte, []byte, error) {
+	c.reads.Add(c.bucket, op, arg, k, v, err)
+	return k, v, err
+}
+
+func (c *kvReadsCursorDupSort) First() ([]byte, []byte, error) {
+	k, v, err := c.CursorDupSort.First()
+	return c.add("First", nil, k, v, err)
+}
+
+func (c *kvReadsCursorDupSort) Seek(seek []byte) ([]byte, []byte, error) {
+	k, v, err := c.CursorDupSort.Seek(seek)
+	return c.add("Seek", seek, k, v, err)
+}
+
+func (c *kvReadsCursorDupSort) SeekExact(key []byte) ([]byte, []byte, error) {
+	k, v, err := c.CursorDupSort.SeekExact(key)
+	return c.add("SeekExact", key, k, v, err)
+}
+
+func (c *kvReadsCursorDupSort) Next() ([]byte, []byte, error) {
+	k, v, err := c.CursorDupSort.Next()
+	return c.add("Next", nil, k, v, err)
+}
+
+func (c *kvReadsCursorDupSort) Prev() ([]byte, []byte, error) {
+	k, v, err := c.CursorDupSort.Prev()
+	return c.add("Prev", nil, k, v, err)
+}
+
+func (c *kvReadsCursorDupSort) Last() ([]byte, []byte, error) {
+	k, v, err := c.CursorDupSort.Last()
+	return c.add("Last", nil, k, v, err)
+}
+
+// SeekBothExact and SeekBothRange record key and value they are called with, as one argument
+func (c *kvReadsCursorDupSort) SeekBothExact(key, value []byte) ([]byte, []byte, error) {
+	k, v, err := c.CursorDupSort.SeekBothExact(key, value)
+	return c.add("SeekBothExact", append(append([]byte{}, key...), value...), k, v, err)
+}
+
+func (c *kvReadsCursorDupSort) SeekBothRange(key, value []byte) ([]byte, error) {
+	v, err := c.CursorDupSort.SeekBothRange(key, value)
+	_, v, err = c.add("SeekBothRange", append(append([]byte{}, key...), value...), nil, v, err)
+	return v, err
+}
+
+func (c *kvReadsCursorDupSort) NextDup() ([]byte, []byte, error) {
+	k, v, err := c.CursorDupSort.NextDup()
+	return c.add("NextDup", nil, k, v, err)
+}
+
+func (c *kvReadsCursorDupSort) NextNoDup() ([]byte, []byte, error) {
+	k, v, err := c.CursorDupSort.NextNoDup()
+	return c.add("NextNoDup", nil, k, v, err)
+}
@@ -0,0 +1,77 @@
+// Package tracing - OpenTelemetry tracing of rpcdaemon and Erigon: spans of JSON-RPC calls, gRPC calls between them
+// and database transactions. Trace context is propagated by W3C `traceparent` HTTP header and gRPC metadata.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+var enabled int32
+
+// Enabled - if Setup was called, without it spans are not recorded and instrumentation can be skipped
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+// Setup - exports spans of service to OTLP collector listening for gRPC at endpoint (host:port). Traces started by
+// callers (with sampled `traceparent`) are always recorded, other ones - with probability sampleRatio.
+// Returned function flushes not exported spans and must be called on exit.
+func Setup(ctx context.Context, service string, endpoint string, sampleRatio float64) (func(context.Context) error, error) {
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(service))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	atomic.StoreInt32(&enabled, 1)
+	return provider.Shutdown, nil
+}
+
+// Tracer - tracer of instrumented package, no-op until Setup is called
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// ExtractHTTP - context continuing trace of caller which sent `traceparent` header
+func ExtractHTTP(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// DialOptions - client interceptors starting span per gRPC call and passing its context to the server,
+// nil if tracing is not enabled
+func DialOptions() []grpc.DialOption {
+	if !Enabled() {
+		return nil
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+	}
+}
+
+// ServerInterceptors - server interceptors continuing traces of clients, nil if tracing is not enabled
+func ServerInterceptors() ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
+	if !Enabled() {
+		return nil, nil
+	}
+	return []grpc.UnaryServerInterceptor{otelgrpc.UnaryServerInterceptor()},
+		[]grpc.StreamServerInterceptor{otelgrpc.StreamServerInterceptor()}
+}
@@ -42,13 +42,17 @@ func ExpHandler(r metrics.Registry) http.Handler {
 	return http.HandlerFunc(e.expHandler)
 }
 
+// PrometheusHandler - writes all metrics in Prometheus text format
+func PrometheusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	metrics2.WritePrometheus(w, true)
+}
+
 // Setup starts a dedicated metrics server at the given address.
 // This function enables metrics reporting separate from pprof.
 func Setup(address string) {
-	http.HandleFunc("/debug/metrics/prometheus", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		metrics2.WritePrometheus(w, true)
-	})
+	http.HandleFunc("/debug/metrics/prometheus", PrometheusHandler)
+	http.HandleFunc("/metrics", PrometheusHandler) // default path of Prometheus scrape configs
 	//m.Handle("/debug/metrics", ExpHandler(metrics.DefaultRegistry))
 	//m.Handle("/debug/metrics/prometheus2", promhttp.HandlerFor(prometheus2.DefaultGatherer, promhttp.HandlerOpts{
 	//	EnableOpenMetrics: true,
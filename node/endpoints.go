@@ -17,6 +17,7 @@
 package node
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"time"
@@ -27,6 +28,11 @@ import (
 
 // StartHTTPEndpoint starts the HTTP RPC endpoint.
 func StartHTTPEndpoint(endpoint string, timeouts rpc.HTTPTimeouts, handler http.Handler) (*http.Server, net.Addr, error) {
+	return StartHTTPSEndpoint(endpoint, timeouts, handler, nil)
+}
+
+// StartHTTPSEndpoint starts the HTTP RPC endpoint terminating TLS with tlsConfig, plain HTTP if it's nil.
+func StartHTTPSEndpoint(endpoint string, timeouts rpc.HTTPTimeouts, handler http.Handler, tlsConfig *tls.Config) (*http.Server, net.Addr, error) {
 	// start the HTTP listener
 	var (
 		listener net.Listener
@@ -35,6 +41,9 @@ func StartHTTPEndpoint(endpoint string, timeouts rpc.HTTPTimeouts, handler http.
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
 	// make sure timeout values are meaningful
 	CheckTimeouts(&timeouts)
 	// Bundle and start the HTTP server
@@ -20,7 +20,6 @@ import (
 	"compress/gzip"
 	"context"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -289,7 +288,7 @@ func (h *httpServer) enableRPC(apis []rpc.API, config httpConfig, allowList rpc.
 	}
 	h.httpConfig = config
 	h.httpHandler.Store(&rpcHandler{
-		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts, config.Compression),
+		Handler: NewHTTPHandlerStack(srv, config.CorsAllowedOrigins, config.Vhosts, defaultGzip(config.Compression)),
 		server:  srv,
 	})
 	return nil
@@ -366,13 +365,13 @@ func isWebsocket(r *http.Request) bool {
 		strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade")
 }
 
-// NewHTTPHandlerStack returns wrapped http-related handlers
-func NewHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string, compression bool) http.Handler {
+// NewHTTPHandlerStack returns wrapped http-related handlers, responses are not compressed if gz is nil
+func NewHTTPHandlerStack(srv http.Handler, cors []string, vhosts []string, gz *GzipConfig) http.Handler {
 	// Wrap the CORS-handler within a host-handler
 	handler := newCorsHandler(srv, cors)
 	handler = newVHostHandler(vhosts, handler)
-	if compression {
-		handler = newGzipHandler(handler)
+	if gz != nil {
+		handler = newGzipHandler(handler, *gz)
 	}
 	return handler
 }
@@ -438,43 +437,92 @@ func (h *virtualHostHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "invalid host specified", http.StatusForbidden)
 }
 
-var gzPool = sync.Pool{
-	New: func() interface{} {
-		w := gzip.NewWriter(ioutil.Discard)
-		return w
-	},
+// GzipConfig - gzip of HTTP responses to clients accepting it
+type GzipConfig struct {
+	Level   int // gzip.HuffmanOnly (-2) to gzip.BestCompression (9), or gzip.DefaultCompression (-1)
+	MinSize int // responses smaller than this amount of bytes are sent uncompressed
 }
 
+func defaultGzip(enabled bool) *GzipConfig {
+	if !enabled {
+		return nil
+	}
+	return &GzipConfig{Level: gzip.DefaultCompression}
+}
+
+// gzipResponseWriter - buffers first MinSize bytes of response, compression starts once there are as many,
+// status is sent together with the first bytes
 type gzipResponseWriter struct {
-	io.Writer
 	http.ResponseWriter
+	minSize int
+	pool    *sync.Pool
+	gz      *gzip.Writer // nil until compression starts
+	buf     []byte
+	status  int
 }
 
 func (w *gzipResponseWriter) WriteHeader(status int) {
-	w.Header().Del("Content-Length")
-	w.ResponseWriter.WriteHeader(status)
+	w.status = status
 }
 
 func (w *gzipResponseWriter) Write(b []byte) (int, error) {
-	return w.Writer.Write(b)
+	if w.gz != nil {
+		return w.gz.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	if len(w.buf) < w.minSize {
+		return len(b), nil
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Encoding", "gzip")
+	w.writeHeader()
+	w.gz = w.pool.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+	if _, err := w.gz.Write(w.buf); err != nil {
+		return 0, err
+	}
+	w.buf = nil
+	return len(b), nil
+}
+
+func (w *gzipResponseWriter) writeHeader() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
 }
 
-func newGzipHandler(next http.Handler) http.Handler {
+// finish - ends compressed response, or sends response smaller than MinSize as is
+func (w *gzipResponseWriter) finish() {
+	if w.gz != nil {
+		w.gz.Close()
+		w.pool.Put(w.gz)
+		return
+	}
+	w.writeHeader()
+	if len(w.buf) > 0 {
+		w.ResponseWriter.Write(w.buf) //nolint:errcheck
+	}
+}
+
+func newGzipHandler(next http.Handler, cfg GzipConfig) http.Handler {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			w, err := gzip.NewWriterLevel(ioutil.Discard, cfg.Level)
+			if err != nil {
+				w = gzip.NewWriter(ioutil.Discard)
+			}
+			return w
+		},
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
 			next.ServeHTTP(w, r)
 			return
 		}
-
-		w.Header().Set("Content-Encoding", "gzip")
-
-		gz := gzPool.Get().(*gzip.Writer)
-		defer gzPool.Put(gz)
-
-		gz.Reset(w)
-		defer gz.Close()
-
-		next.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, Writer: gz}, r)
+		w.Header().Add("Vary", "Accept-Encoding")
+		gw := &gzipResponseWriter{ResponseWriter: w, minSize: cfg.MinSize, pool: pool}
+		defer gw.finish()
+		next.ServeHTTP(gw, r)
 	})
 }
 
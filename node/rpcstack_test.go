@@ -18,9 +18,11 @@ package node
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strconv"
 	"strings"
@@ -180,6 +182,49 @@ func TestIsWebsocket(t *testing.T) {
 	assert.True(t, isWebsocket(r))
 }
 
+func TestGzipHandler(t *testing.T) {
+	large := strings.Repeat("x", 100)
+	handler := newGzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+		w.Header().Set("Content-Length", strconv.Itoa(2*size))
+		w.WriteHeader(http.StatusAccepted)
+		for i := 0; i < 2; i++ {
+			w.Write([]byte(large[:size])) //nolint:errcheck
+		}
+	}), GzipConfig{Level: gzip.BestSpeed, MinSize: 60})
+	request := func(size int, acceptGzip bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/?size="+strconv.Itoa(size), nil)
+		if acceptGzip {
+			r.Header.Set("Accept-Encoding", "gzip, deflate")
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// 2 writes of 50 bytes
+	resp := request(50, true)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, "gzip", resp.Header().Get("Content-Encoding"))
+	assert.Equal(t, "", resp.Header().Get("Content-Length"))
+	gz, err := gzip.NewReader(resp.Body)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(gz)
+	assert.NoError(t, err)
+	assert.Equal(t, large, string(body))
+
+	// 2 writes of 25 bytes are below MinSize
+	resp = request(25, true)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, "", resp.Header().Get("Content-Encoding"))
+	assert.Equal(t, "50", resp.Header().Get("Content-Length"))
+	assert.Equal(t, large[:50], resp.Body.String())
+
+	resp = request(50, false)
+	assert.Equal(t, "", resp.Header().Get("Content-Encoding"))
+	assert.Equal(t, large, resp.Body.String())
+}
+
 func Test_checkPath(t *testing.T) {
 	tests := []struct {
 		req      *http.Request
@@ -122,6 +122,12 @@ const (
 	ElasticityMultiplier     = 2          // Bounds the maximum gas limit an EIP-1559 block may have.
 	InitialBaseFee           = 1000000000 // Initial base fee for EIP-1559 blocks.
 
+	BlobGasPerBlob             uint64 = 1 << 17       // Gas consumption of a single data blob (EIP-4844)
+	MaxBlobGasPerBlock         uint64 = 6 * (1 << 17) // Maximum blob gas of a block, 6 blobs (EIP-4844)
+	MinBlobGasPrice            uint64 = 1             // Minimum price of blob gas (EIP-4844)
+	BlobGasPriceUpdateFraction uint64 = 3338477       // Controls maximum rate of change of blob gas price (EIP-4844)
+	BlobCommitmentVersionKZG   byte   = 0x01          // Version byte of versioned hashes of KZG commitments (EIP-4844)
+
 	MaxCodeSize = 24576 // Maximum bytecode to permit for a contract
 
 	// Precompiled contract gas prices
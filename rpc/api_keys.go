@@ -0,0 +1,130 @@
+package rpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// APIKeyHeader - HTTP header with API key of client, also read on websocket handshake
+const APIKeyHeader = "X-API-Key"
+
+// apiKey - API key sent by client of the call, empty if it didn't send one
+func apiKey(ctx context.Context) string {
+	key, _ := ctx.Value("apiKey").(string)
+	return key
+}
+
+// Authenticator decides if client can call method, key is the API key sent by client or empty if it didn't send one.
+// Returned error is sent to client instead of the call result.
+type Authenticator interface {
+	Authorize(ctx context.Context, key string, method string) error
+}
+
+// Permissions - namespaces and single methods available to client, "*" namespace allows all methods
+type Permissions struct {
+	Namespaces []string    `json:"namespaces"`
+	Methods    []string    `json:"methods"`
+	CallLimits *CallLimits `json:"callLimits,omitempty"` // nil - limits of the server
+	Name       string      `json:"name,omitempty"`       // client of the key in usage reports, not used by Public
+	Quota      *UsageQuota `json:"quota,omitempty"`      // of each key, or of each IP address for Public
+}
+
+func (p Permissions) allows(method string) bool {
+	namespace := namespaceOf(method)
+	for _, ns := range p.Namespaces {
+		if ns == "*" || ns == namespace {
+			return true
+		}
+	}
+	for _, m := range p.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeys - permissions of clients without API key (Public) and permissions which each key adds to them
+type APIKeys struct {
+	Public Permissions            `json:"public"`
+	Keys   map[string]Permissions `json:"keys"`
+}
+
+// Validate - checks quotas of keys
+func (k APIKeys) Validate() error {
+	if k.Public.Quota != nil {
+		if err := k.Public.Quota.Validate(); err != nil {
+			return fmt.Errorf("public: %w", err)
+		}
+	}
+	for _, permissions := range k.Keys {
+		if permissions.Quota == nil {
+			continue
+		}
+		if err := permissions.Quota.Validate(); err != nil {
+			if permissions.Name != "" {
+				return fmt.Errorf("key of %s: %w", permissions.Name, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// NewAPIKeyAuthenticator - authenticator checking API keys against the fixed set of keys, unknown keys are rejected
+func NewAPIKeyAuthenticator(keys APIKeys) Authenticator {
+	return &apiKeyAuthenticator{keys: keys}
+}
+
+type apiKeyAuthenticator struct {
+	keys APIKeys
+}
+
+func (a *apiKeyAuthenticator) Authorize(_ context.Context, key string, method string) error {
+	permissions, ok := a.keys.Keys[key]
+	if key != "" && !ok {
+		return &unauthorizedError{"invalid API key"}
+	}
+	if a.keys.Public.allows(method) || (ok && permissions.allows(method)) {
+		return nil
+	}
+	if key == "" {
+		return &unauthorizedError{fmt.Sprintf("method %s requires API key", method)}
+	}
+	return &unauthorizedError{fmt.Sprintf("method %s is not allowed for this API key", method)}
+}
+
+// CallLimits - limits of the key, public limits for calls without key and keys without own limits
+func (a *apiKeyAuthenticator) CallLimits(_ context.Context, key string) *CallLimits {
+	if permissions, ok := a.keys.Keys[key]; ok && permissions.CallLimits != nil {
+		return permissions.CallLimits
+	}
+	return a.keys.Public.CallLimits
+}
+
+// ClientPlan - name (digest of the key if it has no name) and quota of the key, public quota for keys without own
+// quota. Empty name and public quota for calls without key and unknown keys
+func (a *apiKeyAuthenticator) ClientPlan(_ context.Context, key string) (string, *UsageQuota) {
+	permissions, ok := a.keys.Keys[key]
+	if !ok {
+		return "", a.keys.Public.Quota
+	}
+	name := permissions.Name
+	if name == "" {
+		sum := sha256.Sum256([]byte(key))
+		name = "key:" + hex.EncodeToString(sum[:8])
+	}
+	if permissions.Quota != nil {
+		return name, permissions.Quota
+	}
+	return name, a.keys.Public.Quota
+}
+
+// unauthorizedError - returned for calls rejected by Authenticator
+type unauthorizedError struct{ message string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *unauthorizedError) Error() string { return e.message }
@@ -0,0 +1,164 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var testAPIKeys = APIKeys{
+	Public: Permissions{Methods: []string{"test_echo"}},
+	Keys: map[string]Permissions{
+		"admin": {Namespaces: []string{"*"}},
+		"user":  {Namespaces: []string{"nftest"}, Methods: []string{"test_rets"}},
+	},
+}
+
+func TestHTTPAPIKeys(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetAuthenticator(NewAPIKeyAuthenticator(testAPIKeys))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	call := func(key string, method string, args ...interface{}) error {
+		client, err := DialHTTP(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		if key != "" {
+			client.SetHeader(APIKeyHeader, key)
+		}
+		var result interface{}
+		return client.Call(&result, method, args...)
+	}
+	tests := []struct {
+		key, method string
+		args        []interface{}
+		allowed     bool
+	}{
+		{"", "test_echo", []interface{}{"hello", 10, &echoArgs{"world"}}, true},
+		{"", "test_rets", nil, false},
+		{"user", "test_echo", []interface{}{"hello", 10, &echoArgs{"world"}}, true},
+		{"user", "test_rets", nil, true},
+		{"user", "nftest_echo", []interface{}{1}, true},
+		{"user", "test_sleep", []interface{}{0}, false},
+		{"admin", "test_sleep", []interface{}{0}, true},
+		{"unknown", "test_echo", []interface{}{"hello", 10, &echoArgs{"world"}}, false},
+	}
+	for _, tt := range tests {
+		err := call(tt.key, tt.method, tt.args...)
+		if tt.allowed {
+			if err != nil {
+				t.Errorf("key %q, method %s: %v", tt.key, tt.method, err)
+			}
+			continue
+		}
+		var rpcErr Error
+		if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32001 {
+			t.Errorf("key %q, method %s: expected unauthorized error, got %v", tt.key, tt.method, err)
+		}
+	}
+}
+
+func TestWebsocketAPIKeys(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetAuthenticator(NewAPIKeyAuthenticator(testAPIKeys))
+	httpsrv := httptest.NewServer(server.WebsocketHandler([]string{"*"}, false))
+	defer httpsrv.Close()
+	wsURL := "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+
+	call := func(key string) string {
+		header := http.Header{}
+		if key != "" {
+			header.Set(APIKeyHeader, key)
+		}
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		if err = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_rets"}`)); err != nil {
+			t.Fatal(err)
+		}
+		_, resp, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(resp)
+	}
+	if resp := call(""); !strings.Contains(resp, `"code":-32001`) {
+		t.Fatalf("call without key is not rejected: %s", resp)
+	}
+	if resp := call("user"); strings.Contains(resp, `"error"`) {
+		t.Fatalf("call with key failed: %s", resp)
+	}
+}
+
+type callLimitsService struct{}
+
+func (s *callLimitsService) Get(ctx context.Context) *CallLimits { return CallLimitsFromContext(ctx) }
+
+func TestAPIKeysCallLimits(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	if err := server.RegisterName("limits", &callLimitsService{}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetAuthenticator(NewAPIKeyAuthenticator(APIKeys{
+		Public: Permissions{Namespaces: []string{"limits"}, CallLimits: &CallLimits{GasCap: 1000000, ReturnDataLimit: 1024}},
+		Keys: map[string]Permissions{
+			"internal": {CallLimits: &CallLimits{GasCap: 100000000, EVMTimeout: Duration(time.Minute)}},
+			"default":  {},
+		},
+	}))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	limits := func(key string) *CallLimits {
+		client, err := DialHTTP(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		if key != "" {
+			client.SetHeader(APIKeyHeader, key)
+		}
+		var result *CallLimits
+		if err = client.Call(&result, "limits_get"); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	if l := limits(""); l == nil || l.GasCap != 1000000 || l.ReturnDataLimit != 1024 {
+		t.Errorf("public limits: %+v", l)
+	}
+	if l := limits("default"); l == nil || l.GasCap != 1000000 {
+		t.Errorf("limits of key without own ones: %+v", l)
+	}
+	if l := limits("internal"); l == nil || l.GasCap != 100000000 || l.EVMTimeout != Duration(time.Minute) || l.ReturnDataLimit != 0 {
+		t.Errorf("limits of key: %+v", l)
+	}
+}
+
+func TestCallLimitsJSON(t *testing.T) {
+	var keys APIKeys
+	if err := json.Unmarshal([]byte(`{"keys":{"k":{"namespaces":["eth"],"callLimits":{"gasCap":5,"evmTimeout":"1m30s"}}}}`), &keys); err != nil {
+		t.Fatal(err)
+	}
+	if l := keys.Keys["k"].CallLimits; l == nil || l.GasCap != 5 || time.Duration(l.EVMTimeout) != 90*time.Second {
+		t.Errorf("unexpected limits: %+v", l)
+	}
+	if err := json.Unmarshal([]byte(`{"public":{"callLimits":{"evmTimeout":"soon"}}}`), &keys); err == nil {
+		t.Error("expected error for invalid duration")
+	}
+}
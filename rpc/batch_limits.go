@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// BatchLimits - limits of batch requests, 0 means no limit
+type BatchLimits struct {
+	MaxItems        int // messages in one batch, larger batches are rejected without executing any call
+	MaxResponseSize int // bytes of all answers of one batch, answers following the one which reached it are replaced by error
+}
+
+// tooLarge - answers rejecting every call of batch larger than MaxItems, nil if it isn't
+func (l BatchLimits) tooLarge(msgs []*jsonrpcMessage) []*jsonrpcMessage {
+	if l.MaxItems <= 0 || len(msgs) <= l.MaxItems {
+		return nil
+	}
+	err := &invalidRequestError{fmt.Sprintf("batch of %d requests exceeds limit of %d", len(msgs), l.MaxItems)}
+	answers := make([]*jsonrpcMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.hasValidID() {
+			answers = append(answers, msg.errorResponse(err))
+		}
+	}
+	if len(answers) == 0 { // batch of notifications
+		answers = append(answers, errorMessage(err))
+	}
+	return answers
+}
+
+// limitResponse - replaces answers to calls (in order of requests) following the one which reached MaxResponseSize
+func (l BatchLimits) limitResponse(calls []*jsonrpcMessage, answers []interface{}) {
+	if l.MaxResponseSize <= 0 {
+		return
+	}
+	size := 0
+	for i, call := range calls {
+		if answers[i] == nil { // notification
+			continue
+		}
+		if size >= l.MaxResponseSize {
+			answers[i] = call.errorResponse(&responseTooLargeError{limit: l.MaxResponseSize})
+			continue
+		}
+		size += answerSize(answers[i])
+	}
+}
+
+func answerSize(answer interface{}) int {
+	switch answer := answer.(type) {
+	case json.RawMessage:
+		return len(answer)
+	case *jsonrpcMessage:
+		size := len(answer.ID) + len(answer.Result)
+		if answer.Error != nil {
+			size += len(answer.Error.Message)
+		}
+		return size
+	default:
+		return 0
+	}
+}
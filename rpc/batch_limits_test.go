@@ -0,0 +1,49 @@
+package rpc
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBatchLimits(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetBatchLimits(BatchLimits{MaxItems: 3, MaxResponseSize: 60})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client, err := DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	echo := func(s string) BatchElem {
+		return BatchElem{Method: "test_echo", Args: []interface{}{s, 1, &echoArgs{"x"}}, Result: new(echoResult)}
+	}
+	batch := []BatchElem{echo("a"), echo("b"), echo("c"), echo("d")}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	}
+	var rpcErr Error
+	for i, elem := range batch {
+		if !errors.As(elem.Error, &rpcErr) || rpcErr.ErrorCode() != -32600 || !strings.Contains(elem.Error.Error(), "exceeds limit of 3") {
+			t.Fatalf("call %d: expected error of too large batch, got %v", i, elem.Error)
+		}
+	}
+
+	// every answer is ~40 bytes: the second one reaches the limit, the third is replaced
+	batch = []BatchElem{echo("a"), echo("b"), echo("c")}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatal(err)
+	}
+	for i, elem := range batch[:2] {
+		if elem.Error != nil {
+			t.Fatalf("call %d failed: %v", i, elem.Error)
+		}
+	}
+	if !errors.As(batch[2].Error, &rpcErr) || rpcErr.ErrorCode() != -32003 {
+		t.Fatalf("expected error of too large response, got %v", batch[2].Error)
+	}
+}
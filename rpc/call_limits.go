@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// CallLimits - limits of EVM execution of eth_call and eth_estimateGas, zero fields keep limits of the server
+type CallLimits struct {
+	GasCap          uint64   `json:"gasCap"`
+	EVMTimeout      Duration `json:"evmTimeout"`
+	ReturnDataLimit int      `json:"returnDataLimit"` // bytes
+}
+
+// Duration - time.Duration read from JSON string like "30s"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(input []byte) error {
+	var s string
+	if err := json.Unmarshal(input, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// CallLimiter - optionally implemented by Authenticator to override CallLimits of authorized calls, nil - no overrides
+type CallLimiter interface {
+	CallLimits(ctx context.Context, key string) *CallLimits
+}
+
+type callLimitsKey struct{}
+
+// CallLimitsFromContext - overrides of server limits for the call, nil if there are none
+func CallLimitsFromContext(ctx context.Context) *CallLimits {
+	limits, _ := ctx.Value(callLimitsKey{}).(*CallLimits)
+	return limits
+}
+
+// WithCallLimits - ctx of call with overridden limits
+func WithCallLimits(ctx context.Context, limits *CallLimits) context.Context {
+	return context.WithValue(ctx, callLimitsKey{}, limits)
+}
+
+// callLimits - adds overrides of authenticator, if it provides them, to ctx of the call
+func (h *handler) callLimits(ctx context.Context) context.Context {
+	limiter, ok := h.authenticator.(CallLimiter)
+	if !ok {
+		return ctx
+	}
+	if limits := limiter.CallLimits(ctx, apiKey(ctx)); limits != nil {
+		return WithCallLimits(ctx, limits)
+	}
+	return ctx
+}
@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common/ctxutil"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// CallRecord - served call as written to AuditLog. Params are recorded only as digest, they may be large or private
+type CallRecord struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	Params    string    `json:"params"`
+	Remote    string    `json:"remote"`
+	RequestID string    `json:"requestId,omitempty"`
+	Duration  float64   `json:"durationMs"`
+	GRPC      float64   `json:"grpcMs"` // part of Duration spent waiting for Erigon
+	Error     string    `json:"error,omitempty"`
+}
+
+// AuditLog - receives record of every call served by Server
+type AuditLog interface {
+	Record(rec *CallRecord)
+	Close() error
+}
+
+// NewAuditLog - audit log writing records as JSON lines to file at path (appending to it), or to local syslog if path
+// is "syslog"
+func NewAuditLog(path string) (AuditLog, error) {
+	if path == "syslog" {
+		w, err := newSyslogWriter()
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to syslog: %w", err)
+		}
+		return &jsonAuditLog{w: w}, nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open audit log: %w", err)
+	}
+	return &jsonAuditLog{w: f}, nil
+}
+
+type jsonAuditLog struct {
+	lock sync.Mutex
+	w    io.WriteCloser
+}
+
+func (l *jsonAuditLog) Record(rec *CallRecord) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, err = l.w.Write(append(line, '\n')); err != nil {
+		log.Warn("Could not write RPC audit log", "err", err)
+	}
+}
+
+func (l *jsonAuditLog) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.w.Close()
+}
+
+// callLog - logs calls taking longer than slowThreshold (0 - none are logged), records every call to audit and its
+// request and response to recorder (if set)
+type callLog struct {
+	slowThreshold time.Duration
+	audit         AuditLog
+	recorder      RequestRecorder
+}
+
+func (l *callLog) enabled() bool {
+	return l != nil && (l.slowThreshold > 0 || l.audit != nil)
+}
+
+// record - called after answering msg, grpcTime is the one attached to context of the call
+func (l *callLog) record(ctx context.Context, logger log.Logger, msg *jsonrpcMessage, answer *jsonrpcMessage, remoteAddr string, start time.Time, grpcTime *ctxutil.GRPCTime) {
+	if !l.enabled() {
+		return
+	}
+	took := time.Since(start)
+	slow := l.slowThreshold > 0 && took >= l.slowThreshold
+	if !slow && l.audit == nil {
+		return
+	}
+	remote := remoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		remote = host
+	}
+	params, grpc := paramsDigest(msg.Params), grpcTime.Get()
+	if slow {
+		logger.Warn("Slow RPC call", "method", msg.Method, "params", params, "remote", remote, "took", took,
+			"grpc", grpc, "local", took-grpc)
+	}
+	if l.audit == nil {
+		return
+	}
+	rec := &CallRecord{
+		Time:      start,
+		Method:    msg.Method,
+		Params:    params,
+		Remote:    remote,
+		RequestID: ctxutil.RequestID(ctx),
+		Duration:  float64(took) / float64(time.Millisecond),
+		GRPC:      float64(grpc) / float64(time.Millisecond),
+	}
+	if answer != nil && answer.Error != nil {
+		rec.Error = answer.Error.Message
+	}
+	l.audit.Record(rec)
+}
+
+// paramsDigest - identifies calls with the same params without revealing them
+func paramsDigest(params json.RawMessage) string {
+	if len(params) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(params)
+	return hex.EncodeToString(sum[:8])
+}
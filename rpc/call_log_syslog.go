@@ -0,0 +1,13 @@
+//go:build !windows
+// +build !windows
+
+package rpc
+
+import (
+	"io"
+	"log/syslog"
+)
+
+func newSyslogWriter() (io.WriteCloser, error) {
+	return syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "rpcdaemon")
+}
@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ledgerwatch/erigon/common/ctxutil"
+	"github.com/ledgerwatch/log/v3"
+)
+
+type memoryAuditLog struct {
+	lock    sync.Mutex
+	records []*CallRecord
+}
+
+func (l *memoryAuditLog) Record(rec *CallRecord) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.records = append(l.records, rec)
+}
+
+func (l *memoryAuditLog) Close() error { return nil }
+
+func TestAuditLog(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	audit := &memoryAuditLog{}
+	server.SetAuditLog(audit)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client, err := DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := client.Call(nil, "test_sleep", 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(nil, "test_returnError"); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(audit.records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(audit.records))
+	}
+	sleep, failed := audit.records[0], audit.records[1]
+	if sleep.Method != "test_sleep" || sleep.Params == "" || sleep.Remote != "127.0.0.1" || sleep.Duration < 10 || sleep.Error != "" {
+		t.Fatalf("unexpected record %+v", sleep)
+	}
+	if failed.Method != "test_returnError" || failed.Params != "" || failed.Error == "" {
+		t.Fatalf("unexpected record %+v", failed)
+	}
+}
+
+func TestCallLogGRPCTime(t *testing.T) {
+	audit := &memoryAuditLog{}
+	l := &callLog{audit: audit}
+	ctx, grpcTime := ctxutil.WithGRPCTime(context.Background())
+	start := time.Now()
+	ctxutil.AddGRPCTime(ctx, 3*time.Millisecond)
+	ctxutil.AddGRPCTime(ctx, 2*time.Millisecond)
+	msg := &jsonrpcMessage{Method: "eth_call", Params: json.RawMessage(`[{}, "latest"]`)}
+	l.record(ctx, log.Root(), msg, nil, "10.0.0.1:5555", start.Add(-time.Second), grpcTime)
+	if rec := audit.records[0]; rec.GRPC != 5 || rec.Duration < 1000 || rec.Remote != "10.0.0.1" {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+}
+
+func TestFileAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := NewAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit.Record(&CallRecord{Method: "eth_chainId"})
+	audit.Record(&CallRecord{Method: "eth_blockNumber"})
+	if err := audit.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for _, method := range []string{"eth_chainId", "eth_blockNumber"} {
+		var rec CallRecord
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Method != method {
+			t.Fatalf("expected %s, got %s", method, rec.Method)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package rpc
+
+import (
+	"errors"
+	"io"
+)
+
+func newSyslogWriter() (io.WriteCloser, error) {
+	return nil, errors.New("syslog is not supported on windows")
+}
@@ -78,6 +78,14 @@ type Client struct {
 	isHTTP          bool
 	services        *serviceRegistry
 	methodAllowList AllowList
+	rateLimiter     RateLimiter
+	authenticator   Authenticator
+	drainer         *drainer
+	workerPools     *workerPools
+	batchLimits     BatchLimits
+	callLog         *callLog
+	formatter       ResponseFormatter
+	usage           UsageMeter
 
 	idCounter uint32
 
@@ -112,7 +120,21 @@ type clientConn struct {
 
 func (c *Client) newClientConn(conn ServerCodec) *clientConn {
 	ctx := context.WithValue(context.Background(), clientContextKey{}, c)
+	if ka, ok := conn.(connAPIKey); ok && ka.apiKey() != "" {
+		ctx = context.WithValue(ctx, "apiKey", ka.apiKey())
+	}
 	handler := newHandler(ctx, conn, c.idgen, c.services, c.methodAllowList, 50)
+	handler.rateLimiter = c.rateLimiter
+	handler.authenticator = c.authenticator
+	handler.drainer = c.drainer
+	handler.workerPools = c.workerPools
+	handler.batchLimits = c.batchLimits
+	handler.callLog = c.callLog
+	handler.formatter = c.formatter
+	handler.usage = c.usage
+	if sl, ok := conn.(connSubscriptionLimit); ok {
+		handler.maxSubscriptions = sl.subscriptionLimit()
+	}
 	return &clientConn{conn, handler}
 }
 
@@ -153,8 +175,8 @@ func (op *requestOp) wait(ctx context.Context, c *Client) (*jsonrpcMessage, erro
 //
 // The currently supported URL schemes are "http", "https", "ws" and "wss". If rawurl is a
 // file name with no URL scheme, a local socket connection is established using UNIX
-// domain sockets on supported platforms and named pipes on Windows. If you want to
-// configure transport options, use DialHTTP, DialWebsocket or DialIPC instead.
+// domain sockets on supported platforms. If you want to configure transport options,
+// use DialHTTP, DialWebsocket or DialIPC instead.
 //
 // For websocket connections, the origin is set to the local host name.
 //
@@ -179,6 +201,8 @@ func DialContext(ctx context.Context, rawurl string) (*Client, error) {
 		return DialWebsocket(ctx, rawurl, "")
 	case "stdio":
 		return DialStdIO(ctx)
+	case "":
+		return DialIPC(ctx, rawurl)
 	default:
 		return nil, fmt.Errorf("no known transport for URL scheme %q", u.Scheme)
 	}
@@ -196,27 +220,35 @@ func newClient(initctx context.Context, connect reconnectFunc) (*Client, error)
 	if err != nil {
 		return nil, err
 	}
-	c := initClient(conn, randomIDGenerator(), new(serviceRegistry))
+	c := initClient(conn, randomIDGenerator(), new(serviceRegistry), nil, nil, nil, nil, BatchLimits{}, nil, nil, nil)
 	c.reconnectFunc = connect
 	return c, nil
 }
 
-func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry) *Client {
+func initClient(conn ServerCodec, idgen func() ID, services *serviceRegistry, rateLimiter RateLimiter, authenticator Authenticator, drainer *drainer, workerPools *workerPools, batchLimits BatchLimits, callLog *callLog, formatter ResponseFormatter, usage UsageMeter) *Client {
 	_, isHTTP := conn.(*httpConn)
 	c := &Client{
-		idgen:       idgen,
-		isHTTP:      isHTTP,
-		services:    services,
-		writeConn:   conn,
-		close:       make(chan struct{}),
-		closing:     make(chan struct{}),
-		didClose:    make(chan struct{}),
-		reconnected: make(chan ServerCodec),
-		readOp:      make(chan readOp),
-		readErr:     make(chan error),
-		reqInit:     make(chan *requestOp),
-		reqSent:     make(chan error, 1),
-		reqTimeout:  make(chan *requestOp),
+		idgen:         idgen,
+		isHTTP:        isHTTP,
+		services:      services,
+		rateLimiter:   rateLimiter,
+		authenticator: authenticator,
+		drainer:       drainer,
+		workerPools:   workerPools,
+		batchLimits:   batchLimits,
+		callLog:       callLog,
+		formatter:     formatter,
+		usage:         usage,
+		writeConn:     conn,
+		close:         make(chan struct{}),
+		closing:       make(chan struct{}),
+		didClose:      make(chan struct{}),
+		reconnected:   make(chan ServerCodec),
+		readOp:        make(chan readOp),
+		readErr:       make(chan error),
+		reqInit:       make(chan *requestOp),
+		reqSent:       make(chan error, 1),
+		reqTimeout:    make(chan *requestOp),
 	}
 	if !isHTTP {
 		go c.dispatch(conn)
@@ -72,3 +72,21 @@ type invalidParamsError struct{ message string }
 func (e *invalidParamsError) ErrorCode() int { return -32602 }
 
 func (e *invalidParamsError) Error() string { return e.message }
+
+// answers of batch request exceeded BatchLimits.MaxResponseSize
+type responseTooLargeError struct{ limit int }
+
+func (e *responseTooLargeError) ErrorCode() int { return -32003 }
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("batch response exceeds limit of %d bytes", e.limit)
+}
+
+// subscribe call of connection having WebsocketLimits.MaxSubscriptions subscriptions
+type subscriptionLimitError struct{ limit int }
+
+func (e *subscriptionLimitError) ErrorCode() int { return -32005 }
+
+func (e *subscriptionLimitError) Error() string {
+	return fmt.Sprintf("limit of %d subscriptions per connection reached, unsubscribe first", e.limit)
+}
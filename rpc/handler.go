@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"reflect"
 	"strconv"
 	"strings"
@@ -27,9 +28,17 @@ import (
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
+	"github.com/ledgerwatch/erigon/common/ctxutil"
+	"github.com/ledgerwatch/erigon/internal/tracing"
 	"github.com/ledgerwatch/log/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// callTracer - records span per method call, continuing trace of HTTP request
+var callTracer = tracing.Tracer("rpc")
+
 // handler handles JSON-RPC messages. There is one handler per connection. Note that
 // handler is not safe for concurrent use. Message handling never blocks indefinitely
 // because RPCs are processed on background goroutines launched by handler.
@@ -64,10 +73,20 @@ type handler struct {
 	log            log.Logger
 	allowSubscribe bool
 
-	allowList AllowList // a list of explicitly allowed methods, if empty -- everything is allowed
+	allowList     AllowList     // a list of explicitly allowed methods, if empty -- everything is allowed
+	rateLimiter   RateLimiter   // limits calls of methods per client, nil if there are no limits
+	authenticator Authenticator // permissions of clients to call methods, nil if all methods are allowed
+	drainer       *drainer      // calls in progress of the whole server, nil on client side
+	workerPools   *workerPools  // bounded concurrency of namespaces, nil if it isn't bounded
+	batchLimits   BatchLimits
+	callLog       *callLog
+	formatter     ResponseFormatter // nil if responses are sent as methods return them
+	usage         UsageMeter        // nil if usage of clients isn't accounted
 
 	subLock             sync.Mutex
 	serverSubs          map[ID]*Subscription
+	maxSubscriptions    int // 0 - no limit
+	reservedSubs        int // subscribe calls in progress, counted by the limit
 	maxBatchConcurrency uint
 }
 
@@ -110,6 +129,13 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 		return
 	}
 
+	if resp := h.batchLimits.tooLarge(msgs); resp != nil {
+		h.startCallProc(func(cp *callProc) {
+			h.conn.writeJSON(cp.ctx, resp)
+		})
+		return
+	}
+
 	// Handle non-call messages first:
 	calls := make([]*jsonrpcMessage, 0, len(msgs))
 	for _, msg := range msgs {
@@ -122,6 +148,8 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 	}
 	// Process calls on a goroutine because they may block indefinitely:
 	h.startCallProc(func(cp *callProc) {
+		// answers are embedded into one array, so they can't be newline delimited
+		cp.ctx = withNDJSON(cp.ctx, false)
 		// All goroutines will place results right to this array. Because requests order must match reply orders.
 		answersWithNils := make([]interface{}, len(msgs))
 		// Bounded parallelism pattern explanation https://blog.golang.org/pipelines#TOC_9.
@@ -144,7 +172,7 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 				}
 
 				buf := bytes.NewBuffer(nil)
-				stream := jsoniter.NewStream(jsoniter.ConfigDefault, buf, 4096)
+				stream := newCallStream(buf)
 				if res := h.handleCallMsg(cp, calls[i], stream); res != nil {
 					answersWithNils[i] = res
 				}
@@ -155,6 +183,7 @@ func (h *handler) handleBatch(msgs []*jsonrpcMessage) {
 			}(i)
 		}
 		wg.Wait()
+		h.batchLimits.limitResponse(calls, answersWithNils)
 		answers := make([]interface{}, 0, len(msgs))
 		for _, answer := range answersWithNils {
 			if answer != nil {
@@ -177,14 +206,56 @@ func (h *handler) handleMsg(msg *jsonrpcMessage) {
 		return
 	}
 	h.startCallProc(func(cp *callProc) {
-		stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 4096)
+		sw, ok := h.conn.(jsonStreamWriter)
+		if !ok {
+			stream := jsoniter.NewStream(jsoniter.ConfigDefault, nil, 4096)
+			answer := h.handleCallMsg(cp, msg, stream)
+			h.addSubscriptions(cp.notifiers)
+			if answer != nil {
+				h.conn.writeJSON(cp.ctx, answer)
+			} else {
+				_ = stream.Flush()
+				h.conn.writeJSON(cp.ctx, json.RawMessage(stream.Buffer()))
+			}
+			for _, n := range cp.notifiers {
+				n.activate()
+			}
+			return
+		}
+
+		// Streamable methods flush their results to the connection as they go, so huge responses (like struct
+		// logs of debug_traceTransaction) are not kept in memory
+		out := &responseStream{ctx: cp.ctx, conn: sw}
+		stream := newCallStream(out)
 		answer := h.handleCallMsg(cp, msg, stream)
 		h.addSubscriptions(cp.notifiers)
-		if answer != nil {
+		cutOff := false
+		switch {
+		case answer != nil && out.started() && NDJSONFromContext(cp.ctx):
+			// sent lines are whole elements, the error response goes on its own line after them instead of the
+			// element which was not finished
+			stream.SetBuffer(stream.Buffer()[:0])
+			stream.WriteRaw("\n")
+			stream.WriteVal(answer)
+			_ = stream.Flush()
+		case answer != nil && out.started():
+			// part of the result is on the wire already and the response can't have error next to it: the rest
+			// is dropped and the connection is closed, so the client doesn't take the sent part for the whole result
+			stream.SetBuffer(stream.Buffer()[:0])
+			cutOff = true
+		case answer != nil:
 			h.conn.writeJSON(cp.ctx, answer)
-		} else {
+		default:
 			_ = stream.Flush()
-			h.conn.writeJSON(cp.ctx, json.RawMessage(stream.Buffer()))
+		}
+		if err := out.Close(); err != nil {
+			h.callLogger(cp.ctx).Debug("Failed to write streamed response", "method", msg.Method, "reqid", idForLog{msg.ID}, "err", err)
+		}
+		if cutOff {
+			h.callLogger(cp.ctx).Debug("Streamed response cut off by error", "method", msg.Method, "reqid", idForLog{msg.ID}, "err", answer.Error)
+			if c, ok := h.conn.(ServerCodec); ok {
+				c.close()
+			}
 		}
 		for _, n := range cp.notifiers {
 			n.activate()
@@ -192,6 +263,39 @@ func (h *handler) handleMsg(msg *jsonrpcMessage) {
 	})
 }
 
+// responseStream - writer of a single streamed response, which takes the connection on the first non-empty write
+// only. Until then responses can be replaced, for example by errors returned before anything was flushed.
+type responseStream struct {
+	ctx  context.Context
+	conn jsonStreamWriter
+	w    io.WriteCloser
+}
+
+func (s *responseStream) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if s.w == nil {
+		w, err := s.conn.writeStream(s.ctx)
+		if err != nil {
+			return 0, err
+		}
+		s.w = w
+	}
+	return s.w.Write(p)
+}
+
+func (s *responseStream) started() bool {
+	return s.w != nil
+}
+
+func (s *responseStream) Close() error {
+	if s.w == nil {
+		return nil
+	}
+	return s.w.Close()
+}
+
 // close cancels all requests except for inflightReq and waits for
 // call goroutines to shut down.
 func (h *handler) close(err error, inflightReq *requestOp) {
@@ -245,8 +349,21 @@ func (h *handler) addSubscriptions(nn []*Notifier) {
 	for _, n := range nn {
 		if sub := n.takeSubscription(); sub != nil {
 			h.serverSubs[sub.ID] = sub
+			activeSubscriptionsGauge.Inc()
 		}
 	}
+	h.reservedSubs -= len(nn)
+}
+
+// reserveSubscription - counts subscribe call in progress against maxSubscriptions, released by addSubscriptions
+func (h *handler) reserveSubscription() bool {
+	h.subLock.Lock()
+	defer h.subLock.Unlock()
+	if h.maxSubscriptions > 0 && len(h.serverSubs)+h.reservedSubs >= h.maxSubscriptions {
+		return false
+	}
+	h.reservedSubs++
+	return true
 }
 
 // cancelServerSubscriptions removes all subscriptions and closes their error channels.
@@ -258,16 +375,29 @@ func (h *handler) cancelServerSubscriptions(err error) {
 		s.err <- err
 		close(s.err)
 		delete(h.serverSubs, id)
+		activeSubscriptionsGauge.Dec()
+	}
+}
+
+// callLogger - logger of the connection, tagged by request id of ctx
+func (h *handler) callLogger(ctx context.Context) log.Logger {
+	if id := ctxutil.RequestID(ctx); id != "" {
+		return h.log.New("requestId", id)
 	}
+	return h.log
 }
 
 // startCallProc runs fn in a new goroutine and starts tracking it in the h.calls wait group.
+// Messages which came without request id (websocket, IPC) get one of their own, a batch shares one
 func (h *handler) startCallProc(fn func(*callProc)) {
 	h.callWG.Add(1)
 	go func() {
 		ctx, cancel := context.WithCancel(h.rootCtx)
 		defer h.callWG.Done()
 		defer cancel()
+		if ctxutil.RequestID(ctx) == "" {
+			ctx = ctxutil.WithRequestID(ctx, ctxutil.NewRequestID())
+		}
 		fn(&callProc{ctx: ctx})
 	}()
 }
@@ -332,25 +462,36 @@ func (h *handler) handleResponse(msg *jsonrpcMessage) {
 }
 
 // handleCallMsg executes a call message and returns the answer.
+// Log lines of the call are tagged by request id, which startCallProc put into its context
 func (h *handler) handleCallMsg(ctx *callProc, msg *jsonrpcMessage, stream *jsoniter.Stream) *jsonrpcMessage {
 	start := time.Now()
+	logger := h.callLogger(ctx.ctx)
+	if (msg.isCall() || msg.isNotification()) && !msg.isUnsubscribe() {
+		if !h.drainer.start() {
+			if msg.isNotification() {
+				return nil
+			}
+			return msg.errorResponse(&shuttingDownError{})
+		}
+		defer h.drainer.done()
+	}
 	switch {
 	case msg.isNotification():
 		h.handleCall(ctx, msg, stream)
-		h.log.Trace("Served", "t", time.Since(start), "method", msg.Method, "params", string(msg.Params))
+		logger.Trace("Served", "t", time.Since(start), "method", msg.Method, "params", string(msg.Params))
 		return nil
 	case msg.isCall():
 		resp := h.handleCall(ctx, msg, stream)
 		if resp != nil && resp.Error != nil {
 			if resp.Error.Data != nil {
-				h.log.Warn("Served", "method", msg.Method, "reqid", idForLog{msg.ID}, "t", time.Since(start),
+				logger.Warn("Served", "method", msg.Method, "reqid", idForLog{msg.ID}, "t", time.Since(start),
 					"err", resp.Error.Message, "errdata", resp.Error.Data)
 			} else {
-				h.log.Warn("Served", "method", msg.Method, "reqid", idForLog{msg.ID}, "t", time.Since(start),
+				logger.Warn("Served", "method", msg.Method, "reqid", idForLog{msg.ID}, "t", time.Since(start),
 					"err", resp.Error.Message)
 			}
 		}
-		h.log.Trace("Served", "t", time.Since(start), "method", msg.Method, "reqid", idForLog{msg.ID}, "params", string(msg.Params))
+		logger.Trace("Served", "t", time.Since(start), "method", msg.Method, "reqid", idForLog{msg.ID}, "params", string(msg.Params))
 		return resp
 	case msg.hasValidID():
 		return msg.errorResponse(&invalidRequestError{"invalid request"})
@@ -367,6 +508,30 @@ func (h *handler) isMethodAllowedByGranularControl(method string) bool {
 	return ok
 }
 
+// authorize - checks method against permissions of the client API key, everything is allowed without authenticator
+func (h *handler) authorize(ctx context.Context, method string) error {
+	if h.authenticator == nil {
+		return nil
+	}
+	return h.authenticator.Authorize(ctx, apiKey(ctx), method)
+}
+
+// allowedByRateLimiter - calls are served if limiter fails, to not depend on its backend availability
+func (h *handler) allowedByRateLimiter(ctx context.Context, method string) bool {
+	if h.rateLimiter == nil {
+		return true
+	}
+	allowed, err := h.rateLimiter.Allow(ctx, method, h.rateLimitClient(ctx))
+	if err != nil {
+		h.log.Warn("Rate limiter failed", "method", method, "err", err)
+		return true
+	}
+	if !allowed {
+		rateLimitedCounter(method).Inc()
+	}
+	return allowed
+}
+
 // handleCall processes method calls.
 func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter.Stream) *jsonrpcMessage {
 	if msg.isSubscribe() {
@@ -381,21 +546,68 @@ func (h *handler) handleCall(cp *callProc, msg *jsonrpcMessage, stream *jsoniter
 	if callb == nil {
 		return msg.errorResponse(&methodNotFoundError{method: msg.Method})
 	}
+	if callb != h.unsubscribeCb {
+		if err := h.authorize(cp.ctx, msg.Method); err != nil {
+			return msg.errorResponse(err)
+		}
+		if !h.allowedByRateLimiter(cp.ctx, msg.Method) {
+			return msg.errorResponse(&rateLimitedError{method: msg.Method})
+		}
+		if err := h.allowedByQuota(cp.ctx); err != nil {
+			return msg.errorResponse(err)
+		}
+		release, err := h.workerPools.acquire(cp.ctx, msg.Method)
+		if err != nil {
+			return msg.errorResponse(err)
+		}
+		defer release()
+	}
 	args, err := parsePositionalArguments(msg.Params, callb.argTypes)
 	if err != nil {
 		return msg.errorResponse(&invalidParamsError{err.Error()})
 	}
+	ctx := context.WithValue(cp.ctx, callerKey{}, h.rateLimitClient(cp.ctx))
+	if callb != h.unsubscribeCb {
+		ctx = h.callLimits(ctx)
+	}
+	var span trace.Span
+	if callb != h.unsubscribeCb {
+		ctx, span = callTracer.Start(ctx, msg.Method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("rpc.system", "jsonrpc"), attribute.String("rpc.method", msg.Method)))
+	}
+	var grpcTime *ctxutil.GRPCTime
+	if callb != h.unsubscribeCb && h.callLog.enabled() {
+		ctx, grpcTime = ctxutil.WithGRPCTime(ctx)
+	}
+	var kvReads *ctxutil.KVReads
+	if callb != h.unsubscribeCb {
+		ctx, kvReads = h.callLog.withKVReads(ctx)
+		h.callLog.recordedStream(stream)
+	}
 	start := time.Now()
-	answer := h.runMethod(cp.ctx, msg, callb, args, stream)
+	answer := h.runMethod(ctx, msg, callb, args, stream)
+	if span != nil {
+		if answer != nil && answer.Error != nil {
+			span.SetStatus(codes.Error, answer.Error.Message)
+		}
+		span.End()
+	}
 
 	// Collect the statistics for RPC calls if metrics is enabled.
 	// We only care about pure rpc call. Filter out subscription.
 	if callb != h.unsubscribeCb {
+		requests, failures, duration := newRPCMethodMetrics(msg.Method)
 		rpcRequestGauge.Inc()
+		requests.Inc()
 		if answer != nil && answer.Error != nil {
 			failedReqeustGauge.Inc()
+			failures.Inc()
 		}
 		newRPCServingTimerMS(msg.Method, answer == nil || answer.Error == nil).UpdateDuration(start)
+		duration.UpdateDuration(start)
+		h.callLog.record(ctx, h.log, msg, answer, h.conn.remoteAddr(), start, grpcTime)
+		h.recordUsage(cp.ctx, msg, answer, stream)
+		h.callLog.recordRequest(ctx, msg, answer, stream, start, kvReads)
 	}
 	return answer
 }
@@ -405,6 +617,15 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage, stream *jso
 	if !h.allowSubscribe {
 		return msg.errorResponse(ErrNotificationsUnsupported)
 	}
+	if err := h.authorize(cp.ctx, msg.Method); err != nil {
+		return msg.errorResponse(err)
+	}
+	if !h.allowedByRateLimiter(cp.ctx, msg.Method) {
+		return msg.errorResponse(&rateLimitedError{method: msg.Method})
+	}
+	if err := h.allowedByQuota(cp.ctx); err != nil {
+		return msg.errorResponse(err)
+	}
 
 	// Subscription method name is first argument.
 	name, err := parseSubscriptionName(msg.Params)
@@ -425,16 +646,29 @@ func (h *handler) handleSubscribe(cp *callProc, msg *jsonrpcMessage, stream *jso
 	}
 	args = args[1:]
 
+	if !h.reserveSubscription() {
+		return msg.errorResponse(&subscriptionLimitError{limit: h.maxSubscriptions})
+	}
 	// Install notifier in context so the subscription handler can find it.
 	n := &Notifier{h: h, namespace: namespace}
 	cp.notifiers = append(cp.notifiers, n)
 	ctx := context.WithValue(cp.ctx, notifierKey{}, n)
 
-	return h.runMethod(ctx, msg, callb, args, stream)
+	answer := h.runMethod(ctx, msg, callb, args, stream)
+	h.recordUsage(cp.ctx, msg, answer, stream)
+	return answer
 }
 
 // runMethod runs the Go callback for an RPC method.
 func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *callback, args []reflect.Value, stream *jsoniter.Stream) *jsonrpcMessage {
+	if callb.streamable && NDJSONFromContext(ctx) {
+		// result goes without envelope
+		if _, err := callb.call(ctx, msg.Method, args, stream); err != nil {
+			return h.callErrorResponse(msg, err)
+		}
+		stream.Flush()
+		return nil
+	}
 	if callb.streamable {
 		stream.WriteObjectStart()
 		stream.WriteObjectField("jsonrpc")
@@ -442,13 +676,13 @@ func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *cal
 		stream.WriteMore()
 		if msg.ID != nil {
 			stream.WriteObjectField("id")
-			stream.Write(msg.ID)
+			stream.WriteRaw(string(msg.ID))
 			stream.WriteMore()
 		}
 		stream.WriteObjectField("result")
 		_, err := callb.call(ctx, msg.Method, args, stream)
 		if err != nil {
-			return msg.errorResponse(err)
+			return h.callErrorResponse(msg, err)
 			/*
 				stream.WriteMore()
 				stream.WriteObjectField("error")
@@ -483,12 +717,22 @@ func (h *handler) runMethod(ctx context.Context, msg *jsonrpcMessage, callb *cal
 	} else {
 		result, err := callb.call(ctx, msg.Method, args, stream)
 		if err != nil {
-			return msg.errorResponse(err)
+			return h.callErrorResponse(msg, err)
+		}
+		if h.formatter != nil {
+			result = h.formatter.FormatResult(msg.Method, result)
 		}
 		return msg.response(result)
 	}
 }
 
+func (h *handler) callErrorResponse(msg *jsonrpcMessage, err error) *jsonrpcMessage {
+	if h.formatter != nil {
+		err = h.formatter.FormatError(msg.Method, err)
+	}
+	return msg.errorResponse(err)
+}
+
 // unsubscribe is the callback function for all *_unsubscribe calls.
 func (h *handler) unsubscribe(ctx context.Context, id ID) (bool, error) {
 	h.subLock.Lock()
@@ -500,6 +744,7 @@ func (h *handler) unsubscribe(ctx context.Context, id ID) (bool, error) {
 	}
 	close(s.err)
 	delete(h.serverSubs, id)
+	activeSubscriptionsGauge.Dec()
 	return true, nil
 }
 
@@ -29,6 +29,9 @@ import (
 	"net/url"
 	"sync"
 	"time"
+
+	"github.com/ledgerwatch/erigon/common/ctxutil"
+	"github.com/ledgerwatch/erigon/internal/tracing"
 )
 
 const (
@@ -247,11 +250,32 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if origin := r.Header.Get("Origin"); origin != "" {
 		ctx = context.WithValue(ctx, "Origin", origin)
 	}
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		ctx = context.WithValue(ctx, "apiKey", key)
+	}
+	ctx = tracing.ExtractHTTP(ctx, r.Header)
+	id := r.Header.Get(ctxutil.RequestIDKey)
+	if !ctxutil.ValidRequestID(id) {
+		id = ctxutil.NewRequestID()
+	}
+	ctx = ctxutil.WithRequestID(ctx, id)
+	w.Header().Set(ctxutil.RequestIDKey, id)
 
-	w.Header().Set("content-type", contentType)
+	if acceptsNDJSON(r) {
+		ctx = withNDJSON(ctx, true)
+		w.Header().Set("content-type", NDJSONContentType)
+	} else {
+		w.Header().Set("content-type", contentType)
+	}
 	codec := newHTTPServerConn(r, w)
 	defer codec.close()
 	s.serveSingleRequest(ctx, codec)
+	select {
+	case <-codec.closed():
+		// streamed response was cut off by error, it must not end like a complete one
+		panic(http.ErrAbortHandler)
+	default:
+	}
 }
 
 // validateRequest returns a non-zero response code and error message if the
@@ -17,10 +17,17 @@
 package rpc
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/ledgerwatch/erigon/common/ctxutil"
 )
 
 func confirmStatusCode(t *testing.T, got, want int) {
@@ -126,3 +133,199 @@ func TestHTTPRespBodyUnlimited(t *testing.T) {
 		t.Fatalf("response has wrong length %d, want %d", len(r), respLength)
 	}
 }
+
+// This checks that results of streamable methods reach the client before the method returns.
+func TestHTTPStreamedResponse(t *testing.T) {
+	const n = 10000
+
+	service := &streamService{proceed: make(chan struct{})}
+	s := NewServer(50)
+	defer s.Stop()
+	if err := s.RegisterName("test", service); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL, contentType, strings.NewReader(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"test_numbers","params":[%d]}`, n)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	// the method waits until the first half of numbers is received
+	head := make([]byte, 64)
+	if _, err := io.ReadFull(resp.Body, head); err != nil {
+		t.Fatal(err)
+	}
+	close(service.proceed)
+	rest, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg struct {
+		ID     int
+		Result []int
+	}
+	if err := json.Unmarshal(append(head, rest...), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.ID != 1 || len(msg.Result) != n || msg.Result[n-1] != n-1 {
+		t.Fatalf("wrong response: id %d, %d numbers", msg.ID, len(msg.Result))
+	}
+}
+
+// This checks that response of streamable method failing after a part of it was sent is cut off, instead of getting
+// error next to the result.
+func TestHTTPStreamedResponseError(t *testing.T) {
+	s := NewServer(50)
+	defer s.Stop()
+	if err := s.RegisterName("test", &streamService{}); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	for _, n := range []int{1, 100000} {
+		// small response is aborted before headers are sent, a larger one in the middle of the body
+		resp, err := http.Post(ts.URL, contentType, strings.NewReader(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"test_failing","params":[%d]}`, n)))
+		if err != nil && n == 1 {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err == nil {
+			t.Fatalf("expected aborted response, got %q", body)
+		}
+		if !strings.Contains(string(body), `"result":[0,1,`) || strings.Contains(string(body), `"error"`) {
+			t.Fatalf("wrong part of response %q", body[:64])
+		}
+	}
+
+	client, err := DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var result []int
+	if err = client.Call(&result, "test_failing", 1); err == nil {
+		t.Fatalf("expected error, got result %v", result)
+	}
+}
+
+// This checks that clients accepting NDJSON get array results of streamable methods one element per line.
+func TestHTTPNDJSONResponse(t *testing.T) {
+	s := NewServer(50)
+	defer s.Stop()
+	if err := s.RegisterName("test", &streamService{}); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	post := func(body string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		req.Header.Set("content-type", contentType)
+		req.Header.Set("accept", NDJSONContentType)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(data)
+	}
+
+	resp, body := post(`{"jsonrpc":"2.0","id":1,"method":"test_elements","params":[3,false]}`)
+	if ct := resp.Header.Get("content-type"); ct != NDJSONContentType {
+		t.Fatalf("wrong content type %q", ct)
+	}
+	if body != "0\n1\n2\n" {
+		t.Fatalf("wrong response %q", body)
+	}
+
+	// error response is the last line, instead of the element which was not finished
+	_, body = post(`{"jsonrpc":"2.0","id":1,"method":"test_elements","params":[2,true]}`)
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	if len(lines) != 3 || lines[0] != "0" || lines[1] != "1" {
+		t.Fatalf("wrong response %q", body)
+	}
+	var msg jsonrpcMessage
+	if err := json.Unmarshal([]byte(lines[2]), &msg); err != nil || msg.Version != vsn || string(msg.ID) != "1" || msg.Error == nil || msg.Error.Code != (testError{}).ErrorCode() || msg.Result != nil {
+		t.Fatalf("wrong error line %q", lines[2])
+	}
+
+	// answers of batch are embedded into JSON array
+	_, body = post(`[{"jsonrpc":"2.0","id":1,"method":"test_elements","params":[2,false]}]`)
+	var batch []struct{ Result []int }
+	if err := json.Unmarshal([]byte(body), &batch); err != nil || len(batch) != 1 || len(batch[0].Result) != 2 {
+		t.Fatalf("wrong batch response %q", body)
+	}
+}
+
+type requestIDService struct{}
+
+func (requestIDService) RequestID(ctx context.Context) string { return ctxutil.RequestID(ctx) }
+
+// This checks that request id given by client or generated is passed to methods and echoed in response headers.
+func TestHTTPRequestID(t *testing.T) {
+	s := NewServer(50)
+	defer s.Stop()
+	if err := s.RegisterName("test", requestIDService{}); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	post := func(id string) (string, string) {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"test_requestID"}`))
+		req.Header.Set("content-type", contentType)
+		if id != "" {
+			req.Header.Set("X-Request-ID", id)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var msg struct{ Result string }
+		if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Header.Get("X-Request-ID"), msg.Result
+	}
+
+	if header, result := post("complaint-17"); header != "complaint-17" || result != "complaint-17" {
+		t.Fatalf("given id: header %q, method saw %q", header, result)
+	}
+	for _, id := range []string{"", "two words", strings.Repeat("x", 200)} {
+		header, result := post(id)
+		if header == "" || header == id || header != result {
+			t.Fatalf("id %q: header %q, method saw %q", id, header, result)
+		}
+	}
+	first, _ := post("")
+	second, _ := post("")
+	if first == second {
+		t.Fatal("generated ids are not unique")
+	}
+
+	// calls of other transports get ids of their own
+	client := DialInProc(s)
+	defer client.Close()
+	var id1, id2 string
+	if err := client.Call(&id1, "test_requestID"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(&id2, "test_requestID"); err != nil {
+		t.Fatal(err)
+	}
+	if id1 == "" || id1 == id2 {
+		t.Fatalf("wrong ids %q, %q", id1, id2)
+	}
+}
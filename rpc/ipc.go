@@ -17,7 +17,9 @@
 package rpc
 
 import (
+	"context"
 	"net"
+	"os"
 
 	"github.com/ledgerwatch/erigon/p2p/netutil"
 	"github.com/ledgerwatch/log/v3"
@@ -37,3 +39,24 @@ func (s *Server) ServeListener(l net.Listener) error {
 		go s.ServeCodec(NewCodec(conn), 0)
 	}
 }
+
+// ListenIPC creates the unix socket at endpoint with permissions mode, stale socket left by previous run is
+// removed. Not supported on Windows.
+func ListenIPC(endpoint string, mode os.FileMode) (net.Listener, error) {
+	return ipcListen(endpoint, mode)
+}
+
+// DialIPC create a new IPC client that connects to the given endpoint, the full path to
+// a unix socket.
+//
+// The context is used for the initial connection establishment. It does not
+// affect subsequent interactions with the client.
+func DialIPC(ctx context.Context, endpoint string) (*Client, error) {
+	return newClient(ctx, func(ctx context.Context) (ServerCodec, error) {
+		conn, err := newIPCConnection(ctx, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		return NewCodec(conn), err
+	})
+}
@@ -0,0 +1,21 @@
+//go:build !(darwin || dragonfly || freebsd || linux || nacl || netbsd || openbsd || solaris)
+// +build !darwin,!dragonfly,!freebsd,!linux,!nacl,!netbsd,!openbsd,!solaris
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+)
+
+var errIPCNotSupported = errors.New("IPC is not supported on this platform")
+
+func ipcListen(string, os.FileMode) (net.Listener, error) {
+	return nil, errIPCNotSupported
+}
+
+func newIPCConnection(context.Context, string) (net.Conn, error) {
+	return nil, errIPCNotSupported
+}
@@ -0,0 +1,55 @@
+package rpc
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestIPC(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("IPC is not supported on windows")
+	}
+	server := newTestServer()
+	defer server.Stop()
+	endpoint := filepath.Join(t.TempDir(), "sub", "rpc.sock")
+	listener, err := ListenIPC(endpoint, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go server.ServeListener(listener)
+
+	info, err := os.Stat(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Fatalf("socket permissions %o, want 600", perm)
+	}
+
+	client, err := DialContext(context.Background(), endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var result echoResult
+	if err := client.Call(&result, "test_echo", "hello", 10, &echoArgs{"world"}); err != nil {
+		t.Fatal(err)
+	}
+	if result.String != "hello" || result.Int != 10 || result.Args.S != "world" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	// stale socket of previous run doesn't prevent listening
+	listener.Close()
+	if err := os.WriteFile(endpoint, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if listener, err = ListenIPC(endpoint, 0600); err != nil {
+		t.Fatal(err)
+	}
+	listener.Close()
+}
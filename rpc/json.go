@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -37,6 +38,7 @@ const (
 	notificationMethodSuffix = "_subscription"
 
 	defaultWriteTimeout = 10 * time.Minute // used if context has no deadline
+	streamBufferSize    = 4096             // bytes of streamed response kept in memory before writing to connection
 )
 
 var null = json.RawMessage("null")
@@ -111,12 +113,13 @@ func errorMessage(err error) *jsonrpcMessage {
 		Code:    defaultErrorCode,
 		Message: err.Error(),
 	}}
-	ec, ok := err.(Error)
-	if ok {
+	// wrapped errors keep code and data, for example "getReceipts error: %w" of pruned history
+	var ec Error
+	if errors.As(err, &ec) {
 		msg.Error.Code = ec.ErrorCode()
 	}
-	de, ok := err.(DataError)
-	if ok {
+	var de DataError
+	if errors.As(err, &de) {
 		msg.Error.Data = de.ErrorData()
 	}
 	return msg
@@ -170,7 +173,10 @@ type jsonCodec struct {
 	decode  func(v interface{}) error // decoder to allow multiple transports
 	encMu   sync.Mutex                // guards the encoder
 	encode  func(v interface{}) error // encoder to allow multiple transports
+	out     io.Writer                 // raw connection writer, nil if messages can only be written by encode
 	conn    deadlineCloser
+
+	writeTimeout time.Duration // bounds also writes of calls with later deadline, 0 - defaultWriteTimeout if there's no deadline
 }
 
 // NewFuncCodec creates a codec which uses the given functions to read and write. If conn
@@ -195,7 +201,9 @@ func NewCodec(conn Conn) ServerCodec {
 	enc := json.NewEncoder(conn)
 	dec := json.NewDecoder(conn)
 	dec.UseNumber()
-	return NewFuncCodec(conn, enc.Encode, dec.Decode)
+	codec := NewFuncCodec(conn, enc.Encode, dec.Decode).(*jsonCodec)
+	codec.out = conn
+	return codec
 }
 
 func (c *jsonCodec) remoteAddr() string {
@@ -224,12 +232,77 @@ func (c *jsonCodec) writeJSON(ctx context.Context, v interface{}) error {
 	c.encMu.Lock()
 	defer c.encMu.Unlock()
 
+	c.setWriteDeadline(ctx)
+	return c.encode(v)
+}
+
+func (c *jsonCodec) setWriteDeadline(ctx context.Context) {
 	deadline, ok := ctx.Deadline()
-	if !ok {
+	if c.writeTimeout > 0 {
+		if limit := time.Now().Add(c.writeTimeout); !ok || limit.Before(deadline) {
+			deadline = limit
+		}
+	} else if !ok {
 		deadline = time.Now().Add(defaultWriteTimeout)
 	}
 	c.conn.SetWriteDeadline(deadline)
-	return c.encode(v)
+}
+
+// writeStream returns writer of a single JSON message, which goes to the connection through a bounded buffer.
+// The connection is locked for other writes until the writer is closed. Codecs created by NewFuncCodec can
+// only encode whole messages, so they get the message buffered in memory and encoded on close.
+func (c *jsonCodec) writeStream(ctx context.Context) (io.WriteCloser, error) {
+	c.encMu.Lock()
+	if c.out == nil {
+		return &bufferedStreamWriter{c: c, ctx: ctx}, nil
+	}
+	w := &connStreamWriter{c: c, ctx: ctx}
+	w.buf = bufio.NewWriterSize(deadlineWriter{w}, streamBufferSize)
+	return w, nil
+}
+
+// connStreamWriter - writes message straight to the connection, deadline is renewed on every write to let
+// long responses finish
+type connStreamWriter struct {
+	c   *jsonCodec
+	ctx context.Context
+	buf *bufio.Writer
+}
+
+type deadlineWriter struct{ w *connStreamWriter }
+
+func (d deadlineWriter) Write(p []byte) (int, error) {
+	d.w.c.setWriteDeadline(d.w.ctx)
+	return d.w.c.out.Write(p)
+}
+
+func (w *connStreamWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Close terminates the message with newline, same as json.Encoder does, and unlocks the connection
+func (w *connStreamWriter) Close() error {
+	defer w.c.encMu.Unlock()
+	if err := w.buf.WriteByte('\n'); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+type bufferedStreamWriter struct {
+	c   *jsonCodec
+	ctx context.Context
+	buf bytes.Buffer
+}
+
+func (w *bufferedStreamWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *bufferedStreamWriter) Close() error {
+	defer w.c.encMu.Unlock()
+	w.c.setWriteDeadline(w.ctx)
+	return w.c.encode(json.RawMessage(w.buf.Bytes()))
 }
 
 func (c *jsonCodec) close() {
@@ -25,6 +25,9 @@ import (
 var (
 	rpcRequestGauge    = metrics.GetOrCreateCounter("rpc_total")
 	failedReqeustGauge = metrics.GetOrCreateCounter("rpc_failure")
+
+	activeSubscriptionsGauge = metrics.GetOrCreateCounter("rpc_subscriptions_active")
+	activeWebsocketsGauge    = metrics.GetOrCreateCounter("rpc_websocket_connections_active")
 )
 
 func newRPCServingTimerMS(method string, valid bool) *metrics.Summary {
@@ -35,3 +38,11 @@ func newRPCServingTimerMS(method string, valid bool) *metrics.Summary {
 	m := fmt.Sprintf(`rpc_duration_seconds{method="%s",success="%s"}`, method, flag)
 	return metrics.GetOrCreateSummary(m)
 }
+
+// newRPCMethodMetrics - number of calls, number of failed calls and latency histogram of method
+func newRPCMethodMetrics(method string) (requests, failures *metrics.Counter, duration *metrics.Histogram) {
+	requests = metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_requests_total{method="%s"}`, method))
+	failures = metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_errors_total{method="%s"}`, method))
+	duration = metrics.GetOrCreateHistogram(fmt.Sprintf(`rpc_request_duration_seconds{method="%s"}`, method))
+	return requests, failures, duration
+}
@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMethodMetrics(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	requests, failures, _ := newRPCMethodMetrics("test_returnError")
+	requestsBefore, failuresBefore := requests.Get(), failures.Get()
+	if err := client.Call(nil, "test_returnError"); err == nil {
+		t.Fatal("expected error")
+	}
+	if requests.Get() != requestsBefore+1 || failures.Get() != failuresBefore+1 {
+		t.Fatalf("wrong counters: requests %d -> %d, failures %d -> %d", requestsBefore, requests.Get(), failuresBefore, failures.Get())
+	}
+}
+
+func TestActiveSubscriptionsMetric(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	waitFor := func(want uint64) {
+		for deadline := time.Now().Add(5 * time.Second); activeSubscriptionsGauge.Get() != want; {
+			if time.Now().After(deadline) {
+				t.Fatalf("active subscriptions %d, want %d", activeSubscriptionsGauge.Get(), want)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	before := activeSubscriptionsGauge.Get()
+	sub, err := client.Subscribe(context.Background(), "nftest", make(chan int, 1), "someSubscription", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(before + 1)
+	sub.Unsubscribe()
+	waitFor(before)
+}
@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// NDJSONContentType - HTTP clients sending it in Accept header get array results of streamable methods as
+// newline delimited JSON: one element per line, without JSON-RPC envelope. Error, if any, is the last line: JSON-RPC
+// error response, it replaces the element which was written when the method failed.
+const NDJSONContentType = "application/x-ndjson"
+
+type ndjsonKey struct{}
+
+// NDJSONFromContext - true if the result of the call is written as newline delimited JSON
+func NDJSONFromContext(ctx context.Context) bool {
+	ndjson, _ := ctx.Value(ndjsonKey{}).(bool)
+	return ndjson
+}
+
+func withNDJSON(ctx context.Context, ndjson bool) context.Context {
+	return context.WithValue(ctx, ndjsonKey{}, ndjson)
+}
+
+func acceptsNDJSON(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mt, _, err := mime.ParseMediaType(strings.TrimSpace(accepted)); err == nil && mt == NDJSONContentType {
+			return true
+		}
+	}
+	return false
+}
+
+// ArrayStream - writes array result of streamable method element by element: as JSON array or, if the client asked
+// for it, as newline delimited JSON
+type ArrayStream struct {
+	stream *jsoniter.Stream
+	ndjson bool
+	n      int
+}
+
+// NewArrayStream - array writer of streamable method called with ctx
+func NewArrayStream(ctx context.Context, stream *jsoniter.Stream) *ArrayStream {
+	return &ArrayStream{stream: stream, ndjson: NDJSONFromContext(ctx)}
+}
+
+// Start - must be called before the first element
+func (a *ArrayStream) Start() {
+	if !a.ndjson {
+		a.stream.WriteArrayStart()
+	}
+}
+
+// Next - must be called before writing every element
+func (a *ArrayStream) Next() {
+	if a.n > 0 {
+		if a.ndjson {
+			a.stream.WriteRaw("\n")
+		} else {
+			a.stream.WriteMore()
+		}
+	}
+	a.n++
+}
+
+// End - must be called after the last element, also when the method fails after Start
+func (a *ArrayStream) End() {
+	if !a.ndjson {
+		a.stream.WriteArrayEnd()
+	}
+}
@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	"golang.org/x/time/rate"
+)
+
+// RateLimit - token bucket parameters: Rate calls per second on average with bursts of up to Burst calls
+type RateLimit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// idle - time in which empty bucket becomes full again
+func (l RateLimit) idle() time.Duration {
+	return time.Duration(math.Ceil(float64(l.Burst) / l.Rate * float64(time.Second)))
+}
+
+// RateLimits - limits of calls per client, Methods override Default for particular methods. Methods without limit
+// are not limited.
+type RateLimits struct {
+	Default *RateLimit           `json:"default"`
+	Methods map[string]RateLimit `json:"methods"`
+}
+
+// Validate - checks that every limit lets at least some calls through
+func (l RateLimits) Validate() error {
+	if l.Default != nil && (l.Default.Rate <= 0 || l.Default.Burst < 1) {
+		return fmt.Errorf("default rate limit needs positive rate and burst")
+	}
+	for method, limit := range l.Methods {
+		if limit.Rate <= 0 || limit.Burst < 1 {
+			return fmt.Errorf("rate limit of %s needs positive rate and burst", method)
+		}
+	}
+	return nil
+}
+
+func (l RateLimits) limit(method string) (RateLimit, bool) {
+	if limit, ok := l.Methods[method]; ok {
+		return limit, true
+	}
+	if l.Default != nil {
+		return *l.Default, true
+	}
+	return RateLimit{}, false
+}
+
+// RateLimiter decides if client can call method now
+type RateLimiter interface {
+	Allow(ctx context.Context, method string, client string) (bool, error)
+}
+
+// memoryRateLimiter - token buckets kept in memory, every rpcdaemon instance limits clients separately
+type memoryRateLimiter struct {
+	limits RateLimits
+
+	lock      sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+	idle     time.Duration
+}
+
+// NewRateLimiter - rate limiter keeping token buckets in memory
+func NewRateLimiter(limits RateLimits) RateLimiter {
+	return &memoryRateLimiter{limits: limits, buckets: map[string]*bucket{}, lastSweep: time.Now()}
+}
+
+func (l *memoryRateLimiter) Allow(_ context.Context, method string, client string) (bool, error) {
+	limit, ok := l.limits.limit(method)
+	if !ok {
+		return true, nil
+	}
+	now := time.Now()
+	key := method + "/" + client
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst), idle: limit.idle()}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	return b.limiter.AllowN(now, 1), nil
+}
+
+// sweep - forgets buckets which got full again, once a minute
+func (l *memoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > b.idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimitedError - returned for calls rejected by RateLimiter, same code as "limit exceeded" of EIP-1474
+type rateLimitedError struct{ method string }
+
+func (e *rateLimitedError) ErrorCode() int { return -32005 }
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("rate limit of method %s exceeded, retry later", e.method)
+}
+
+func rateLimitedCounter(method string) *metrics.Counter {
+	return metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_rate_limited{method="%s"}`, method))
+}
+
+// rateLimitClient - API key of the client if authenticator accepted it (called after authorize), IP address otherwise:
+// without authenticator keys are not verified, client sending new key with each call would get new bucket each time
+func (h *handler) rateLimitClient(ctx context.Context) string {
+	if key := apiKey(ctx); key != "" && h.authenticator != nil {
+		return "key:" + key
+	}
+	remoteAddr := h.conn.remoteAddr()
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+type callerKey struct{}
+
+// CallerFromContext - client of the call identified the same way as for rate limits, empty outside of calls
+func CallerFromContext(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return caller
+}
@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+const redisKeyPrefix = "rpc_rate_limit:"
+
+// tokenBucketScript - refills bucket stored in hash for the time passed since the previous call and takes a token
+// from it. Runs atomically, so concurrent calls from different instances can't take the same token.
+const tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate / 1000)
+else
+	now = ts
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return allowed
+`
+
+// redisRateLimiter - token buckets kept in Redis, so limits are shared by all rpcdaemon instances using it
+type redisRateLimiter struct {
+	limits RateLimits
+	redis  *RedisClient
+}
+
+// NewRedisRateLimiter - rate limiter keeping token buckets in Redis at addr
+func NewRedisRateLimiter(addr string, limits RateLimits) RateLimiter {
+	return &redisRateLimiter{limits: limits, redis: NewRedisClient(addr)}
+}
+
+func (l *redisRateLimiter) Allow(ctx context.Context, method string, client string) (bool, error) {
+	limit, ok := l.limits.limit(method)
+	if !ok {
+		return true, nil
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	ttl := limit.idle()/time.Millisecond + 1000
+	reply, err := l.redis.DoInt(ctx, "EVAL", tokenBucketScript, "1", redisKeyPrefix+method+"/"+client,
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64), strconv.Itoa(limit.Burst), strconv.FormatInt(now, 10), strconv.FormatInt(int64(ttl), 10))
+	if err != nil {
+		return false, err
+	}
+	return reply == 1, nil
+}
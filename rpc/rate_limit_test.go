@@ -0,0 +1,201 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMemoryRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(RateLimits{
+		Default: &RateLimit{Rate: 1000, Burst: 1000},
+		Methods: map[string]RateLimit{"test_echo": {Rate: 0.001, Burst: 2}},
+	})
+	ctx := context.Background()
+	for i, want := range []bool{true, true, false} {
+		if allowed, _ := limiter.Allow(ctx, "test_echo", "1.2.3.4"); allowed != want {
+			t.Fatalf("call %d: allowed %t, want %t", i, allowed, want)
+		}
+	}
+	// other clients and methods have own buckets
+	if allowed, _ := limiter.Allow(ctx, "test_echo", "4.3.2.1"); !allowed {
+		t.Fatal("call of another client is not allowed")
+	}
+	if allowed, _ := limiter.Allow(ctx, "test_rets", "1.2.3.4"); !allowed {
+		t.Fatal("call of another method is not allowed")
+	}
+	if allowed, _ := NewRateLimiter(RateLimits{}).Allow(ctx, "test_echo", "1.2.3.4"); !allowed {
+		t.Fatal("call without limits is not allowed")
+	}
+
+	if err := (RateLimits{Methods: map[string]RateLimit{"test_echo": {Rate: 1}}}).Validate(); err == nil {
+		t.Fatal("limit without burst is valid")
+	}
+}
+
+func TestHTTPRateLimit(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetRateLimiter(NewRateLimiter(RateLimits{Methods: map[string]RateLimit{"test_echo": {Rate: 0.001, Burst: 1}}}))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	call := func(key string) error {
+		client, err := DialHTTP(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		if key != "" {
+			client.SetHeader(APIKeyHeader, key)
+		}
+		var result echoResult
+		return client.Call(&result, "test_echo", "hello", 10, &echoArgs{"world"})
+	}
+	if err := call(""); err != nil {
+		t.Fatal(err)
+	}
+	err := call("")
+	var rpcErr Error
+	if !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32005 {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	// unverified API keys don't get own buckets
+	for _, key := range []string{"fake1", "fake2"} {
+		if err := call(key); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32005 {
+			t.Fatalf("expected rate limit error for key %s, got %v", key, err)
+		}
+	}
+	// methods without limit are not affected
+	client, _ := DialHTTP(ts.URL)
+	defer client.Close()
+	var result string
+	if err := client.Call(&result, "test_rets"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeRedis - replies to EVAL with the number of tokens left in the only bucket
+func fakeRedis(t *testing.T, tokens int) string {
+	return serveFakeRedis(t, func(args []string) string {
+		if args[0] != "EVAL" {
+			return "-ERR unknown command\r\n"
+		}
+		if tokens > 0 {
+			tokens--
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	})
+}
+
+// serveFakeRedis - reads commands of Redis protocol and writes raw replies returned by `reply`
+func serveFakeRedis(t *testing.T, reply func(args []string) string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					header, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					n, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+					var args []string
+					for i := 0; i < n; i++ {
+						size, err := r.ReadString('\n')
+						if err != nil {
+							return
+						}
+						l, _ := strconv.Atoi(strings.TrimSpace(size[1:]))
+						arg := make([]byte, l+2)
+						if _, err = io.ReadFull(r, arg); err != nil {
+							return
+						}
+						args = append(args, string(arg[:l]))
+					}
+					if _, err = conn.Write([]byte(reply(args))); err != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestRedisRateLimiter(t *testing.T) {
+	limiter := NewRedisRateLimiter(fakeRedis(t, 2), RateLimits{Default: &RateLimit{Rate: 1, Burst: 2}})
+	ctx := context.Background()
+	for i, want := range []bool{true, true, false} {
+		allowed, err := limiter.Allow(ctx, "test_echo", "1.2.3.4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allowed != want {
+			t.Fatalf("call %d: allowed %t, want %t", i, allowed, want)
+		}
+	}
+
+	if _, err := NewRedisRateLimiter("127.0.0.1:1", RateLimits{Default: &RateLimit{Rate: 1, Burst: 2}}).Allow(ctx, "test_echo", "1.2.3.4"); err == nil {
+		t.Fatal("expected error of unavailable redis")
+	}
+}
+
+func TestHTTPRateLimitByAPIKey(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetRateLimiter(NewRateLimiter(RateLimits{Methods: map[string]RateLimit{"test_echo": {Rate: 0.001, Burst: 1}}}))
+	server.SetAuthenticator(NewAPIKeyAuthenticator(APIKeys{
+		Public: Permissions{Namespaces: []string{"test"}},
+		Keys:   map[string]Permissions{"secret": {}},
+	}))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	call := func(key string) error {
+		client, err := DialHTTP(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		if key != "" {
+			client.SetHeader(APIKeyHeader, key)
+		}
+		var result echoResult
+		return client.Call(&result, "test_echo", "hello", 10, &echoArgs{"world"})
+	}
+	if err := call(""); err != nil {
+		t.Fatal(err)
+	}
+	var rpcErr Error
+	if err := call(""); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32005 {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	// accepted key is limited separately from its IP
+	if err := call("secret"); err != nil {
+		t.Fatal(err)
+	}
+	// rotating fake keys are rejected, not given fresh buckets
+	for i := 0; i < 3; i++ {
+		if err := call("fake" + strconv.Itoa(i)); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32001 {
+			t.Fatalf("expected invalid API key error, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	redisTimeout  = time.Second // used if context has no deadline
+	redisPoolSize = 16
+)
+
+// RedisClient - minimal client of Redis, shared by rpcdaemon components which keep their state in Redis to share it
+// between several instances
+type RedisClient struct {
+	addr string
+	pool chan *redisConn
+}
+
+// NewRedisClient - client of Redis at addr, connections are opened on demand
+func NewRedisClient(addr string) *RedisClient {
+	return &RedisClient{addr: addr, pool: make(chan *redisConn, redisPoolSize)}
+}
+
+// Do - executes command, reply is int64, string, nil or []interface{} of them. Connections are reused unless they failed
+func (c *RedisClient) Do(ctx context.Context, args ...string) (interface{}, error) {
+	var conn *redisConn
+	select {
+	case conn = <-c.pool:
+	default:
+		nc, err := net.DialTimeout("tcp", c.addr, redisTimeout)
+		if err != nil {
+			return nil, err
+		}
+		conn = &redisConn{conn: nc, r: bufio.NewReader(nc)}
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisTimeout)
+	}
+	if err := conn.conn.SetDeadline(deadline); err != nil {
+		conn.conn.Close()
+		return nil, err
+	}
+	reply, err := conn.do(args...)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		conn.conn.Close()
+		return nil, err
+	}
+	select {
+	case c.pool <- conn:
+	default:
+		conn.conn.Close()
+	}
+	return reply, err
+}
+
+// DoInt - Do of command which replies with integer
+func (c *RedisClient) DoInt(ctx context.Context, args ...string) (int64, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected reply %v, expected integer", reply)
+	}
+	return n, nil
+}
+
+// redisConn - minimal client of Redis protocol (RESP)
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// redisError - error reply of Redis, connection stays usable after it
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	var cmd strings.Builder
+	fmt.Fprintf(&cmd, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := c.conn.Write([]byte(cmd.String())); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if len(line) == 0 {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+	switch line[0] {
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err // nil bulk string
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err // nil array
+		}
+		items := make([]interface{}, n)
+		var itemErr error
+		for i := range items {
+			// error items don't break the connection, the first of them is returned after reading the whole array
+			item, err := c.readReply()
+			var redisErr redisError
+			if err != nil && !errors.As(err, &redisErr) {
+				return nil, err
+			}
+			if err != nil && itemErr == nil {
+				itemErr = err
+			}
+			items[i] = item
+		}
+		return items, itemErr
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
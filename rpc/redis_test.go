@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRedisClient(t *testing.T) {
+	client := NewRedisClient(serveFakeRedis(t, func(args []string) string {
+		switch args[0] {
+		case "GET":
+			if args[1] == "missing" {
+				return "$-1\r\n"
+			}
+			return "$5\r\nva\r\nl\r\n"
+		case "SET":
+			return "+OK\r\n"
+		case "MGET":
+			return "*3\r\n:1\r\n-ERR wrong type\r\n$1\r\nx\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	}))
+	ctx := context.Background()
+	for _, c := range []struct {
+		args []string
+		want interface{}
+	}{
+		{[]string{"GET", "key"}, "va\r\nl"},
+		{[]string{"GET", "missing"}, nil},
+		{[]string{"SET", "key", "val"}, "OK"},
+	} {
+		reply, err := client.Do(ctx, c.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(reply, c.want) {
+			t.Fatalf("%v: reply %#v, want %#v", c.args, reply, c.want)
+		}
+	}
+	reply, err := client.Do(ctx, "MGET", "a", "b", "c")
+	if err == nil || !reflect.DeepEqual(reply, []interface{}{int64(1), nil, "x"}) {
+		t.Fatalf("array reply %#v, error %v", reply, err)
+	}
+	// connection stays usable after error replies
+	if _, err := client.DoInt(ctx, "UNKNOWN"); err == nil {
+		t.Fatal("expected error reply")
+	}
+	if reply, err = client.Do(ctx, "GET", "key"); err != nil || reply != "va\r\nl" {
+		t.Fatalf("reply %#v, error %v", reply, err)
+	}
+}
@@ -0,0 +1,131 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/ledgerwatch/erigon/common/ctxutil"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// RequestRecord - served call as written by RequestRecorder, for replay of it by `rpctest replayRecording`. Response
+// is the message the client received, KVReads are reads of db made by the call if they are recorded
+type RequestRecord struct {
+	Time           time.Time        `json:"time"`
+	Request        json.RawMessage  `json:"request"`
+	Response       json.RawMessage  `json:"response"`
+	Duration       float64          `json:"durationMs"`
+	KVReads        []ctxutil.KVRead `json:"kvReads,omitempty"`
+	KVReadsDropped int              `json:"kvReadsDropped,omitempty"` // reads over the limit of one call
+}
+
+// RequestRecorder - receives request and response of every call served by Server, except subscriptions
+type RequestRecorder interface {
+	Record(rec *RequestRecord)
+	// KVReads - whether reads of db made by calls are collected (into ctxutil.KVReads of context of the call) and
+	// recorded with them
+	KVReads() bool
+	Close() error
+}
+
+// NewRequestRecorder - recorder writing records as JSON lines to file at path (appending to it)
+func NewRequestRecorder(path string, kvReads bool) (RequestRecorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open request recording: %w", err)
+	}
+	return &jsonRequestRecorder{w: f, kvReads: kvReads}, nil
+}
+
+type jsonRequestRecorder struct {
+	lock    sync.Mutex
+	w       io.WriteCloser
+	kvReads bool
+}
+
+func (r *jsonRequestRecorder) Record(rec *RequestRecord) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, err = r.w.Write(append(line, '\n')); err != nil {
+		log.Warn("Could not write RPC request recording", "err", err)
+	}
+}
+
+func (r *jsonRequestRecorder) KVReads() bool { return r.kvReads }
+
+func (r *jsonRequestRecorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.w.Close()
+}
+
+// SetRequestRecorder sets the recorder of requests and responses of calls served by this server
+func (s *Server) SetRequestRecorder(recorder RequestRecorder) {
+	s.callLog.recorder = recorder
+}
+
+// recording - whether responses written to streams of calls are kept for the recorder
+func (l *callLog) recording() bool {
+	return l != nil && l.recorder != nil
+}
+
+// withKVReads - attaches ctxutil.KVReads to context of call if the recorder records them
+func (l *callLog) withKVReads(ctx context.Context) (context.Context, *ctxutil.KVReads) {
+	if !l.recording() || !l.recorder.KVReads() {
+		return ctx, nil
+	}
+	return ctxutil.WithKVReads(ctx)
+}
+
+// recordRequest - called after answering msg. Response of streamable method is taken from the stream, calls answered
+// as NDJSON are not recorded: their response is not a JSON-RPC message
+func (l *callLog) recordRequest(ctx context.Context, msg *jsonrpcMessage, answer *jsonrpcMessage, stream *jsoniter.Stream, start time.Time, kvReads *ctxutil.KVReads) {
+	if !l.recording() || NDJSONFromContext(ctx) {
+		return
+	}
+	took := time.Since(start)
+	request, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	var response []byte
+	if answer != nil {
+		if response, err = json.Marshal(answer); err != nil {
+			return
+		}
+	} else {
+		if w, ok := stream.Attachment.(*countingWriter); ok && w.tee != nil {
+			response = append(response, w.tee.Bytes()...)
+		}
+		response = append(response, stream.Buffer()...)
+	}
+	rec := &RequestRecord{
+		Time:     start,
+		Request:  request,
+		Response: response,
+		Duration: float64(took) / float64(time.Millisecond),
+	}
+	rec.KVReads, rec.KVReadsDropped = kvReads.Get()
+	l.recorder.Record(rec)
+}
+
+// recordedStream - keeps copy of what is written to stream of call for the recorder, if there is one
+func (l *callLog) recordedStream(stream *jsoniter.Stream) {
+	if !l.recording() {
+		return
+	}
+	if w, ok := stream.Attachment.(*countingWriter); ok {
+		w.tee = &bytes.Buffer{}
+	}
+}
@@ -0,0 +1,103 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ledgerwatch/erigon/common/ctxutil"
+)
+
+type kvReadsService struct{}
+
+func (kvReadsService) Read(ctx context.Context) string {
+	ctxutil.KVReadsFromContext(ctx).Add("Header", "GetOne", []byte{1}, nil, []byte{2}, nil)
+	return "read"
+}
+
+func TestRequestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.jsonl")
+	recorder, err := NewRequestRecorder(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	defer server.Stop()
+	proceed := make(chan struct{})
+	close(proceed)
+	if err = server.RegisterName("stream", &streamService{proceed: proceed}); err != nil {
+		t.Fatal(err)
+	}
+	if err = server.RegisterName("kv", kvReadsService{}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetRequestRecorder(recorder)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client, err := DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var read string
+	if err = client.Call(&read, "kv_read"); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.Call(nil, "test_returnError"); err == nil {
+		t.Fatal("expected error")
+	}
+	var numbers []int
+	if err = client.Call(&numbers, "stream_numbers", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err = recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var recs []RequestRecord
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		var rec RequestRecord
+		if err = json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(recs))
+	}
+
+	var requests, responses [3]jsonrpcMessage
+	for i, rec := range recs {
+		if err = json.Unmarshal(rec.Request, &requests[i]); err != nil {
+			t.Fatal(err)
+		}
+		if err = json.Unmarshal(rec.Response, &responses[i]); err != nil {
+			t.Fatalf("response %d %s: %v", i, rec.Response, err)
+		}
+		if string(requests[i].ID) != string(responses[i].ID) {
+			t.Errorf("response %d is not to its request: %s %s", i, rec.Request, rec.Response)
+		}
+	}
+	if requests[0].Method != "kv_read" || string(responses[0].Result) != `"read"` {
+		t.Errorf("wrong record of call: %s %s", recs[0].Request, recs[0].Response)
+	}
+	if len(recs[0].KVReads) != 1 || recs[0].KVReads[0].Table != "Header" || recs[0].KVReads[0].Value.String() != "0x02" {
+		t.Errorf("wrong kv reads: %+v", recs[0].KVReads)
+	}
+	if requests[1].Method != "test_returnError" || responses[1].Error == nil {
+		t.Errorf("wrong record of failed call: %s %s", recs[1].Request, recs[1].Response)
+	}
+	if requests[2].Method != "stream_numbers" || string(responses[2].Result) != "[0,1,2]" {
+		t.Errorf("wrong record of streamed call: %s %s", recs[2].Request, recs[2].Response)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"io"
 	"sync/atomic"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/ledgerwatch/log/v3"
@@ -44,6 +45,17 @@ const (
 type Server struct {
 	services        serviceRegistry
 	methodAllowList AllowList
+	rateLimiter     RateLimiter
+	authenticator   Authenticator
+	drainer         *drainer
+	workerPools     *workerPools
+	batchLimits     BatchLimits
+	wsLimits        WebsocketLimits
+	wsCompression   *WebsocketCompression
+	wsConnections   int32 // open websocket connections
+	callLog         callLog
+	formatter       ResponseFormatter
+	usage           UsageMeter
 	idgen           func() ID
 	run             int32
 	codecs          mapset.Set
@@ -53,7 +65,7 @@ type Server struct {
 
 // NewServer creates a new server instance with no registered handlers.
 func NewServer(batchConcurrency uint) *Server {
-	server := &Server{idgen: randomIDGenerator(), codecs: mapset.NewSet(), run: 1, batchConcurrency: batchConcurrency}
+	server := &Server{idgen: randomIDGenerator(), codecs: mapset.NewSet(), run: 1, batchConcurrency: batchConcurrency, drainer: newDrainer()}
 	// Register the default service providing meta information about the RPC service such
 	// as the services and methods it offers.
 	rpcService := &RPCService{server: server}
@@ -66,6 +78,58 @@ func (s *Server) SetAllowList(allowList AllowList) {
 	s.methodAllowList = allowList
 }
 
+// SetRateLimiter sets the limiter of method calls made by clients of this server
+func (s *Server) SetRateLimiter(rateLimiter RateLimiter) {
+	s.rateLimiter = rateLimiter
+}
+
+// SetNamespaceConcurrency bounds numbers of concurrently executed calls of namespaces, calls over the bound wait
+// for running ones. Namespaces without bound aren't limited
+func (s *Server) SetNamespaceConcurrency(concurrency map[string]int) {
+	s.workerPools = newWorkerPools(concurrency)
+}
+
+// SetBatchLimits sets limits of size of batch requests and of their responses
+func (s *Server) SetBatchLimits(limits BatchLimits) {
+	s.batchLimits = limits
+}
+
+// SetWebsocketLimits sets limits of websocket connections, has effect on connections opened after it
+func (s *Server) SetWebsocketLimits(limits WebsocketLimits) {
+	s.wsLimits = limits
+}
+
+// SetWebsocketCompression sets level of permessage-deflate and size of messages from which they are compressed, has
+// effect on connections of handlers created with compression enabled and opened after it
+func (s *Server) SetWebsocketCompression(compression WebsocketCompression) {
+	s.wsCompression = &compression
+}
+
+// SetSlowCallThreshold sets duration of calls from which they are logged as slow, 0 disables logging them
+func (s *Server) SetSlowCallThreshold(threshold time.Duration) {
+	s.callLog.slowThreshold = threshold
+}
+
+// SetAuditLog sets the log recording every call served by this server
+func (s *Server) SetAuditLog(audit AuditLog) {
+	s.callLog.audit = audit
+}
+
+// SetAuthenticator sets the authenticator of API keys deciding which methods clients of this server can call
+func (s *Server) SetAuthenticator(authenticator Authenticator) {
+	s.authenticator = authenticator
+}
+
+// SetResponseFormatter sets the formatter of results and errors of method calls
+func (s *Server) SetResponseFormatter(formatter ResponseFormatter) {
+	s.formatter = formatter
+}
+
+// SetUsageMeter sets the meter accounting calls of each client of this server and enforcing their quotas
+func (s *Server) SetUsageMeter(usage UsageMeter) {
+	s.usage = usage
+}
+
 // RegisterName creates a service for the given receiver type under the given name. When no
 // methods on the given receiver match the criteria to be either a RPC method or a
 // subscription an error is returned. Otherwise a new service is created and added to the
@@ -91,7 +155,7 @@ func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	s.codecs.Add(codec)
 	defer s.codecs.Remove(codec)
 
-	c := initClient(codec, s.idgen, &s.services)
+	c := initClient(codec, s.idgen, &s.services, s.rateLimiter, s.authenticator, s.drainer, s.workerPools, s.batchLimits, &s.callLog, s.formatter, s.usage)
 	<-codec.closed()
 	c.Close()
 }
@@ -107,6 +171,14 @@ func (s *Server) serveSingleRequest(ctx context.Context, codec ServerCodec) {
 
 	h := newHandler(ctx, codec, s.idgen, &s.services, s.methodAllowList, s.batchConcurrency)
 	h.allowSubscribe = false
+	h.rateLimiter = s.rateLimiter
+	h.authenticator = s.authenticator
+	h.drainer = s.drainer
+	h.workerPools = s.workerPools
+	h.batchLimits = s.batchLimits
+	h.callLog = &s.callLog
+	h.formatter = s.formatter
+	h.usage = s.usage
 	defer h.close(io.EOF, nil)
 
 	reqs, batch, err := codec.readBatch()
@@ -131,7 +203,7 @@ func (s *Server) Stop() {
 		log.Info("RPC server shutting down")
 		s.codecs.Each(func(c interface{}) bool {
 			c.(ServerCodec).close()
-			return true
+			return false // continue iteration
 		})
 	}
 }
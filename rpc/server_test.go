@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"io/ioutil"
 	"net"
@@ -166,3 +167,33 @@ func TestServerShortLivedConn(t *testing.T) {
 		}
 	}
 }
+
+type methodPrefixFormatter struct{}
+
+func (methodPrefixFormatter) FormatResult(method string, result interface{}) interface{} {
+	if s, ok := result.(string); ok {
+		return method + ":" + s
+	}
+	return result
+}
+
+func (methodPrefixFormatter) FormatError(method string, err error) error {
+	return errors.New(method + ":" + err.Error())
+}
+
+func TestServerResponseFormatter(t *testing.T) {
+	server := newTestServer()
+	server.SetResponseFormatter(methodPrefixFormatter{})
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	var result string
+	if err := client.Call(&result, "test_rets"); err != nil || result != "test_rets:" {
+		t.Fatalf("formatted result: %q %v", result, err)
+	}
+	err := client.Call(nil, "test_returnError")
+	if err == nil || err.Error() != "test_returnError:testError" || err.(Error).ErrorCode() != defaultErrorCode {
+		t.Fatalf("formatted error: %v", err)
+	}
+}
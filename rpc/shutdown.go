@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// ShutdownReason - reason of close frame sent to websocket clients by Shutdown
+const ShutdownReason = "server shutting down"
+
+// shuttingDownError - returned for calls received after Shutdown started
+type shuttingDownError struct{}
+
+func (e *shuttingDownError) ErrorCode() int { return defaultErrorCode }
+
+func (e *shuttingDownError) Error() string { return ShutdownReason }
+
+// drainer - counts calls in progress. Calls can't start after drain started. Methods of nil drainer do nothing
+type drainer struct {
+	lock     sync.Mutex
+	draining bool
+	calls    int
+	idle     chan struct{} // closed when there are no calls while draining
+}
+
+func newDrainer() *drainer {
+	return &drainer{idle: make(chan struct{})}
+}
+
+// start - false if call must be rejected, otherwise done must be called after it
+func (d *drainer) start() bool {
+	if d == nil {
+		return true
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.draining {
+		return false
+	}
+	d.calls++
+	return true
+}
+
+func (d *drainer) done() {
+	if d == nil {
+		return
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.calls--
+	if d.draining && d.calls == 0 {
+		close(d.idle)
+	}
+}
+
+// drain - rejects new calls and waits for calls in progress until ctx is done
+func (d *drainer) drain(ctx context.Context) error {
+	d.lock.Lock()
+	if !d.draining {
+		d.draining = true
+		if d.calls == 0 {
+			close(d.idle)
+		}
+	}
+	d.lock.Unlock()
+	select {
+	case <-d.idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// goingAwayCodec - codec which can tell the client why the connection is closed
+type goingAwayCodec interface {
+	closeGoingAway(reason string)
+}
+
+// Shutdown - gracefully stops the server: calls received from now on are rejected, calls in progress are waited
+// for until ctx is done, then websocket clients get close frame with ShutdownReason and all connections are closed
+// like by Stop. Returns ctx error if some calls were still in progress.
+func (s *Server) Shutdown(ctx context.Context) error {
+	log.Info("RPC server draining requests")
+	err := s.drainer.drain(ctx)
+	if err != nil {
+		log.Warn("RPC server shutdown timed out, cancelling requests in progress", "err", err)
+	}
+	if atomic.CompareAndSwapInt32(&s.run, 1, 0) {
+		log.Info("RPC server shutting down")
+		s.codecs.Each(func(c interface{}) bool {
+			if codec, ok := c.(goingAwayCodec); ok {
+				codec.closeGoingAway(ShutdownReason)
+			} else {
+				c.(ServerCodec).close()
+			}
+			return false // continue iteration
+		})
+	}
+	return err
+}
+
+func (wc *websocketCodec) closeGoingAway(reason string) {
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, reason)
+	if err := wc.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsPingWriteTimeout)); err != nil {
+		log.Trace("Failed to send websocket close frame", "conn", wc.remoteAddr(), "err", err)
+	}
+	wc.close()
+}
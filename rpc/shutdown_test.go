@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestShutdown(t *testing.T) {
+	server := newTestServer()
+	ts := httptest.NewServer(server.WebsocketHandler([]string{"*"}, false))
+	defer ts.Close()
+	wsURL := "ws:" + strings.TrimPrefix(ts.URL, "http:")
+
+	client, err := DialWebsocket(context.Background(), wsURL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sleepDone := make(chan error, 1)
+	go func() { sleepDone <- client.Call(nil, "test_sleep", 300*time.Millisecond) }()
+	time.Sleep(100 * time.Millisecond)
+	shutdownDone := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownDone <- server.Shutdown(ctx)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// calls received while draining are rejected
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x",1,{"S":"y"}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	_, reply, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(reply), ShutdownReason) {
+		t.Fatalf("expected shutdown error, got %s", reply)
+	}
+	// call in progress completes
+	if err := <-sleepDone; err != nil {
+		t.Fatalf("call in progress failed: %v", err)
+	}
+	if err := <-shutdownDone; err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway || closeErr.Text != ShutdownReason {
+		t.Fatalf("expected close frame with shutdown reason, got %v", err)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	server := newTestServer()
+	ts := httptest.NewServer(server.WebsocketHandler([]string{"*"}, false))
+	defer ts.Close()
+	client, err := DialWebsocket(context.Background(), "ws:"+strings.TrimPrefix(ts.URL, "http:"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	blockDone := make(chan error, 1)
+	go func() { blockDone <- client.Call(nil, "test_block") }()
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := server.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+	select {
+	case <-blockDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("call in progress is not cancelled after timeout")
+	}
+}
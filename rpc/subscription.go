@@ -147,6 +147,14 @@ func (n *Notifier) Closed() <-chan interface{} {
 	return n.h.conn.closed()
 }
 
+// CloseConnection closes the RPC connection of the subscription, for example when client doesn't read notifications
+// fast enough. Other subscriptions and calls of the connection end too
+func (n *Notifier) CloseConnection() {
+	if c, ok := n.h.conn.(interface{ close() }); ok {
+		c.close()
+	}
+}
+
 // takeSubscription returns the subscription (if one has been created). No subscription can
 // be created after this call.
 func (n *Notifier) takeSubscription() *Subscription {
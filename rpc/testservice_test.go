@@ -23,6 +23,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	jsoniter "github.com/json-iterator/go"
 )
 
 func newTestServer() *Server {
@@ -196,6 +198,32 @@ func (s *notificationTestService) HangSubscription(ctx context.Context, val int)
 	return subscription, nil
 }
 
+// Ticks sends notification every `ms` milliseconds until the subscription is closed
+func (s *notificationTestService) Ticks(ctx context.Context, ms int) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	subscription := notifier.CreateSubscription()
+	go func() {
+		ticker := time.NewTicker(time.Duration(ms) * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			select {
+			case <-ticker.C:
+				if err := notifier.Notify(subscription.ID, i); err != nil {
+					return
+				}
+			case <-notifier.Closed():
+				return
+			case <-subscription.Err():
+				return
+			}
+		}
+	}()
+	return subscription, nil
+}
+
 // largeRespService generates arbitrary-size JSON responses.
 type largeRespService struct {
 	length int
@@ -204,3 +232,68 @@ type largeRespService struct {
 func (x largeRespService) LargeResp() string {
 	return strings.Repeat("x", x.length)
 }
+
+// streamService writes responses directly to JSON stream.
+type streamService struct {
+	proceed chan struct{}
+}
+
+// Numbers writes numbers from 0 to n-1 and after the first half waits for proceed to be closed.
+func (s *streamService) Numbers(n int, stream *jsoniter.Stream) error {
+	stream.WriteArrayStart()
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			stream.WriteMore()
+		}
+		stream.WriteInt(i)
+		if i == n/2 {
+			if err := stream.Flush(); err != nil {
+				return err
+			}
+			select {
+			case <-s.proceed:
+			case <-time.After(10 * time.Second):
+				return errors.New("response was not streamed")
+			}
+		}
+	}
+	stream.WriteArrayEnd()
+	return nil
+}
+
+// Elements writes numbers from 0 to n-1 with ArrayStream, then, if asked to, fails in the middle of the next element
+func (s *streamService) Elements(ctx context.Context, n int, fail bool, stream *jsoniter.Stream) error {
+	arr := NewArrayStream(ctx, stream)
+	arr.Start()
+	for i := 0; i < n; i++ {
+		arr.Next()
+		stream.WriteInt(i)
+	}
+	if err := stream.Flush(); err != nil {
+		return err
+	}
+	if fail {
+		arr.Next()
+		stream.WriteObjectStart()
+		arr.End()
+		return testError{}
+	}
+	arr.End()
+	return stream.Flush()
+}
+
+// Failing writes numbers from 0 to n-1, flushes them and fails
+func (s *streamService) Failing(n int, stream *jsoniter.Stream) error {
+	stream.WriteArrayStart()
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			stream.WriteMore()
+		}
+		stream.WriteInt(i)
+	}
+	stream.WriteArrayEnd()
+	if err := stream.Flush(); err != nil {
+		return err
+	}
+	return testError{}
+}
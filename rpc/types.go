@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"strconv"
 	"strings"
@@ -34,6 +35,8 @@ type API struct {
 	Version   string      // api version for DApp's
 	Service   interface{} // receiver instance which holds the methods
 	Public    bool        // indication if the methods must be considered safe for public use
+	// Authenticated - served only on JWT-authenticated endpoint (--authrpc of rpcdaemon), like Engine API
+	Authenticated bool
 }
 
 // Error wraps RPC errors, which contain an error code in addition to the message.
@@ -48,6 +51,14 @@ type DataError interface {
 	ErrorData() interface{} // returns the error data
 }
 
+// ResponseFormatter rewrites results and errors of method calls before they are sent, for example to match
+// responses of another client. Results of streamable methods and subscription notifications are not formatted.
+// Results may be shared with caches, so they must be copied, not changed.
+type ResponseFormatter interface {
+	FormatResult(method string, result interface{}) interface{}
+	FormatError(method string, err error) error
+}
+
 // ServerCodec implements reading, parsing and writing RPC messages for the server side of
 // a RPC session. Implementations must be go-routine safe since the codec can be called in
 // multiple go-routines concurrently.
@@ -67,17 +78,35 @@ type jsonWriter interface {
 	remoteAddr() string
 }
 
+// connAPIKey is implemented by connections which know API key of the client.
+type connAPIKey interface {
+	apiKey() string
+}
+
+// connSubscriptionLimit is implemented by connections which bound amount of subscriptions of the client.
+type connSubscriptionLimit interface {
+	subscriptionLimit() int
+}
+
+// jsonStreamWriter can write a single JSON message incrementally, without having it fully in memory.
+type jsonStreamWriter interface {
+	// writeStream returns writer of the next message. Other writes to the connection wait until it's closed.
+	writeStream(context.Context) (io.WriteCloser, error)
+}
+
 type BlockNumber int64
 type Timestamp uint64
 
 const (
-	PendingBlockNumber  = BlockNumber(-2)
-	LatestBlockNumber   = BlockNumber(-1)
-	EarliestBlockNumber = BlockNumber(0)
+	SafeBlockNumber      = BlockNumber(-4)
+	FinalizedBlockNumber = BlockNumber(-3)
+	PendingBlockNumber   = BlockNumber(-2)
+	LatestBlockNumber    = BlockNumber(-1)
+	EarliestBlockNumber  = BlockNumber(0)
 )
 
 // UnmarshalJSON parses the given JSON fragment into a BlockNumber. It supports:
-// - "latest", "earliest" or "pending" as string arguments
+// - "latest", "earliest", "pending", "safe" or "finalized" as string arguments
 // - the block number
 // Returned errors:
 // - an invalid block number error when the given argument isn't a known strings
@@ -98,6 +127,12 @@ func (bn *BlockNumber) UnmarshalJSON(data []byte) error {
 	case "pending":
 		*bn = PendingBlockNumber
 		return nil
+	case "safe":
+		*bn = SafeBlockNumber
+		return nil
+	case "finalized":
+		*bn = FinalizedBlockNumber
+		return nil
 	case "null":
 		*bn = LatestBlockNumber
 		return nil
@@ -168,6 +203,14 @@ func (bnh *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
 		bn := PendingBlockNumber
 		bnh.BlockNumber = &bn
 		return nil
+	case "safe":
+		bn := SafeBlockNumber
+		bnh.BlockNumber = &bn
+		return nil
+	case "finalized":
+		bn := FinalizedBlockNumber
+		bnh.BlockNumber = &bn
+		return nil
 	default:
 		if len(input) == 66 {
 			hash := common.Hash{}
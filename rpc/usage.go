@@ -0,0 +1,410 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/VictoriaMetrics/metrics"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/ledgerwatch/log/v3"
+)
+
+// usageBucket - granularity of kept aggregates of usage, reports and quotas are counted by whole buckets
+const usageBucket = time.Hour
+
+// UsageCosts - compute units charged for a call of method, Methods override Default. Methods without cost cost 1
+// unit if there is no Default
+type UsageCosts struct {
+	Default uint64            `json:"default"`
+	Methods map[string]uint64 `json:"methods"`
+}
+
+func (c UsageCosts) cost(method string) uint64 {
+	if cost, ok := c.Methods[method]; ok {
+		return cost
+	}
+	if c.Default > 0 {
+		return c.Default
+	}
+	return 1
+}
+
+// UsageQuota - compute units client can spend within rolling Period, calls over it are rejected
+type UsageQuota struct {
+	ComputeUnits uint64   `json:"computeUnits"`
+	Period       Duration `json:"period"`
+}
+
+// Validate - checks that quota counts at least one bucket of usage
+func (q UsageQuota) Validate() error {
+	if time.Duration(q.Period) < usageBucket {
+		return fmt.Errorf("period of quota must be at least %s", usageBucket)
+	}
+	return nil
+}
+
+// Usage - calls of client, sizes of requests are sizes of their method, params and id. Notifications of
+// subscriptions are not counted
+type Usage struct {
+	Requests      uint64 `json:"requests"`
+	Failures      uint64 `json:"failures"`
+	ComputeUnits  uint64 `json:"computeUnits"`
+	RequestBytes  uint64 `json:"requestBytes"`
+	ResponseBytes uint64 `json:"responseBytes"`
+}
+
+func (u *Usage) add(other *Usage) {
+	u.Requests += other.Requests
+	u.Failures += other.Failures
+	u.ComputeUnits += other.ComputeUnits
+	u.RequestBytes += other.RequestBytes
+	u.ResponseBytes += other.ResponseBytes
+}
+
+// QuotaUsage - quota of client with compute units it spent within the period of quota
+type QuotaUsage struct {
+	UsageQuota
+	Used      uint64 `json:"used"`
+	Remaining uint64 `json:"remaining"`
+}
+
+// ClientUsage - usage of one client in [From, To), Quota is set for clients which have one
+type ClientUsage struct {
+	Client string    `json:"client"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Usage
+	Quota *QuotaUsage `json:"quota,omitempty"`
+}
+
+// UsageMeter receives every call served by Server with its client: API key name (or digest of key) or IP address
+type UsageMeter interface {
+	// Allow - error sent to client instead of the call result if client spent its quota, nil quota - no quota
+	Allow(client string, quota *UsageQuota) error
+	Record(client, method string, requestBytes, responseBytes int, failed bool)
+}
+
+// ClientPlanner - optionally implemented by Authenticator: name of client of the key for usage accounting and its
+// quota, nil if it has none. Name is empty for calls without key and for keys the authenticator doesn't know: they are
+// accounted by IP address. Without ClientPlanner all calls are accounted by IP address
+type ClientPlanner interface {
+	ClientPlan(ctx context.Context, key string) (name string, quota *UsageQuota)
+}
+
+// UsageAccounting - UsageMeter keeping hourly aggregates of usage of each client for `retention`, saved to file if
+// its path is set
+type UsageAccounting struct {
+	costs     UsageCosts
+	retention time.Duration
+	path      string
+
+	lock    sync.Mutex
+	clients map[string]*clientUsage
+}
+
+type clientUsage struct {
+	Buckets map[int64]*Usage `json:"buckets"` // by unix time of start of bucket
+	quota   *UsageQuota      // the latest one client called with, not saved
+}
+
+// NewUsageAccounting - accounting loading aggregates saved to `path` before, if path is set and file exists
+func NewUsageAccounting(costs UsageCosts, retention time.Duration, path string) (*UsageAccounting, error) {
+	a := &UsageAccounting{costs: costs, retention: retention, path: path, clients: map[string]*clientUsage{}}
+	if path == "" {
+		return a, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read usage: %w", err)
+	}
+	if err = json.Unmarshal(data, &a.clients); err != nil {
+		return nil, fmt.Errorf("could not parse usage %s: %w", path, err)
+	}
+	a.sweep(time.Now())
+	return a, nil
+}
+
+func (a *UsageAccounting) Allow(client string, quota *UsageQuota) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	c := a.client(client)
+	c.quota = quota
+	if quota == nil {
+		return nil
+	}
+	if used := c.computeUnits(time.Now(), time.Duration(quota.Period)); used >= quota.ComputeUnits {
+		quotaExceededCounter(client).Inc()
+		return &quotaExceededError{used: used, quota: *quota}
+	}
+	return nil
+}
+
+func (a *UsageAccounting) Record(client, method string, requestBytes, responseBytes int, failed bool) {
+	cost := a.costs.cost(method)
+	bucket := time.Now().Truncate(usageBucket).Unix()
+
+	a.lock.Lock()
+	c := a.client(client)
+	u, ok := c.Buckets[bucket]
+	if !ok {
+		u = &Usage{}
+		c.Buckets[bucket] = u
+	}
+	u.Requests++
+	if failed {
+		u.Failures++
+	}
+	u.ComputeUnits += cost
+	u.RequestBytes += uint64(requestBytes)
+	u.ResponseBytes += uint64(responseBytes)
+	a.lock.Unlock()
+
+	requests, units, requestBytesTotal, responseBytesTotal := newUsageMetrics(client)
+	requests.Inc()
+	units.Add(int(cost))
+	requestBytesTotal.Add(requestBytes)
+	responseBytesTotal.Add(responseBytes)
+}
+
+// Report - usage of clients in buckets starting within [from, to), `from` is rounded down to the hour. Reports all
+// clients if `client` is empty, sorted by client
+func (a *UsageAccounting) Report(client string, from, to time.Time) []*ClientUsage {
+	now := time.Now()
+	from = from.Truncate(usageBucket)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.sweep(now)
+	var report []*ClientUsage
+	for name, c := range a.clients {
+		if client != "" && name != client {
+			continue
+		}
+		r := &ClientUsage{Client: name, From: from, To: to}
+		for bucket, u := range c.Buckets {
+			if t := time.Unix(bucket, 0); !t.Before(from) && t.Before(to) {
+				r.Usage.add(u)
+			}
+		}
+		if c.quota != nil {
+			r.Quota = &QuotaUsage{UsageQuota: *c.quota, Used: c.computeUnits(now, time.Duration(c.quota.Period))}
+			if r.Quota.Used < r.Quota.ComputeUnits {
+				r.Quota.Remaining = r.Quota.ComputeUnits - r.Quota.Used
+			}
+		}
+		report = append(report, r)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Client < report[j].Client })
+	return report
+}
+
+// Save - writes aggregates to the file, replacing it at once. Does nothing if path is not set
+func (a *UsageAccounting) Save() error {
+	if a.path == "" {
+		return nil
+	}
+	a.lock.Lock()
+	a.sweep(time.Now())
+	data, err := json.Marshal(a.clients)
+	a.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(a.path), filepath.Base(a.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), a.path)
+}
+
+// SaveEvery - saves aggregates each `interval` until ctx is done, and once more then
+func (a *UsageAccounting) SaveEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := a.Save(); err != nil {
+				log.Warn("Could not save RPC usage", "path", a.path, "err", err)
+			}
+			return
+		case <-ticker.C:
+			if err := a.Save(); err != nil {
+				log.Warn("Could not save RPC usage", "path", a.path, "err", err)
+			}
+		}
+	}
+}
+
+// client - usage of client, created on the first call. Caller holds the lock
+func (a *UsageAccounting) client(name string) *clientUsage {
+	c, ok := a.clients[name]
+	if !ok {
+		c = &clientUsage{Buckets: map[int64]*Usage{}}
+		a.clients[name] = c
+	}
+	return c
+}
+
+// sweep - forgets buckets older than retention and clients without buckets. Caller holds the lock
+func (a *UsageAccounting) sweep(now time.Time) {
+	oldest := now.Add(-a.retention).Truncate(usageBucket).Unix()
+	for name, c := range a.clients {
+		for bucket := range c.Buckets {
+			if bucket < oldest {
+				delete(c.Buckets, bucket)
+			}
+		}
+		if len(c.Buckets) == 0 && c.quota == nil {
+			delete(a.clients, name)
+		}
+	}
+}
+
+// computeUnits - spent in buckets within `period` before now, including the current one
+func (c *clientUsage) computeUnits(now time.Time, period time.Duration) uint64 {
+	since := now.Add(-period).Truncate(usageBucket).Unix()
+	var units uint64
+	for bucket, u := range c.Buckets {
+		if bucket > since {
+			units += u.ComputeUnits
+		}
+	}
+	return units
+}
+
+// quotaExceededError - returned for calls of clients which spent their quota, same code as "limit exceeded" of EIP-1474
+type quotaExceededError struct {
+	used  uint64
+	quota UsageQuota
+}
+
+func (e *quotaExceededError) ErrorCode() int { return -32005 }
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("quota of %d compute units per %s exceeded (used %d), retry later", e.quota.ComputeUnits,
+		time.Duration(e.quota.Period), e.used)
+}
+
+// metricsClient - API key clients are labeled by name, anonymous ones by IP address are counted together
+func metricsClient(client string) string {
+	if net.ParseIP(client) != nil {
+		return "public"
+	}
+	return client
+}
+
+func newUsageMetrics(client string) (requests, units, requestBytes, responseBytes *metrics.Counter) {
+	client = metricsClient(client)
+	requests = metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_usage_requests_total{client="%s"}`, client))
+	units = metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_usage_compute_units_total{client="%s"}`, client))
+	requestBytes = metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_usage_request_bytes_total{client="%s"}`, client))
+	responseBytes = metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_usage_response_bytes_total{client="%s"}`, client))
+	return requests, units, requestBytes, responseBytes
+}
+
+func quotaExceededCounter(client string) *metrics.Counter {
+	return metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_quota_exceeded{client="%s"}`, metricsClient(client)))
+}
+
+// usageClient - name of API key client known to ClientPlanner, IP address of client without key or with unknown one:
+// unverified keys must not create new clients, any caller could send new key with each call
+func (h *handler) usageClient(ctx context.Context) (string, *UsageQuota) {
+	var name string
+	var quota *UsageQuota
+	if planner, ok := h.authenticator.(ClientPlanner); ok {
+		name, quota = planner.ClientPlan(ctx, apiKey(ctx))
+	}
+	if name != "" {
+		return name, quota
+	}
+	remote := h.conn.remoteAddr()
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		return host, quota
+	}
+	return remote, quota
+}
+
+// allowedByQuota - error if client of the call spent its quota, everything is allowed without usage meter
+func (h *handler) allowedByQuota(ctx context.Context) error {
+	if h.usage == nil {
+		return nil
+	}
+	client, quota := h.usageClient(ctx)
+	return h.usage.Allow(client, quota)
+}
+
+// recordUsage - called after answering msg of allowed call
+func (h *handler) recordUsage(ctx context.Context, msg *jsonrpcMessage, answer *jsonrpcMessage, stream *jsoniter.Stream) {
+	if h.usage == nil {
+		return
+	}
+	client, _ := h.usageClient(ctx)
+	h.usage.Record(client, msg.Method, requestSize(msg), responseSize(answer, stream), answer != nil && answer.Error != nil)
+}
+
+// requestSize - size of method, params and id of call
+func requestSize(msg *jsonrpcMessage) int {
+	return len(msg.Method) + len(msg.Params) + len(msg.ID)
+}
+
+// responseSize - size of result or error of answer, or of result written to stream of streamable method
+func responseSize(answer *jsonrpcMessage, stream *jsoniter.Stream) int {
+	size := stream.Buffered()
+	if w, ok := stream.Attachment.(*countingWriter); ok {
+		size += w.n
+	}
+	if answer != nil {
+		size += len(answer.Result)
+		if answer.Error != nil {
+			size += len(answer.Error.Message)
+		}
+	}
+	return size
+}
+
+// countingWriter - writer of stream of call, set as Attachment of the stream for usage accounting. Copies what is
+// written to tee if it's set, for the request recorder
+type countingWriter struct {
+	w   io.Writer
+	n   int
+	tee *bytes.Buffer
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += n
+	if w.tee != nil {
+		w.tee.Write(p[:n])
+	}
+	return n, err
+}
+
+// newCallStream - stream of call writing to w, counting bytes written to it
+func newCallStream(w io.Writer) *jsoniter.Stream {
+	cw := &countingWriter{w: w}
+	stream := jsoniter.NewStream(jsoniter.ConfigDefault, cw, 4096)
+	stream.Attachment = cw
+	return stream
+}
@@ -0,0 +1,162 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUsageAccounting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	usage, err := NewUsageAccounting(UsageCosts{Default: 2, Methods: map[string]uint64{"test_rets": 10}}, time.Hour, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := APIKeys{
+		Public: Permissions{Methods: []string{"test_echo"}},
+		Keys: map[string]Permissions{
+			"plan":   {Namespaces: []string{"test"}, Name: "acme", Quota: &UsageQuota{ComputeUnits: 15, Period: Duration(time.Hour)}},
+			"noname": {Namespaces: []string{"test"}},
+		},
+	}
+	if err = keys.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	defer server.Stop()
+	server.SetAuthenticator(NewAPIKeyAuthenticator(keys))
+	server.SetUsageMeter(usage)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	call := func(key string, method string, args ...interface{}) error {
+		client, err := DialHTTP(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer client.Close()
+		if key != "" {
+			client.SetHeader(APIKeyHeader, key)
+		}
+		var result interface{}
+		return client.Call(&result, method, args...)
+	}
+	for _, key := range []string{"", "noname", "plan"} {
+		if err = call(key, "test_echo", "hello", 10, &echoArgs{"world"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = call("plan", "test_rets"); err != nil {
+		t.Fatal(err)
+	}
+	if err = call("plan", "test_returnError"); err == nil {
+		t.Fatal("expected error of the method")
+	}
+	// 2 + 10 + 2 units are spent, quota lets the next call through and rejects the one after it
+	if err = call("plan", "test_echo", "hello", 10, &echoArgs{"world"}); err != nil {
+		t.Fatal(err)
+	}
+	var rpcErr Error
+	if err = call("plan", "test_echo", "hello", 10, &echoArgs{"world"}); !errors.As(err, &rpcErr) || rpcErr.ErrorCode() != -32005 {
+		t.Fatalf("expected quota exceeded error, got %v", err)
+	}
+
+	report := usage.Report("", time.Now().Add(-time.Hour), time.Now().Add(time.Second))
+	if len(report) != 3 {
+		t.Fatalf("expected 3 clients, got %d", len(report))
+	}
+	if report[0].Client != "127.0.0.1" || report[1].Client != "acme" || report[2].Client[:4] != "key:" {
+		t.Fatalf("unexpected clients %s, %s, %s", report[0].Client, report[1].Client, report[2].Client)
+	}
+	acme := report[1]
+	if acme.Requests != 4 || acme.Failures != 1 || acme.ComputeUnits != 16 {
+		t.Fatalf("unexpected usage of acme: %+v", acme.Usage)
+	}
+	if acme.RequestBytes == 0 || acme.ResponseBytes == 0 {
+		t.Fatalf("bytes are not counted: %+v", acme.Usage)
+	}
+	if acme.Quota == nil || acme.Quota.Used != 16 || acme.Quota.Remaining != 0 {
+		t.Fatalf("unexpected quota of acme: %+v", acme.Quota)
+	}
+	if report[0].Quota != nil {
+		t.Fatalf("unexpected quota of public client: %+v", report[0].Quota)
+	}
+	if past := usage.Report("acme", time.Now().Add(-3*time.Hour), time.Now().Add(-2*time.Hour)); len(past) != 1 || past[0].Requests != 0 {
+		t.Fatalf("unexpected usage before calls: %+v", past)
+	}
+
+	// aggregates survive restart
+	if err = usage.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewUsageAccounting(UsageCosts{}, time.Hour, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report = loaded.Report("acme", time.Now().Add(-time.Hour), time.Now().Add(time.Second))
+	if len(report) != 1 || report[0].Usage != acme.Usage || report[0].Quota != nil {
+		t.Fatalf("unexpected loaded usage: %+v", report)
+	}
+}
+
+func TestStreamedResponseUsage(t *testing.T) {
+	usage, err := NewUsageAccounting(UsageCosts{}, time.Hour, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(1)
+	defer server.Stop()
+	proceed := make(chan struct{})
+	close(proceed)
+	if err = server.RegisterName("stream", &streamService{proceed: proceed}); err != nil {
+		t.Fatal(err)
+	}
+	server.SetUsageMeter(usage)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	client, err := DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	var result []int
+	if err = client.Call(&result, "stream_numbers", 2000); err != nil {
+		t.Fatal(err)
+	}
+	report := usage.Report("", time.Now().Add(-time.Hour), time.Now().Add(time.Second))
+	if len(report) != 1 || report[0].ResponseBytes < 8000 {
+		t.Fatalf("streamed response isn't counted: %+v", report)
+	}
+}
+
+func TestUsageOfUnverifiedKeys(t *testing.T) {
+	usage, err := NewUsageAccounting(UsageCosts{}, time.Hour, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer()
+	defer server.Stop()
+	server.SetUsageMeter(usage)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	for i := 0; i < 3; i++ {
+		client, err := DialHTTP(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.SetHeader(APIKeyHeader, fmt.Sprintf("fake%d", i))
+		var result echoResult
+		if err = client.Call(&result, "test_echo", "hello", 10, &echoArgs{"world"}); err != nil {
+			t.Fatal(err)
+		}
+		client.Close()
+	}
+	// without authenticator keys are not verified, calls are accounted by IP address
+	report := usage.Report("", time.Now().Add(-time.Hour), time.Now().Add(time.Second))
+	if len(report) != 1 || report[0].Client != "127.0.0.1" || report[0].Requests != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
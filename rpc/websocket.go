@@ -19,12 +19,16 @@ package rpc
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	mapset "github.com/deckarep/golang-set"
@@ -42,6 +46,21 @@ const (
 
 var wsBufferPool = new(sync.Pool)
 
+// WebsocketCompression - permessage-deflate (RFC 7692) of messages sent to clients which negotiated it
+type WebsocketCompression struct {
+	Level   int // flate level: -2 (Huffman only) to 9 (best compression), -1 is default of flate
+	MinSize int // messages smaller than this amount of bytes are sent uncompressed
+}
+
+// WebsocketLimits - limits of websocket connections of a server, zero values keep the defaults
+type WebsocketLimits struct {
+	MaxConnections   int           // connections over it are closed with 1013 (try again later) right after upgrade. 0 - no limit
+	MaxSubscriptions int           // subscribing over it fails with error, the connection stays open. 0 - no limit
+	ReadTimeout      time.Duration // connection sending neither messages nor pongs for this long is closed with 1008. 0 - no timeout
+	WriteTimeout     time.Duration // connection is closed if write of a message takes longer, also if the call has later deadline
+	PingInterval     time.Duration // idle connection is pinged with this interval, any one with ReadTimeout. Use less than ReadTimeout
+}
+
 // WebsocketHandler returns a handler that serves JSON-RPC to WebSocket connections.
 //
 // allowedOrigins should be a comma-separated list of allowed origin URLs.
@@ -60,7 +79,22 @@ func (s *Server) WebsocketHandler(allowedOrigins []string, compression bool) htt
 			log.Warn("WebSocket upgrade failed", "err", err)
 			return
 		}
-		codec := newWebsocketCodec(conn)
+		limits := s.wsLimits
+		if n := atomic.AddInt32(&s.wsConnections, 1); limits.MaxConnections > 0 && n > int32(limits.MaxConnections) {
+			atomic.AddInt32(&s.wsConnections, -1)
+			log.Debug("Rejected WebSocket connection over limit", "limit", limits.MaxConnections, "remote", conn.RemoteAddr())
+			closeWebsocket(conn, websocket.CloseTryAgainLater, "too many websocket connections")
+			return
+		}
+		defer atomic.AddInt32(&s.wsConnections, -1)
+		codec := newWebsocketCodec(conn, limits)
+		codec.(*websocketCodec).key = r.Header.Get(APIKeyHeader)
+		if c := s.wsCompression; c != nil {
+			conn.SetCompressionLevel(c.Level) //nolint:errcheck
+			codec.(*websocketCodec).compressMin = c.MinSize
+		}
+		activeWebsocketsGauge.Inc()
+		defer activeWebsocketsGauge.Dec()
 		s.ServeCodec(codec, 0)
 	})
 }
@@ -198,7 +232,7 @@ func DialWebsocketWithDialer(ctx context.Context, endpoint, origin string, diale
 			}
 			return nil, hErr
 		}
-		return newWebsocketCodec(conn), nil
+		return newWebsocketCodec(conn, WebsocketLimits{}), nil
 	})
 }
 
@@ -235,24 +269,85 @@ func wsClientHeaders(endpoint, origin string) (string, http.Header, error) {
 
 type websocketCodec struct {
 	*jsonCodec
-	conn *websocket.Conn
+	conn   *websocket.Conn
+	key    string // API key sent by client in the handshake
+	limits WebsocketLimits
+
+	compressMin int // messages shorter than it are not compressed, 0 - all messages are compressed if negotiated
 
 	wg        sync.WaitGroup
 	pingReset chan struct{}
 }
 
-func newWebsocketCodec(conn *websocket.Conn) ServerCodec {
+func newWebsocketCodec(conn *websocket.Conn, limits WebsocketLimits) ServerCodec {
 	conn.SetReadLimit(wsMessageSizeLimit)
+	if limits.PingInterval <= 0 {
+		limits.PingInterval = wsPingInterval
+	}
 	wc := &websocketCodec{
-		jsonCodec: NewFuncCodec(conn, conn.WriteJSON, conn.ReadJSON).(*jsonCodec),
 		conn:      conn,
+		limits:    limits,
 		pingReset: make(chan struct{}, 1),
 	}
+	decode := conn.ReadJSON
+	if limits.ReadTimeout > 0 {
+		decode = wc.readJSON
+		wc.extendReadDeadline()
+		conn.SetPongHandler(func(string) error {
+			wc.extendReadDeadline()
+			return nil
+		})
+	}
+	wc.jsonCodec = NewFuncCodec(conn, wc.writeMessage, decode).(*jsonCodec)
+	wc.jsonCodec.writeTimeout = limits.WriteTimeout
 	wc.wg.Add(1)
 	go wc.pingLoop()
 	return wc
 }
 
+func (wc *websocketCodec) apiKey() string {
+	return wc.key
+}
+
+func (wc *websocketCodec) subscriptionLimit() int {
+	return wc.limits.MaxSubscriptions
+}
+
+func (wc *websocketCodec) writeMessage(v interface{}) error {
+	if wc.compressMin <= 0 {
+		return wc.conn.WriteJSON(v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	wc.conn.EnableWriteCompression(len(b) >= wc.compressMin)
+	return wc.conn.WriteMessage(websocket.TextMessage, b)
+}
+
+func (wc *websocketCodec) extendReadDeadline() {
+	wc.conn.SetReadDeadline(time.Now().Add(wc.limits.ReadTimeout)) //nolint:errcheck
+}
+
+// readJSON - reads next message, peer silent for ReadTimeout gets close frame before the connection is closed
+func (wc *websocketCodec) readJSON(v interface{}) error {
+	err := wc.conn.ReadJSON(v)
+	if err != nil {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			closeWebsocket(wc.conn, websocket.ClosePolicyViolation, "keepalive timeout")
+		}
+		return err
+	}
+	wc.extendReadDeadline()
+	return nil
+}
+
+// closeWebsocket - sends close frame with the code and reason, then closes the connection
+func closeWebsocket(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsPingWriteTimeout)) //nolint:errcheck
+	conn.Close()
+}
+
 func (wc *websocketCodec) close() {
 	wc.jsonCodec.close()
 	wc.wg.Wait()
@@ -260,37 +355,110 @@ func (wc *websocketCodec) close() {
 
 func (wc *websocketCodec) writeJSON(ctx context.Context, v interface{}) error {
 	err := wc.jsonCodec.writeJSON(ctx, v)
+	wc.written(err)
+	return err
+}
+
+func (wc *websocketCodec) written(err error) {
 	if err == nil {
 		// Notify pingLoop to delay the next idle ping.
 		select {
 		case wc.pingReset <- struct{}{}:
 		default:
 		}
+	} else if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		// websocket connection is unusable after a write timed out
+		wc.jsonCodec.close()
 	}
+}
+
+func (wc *websocketCodec) writeStream(ctx context.Context) (io.WriteCloser, error) {
+	wc.encMu.Lock()
+	wc.setWriteDeadline(ctx)
+	w := &wsStreamWriter{wc: wc, ctx: ctx}
+	if wc.compressMin <= 0 {
+		if err := w.open(); err != nil {
+			wc.encMu.Unlock()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// wsStreamWriter - writes message as a single websocket frame sequence, the websocket connection buffers it.
+// With compressMin first bytes are buffered until it's known if the message is large enough to be compressed
+type wsStreamWriter struct {
+	wc  *websocketCodec
+	ctx context.Context
+	w   io.WriteCloser // nil while first bytes are buffered
+	buf []byte
+}
+
+func (w *wsStreamWriter) open() (err error) {
+	w.w, err = w.wc.conn.NextWriter(websocket.TextMessage)
 	return err
 }
 
-// pingLoop sends periodic ping frames when the connection is idle.
+func (w *wsStreamWriter) Write(p []byte) (int, error) {
+	w.wc.setWriteDeadline(w.ctx)
+	if w.w != nil {
+		return w.w.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) < w.wc.compressMin {
+		return len(p), nil
+	}
+	w.wc.conn.EnableWriteCompression(true)
+	if err := w.open(); err != nil {
+		return 0, err
+	}
+	if _, err := w.w.Write(w.buf); err != nil {
+		return 0, err
+	}
+	w.buf = nil
+	return len(p), nil
+}
+
+func (w *wsStreamWriter) Close() error {
+	var err error
+	if w.w != nil {
+		err = w.w.Close()
+	} else {
+		w.wc.setWriteDeadline(w.ctx)
+		w.wc.conn.EnableWriteCompression(false)
+		err = w.wc.conn.WriteMessage(websocket.TextMessage, w.buf)
+	}
+	w.wc.encMu.Unlock()
+	w.wc.written(err)
+	return err
+}
+
+// pingLoop sends periodic ping frames when the connection is idle. With ReadTimeout pings go on fixed schedule: writes
+// don't extend read deadline, so subscriber which only receives notifications has to be pinged to answer with pongs.
 func (wc *websocketCodec) pingLoop() {
-	timer := time.NewTimer(wsPingInterval)
+	timer := time.NewTimer(wc.limits.PingInterval)
 	defer wc.wg.Done()
 	defer timer.Stop()
+	pingReset := wc.pingReset
+	if wc.limits.ReadTimeout > 0 {
+		pingReset = nil
+	}
 
 	for {
 		select {
 		case <-wc.closed():
 			return
-		case <-wc.pingReset:
+		case <-pingReset:
 			if !timer.Stop() {
 				<-timer.C
 			}
-			timer.Reset(wsPingInterval)
+			timer.Reset(wc.limits.PingInterval)
 		case <-timer.C:
 			wc.jsonCodec.encMu.Lock()
 			wc.conn.SetWriteDeadline(time.Now().Add(wsPingWriteTimeout)) //nolint:errcheck
 			wc.conn.WriteMessage(websocket.PingMessage, nil)             //nolint:errcheck
 			wc.jsonCodec.encMu.Unlock()
-			timer.Reset(wsPingInterval)
+			timer.Reset(wc.limits.PingInterval)
 		}
 	}
 }
@@ -23,6 +23,7 @@ import (
 	"net/http/httptest"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -291,3 +292,170 @@ func wsPingTestHandler(t *testing.T, conn *websocket.Conn, shutdown, sendPing <-
 		}
 	}
 }
+
+func TestWebsocketLimits(t *testing.T) {
+	srv := newTestServer()
+	srv.SetWebsocketLimits(WebsocketLimits{MaxConnections: 1, MaxSubscriptions: 1, ReadTimeout: 300 * time.Millisecond, PingInterval: 50 * time.Millisecond})
+	httpsrv := httptest.NewServer(srv.WebsocketHandler([]string{"*"}, false))
+	defer srv.Stop()
+	defer httpsrv.Close()
+	wsURL := "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+	closeCode := func(conn *websocket.Conn) int {
+		_, _, err := conn.ReadMessage()
+		if ce, ok := err.(*websocket.CloseError); ok {
+			return ce.Code
+		}
+		t.Fatalf("expected close frame, got %v", err)
+		return 0
+	}
+
+	client, err := DialWebsocket(context.Background(), wsURL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Subscribe(context.Background(), "nftest", make(chan int, 1), "someSubscription", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Subscribe(context.Background(), "nftest", make(chan int, 1), "someSubscription", 1, 1)
+	if e, ok := err.(Error); !ok || e.ErrorCode() != -32005 {
+		t.Fatalf("expected subscription limit error, got %v", err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := closeCode(conn); code != websocket.CloseTryAgainLater {
+		t.Fatalf("connection over limit closed with %d", code)
+	}
+
+	// pongs keep connection of the client alive
+	time.Sleep(time.Second)
+	var result int
+	if err = client.Call(&result, "nftest_echo", 7); err != nil || result != 7 {
+		t.Fatalf("call after read timeout: %d %v", result, err)
+	}
+	client.Close()
+
+	// slot of closed client is released, peer not answering pings is disconnected
+	for deadline := time.Now().Add(time.Second); ; {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetPingHandler(func(string) error { return nil })
+		code := closeCode(conn)
+		if code == websocket.ClosePolicyViolation {
+			break
+		}
+		if code != websocket.CloseTryAgainLater || time.Now().After(deadline) {
+			t.Fatalf("silent connection closed with %d", code)
+		}
+	}
+}
+
+// This checks that subscriber getting notifications more often than ping interval is pinged anyway: only its pongs
+// extend read timeout.
+func TestWebsocketPingBusySubscriber(t *testing.T) {
+	srv := newTestServer()
+	srv.SetWebsocketLimits(WebsocketLimits{ReadTimeout: 300 * time.Millisecond, PingInterval: 50 * time.Millisecond})
+	httpsrv := httptest.NewServer(srv.WebsocketHandler([]string{"*"}, false))
+	defer srv.Stop()
+	defer httpsrv.Close()
+	wsURL := "ws:" + strings.TrimPrefix(httpsrv.URL, "http:")
+
+	client, err := DialWebsocket(context.Background(), wsURL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ticks := make(chan int, 1024)
+	sub, err := client.Subscribe(context.Background(), "nftest", ticks, "ticks", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(time.Second)
+	for received := 0; ; {
+		select {
+		case <-ticks:
+			received++
+			continue
+		case err := <-sub.Err():
+			t.Fatalf("subscription closed after %d notifications: %v", received, err)
+		case <-timeout:
+		}
+		if received == 0 {
+			t.Fatal("no notifications")
+		}
+		break
+	}
+	var result int
+	if err = client.Call(&result, "nftest_echo", 7); err != nil || result != 7 {
+		t.Fatalf("call after read timeout: %d %v", result, err)
+	}
+}
+
+type countingConn struct {
+	net.Conn
+	read int64
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	atomic.AddInt64(&c.read, int64(n))
+	return n, err
+}
+
+func TestWebsocketCompression(t *testing.T) {
+	srv := NewServer(50)
+	srv.SetWebsocketCompression(WebsocketCompression{Level: 1, MinSize: 1000})
+	proceed := make(chan struct{})
+	close(proceed)
+	if err := srv.RegisterName("test", largeRespService{100_000}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.RegisterName("stream", &streamService{proceed: proceed}); err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(srv.WebsocketHandler([]string{"*"}, true))
+	defer srv.Stop()
+	defer httpsrv.Close()
+
+	conn := &countingConn{}
+	dialer := websocket.Dialer{EnableCompression: true, NetDial: func(network, addr string) (net.Conn, error) {
+		c, err := net.Dial(network, addr)
+		conn.Conn = c
+		return conn, err
+	}}
+	client, err := DialWebsocketWithDialer(context.Background(), "ws:"+strings.TrimPrefix(httpsrv.URL, "http:"), "", dialer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	received := func(call func()) int64 {
+		before := atomic.LoadInt64(&conn.read)
+		call()
+		return atomic.LoadInt64(&conn.read) - before
+	}
+
+	var large string
+	if n := received(func() {
+		if err := client.Call(&large, "test_largeResp"); err != nil {
+			t.Fatal(err)
+		}
+	}); len(large) != 100_000 || n > 10_000 {
+		t.Fatalf("response of %d bytes, received %d bytes", len(large), n)
+	}
+	var numbers []int
+	if n := received(func() {
+		if err := client.Call(&numbers, "stream_numbers", 20_000); err != nil {
+			t.Fatal(err)
+		}
+	}); len(numbers) != 20_000 || n > 50_000 {
+		t.Fatalf("streamed response of %d numbers, received %d bytes", len(numbers), n)
+	}
+	// below MinSize streamed response is sent as one message
+	if err := client.Call(&numbers, "stream_numbers", 3); err != nil || len(numbers) != 3 {
+		t.Fatalf("short streamed response: %v %v", numbers, err)
+	}
+}
@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/VictoriaMetrics/metrics"
+)
+
+// workerPools - bounded numbers of concurrently executed calls of namespaces, calls over the bound wait for one of the
+// running calls to finish. Isolates heavy namespaces (debug, trace) from the rest: they can't take all CPU and db
+// transactions. Methods of nil workerPools do nothing
+type workerPools struct {
+	slots   map[string]chan struct{}
+	waiting map[string]*metrics.Counter
+	running map[string]*metrics.Counter
+}
+
+func newWorkerPools(concurrency map[string]int) *workerPools {
+	p := &workerPools{slots: map[string]chan struct{}{}, waiting: map[string]*metrics.Counter{}, running: map[string]*metrics.Counter{}}
+	for namespace, n := range concurrency {
+		if n <= 0 {
+			continue
+		}
+		p.slots[namespace] = make(chan struct{}, n)
+		p.waiting[namespace] = metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_pool_waiting{namespace=%q}`, namespace))
+		p.running[namespace] = metrics.GetOrCreateCounter(fmt.Sprintf(`rpc_pool_running{namespace=%q}`, namespace))
+	}
+	return p
+}
+
+// acquire - waits for free slot in pool of method namespace, returned func frees it
+func (p *workerPools) acquire(ctx context.Context, method string) (func(), error) {
+	if p == nil {
+		return func() {}, nil
+	}
+	namespace := namespaceOf(method)
+	slots, ok := p.slots[namespace]
+	if !ok {
+		return func() {}, nil
+	}
+	waiting, running := p.waiting[namespace], p.running[namespace]
+	waiting.Inc()
+	defer waiting.Dec()
+	select {
+	case slots <- struct{}{}:
+		running.Inc()
+		return func() {
+			running.Dec()
+			<-slots
+		}, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for free worker of %s namespace: %w", namespace, ctx.Err())
+	}
+}
+
+func namespaceOf(method string) string {
+	if i := strings.Index(method, serviceMethodSeparator); i != -1 {
+		return method[:i]
+	}
+	return method
+}
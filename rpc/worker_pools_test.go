@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNamespaceConcurrency(t *testing.T) {
+	server := newTestServer()
+	defer server.Stop()
+	server.SetNamespaceConcurrency(map[string]int{"test": 1})
+	client := DialInProc(server)
+	defer client.Close()
+
+	sleepDone := make(chan error, 1)
+	go func() { sleepDone <- client.Call(nil, "test_sleep", 500*time.Millisecond) }()
+	time.Sleep(100 * time.Millisecond)
+
+	// the only worker of namespace is busy
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var result echoResult
+	if err := client.CallContext(ctx, &result, "test_echo", "hello", 10, &echoArgs{"world"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected call to wait for worker, got %v", err)
+	}
+	// other namespaces aren't affected
+	var modules map[string]string
+	if err := client.Call(&modules, "rpc_modules"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-sleepDone; err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call(&result, "test_echo", "hello", 10, &echoArgs{"world"}); err != nil {
+		t.Fatal(err)
+	}
+}
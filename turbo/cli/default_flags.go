@@ -85,4 +85,6 @@ var DefaultFlags = []cli.Flag{
 	utils.MinerSigningKeyFileFlag,
 	utils.SentryAddrFlag,
 	HealthCheckFlag,
+	TracingEndpointFlag,
+	TracingSampleRatioFlag,
 }
@@ -154,6 +154,17 @@ var (
 		Name:  "healthcheck",
 		Usage: "Enable grpc health check",
 	}
+
+	TracingEndpointFlag = cli.StringFlag{
+		Name:  "tracing.endpoint",
+		Usage: "Export OpenTelemetry spans of private api calls (continuing traces of rpcdaemon) to OTLP collector (gRPC) at this address, for example: 127.0.0.1:4317",
+	}
+
+	TracingSampleRatioFlag = cli.Float64Flag{
+		Name:  "tracing.sample_ratio",
+		Usage: "Share of private api calls traced when caller doesn't trace them",
+		Value: 1,
+	}
 )
 
 func ApplyFlagsForEthConfig(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -2,6 +2,7 @@
 package cli
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/ledgerwatch/erigon/core"
 	"github.com/ledgerwatch/erigon/internal/debug"
 	"github.com/ledgerwatch/erigon/internal/flags"
+	"github.com/ledgerwatch/erigon/internal/tracing"
 	"github.com/ledgerwatch/erigon/node"
 	"github.com/ledgerwatch/erigon/params"
 
@@ -26,11 +28,27 @@ func MakeApp(action func(*cli.Context), cliFlags []cli.Flag) *cli.App {
 	app := flags.NewApp("", "", "erigon experimental cli")
 	app.Action = action
 	app.Flags = append(cliFlags, debug.Flags...) // debug flags are required
+	var stopTracing func(context.Context) error
 	app.Before = func(ctx *cli.Context) error {
-		return debug.Setup(ctx)
+		if err := debug.Setup(ctx); err != nil {
+			return err
+		}
+		if endpoint := ctx.GlobalString(TracingEndpointFlag.Name); endpoint != "" {
+			var err error
+			stopTracing, err = tracing.Setup(context.Background(), "erigon", endpoint, ctx.GlobalFloat64(TracingSampleRatioFlag.Name))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 	app.After = func(ctx *cli.Context) error {
 		debug.Exit()
+		if stopTracing != nil {
+			if err := stopTracing(context.Background()); err != nil {
+				log.Warn("flushing traces", "err", err)
+			}
+		}
 		return nil
 	}
 	app.Commands = []cli.Command{initCommand}
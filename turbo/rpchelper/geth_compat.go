@@ -0,0 +1,101 @@
+package rpchelper
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon/accounts/abi"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/internal/ethapi"
+	"github.com/ledgerwatch/erigon/rpc"
+)
+
+// GethCompatible is implemented by results which fields are present differently than in responses of geth
+type GethCompatible interface {
+	GethCompatible() interface{}
+}
+
+// GethCompatFormatter - responses of --rpc.gethcompat: codes, messages and data of errors and fields of results as
+// geth sends them, for SDKs parsing them
+type GethCompatFormatter struct{}
+
+func (GethCompatFormatter) FormatResult(method string, result interface{}) interface{} {
+	switch r := result.(type) {
+	case GethCompatible:
+		return r.GethCompatible()
+	case map[string]interface{}: // block with full transactions
+		txs, ok := r["transactions"].([]interface{})
+		if !ok {
+			return result
+		}
+		compat := make(map[string]interface{}, len(r))
+		for k, v := range r {
+			compat[k] = v
+		}
+		compatTxs := make([]interface{}, len(txs))
+		for i, tx := range txs {
+			if c, ok := tx.(GethCompatible); ok {
+				compatTxs[i] = c.GethCompatible()
+			} else {
+				compatTxs[i] = tx
+			}
+		}
+		compat["transactions"] = compatTxs
+		return compat
+	}
+	return result
+}
+
+func (GethCompatFormatter) FormatError(method string, err error) error {
+	var pruned *PrunedError
+	var notFound BlockNotFoundError
+	var nonCanonical NonCanonicalHashError
+	var coded rpc.Error
+	switch {
+	case errors.As(err, &pruned):
+		if pruned.Kind == PrunedHistory {
+			return fmt.Errorf("missing trie node %x (path )", pruned.Root)
+		}
+		return errors.New(pruned.Error())
+	case errors.As(err, &notFound):
+		return errors.New("header for hash not found")
+	case errors.As(err, &nonCanonical):
+		return errors.New("hash is not currently canonical")
+	case errors.As(err, &coded) && coded.ErrorCode() == 3:
+		var data rpc.DataError
+		if !errors.As(err, &data) {
+			return err
+		}
+		var hexData string
+		switch d := data.ErrorData().(type) {
+		case string:
+			hexData = d
+		case *ethapi.RevertErrorData: // geth doesn't decode custom errors and sends only hex
+			hexData = d.Data
+		default:
+			return err
+		}
+		revert, _ := hexutil.Decode(hexData)
+		if reason, unpackErr := abi.UnpackRevert(revert); unpackErr == nil {
+			return &revertError{message: "execution reverted: " + reason, data: hexData}
+		}
+		if reason, ok := ethapi.PanicReason(revert); ok {
+			return &revertError{message: "execution reverted: " + reason, data: hexData}
+		}
+		return &revertError{message: vm.ErrExecutionReverted.Error(), data: hexData}
+	case errors.Is(err, vm.ErrExecutionReverted):
+		// geth sends revert without data also with code 3
+		return &revertError{message: vm.ErrExecutionReverted.Error(), data: "0x"}
+	}
+	return err
+}
+
+// revertError - revert as geth sends it: code 3, hex of revert data
+type revertError struct{ message, data string }
+
+func (e *revertError) Error() string { return e.message }
+
+func (e *revertError) ErrorCode() int { return 3 }
+
+func (e *revertError) ErrorData() interface{} { return e.data }
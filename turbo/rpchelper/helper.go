@@ -12,23 +12,36 @@ import (
 	"github.com/ledgerwatch/erigon/core/state"
 	"github.com/ledgerwatch/erigon/core/types/accounts"
 	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
 	"github.com/ledgerwatch/erigon/rpc"
 	"github.com/ledgerwatch/erigon/turbo/adapter"
 )
 
-// unable to decode supplied params, or an invalid number of parameters
-type nonCanonocalHashError struct{ hash common.Hash }
+// NonCanonicalHashError - block of EIP-1898 blockHash parameter is not canonical: client asked for canonical one, or for
+// state of the block, which is kept only for canonical blocks. Code is "invalid input" recommended by EIP-1898
+type NonCanonicalHashError struct{ Hash common.Hash }
 
-func (e nonCanonocalHashError) ErrorCode() int { return -32603 }
+func (e NonCanonicalHashError) ErrorCode() int { return -32000 }
 
-func (e nonCanonocalHashError) Error() string {
-	return fmt.Sprintf("hash %x is not currently canonical", e.hash)
+func (e NonCanonicalHashError) Error() string {
+	return fmt.Sprintf("hash %x is not currently canonical", e.Hash)
+}
+
+// BlockNotFoundError - unknown block of EIP-1898 blockHash parameter. Code is "resource not found" recommended by EIP-1898
+type BlockNotFoundError struct{ Hash common.Hash }
+
+func (e BlockNotFoundError) ErrorCode() int { return -32001 }
+
+func (e BlockNotFoundError) Error() string {
+	return fmt.Sprintf("block %x not found", e.Hash)
 }
 
 func GetBlockNumber(blockNrOrHash rpc.BlockNumberOrHash, tx kv.Tx, filters *filters.Filters) (uint64, common.Hash, bool, error) {
 	return _GetBlockNumber(blockNrOrHash.RequireCanonical, blockNrOrHash, tx, filters)
 }
 
+// GetCanonicalBlockNumber - same as GetBlockNumber, but hash of non-canonical block is rejected even without
+// requireCanonical, because state and receipts are kept only for canonical blocks
 func GetCanonicalBlockNumber(blockNrOrHash rpc.BlockNumberOrHash, tx kv.Tx, filters *filters.Filters) (uint64, common.Hash, bool, error) {
 	return _GetBlockNumber(true, blockNrOrHash, tx, filters)
 }
@@ -46,6 +59,13 @@ func _GetBlockNumber(requireCanonical bool, blockNrOrHash rpc.BlockNumberOrHash,
 			blockNumber = latestBlockNumber
 		} else if number == rpc.EarliestBlockNumber {
 			blockNumber = 0
+		} else if number == rpc.SafeBlockNumber || number == rpc.FinalizedBlockNumber {
+			if blockNumber, err = ForkChoiceBlockNumber(number, filters); err != nil {
+				return 0, common.Hash{}, false, err
+			}
+			if blockNumber > latestBlockNumber {
+				return 0, common.Hash{}, false, fmt.Errorf("block %d is not executed yet, latest block %d", blockNumber, latestBlockNumber)
+			}
 		} else if number == rpc.PendingBlockNumber {
 			pendingBlock := filters.LastPendingBlock()
 			if pendingBlock == nil {
@@ -66,7 +86,7 @@ func _GetBlockNumber(requireCanonical bool, blockNrOrHash rpc.BlockNumberOrHash,
 	} else {
 		number := rawdb.ReadHeaderNumber(tx, hash)
 		if number == nil {
-			return 0, common.Hash{}, false, fmt.Errorf("block %x not found", hash)
+			return 0, common.Hash{}, false, BlockNotFoundError{hash}
 		}
 		blockNumber = *number
 
@@ -75,12 +95,38 @@ func _GetBlockNumber(requireCanonical bool, blockNrOrHash rpc.BlockNumberOrHash,
 			return 0, common.Hash{}, false, err
 		}
 		if requireCanonical && ch != hash {
-			return 0, common.Hash{}, false, nonCanonocalHashError{hash}
+			return 0, common.Hash{}, false, NonCanonicalHashError{hash}
 		}
 	}
 	return blockNumber, hash, blockNumber == latestBlockNumber, nil
 }
 
+// ForkChoiceBlockNumber - number of "safe" or "finalized" block of the latest fork choice of consensus layer, Erigon
+// notifies filters about it. Error if there was no such fork choice yet (before the merge)
+func ForkChoiceBlockNumber(number rpc.BlockNumber, filters *filters.Filters) (uint64, error) {
+	forkChoice := filters.ForkChoice()
+	var block *privateapi.BlockRef
+	var tag string
+	switch number {
+	case rpc.SafeBlockNumber:
+		tag = "safe"
+		if forkChoice != nil {
+			block = forkChoice.Safe
+		}
+	case rpc.FinalizedBlockNumber:
+		tag = "finalized"
+		if forkChoice != nil {
+			block = forkChoice.Finalized
+		}
+	default:
+		return 0, fmt.Errorf("%d is not a fork choice block tag", number)
+	}
+	if block == nil {
+		return 0, fmt.Errorf("%s block not found", tag)
+	}
+	return block.Number, nil
+}
+
 func GetAccount(tx kv.Tx, blockNumber uint64, address common.Address) (*accounts.Account, error) {
 	reader := adapter.NewStateReader(tx, blockNumber)
 	return reader.ReadAccountData(address)
@@ -99,6 +145,9 @@ func CreateStateReader(ctx context.Context, tx kv.Tx, blockNrOrHash rpc.BlockNum
 		}
 		stateReader = state.NewCachedReader2(cacheView, tx)
 	} else {
+		if err = CheckPruned(tx, PrunedHistory, blockNumber); err != nil {
+			return nil, err
+		}
 		stateReader = state.NewPlainState(tx, blockNumber)
 	}
 	return stateReader, nil
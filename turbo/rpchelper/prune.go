@@ -0,0 +1,117 @@
+package rpchelper
+
+import (
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/common/hexutil"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/ethdb/prune"
+)
+
+// Kinds of data removed by pruning, see prune.Mode
+const (
+	PrunedHistory    = "history"    // changesets and history indices: state of old blocks, receipts not stored in db
+	PrunedReceipts   = "receipts"   // receipts, logs and their indices
+	PrunedTxIndex    = "txIndex"    // lookup of transactions by hash
+	PrunedCallTraces = "callTraces" // indices of trace_filter
+)
+
+// PrunedError - data asked for is pruned by the node, unlike not existing data, which is null result. It can be
+// requested from archive node. Code is the one of geth for pruned history
+type PrunedError struct {
+	Kind     string
+	Block    uint64
+	Hash     common.Hash // of transaction not found by hash in pruned index, Block is unknown then
+	Earliest uint64      // the first block of which the node keeps data of this kind
+	Root     common.Hash // state root of the block of pruned history, if its header is known
+}
+
+func (e *PrunedError) ErrorCode() int { return 4444 }
+
+func (e *PrunedError) Error() string {
+	if e.Hash != (common.Hash{}) {
+		return fmt.Sprintf("transaction %x is not found in blocks indexed since %d, the older ones are pruned", e.Hash, e.Earliest)
+	}
+	return fmt.Sprintf("%s of block %d is pruned, the earliest available block is %d", e.Kind, e.Block, e.Earliest)
+}
+
+func (e *PrunedError) ErrorData() interface{} {
+	return map[string]interface{}{"kind": e.Kind, "earliestBlock": hexutil.Uint64(e.Earliest)}
+}
+
+// pruneStages - stage whose progress data of each kind is pruned relative to
+var pruneStages = map[string]stages.SyncStage{
+	PrunedHistory:    stages.Execution,
+	PrunedReceipts:   stages.Execution,
+	PrunedTxIndex:    stages.TxLookup,
+	PrunedCallTraces: stages.CallTraces,
+}
+
+func blockAmount(pm prune.Mode, kind string) prune.BlockAmount {
+	switch kind {
+	case PrunedHistory:
+		return pm.History
+	case PrunedReceipts:
+		return pm.Receipts
+	case PrunedTxIndex:
+		return pm.TxIndex
+	default:
+		return pm.CallTraces
+	}
+}
+
+// EarliestAvailableBlocks - prune mode of the node and the first block of which it keeps data of each kind, 0 - nothing
+// is pruned. Pruning runs after sync cycles, so data of some earlier blocks may be still there
+func EarliestAvailableBlocks(tx kv.Tx) (prune.Mode, map[string]uint64, error) {
+	pm, err := prune.Get(tx)
+	if err != nil {
+		return pm, nil, err
+	}
+	earliest := make(map[string]uint64, len(pruneStages))
+	for kind := range pruneStages {
+		if earliest[kind], err = earliestAvailable(tx, kind, blockAmount(pm, kind)); err != nil {
+			return pm, nil, err
+		}
+	}
+	return pm, earliest, nil
+}
+
+// CheckPruned - PrunedError if data of the kind about the block is pruned
+func CheckPruned(tx kv.Tx, kind string, block uint64) error {
+	pm, err := prune.Get(tx)
+	if err != nil {
+		return err
+	}
+	earliest, err := earliestAvailable(tx, kind, blockAmount(pm, kind))
+	if err != nil {
+		return err
+	}
+	if block < earliest {
+		err := &PrunedError{Kind: kind, Block: block, Earliest: earliest}
+		if kind == PrunedHistory {
+			if header := rawdb.ReadHeaderByNumber(tx, block); header != nil {
+				err.Root = header.Root
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// earliestAvailable - stages keep data of blocks after amount.PruneTo of their progress
+func earliestAvailable(tx kv.Tx, kind string, amount prune.BlockAmount) (uint64, error) {
+	if !amount.Enabled() {
+		return 0, nil
+	}
+	head, err := stages.GetStageProgress(tx, pruneStages[kind])
+	if err != nil {
+		return 0, err
+	}
+	if pruneTo := amount.PruneTo(head); pruneTo > 0 {
+		return pruneTo + 1, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,391 @@
+package snapshotsync
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	libcommon "github.com/ledgerwatch/erigon-lib/common"
+	"github.com/ledgerwatch/erigon-lib/compress"
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/recsplit"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/rlp"
+)
+
+// Block snapshots - frozen blocks of canonical chain in read-only segment files, named by block range in thousands:
+//   v1-000000-000500-headers.seg      - header RLP of every block
+//   v1-000000-000500-bodies.seg       - snapshotBody of every block
+//   v1-000000-000500-transactions.seg - sender address and transaction (as in kv.EthTx) of every transaction
+//   v1-000000-000500-headers.idx, v1-000000-000500-bodies.idx - offsets of words of block in .seg files by its number
+//   v1-000000-000500-transactions.idx - offsets of transaction words by ordinal number of transaction in segment
+const (
+	SnapshotHeaders      = "headers"
+	SnapshotBodies       = "bodies"
+	SnapshotTransactions = "transactions"
+)
+
+// DefaultSegmentSize - blocks in one segment, segments may be smaller, but their bounds are multiples of 1000
+const DefaultSegmentSize = 500_000
+
+var segmentFileRe = regexp.MustCompile(`^v1-(\d{6})-(\d{6})-headers\.seg$`)
+
+// SegmentFileName - name of file of segment of blocks [from, to), ext - "seg" or "idx"
+func SegmentFileName(from, to uint64, kind string, ext string) string {
+	return fmt.Sprintf("v1-%06d-%06d-%s.%s", from/1000, to/1000, kind, ext)
+}
+
+// snapshotMinPatternScore - patterns of compression dictionary must save at least this many bits in total
+const snapshotMinPatternScore = 1024
+
+// snapshotBody - word of bodies segment, transactions of the block are TxAmount words of transactions segment from
+// the one with ordinal BaseTxOrdinal
+type snapshotBody struct {
+	BaseTxOrdinal uint64
+	TxAmount      uint32
+	Uncles        []*types.Header
+}
+
+// BlockSnapshots - segments of blocks from 0 to BlocksAvailable, opened read-only. Safe for concurrent use
+type BlockSnapshots struct {
+	dir      string
+	segments []*blockSegment
+}
+
+type blockSegment struct {
+	from, to                      uint64
+	headers, bodies, txs          *compress.Decompressor
+	headersIdx, bodiesIdx, txsIdx *recsplit.Index
+}
+
+// OpenBlockSnapshots - opens segments of dir, only ones continuing each other from block 0 are used. Segments without
+// all their files (for example, still downloaded) are ignored
+func OpenBlockSnapshots(dir string) (*BlockSnapshots, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type segmentRange struct{ from, to uint64 }
+	var ranges []segmentRange
+	for _, f := range files {
+		m := segmentFileRe.FindStringSubmatch(f.Name())
+		if m == nil {
+			continue
+		}
+		from, _ := strconv.ParseUint(m[1], 10, 64)
+		to, _ := strconv.ParseUint(m[2], 10, 64)
+		if from < to {
+			ranges = append(ranges, segmentRange{from * 1000, to * 1000})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].from < ranges[j].from })
+
+	s := &BlockSnapshots{dir: dir}
+	for _, r := range ranges {
+		if r.from != s.BlocksAvailable() {
+			continue // gap or overlap
+		}
+		seg, err := openBlockSegment(dir, r.from, r.to)
+		if err != nil {
+			if os.IsNotExist(err) {
+				break
+			}
+			s.Close()
+			return nil, err
+		}
+		s.segments = append(s.segments, seg)
+	}
+	return s, nil
+}
+
+func openBlockSegment(dir string, from, to uint64) (_ *blockSegment, err error) {
+	seg := &blockSegment{from: from, to: to}
+	defer func() {
+		if err != nil {
+			seg.close()
+		}
+	}()
+	path := func(kind, ext string) string { return filepath.Join(dir, SegmentFileName(from, to, kind, ext)) }
+	for _, kind := range []string{SnapshotHeaders, SnapshotBodies, SnapshotTransactions} {
+		for _, ext := range []string{"seg", "idx"} {
+			if _, err = os.Stat(path(kind, ext)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if seg.headers, err = compress.NewDecompressor(path(SnapshotHeaders, "seg")); err != nil {
+		return nil, err
+	}
+	if seg.bodies, err = compress.NewDecompressor(path(SnapshotBodies, "seg")); err != nil {
+		return nil, err
+	}
+	if seg.txs, err = compress.NewDecompressor(path(SnapshotTransactions, "seg")); err != nil {
+		return nil, err
+	}
+	if seg.headersIdx, err = recsplit.NewIndex(path(SnapshotHeaders, "idx")); err != nil {
+		return nil, err
+	}
+	if seg.bodiesIdx, err = recsplit.NewIndex(path(SnapshotBodies, "idx")); err != nil {
+		return nil, err
+	}
+	if seg.txsIdx, err = recsplit.NewIndex(path(SnapshotTransactions, "idx")); err != nil {
+		return nil, err
+	}
+	return seg, nil
+}
+
+func (seg *blockSegment) close() {
+	for _, d := range []*compress.Decompressor{seg.headers, seg.bodies, seg.txs} {
+		if d != nil {
+			d.Close()
+		}
+	}
+	for _, idx := range []*recsplit.Index{seg.headersIdx, seg.bodiesIdx, seg.txsIdx} {
+		if idx != nil {
+			idx.Close()
+		}
+	}
+}
+
+// Close - unmaps all files, nothing can be read after it
+func (s *BlockSnapshots) Close() {
+	for _, seg := range s.segments {
+		seg.close()
+	}
+	s.segments = nil
+}
+
+// BlocksAvailable - blocks [0, BlocksAvailable) are frozen in snapshots
+func (s *BlockSnapshots) BlocksAvailable() uint64 {
+	if len(s.segments) == 0 {
+		return 0
+	}
+	return s.segments[len(s.segments)-1].to
+}
+
+func (s *BlockSnapshots) segment(number uint64) *blockSegment {
+	i := sort.Search(len(s.segments), func(i int) bool { return s.segments[i].to > number })
+	if i == len(s.segments) {
+		return nil
+	}
+	return s.segments[i]
+}
+
+// Header - canonical header of frozen block, nil if the block is not frozen
+func (s *BlockSnapshots) Header(number uint64) (*types.Header, error) {
+	seg := s.segment(number)
+	if seg == nil {
+		return nil, nil
+	}
+	return seg.header(number)
+}
+
+func (seg *blockSegment) header(number uint64) (*types.Header, error) {
+	g := seg.headers.MakeGetter()
+	g.Reset(seg.headersIdx.Lookup2(number - seg.from))
+	word, _ := g.Next(nil)
+	h := &types.Header{}
+	if err := rlp.DecodeBytes(word, h); err != nil {
+		return nil, fmt.Errorf("header %d in snapshot: %w", number, err)
+	}
+	return h, nil
+}
+
+// BlockWithSenders - canonical block of frozen block with senders of its transactions, nil if the block is not frozen
+func (s *BlockSnapshots) BlockWithSenders(number uint64) (*types.Block, []common.Address, error) {
+	seg := s.segment(number)
+	if seg == nil {
+		return nil, nil, nil
+	}
+	header, err := seg.header(number)
+	if err != nil {
+		return nil, nil, err
+	}
+	g := seg.bodies.MakeGetter()
+	g.Reset(seg.bodiesIdx.Lookup2(number - seg.from))
+	word, _ := g.Next(nil)
+	var body snapshotBody
+	if err = rlp.DecodeBytes(word, &body); err != nil {
+		return nil, nil, fmt.Errorf("body %d in snapshot: %w", number, err)
+	}
+
+	txs := make([]types.Transaction, body.TxAmount)
+	senders := make([]common.Address, body.TxAmount)
+	g = seg.txs.MakeGetter()
+	if body.TxAmount > 0 {
+		g.Reset(seg.txsIdx.Lookup2(body.BaseTxOrdinal))
+	}
+	reader := bytes.NewReader(nil)
+	stream := rlp.NewStream(reader, 0)
+	for i := range txs {
+		if !g.HasNext() {
+			return nil, nil, fmt.Errorf("transactions of block %d in snapshot: only %d of %d", number, i, body.TxAmount)
+		}
+		word, _ = g.Next(word[:0])
+		if len(word) < common.AddressLength {
+			return nil, nil, fmt.Errorf("transaction %d of block %d in snapshot: too short", i, number)
+		}
+		senders[i] = common.BytesToAddress(word[:common.AddressLength])
+		reader.Reset(word[common.AddressLength:])
+		stream.Reset(reader, 0)
+		if txs[i], err = types.DecodeTransaction(stream); err != nil {
+			return nil, nil, fmt.Errorf("transaction %d of block %d in snapshot: %w", i, number, err)
+		}
+	}
+	block := types.NewBlockFromStorage(header.Hash(), header, txs, body.Uncles)
+	block.SendersToTxs(senders)
+	return block, senders, nil
+}
+
+// DumpBlocks - writes segment of canonical blocks [from, to) of tx to dir, tmpDir - for temporary files
+func DumpBlocks(ctx context.Context, tx kv.Tx, from, to uint64, dir, tmpDir string) error {
+	if from%1000 != 0 || to%1000 != 0 || from >= to {
+		return fmt.Errorf("segment range [%d, %d) is not multiple of 1000 blocks", from, to)
+	}
+	path := func(kind, ext string) string { return filepath.Join(dir, SegmentFileName(from, to, kind, ext)) }
+	headers, err := compress.NewCompressor("Snapshot headers", path(SnapshotHeaders, "seg"), tmpDir, snapshotMinPatternScore)
+	if err != nil {
+		return err
+	}
+	bodies, err := compress.NewCompressor("Snapshot bodies", path(SnapshotBodies, "seg"), tmpDir, snapshotMinPatternScore)
+	if err != nil {
+		return err
+	}
+	txs, err := compress.NewCompressor("Snapshot transactions", path(SnapshotTransactions, "seg"), tmpDir, snapshotMinPatternScore)
+	if err != nil {
+		return err
+	}
+
+	var txCount uint64
+	var buf bytes.Buffer
+	for number := from; number < to; number++ {
+		if common.IsCanceled(ctx) {
+			return libcommon.ErrStopped
+		}
+		hash, err := rawdb.ReadCanonicalHash(tx, number)
+		if err != nil {
+			return err
+		}
+		headerRLP := rawdb.ReadHeaderRLP(tx, hash, number)
+		if len(headerRLP) == 0 {
+			return fmt.Errorf("header %d not found", number)
+		}
+		if err = headers.AddWord(headerRLP); err != nil {
+			return err
+		}
+		block, senders, err := rawdb.ReadBlockWithSenders(tx, hash, number)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("body %d not found", number)
+		}
+		if len(senders) != len(block.Transactions()) {
+			return fmt.Errorf("senders of block %d not found", number)
+		}
+		word, err := rlp.EncodeToBytes(&snapshotBody{BaseTxOrdinal: txCount, TxAmount: uint32(len(senders)), Uncles: block.Uncles()})
+		if err != nil {
+			return err
+		}
+		if err = bodies.AddWord(word); err != nil {
+			return err
+		}
+		for i, txn := range block.Transactions() {
+			buf.Reset()
+			buf.Write(senders[i][:])
+			if err = rlp.Encode(&buf, txn); err != nil {
+				return err
+			}
+			if err = txs.AddWord(buf.Bytes()); err != nil {
+				return err
+			}
+			txCount++
+		}
+	}
+	for _, c := range []*compress.Compressor{headers, bodies, txs} {
+		if err = c.Compress(); err != nil {
+			return err
+		}
+	}
+	if err = buildSegmentIndex(path(SnapshotHeaders, "seg"), path(SnapshotHeaders, "idx"), from, tmpDir); err != nil {
+		return err
+	}
+	if err = buildSegmentIndex(path(SnapshotBodies, "seg"), path(SnapshotBodies, "idx"), from, tmpDir); err != nil {
+		return err
+	}
+	return buildSegmentIndex(path(SnapshotTransactions, "seg"), path(SnapshotTransactions, "idx"), 0, tmpDir)
+}
+
+// buildSegmentIndex - index of offsets of words of segment by their ordinal numbers starting with first
+func buildSegmentIndex(segFile, idxFile string, first uint64, tmpDir string) error {
+	count, err := wordCount(segFile)
+	if err != nil {
+		return err
+	}
+	rs, err := recsplit.NewRecSplit(recsplit.RecSplitArgs{
+		KeyCount:   count,
+		BucketSize: 2000,
+		LeafSize:   8,
+		TmpDir:     tmpDir,
+		IndexFile:  idxFile,
+		Enums:      true,
+		StartSeed: []uint64{0x106393c187cae21a, 0x6453cec3f7376937, 0x643e521ddbd2be98, 0x3740c6412f6572cb, 0x717d47562f1ce470, 0x4cd6eb4c63befb7c, 0x9bfd8c5e18c8da73,
+			0x082f20e10092a9a3, 0x2ada2ce68d21defc, 0xe33cb4f3e7c6466b, 0x3980be458c509c59, 0xc466fd9584828e8c, 0x45f0aabe1a61ede6, 0xf6e7b8b33ad9b98d,
+			0x4ef95e25f4b4983d, 0x81175195173b92d3, 0x4e50927d8dd15978, 0x1ea2099d1fafae7f, 0x425c8a06fbaaa815, 0xcd4216006c74052a},
+	})
+	if err != nil {
+		return err
+	}
+	var key [8]byte
+	if err = forEachWord(segFile, func(i int, _ []byte, offset uint64) error {
+		binary.BigEndian.PutUint64(key[:], first+uint64(i))
+		return rs.AddKey(key[:], offset)
+	}); err != nil {
+		return err
+	}
+	if err = rs.Build(); err != nil {
+		return err
+	}
+	if rs.Collision() {
+		return fmt.Errorf("collision building %s, try again", idxFile)
+	}
+	return nil
+}
+
+func forEachWord(segFile string, f func(i int, word []byte, offset uint64) error) error {
+	d, err := compress.NewDecompressor(segFile)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	g := d.MakeGetter()
+	var word []byte
+	var offset uint64
+	for i := 0; g.HasNext(); i++ {
+		var next uint64
+		word, next = g.Next(word[:0])
+		if err = f(i, word, offset); err != nil {
+			return err
+		}
+		offset = next
+	}
+	return nil
+}
+
+func wordCount(segFile string) (int, error) {
+	count := 0
+	err := forEachWord(segFile, func(int, []byte, uint64) error {
+		count++
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,80 @@
+package snapshotsync
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/kv/memdb"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockSnapshots(t *testing.T) {
+	_, tx := memdb.NewTestTx(t)
+	parent := common.Hash{}
+	blocks := make([]*types.Block, 2000)
+	for i := range blocks {
+		header := &types.Header{ParentHash: parent, Number: big.NewInt(int64(i)), Difficulty: big.NewInt(1), GasLimit: 8_000_000, Extra: []byte("snapshot")}
+		var txs []types.Transaction
+		var senders []common.Address
+		for j := 0; j < i%3; j++ {
+			txs = append(txs, types.NewTransaction(uint64(j), common.Address{byte(i)}, uint256.NewInt(uint64(i)), 21000, uint256.NewInt(1), nil))
+			senders = append(senders, common.Address{byte(j), byte(i)})
+		}
+		var uncles []*types.Header
+		if i%100 == 7 {
+			uncles = []*types.Header{{Number: big.NewInt(int64(i - 1)), Difficulty: big.NewInt(2), Extra: []byte("uncle")}}
+		}
+		block := types.NewBlock(header, txs, uncles, nil)
+		blocks[i] = block
+		parent = block.Hash()
+		rawdb.WriteHeader(tx, block.Header())
+		require.NoError(t, rawdb.WriteCanonicalHash(tx, block.Hash(), block.NumberU64()))
+		require.NoError(t, rawdb.WriteBody(tx, block.Hash(), block.NumberU64(), block.Body()))
+		require.NoError(t, rawdb.WriteSenders(tx, block.Hash(), block.NumberU64(), senders))
+	}
+
+	dir, tmpDir := t.TempDir(), t.TempDir()
+	require.Error(t, DumpBlocks(context.Background(), tx, 0, 1500, dir, tmpDir))
+	require.NoError(t, DumpBlocks(context.Background(), tx, 0, 1000, dir, tmpDir))
+	require.NoError(t, DumpBlocks(context.Background(), tx, 1000, 2000, dir, tmpDir))
+
+	snapshots, err := OpenBlockSnapshots(dir)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2000), snapshots.BlocksAvailable())
+	for _, n := range []uint64{0, 1, 2, 7, 999, 1000, 1107, 1998, 1999} {
+		header, err := snapshots.Header(n)
+		require.NoError(t, err)
+		require.Equal(t, blocks[n].Hash(), header.Hash())
+
+		block, senders, err := snapshots.BlockWithSenders(n)
+		require.NoError(t, err)
+		require.Equal(t, blocks[n].Hash(), block.Hash())
+		require.Equal(t, blocks[n].Transactions().Len(), block.Transactions().Len())
+		for i, txn := range block.Transactions() {
+			require.Equal(t, blocks[n].Transactions()[i].Hash(), txn.Hash())
+			require.Equal(t, common.Address{byte(i), byte(n)}, senders[i])
+			sender, ok := txn.GetSender()
+			require.True(t, ok)
+			require.Equal(t, senders[i], sender)
+		}
+		require.Equal(t, blocks[n].UncleHash(), block.UncleHash())
+	}
+	header, err := snapshots.Header(2000)
+	require.NoError(t, err)
+	require.Nil(t, header)
+	snapshots.Close()
+
+	// segment without all files is not used, neither are segments after it
+	require.NoError(t, os.Remove(filepath.Join(dir, SegmentFileName(0, 1000, SnapshotTransactions, "idx"))))
+	snapshots, err = OpenBlockSnapshots(dir)
+	require.NoError(t, err)
+	defer snapshots.Close()
+	require.Equal(t, uint64(0), snapshots.BlocksAvailable())
+}
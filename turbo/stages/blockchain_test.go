@@ -26,6 +26,7 @@ import (
 	"testing"
 
 	"github.com/holiman/uint256"
+	"github.com/ledgerwatch/erigon-lib/gointerfaces"
 	"github.com/ledgerwatch/erigon-lib/kv"
 	"github.com/ledgerwatch/erigon/ethdb/bitmapdb"
 	"github.com/ledgerwatch/erigon/ethdb/olddb"
@@ -43,6 +44,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/types"
 	"github.com/ledgerwatch/erigon/core/vm"
 	"github.com/ledgerwatch/erigon/crypto"
+	"github.com/ledgerwatch/erigon/ethdb/privateapi"
 	"github.com/ledgerwatch/erigon/params"
 	"github.com/ledgerwatch/erigon/turbo/stages"
 	"github.com/ledgerwatch/log/v3"
@@ -509,6 +511,59 @@ func TestChainTxReorgs(t *testing.T) {
 	}
 }
 
+func TestReorgNotifications(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  core.GenesisAlloc{addr: {Balance: big.NewInt(1000000000000000)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	m := stages.MockWithGenesis(t, gspec, key)
+	m2 := stages.MockWithGenesis(t, gspec, key)
+	defer m2.DB.Close()
+
+	// init code of contract emitting a log: PUSH1 0 PUSH1 0 LOG0 STOP
+	logging, _ := types.SignTx(types.NewContractCreation(0, uint256.NewInt(0), 100000, uint256.NewInt(1), []byte{0x60, 0x00, 0x60, 0x00, 0xa0, 0x00}), *signer, key)
+	chain, err := core.GenerateChain(m.ChainConfig, m.Genesis, m.Engine, m.DB, 3, func(i int, gen *core.BlockGen) {
+		switch i {
+		case 1:
+			gen.AddTx(logging)
+		case 2:
+			gen.OffsetTime(9) // weaker chain
+		}
+	}, false /* intemediateHashes */)
+	require.NoError(t, err)
+	require.NoError(t, m.InsertChain(chain))
+
+	logs, cleanLogs := m.Notifications.Events.AddLogsSubscription()
+	defer cleanLogs()
+	m.Notifications.Events.EmptyLogSubsctiption(false)
+	reorgs, cleanReorgs := m.Notifications.Events.AddReorgSubscription()
+	defer cleanReorgs()
+
+	// the same first block, then a heavier chain without logs
+	fork, err := core.GenerateChain(m2.ChainConfig, m2.Genesis, m2.Engine, m2.DB, 5, func(int, *core.BlockGen) {}, false /* intemediateHashes */)
+	require.NoError(t, err)
+	require.Equal(t, chain.Blocks[0].Hash(), fork.Blocks[0].Hash())
+	require.NoError(t, m.InsertChain(fork))
+
+	reorg := <-reorgs
+	require.Equal(t, privateapi.Reorg{
+		OldHead:        privateapi.BlockRef{Number: 3, Hash: chain.TopBlock.Hash()},
+		NewHead:        privateapi.BlockRef{Number: 5, Hash: fork.TopBlock.Hash()},
+		CommonAncestor: privateapi.BlockRef{Number: 1, Hash: fork.Blocks[0].Hash()},
+	}, *reorg)
+	removed := <-logs
+	require.Len(t, removed, 1)
+	require.True(t, removed[0].Removed)
+	require.Equal(t, uint64(2), removed[0].BlockNumber)
+	require.Equal(t, chain.Blocks[1].Hash(), common.Hash(gointerfaces.ConvertH256ToHash(removed[0].BlockHash)))
+	require.Equal(t, logging.Hash(), common.Hash(gointerfaces.ConvertH256ToHash(removed[0].TransactionHash)))
+}
+
 // Tests if the canonical block can be fetched from the database during chain insertion.
 func TestCanonicalBlockRetrieval(t *testing.T) {
 	m := newCanonical(t, 0)
@@ -23,30 +23,38 @@ import (
 	"github.com/ledgerwatch/log/v3"
 )
 
-const callTimeout = 5 * time.Minute
+// DefaultCallTimeout - EVM execution of calls is aborted after it
+const DefaultCallTimeout = 5 * time.Minute
 
 func DoCall(
 	ctx context.Context,
 	args ethapi.CallArgs,
 	tx kv.Tx, blockNrOrHash rpc.BlockNumberOrHash,
 	block *types.Block, overrides *ethapi.StateOverrides,
-	gasCap uint64,
+	gasCap uint64, timeout time.Duration,
 	chainConfig *params.ChainConfig,
 	filters *filters.Filters,
 	stateCache kvcache.Cache,
 	contractHasTEVM func(hash common.Hash) (bool, error),
 ) (*core.ExecutionResult, error) {
-	// todo: Pending state is only known by the miner
-	/*
-		if blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber {
-			block, state, _ := b.eth.miner.Pending()
-			return state, block.Header(), nil
-		}
-	*/
 	stateReader, err := rpchelper.CreateStateReader(ctx, tx, blockNrOrHash, filters, stateCache)
 	if err != nil {
 		return nil, err
 	}
+	return DoCallWithState(ctx, args, tx, stateReader, blockNrOrHash.RequireCanonical, block, overrides, gasCap, timeout, chainConfig, contractHasTEVM)
+}
+
+// DoCallWithState - DoCall on top of given state, for example the one of pending block. Execution is aborted after
+// timeout, 0 - never
+func DoCallWithState(
+	ctx context.Context,
+	args ethapi.CallArgs,
+	tx kv.Tx, stateReader state.StateReader, requireCanonical bool,
+	block *types.Block, overrides *ethapi.StateOverrides,
+	gasCap uint64, timeout time.Duration,
+	chainConfig *params.ChainConfig,
+	contractHasTEVM func(hash common.Hash) (bool, error),
+) (*core.ExecutionResult, error) {
 	state := state.New(stateReader)
 
 	header := block.Header()
@@ -62,8 +70,8 @@ func DoCall(
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
 	var cancel context.CancelFunc
-	if callTimeout > 0 {
-		ctx, cancel = context.WithTimeout(ctx, callTimeout)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
 	} else {
 		ctx, cancel = context.WithCancel(ctx)
 	}
@@ -85,7 +93,7 @@ func DoCall(
 	if err != nil {
 		return nil, err
 	}
-	blockCtx, txCtx := GetEvmContext(msg, header, blockNrOrHash.RequireCanonical, tx, contractHasTEVM)
+	blockCtx, txCtx := GetEvmContext(msg, header, requireCanonical, tx, contractHasTEVM)
 
 	evm := vm.NewEVM(blockCtx, txCtx, state, chainConfig, vm.Config{NoBaseFee: true})
 
@@ -104,7 +112,7 @@ func DoCall(
 
 	// If the timer caused an abort, return an appropriate error message
 	if evm.Cancelled() {
-		return nil, fmt.Errorf("execution aborted (timeout = %v)", callTimeout)
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
 	}
 	return result, nil
 }
@@ -0,0 +1,64 @@
+package transactions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ledgerwatch/erigon-lib/kv"
+	"github.com/ledgerwatch/erigon-lib/kv/kvcache"
+	"github.com/ledgerwatch/erigon/common"
+	"github.com/ledgerwatch/erigon/consensus/ethash"
+	"github.com/ledgerwatch/erigon/core"
+	"github.com/ledgerwatch/erigon/core/rawdb"
+	"github.com/ledgerwatch/erigon/core/state"
+	"github.com/ledgerwatch/erigon/core/types"
+	"github.com/ledgerwatch/erigon/core/vm"
+	"github.com/ledgerwatch/erigon/eth/stagedsync/stages"
+	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/shards"
+)
+
+// PendingStateReader - state after executing transactions of pending block on top of the latest state. Returns nil if
+// block isn't built on the latest executed block: it's stale, miner builds a new one soon
+func PendingStateReader(ctx context.Context, tx kv.Tx, block *types.Block, chainConfig *params.ChainConfig, stateCache kvcache.Cache, contractHasTEVM func(hash common.Hash) (bool, error)) (state.StateReader, error) {
+	latest, err := stages.GetStageProgress(tx, stages.Execution)
+	if err != nil {
+		return nil, fmt.Errorf("getting latest block number: %w", err)
+	}
+	if block.NumberU64() != latest+1 {
+		return nil, nil
+	}
+	latestHash, err := rawdb.ReadCanonicalHash(tx, latest)
+	if err != nil {
+		return nil, err
+	}
+	if block.ParentHash() != latestHash {
+		return nil, nil
+	}
+	cacheView, err := stateCache.View(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// changes of pending transactions stay in memory, reads of other state fall through to the latest one
+	changes := shards.NewStateCache(32, 0)
+	reader := state.NewCachedReader(state.NewCachedReader2(cacheView, tx), changes)
+	writer := state.NewCachedWriter(state.NewNoopWriter(), changes)
+	ibs := state.New(reader)
+	getHeader := func(hash common.Hash, number uint64) *types.Header {
+		return rawdb.ReadHeader(tx, hash, number)
+	}
+	header := block.Header()
+	usedGas := new(uint64)
+	gp := new(core.GasPool).AddGas(block.GasLimit())
+	for i, txn := range block.Transactions() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ibs.Prepare(txn.Hash(), block.Hash(), i)
+		if _, _, err := core.ApplyTransaction(chainConfig, getHeader, ethash.NewFaker(), nil, gp, ibs, writer, header, txn, usedGas, vm.Config{}, contractHasTEVM); err != nil {
+			return nil, fmt.Errorf("cannot execute pending transaction %x: %w", txn.Hash(), err)
+		}
+	}
+	return reader, nil
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/ledgerwatch/erigon/core/vm/stack"
 	"github.com/ledgerwatch/erigon/eth/tracers"
 	"github.com/ledgerwatch/erigon/params"
+	"github.com/ledgerwatch/erigon/turbo/rpchelper"
 )
 
 type BlockGetter interface {
@@ -34,6 +35,9 @@ type BlockGetter interface {
 // computeTxEnv returns the execution environment of a certain transaction.
 func ComputeTxEnv(ctx context.Context, block *types.Block, cfg *params.ChainConfig, getHeader func(hash common.Hash, number uint64) *types.Header, contractHasTEVM func(common.Hash) (bool, error), engine consensus.Engine, dbtx kv.Tx, blockHash common.Hash, txIndex uint64) (core.Message, vm.BlockContext, vm.TxContext, *state.IntraBlockState, *state.PlainState, error) {
 	// Create the parent state database
+	if err := rpchelper.CheckPruned(dbtx, rpchelper.PrunedHistory, block.NumberU64()-1); err != nil {
+		return nil, vm.BlockContext{}, vm.TxContext{}, nil, nil, err
+	}
 	reader := state.NewPlainState(dbtx, block.NumberU64()-1)
 	statedb := state.New(reader)
 
@@ -98,7 +102,7 @@ func TraceTx(
 	switch {
 	case config != nil && config.Tracer != nil:
 		// Define a meaningful timeout of a single transaction trace
-		timeout := callTimeout
+		timeout := DefaultCallTimeout
 		if config.Timeout != nil {
 			if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
 				stream.WriteNil()
@@ -129,6 +133,9 @@ func TraceTx(
 	}
 	// Run the transaction with tracing enabled.
 	vmenv := vm.NewEVM(blockCtx, txCtx, ibs, chainConfig, vm.Config{Debug: true, Tracer: tracer})
+	if !streaming {
+		tracer.(*tracers.Tracer).SetEVM(vmenv)
+	}
 
 	var refunds bool = true
 	if config != nil && config.NoRefunds != nil && *config.NoRefunds {
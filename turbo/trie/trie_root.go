@@ -122,6 +122,9 @@ type RootHashAggregator struct {
 	a              accounts.Account
 	leafData       GenStructStepLeafData
 	accData        GenStructStepAccountData
+	rd             RetainDecider // nil - only root hash is calculated, see NewProofAggregator
+	retainBuf      []byte
+	proofRoot      node
 }
 
 type StreamReceiver interface {
@@ -146,6 +149,15 @@ func NewRootHashAggregator() *RootHashAggregator {
 	}
 }
 
+// NewProofAggregator - RootHashAggregator which also keeps trie nodes on the paths to keys of `rd`, so proofs of
+// these keys can be made from ProofTrie. Keys of `rd` are full keys of HashedAccounts and HashedStorage
+func NewProofAggregator(rd RetainDecider) *RootHashAggregator {
+	return &RootHashAggregator{
+		hb: NewHashBuilder(false),
+		rd: rd,
+	}
+}
+
 func NewFlatDBTrieLoader(logPrefix string) *FlatDBTrieLoader {
 	return &FlatDBTrieLoader{
 		logPrefix:       logPrefix,
@@ -327,6 +339,33 @@ func (r *RootHashAggregator) RetainNothing(_ []byte) bool {
 	return false
 }
 
+func (r *RootHashAggregator) retainAccount(prefix []byte) bool {
+	if r.rd == nil {
+		return false
+	}
+	return r.rd.Retain(prefix)
+}
+
+// retainStorage - storage prefixes don't include account, but keys of r.rd do
+func (r *RootHashAggregator) retainStorage(prefix []byte) bool {
+	if r.rd == nil {
+		return false
+	}
+	hexutil.DecompressNibbles(r.currAccK, &r.retainBuf)
+	r.retainBuf = append(r.retainBuf, prefix...)
+	return r.rd.Retain(r.retainBuf)
+}
+
+// ProofTrie - trie kept by aggregator created by NewProofAggregator during the last CalcTrieRoot. Only nodes on the
+// paths to retained keys are resolved, so Prove works only for these keys
+func (r *RootHashAggregator) ProofTrie() *Trie {
+	t := New(r.root)
+	if r.proofRoot != nil {
+		t.root = r.proofRoot
+	}
+	return t
+}
+
 func (r *RootHashAggregator) Reset(hc HashCollector2, shc StorageHashCollector2, trace bool) {
 	r.hc = hc
 	r.shc = shc
@@ -344,6 +383,7 @@ func (r *RootHashAggregator) Reset(hc HashCollector2, shc StorageHashCollector2,
 	r.valueStorage = nil
 	r.wasIHStorage = false
 	r.root = common.Hash{}
+	r.proofRoot = nil
 	r.trace = trace
 	r.hb.trace = trace
 }
@@ -493,6 +533,7 @@ func (r *RootHashAggregator) Receive(itemType StreamItem,
 		}
 		if r.hb.hasRoot() {
 			r.root = r.hb.rootHash()
+			r.proofRoot = r.hb.root()
 		} else {
 			r.root = EmptyRoot
 		}
@@ -563,7 +604,7 @@ func (r *RootHashAggregator) genStructStorage() error {
 		r.leafData.Value = rlphacks.RlpSerializableBytes(r.valueStorage)
 		data = &r.leafData
 	}
-	r.groupsStorage, r.hasTreeStorage, r.hasHashStorage, err = GenStructStep(r.RetainNothing, r.currStorage.Bytes(), r.succStorage.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
+	r.groupsStorage, r.hasTreeStorage, r.hasHashStorage, err = GenStructStep(r.retainStorage, r.currStorage.Bytes(), r.succStorage.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
 		if r.shc == nil {
 			return nil
 		}
@@ -631,7 +672,7 @@ func (r *RootHashAggregator) genStructAccount() error {
 	r.currStorage.Reset()
 	r.succStorage.Reset()
 	var err error
-	if r.groups, r.hasTree, r.hasHash, err = GenStructStep(r.RetainNothing, r.curr.Bytes(), r.succ.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
+	if r.groups, r.hasTree, r.hasHash, err = GenStructStep(r.retainAccount, r.curr.Bytes(), r.succ.Bytes(), r.hb, func(keyHex []byte, hasState, hasTree, hasHash uint16, hashes, rootHash []byte) error {
 		if r.hc == nil {
 			return nil
 		}